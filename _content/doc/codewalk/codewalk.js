@@ -267,6 +267,7 @@ CodewalkViewer.prototype.changeSelectedComment = function(target) {
         'disabled', !target.prev().length);
     this.context.find('#next-comment').toggleClass(
         'disabled', !target.next().length);
+    this.sendStep(target.index());
   }
 
   // Force original file even if user hasn't changed comments since they may
@@ -274,6 +275,17 @@ CodewalkViewer.prototype.changeSelectedComment = function(target) {
   this.navigateToCode(currentFile);
 };
 
+/**
+ * Reports to the site's analytics collector that the given step was shown.
+ * @param {number} step The index of the step's comment.
+ */
+CodewalkViewer.prototype.sendStep = function(step) {
+  if (!navigator.sendBeacon || navigator.doNotTrack === '1' ||
+      navigator.globalPrivacyControl) return;
+  navigator.sendBeacon('/_beacon', JSON.stringify(
+      {kind: 'step', path: location.pathname, step: step}));
+};
+
 /**
  * Updates the viewer by changing the height of the comments and code so that
  * they fit within the height of the window.  The function is typically called
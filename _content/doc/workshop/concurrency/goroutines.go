@@ -0,0 +1,23 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+func say(s string) {
+	for range 3 {
+		fmt.Println(s)
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+func main() {
+	go say("world")
+	say("hello")
+	time.Sleep(100 * time.Millisecond)
+}
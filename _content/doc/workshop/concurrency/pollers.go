@@ -0,0 +1,40 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A Resource is owned by whichever goroutine last received it.
+type Resource struct {
+	url   string
+	polls int
+}
+
+func poller(in <-chan *Resource, out chan<- *Resource) {
+	for r := range in {
+		r.polls++ // no lock needed: only this goroutine has r
+		out <- r
+	}
+}
+
+func main() {
+	pending, complete := make(chan *Resource), make(chan *Resource)
+	for range 2 {
+		go poller(pending, complete)
+	}
+	urls := strings.Fields("https://go.dev/ https://go.dev/blog/ https://go.dev/doc/")
+	go func() {
+		for _, url := range urls {
+			pending <- &Resource{url: url}
+		}
+	}()
+	for range urls {
+		r := <-complete
+		fmt.Println(r.url, "polled", r.polls, "time")
+	}
+}
@@ -0,0 +1,39 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+/**
+ * Reloads the page when the development server reports that
+ * the content or templates it is rendered from changed.
+ * The script's data-path attribute is the path of the server's channel.
+ */
+(() => {
+  'use strict';
+
+  const path = document.currentScript.dataset.path;
+  let lost = false;
+
+  function connect() {
+    const scheme = location.protocol === 'https:' ? 'wss://' : 'ws://';
+    const ws = new WebSocket(scheme + location.host + path);
+    ws.addEventListener('open', () => {
+      // After losing the server, as when it restarts,
+      // its templates may have changed too.
+      if (lost) {
+        location.reload();
+      }
+    });
+    ws.addEventListener('message', e => {
+      const event = JSON.parse(e.data);
+      if (event.kind !== 'ping') {
+        location.reload();
+      }
+    });
+    ws.addEventListener('close', () => {
+      lost = true;
+      setTimeout(connect, 1000);
+    });
+  }
+
+  connect();
+})();
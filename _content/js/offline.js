@@ -0,0 +1,22 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+/**
+ * Registers the site's service worker, which keeps the pages
+ * the reader visits readable offline.
+ * The script's data-path attribute is the path of the worker's script.
+ */
+(() => {
+  'use strict';
+
+  if (!('serviceWorker' in navigator)) {
+    return;
+  }
+  const path = document.currentScript.dataset.path;
+  window.addEventListener('load', () => {
+    navigator.serviceWorker.register(path).catch(err => {
+      console.warn('registering service worker:', err);
+    });
+  });
+})();
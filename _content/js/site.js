@@ -313,6 +313,38 @@ window.initFuncs = [];
     });
   }
 
+  /**
+   * registerAnnouncements hides an announcement when its dismiss button is
+   * clicked, remembering the dismissal in a cookie the server honors.
+   */
+  function registerAnnouncements() {
+    document.querySelectorAll('.js-announcementDismiss').forEach(button => {
+      button.addEventListener('click', () => {
+        const announcement = button.closest('.js-announcement');
+        const match = document.cookie.match(/(?:^|; )dismissed-announcements=([^;]*)/);
+        const ids = match ? match[1].split('.') : [];
+        ids.push(announcement.dataset.id);
+        let domain = '';
+        if (location.hostname === 'go.dev') {
+          // Apply the cookie to *.go.dev.
+          domain = 'domain=.go.dev;';
+        }
+        // Keep the most recent dismissals within a reasonable cookie size.
+        document.cookie = `dismissed-announcements=${ids.slice(-20).join('.')};${domain}path=/;max-age=31536000`;
+        announcement.remove();
+      });
+    });
+  }
+
+  /**
+   * sendPageView reports the page view to the site's analytics collector,
+   * which keeps no cookies or addresses. Only the path is sent, not the query.
+   */
+  function sendPageView() {
+    if (!navigator.sendBeacon || navigator.doNotTrack === '1' || navigator.globalPrivacyControl) return;
+    navigator.sendBeacon('/_beacon', JSON.stringify({kind: 'view', path: location.pathname}));
+  }
+
   /**
    * toggleTheme switches the preferred color scheme between auto, light, and dark.
    */
@@ -360,6 +392,8 @@ window.initFuncs = [];
     setVersionSpans();
     registerPortToggles();
     registerCookieNotice();
+    registerAnnouncements();
+    sendPageView();
   };
 
   // DOM might be already loaded when we try to setup the callback, hence the check.
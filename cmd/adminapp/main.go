@@ -17,6 +17,7 @@ import (
 	"time"
 
 	"cloud.google.com/go/datastore"
+	"github.com/matttproud/yourtour/internal/env"
 	"github.com/matttproud/yourtour/internal/memcache"
 	"github.com/matttproud/yourtour/internal/short"
 	"google.golang.org/api/idtoken"
@@ -97,7 +98,7 @@ func (h *snippetHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte("snippet deleted\n"))
 }
 
-func getClients() (*datastore.Client, *memcache.Client) {
+func getClients() (*datastore.Client, memcache.Cache) {
 	ctx := context.Background()
 
 	datastoreClient, err := datastore.NewClient(ctx, "")
@@ -108,11 +109,14 @@ func getClients() (*datastore.Client, *memcache.Client) {
 		log.Fatalf("datastore.NewClient: %v.", err)
 	}
 
-	redisAddr := os.Getenv("GOLANGORG_REDIS_ADDR")
-	if redisAddr == "" {
+	cfg := env.Get()
+	if (cfg.CacheBackend == "" || cfg.CacheBackend == "redis") && cfg.RedisAddr == "" {
 		log.Fatalf("Missing redis server for golangorg in production mode. set GOLANGORG_REDIS_ADDR environment variable.")
 	}
-	memcacheClient := memcache.New(redisAddr)
+	memcacheClient, err := memcache.Open(cfg.CacheBackend, cfg.RedisAddr)
+	if err != nil {
+		log.Fatalf("memcache.Open: %v", err)
+	}
 
 	return datastoreClient, memcacheClient
 }
@@ -0,0 +1,110 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Changelog writes the revision history of the site content,
+// read from the Git log, to the manifest served as /changes.json
+// (see package internal/changelog).
+//
+// Usage:
+//
+//	changelog [-n max] [-o _content/changelog.json]
+//
+// It must be run in the root of the website repo, with its Git history,
+// before the site is built, so that the manifest is embedded in the binary.
+// Cloud Build runs it before deploying.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/matttproud/yourtour/internal/changelog"
+)
+
+// contentDir is the directory of the content in the repo.
+const contentDir = "_content"
+
+var (
+	limit = flag.Int("n", 1000, "record at most `n` revisions")
+	out   = flag.String("o", contentDir+"/"+changelog.File, "write manifest to `file`")
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: changelog [-n max] [-o file]\n")
+	os.Exit(2)
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+	log.SetPrefix("changelog: ")
+	log.SetFlags(0)
+
+	if flag.NArg() != 0 || *limit <= 0 {
+		usage()
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("git", "log", fmt.Sprintf("-n%d", *limit),
+		"--format=%x00%H %cI %s", "--name-only", "--", contentDir)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		log.Fatalf("git log: %v\n%s", err, stderr.Bytes())
+	}
+	revs, err := parseLog(&stdout)
+	if err != nil {
+		log.Fatal(err)
+	}
+	data, err := json.MarshalIndent(revs, "", "\t")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*out, append(data, '\n'), 0666); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// parseLog parses the output of git log with the format
+// "%x00%H %cI %s" and --name-only, omitting the files
+// outside the content directory and the changelog itself.
+func parseLog(r io.Reader) ([]*changelog.Revision, error) {
+	revs := []*changelog.Revision{}
+	var rev *changelog.Revision
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := s.Text()
+		if line, ok := strings.CutPrefix(line, "\x00"); ok {
+			f := strings.SplitN(line, " ", 3)
+			if len(f) < 2 {
+				return nil, fmt.Errorf("malformed commit line %q", line)
+			}
+			t, err := time.Parse(time.RFC3339, f[1])
+			if err != nil {
+				return nil, fmt.Errorf("commit %s: %v", f[0], err)
+			}
+			rev = &changelog.Revision{Commit: f[0], Time: t.UTC()}
+			if len(f) == 3 {
+				rev.Subject = f[2]
+			}
+			revs = append(revs, rev)
+			continue
+		}
+		file, ok := strings.CutPrefix(line, contentDir+"/")
+		if !ok || rev == nil || file == changelog.File {
+			continue
+		}
+		rev.Files = append(rev.Files, file)
+	}
+	return revs, s.Err()
+}
@@ -0,0 +1,42 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matttproud/yourtour/internal/changelog"
+)
+
+const gitLog = "\x00b2 2026-06-02T22:43:09+02:00 _content/doc: fix typo\n" +
+	"\n" +
+	"_content/doc/faq.md\n" +
+	"_content/changelog.json\n" +
+	"cmd/golangorg/server.go\n" +
+	"\x00a1 2026-05-01T10:00:00Z \n" +
+	"\n" +
+	"_content/blog/go1.md\n" +
+	"_content/images/gopher.png\n"
+
+func TestParseLog(t *testing.T) {
+	revs, err := parseLog(strings.NewReader(gitLog))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*changelog.Revision{
+		{Commit: "b2", Time: time.Date(2026, 6, 2, 20, 43, 9, 0, time.UTC), Subject: "_content/doc: fix typo", Files: []string{"doc/faq.md"}},
+		{Commit: "a1", Time: time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC), Files: []string{"blog/go1.md", "images/gopher.png"}},
+	}
+	if !reflect.DeepEqual(revs, want) {
+		t.Errorf("parseLog:\nhave %+v\nwant %+v", revs, want)
+	}
+
+	if _, err := parseLog(strings.NewReader("\x00a1 yesterday\n")); err == nil {
+		t.Errorf("parseLog with bad time succeeded")
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Codewalkbundle writes the bundle of the site's codewalks,
+// with their source addresses resolved, which the server reads
+// instead of the codewalk descriptions (see package internal/codewalk).
+//
+// Usage:
+//
+//	codewalkbundle [-o _content/doc/codewalk/bundle.json]
+//
+// It must be run in the root of the website repo before the site is built,
+// so that the bundle is embedded in the binary.
+// Cloud Build runs it before deploying.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/matttproud/yourtour/internal/codewalk"
+)
+
+// contentDir is the directory of the content in the repo.
+const contentDir = "_content"
+
+var out = flag.String("o", contentDir+"/"+codewalk.BundleFile, "write bundle to `file`")
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: codewalkbundle [-o file]\n")
+	os.Exit(2)
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+	log.SetPrefix("codewalkbundle: ")
+	log.SetFlags(0)
+
+	if flag.NArg() != 0 {
+		usage()
+	}
+
+	fsys := os.DirFS(contentDir)
+	if err := codewalk.Validate(fsys); err != nil {
+		log.Fatal(err)
+	}
+	data, err := codewalk.BuildBundle(fsys)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*out, append(data, '\n'), 0666); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/matttproud/yourtour/internal/env"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// autocertSetup configures srv to serve HTTPS for cfg.TLSHosts
+// with certificates obtained from Let's Encrypt.
+// It returns the handler for the plain HTTP server,
+// which answers ACME challenges and redirects everything else to HTTPS.
+func autocertSetup(cfg *env.Config, srv *http.Server) http.Handler {
+	var hosts []string
+	for _, h := range strings.Split(cfg.TLSHosts, ",") {
+		if h = strings.TrimSpace(h); h != "" {
+			hosts = append(hosts, h)
+		}
+	}
+	m := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(hosts...),
+		Cache:      autocert.DirCache(cfg.TLSCacheDir),
+		Email:      cfg.ACMEEmail,
+	}
+	srv.Addr = cfg.TLSAddr
+	if srv.Addr == "" {
+		srv.Addr = ":443"
+	}
+	srv.TLSConfig = m.TLSConfig()
+	return m.HTTPHandler(http.HandlerFunc(httpsRedirect))
+}
+
+// httpsRedirect redirects a plain HTTP request to the same URL over HTTPS.
+func httpsRedirect(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" && r.Method != "HEAD" {
+		http.Error(w, "use HTTPS", http.StatusBadRequest)
+		return
+	}
+	host, _, ok := strings.Cut(r.Host, ":")
+	if !ok {
+		host = r.Host
+	}
+	http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+}
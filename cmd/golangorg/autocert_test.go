@@ -0,0 +1,38 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/matttproud/yourtour/internal/env"
+)
+
+func TestAutocertRedirect(t *testing.T) {
+	srv := new(http.Server)
+	h := autocertSetup(&env.Config{TLSHosts: "go.example, tip.go.example", TLSCacheDir: t.TempDir()}, srv)
+	if srv.Addr != ":443" || srv.TLSConfig == nil {
+		t.Errorf("autocertSetup left server with Addr %q, TLSConfig %v", srv.Addr, srv.TLSConfig)
+	}
+
+	var tests = []struct {
+		method, url string
+		code        int
+		location    string
+	}{
+		{"GET", "http://go.example/doc/?x=1", http.StatusMovedPermanently, "https://go.example/doc/?x=1"},
+		{"HEAD", "http://go.example:8080/", http.StatusMovedPermanently, "https://go.example/"},
+		{"POST", "http://go.example/dl/upload", http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(tt.method, tt.url, nil))
+		if w.Code != tt.code || w.Header().Get("Location") != tt.location {
+			t.Errorf("%s %s: %d %q, want %d %q", tt.method, tt.url, w.Code, w.Header().Get("Location"), tt.code, tt.location)
+		}
+	}
+}
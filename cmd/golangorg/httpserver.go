@@ -0,0 +1,55 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/matttproud/yourtour/internal/env"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+// Defaults for the server settings in env.Config.
+const (
+	defaultReadHeaderTimeout = 10 * time.Second
+	defaultReadTimeout       = 2 * time.Minute // content zips can be large
+	defaultWriteTimeout      = 5 * time.Minute // outlasts the 30s handler timeouts; streams and profiles extend it
+	defaultIdleTimeout       = 11 * time.Minute
+	defaultMaxHeaderBytes    = 64 << 10
+)
+
+// newServer returns the HTTP server for h at addr,
+// with the timeouts and header limit cfg sets.
+//
+// The server speaks HTTP/2 over TLS, if it is given a TLSConfig,
+// and HTTP/2 without TLS (h2c) if cfg.H2C is set.
+func newServer(cfg *env.Config, addr string, h http.Handler) *http.Server {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           h,
+		ReadHeaderTimeout: orDefault(cfg.ServerReadHeaderTimeout, defaultReadHeaderTimeout),
+		ReadTimeout:       orDefault(cfg.ServerReadTimeout, defaultReadTimeout),
+		WriteTimeout:      orDefault(cfg.ServerWriteTimeout, defaultWriteTimeout),
+		IdleTimeout:       orDefault(cfg.ServerIdleTimeout, defaultIdleTimeout),
+		MaxHeaderBytes:    orDefault(cfg.ServerMaxHeaderBytes, defaultMaxHeaderBytes),
+	}
+	if cfg.H2C {
+		// The HTTP/2 server takes its idle timeout and header limit
+		// from srv, which h2c finds in each request's context.
+		srv.Handler = h2c.NewHandler(h, new(http2.Server))
+	}
+	return srv
+}
+
+// orDefault returns v, or def if v is zero.
+func orDefault[T comparable](v, def T) T {
+	var zero T
+	if v == zero {
+		return def
+	}
+	return v
+}
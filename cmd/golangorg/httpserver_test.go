@@ -0,0 +1,79 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/matttproud/yourtour/internal/env"
+	"golang.org/x/net/http2"
+)
+
+func TestNewServer(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	srv := newServer(&env.Config{ServerWriteTimeout: time.Minute}, ":8080", ok)
+	if srv.Addr != ":8080" {
+		t.Errorf("Addr = %q, want :8080", srv.Addr)
+	}
+	if srv.ReadHeaderTimeout != defaultReadHeaderTimeout || srv.ReadTimeout != defaultReadTimeout ||
+		srv.WriteTimeout != time.Minute || srv.IdleTimeout != defaultIdleTimeout {
+		t.Errorf("timeouts = %v, %v, %v, %v, want %v, %v, 1m0s, %v",
+			srv.ReadHeaderTimeout, srv.ReadTimeout, srv.WriteTimeout, srv.IdleTimeout,
+			defaultReadHeaderTimeout, defaultReadTimeout, defaultIdleTimeout)
+	}
+	if srv.MaxHeaderBytes != defaultMaxHeaderBytes {
+		t.Errorf("MaxHeaderBytes = %d, want %d", srv.MaxHeaderBytes, defaultMaxHeaderBytes)
+	}
+	if srv.TLSConfig != nil {
+		t.Errorf("TLSConfig set without TLS")
+	}
+}
+
+func TestNewServerH2C(t *testing.T) {
+	proto := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.Proto)
+	})
+	ts := httptest.NewUnstartedServer(nil)
+	ts.Config = newServer(&env.Config{H2C: true}, "", proto)
+	ts.Start()
+	defer ts.Close()
+
+	// A plain HTTP/1.1 client is still served.
+	if got := get(t, http.DefaultClient, ts.URL); got != "HTTP/1.1" {
+		t.Errorf("HTTP/1.1 client served with %s", got)
+	}
+
+	// An HTTP/2 client with prior knowledge is served with HTTP/2.
+	h2 := &http.Client{Transport: &http2.Transport{
+		AllowHTTP: true,
+		DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+			return new(net.Dialer).DialContext(ctx, network, addr)
+		},
+	}}
+	if got := get(t, h2, ts.URL); got != "HTTP/2.0" {
+		t.Errorf("h2c client served with %s", got)
+	}
+}
+
+func get(t *testing.T, c *http.Client, url string) string {
+	t.Helper()
+	resp, err := c.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
@@ -0,0 +1,196 @@
+// Copyright 2009 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Golangorg serves the golang.org web sites.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/matttproud/yourtour/golangorg"
+	"github.com/matttproud/yourtour/internal/env"
+	"github.com/matttproud/yourtour/internal/errreport"
+	"github.com/matttproud/yourtour/internal/graceful"
+)
+
+var (
+	httpAddr   = flag.String("http", "localhost:6060", "HTTP service address")
+	verbose    = flag.Bool("v", false, "verbose mode")
+	goroot     = flag.String("goroot", runtime.GOROOT(), "Go root directory")
+	contentDir = flag.String("content", "", "path to _content directory")
+	configFile = flag.String("config", os.Getenv(env.ConfigFileEnv), "path to YAML configuration file")
+
+	runningOnAppEngine = os.Getenv("PORT") != ""
+
+	tipFlag  = flag.Bool("tip", runningOnAppEngine, "load git content for tip.golang.org")
+	wikiFlag = flag.Bool("wiki", runningOnAppEngine, "load git content for go.dev/wiki")
+)
+
+// background is the context for the program's background work,
+// such as reloading the configuration.
+// It is canceled by stopBackground when the server shuts down.
+var background, stopBackground = context.WithCancel(context.Background())
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: golangorg\n")
+	flag.PrintDefaults()
+	os.Exit(2)
+}
+
+func main() {
+	if runningOnAppEngine {
+		log.Print("golang.org server starting")
+		*goroot = "_goroot.zip"
+		log.SetFlags(log.Lshortfile | log.LstdFlags)
+		port := "8080"
+		if p := os.Getenv("PORT"); p != "" {
+			port = p
+		}
+		*httpAddr = ":" + port
+	}
+
+	flag.Usage = usage
+	flag.Parse()
+
+	// Check usage.
+	if flag.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, "Unexpected arguments.")
+		usage()
+	}
+	if *httpAddr == "" {
+		fmt.Fprintln(os.Stderr, "-http must be set")
+		usage()
+	}
+	cfg, err := env.Load(*configFile)
+	if err != nil {
+		log.Fatalf("invalid configuration: %v", err)
+	}
+	env.Set(cfg)
+	go env.WatchReload(background, *configFile, env.ReloadInterval)
+	errorReportSetup(cfg)
+
+	// With hot reload, find the local _content directory when it's available nearby,
+	// so that updates to those files appear on the local dev instance without restarting.
+	// Otherwise (as on App Engine), leave contentDir empty, so we use the embedded copy,
+	// which is much faster to access than the simulated file system.
+	if *contentDir == "" && cfg.HotReload {
+		if fi, err := os.Stat(filepath.Join("..", "..", "_content")); err == nil && fi.IsDir() {
+			*contentDir = filepath.Join("..", "..", "_content")
+		} else if fi, err := os.Stat("_content"); err == nil && fi.IsDir() {
+			*contentDir = "_content"
+		} else {
+			*contentDir = "" // Fall back to using embedded content.
+		}
+	}
+
+	opts := []golangorg.Option{
+		golangorg.WithGoroot(*goroot),
+		golangorg.WithGit(*tipFlag, *wikiFlag),
+	}
+	if *contentDir != "" {
+		opts = append(opts, golangorg.WithContentDir(*contentDir))
+	}
+	if runningOnAppEngine {
+		opts = append(opts, golangorg.WithAppEngine())
+	}
+	s := golangorg.NewServer(opts...)
+	report := s.Preflight(context.Background())
+	log.Printf("preflight checks: %v", report)
+
+	var handler http.Handler = s
+	if *verbose {
+		log.Printf("golang.org server:")
+		log.Printf("\tversion = %s", runtime.Version())
+		log.Printf("\taddress = %s", *httpAddr)
+		log.Printf("\tgoroot = %s", *goroot)
+		log.Printf("\tcontent = %s", contentSource())
+		handler = loggingHandler(handler)
+	}
+
+	// Start http server, shutting down gracefully on SIGTERM.
+	runner := &graceful.Runner{Server: newServer(cfg, *httpAddr, handler)}
+	runner.OnShutdown(func() {
+		// Wait for jobs in progress to stop, within a limit.
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := s.Shutdown(ctx); err != nil {
+			log.Printf("ERROR stopping background jobs: %v", err)
+		}
+	})
+	runner.OnShutdown(stopBackground)
+	if cfg.TLSHosts != "" {
+		// Serve HTTPS directly, with -http answering ACME challenges
+		// and redirecting to HTTPS.
+		redirector := &graceful.Runner{Server: newServer(cfg, *httpAddr, autocertSetup(cfg, runner.Server))}
+		go func() {
+			if err := redirector.ListenAndServe(background); err != nil {
+				log.Fatalf("ListenAndServe %s: %v", *httpAddr, err)
+			}
+		}()
+		fmt.Fprintf(os.Stderr, "serving https://%s for %s\n", runner.Server.Addr, cfg.TLSHosts)
+	} else {
+		fmt.Fprintf(os.Stderr, "serving http://%s\n", *httpAddr)
+	}
+	if err := runner.ListenAndServe(context.Background()); err != nil {
+		log.Fatalf("ListenAndServe %s: %v", runner.Server.Addr, err)
+	}
+}
+
+// contentSource returns a human-readable description
+// of where the x/website _content dir is coming from.
+func contentSource() string {
+	src := "embedded content"
+	if *contentDir != "" {
+		src = absPath(*contentDir)
+	}
+	if dir := env.Get().ContentOverlay; dir != "" {
+		src += " overlaid by " + absPath(dir)
+	}
+	return src
+}
+
+// absPath returns the absolute form of file, if known, or else file.
+func absPath(file string) string {
+	if abs, err := filepath.Abs(file); err == nil {
+		return abs
+	}
+	return file
+}
+
+// errorReportSetup sends server errors to cfg.ErrorReportURL, if set.
+func errorReportSetup(cfg *env.Config) {
+	if cfg.ErrorReportURL == "" {
+		return
+	}
+	rate, err := errreport.ParseRate(cfg.ErrorSampleRate)
+	if err != nil {
+		log.Fatalf("error reporting: %v", err)
+	}
+	r := errreport.NewHTTP(cfg.ErrorReportURL)
+	errreport.Set(r, rate)
+	// Send the reports still queued at shutdown.
+	go func() {
+		<-background.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := r.Close(ctx); err != nil {
+			log.Printf("ERROR sending queued error reports: %v", err)
+		}
+	}()
+}
+
+func loggingHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log.Printf("%s\t%s", r.RemoteAddr, r.URL)
+		h.ServeHTTP(w, r)
+	})
+}
@@ -6,4 +6,4 @@
 # This script compares web pages generated by a golangorg web server running locally
 # with the pages on the production go.dev server.
 
-go run ./cmd/screentest http://localhost:6060/go.dev https://go.dev ./cmd/golangorg/testdata/screentest/*
+go run ./cmd/screentest http://localhost:6060/go.dev https://go.dev ./golangorg/testdata/screentest/*
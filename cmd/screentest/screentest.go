@@ -2,7 +2,7 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
-// TODO(jba): remove ints function in template (see cmd/golangorg/testdata/screentest/relnotes.txt)
+// TODO(jba): remove ints function in template (see golangorg/testdata/screentest/relnotes.txt)
 
 // TODO(jba): Provide a way to capture the results of an eval directive.
 // If the second argument to chromedp.Evaluate is a *[]byte, the result will be written
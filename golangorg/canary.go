@@ -0,0 +1,81 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golangorg
+
+import (
+	"log"
+	"net/http"
+	"sync"
+
+	"github.com/matttproud/yourtour/internal/canary"
+	"github.com/matttproud/yourtour/internal/codewalk"
+	"github.com/matttproud/yourtour/internal/env"
+	"github.com/matttproud/yourtour/internal/rates"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// A canaryRoute is a route whose handler has another version, alt(h),
+// to serve some clients with instead of the handler in service, h:
+// usually a new version to roll out, or else an old one
+// to measure the handler in service against.
+type canaryRoute struct {
+	name  string
+	match func(*http.Request) bool
+	alt   func(h http.Handler) http.Handler
+}
+
+// canaryRoutes lists the routes with other versions to serve,
+// which the canaries setting enables by name.
+// Add a route here once its rewrite replies as the old handler does
+// (see shadowedRoutes), and remove it once the rollout is complete
+// and the rewrite has replaced the old handler.
+var canaryRoutes = []canaryRoute{
+	{
+		// Codewalk pages are served from the codewalk bundle.
+		// Serving some clients pages rendered from the descriptions
+		// compares the latencies of the two in the canary metrics.
+		name:  "codewalk",
+		match: isCodewalkRequest,
+		alt:   codewalk.Unbundled,
+	},
+}
+
+// canaryMetrics returns the metrics of the canaryRoutes,
+// registered once for the whole process.
+var canaryMetrics = sync.OnceValue(func() *canary.PrometheusMetrics {
+	m, err := canary.NewPrometheusMetrics(prometheus.DefaultRegisterer)
+	if err != nil {
+		log.Fatalf("canary.NewPrometheusMetrics: %v", err)
+	}
+	return m
+})
+
+// canaryHandler wraps h, serving requests for canaryRoutes from
+// the fractions of clients set by cfg.Canaries with their other versions.
+func canaryHandler(cfg *env.Config, h http.Handler) (http.Handler, error) {
+	rs, err := rates.Parse(cfg.Canaries)
+	if err != nil {
+		return nil, err
+	}
+	return routeCanaries(canaryRoutes, rs, h)
+}
+
+// routeCanaries wraps h, serving requests for routes
+// from the fractions of clients set by rs with their other versions.
+func routeCanaries(routes []canaryRoute, rs rates.Rates, h http.Handler) (http.Handler, error) {
+	next := h
+	for _, route := range routes {
+		rate, ok := rs[route.name]
+		if !ok {
+			continue
+		}
+		delete(rs, route.name)
+		h = matchHandler(route.match, canary.New(route.name, next, route.alt(next), rate, canaryMetrics()), h)
+	}
+	if err := unknownRoutes("canaries", rs); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
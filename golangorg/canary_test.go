@@ -0,0 +1,45 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golangorg
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/matttproud/yourtour/internal/env"
+	"github.com/matttproud/yourtour/internal/rates"
+)
+
+func TestCanaryHandler(t *testing.T) {
+	if _, err := canaryHandler(&env.Config{Canaries: "nosuchroute=5%"}, http.NotFoundHandler()); err == nil {
+		t.Errorf("canaryHandler with canary for unknown route succeeded")
+	}
+	if _, err := canaryHandler(&env.Config{Canaries: "codewalk=5%"}, http.NotFoundHandler()); err != nil {
+		t.Errorf("canaryHandler with canary for codewalk: %v", err)
+	}
+
+	reply := func(s string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { fmt.Fprint(w, s) })
+	}
+	routes := []canaryRoute{{
+		name:  "codewalk",
+		match: func(r *http.Request) bool { return strings.HasPrefix(r.URL.Path, "/doc/codewalk/") },
+		alt:   func(http.Handler) http.Handler { return reply("new") },
+	}}
+	h, err := routeCanaries(routes, rates.Rates{"codewalk": 1}, reply("old"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range map[string]string{"/doc/codewalk/sharemem/": "new", "/doc/": "old"} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if w.Body.String() != want {
+			t.Errorf("GET %s = %q, want %q", path, w.Body, want)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golangorg
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"cloud.google.com/go/datastore"
+	"github.com/matttproud/yourtour/internal/chaos"
+	"github.com/matttproud/yourtour/internal/dl"
+	"github.com/matttproud/yourtour/internal/env"
+	"github.com/matttproud/yourtour/internal/memcache"
+)
+
+// chaosDeps are the dependencies into which cfg.Chaos can inject faults.
+var chaosDeps = []string{"datastore", "memcache"}
+
+// chaosSetup loads the faults injected for resilience testing
+// from cfg.Chaos into s.chaosRules.
+func (s *Server) chaosSetup(cfg *env.Config) error {
+	rules, err := chaos.ParseRules(cfg.Chaos)
+	if err != nil {
+		return err
+	}
+	for target := range rules {
+		if !strings.HasPrefix(target, "/") && !slices.Contains(chaosDeps, target) {
+			return fmt.Errorf("faults for unknown dependency %s; want a path prefix or one of %s", target, strings.Join(chaosDeps, ", "))
+		}
+	}
+	if len(rules) > 0 {
+		log.Printf("WARNING injecting faults for resilience testing: %v", rules)
+	}
+	s.chaosRules = rules
+	return nil
+}
+
+// chaosCache returns c with the faults for memcache, if any.
+func (s *Server) chaosCache(c memcache.Cache) memcache.Cache {
+	if f, ok := s.chaosRules["memcache"]; ok {
+		return chaos.NewCache(c, f)
+	}
+	return c
+}
+
+// chaosDatastore returns dc with the faults for datastore, if any.
+func (s *Server) chaosDatastore(dc dl.Datastore) dl.Datastore {
+	if f, ok := s.chaosRules["datastore"]; ok {
+		return &faultyDatastore{dc, f}
+	}
+	return dc
+}
+
+// A faultyDatastore is a dl.Datastore that injects a fault into every call.
+type faultyDatastore struct {
+	ds    dl.Datastore
+	fault chaos.Fault
+}
+
+func (d *faultyDatastore) GetAll(ctx context.Context, q *datastore.Query, dst any) ([]*datastore.Key, error) {
+	if err := d.fault.Inject(ctx); err != nil {
+		return nil, err
+	}
+	return d.ds.GetAll(ctx, q, dst)
+}
+
+func (d *faultyDatastore) Put(ctx context.Context, key *datastore.Key, src any) (*datastore.Key, error) {
+	if err := d.fault.Inject(ctx); err != nil {
+		return nil, err
+	}
+	return d.ds.Put(ctx, key, src)
+}
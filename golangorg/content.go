@@ -0,0 +1,358 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golangorg
+
+import (
+	"archive/zip"
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/matttproud/yourtour/internal/clientip"
+	"github.com/matttproud/yourtour/internal/etag"
+	"github.com/matttproud/yourtour/internal/gitfs"
+	"github.com/matttproud/yourtour/internal/validate"
+	"github.com/matttproud/yourtour/internal/web"
+	"github.com/matttproud/yourtour/internal/webhooks"
+)
+
+// maxContentZip is the largest content zip file accepted by /_content.
+const maxContentZip = 512 << 20
+
+// A contentDeployer replaces the site content of a running server,
+// so that content-only changes can be deployed without a new binary.
+type contentDeployer struct {
+	server  *Server   // server of the sites, which validates content
+	content *atomicFS // content served by the sites
+	goroot  fs.FS
+	sites   []*web.Site   // sites serving content
+	version *etag.Version // version of content, if non-nil; see etag.Version
+
+	mu sync.Mutex // serializes Deploy
+}
+
+// Deploy checks that fsys holds valid site content
+// and, if so, starts serving it in place of the current content.
+// Requests in progress finish with the content they started with.
+// The version function reports the version of fsys, or the empty string
+// if it is unknown, for tagging the pages rendered from it.
+func (d *contentDeployer) Deploy(fsys fs.FS, version func() string) error {
+	if err := d.server.checkContent(fsys, d.goroot).Err(); err != nil {
+		return err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.content.Set(fsys)
+	if d.version != nil {
+		d.version.SetFunc("content", version)
+	}
+	for _, site := range d.sites {
+		site.ClearCache()
+	}
+	return nil
+}
+
+// checkContent validates the site content in fsys,
+// served with the GOROOT goroot, as the sites would serve it.
+func (s *Server) checkContent(fsys fs.FS, goroot fs.FS) *validate.Report {
+	site, _ := s.newWebSite("", fsys, goroot)
+	return validate.Validate(fsys, site)
+}
+
+// ServeHTTP serves POST /_content, deploying the content in the request.
+// The request body is either a zip file of the content,
+// with Content-Type application/zip, or a form
+// whose dir value names a directory holding the content
+// on the server's file system. As with the -content flag,
+// the directory is served live: later changes to it are not checked.
+func (d *contentDeployer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	fsys, src, version, err := requestContent(w, r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := d.Deploy(fsys, version); err != nil {
+		log.Printf("ERROR deploying content from %s for %s: %v", src, clientip.FromRequest(r), err)
+		http.Error(w, "invalid content:\n"+err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	log.Printf("deployed content from %s for %s", src, clientip.FromRequest(r))
+	w.Header().Set("Cache-Control", "no-store")
+	fmt.Fprintf(w, "deployed content from %s\n", src)
+}
+
+// validateHandler returns a handler serving /_validate, which reports
+// as JSON whether site content is valid, check by check, without deploying it:
+// the content being served, for GET, or the content in a POST request,
+// sent as it is to /_content.
+func (d *contentDeployer) validateHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var fsys fs.FS = d.content
+		switch r.Method {
+		case "GET", "HEAD":
+		case "POST":
+			var err error
+			if fsys, _, _, err = requestContent(w, r); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		report := d.server.checkContent(fsys, d.goroot)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", " ")
+		if err := enc.Encode(struct {
+			OK bool `json:"ok"`
+			*validate.Report
+		}{report.OK(), report}); err != nil {
+			log.Printf("ERROR writing validation report: %v", err)
+		}
+	})
+}
+
+// requestContent returns the content sent in r, as for ServeHTTP,
+// a description of where it came from, and a function reporting its version:
+// the checksum of a zip file, or the fingerprint of a directory.
+func requestContent(w http.ResponseWriter, r *http.Request) (fsys fs.FS, src string, version func() string, err error) {
+	if mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mt == "application/zip" {
+		data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxContentZip))
+		if err != nil {
+			return nil, "", nil, fmt.Errorf("reading zip file: %v", err)
+		}
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, "", nil, fmt.Errorf("reading zip file: %v", err)
+		}
+		sum := fmt.Sprintf("zip:%x", sha256.Sum256(data))
+		return &seekableFS{contentRoot(zr)}, fmt.Sprintf("zip file (%d bytes)", len(data)), func() string { return sum }, nil
+	}
+
+	dir := r.FormValue("dir")
+	if dir == "" {
+		return nil, "", nil, errors.New("missing content: want application/zip body or dir form value")
+	}
+	if fi, err := os.Stat(dir); err != nil {
+		return nil, "", nil, err
+	} else if !fi.IsDir() {
+		return nil, "", nil, fmt.Errorf("%s is not a directory", dir)
+	}
+	return os.DirFS(dir), dir, etag.DirVersion(dir), nil
+}
+
+// contentRoot returns the site content in fsys: fsys itself, if it holds
+// a site.tmpl, or else its _content directory, if that does.
+// This allows deploying the _content directory itself
+// as well as a copy of the whole website repository.
+func contentRoot(fsys fs.FS) fs.FS {
+	if _, err := fs.Stat(fsys, "site.tmpl"); err != nil {
+		if _, err := fs.Stat(fsys, "_content/site.tmpl"); err == nil {
+			sub, _ := fs.Sub(fsys, "_content")
+			return sub
+		}
+	}
+	return fsys
+}
+
+// contentWebhookSecretName is the name of the secret
+// with which the Git forge signs content webhook requests.
+const contentWebhookSecretName = "content-webhook-secret"
+
+// maxWebhookBody is the largest webhook request body accepted.
+const maxWebhookBody = 25 << 20
+
+// A contentWebhook deploys the site content in the commits
+// pushed to a branch of a Git repository, as the repository's
+// Git forge reports them in push event webhook requests.
+type contentWebhook struct {
+	deployer *contentDeployer
+	branch   string                                // ref of the deployed branch, such as refs/heads/master
+	secret   func(context.Context) (string, error) // key signing requests
+	fetch    func(commit string) (fs.FS, error)    // returns the tree of a commit
+	queue    *webhooks.Queue                       // queue of pushes until deployed, if any
+
+	mu      sync.Mutex
+	running bool   // a deploy is in progress
+	next    string // commit to deploy when the running deploy finishes, if any
+	wg      sync.WaitGroup
+}
+
+// gitFetch returns a function fetching the trees of commits
+// in the Git repository at url, for a contentWebhook.
+func gitFetch(url string) func(commit string) (fs.FS, error) {
+	return func(commit string) (fs.FS, error) {
+		repo, err := gitfs.NewRepo(url)
+		if err != nil {
+			return nil, err
+		}
+		_, fsys, err := repo.Clone(commit)
+		return fsys, err
+	}
+}
+
+// ServeHTTP serves POST /_content/webhook, the destination of the
+// content repository's webhook. Requests must carry an X-Hub-Signature-256
+// header with the HMAC-SHA256 of their body under the webhook secret,
+// as GitHub, Gitea, and Forgejo send. A push to the webhook's branch
+// starts a deploy of the pushed commit's content and returns status 202;
+// the deploy's result is logged. A push arriving while a deploy
+// is in progress is deployed after it, superseding earlier ones.
+// Other events are ignored. Without a webhook secret, or with an empty one,
+// every request fails with status 500.
+//
+// The deploy replaces the content of this server only: with several
+// instances behind a load balancer, only the instance receiving the
+// request serves the pushed content, so the webhook is for servers
+// run as a single instance.
+func (h *contentWebhook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBody))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	key, err := h.secret(r.Context())
+	if err == nil && key == "" {
+		err = errors.New("empty secret") // would let anyone sign requests
+	}
+	if err != nil {
+		log.Printf("ERROR reading content webhook secret: %v", err)
+		http.Error(w, "webhook not configured", http.StatusInternalServerError)
+		return
+	}
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write(body)
+	sig, _ := hex.DecodeString(strings.TrimPrefix(r.Header.Get("X-Hub-Signature-256"), "sha256="))
+	if !hmac.Equal(sig, mac.Sum(nil)) {
+		http.Error(w, "invalid signature", http.StatusForbidden)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	switch event := cmp.Or(r.Header.Get("X-GitHub-Event"), r.Header.Get("X-Gitea-Event")); event {
+	case "push":
+	case "ping":
+		fmt.Fprintf(w, "pong\n")
+		return
+	default:
+		fmt.Fprintf(w, "ignoring %q event\n", event)
+		return
+	}
+	var push struct {
+		Ref   string `json:"ref"`
+		After string `json:"after"`
+	}
+	if err := json.Unmarshal(body, &push); err != nil {
+		http.Error(w, "invalid push event: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if push.Ref != h.branch {
+		fmt.Fprintf(w, "ignoring push to %s\n", push.Ref)
+		return
+	}
+	if !validCommit.MatchString(push.After) {
+		http.Error(w, fmt.Sprintf("invalid push event: invalid commit %q", push.After), http.StatusBadRequest)
+		return
+	}
+	if strings.Trim(push.After, "0") == "" {
+		fmt.Fprintf(w, "ignoring deletion of %s\n", push.Ref)
+		return
+	}
+	log.Printf("content webhook: push of %s to %s from %s", push.After, push.Ref, clientip.FromRequest(r))
+	if h.queue != nil {
+		if err := h.queue.Add(r.Context(), contentWebhookName, push.After); err != nil {
+			log.Printf("ERROR queuing push of %s: %v", push.After, err)
+		}
+	}
+	h.start(push.After)
+	w.WriteHeader(http.StatusAccepted)
+	fmt.Fprintf(w, "deploying content from commit %s\n", push.After)
+}
+
+var validCommit = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// contentWebhookName names the content webhook in its webhooks.Queue.
+// Each push supersedes the earlier ones.
+const contentWebhookName = "content"
+
+// start starts deploying the content of commit, unless a deploy
+// is in progress, in which case commit is deployed after it.
+func (h *contentWebhook) start(commit string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.running {
+		h.next = commit
+		return
+	}
+	h.running = true
+	h.wg.Add(1)
+	go h.run(commit)
+}
+
+// run deploys commit and then any commits pushed meanwhile.
+func (h *contentWebhook) run(commit string) {
+	defer h.wg.Done()
+	for {
+		err := h.deploy(commit)
+		if err != nil {
+			log.Printf("ERROR deploying content from commit %s: %v", commit, err)
+		} else {
+			log.Printf("deployed content from commit %s", commit)
+		}
+		h.record(commit, err)
+		h.mu.Lock()
+		commit, h.next = h.next, ""
+		if commit == "" {
+			h.running = false
+			h.mu.Unlock()
+			return
+		}
+		h.mu.Unlock()
+	}
+}
+
+// record records in h.queue, if any, that the deploy of commit
+// failed with err or, if err is nil, succeeded.
+func (h *contentWebhook) record(commit string, err error) {
+	if h.queue == nil {
+		return
+	}
+	ctx := context.Background()
+	if err != nil {
+		err = h.queue.Failed(ctx, contentWebhookName, commit, err)
+	} else {
+		err = h.queue.Delivered(ctx, contentWebhookName, commit)
+	}
+	if err != nil {
+		log.Printf("ERROR recording deploy of commit %s: %v", commit, err)
+	}
+}
+
+// deploy fetches the tree of commit and deploys the content in it.
+func (h *contentWebhook) deploy(commit string) error {
+	fsys, err := h.fetch(commit)
+	if err != nil {
+		return err
+	}
+	version := "git:" + commit
+	return h.deployer.Deploy(contentRoot(fsys), func() string { return version })
+}
@@ -0,0 +1,275 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golangorg
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/matttproud/yourtour"
+	"github.com/matttproud/yourtour/internal/etag"
+	"github.com/matttproud/yourtour/internal/web"
+)
+
+func TestCheckContent(t *testing.T) {
+	if err := testServer().checkContent(website.Content(), fstest.MapFS{}).Err(); err != nil {
+		t.Fatalf("checkContent(website.Content()): %v", err)
+	}
+}
+
+func TestContentDeployer(t *testing.T) {
+	oldFS := fstest.MapFS{
+		"site.tmpl": {Data: []byte(`{{.Content}}`)},
+		"index.md":  {Data: []byte("old")},
+	}
+	content := new(atomicFS)
+	content.Set(oldFS)
+	s := testServer()
+	site, _ := s.newWebSite("", content, fstest.MapFS{})
+	version := new(etag.Version)
+	version.Set("content", "old")
+	oldVersion := version.String()
+	d := &contentDeployer{server: s, content: content, goroot: fstest.MapFS{}, sites: []*web.Site{site}, version: version}
+
+	get := func() string {
+		w := httptest.NewRecorder()
+		site.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+		return strings.TrimSpace(w.Body.String())
+	}
+	if body := get(); !strings.Contains(body, "old") {
+		t.Fatalf("before deploy: %q", body)
+	}
+
+	bad := fstest.MapFS{
+		"site.tmpl":          {Data: []byte(`{{.Content}}`)},
+		"index.md":           {Data: []byte("bad")},
+		"doc/default.tmpl":   {Data: []byte(`{{if}}`)},
+		"doc/codewalk/x.xml": {Data: []byte(`<codewalk title="X"><step src="missing.go"/></codewalk>`)},
+	}
+	err := d.Deploy(bad, nil)
+	if err == nil || !strings.Contains(err.Error(), "doc/default.tmpl") || !strings.Contains(err.Error(), "missing.go") {
+		t.Errorf("Deploy(bad) = %v, want template and codewalk errors", err)
+	}
+	if body := get(); !strings.Contains(body, "old") {
+		t.Errorf("after failed deploy: %q, want old content", body)
+	}
+	if v := version.String(); v != oldVersion {
+		t.Errorf("after failed deploy: version %q, want %q", v, oldVersion)
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, data := range map[string]string{
+		"_content/site.tmpl":          `{{.Content}}`,
+		"_content/index.md":           "new",
+		"_content/doc/codewalk/x.xml": `<codewalk title="X"></codewalk>`,
+	} {
+		f, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.WriteString(f, data)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	r := httptest.NewRequest("POST", "/_content", &buf)
+	r.Header.Set("Content-Type", "application/zip")
+	w := httptest.NewRecorder()
+	d.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("POST zip: %d %s", w.Code, w.Body)
+	}
+	if body := get(); !strings.Contains(body, "new") {
+		t.Errorf("after deploy: %q, want new content", body)
+	}
+	if v := version.String(); v == "" || v == oldVersion {
+		t.Errorf("after deploy: version %q, want new version", v)
+	}
+	if _, err := fs.Stat(content, "site.tmpl"); err != nil {
+		t.Errorf("deployed zip not rooted at _content: %v", err)
+	}
+
+	w = httptest.NewRecorder()
+	d.ServeHTTP(w, httptest.NewRequest("POST", "/_content?dir="+t.TempDir()+"/missing", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("POST missing dir: %d %s, want 400", w.Code, w.Body)
+	}
+}
+
+func TestValidateHandler(t *testing.T) {
+	content := new(atomicFS)
+	content.Set(fstest.MapFS{
+		"site.tmpl":          {Data: []byte(`{{.Content}}`)},
+		"index.md":           {Data: []byte("---\ntitle: Home\n---\nhome")},
+		"doc/codewalk/x.xml": {Data: []byte(`<codewalk title="X"></codewalk>`)},
+	})
+	d := &contentDeployer{server: testServer(), content: content, goroot: fstest.MapFS{}}
+	h := d.validateHandler()
+	get := func(r *http.Request) (code int, ok bool, problems []string) {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		var report struct {
+			OK     bool
+			Checks []struct{ Problems []string }
+		}
+		if w.Code == http.StatusOK {
+			if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+				t.Fatalf("%s %s: %v\n%s", r.Method, r.URL, err, w.Body)
+			}
+		}
+		for _, c := range report.Checks {
+			problems = append(problems, c.Problems...)
+		}
+		return w.Code, report.OK, problems
+	}
+
+	if code, ok, problems := get(httptest.NewRequest("GET", "/_validate", nil)); code != 200 || !ok || len(problems) != 0 {
+		t.Errorf("GET: %d ok=%v %q, want 200 ok", code, ok, problems)
+	}
+
+	dir := t.TempDir()
+	for name, data := range map[string]string{
+		"site.tmpl":          `{{.Content}}`,
+		"index.md":           "---\ntitle: [Home]\n---\nhome",
+		"doc/codewalk/x.xml": `<codewalk title="X"></codewalk>`,
+	} {
+		file := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(file), 0o777); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(data), 0o666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r := httptest.NewRequest("POST", "/_validate", strings.NewReader(url.Values{"dir": {dir}}.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if code, ok, problems := get(r); code != 200 || ok || len(problems) != 1 || !strings.Contains(problems[0], "index.md: title") {
+		t.Errorf("POST invalid dir: %d ok=%v %q, want 200 with title problem", code, ok, problems)
+	}
+	if data, _ := fs.ReadFile(content, "index.md"); !strings.Contains(string(data), "title: Home") {
+		t.Errorf("POST deployed content: index.md = %q", data)
+	}
+
+	if code, _, _ := get(httptest.NewRequest("DELETE", "/_validate", nil)); code != http.StatusMethodNotAllowed {
+		t.Errorf("DELETE: %d, want 405", code)
+	}
+}
+
+func TestContentWebhook(t *testing.T) {
+	content := new(atomicFS)
+	content.Set(fstest.MapFS{
+		"site.tmpl": {Data: []byte(`{{.Content}}`)},
+		"index.md":  {Data: []byte("old")},
+	})
+	version := new(etag.Version)
+	d := &contentDeployer{server: testServer(), content: content, goroot: fstest.MapFS{}, version: version}
+
+	const (
+		good    = "1111111111111111111111111111111111111111"
+		bad     = "2222222222222222222222222222222222222222"
+		missing = "3333333333333333333333333333333333333333"
+	)
+	commits := map[string]fstest.MapFS{
+		good: {
+			"README.md":                   {Data: []byte("website")},
+			"_content/site.tmpl":          {Data: []byte(`{{.Content}}`)},
+			"_content/index.md":           {Data: []byte("new")},
+			"_content/doc/codewalk/x.xml": {Data: []byte(`<codewalk title="X"></codewalk>`)},
+		},
+		bad: {
+			"site.tmpl":        {Data: []byte(`{{.Content}}`)},
+			"doc/default.tmpl": {Data: []byte(`{{if}}`)},
+		},
+	}
+	var mu sync.Mutex
+	var fetched []string
+	h := &contentWebhook{
+		deployer: d,
+		branch:   "refs/heads/master",
+		secret:   func(context.Context) (string, error) { return "key", nil },
+		fetch: func(commit string) (fs.FS, error) {
+			mu.Lock()
+			fetched = append(fetched, commit)
+			mu.Unlock()
+			fsys, ok := commits[commit]
+			if !ok {
+				return nil, errors.New("no such commit")
+			}
+			return fsys, nil
+		},
+	}
+	post := func(event, body, key string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("POST", "/_content/webhook", strings.NewReader(body))
+		r.Header.Set("X-GitHub-Event", event)
+		mac := hmac.New(sha256.New, []byte(key))
+		mac.Write([]byte(body))
+		r.Header.Set("X-Hub-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		h.wg.Wait()
+		return w
+	}
+	index := func() string {
+		data, _ := fs.ReadFile(content, "index.md")
+		return string(data)
+	}
+
+	for _, tt := range []struct {
+		event, body, key string
+		code             int
+		fetch            string // commit fetched, if any
+		index            string // content of index.md afterward
+	}{
+		{"push", `{"ref": "refs/heads/master", "after": "` + good + `"}`, "wrong", 403, "", "old"},
+		{"ping", `{"zen": "Keep it simple."}`, "key", 200, "", "old"},
+		{"issues", `{}`, "key", 200, "", "old"},
+		{"push", `{"ref": "refs/heads/dev", "after": "` + good + `"}`, "key", 200, "", "old"},
+		{"push", `{"ref": "refs/heads/master", "after": "HEAD"}`, "key", 400, "", "old"},
+		{"push", `{"ref": "refs/heads/master", "after": "0000000000000000000000000000000000000000"}`, "key", 200, "", "old"},
+		{"push", `{"ref":`, "key", 400, "", "old"},
+		{"push", `{"ref": "refs/heads/master", "after": "` + bad + `"}`, "key", 202, bad, "old"},
+		{"push", `{"ref": "refs/heads/master", "after": "` + missing + `"}`, "key", 202, missing, "old"},
+		{"push", `{"ref": "refs/heads/master", "after": "` + good + `"}`, "key", 202, good, "new"},
+	} {
+		fetched = nil
+		w := post(tt.event, tt.body, tt.key)
+		if w.Code != tt.code {
+			t.Errorf("%s %s: %d %s, want %d", tt.event, tt.body, w.Code, w.Body, tt.code)
+		}
+		if got := strings.Join(fetched, ","); got != tt.fetch {
+			t.Errorf("%s %s: fetched %q, want %q", tt.event, tt.body, got, tt.fetch)
+		}
+		if got := index(); got != tt.index {
+			t.Errorf("%s %s: index.md = %q, want %q", tt.event, tt.body, got, tt.index)
+		}
+	}
+	if v := version.String(); v == "" {
+		t.Errorf("after deploy: no version")
+	}
+
+	h.secret = func(context.Context) (string, error) { return "", nil }
+	fetched = nil
+	if w := post("push", `{"ref": "refs/heads/master", "after": "`+good+`"}`, ""); w.Code != 500 || fetched != nil {
+		t.Errorf("push signed with empty secret: %d %s, fetched %q, want 500", w.Code, w.Body, fetched)
+	}
+}
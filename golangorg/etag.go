@@ -0,0 +1,52 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golangorg
+
+import (
+	"io/fs"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/matttproud/yourtour/internal/etag"
+	"github.com/matttproud/yourtour/internal/web"
+)
+
+// untaggedPages are the paths of site pages that are rendered from
+// data other than the content, which a content version cannot identify.
+var untaggedPages = []string{
+	"/rebuild",            // gorebuild reports
+	web.ServiceWorkerPath, // precache manifest, listing release data
+}
+
+// contentETags wraps the site handler h, tagging its replies with version,
+// except for untaggedPages and the pages in s.untaggedSections.
+// When the pages show announcements, which readers dismiss with a cookie,
+// the replies vary with the cookie.
+func (s *Server) contentETags(h http.Handler, version *etag.Version) http.Handler {
+	tagged := etag.Handler(h, version.String)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.announcements != nil {
+			w.Header().Add("Vary", "Cookie")
+		}
+		if slices.Contains(untaggedPages, strings.TrimSuffix(r.URL.Path, ".html")) ||
+			slices.ContainsFunc(s.untaggedSections, func(p string) bool { return strings.HasPrefix(r.URL.Path, p) }) {
+			h.ServeHTTP(w, r)
+			return
+		}
+		tagged.ServeHTTP(w, r)
+	})
+}
+
+// gorootVersion returns the Go version in the VERSION file of goroot,
+// or the empty string if there is none, as in a development tree.
+func gorootVersion(goroot fs.FS) string {
+	data, err := fs.ReadFile(goroot, "VERSION")
+	if err != nil {
+		return ""
+	}
+	version, _, _ := strings.Cut(string(data), "\n")
+	return strings.TrimSpace(version)
+}
@@ -0,0 +1,35 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golangorg
+
+import (
+	"testing"
+
+	"github.com/matttproud/yourtour/internal/golden"
+	"github.com/matttproud/yourtour/internal/memcache"
+)
+
+// goldenRoutes are the routes whose responses are recorded
+// in testdata/golden, to be kept unchanged by refactorings.
+var goldenRoutes = []golden.Route{
+	{Name: "codewalk", URL: "https://go.dev/doc/codewalk/sharemem/"},
+	{Name: "codewalk-index", URL: "https://go.dev/doc/codewalk/"},
+	{Name: "fileprint", URL: "https://go.dev/doc/codewalk/?fileprint=/doc/codewalk/urlpoll.go"},
+	{Name: "dl", URL: "https://go.dev/dl/"},
+	{Name: "dl-json", URL: "https://go.dev/dl/?mode=json"},
+}
+
+// TestGolden checks the responses of the full handler, serving the
+// content in _content, to goldenRoutes. There is no datastore client,
+// so the download pages serve the embedded snapshot of release data,
+// and the cache is in memory. Run it with -golden.update to record
+// the current responses.
+func TestGolden(t *testing.T) {
+	h := NewServer(
+		WithContentDir("../_content"),
+		WithCache(memcache.NewMemory(0)),
+	)
+	golden.Test(t, h, "testdata/golden", goldenRoutes)
+}
@@ -0,0 +1,278 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golangorg
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/matttproud/yourtour/internal/codewalk"
+	"github.com/matttproud/yourtour/internal/dl"
+	"github.com/matttproud/yourtour/internal/graphql"
+	"github.com/matttproud/yourtour/internal/memcache"
+	"github.com/matttproud/yourtour/internal/vhost"
+	"github.com/matttproud/yourtour/internal/web"
+)
+
+// graphqlHandler returns the handler for /graphql, which serves a GraphQL API
+// over the releases listed by dl (from dc and mc), the codewalks,
+// and the content pages of the virtual host h.
+// GET /graphql with no query prints the schema.
+// Any origin may use the API, which is read-only.
+func graphqlHandler(h *vhost.Host, dc dl.Datastore, mc memcache.Cache) http.Handler {
+	schema := siteSchema(h, dc, mc)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		if r.Method == "OPTIONS" {
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+			w.Header().Set("Access-Control-Max-Age", "86400")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		schema.ServeHTTP(w, r)
+	})
+}
+
+// siteSchema returns the GraphQL schema served by graphqlHandler.
+func siteSchema(h *vhost.Host, dc dl.Datastore, mc memcache.Cache) *graphql.Schema {
+	fileType := &graphql.Object{
+		Name: "File",
+		Doc:  "A File is a downloadable file of a Go release.",
+		Fields: []*graphql.Field{
+			{Name: "filename", Type: graphql.NonNull(graphql.String)},
+			{Name: "os", Type: graphql.NonNull(graphql.String)},
+			{Name: "arch", Type: graphql.NonNull(graphql.String)},
+			{Name: "version", Type: graphql.NonNull(graphql.String)},
+			{Name: "kind", Doc: "kind is archive, installer, or source.", Type: graphql.NonNull(graphql.String)},
+			{Name: "size", Type: graphql.NonNull(graphql.Float)},
+			{
+				Name: "sha256",
+				Type: graphql.NonNull(graphql.String),
+				Resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+					return source.(dl.File).ChecksumSHA256, nil
+				},
+			},
+			{
+				Name: "url",
+				Doc:  "url is the file's download URL path, such as /dl/go1.22.0.linux-amd64.tar.gz.",
+				Type: graphql.NonNull(graphql.String),
+				Resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+					return source.(dl.File).URL(), nil
+				},
+			},
+		},
+	}
+
+	releaseType := &graphql.Object{
+		Name: "Release",
+		Doc:  "A Release is a Go release, as listed on the download page.",
+		Fields: []*graphql.Field{
+			{Name: "version", Type: graphql.NonNull(graphql.String)},
+			{Name: "stable", Type: graphql.NonNull(graphql.Boolean)},
+			{
+				Name: "files",
+				Doc:  "files lists the release's files, optionally only those for a given os, arch, or kind.",
+				Args: []*graphql.Arg{
+					{Name: "os", Type: graphql.String},
+					{Name: "arch", Type: graphql.String},
+					{Name: "kind", Type: graphql.String},
+				},
+				Type: graphql.NonNull(graphql.List(graphql.NonNull(fileType))),
+				Resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+					files := []dl.File{}
+					for _, f := range source.(dl.Release).Files {
+						if match(args["os"], f.OS) && match(args["arch"], f.Arch) && match(args["kind"], f.Kind) {
+							files = append(files, f)
+						}
+					}
+					return files, nil
+				},
+			},
+		},
+	}
+
+	stepType := &graphql.Object{
+		Name: "Step",
+		Doc:  "A Step is a step of a codewalk.",
+		Fields: []*graphql.Field{
+			{Name: "title", Type: graphql.NonNull(graphql.String)},
+			{Name: "src", Doc: "src is the step's source address, as written in the codewalk.", Type: graphql.NonNull(graphql.String)},
+			{Name: "prose", Doc: "prose is the step's explanation, in HTML.", Type: graphql.NonNull(graphql.String)},
+			{Name: "file", Doc: "file is the file shown, or empty if src cannot be resolved.", Type: graphql.NonNull(graphql.String)},
+			{Name: "lo", Doc: "lo and hi are the lines shown, or 0 for the whole file.", Type: graphql.NonNull(graphql.Int)},
+			{Name: "hi", Type: graphql.NonNull(graphql.Int)},
+		},
+	}
+
+	codewalkType := &graphql.Object{
+		Name: "Codewalk",
+		Doc:  "A Codewalk is a guided tour through a program.",
+		Fields: []*graphql.Field{
+			{Name: "path", Doc: "path is the codewalk's URL path, such as /doc/codewalk/sharemem.", Type: graphql.NonNull(graphql.String)},
+			{Name: "title", Type: graphql.NonNull(graphql.String)},
+			{Name: "files", Type: graphql.NonNull(graphql.List(graphql.NonNull(graphql.String)))},
+			{Name: "steps", Type: graphql.NonNull(graphql.List(graphql.NonNull(stepType)))},
+		},
+	}
+
+	pageType := &graphql.Object{
+		Name: "Page",
+		Doc:  "A Page is a content page of the site.",
+		Fields: []*graphql.Field{
+			{Name: "url", Type: graphql.NonNull(graphql.String), Resolve: pageField("URL")},
+			{Name: "title", Type: graphql.String, Resolve: pageField("title")},
+			{Name: "summary", Type: graphql.String, Resolve: pageField("summary")},
+			{Name: "date", Doc: "date is the page's date, if any, in RFC 3339 form.", Type: graphql.String, Resolve: pageField("date")},
+			{
+				Name: "tags",
+				Type: graphql.NonNull(graphql.List(graphql.NonNull(graphql.String))),
+				Resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+					// YAML reads some tags as numbers; list them as written.
+					tags := []string{}
+					raw, _ := source.(web.Page)["tags"].([]any)
+					for _, t := range raw {
+						if t != nil {
+							tags = append(tags, fmt.Sprint(t))
+						}
+					}
+					return tags, nil
+				},
+			},
+		},
+	}
+
+	limitArg := &graphql.Arg{Name: "limit", Doc: "limit is the maximum number of results.", Type: graphql.Int}
+
+	return &graphql.Schema{
+		Query: &graphql.Object{
+			Name: "Query",
+			Fields: []*graphql.Field{
+				{
+					Name: "releases",
+					Doc: "releases lists the Go releases, newest first:\n" +
+						"those in channel stable, unstable, or archive, or all of them.",
+					Args: []*graphql.Arg{{Name: "channel", Type: graphql.String, Default: "all"}, limitArg},
+					Type: graphql.NonNull(graphql.List(graphql.NonNull(releaseType))),
+					Resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+						stable, unstable, archive, err := dl.Releases(ctx, dc, mc)
+						if err != nil {
+							return nil, err
+						}
+						var list []dl.Release
+						switch ch := args["channel"]; ch {
+						case "all":
+							list = append(append(append(list, unstable...), stable...), archive...)
+						case "stable":
+							list = stable
+						case "unstable":
+							list = unstable
+						case "archive":
+							list = archive
+						default:
+							return nil, fmt.Errorf("unknown channel %q", ch)
+						}
+						return limit(list, args["limit"]), nil
+					},
+				},
+				{
+					Name: "release",
+					Doc:  "release returns the Go release with the given version, such as go1.22.0.",
+					Args: []*graphql.Arg{{Name: "version", Type: graphql.NonNull(graphql.String)}},
+					Type: releaseType,
+					Resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+						stable, unstable, archive, err := dl.Releases(ctx, dc, mc)
+						if err != nil {
+							return nil, err
+						}
+						for _, list := range [][]dl.Release{stable, unstable, archive} {
+							for _, rel := range list {
+								if rel.Version == args["version"] {
+									return rel, nil
+								}
+							}
+						}
+						return nil, nil
+					},
+				},
+				{
+					Name: "codewalks",
+					Type: graphql.NonNull(graphql.List(graphql.NonNull(codewalkType))),
+					Resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+						return codewalk.List(h.FS)
+					},
+				},
+				{
+					Name: "codewalk",
+					Doc:  "codewalk returns the codewalk with the given URL path.",
+					Args: []*graphql.Arg{{Name: "path", Type: graphql.NonNull(graphql.String)}},
+					Type: codewalkType,
+					Resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+						list, err := codewalk.List(h.FS)
+						if err != nil {
+							return nil, err
+						}
+						for _, cw := range list {
+							if cw.Path == args["path"] {
+								return cw, nil
+							}
+						}
+						return nil, nil
+					},
+				},
+				{
+					Name: "pages",
+					Doc: "pages lists the content pages with URL paths matching glob,\n" +
+						"a path.Match pattern such as /blog/*, sorted by URL path.\n" +
+						"It leaves out the pages of private sections.",
+					Args: []*graphql.Arg{{Name: "glob", Type: graphql.NonNull(graphql.String)}, limitArg},
+					Type: graphql.NonNull(graphql.List(graphql.NonNull(pageType))),
+					Resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+						pages, err := h.Site.Pages(args["glob"].(string))
+						if err != nil {
+							return nil, err
+						}
+						pages = slices.DeleteFunc(pages, func(p web.Page) bool {
+							url, _ := p["URL"].(string)
+							return h.Site.Private(url)
+						})
+						return limit(pages, args["limit"]), nil
+					},
+				},
+			},
+		},
+	}
+}
+
+// match reports whether value matches the optional filter argument want.
+func match(want any, value string) bool {
+	return want == nil || want == value
+}
+
+// limit returns the first n elements of list,
+// where n is the optional limit argument.
+func limit[T any](list []T, n any) []T {
+	if n, ok := n.(int); ok && n >= 0 && n < len(list) {
+		return list[:n]
+	}
+	if list == nil {
+		return []T{}
+	}
+	return list
+}
+
+// pageField returns a resolver for the metadata key of a web.Page.
+func pageField(key string) func(context.Context, any, map[string]any) (any, error) {
+	return func(ctx context.Context, source any, args map[string]any) (any, error) {
+		v := source.(web.Page)[key]
+		if t, ok := v.(time.Time); ok && t.IsZero() {
+			return nil, nil
+		}
+		return v, nil
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golangorg
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/matttproud/yourtour/internal/private"
+	"github.com/matttproud/yourtour/internal/router"
+	"github.com/matttproud/yourtour/internal/vhost"
+	"github.com/matttproud/yourtour/internal/web"
+)
+
+func TestGraphQLPrivatePages(t *testing.T) {
+	fsys := fstest.MapFS{
+		"notes/public.md":         {Data: []byte("---\ntitle: Public\n---\nPublic notes.\n")},
+		"notes/team/index.md":     {Data: []byte("---\ntitle: Team\n---\nTeam notes.\n")},
+		"notes/team/plans.md":     {Data: []byte("---\ntitle: Plans\n---\nSecret plans.\n")},
+		"notes/teamwork/index.md": {Data: []byte("---\ntitle: Teamwork\n---\nTeamwork.\n")},
+	}
+	gate, err := private.NewGate("/notes/team/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	site := web.NewSite(fsys)
+	site.SetPrivate(gate.Private)
+	var vhosts vhost.Registry
+	h := graphqlHandler(vhosts.Add("", site, fsys, router.New(http.NewServeMux())), nil, nil)
+
+	for _, tt := range []struct {
+		glob string
+		want string
+	}{
+		{"/notes/*", `{"data":{"pages":[{"url":"/notes/public"},{"url":"/notes/teamwork/"}]}}`},
+		{"/notes/team/*", `{"data":{"pages":[]}}`},
+	} {
+		query := `{pages(glob:"` + tt.glob + `"){url}}`
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", "/graphql?query="+url.QueryEscape(query), nil))
+		if got := strings.TrimSpace(w.Body.String()); got != tt.want {
+			t.Errorf("%s = %s, want %s", query, got, tt.want)
+		}
+	}
+}
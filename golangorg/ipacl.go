@@ -0,0 +1,87 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golangorg
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/matttproud/yourtour/internal/clientip"
+	"github.com/matttproud/yourtour/internal/env"
+	"github.com/matttproud/yourtour/internal/ipacl"
+)
+
+// A protectedRoute is a sensitive route whose access can be limited
+// by client address.
+type protectedRoute struct {
+	name  string
+	match func(*http.Request) bool
+}
+
+// protectedRoutes lists the routes with access control lists,
+// which the ip_access setting gives by name.
+// A request is checked against the first route it matches.
+var protectedRoutes = []protectedRoute{
+	{
+		// Release automation uploads new downloads.
+		name:  "upload",
+		match: func(r *http.Request) bool { return r.URL.Path == "/dl/upload" },
+	},
+	{
+		// Administrative endpoints change or reveal server state.
+		name: "admin",
+		match: func(r *http.Request) bool {
+			switch r.URL.Path {
+			case "/_analytics", "/_apikeys", "/_comments", "/_content", "/_features", "/_feedback", "/_flags", "/_links", "/_metrics", "/_redirects", "/_shortlinks", "/_slo", "/_validate", "/_webhooks", "/debug/config":
+				return true
+			}
+			return strings.HasPrefix(r.URL.Path, "/_apikeys/") || strings.HasPrefix(r.URL.Path, "/_comments/") || strings.HasPrefix(r.URL.Path, "/_shortlinks/") || strings.HasPrefix(r.URL.Path, "/_webhooks/")
+		},
+	},
+	{
+		// The content repository's Git forge reports pushes.
+		name:  "webhook",
+		match: func(r *http.Request) bool { return r.URL.Path == "/_content/webhook" },
+	},
+	{
+		// Runtime debugging endpoints, as served by debughttp.
+		name:  "debug",
+		match: func(r *http.Request) bool { return strings.HasPrefix(r.URL.Path, "/debug/") },
+	},
+}
+
+// ipAccessHandler wraps h, refusing requests for protectedRoutes
+// from client addresses not allowed by cfg.IPAccess.
+func ipAccessHandler(cfg *env.Config, h http.Handler) (http.Handler, error) {
+	rules, err := ipacl.ParseRules(cfg.IPAccess)
+	if err != nil {
+		return nil, err
+	}
+	acls := make(map[string]http.Handler)
+	for _, route := range protectedRoutes {
+		if acl, ok := rules[route.name]; ok {
+			acls[route.name] = acl.Handler(h, clientip.FromRequest)
+			delete(rules, route.name)
+		}
+	}
+	if err := unknownRoutes("access lists", rules); err != nil {
+		return nil, err
+	}
+	if len(acls) == 0 {
+		return h, nil
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, route := range protectedRoutes {
+			if route.match(r) {
+				if ah, ok := acls[route.name]; ok {
+					ah.ServeHTTP(w, r)
+					return
+				}
+				break
+			}
+		}
+		h.ServeHTTP(w, r)
+	}), nil
+}
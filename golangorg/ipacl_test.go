@@ -0,0 +1,67 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golangorg
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/matttproud/yourtour/internal/env"
+)
+
+func TestIPAccessHandler(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	h, err := ipAccessHandler(&env.Config{IPAccess: "upload=10.0.0.0/8,admin=!192.0.2.0/24,webhook=203.0.113.0/24"}, ok)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		method, url, remote, forwarded string
+		code                           int
+	}{
+		{"POST", "/dl/upload", "10.1.2.3:1234", "", 200},
+		{"POST", "/dl/upload", "192.0.2.1:1234", "", 403},
+		{"POST", "/dl/upload", "192.0.2.1:1234", "10.1.2.3", 403}, // no trusted proxies
+		{"GET", "/dl/", "192.0.2.1:1234", "", 200},
+		{"GET", "/_flags", "192.0.2.1:1234", "", 403},
+		{"GET", "/_analytics", "192.0.2.1:1234", "", 403},
+		{"GET", "/_features", "192.0.2.1:1234", "", 403},
+		{"DELETE", "/_apikeys/abc", "192.0.2.1:1234", "", 403},
+		{"GET", "/_links", "192.0.2.1:1234", "", 403},
+		{"GET", "/_redirects", "192.0.2.1:1234", "", 403},
+		{"GET", "/_validate", "192.0.2.1:1234", "", 403},
+		{"GET", "/_slo", "192.0.2.1:1234", "", 403},
+		{"POST", "/_webhooks/content:1111111111111111111111111111111111111111", "192.0.2.1:1234", "", 403},
+		{"POST", "/_feedback", "192.0.2.1:1234", "", 403},
+		{"POST", "/feedback", "192.0.2.1:1234", "", 200},
+		{"PUT", "/_comments/12", "192.0.2.1:1234", "", 403},
+		{"POST", "/comments", "192.0.2.1:1234", "", 200},
+		{"POST", "/_content", "198.51.100.1:1234", "", 200},
+		{"POST", "/_content/webhook", "203.0.113.1:1234", "", 200},
+		{"POST", "/_content/webhook", "192.0.2.1:1234", "", 403},
+		{"POST", "/_shortlinks", "192.0.2.1:1234", "", 403},
+		{"DELETE", "/_shortlinks/spec", "192.0.2.1:1234", "", 403},
+		{"GET", "/debug/pprof/", "192.0.2.1:1234", "", 200},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(tt.method, tt.url, nil)
+		r.RemoteAddr = tt.remote
+		if tt.forwarded != "" {
+			r.Header.Set("X-Forwarded-For", tt.forwarded)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != tt.code {
+			t.Errorf("%s %s from %s (forwarded %q): %d, want %d", tt.method, tt.url, tt.remote, tt.forwarded, w.Code, tt.code)
+		}
+	}
+
+	_, err = ipAccessHandler(&env.Config{IPAccess: "uplaod=10.0.0.0/8"}, ok)
+	if err == nil || !strings.Contains(err.Error(), "uplaod") {
+		t.Errorf("unknown route: err = %v, want error naming it", err)
+	}
+}
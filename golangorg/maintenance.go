@@ -0,0 +1,85 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golangorg
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/matttproud/yourtour/internal/dl"
+	"github.com/matttproud/yourtour/internal/env"
+	"github.com/matttproud/yourtour/internal/web"
+)
+
+// maintenanceFlag is the feature flag that puts the server in maintenance
+// mode, for planned datastore migrations: write endpoints are refused,
+// and read paths serve cached or embedded data without using datastore.
+const maintenanceFlag = "maintenance"
+
+// maintenanceRetryAfter is the time clients refused during maintenance
+// are asked to wait before retrying.
+const maintenanceRetryAfter = 10 * time.Minute
+
+// writeRoutes are the path prefixes of the endpoints that write to datastore,
+// which are refused during maintenance.
+var writeRoutes = []string{"/dl/upload", "/_shortlinks", "/feedback", "/_feedback", "/comments", "/_comments"}
+
+// errMaintenance is the error shown for requests refused during maintenance.
+var errMaintenance = errors.New("this page is unavailable during planned maintenance; please try again later")
+
+// maintenanceHandler wraps h, answering requests for writeRoutes
+// with the 503 error page of siteFor(r) while the maintenance flag is enabled.
+func maintenanceHandler(h http.Handler, siteFor func(*http.Request) *web.Site) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if env.Enabled(maintenanceFlag) && isWriteRoute(r.URL.Path) {
+			w.Header().Set("Retry-After", strconv.Itoa(int(maintenanceRetryAfter.Seconds())))
+			siteFor(r).ServeErrorStatus(w, r, errMaintenance, http.StatusServiceUnavailable)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// isWriteRoute reports whether path is under one of writeRoutes.
+func isWriteRoute(path string) bool {
+	for _, p := range writeRoutes {
+		if strings.HasPrefix(path, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// maintenanceDatastore returns dc, made unavailable while
+// the maintenance flag is enabled, so that the download pages
+// stop reading from it and serve cached or embedded data.
+func maintenanceDatastore(dc dl.Datastore) dl.Datastore {
+	return &pausedDatastore{dc}
+}
+
+// A pausedDatastore is a dl.Datastore that fails every call
+// with dl.ErrUnavailable during maintenance.
+type pausedDatastore struct {
+	ds dl.Datastore
+}
+
+func (d *pausedDatastore) GetAll(ctx context.Context, q *datastore.Query, dst any) ([]*datastore.Key, error) {
+	if env.Enabled(maintenanceFlag) {
+		return nil, dl.ErrUnavailable
+	}
+	return d.ds.GetAll(ctx, q, dst)
+}
+
+func (d *pausedDatastore) Put(ctx context.Context, key *datastore.Key, src any) (*datastore.Key, error) {
+	if env.Enabled(maintenanceFlag) {
+		return nil, dl.ErrUnavailable
+	}
+	return d.ds.Put(ctx, key, src)
+}
@@ -0,0 +1,72 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golangorg
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/matttproud/yourtour"
+	"github.com/matttproud/yourtour/internal/env"
+	"github.com/matttproud/yourtour/internal/web"
+)
+
+func TestMaintenanceHandler(t *testing.T) {
+	site, _ := testServer().newWebSite("", website.Content(), fstest.MapFS{})
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	h := maintenanceHandler(ok, func(*http.Request) *web.Site { return site })
+
+	file := filepath.Join(t.TempDir(), "flags.yaml")
+	setMaintenance := func(on bool) {
+		t.Helper()
+		data := "maintenance: false\n"
+		if on {
+			data = "maintenance: true\n"
+		}
+		if err := os.WriteFile(file, []byte(data), 0666); err != nil {
+			t.Fatal(err)
+		}
+		flags := env.NewFlags(env.FileFlags(file))
+		if err := flags.Refresh(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		env.SetFlags(flags)
+	}
+	defer env.SetFlags(nil)
+
+	tests := []struct {
+		on         bool
+		method     string
+		url        string
+		code       int
+		retryAfter string
+	}{
+		{false, "POST", "/dl/upload", 200, ""},
+		{true, "POST", "/dl/upload", 503, "600"},
+		{true, "GET", "/dl/", 200, ""},
+		{true, "POST", "/_flags", 200, ""},
+		{true, "PUT", "/_shortlinks/spec", 503, "600"},
+		{true, "POST", "/feedback", 503, "600"},
+		{true, "POST", "/comments", 503, "600"},
+	}
+	for _, tt := range tests {
+		setMaintenance(tt.on)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(tt.method, tt.url, nil))
+		if w.Code != tt.code || w.Header().Get("Retry-After") != tt.retryAfter {
+			t.Errorf("maintenance=%v %s %s: %d, Retry-After %q, want %d, %q",
+				tt.on, tt.method, tt.url, w.Code, w.Header().Get("Retry-After"), tt.code, tt.retryAfter)
+		}
+		if w.Code == 503 && !strings.Contains(w.Body.String(), "planned maintenance") {
+			t.Errorf("%s %s during maintenance: error page does not mention maintenance:\n%s", tt.method, tt.url, w.Body)
+		}
+	}
+}
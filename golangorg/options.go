@@ -0,0 +1,148 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golangorg
+
+import (
+	"archive/zip"
+	"context"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"cloud.google.com/go/datastore"
+	"github.com/matttproud/yourtour/internal/announce"
+	"github.com/matttproud/yourtour/internal/chaos"
+	"github.com/matttproud/yourtour/internal/env"
+	"github.com/matttproud/yourtour/internal/env/boot"
+	"github.com/matttproud/yourtour/internal/jobs"
+	"github.com/matttproud/yourtour/internal/memcache"
+	"github.com/matttproud/yourtour/internal/redirect"
+)
+
+// A Server serves the web sites: go.dev, golang.org, and their
+// subdomains and mirrors, with all their handlers and middleware.
+// It is created by NewServer, so that tests and other programs
+// can serve the sites as the golangorg command does,
+// with their own content and storage.
+type Server struct {
+	cfg        *env.Config // configuration, as when NewServer was called
+	content    fs.FS
+	contentDir string // directory of content, if read from one
+	goroot     fs.FS
+	gorootPath string // directory or zip file of goroot, if not yet open
+	datastore  *datastore.Client
+	cache      memcache.Cache
+	appEngine  bool            // connect to the App Engine project's datastore and cache
+	hosts      map[string]bool // hosts served, as validHosts
+	tip, wiki  bool            // load tip.golang.org and go.dev/wiki from Git
+	handler    http.Handler
+
+	// background is the context for the server's background work,
+	// such as refreshing feature flags. It is canceled by Shutdown.
+	background context.Context
+	stop       context.CancelFunc
+	jobs       *jobs.Manager // periodic background work; stops with background
+
+	checks boot.Checker // preflight checks, run by Preflight
+
+	chaosRules       chaos.Rules               // faults injected for resilience testing
+	googleAnalytics  string                    // analytics ID shown in the sites' pages
+	untaggedSections []string                  // path prefixes of sections left untagged by contentETags
+	sectionRedirects []func() []redirect.Entry // redirects of the mounted sections, for export
+	announcements    *announce.Board           // announcements shown on the pages, if any
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.handler.ServeHTTP(w, r)
+}
+
+// An Option configures a Server created by NewServer.
+type Option func(*Server)
+
+// WithContent makes the server serve the _content files in fsys,
+// which it tags with the revision of the binary.
+// By default, the server serves the copy embedded in the binary.
+func WithContent(fsys fs.FS) Option {
+	return func(s *Server) { s.content, s.contentDir = fsys, "" }
+}
+
+// WithContentDir makes the server serve the _content files in dir,
+// tagging them with the version of the directory
+// and, on development servers, reloading pages when they change.
+func WithContentDir(dir string) Option {
+	return func(s *Server) { s.content, s.contentDir = os.DirFS(dir), dir }
+}
+
+// WithGoroot makes the server serve the GOROOT in goroot,
+// a directory or a zip file of one.
+// By default, the server serves runtime.GOROOT().
+func WithGoroot(goroot string) Option {
+	return func(s *Server) { s.goroot, s.gorootPath = nil, goroot }
+}
+
+// WithGorootFS makes the server serve the GOROOT in fsys.
+func WithGorootFS(fsys fs.FS) Option {
+	return func(s *Server) { s.goroot, s.gorootPath = fsys, "" }
+}
+
+// WithDatastore makes the server keep its data in dc,
+// serving the features that need a datastore, such as short links,
+// analytics, feedback, API keys, and comments.
+// Without a datastore, those features are off, and the download
+// pages serve the release data embedded in the binary.
+func WithDatastore(dc *datastore.Client) Option {
+	return func(s *Server) { s.datastore = dc }
+}
+
+// WithCache makes the server cache data shared by its instances in c.
+// Without a cache, the playground handlers do not cache their data,
+// and the download and tour handlers and short links cache theirs
+// in the memory of each instance.
+func WithCache(c memcache.Cache) Option {
+	return func(s *Server) { s.cache = c }
+}
+
+// WithAppEngine makes the server keep its data in the datastore
+// and cache of the App Engine project it runs in, as the configuration says,
+// checking that it can reach them in Preflight.
+func WithAppEngine() Option {
+	return func(s *Server) { s.appEngine = true }
+}
+
+// WithHosts adds hosts to the hosts the server serves as themselves,
+// for programs serving the sites under other names.
+// Requests for other hosts, apart from the alternate names
+// in canonicalHosts, are redirected to go.dev.
+func WithHosts(hosts ...string) Option {
+	return func(s *Server) {
+		for _, host := range hosts {
+			s.hosts[strings.ToLower(host)] = true
+		}
+	}
+}
+
+// WithGit makes the server load the content of tip.golang.org,
+// if tip is set, and of go.dev/wiki, if wiki is set, from the latest
+// commits of their Git repos, watching for new ones.
+// By default, tip.golang.org serves the Go repo at the commit
+// that it is bundled with, and go.dev/wiki serves _content/wiki.
+func WithGit(tip, wiki bool) Option {
+	return func(s *Server) { s.tip, s.wiki = tip, wiki }
+}
+
+// openGoroot returns the file system of the GOROOT in goroot,
+// a directory or a zip file of one.
+func openGoroot(goroot string) fs.FS {
+	if strings.HasSuffix(goroot, ".zip") {
+		z, err := zip.OpenReader(goroot)
+		if err != nil {
+			log.Fatal(err)
+		}
+		return &seekableFS{z}
+	}
+	return os.DirFS(goroot)
+}
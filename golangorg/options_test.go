@@ -0,0 +1,77 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golangorg
+
+import (
+	"context"
+	"net"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"cloud.google.com/go/datastore"
+	"github.com/matttproud/yourtour/internal/env"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestServerOptions(t *testing.T) {
+	get := func(h *Server, url string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", url, nil))
+		return w
+	}
+
+	s := NewServer(WithContentDir("../_content"), WithGoroot(runtime.GOROOT()), WithHosts("Go.Example"))
+	if w := get(s, "https://go.example/doc/"); w.Code != 200 || !strings.Contains(w.Body.String(), "Documentation") {
+		t.Errorf("GET https://go.example/doc/ = %d, want the documentation page", w.Code)
+	}
+	if w := get(NewServer(WithContentDir("../_content")), "https://go.example/doc/"); w.Code != 302 || w.Header().Get("Location") != "https://go.dev/doc/" {
+		t.Errorf("without WithHosts, GET https://go.example/doc/ = %d to %q, want 302 to https://go.dev/doc/", w.Code, w.Header().Get("Location"))
+	}
+
+	goroot := fstest.MapFS{
+		"doc/go_spec.html": {Data: []byte("<!--{\n\t\"Title\": \"The Go Programming Language Specification\"\n}-->\n\n<p>A spec from a test GOROOT.</p>\n")},
+	}
+	s = NewServer(WithContentDir("../_content"), WithGorootFS(goroot))
+	if w := get(s, "https://go.dev/ref/spec"); !strings.Contains(w.Body.String(), "A spec from a test GOROOT.") {
+		t.Errorf("GET https://go.dev/ref/spec = %d, not the spec from WithGorootFS", w.Code)
+	}
+
+	// With a datastore but no cache, dl caches release data in memory.
+	// The datastore is a fake that fails every call.
+	defer env.Set(env.Get())
+	cfg := *env.Get()
+	cfg.FakeDLData = false
+	env.Set(&cfg)
+	s = NewServer(WithContentDir("../_content"), WithDatastore(failingDatastore(t)))
+	if w := get(s, "https://go.dev/dl/"); w.Code != 500 || !strings.Contains(w.Body.String(), "Could not get download page.") {
+		t.Errorf("with a failing datastore and no cache, GET https://go.dev/dl/ = %d\n%s\nwant 500 from listing the downloads", w.Code, w.Body)
+	}
+}
+
+// failingDatastore returns a datastore client of a fake datastore
+// that answers every call with an Unimplemented error.
+func failingDatastore(t *testing.T) *datastore.Client {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer(grpc.UnknownServiceHandler(func(any, grpc.ServerStream) error {
+		return status.Error(codes.Unimplemented, "fake datastore")
+	}))
+	go srv.Serve(l)
+	t.Cleanup(srv.Stop)
+	t.Setenv("DATASTORE_EMULATOR_HOST", l.Addr().String())
+	dc, err := datastore.NewClient(context.Background(), "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { dc.Close() })
+	return dc
+}
@@ -0,0 +1,162 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golangorg
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/matttproud/yourtour/internal/apikey"
+	"github.com/matttproud/yourtour/internal/env"
+	"github.com/matttproud/yourtour/internal/ratelimit"
+)
+
+// A limitedRoute is a route whose request rate is limited.
+type limitedRoute struct {
+	name  string
+	match func(*http.Request) bool
+	rule  string // default rule, as for ratelimit.ParseRules
+
+	// keyed says the route is an API whose clients may present API keys;
+	// requests with valid keys are limited by their keys' quotas instead.
+	keyed bool
+}
+
+// limitedRoutes lists the routes with rate limits,
+// which the rate_limits setting can override by name.
+var limitedRoutes = []limitedRoute{
+	{
+		// Release automation uploads a few hundred files at once.
+		name:  "upload",
+		match: func(r *http.Request) bool { return r.URL.Path == "/dl/upload" },
+		rule:  "60/m:300",
+	},
+	{
+		// Fileprint requests render arbitrary source files.
+		name: "fileprint",
+		match: func(r *http.Request) bool {
+			return isCodewalkRequest(r) && r.URL.Query().Has("fileprint")
+		},
+		rule: "60/m:120",
+	},
+	{
+		// Excerpts resolve addresses in arbitrary source files.
+		name:  "excerpt",
+		match: func(r *http.Request) bool { return r.URL.Path == "/api/excerpt" },
+		rule:  "60/m:120",
+		keyed: true,
+	},
+	{
+		// Playground requests run programs on the playground backend.
+		name:  "play",
+		match: isPlayRequest,
+		rule:  "30/m:60",
+	},
+	{
+		// Tour API writes issue tokens and save progress in the cache.
+		name: "tour",
+		match: func(r *http.Request) bool {
+			return strings.HasPrefix(r.URL.Path, "/tour/api/") && r.Method != "GET" && r.Method != "HEAD"
+		},
+		rule: "60/m:120",
+	},
+	{
+		// Analytics beacons are counted in memory until recorded.
+		name:  "beacon",
+		match: func(r *http.Request) bool { return r.URL.Path == "/_beacon" },
+		rule:  "60/m:120",
+	},
+	{
+		// Feedback is stored in datastore; throttle spam.
+		name:  "feedback",
+		match: func(r *http.Request) bool { return r.URL.Path == "/feedback" && r.Method == "POST" },
+		rule:  "5/m:10",
+	},
+	{
+		// Comments are stored in datastore; throttle spam.
+		name:  "comments",
+		match: func(r *http.Request) bool { return r.URL.Path == "/comments" && r.Method == "POST" },
+		rule:  "5/m:10",
+	},
+	{
+		// Quiz scores are stored in datastore; throttle guessing.
+		name:  "quiz",
+		match: func(r *http.Request) bool { return strings.HasPrefix(r.URL.Path, "/quiz/") && r.Method == "POST" },
+		rule:  "30/m:60",
+	},
+	{
+		// GraphQL queries can ask for much of the site at once.
+		name:  "graphql",
+		match: func(r *http.Request) bool { return r.URL.Path == "/graphql" },
+		rule:  "120/m:240",
+		keyed: true,
+	},
+	{
+		// Release automation and package managers poll the list of downloads.
+		name:  "dljson",
+		match: func(r *http.Request) bool { return r.URL.Path == "/dl/" && r.URL.Query().Get("mode") == "json" },
+		rule:  "60/m:120",
+		keyed: true,
+	},
+	{
+		// Searches scan the whole search index.
+		name:  "search",
+		match: func(r *http.Request) bool { return r.URL.Path == "/search" },
+		rule:  "60/m:120",
+	},
+}
+
+// isPlayRequest reports whether r is a request
+// for the playground's compile, format, or share endpoints.
+func isPlayRequest(r *http.Request) bool {
+	switch strings.TrimPrefix(r.URL.Path, "/_") {
+	case "/compile", "/fmt", "/share":
+		return r.Method == "POST"
+	}
+	return false
+}
+
+// isAPIRequest reports whether r is a request for a keyed route.
+func isAPIRequest(r *http.Request) bool {
+	for _, route := range limitedRoutes {
+		if route.keyed && route.match(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// rateLimitHandler wraps h, applying the rate limits of limitedRoutes
+// as overridden by cfg.RateLimits.
+func rateLimitHandler(cfg *env.Config, h http.Handler) (http.Handler, error) {
+	rules, err := ratelimit.ParseRules(cfg.RateLimits)
+	if err != nil {
+		return nil, err
+	}
+	for _, route := range limitedRoutes {
+		rule, ok := rules[route.name]
+		if ok {
+			delete(rules, route.name)
+		} else {
+			defaults, err := ratelimit.ParseRules(route.name + "=" + route.rule)
+			if err != nil {
+				panic(err)
+			}
+			rule = defaults[route.name]
+		}
+		match := route.match
+		if route.keyed {
+			match = func(r *http.Request) bool {
+				_, ok := apikey.Verified(r)
+				return !ok && route.match(r)
+			}
+		}
+		h = ratelimit.New(rule.Limit, rule.KeyFunc()).Handler(h, match)
+	}
+	if err := unknownRoutes("rate limits", rules); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
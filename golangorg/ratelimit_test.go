@@ -0,0 +1,157 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golangorg
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"cloud.google.com/go/datastore"
+	"github.com/matttproud/yourtour/internal/apikey"
+	"github.com/matttproud/yourtour/internal/env"
+)
+
+func TestRateLimitHandler(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	h, err := rateLimitHandler(&env.Config{RateLimits: "fileprint=1/h,play=1/h,tour=1/h,beacon=1/h,feedback=1/h,comments=1/h,quiz=1/h,graphql=1/h,search=1/h,dljson=1/h"}, ok)
+	if err != nil {
+		t.Fatal(err)
+	}
+	do := func(method, url string) int {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(method, url, nil))
+		return w.Code
+	}
+	get := func(url string) int { return do("GET", url) }
+	const fileprint = "/doc/codewalk/?fileprint=/doc/codewalk/urlpoll.go"
+	if code := get(fileprint); code != 200 {
+		t.Fatalf("first fileprint: %d, want 200", code)
+	}
+	if code := get(fileprint); code != http.StatusTooManyRequests {
+		t.Fatalf("second fileprint: %d, want 429", code)
+	}
+	if code := get("/doc/codewalk/urlpoll"); code != 200 {
+		t.Errorf("codewalk page: %d, want 200", code)
+	}
+	if code := do("POST", "/_/compile"); code != 200 {
+		t.Fatalf("first compile: %d, want 200", code)
+	}
+	if code := do("POST", "/_/fmt"); code != http.StatusTooManyRequests {
+		t.Errorf("format after compile: %d, want 429", code)
+	}
+	if code := get("/_/share?id=abc"); code != 200 {
+		t.Errorf("shared snippet: %d, want 200", code)
+	}
+	if code := do("POST", "/tour/api/token"); code != 200 {
+		t.Fatalf("first tour token: %d, want 200", code)
+	}
+	if code := do("PUT", "/tour/api/progress/basics"); code != http.StatusTooManyRequests {
+		t.Errorf("tour progress after token: %d, want 429", code)
+	}
+	if code := get("/tour/api/manifest"); code != 200 {
+		t.Errorf("tour manifest: %d, want 200", code)
+	}
+	if code := do("POST", "/_beacon"); code != 200 {
+		t.Fatalf("first beacon: %d, want 200", code)
+	}
+	if code := do("POST", "/_beacon"); code != http.StatusTooManyRequests {
+		t.Errorf("second beacon: %d, want 429", code)
+	}
+	if code := do("POST", "/feedback"); code != 200 {
+		t.Fatalf("first feedback: %d, want 200", code)
+	}
+	if code := do("POST", "/feedback"); code != http.StatusTooManyRequests {
+		t.Errorf("second feedback: %d, want 429", code)
+	}
+	if code := do("POST", "/comments"); code != 200 {
+		t.Fatalf("first comment: %d, want 200", code)
+	}
+	if code := do("POST", "/comments"); code != http.StatusTooManyRequests {
+		t.Errorf("second comment: %d, want 429", code)
+	}
+	if code := do("POST", "/quiz/sharemem"); code != 200 {
+		t.Fatalf("first quiz submission: %d, want 200", code)
+	}
+	if code := do("POST", "/quiz/other"); code != http.StatusTooManyRequests {
+		t.Errorf("second quiz submission: %d, want 429", code)
+	}
+	if code := get("/quiz/sharemem"); code != 200 {
+		t.Errorf("quiz page: %d, want 200", code)
+	}
+	if code := do("POST", "/graphql"); code != 200 {
+		t.Fatalf("first graphql query: %d, want 200", code)
+	}
+	if code := get("/graphql?query={codewalks{title}}"); code != http.StatusTooManyRequests {
+		t.Errorf("second graphql query: %d, want 429", code)
+	}
+	if code := get("/search?q=go"); code != 200 {
+		t.Fatalf("first search: %d, want 200", code)
+	}
+	if code := get("/search?format=suggest&q=go"); code != http.StatusTooManyRequests {
+		t.Errorf("second search: %d, want 429", code)
+	}
+	if code := get("/opensearch.xml"); code != 200 {
+		t.Errorf("search description: %d, want 200", code)
+	}
+
+	if code := get("/dl/?mode=json"); code != 200 {
+		t.Fatalf("first download list: %d, want 200", code)
+	}
+	if code := get("/dl/?mode=json&include=all"); code != http.StatusTooManyRequests {
+		t.Errorf("second download list: %d, want 429", code)
+	}
+	if code := get("/dl/"); code != 200 {
+		t.Errorf("download page: %d, want 200", code)
+	}
+
+	// Requests with API keys are limited by their keys' quotas instead.
+	const key = "gok_0123456789abcdef"
+	sum := sha256.Sum256([]byte(key))
+	keyed := apikey.NewStore(keyDatastore{hex.EncodeToString(sum[:]): {Owner: "gopher", Limit: "2/h"}}).Handler(h, isAPIRequest)
+	for i, want := range []int{200, 200, http.StatusTooManyRequests} {
+		r := httptest.NewRequest("GET", "/dl/?mode=json", nil)
+		r.Header.Set("Authorization", "Bearer "+key)
+		w := httptest.NewRecorder()
+		keyed.ServeHTTP(w, r)
+		if w.Code != want {
+			t.Errorf("keyed download list #%d: %d, want %d", i+1, w.Code, want)
+		}
+	}
+	w := httptest.NewRecorder()
+	keyed.ServeHTTP(w, httptest.NewRequest("POST", "/graphql?key=gok_unknown", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("graphql query with unknown key: %d, want 401", w.Code)
+	}
+
+	_, err = rateLimitHandler(&env.Config{RateLimits: "uplaod=1/h"}, ok)
+	if err == nil || !strings.Contains(err.Error(), "uplaod") {
+		t.Errorf("unknown route: err = %v, want error naming it", err)
+	}
+}
+
+// A keyDatastore is an apikey.Datastore holding keys by ID.
+type keyDatastore map[string]*apikey.Key
+
+func (d keyDatastore) Get(ctx context.Context, key *datastore.Key, dst any) error {
+	k, ok := d[key.Name]
+	if !ok {
+		return datastore.ErrNoSuchEntity
+	}
+	*dst.(*apikey.Key) = *k
+	return nil
+}
+
+func (d keyDatastore) GetAll(ctx context.Context, q *datastore.Query, dst any) ([]*datastore.Key, error) {
+	return nil, nil
+}
+
+func (d keyDatastore) Put(ctx context.Context, key *datastore.Key, src any) (*datastore.Key, error) {
+	return key, nil
+}
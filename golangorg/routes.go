@@ -0,0 +1,44 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golangorg
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// unknownRoutes returns an error naming the routes of settings,
+// the per-route settings left over once each known route has taken its own,
+// or nil if there are none. What names the settings in the error.
+func unknownRoutes[T any](what string, settings map[string]T) error {
+	if len(settings) == 0 {
+		return nil
+	}
+	var unknown []string
+	for name := range settings {
+		unknown = append(unknown, name)
+	}
+	sort.Strings(unknown)
+	return fmt.Errorf("%s for unknown routes %s", what, strings.Join(unknown, ", "))
+}
+
+// matchHandler returns a handler that serves requests
+// for which match reports true with yes, and others with no.
+func matchHandler(match func(*http.Request) bool, yes, no http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if match(r) {
+			yes.ServeHTTP(w, r)
+			return
+		}
+		no.ServeHTTP(w, r)
+	})
+}
+
+// isCodewalkRequest reports whether r is a request for a codewalk page.
+func isCodewalkRequest(r *http.Request) bool {
+	return strings.HasPrefix(r.URL.Path, "/doc/codewalk/")
+}
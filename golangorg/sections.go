@@ -0,0 +1,80 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golangorg
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/matttproud/yourtour/internal/env"
+	"github.com/matttproud/yourtour/internal/etag"
+	"github.com/matttproud/yourtour/internal/jsonld"
+	"github.com/matttproud/yourtour/internal/lite"
+	"github.com/matttproud/yourtour/internal/redirect"
+	"github.com/matttproud/yourtour/internal/router"
+	"github.com/matttproud/yourtour/internal/search"
+	"github.com/matttproud/yourtour/internal/tenant"
+	"github.com/matttproud/yourtour/internal/vhost"
+	"github.com/matttproud/yourtour/internal/web"
+)
+
+// sectionsSetup mounts the sections that cfg.Sections lists,
+// registering their handlers with rt and adding them to vhosts,
+// so that they take precedence over the sites registered in siteMux.
+// Their layouts may preview the upcoming release notes in goroot.
+// The hosts of the sections are added to hosts, the hosts served.
+func (s *Server) sectionsSetup(cfg *env.Config, vhosts *vhost.Registry, rt *router.Router, goroot fs.FS, hosts map[string]bool) error {
+	mounts, err := tenant.ParseMounts(cfg.Sections)
+	if err != nil {
+		return err
+	}
+	for _, m := range mounts {
+		if err := s.mountSection(vhosts, rt, m, goroot, hosts); err != nil {
+			return fmt.Errorf("section %s: %v", m.Pattern(), err)
+		}
+	}
+	return nil
+}
+
+// mountSection mounts the section m as a virtual host in vhosts,
+// serving its pages, redirects, and search from its directory.
+// It adds the section's redirects to s.sectionRedirects.
+func (s *Server) mountSection(vhosts *vhost.Registry, rt *router.Router, m tenant.Mount, goroot fs.FS, hosts map[string]bool) error {
+	fi, err := os.Stat(m.Dir)
+	if err != nil {
+		return err
+	}
+	if !fi.IsDir() {
+		return fmt.Errorf("%s is not a directory", m.Dir)
+	}
+	root := os.DirFS(m.Dir)
+	fsys := tenant.FS(m.Prefix, root)
+	site := s.newContentSite(m.Host, fsys, goroot)
+	if err := site.CheckTemplates("error.tmpl"); err != nil {
+		return err
+	}
+	var rules redirect.Rules
+	if err := rules.LoadFS(root, tenant.RedirectsFile, m.Prefix); err != nil {
+		return err
+	}
+
+	// The section's pages are tagged with the version of its directory.
+	version := new(etag.Version)
+	version.SetFunc("content", etag.DirVersion(m.Dir))
+
+	s.sectionRedirects = append(s.sectionRedirects, func() []redirect.Entry { return rules.Entries(m.Host) })
+
+	h := vhosts.Mount(m.Host, m.Prefix, site, fsys, rt.With(rules.Handler))
+	h.Router.Handle("", "/", s.contentETags(site, version))
+	search.RegisterHandlers(h)
+	lite.RegisterHandlers(h)
+	jsonld.RegisterHandlers(h)
+	site.AddSuggester(web.PageSuggester(fsys))
+	if m.Host != "" {
+		hosts[m.Host] = true
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golangorg
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/matttproud/yourtour/internal/env"
+	"github.com/matttproud/yourtour/internal/router"
+	"github.com/matttproud/yourtour/internal/vhost"
+)
+
+func TestSections(t *testing.T) {
+	dir := t.TempDir()
+	for name, data := range map[string]string{
+		"tour/site.tmpl":      `tour {{.URL}}: {{.Content}}{{block "layout" .}}{{end}}`,
+		"tour/error.tmpl":     `{{define "layout"}}{{.error}}{{end}}`,
+		"tour/index.md":       "---\ntitle: Welcome\n---\nWelcome to the tour.",
+		"tour/basics.md":      "---\ntitle: Basics\n---\nPackages, variables, and functions.",
+		"tour/redirects.yaml": "/old: /basics\n",
+		"docs/site.tmpl":      `docs {{.URL}}: {{.Content}}{{block "layout" .}}{{end}}`,
+		"docs/error.tmpl":     `{{define "layout"}}{{.error}}{{end}}`,
+		"docs/index.md":       "Docs",
+	} {
+		file := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(file), 0o777); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(data), 0o666); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/", http.NotFoundHandler())
+	var vhosts vhost.Registry
+	cfg := &env.Config{Sections: "/tour=" + filepath.Join(dir, "tour") + ",docs.example.com=" + filepath.Join(dir, "docs")}
+	hosts := make(map[string]bool)
+	if err := testServer().sectionsSetup(cfg, &vhosts, router.New(mux), fstest.MapFS{}, hosts); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		url  string
+		code int
+		want string
+	}{
+		{"https://go.dev/tour/", 200, "tour /tour/: <p>Welcome to the tour.</p>\n"},
+		{"https://go.dev/tour/basics", 200, "tour /tour/basics: <p>Packages, variables, and functions.</p>\n"},
+		{"https://go.dev/tour/old", 301, ""},
+		{"https://go.dev/tour/redirects.yaml", 404, ""},
+		{"https://go.dev/tour/search?q=variables&format=suggest", 200, `["variables",["Basics"],`},
+		{"https://go.dev/doc/", 404, ""},
+		{"https://docs.example.com/", 200, "docs /: <p>Docs</p>\n"},
+		{"https://docs.example.com/tour/", 404, "docs /tour/: "},
+	} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", tt.url, nil))
+		if w.Code != tt.code || !strings.HasPrefix(w.Body.String(), tt.want) {
+			t.Errorf("GET %s = %d %q, want %d %q", tt.url, w.Code, w.Body, tt.code, tt.want)
+		}
+		if tt.code == 301 && w.Header().Get("Location") != "/tour/basics" {
+			t.Errorf("GET %s redirects to %q, want /tour/basics", tt.url, w.Header().Get("Location"))
+		}
+	}
+
+	if h := vhosts.LookupPath("go.dev", "/tour/basics"); h == nil || h.Prefix != "/tour" {
+		t.Errorf("LookupPath(go.dev, /tour/basics) is not the tour section")
+	}
+	if !hosts["docs.example.com"] {
+		t.Errorf("docs.example.com is not a valid host")
+	}
+
+	if err := os.Remove(filepath.Join(dir, "docs/error.tmpl")); err != nil {
+		t.Fatal(err)
+	}
+	for _, bad := range []string{"/tour=" + filepath.Join(dir, "missing"), "/tour=" + filepath.Join(dir, "tour/index.md"), "/docs=" + filepath.Join(dir, "docs")} {
+		if err := testServer().sectionsSetup(&env.Config{Sections: bad}, new(vhost.Registry), router.New(http.NewServeMux()), fstest.MapFS{}, make(map[string]bool)); err == nil {
+			t.Errorf("sectionsSetup(%q) succeeded", bad)
+		}
+	}
+}
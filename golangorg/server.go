@@ -0,0 +1,1709 @@
+// Copyright 2009 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package golangorg serves the go.dev and golang.org web sites.
+package golangorg
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"embed"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"log"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/matttproud/yourtour"
+	"github.com/matttproud/yourtour/internal/accesslog"
+	"github.com/matttproud/yourtour/internal/analytics"
+	"github.com/matttproud/yourtour/internal/announce"
+	"github.com/matttproud/yourtour/internal/apidoc"
+	"github.com/matttproud/yourtour/internal/apikey"
+	"github.com/matttproud/yourtour/internal/authors"
+	"github.com/matttproud/yourtour/internal/blog"
+	"github.com/matttproud/yourtour/internal/canonical"
+	"github.com/matttproud/yourtour/internal/changelog"
+	"github.com/matttproud/yourtour/internal/chaos"
+	"github.com/matttproud/yourtour/internal/clientip"
+	"github.com/matttproud/yourtour/internal/codewalk"
+	"github.com/matttproud/yourtour/internal/comments"
+	"github.com/matttproud/yourtour/internal/compress"
+	"github.com/matttproud/yourtour/internal/debughttp"
+	"github.com/matttproud/yourtour/internal/dl"
+	"github.com/matttproud/yourtour/internal/env"
+	"github.com/matttproud/yourtour/internal/env/boot"
+	"github.com/matttproud/yourtour/internal/etag"
+	"github.com/matttproud/yourtour/internal/excerpt"
+	"github.com/matttproud/yourtour/internal/feedback"
+	"github.com/matttproud/yourtour/internal/gitfs"
+	"github.com/matttproud/yourtour/internal/history"
+	"github.com/matttproud/yourtour/internal/jobs"
+	"github.com/matttproud/yourtour/internal/jsonld"
+	"github.com/matttproud/yourtour/internal/linkcheck"
+	"github.com/matttproud/yourtour/internal/lite"
+	"github.com/matttproud/yourtour/internal/livereload"
+	"github.com/matttproud/yourtour/internal/memcache"
+	"github.com/matttproud/yourtour/internal/mirror"
+	"github.com/matttproud/yourtour/internal/ogimage"
+	"github.com/matttproud/yourtour/internal/pkgdoc"
+	"github.com/matttproud/yourtour/internal/play"
+	"github.com/matttproud/yourtour/internal/private"
+	"github.com/matttproud/yourtour/internal/quiz"
+	"github.com/matttproud/yourtour/internal/redirect"
+	"github.com/matttproud/yourtour/internal/relnotes"
+	"github.com/matttproud/yourtour/internal/reqlog"
+	"github.com/matttproud/yourtour/internal/router"
+	"github.com/matttproud/yourtour/internal/search"
+	"github.com/matttproud/yourtour/internal/short"
+	"github.com/matttproud/yourtour/internal/talks"
+	"github.com/matttproud/yourtour/internal/timeout"
+	"github.com/matttproud/yourtour/internal/tour"
+	"github.com/matttproud/yourtour/internal/updates"
+	"github.com/matttproud/yourtour/internal/vanity"
+	"github.com/matttproud/yourtour/internal/vhost"
+	"github.com/matttproud/yourtour/internal/web"
+	"github.com/matttproud/yourtour/internal/webhooks"
+	"github.com/matttproud/yourtour/internal/webtest"
+	"github.com/matttproud/yourtour/internal/workshop"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"golang.org/x/build/relnote"
+	"golang.org/x/build/repos"
+	"rsc.io/markdown"
+)
+
+//go:embed testdata
+var testdataFS embed.FS
+
+// readyPath is the path of the servers' readiness check.
+const readyPath = "/_readycheck"
+
+// recheckInterval is how often the preflight checks that failed run again.
+const recheckInterval = 30 * time.Second
+
+// jobMetrics are the metrics of the background jobs of all servers,
+// which can be registered only once.
+var jobMetrics = sync.OnceValue(func() jobs.Metrics {
+	metrics, err := jobs.NewPrometheusMetrics(prometheus.DefaultRegisterer)
+	if err != nil {
+		log.Fatalf("jobs.NewPrometheusMetrics: %v", err)
+	}
+	return metrics
+})
+
+// NewServer returns a new Server configured by opts.
+// It reads the current configuration, as set by env.Set,
+// and exits the program if it is invalid.
+// The server's background work runs until Shutdown is called.
+func NewServer(opts ...Option) *Server {
+	s := &Server{
+		cfg:     env.Get(),
+		content: website.Content(),
+		hosts:   maps.Clone(validHosts),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.background, s.stop = context.WithCancel(context.Background())
+	s.jobs = jobs.NewManager(s.background, jobMetrics())
+	if err := s.chaosSetup(s.cfg); err != nil {
+		log.Fatalf("chaos: %v", err)
+	}
+	if s.appEngine {
+		s.datastore, s.cache = s.appEngineClients()
+	}
+	if s.goroot == nil {
+		s.goroot = openGoroot(cmp.Or(s.gorootPath, runtime.GOROOT()))
+	}
+	h := webtest.HandlerWithCheck(s.newHandler(), readyPath, testdataFS, "testdata/*.txt")
+	s.handler = s.readyHandler(h)
+	return s
+}
+
+// Preflight runs the preflight checks of the server's dependencies,
+// registered as it was set up, and returns their report.
+// While any fail, the server's readiness check fails with the report,
+// keeping it out of rotation; the failed checks run again in the
+// background every recheckInterval until they pass.
+// Preflight should be called once, before serving.
+func (s *Server) Preflight(ctx context.Context) *boot.Report {
+	rep := s.checks.Run(ctx, boot.Timeout)
+	s.jobs.Start(jobs.Job{
+		Name: "preflight",
+		Run: func(ctx context.Context) error {
+			was := s.checks.Report()
+			rep := s.checks.Recheck(ctx, boot.Timeout)
+			if !rep.Ready {
+				return errors.New(rep.String())
+			}
+			if !was.Ready {
+				log.Printf("preflight checks: %v", rep)
+			}
+			return nil
+		},
+		Delay: recheckInterval,
+		Every: recheckInterval,
+	})
+	return rep
+}
+
+// readyHandler wraps h, answering the readiness check at readyPath
+// with the latest preflight report if it is not ready.
+func (s *Server) readyHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if rep := s.checks.Report(); rep != nil {
+			boot.Handler(rep, readyPath, h).ServeHTTP(w, r)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// Shutdown stops the server's background work,
+// waiting until jobs in progress stop or ctx is done,
+// and then closes the files and connections the work used.
+func (s *Server) Shutdown(ctx context.Context) error {
+	err := s.jobs.Shutdown(ctx)
+	s.stop()
+	return err
+}
+
+// newHandler returns the handler serving the web sites of s.
+func (s *Server) newHandler() http.Handler {
+	mux := http.NewServeMux()
+
+	// Serve files from _content, falling back to GOROOT.
+	// Content deploys replace the content while serving, through /_content.
+	deployedFS := new(atomicFS)
+	deployedFS.Set(s.content)
+	var contentFS fs.FS = deployedFS
+	if dir := s.cfg.ContentOverlay; dir != "" {
+		var err error
+		if contentFS, err = contentOverlay(contentFS, dir); err != nil {
+			log.Fatalf("content overlay: %v", err)
+		}
+	}
+
+	// Pages rendered from the content are tagged with its version.
+	// Content embedded in the binary is identified by its revision.
+	contentVersion := new(etag.Version)
+	if s.contentDir != "" {
+		contentVersion.SetFunc("content", etag.DirVersion(s.contentDir))
+	} else {
+		contentVersion.Set("content", etag.BuildVersion())
+	}
+	if dir := s.cfg.ContentOverlay; dir != "" {
+		contentVersion.SetFunc("overlay", etag.DirVersion(dir))
+	}
+
+	gorootFS := s.goroot
+
+	// go.dev/wiki serves content from the very latest Git commit of the wiki repo.
+	// Start with the _content/wiki directory as placeholder until Git loads.
+	var wikiFS atomicFS
+	wikiDefault, err := fs.Sub(contentFS, "wiki")
+	if err != nil {
+		log.Fatalf("loading default wiki content: %v", err)
+	}
+	wikiFS.Set(wikiDefault)
+	if s.wiki {
+		s.watchGit(&wikiFS, "https://go.googlesource.com/wiki", func(head string) {
+			contentVersion.Set("wiki", head)
+		})
+	}
+	contentFS = &mountFS{contentFS, "wiki", &wikiFS}
+	s.checks.Register(boot.Content(contentFS))
+
+	// tip.golang.org serves content from the very latest Git commit
+	// of the main Go repo, instead of the one the app is bundled with.
+	// Its pages are untagged until the first commit is installed.
+	var tipGoroot atomicFS
+	tipVersion := new(etag.Version)
+	tipVersion.SetFunc("content", contentVersion.String)
+	tipVersion.Set("goroot", "")
+	var vhosts vhost.Registry
+	rt := router.New(mux)
+	if _, err := s.newSite(&vhosts, rt, "tip.golang.org", contentFS, &tipGoroot, tipVersion); err != nil {
+		log.Fatalf("loading tip site: %v", err)
+	}
+	if s.tip {
+		s.watchGit(&tipGoroot, "https://go.googlesource.com/go", func(head string) {
+			tipVersion.Set("goroot", head)
+		})
+	}
+
+	// beta.golang.org is an old name for tip.
+	mux.Handle("beta.golang.org/", redirectPrefix("https://tip.golang.org/"))
+
+	// By default, golang.org/foo redirects to go.dev/foo.
+	// All the user-facing golang.org subdomains have moved to go.dev subdirectories.
+	// There are some exceptions below, like for golang.org/x.
+	mux.Handle("golang.org/", redirectPrefix("https://go.dev/"))
+	mux.Handle("blog.golang.org/", redirectPrefix("https://go.dev/blog/"))
+	mux.Handle("learn.go.dev/", redirectPrefix("https://go.dev/learn/"))
+	mux.Handle("talks.golang.org/", redirectPrefix("https://go.dev/talks/"))
+	mux.Handle("tour.golang.org/", redirectPrefix("https://go.dev/tour/"))
+
+	// Redirect subdirectory-like domains to the actual subdirectories,
+	// for people whose fingers learn to type go.dev instead of golang.org
+	// but not the rest of the URL schema change.
+	// Note that these domains have to be listed in knownHosts below as well.
+	mux.Handle("blog.go.dev/", redirectPrefix("https://go.dev/blog/"))
+	mux.Handle("play.go.dev/", redirectPrefix("https://go.dev/play/"))
+	mux.Handle("talks.go.dev/", redirectPrefix("https://go.dev/talks/"))
+	mux.Handle("tour.go.dev/", redirectPrefix("https://go.dev/tour/"))
+
+	// m.golang.org is an old shortcut for golang.org mail.
+	// Gmail itself can serve this redirect, but only on HTTP (not HTTPS).
+	// Golang.org's HSTS header tells browsers to use HTTPS for all subdomains,
+	// which broke the redirect.
+	mux.Handle("m.golang.org/", http.RedirectHandler("https://mail.google.com/a/golang.org/", http.StatusMovedPermanently))
+
+	// Redirect synthetic subtrees on tip.golang.org over to go.dev.
+	tipRedirects := []string{
+		"blog",
+		"cl", "change",
+		"design",
+		"dl",
+		"issue", "issues",
+		"s",
+		"talks",
+		"tour",
+	}
+	for _, name := range tipRedirects {
+		mux.Handle("tip.golang.org/"+name+"/", redirectPrefix("https://go.dev/"))
+	}
+	// tip.golang.org/play redirect adds ?v=gotip so the playground defaults to tip.
+	mux.Handle("tip.golang.org/play/", redirectPrefixQuery("https://go.dev/", "v=gotip"))
+
+	// TODO(rsc): The unionFS is a hack until we move the files in a followup CL.
+	siteMux := http.NewServeMux()
+	siteRouter := router.New(siteMux)
+	siteVersion := new(etag.Version)
+	siteVersion.SetFunc("content", contentVersion.String)
+	siteVersion.Set("goroot", gorootVersion(gorootFS))
+	godev, err := s.newSite(&vhosts, siteRouter, "", contentFS, gorootFS, siteVersion)
+	if err != nil {
+		log.Fatalf("newSite go.dev: %v", err)
+	}
+	china, err := s.newSite(&vhosts, siteRouter, "golang.google.cn", contentFS, gorootFS, siteVersion)
+	if err != nil {
+		log.Fatalf("newSite golang.google.cn: %v", err)
+	}
+	if err := s.sectionsSetup(s.cfg, &vhosts, rt, gorootFS, s.hosts); err != nil {
+		log.Fatalf("sections: %v", err)
+	}
+	godevSite, chinaSite := godev.Site, china.Site
+	s.secretsSetup()
+	if s.cache != nil {
+		if s.cache, err = encryptedCache(context.Background(), s.cache); err != nil {
+			log.Fatalf("cache: %v", err)
+		}
+	}
+	if s.datastore != nil {
+		s.datastoreSetup(mux, godevSite)
+	}
+	deployer := &contentDeployer{
+		server:  s,
+		content: deployedFS,
+		goroot:  gorootFS,
+		version: contentVersion,
+	}
+	for _, h := range vhosts.Hosts() {
+		deployer.sites = append(deployer.sites, h.Site)
+	}
+	if token := s.cfg.AdminToken; token != "" {
+		mux.Handle("/debug/config", env.ConfigHandler(token))
+		rt.Handle("POST", "/_content", env.AdminHandler(token, deployer))
+		mux.Handle("/_validate", env.AdminHandler(token, deployer.validateHandler()))
+	}
+	if cfg := s.cfg; cfg.ContentRepo != "" {
+		h := &contentWebhook{
+			deployer: deployer,
+			branch:   "refs/heads/" + cmp.Or(cfg.ContentBranch, "master"),
+			secret: func(ctx context.Context) (string, error) {
+				return env.GetSecrets().Secret(ctx, contentWebhookSecretName)
+			},
+			fetch: gitFetch(cfg.ContentRepo),
+		}
+		if s.datastore != nil {
+			h.queue = webhooks.NewQueue(s.datastore)
+			h.queue.Register(contentWebhookName, true, h.start)
+			s.webhooksSetup(mux, h.queue)
+		}
+		rt.Handle("POST", "/_content/webhook", h)
+	}
+	s.debugSetup(mux)
+	s.flagsSetup(mux)
+	s.announcementsSetup(&vhosts, contentVersion)
+	s.commentsSetup(mux, rt, &vhosts)
+	s.linkcheckSetup(mux, godevSite, contentFS)
+	privateSections := s.privateSetup(&vhosts)
+	s.liveReloadSetup(mux, &vhosts, contentVersion, s.contentDir)
+	// Without a datastore, dl serves its embedded snapshot of release data.
+	// It caches the release data it keeps in a datastore, in memory
+	// if there is no shared cache.
+	var dlDatastore dl.Datastore
+	dlCache := s.cache
+	switch {
+	case s.cfg.DLBackend == "memory":
+		mem, err := dl.NewMemoryDatastore()
+		if err != nil {
+			log.Fatalf("dl: %v", err)
+		}
+		dlDatastore = maintenanceDatastore(s.chaosDatastore(mem))
+	case s.datastore != nil && !s.cfg.FakeDLData:
+		dlDatastore = maintenanceDatastore(s.chaosDatastore(s.datastore))
+	}
+	if dlDatastore != nil && dlCache == nil {
+		dlCache = memcache.NewMemory(0)
+	}
+	if s.cfg.RequireDLSecretKey {
+		s.checks.Register(boot.Secret(env.GetSecrets(), dl.BuilderSecretName))
+	}
+	dl.RegisterHandlers(godev, dlDatastore, dlCache)
+	dl.RegisterHandlers(china, dlDatastore, dlCache)
+	mux.Handle("/graphql", graphqlHandler(godev, dlDatastore, dlCache))
+	downloads := func(ctx context.Context) ([]string, error) {
+		return dlVersions(ctx, dlDatastore, dlCache)
+	}
+	relnotes.RegisterHandlers(godev, downloads)
+	relnotes.RegisterHandlers(china, downloads)
+	search.RegisterHandlers(godev)
+	search.RegisterHandlers(china)
+	blog.RegisterHandlers(godev)
+	blog.RegisterHandlers(china)
+	s.quizSetup(godev, china)
+	apidoc.RegisterHandlers(godev, rt)
+	apidoc.RegisterHandlers(china, rt)
+	// Mirrors keep the release list, which is not a file of the content.
+	mirror.RegisterHandlers(godev, contentFS, siteMux, "/dl/?mode=json&include=all")
+	mirror.RegisterHandlers(china, contentFS, siteMux, "/dl/?mode=json&include=all")
+	mux.Handle("/", siteMux)
+
+	play.RegisterHandlers(mux, godevSite, chinaSite, s.cache)
+	s.jobs.Start(jobs.Job{Name: "play versions", Run: play.RefreshVersions, Every: time.Minute})
+
+	mux.Handle("/explore/", http.StripPrefix("/explore/", redirectPrefix("https://pkg.go.dev/")))
+	if err := blog.RegisterFeeds(mux, "", godevSite); err != nil {
+		log.Fatalf("blog: %v", err)
+	}
+	updates.RegisterHandlers(mux, "", godevSite, contentFS, "doc", "learn", "security", "solutions")
+
+	// Note: Only golang.org/x/, no go.dev/x/.
+	mux.Handle("golang.org/x/", http.HandlerFunc(xHandler))
+
+	redirect.Register(mux)
+	var redirectRules redirect.Rules
+	if err := redirectRules.Load(s.cfg.RedirectsFile); err != nil {
+		log.Fatalf("redirect rules: %v", err)
+	}
+	env.Subscribe(func(c *env.Config) {
+		if err := redirectRules.Load(c.RedirectsFile); err != nil {
+			log.Printf("ERROR reloading redirect rules: %v", err)
+		}
+	})
+	if token := s.cfg.AdminToken; token != "" {
+		mux.Handle("/_redirects", env.AdminHandler(token, redirect.ExportHandler(func() []redirect.Entry {
+			list := append(redirect.Legacy(), redirectRules.Entries("")...)
+			for _, f := range s.sectionRedirects {
+				list = append(list, f()...)
+			}
+			return list
+		})))
+	}
+
+	var vanityPaths vanity.Paths
+	if err := loadVanity(&vanityPaths, s.cfg); err != nil {
+		log.Fatalf("vanity import paths: %v", err)
+	}
+	env.Subscribe(func(c *env.Config) {
+		if err := loadVanity(&vanityPaths, c); err != nil {
+			log.Printf("ERROR reloading vanity import paths: %v", err)
+		}
+	})
+
+	// Note: Using godevSite (non-China) for global mux registration because there's no sharing in talks.
+	// Don't need the hassle of two separate registrations for different domains in siteMux.
+	if err := talks.RegisterHandlers(mux, godevSite, contentFS); err != nil {
+		log.Fatalf("talks: %v", err)
+	}
+	if err := tour.RegisterHandlers(mux, godevSite, s.cache); err != nil {
+		log.Fatalf("tour: %v", err)
+	}
+
+	// siteFor returns the site whose error pages answer r:
+	// that of its virtual host, or else go.dev.
+	siteFor := vhosts.Site
+
+	var h http.Handler = mux
+	h = addCSP(mux)
+	h = privateSections.Handler(h)
+	h = lite.Handler(h)
+	h = vanityPaths.Handler(h)
+	h = chaos.Handler(h, s.chaosRules)
+	h = maintenanceHandler(h, siteFor)
+	h = redirectRules.Handler(h)
+	h, err = shadowHandler(s.cfg, h)
+	if err != nil {
+		log.Fatalf("shadows: %v", err)
+	}
+	h, err = canaryHandler(s.cfg, h)
+	if err != nil {
+		log.Fatalf("canaries: %v", err)
+	}
+	h, err = rateLimitHandler(s.cfg, h)
+	if err != nil {
+		log.Fatalf("rate limits: %v", err)
+	}
+	h = s.apiKeySetup(mux, h)
+	h, err = ipAccessHandler(s.cfg, h)
+	if err != nil {
+		log.Fatalf("IP access lists: %v", err)
+	}
+	h, err = timeoutHandler(s.cfg, h, siteFor)
+	if err != nil {
+		log.Fatalf("timeouts: %v", err)
+	}
+	h = hostEnforcerHandler(h, s.hosts)
+	h = canonical.Handler(h, canonicalHosts)
+	h = hostPathHandler(h, s.hosts)
+	if s.cfg.NoIndex {
+		h = noIndexHandler(h)
+	}
+	h = compress.Handler(h)
+	h = web.Recover(h, siteFor)
+	h, err = sloHandler(s.cfg, mux, h)
+	if err != nil {
+		log.Fatalf("service level objectives: %v", err)
+	}
+	h = s.accessLogHandler(h)
+	h = reqlog.Handler(h)
+	resolver, err := clientResolver(s.cfg)
+	if err != nil {
+		log.Fatalf("trusted proxies: %v", err)
+	}
+	h = resolver.Handler(h)
+	return h
+}
+
+// defaultTimeouts are the handler timeouts by path prefix,
+// in the format read by timeout.ParseRules,
+// which the timeouts setting can override.
+// Profiles stream for as long as they are asked to,
+// release watches end on their own, and live reload channels
+// stay open as long as their pages do.
+const defaultTimeouts = "*=30s,/debug/pprof/=off,/golang.dl.v1.ReleaseService/WatchReleases=off,/ws/livereload=off"
+
+// errTimeout is the error shown for requests that time out.
+var errTimeout = errors.New("the server took too long to respond; please try again")
+
+// timeoutHandler wraps h, bounding the time requests may take
+// as defaultTimeouts and cfg.Timeouts say, and answering requests
+// that run out of time with the error page of siteFor(r).
+func timeoutHandler(cfg *env.Config, h http.Handler, siteFor func(*http.Request) *web.Site) (http.Handler, error) {
+	rules, err := timeout.ParseRules(defaultTimeouts)
+	if err != nil {
+		panic(err)
+	}
+	override, err := timeout.ParseRules(cfg.Timeouts)
+	if err != nil {
+		return nil, err
+	}
+	timedOut := func(w http.ResponseWriter, r *http.Request) {
+		siteFor(r).ServeErrorStatus(w, r, errTimeout, http.StatusServiceUnavailable)
+	}
+	return timeout.Handler(h, rules.Merge(override), timedOut), nil
+}
+
+// accessLogHandler wraps h, logging each request as the AccessLog setting says.
+func (s *Server) accessLogHandler(h http.Handler) http.Handler {
+	cfg := s.cfg
+	if cfg.AccessLog == "" {
+		return h
+	}
+	format, err := accesslog.ParseFormat(cfg.AccessLogFormat)
+	if err != nil {
+		log.Fatalf("access log: %v", err)
+	}
+	var w io.Writer = os.Stdout
+	if cfg.AccessLog != "stdout" {
+		f, err := accesslog.OpenFile(cfg.AccessLog, int64(cfg.AccessLogMaxSize)<<20)
+		if err != nil {
+			log.Fatalf("access log: %v", err)
+		}
+		// Close the file once requests have drained at shutdown.
+		go func() {
+			<-s.background.Done()
+			f.Close()
+		}()
+		w = f
+	}
+	return accesslog.New(w, format).Handler(h)
+}
+
+// clientResolver returns the resolver of client addresses
+// for the proxies cfg.TrustedProxies lists.
+func clientResolver(cfg *env.Config) (*clientip.Resolver, error) {
+	if cfg.OnAppEngine() {
+		// App Engine's front end appends both the client address
+		// and its own to X-Forwarded-For.
+		return &clientip.Resolver{Hops: 2}, nil
+	}
+	trusted, err := clientip.ParseTrusted(cfg.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+	return &clientip.Resolver{
+		Trusted:   trusted,
+		Forwarded: strings.EqualFold(cfg.TrustedProxyHeader, "Forwarded"),
+	}, nil
+}
+
+// debugSetup registers the runtime debugging endpoints in mux,
+// guarded by the admin token and address allowlist,
+// if either is configured.
+func (s *Server) debugSetup(mux *http.ServeMux) {
+	cfg := s.cfg
+	allow, err := debughttp.ParseAllow(cfg.AdminAllowIPs)
+	if err != nil {
+		log.Fatalf("admin allowlist: %v", err)
+	}
+	g := &debughttp.Guard{
+		Token: cfg.AdminToken,
+		Allow: allow,
+	}
+	if g.Enabled() {
+		debughttp.Register(mux, g)
+	}
+}
+
+// noIndexHandler wraps h, asking search engines not to index its responses,
+// so that development and staging servers stay out of search results.
+func noIndexHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Robots-Tag", "noindex, nofollow")
+		h.ServeHTTP(w, r)
+	})
+}
+
+var gorebuild = NewCachedURL("https://gorebuild.storage.googleapis.com/gorebuild.json", 5*time.Minute)
+
+// newSite creates a new site for a given content and goroot file system pair
+// and adds it to vhosts as the virtual host for host,
+// registering its handlers with rt.
+// If host is the empty string, the site is the default host.
+// The site's pages are tagged with version, the version of that pair.
+func (s *Server) newSite(vhosts *vhost.Registry, rt *router.Router, host string, content, goroot fs.FS, version *etag.Version) (*vhost.Host, error) {
+	site, fsys := s.newWebSite(host, content, goroot)
+	docs, err := pkgdoc.NewServer(fsys, site, googleCN)
+	if err != nil {
+		return nil, err
+	}
+
+	h := vhosts.Add(host, site, fsys, rt)
+	h.Version = version.String
+	h.Router.Handle("", "/", s.contentETags(site, version))
+	h.Router.Handle("", "/cmd/", docs)
+	h.Router.Handle("", "/pkg/", docs)
+	codewalk.RegisterHandlers(h)
+	excerpt.RegisterHandlers(h, quiz.Dir)
+	lite.RegisterHandlers(h)
+	workshop.RegisterHandlers(h)
+	authors.RegisterHandlers(h)
+	ogimage.RegisterHandlers(h)
+	changelog.RegisterHandlers(h)
+	jsonld.RegisterHandlers(h)
+	site.AddSuggester(web.PageSuggester(content))
+	site.AddSuggester(web.StaticSuggester(knownRoutes...))
+	return h, nil
+}
+
+// knownRoutes are the paths of site sections that are
+// not pages in the content, for suggesting on 404 pages.
+var knownRoutes = []string{
+	"/api",
+	"/authors/",
+	"/cmd/",
+	"/dl/",
+	"/doc/codewalk/",
+	"/doc/workshop/",
+	"/pkg/",
+	"/play/",
+	"/quiz/",
+	"/ref/mem",
+	"/ref/spec",
+	"/tour/",
+}
+
+// newWebSite returns the web.Site for host serving content and goroot,
+// along with the file system it serves.
+func (s *Server) newWebSite(host string, content, goroot fs.FS) (*web.Site, fs.FS) {
+	fsys := unionFS{content, &hideRootMDFS{&fixSpecsFS{goroot}}}
+	return s.newContentSite(host, fsys, goroot), fsys
+}
+
+// newContentSite returns the web.Site for host serving fsys,
+// with the template functions of the site's layouts,
+// which preview the upcoming release notes in goroot.
+func (s *Server) newContentSite(host string, fsys, goroot fs.FS) *web.Site {
+	site := web.NewSite(fsys)
+	site.SetDevMode(s.cfg.DevMode)
+	site.Funcs(template.FuncMap{
+		"googleAnalytics": func() string { return s.googleAnalytics },
+		"googleCN":        func() bool { return host == "golang.google.cn" },
+		"gorebuild":       gorebuild.Get,
+		"json":            jsonUnmarshal,
+		"newest":          newest,
+		"now":             func() time.Time { return time.Now() },
+		"releases":        func() []*history.Major { return history.Majors },
+		"rfc3339":         parseRFC3339,
+		"section":         section,
+		"version":         func() string { return runtime.Version() },
+		"docNext":         releaseNotePreview{goroot}.MergedFragments,
+	})
+	return site
+}
+
+// dlVersions returns the versions of the releases on the download page,
+// in the order listed there.
+func dlVersions(ctx context.Context, dc dl.Datastore, mc memcache.Cache) ([]string, error) {
+	stable, unstable, archive, err := dl.Releases(ctx, dc, mc)
+	if err != nil {
+		return nil, err
+	}
+	var list []string
+	for _, rels := range [][]dl.Release{unstable, stable, archive} {
+		for _, rel := range rels {
+			list = append(list, rel.Version)
+		}
+	}
+	return list, nil
+}
+
+// releaseNotePreview implements a preview of upcoming release notes.
+type releaseNotePreview struct {
+	goroot fs.FS // goroot provides the doc/next content to use, if any.
+}
+
+// MergedFragments returns Markdown obtained by merging release note fragments
+// found in the doc/next directory in goroot, to preview relnote generate output.
+// An empty string and no error is returned if the doc/next directory doesn't exist.
+func (p releaseNotePreview) MergedFragments() (markdownWithEmbeddedHTML template.HTML, _ error) {
+	next, err := fs.Sub(p.goroot, "doc/next")
+	if err != nil {
+		return "", err
+	}
+	if _, err := fs.Stat(next, "."); os.IsNotExist(err) || errors.Is(err, errNoFileSystem) {
+		// No next release note fragments.
+		return "", nil
+	}
+	doc, err := relnote.Merge(next)
+	if err != nil {
+		return "", fmt.Errorf("relnote.Merge: %v", err)
+	}
+	// Note: It's possible to render doc, a parsed Markdown document with embedded HTML,
+	// into Markdown or HTML. We choose to render to Markdown and let x/website/internal/web
+	// handle the remaining conversion to HTML. This means the rendering is more consistent
+	// with what'll happen when relnote generate output is added as _content/doc/go1.N.md.
+	return template.HTML(markdown.ToMarkdown(doc)), nil
+}
+
+func parseRFC3339(s string) (time.Time, error) {
+	return time.Parse(time.RFC3339, s)
+}
+
+// watchGit starts a background job that watches a Git repo for updates.
+// When a new commit is available, the job downloads the new tree and calls
+// fsys.Set to install the new file system, then installed with the commit hash.
+func (s *Server) watchGit(fsys *atomicFS, repo string, installed func(head string)) {
+	w := &gitWatcher{fsys: fsys, url: repo, installed: installed}
+	s.jobs.Start(jobs.Job{
+		Name:  "watchGit " + repo,
+		Run:   w.update,
+		Every: 5 * time.Minute,
+		Retry: 1 * time.Minute,
+	})
+}
+
+// A gitWatcher installs the latest tree of a Git repo in an atomicFS.
+type gitWatcher struct {
+	fsys *atomicFS
+	url  string
+	repo *gitfs.Repo // nil until connected
+	head gitfs.Hash  // commit installed in fsys; zero until cloned
+
+	installed func(head string) // called with each commit installed
+}
+
+// update installs the repo's HEAD tree in w.fsys if it has changed.
+func (w *gitWatcher) update(ctx context.Context) error {
+	if w.repo == nil {
+		r, err := gitfs.NewRepo(w.url)
+		if err != nil {
+			return err
+		}
+		w.repo = r
+	}
+	if w.head == (gitfs.Hash{}) {
+		h, fsys, err := w.repo.Clone("HEAD")
+		if err != nil {
+			return err
+		}
+		w.fsys.Set(fsys)
+		w.head = h
+		w.installed(h.String())
+		return nil
+	}
+	h, err := w.repo.Resolve("HEAD")
+	if err != nil || h == w.head {
+		return err
+	}
+	fsys, err := w.repo.CloneHash(h)
+	if err != nil {
+		return err
+	}
+	w.fsys.Set(fsys)
+	w.head = h
+	w.installed(h.String())
+	return nil
+}
+
+// appEngineClients connects to the datastore and cache
+// of the App Engine project, as the configuration says.
+func (s *Server) appEngineClients() (*datastore.Client, memcache.Cache) {
+	cfg := s.cfg
+	ctx := context.Background()
+	dc, err := datastore.NewClient(ctx, "")
+	if err != nil {
+		if strings.Contains(err.Error(), "missing project") {
+			log.Fatalf("Missing datastore project. Set the DATASTORE_PROJECT_ID env variable. Use `gcloud beta emulators datastore` to start a local datastore.")
+		}
+		log.Fatalf("datastore.NewClient: %v.", err)
+	}
+	s.checks.Register(boot.Datastore(dc))
+
+	// Config.Validate has checked that a Redis address is set if needed.
+	cache, err := memcache.Open(cfg.CacheBackend, cfg.RedisAddr)
+	if err != nil {
+		log.Fatalf("memcache.Open: %v", err)
+	}
+	if c, ok := cache.(io.Closer); ok {
+		// Stop ring health checks and close connections at shutdown.
+		go func() {
+			<-s.background.Done()
+			c.Close()
+		}()
+	}
+	cache = s.chaosCache(cache)
+	// Split values too large for one cache item across several.
+	cache = memcache.NewChunked(cache, 0)
+	// Fail fast during cache outages rather than timing out on every request.
+	cache = memcache.NewBreaker(cache, 0, 0)
+	metrics, err := memcache.NewPrometheusMetrics(prometheus.DefaultRegisterer)
+	if err != nil {
+		log.Fatalf("memcache.NewPrometheusMetrics: %v", err)
+	}
+	cache = memcache.NewInstrumented(cache, metrics)
+	s.checks.Register(boot.Memcache(cache))
+	return dc, cache
+}
+
+// cacheKeySecretName is the name of the secret holding the base64-encoded
+// AES key, 16, 24, or 32 bytes long, with which values are encrypted
+// in the shared cache.
+const cacheKeySecretName = "cache-key"
+
+// encryptedCache returns cache, with the values stored in it
+// by memcache.CodecClients encrypted with the cache key secret
+// if that is set. It needs the secrets set up by secretsSetup.
+func encryptedCache(ctx context.Context, cache memcache.Cache) (memcache.Cache, error) {
+	secret, err := env.GetSecrets().Secret(ctx, cacheKeySecretName)
+	if errors.Is(err, env.ErrSecretNotFound) {
+		return cache, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("loading %s: %w", cacheKeySecretName, err)
+	}
+	key, err := base64.StdEncoding.DecodeString(secret)
+	if err == nil {
+		_, err = memcache.EncryptedAESGCM(memcache.Gob, key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %v", cacheKeySecretName, err)
+	}
+	return memcache.WrapCodecs(cache, func(c memcache.Codec) memcache.Codec {
+		c, _ = memcache.EncryptedAESGCM(c, key) // key checked above
+		return c
+	}), nil
+}
+
+// datastoreSetup registers the handlers of the features
+// that keep their data in the datastore of s,
+// such as short links, analytics, and feedback.
+func (s *Server) datastoreSetup(mux *http.ServeMux, site *web.Site) {
+	cfg := s.cfg
+	s.googleAnalytics = cfg.Analytics
+	if cfg.ServeMetrics {
+		mux.Handle("/_metrics", promhttp.Handler())
+	}
+
+	// Short links are cached in memory if there is no shared cache.
+	cache := s.cache
+	if cache == nil {
+		cache = memcache.NewMemory(0)
+	}
+	recordHits := short.RegisterHandlers(mux, "", site, s.datastore, cache)
+	s.jobs.Start(jobs.Job{
+		Name: "shortlink hits",
+		Run: func(ctx context.Context) error {
+			// Hits keep counting until datastore writes resume.
+			if env.Enabled(maintenanceFlag) {
+				return nil
+			}
+			return recordHits(ctx)
+		},
+		Every: time.Minute,
+	})
+	if token := cfg.AdminToken; token != "" {
+		api := env.AdminHandler(token, short.APIHandler("/_shortlinks", s.datastore, cache))
+		mux.Handle("/_shortlinks", api)
+		mux.Handle("/_shortlinks/", api)
+	}
+
+	recordEvents := analytics.RegisterHandlers(mux, s.datastore)
+	s.jobs.Start(jobs.Job{
+		Name: "analytics",
+		Run: func(ctx context.Context) error {
+			if env.Enabled(maintenanceFlag) {
+				return nil
+			}
+			return recordEvents(ctx)
+		},
+		Every: time.Minute,
+	})
+	if token := cfg.AdminToken; token != "" {
+		mux.Handle("/_analytics", env.AdminHandler(token, analytics.ReportHandler(s.datastore)))
+		mux.Handle("/_features", env.AdminHandler(token, analytics.FeaturesHandler(site, s.datastore)))
+	}
+
+	var verify feedback.Verifier
+	if cfg.FeedbackCaptchaURL != "" {
+		verify = feedback.SiteVerify(cfg.FeedbackCaptchaURL, func(ctx context.Context) (string, error) {
+			return env.GetSecrets().Secret(ctx, feedback.CaptchaSecretName)
+		})
+	}
+	feedback.RegisterHandlers(mux, s.datastore, verify)
+	if token := cfg.AdminToken; token != "" {
+		mux.Handle("/_feedback", env.AdminHandler(token, feedback.AdminHandler(s.datastore)))
+	}
+
+	log.Println("datastore initialization complete")
+}
+
+// secretsSetup configures where secrets are read from:
+// the environment, then the configured secrets directory,
+// then the project's Secret Manager.
+func (s *Server) secretsSetup() {
+	cfg := s.cfg
+	list := []env.Secrets{env.EnvSecrets(env.SecretEnvPrefix)}
+	if cfg.SecretsDir != "" {
+		list = append(list, env.FileSecrets(cfg.SecretsDir))
+	}
+	if cfg.SecretsProject != "" {
+		sm, err := env.SecretManager(context.Background(), cfg.SecretsProject)
+		if err != nil {
+			log.Fatalf("env.SecretManager: %v", err)
+		}
+		list = append(list, sm)
+	}
+	if s.datastore != nil {
+		// The builder key is still in datastore until it is migrated.
+		list = append(list, dl.BuilderKeySecrets(s.datastore))
+	}
+	env.SetSecrets(env.CachedSecrets(env.FirstSecrets(list...), cfg.SecretsTTL))
+}
+
+// flagsSetup loads the feature flags from the configured source,
+// if any, and registers the endpoint for toggling them.
+func (s *Server) flagsSetup(mux *http.ServeMux) {
+	cfg := s.cfg
+	var src env.FlagSource
+	switch {
+	case cfg.FlagsFile != "":
+		src = env.FileFlags(cfg.FlagsFile)
+	case s.datastore != nil:
+		src = env.DatastoreFlags(s.datastore)
+	default:
+		return
+	}
+	flags := env.NewFlags(src)
+	if err := flags.Refresh(context.Background()); err != nil {
+		log.Printf("ERROR loading feature flags: %v", err)
+	}
+	interval := cfg.FlagsRefresh
+	if interval == 0 {
+		interval = env.FlagRefreshInterval
+	}
+	s.jobs.Start(jobs.Job{
+		Name:   "flags",
+		Run:    flags.Refresh,
+		Delay:  interval,
+		Every:  interval,
+		Jitter: interval / 10,
+	})
+	env.Subscribe(func(*env.Config) {
+		if err := flags.Refresh(context.Background()); err != nil {
+			log.Printf("ERROR reloading feature flags: %v", err)
+		}
+	})
+	env.SetFlags(flags)
+	if cfg.AdminToken != "" {
+		mux.Handle("/_flags", flags.Handler(cfg.AdminToken))
+	}
+}
+
+// announcementsSetup loads the site-wide announcements from the configured
+// source, if any, and shows them on the pages of every host in vhosts,
+// adding them to version, which tags those pages.
+func (s *Server) announcementsSetup(vhosts *vhost.Registry, version *etag.Version) {
+	cfg := s.cfg
+	var src announce.Source
+	switch {
+	case cfg.AnnouncementsFile != "":
+		src = announce.FileSource(cfg.AnnouncementsFile)
+	case s.datastore != nil:
+		src = announce.DatastoreSource(s.datastore)
+	default:
+		return
+	}
+	board := announce.NewBoard(src)
+	if err := board.Refresh(context.Background()); err != nil {
+		log.Printf("ERROR loading announcements: %v", err)
+	}
+	s.jobs.Start(jobs.Job{
+		Name:   "announcements",
+		Run:    board.Refresh,
+		Delay:  announce.RefreshInterval,
+		Every:  announce.RefreshInterval,
+		Jitter: announce.RefreshInterval / 10,
+	})
+	version.SetFunc("announcements", board.Version)
+	s.announcements = board
+	for _, h := range vhosts.Hosts() {
+		h.Site.AddPageData(board.PageData)
+	}
+}
+
+// quizSetup sets up the quizzes of the hosts, storing their scores
+// in datastore if there is one and signing completion tokens
+// with the quiz token key secret if it is set.
+func (s *Server) quizSetup(hosts ...*vhost.Host) {
+	var dc quiz.Datastore
+	if s.datastore != nil {
+		dc = maintenanceDatastore(s.datastore)
+	}
+	key := func(ctx context.Context) (string, error) {
+		key, err := env.GetSecrets().Secret(ctx, quiz.KeySecretName)
+		if errors.Is(err, env.ErrSecretNotFound) {
+			return "", nil
+		}
+		return key, err
+	}
+	for _, h := range hosts {
+		quiz.RegisterHandlers(h, dc, key)
+	}
+}
+
+// apiKeySetup serves the API for managing API keys at /_apikeys
+// and returns a handler wrapping h that checks the keys presented
+// in requests for the keyed routes of limitedRoutes,
+// or h itself if there is no datastore to hold keys.
+func (s *Server) apiKeySetup(mux *http.ServeMux, h http.Handler) http.Handler {
+	if s.datastore == nil {
+		return h
+	}
+	keys := apikey.NewStore(s.datastore)
+	s.jobs.Start(jobs.Job{
+		Name:  "API key usage",
+		Run:   keys.Flush,
+		Every: time.Minute,
+	})
+	if token := s.cfg.AdminToken; token != "" {
+		api := env.AdminHandler(token, keys.APIHandler("/_apikeys"))
+		mux.Handle("/_apikeys", api)
+		mux.Handle("/_apikeys/", api)
+	}
+	return keys.Handler(h, isAPIRequest)
+}
+
+// webhooksSetup starts retrying the failed events in q
+// and serves the API for inspecting them at /_webhooks.
+func (s *Server) webhooksSetup(mux *http.ServeMux, q *webhooks.Queue) {
+	s.jobs.Start(jobs.Job{
+		Name: "webhook retries",
+		Run: func(ctx context.Context) error {
+			if env.Enabled(maintenanceFlag) {
+				return nil
+			}
+			return q.Retry(ctx)
+		},
+		Delay: time.Minute,
+		Every: time.Minute,
+	})
+	if token := s.cfg.AdminToken; token != "" {
+		api := env.AdminHandler(token, q.APIHandler("/_webhooks"))
+		mux.Handle("/_webhooks", api)
+		mux.Handle("/_webhooks/", api)
+	}
+}
+
+// linkcheckSetup starts the periodic check of the external links
+// in the pages of site, whose content is fsys, recording their statuses
+// in datastore, and registers the report of the failing links.
+func (s *Server) linkcheckSetup(mux *http.ServeMux, site *web.Site, fsys fs.FS) {
+	if s.datastore == nil {
+		return
+	}
+	c := linkcheck.NewChecker(site, fsys, "blogfeed.tmpl", s.datastore)
+	s.jobs.Start(jobs.Job{
+		Name: "link check",
+		Run: func(ctx context.Context) error {
+			if env.Enabled(maintenanceFlag) {
+				return nil
+			}
+			return c.Check(ctx)
+		},
+		Delay:  10 * time.Minute,
+		Every:  linkcheck.Interval,
+		Retry:  time.Hour,
+		Jitter: linkcheck.Interval / 10,
+	})
+	if token := s.cfg.AdminToken; token != "" {
+		mux.Handle("/_links", env.AdminHandler(token, linkcheck.ReportHandler(s.datastore)))
+	}
+}
+
+// commentsSetup sets up readers' comments on the pages
+// of the sections of the sites that cfg.CommentSections lists,
+// storing them in datastore, and the API for moderating them.
+func (s *Server) commentsSetup(mux *http.ServeMux, rt *router.Router, vhosts *vhost.Registry) {
+	cfg := s.cfg
+	if s.datastore == nil || cfg.CommentSections == "" {
+		return
+	}
+	cs, err := comments.NewServer(s.datastore, cfg.CommentSections)
+	if err != nil {
+		log.Fatalf("comments: %v", err)
+	}
+	cs.RegisterHandlers(rt)
+	if token := cfg.AdminToken; token != "" {
+		api := env.AdminHandler(token, cs.APIHandler("/_comments"))
+		mux.Handle("/_comments", api)
+		mux.Handle("/_comments/", api)
+	}
+	for _, h := range vhosts.Hosts() {
+		h.Site.AddPageData(cs.PageData)
+	}
+	s.untaggedSections = cs.Sections()
+}
+
+// privateSetup sets up the private sections that cfg.PrivateSections lists
+// on the sites of vhosts, whose readers sign in with the private sections
+// password secret, returning the gate guarding them.
+func (s *Server) privateSetup(vhosts *vhost.Registry) *private.Gate {
+	gate, err := private.NewGate(s.cfg.PrivateSections, func(ctx context.Context) (string, error) {
+		password, err := env.GetSecrets().Secret(ctx, private.PasswordSecretName)
+		if errors.Is(err, env.ErrSecretNotFound) {
+			return "", nil
+		}
+		return password, err
+	})
+	if err != nil {
+		log.Fatalf("private sections: %v", err)
+	}
+	if len(gate.Sections()) == 0 {
+		return gate
+	}
+	for _, h := range vhosts.Hosts() {
+		if h.Prefix != "" {
+			// Readers sign in on the host the section is mounted on.
+			h.Site.SetPrivate(gate.Private)
+			continue
+		}
+		gate.RegisterHandlers(h)
+	}
+	return gate
+}
+
+// liveReloadSetup sets up, on development servers, the channel
+// telling browsers previewing pages to reload them when the content
+// directory or content overlay served live changes.
+func (s *Server) liveReloadSetup(mux *http.ServeMux, vhosts *vhost.Registry, version *etag.Version, contentDir string) {
+	cfg := s.cfg
+	var dirs []string
+	if contentDir != "" {
+		dirs = append(dirs, contentDir)
+	}
+	if cfg.ContentOverlay != "" {
+		dirs = append(dirs, cfg.ContentOverlay)
+	}
+	if !cfg.DevMode || len(dirs) == 0 {
+		return
+	}
+	w := livereload.NewWatcher(dirs...)
+	version.SetFunc("livereload", w.Version)
+	s.jobs.Start(jobs.Job{Name: "livereload", Run: w.Check, Every: livereload.Interval})
+	mux.Handle(livereload.Path, w)
+	for _, h := range vhosts.Hosts() {
+		h.Site.AddPageData(w.PageData)
+	}
+}
+
+type fmtResponse struct {
+	Body  string
+	Error string
+}
+
+// validHosts are the hosts served as themselves by default,
+// to which the hosts of sections and WithHosts are added.
+var validHosts = map[string]bool{
+	"golang.org":       true,
+	"golang.google.cn": true,
+	"beta.golang.org":  true,
+	"blog.golang.org":  true,
+	"m.golang.org":     true,
+	"talks.golang.org": true,
+	"tip.golang.org":   true,
+	"tour.golang.org":  true,
+
+	"go.dev":       true,
+	"blog.go.dev":  true,
+	"learn.go.dev": true,
+	"play.go.dev":  true,
+	"talks.go.dev": true,
+	"tour.go.dev":  true,
+}
+
+// canonicalHosts maps alternate names for the sites in validHosts
+// to the names they are served under.
+var canonicalHosts = canonical.Hosts{
+	"www.go.dev":           "go.dev",
+	"www.golang.org":       "golang.org",
+	"www.golang.google.cn": "golang.google.cn",
+}
+
+// hostEnforcerHandler redirects http://foo.golang.org/bar to https://golang.org/bar.
+// It also forces all requests coming from China for golang.org to use golang.google.cn.
+func hostEnforcerHandler(h http.Handler, hosts map[string]bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		isHTTPS := r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" || r.URL.Scheme == "https"
+		defaultHost := "go.dev"
+		host := strings.ToLower(r.Host)
+		isValidHost := hosts[host]
+
+		if googleCN(r) && !strings.HasSuffix(host, "google.cn") {
+			// golang.google.cn is the only web site in China.
+			defaultHost = "golang.google.cn"
+			isValidHost = strings.ToLower(r.Host) == defaultHost
+		}
+
+		if !isHTTPS || !isValidHost {
+			r.URL.Scheme = "https"
+			if isValidHost {
+				r.URL.Host = r.Host
+			} else {
+				r.URL.Host = defaultHost
+			}
+			http.Redirect(w, r, r.URL.String(), http.StatusFound)
+			return
+		}
+
+		w.Header().Set("Strict-Transport-Security", "max-age=31536000; includeSubDomains; preload")
+		h.ServeHTTP(w, r)
+	})
+}
+
+// hostPathHandler infers the host from the first element of the URL path
+// when the actual host is a testing domain (localhost or *.appspot.com).
+// It also rewrites the output HTML and Location headers in that case to
+// link back to URLs on the test site.
+func hostPathHandler(h http.Handler, hosts map[string]bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Host != "localhost" && !strings.HasPrefix(r.Host, "localhost:") && !strings.HasSuffix(r.Host, ".appspot.com") {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		elem, rest := strings.TrimPrefix(r.URL.Path, "/"), ""
+		if i := strings.Index(elem, "/"); i >= 0 {
+			if elem[:i] == "tour" {
+				// The Angular router serving /tour/ fails badly when it sees /go.dev/tour/.
+				// Just take http://localhost/tour/ as meaning /go.dev/tour/ instead of redirecting.
+				elem, rest = "go.dev", elem
+			} else {
+				elem, rest = elem[:i], elem[i+1:]
+			}
+		}
+		if !hosts[elem] {
+			u := "/go.dev" + r.URL.EscapedPath()
+			if r.URL.RawQuery != "" {
+				u += "?" + r.URL.RawQuery
+			}
+			http.Redirect(w, r, u, http.StatusTemporaryRedirect)
+			return
+		}
+
+		r.Host = elem
+		r.URL.Scheme = "https"
+		r.URL.Host = elem
+		r.URL.Path = "/" + rest
+
+		log.Print(r.URL.String())
+
+		lw := &linkRewriter{ResponseWriter: w, host: r.Host, hosts: hosts, tour: strings.HasPrefix(r.URL.Path, "/tour/")}
+		h.ServeHTTP(lw, r)
+		lw.Flush()
+	})
+}
+
+// A linkRewriter is a ResponseWriter that rewrites links in HTML output.
+// It rewrites relative links /foo to be /host/foo, and it rewrites any link
+// https://h/foo, where h is in hosts, to be /h/foo. This corrects the
+// links to have the right form for the test server.
+type linkRewriter struct {
+	http.ResponseWriter
+	host  string
+	hosts map[string]bool // the hosts served
+	tour  bool            // is this go.dev/tour/?
+	buf   []byte
+	ct    string // content-type
+}
+
+func (r *linkRewriter) WriteHeader(code int) {
+	loc := r.Header().Get("Location")
+	delete(r.Header(), "Content-Length") // we might change the content
+	if strings.HasPrefix(loc, "/") && !strings.HasPrefix(loc, "/tour/") {
+		r.Header().Set("Location", "/"+r.host+loc)
+	} else if u, _ := url.Parse(loc); u != nil && r.hosts[u.Host] {
+		r.Header().Set("Location", "/"+u.Host+"/"+strings.TrimPrefix(u.Path, "/")+u.RawQuery)
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *linkRewriter) Write(data []byte) (int, error) {
+	if r.ct == "" {
+		ct := r.Header().Get("Content-Type")
+		if ct == "" {
+			// Note: should use first 512 bytes, but first write is fine for our purposes.
+			ct = http.DetectContentType(data)
+		}
+		r.ct = ct
+	}
+	if !strings.HasPrefix(r.ct, "text/html") {
+		return r.ResponseWriter.Write(data)
+	}
+	r.buf = append(r.buf, data...)
+	return len(data), nil
+}
+
+func (r *linkRewriter) Flush() {
+	var repl []string
+	if !r.tour {
+		repl = []string{
+			`href="/`, `href="/` + r.host + `/`,
+			`src="/`, `src="/` + r.host + `/`,
+		}
+	}
+	for host := range r.hosts {
+		repl = append(repl, `href="https://`+host, `href="/`+host)
+		repl = append(repl, `src="https://`+host, `src="/`+host)
+	}
+	strings.NewReplacer(repl...).WriteString(r.ResponseWriter, string(r.buf))
+	r.buf = nil
+}
+
+func xHandler(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.URL.Path, "/x/") {
+		// Shouldn't happen if handler is registered correctly.
+		http.Redirect(w, r, "https://pkg.go.dev/golang.org/x", http.StatusTemporaryRedirect)
+		return
+	}
+	proj, suffix := strings.TrimPrefix(r.URL.Path, "/x/"), ""
+	if i := strings.Index(proj, "/"); i != -1 {
+		proj, suffix = proj[:i], proj[i:]
+	}
+	if proj == "" {
+		http.Redirect(w, r, "https://pkg.go.dev/golang.org/x", http.StatusTemporaryRedirect)
+		return
+	}
+	repo, ok := repos.ByGerritProject[proj]
+	if !ok || !strings.HasPrefix(repo.ImportPath, "golang.org/x/") {
+		http.NotFound(w, r)
+		return
+	}
+	if suffix == "/info/refs" && strings.HasPrefix(r.URL.Query().Get("service"), "git-") && repo.GoGerritProject != "" {
+		// Someone is running 'git clone https://golang.org/x/repo'.
+		// We want the eventual git checkout to have the right origin (go.googlesource.com)
+		// and not just keep hitting golang.org all the time.
+		// A redirect would work for this git command but not record the result.
+		// Instead, print a useful error for the user.
+		http.Error(w, fmt.Sprintf("Use 'git clone https://go.googlesource.com/%s' instead.", repo.GoGerritProject), http.StatusNotFound)
+		return
+	}
+	data := struct {
+		Proj   string // Gerrit project ("net", "sys", etc)
+		Suffix string // optional "/path" for requests like /x/PROJ/path
+	}{proj, suffix}
+	if err := xTemplate.Execute(w, data); err != nil {
+		log.Println("xHandler:", err)
+	}
+}
+
+var xTemplate = template.Must(template.New("x").Parse(`<!DOCTYPE html>
+<html lang="en">
+<title>The Go Programming Language</title>
+<meta http-equiv="Content-Type" content="text/html; charset=utf-8"/>
+<meta name="go-import" content="golang.org/x/{{.Proj}} git https://go.googlesource.com/{{.Proj}}">
+<meta http-equiv="refresh" content="0; url=https://pkg.go.dev/golang.org/x/{{.Proj}}{{.Suffix}}">
+</head>
+<body>
+<a href="https://pkg.go.dev/golang.org/x/{{.Proj}}{{.Suffix}}">Redirecting to documentation...</a>
+</body>
+</html>
+`))
+
+//go:embed vanity.yaml
+var vanityYAML []byte
+
+// loadVanity loads the vanity import paths into p:
+// those in c.VanityFile if set, or else the built-in list in vanity.yaml.
+func loadVanity(p *vanity.Paths, c *env.Config) error {
+	if c.VanityFile != "" {
+		return p.Load(c.VanityFile)
+	}
+	return p.Set(vanityYAML)
+}
+
+var _ fs.ReadDirFS = unionFS{}
+
+// A unionFS is an FS presenting the union of the file systems in the slice.
+// If multiple file systems provide a particular file, Open uses the FS listed earlier in the slice.
+// If multiple file systems provide a particular directory, ReadDir presents the
+// concatenation of all the directories listed in the slice (with duplicates removed).
+type unionFS []fs.FS
+
+func (fsys unionFS) Open(name string) (fs.File, error) {
+	var errOut error
+	for _, sub := range fsys {
+		f, err := sub.Open(name)
+		if err == nil {
+			// Note: Should technically check for directory
+			// and return a synthetic directory that merges
+			// reads from all the matching directories,
+			// but all the directory reads in internal/godoc
+			// come from fsys.ReadDir, which does that for us.
+			// So we can ignore direct f.ReadDir calls.
+			return f, nil
+		}
+		if errOut == nil {
+			errOut = err
+		}
+	}
+	return nil, errOut
+}
+
+func (fsys unionFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	var all []fs.DirEntry
+	var seen map[string]bool // seen[name] is true if name is listed in all; lazily initialized
+	var errOut error
+	for _, sub := range fsys {
+		list, err := fs.ReadDir(sub, name)
+		if err != nil {
+			errOut = err
+		}
+		if len(all) == 0 {
+			all = append(all, list...)
+		} else {
+			if seen == nil {
+				// Initialize seen only after we get two different directory listings.
+				seen = make(map[string]bool)
+				for _, d := range all {
+					seen[d.Name()] = true
+				}
+			}
+			for _, d := range list {
+				name := d.Name()
+				if !seen[name] {
+					seen[name] = true
+					all = append(all, d)
+				}
+			}
+		}
+	}
+	if len(all) > 0 {
+		return all, nil
+	}
+	return nil, errOut
+}
+
+// contentOverlay returns an FS serving the files in the directory dir,
+// falling back to content for files not found there.
+// Directory listings include the files of both.
+func contentOverlay(content fs.FS, dir string) (fs.FS, error) {
+	fi, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+	return unionFS{os.DirFS(dir), content}, nil
+}
+
+// A fixSpecsFS is an FS mapping /ref/mem.html and /ref/spec.html to
+// /doc/go_mem.html and /doc/go_spec.html.
+var _ fs.FS = &fixSpecsFS{}
+
+type fixSpecsFS struct {
+	fs fs.FS
+}
+
+func (fsys fixSpecsFS) Open(name string) (fs.File, error) {
+	switch name {
+	case "ref/mem.html", "ref/spec.html":
+		if f, err := fsys.fs.Open(name); err == nil {
+			// Let Go distribution win if they move.
+			return f, nil
+		}
+		// Otherwise fall back to doc/go_*.html
+		name = "doc/go_" + strings.TrimPrefix(name, "ref/")
+		return fsys.fs.Open(name)
+
+	case "doc/go_mem.html", "doc/go_spec.html":
+		data := []byte("<!--{\n\t\"Redirect\": \"/ref/" + strings.TrimPrefix(strings.TrimSuffix(name, ".html"), "doc/go_") + "\"\n}-->\n")
+		return &memFile{path.Base(name), bytes.NewReader(data)}, nil
+	}
+
+	return fsys.fs.Open(name)
+}
+
+// A hideRootMDFS is an FS that hides *.md files in the root directory.
+// We use this to hide the Go repository's CONTRIBUTING.md,
+// README.md, and SECURITY.md. The last is particularly problematic
+// when running locally on a Mac, because it can be opened as
+// security.md, which takes priority over _content/security.html.
+// Same for wiki.
+type hideRootMDFS struct {
+	fs fs.FS
+}
+
+func (fsys hideRootMDFS) Open(name string) (fs.File, error) {
+	if !strings.Contains(name, "/") && strings.HasSuffix(name, ".md") {
+		return nil, errors.New(".md file not available")
+	}
+	switch name {
+	case "wiki/README.md", "wiki/CONTRIBUTING.md", "wiki/LICENSE", "wiki/PATENTS", "wiki/codereview.cfg":
+		return nil, errors.New("wiki meta file not available")
+	}
+	return fsys.fs.Open(name)
+}
+
+// A seekableFS is an FS wrapper that makes every file seekable
+// by reading it entirely into memory when it is opened and then
+// serving read operations (including seek) from the memory copy.
+type seekableFS struct {
+	fs fs.FS
+}
+
+func (s *seekableFS) Open(name string) (fs.File, error) {
+	f, err := s.fs.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if info.IsDir() {
+		return f, nil
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	var sf seekableFile
+	sf.File = f
+	sf.Reset(data)
+	return &sf, nil
+}
+
+// A seekableFile is a fs.File augmented by an in-memory copy of the file data to allow use of Seek.
+type seekableFile struct {
+	bytes.Reader
+	fs.File
+}
+
+// Read calls f.Reader.Read.
+// Both f.Reader and f.File have Read methods - a conflict - so f inherits neither.
+// This method calls the one we want.
+func (f *seekableFile) Read(b []byte) (int, error) {
+	return f.Reader.Read(b)
+}
+
+// A memFile is an fs.File implementation backed by in-memory data.
+type memFile struct {
+	name string
+	*bytes.Reader
+}
+
+func (f *memFile) Stat() (fs.FileInfo, error) { return f, nil }
+func (f *memFile) Name() string               { return f.name }
+func (*memFile) Mode() fs.FileMode            { return 0o444 }
+func (*memFile) ModTime() time.Time           { return time.Time{} }
+func (*memFile) IsDir() bool                  { return false }
+func (*memFile) Sys() interface{}             { return nil }
+func (*memFile) Close() error                 { return nil }
+
+// An atomicFS is an fs.FS value safe for reading from multiple goroutines
+// as well as updating (assigning a different fs.FS to use in future read requests).
+type atomicFS struct {
+	v atomic.Value
+}
+
+// Set sets the file system used by future calls to Open.
+func (a *atomicFS) Set(fsys fs.FS) {
+	a.v.Store(&fsys)
+}
+
+// A mountFS is a root FS with a second FS mounted at a specific location.
+type mountFS struct {
+	old fs.FS  // root file system
+	dir string // mount point
+	new fs.FS  // fs mounted on dir
+}
+
+func (m *mountFS) Open(name string) (fs.File, error) {
+	if name == m.dir {
+		return m.new.Open(".")
+	}
+	if strings.HasPrefix(name, m.dir) && len(name) > len(m.dir) && name[len(m.dir)] == '/' {
+		return m.new.Open(name[len(m.dir)+1:])
+	}
+	return m.old.Open(name)
+}
+
+var errNoFileSystem = errors.New("no file system")
+
+// Open returns fsys.Open(name) where fsys is the file system passed to the most recent call to Set.
+// If there has been no call to Set, Open returns errNoFileSystem, an error with text “no file system”.
+func (a *atomicFS) Open(name string) (fs.File, error) {
+	fsys, _ := a.v.Load().(*fs.FS)
+	if fsys == nil {
+		return nil, &fs.PathError{Path: name, Op: "open", Err: errNoFileSystem}
+	}
+	return (*fsys).Open(name)
+}
+
+func redirectPrefix(prefix string) http.Handler {
+	return redirectPrefixQuery(prefix, "")
+}
+
+func redirectPrefixQuery(prefix, query string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		url := strings.TrimSuffix(prefix, "/") + "/" + strings.TrimPrefix(r.URL.Path, "/")
+		if r.URL.RawQuery != "" || query != "" {
+			url += "?"
+			if r.URL.RawQuery != "" {
+				url += r.URL.RawQuery
+				if query != "" {
+					url += "&"
+				}
+			}
+			url += query
+		}
+		http.Redirect(w, r, url, http.StatusMovedPermanently)
+	})
+}
+
+type CachedURL struct {
+	url     string
+	timeout time.Duration
+
+	mu      sync.Mutex
+	data    []byte
+	err     error
+	etag    string
+	updated time.Time
+}
+
+func NewCachedURL(url string, timeout time.Duration) *CachedURL {
+	return &CachedURL{url: url, timeout: timeout}
+}
+
+func (c *CachedURL) Get() (data []byte, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if time.Since(c.updated) < c.timeout {
+		return c.data, c.err
+	}
+	defer func() {
+		c.updated = time.Now()
+		c.data, c.err = data, err
+	}()
+
+	cli := &http.Client{Timeout: 60 * time.Second}
+	req, err := http.NewRequest("GET", c.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.etag != "" {
+		req.Header.Set("If-None-Match", c.etag)
+	}
+	resp, err := cli.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("loading rebuild report JSON: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified {
+		// Unmodified.
+		log.Printf("checked %s - unmodified", c.url)
+		return c.data, c.err
+	}
+	log.Printf("reloading %s", c.url)
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("loading rebuild report JSON: %v", resp.Status)
+	}
+	c.etag = resp.Header.Get("Etag")
+	data, err = io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("loading rebuild report JSON: %v", err)
+	}
+	return data, nil
+}
+
+func jsonUnmarshal(data []byte) (any, error) {
+	var x any
+	err := json.Unmarshal(data, &x)
+	return x, err
+}
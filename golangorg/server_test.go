@@ -2,10 +2,12 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
-package main
+package golangorg
 
 import (
 	"bytes"
+	"context"
+	"encoding/base64"
 	"fmt"
 	"io/fs"
 	"net/http/httptest"
@@ -18,13 +20,21 @@ import (
 	"testing"
 
 	"github.com/matttproud/yourtour"
+	"github.com/matttproud/yourtour/internal/env"
 	"github.com/matttproud/yourtour/internal/history"
+	"github.com/matttproud/yourtour/internal/memcache"
 	"github.com/matttproud/yourtour/internal/webtest"
 	"golang.org/x/net/html"
 )
 
+// testServer returns a Server with an empty configuration
+// that has not been set up, for testing the parts of its setup.
+func testServer() *Server {
+	return &Server{cfg: new(env.Config)}
+}
+
 func TestWeb(t *testing.T) {
-	h := NewHandler("../../_content", runtime.GOROOT())
+	h := NewServer(WithContentDir("../_content"), WithGoroot(runtime.GOROOT()))
 
 	files, err := filepath.Glob("testdata/*.txt")
 	if err != nil {
@@ -75,7 +85,7 @@ Lines:
 }
 
 func TestAll(t *testing.T) {
-	h := NewHandler("../../_content", runtime.GOROOT())
+	h := NewServer(WithContentDir("../_content"), WithGoroot(runtime.GOROOT()))
 
 	get := func(url string) (code int, body string, err error) {
 		if url == "https://go.dev/rebuild" {
@@ -107,6 +117,7 @@ func TestAll(t *testing.T) {
 
 		// Support files not meant to be served directly.
 		"/doc/articles/wiki/",
+		"/doc/relnotes/", // fragments merged into release note pages
 		"/talks/2013/highperf/",
 		"/talks/2016/refactor/",
 		"/tour/static/partials/",
@@ -134,7 +145,7 @@ func TestAll(t *testing.T) {
 				strings.HasSuffix(path, ".html") ||
 				strings.HasSuffix(path, ".article") ||
 				strings.HasSuffix(path, ".slide") {
-				if !strings.Contains(path, "/talks/") {
+				if !strings.Contains(path, "/talks/") && !strings.Contains(path, "/doc/workshop/") {
 					siteURL = strings.TrimSuffix(siteURL, pathpkg.Ext(path))
 				}
 				if strings.HasSuffix(siteURL, "/index") {
@@ -237,7 +248,7 @@ func TestAll(t *testing.T) {
 		})
 	}
 
-	testTree("../../_content", "https://go.dev")
+	testTree("../_content", "https://go.dev")
 }
 
 // fixURL returns the corrected URL for u,
@@ -329,3 +340,88 @@ func TestReleaseNotesHaveDate(t *testing.T) {
 		})
 	}
 }
+
+func TestContentOverlay(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "doc/codewalk"), 0o777); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "doc/codewalk/new.xml"), []byte("edited"), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "doc/codewalk/sharemem.xml"), []byte("edited"), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	fsys, err := contentOverlay(website.Content(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"doc/codewalk/new.xml", "doc/codewalk/sharemem.xml"} {
+		if data, err := fs.ReadFile(fsys, name); err != nil || string(data) != "edited" {
+			t.Errorf("ReadFile(%s) = %q, %v, want overlay file", name, data, err)
+		}
+	}
+	want, err := fs.ReadFile(website.Content(), "doc/codewalk/codewalk.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, err := fs.ReadFile(fsys, "doc/codewalk/codewalk.xml"); err != nil || !bytes.Equal(data, want) {
+		t.Errorf("ReadFile(doc/codewalk/codewalk.xml) did not fall back to embedded content: %v", err)
+	}
+	list, err := fs.ReadDir(fsys, "doc/codewalk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, d := range list {
+		names = append(names, d.Name())
+	}
+	if s := strings.Join(names, " "); !strings.Contains(s, "new.xml") || !strings.Contains(s, "codewalk.xml") {
+		t.Errorf("ReadDir(doc/codewalk) = %v, want overlay and embedded files", names)
+	}
+
+	if _, err := contentOverlay(website.Content(), filepath.Join(dir, "missing")); err == nil {
+		t.Errorf("contentOverlay of missing directory succeeded")
+	}
+}
+
+func TestEncryptedCache(t *testing.T) {
+	ctx := context.Background()
+	defer env.SetSecrets(nil)
+	env.SetSecrets(env.EnvSecrets(env.SecretEnvPrefix))
+	mem := memcache.NewMemory(0)
+	set := func(cache memcache.Cache) []byte {
+		t.Helper()
+		if err := memcache.NewCodecClient(cache, memcache.JSON).Set(ctx, &memcache.Item{Key: "k", Object: "gopher"}); err != nil {
+			t.Fatal(err)
+		}
+		b, _ := mem.Get(ctx, "k")
+		return b
+	}
+
+	cache, err := encryptedCache(ctx, mem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := set(cache); string(b) != `"gopher"` {
+		t.Errorf("without key, stored %q, want plaintext", b)
+	}
+
+	t.Setenv("GOLANGORG_SECRET_CACHE_KEY", base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{7}, 32)))
+	cache, err = encryptedCache(ctx, mem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := set(cache); bytes.Contains(b, []byte("gopher")) {
+		t.Errorf("with key, stored %q, want ciphertext", b)
+	}
+	var v string
+	if err := memcache.NewCodecClient(cache, memcache.JSON).Get(ctx, "k", &v); err != nil || v != "gopher" {
+		t.Errorf("with key, Get = %q, %v, want gopher", v, err)
+	}
+
+	t.Setenv("GOLANGORG_SECRET_CACHE_KEY", base64.StdEncoding.EncodeToString([]byte("short")))
+	if _, err := encryptedCache(ctx, mem); err == nil {
+		t.Errorf("encryptedCache with 5-byte key succeeded")
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golangorg
+
+import (
+	"net/http"
+
+	"github.com/matttproud/yourtour/internal/codewalk"
+	"github.com/matttproud/yourtour/internal/env"
+	"github.com/matttproud/yourtour/internal/rates"
+	"github.com/matttproud/yourtour/internal/shadow"
+)
+
+// A shadowedRoute is a route whose handler is being rewritten.
+// Requests for it can be mirrored to the rewrite, alt(h),
+// to compare its replies with those of the handler in service, h.
+type shadowedRoute struct {
+	name  string
+	match func(*http.Request) bool
+	alt   func(h http.Handler) http.Handler
+}
+
+// shadowedRoutes lists the routes with rewrites to try,
+// which the shadows setting enables by name.
+// Add a route here while its rewrite is in progress,
+// and remove it once the rewrite has replaced the old handler.
+var shadowedRoutes = []shadowedRoute{
+	{
+		// Codewalk pages are served from the codewalk bundle.
+		// Mirroring them to pages rendered from the descriptions
+		// checks that the bundle renders them as they are.
+		name:  "codewalk",
+		match: isCodewalkRequest,
+		alt:   codewalk.Unbundled,
+	},
+}
+
+// shadowHandler wraps h, mirroring requests for shadowedRoutes
+// to their rewrites at the rates set by cfg.Shadows.
+func shadowHandler(cfg *env.Config, h http.Handler) (http.Handler, error) {
+	rs, err := rates.Parse(cfg.Shadows)
+	if err != nil {
+		return nil, err
+	}
+	next := h
+	for _, route := range shadowedRoutes {
+		rate, ok := rs[route.name]
+		if !ok {
+			continue
+		}
+		delete(rs, route.name)
+		h = matchHandler(route.match, shadow.New(route.name, next, route.alt(next), rate), h)
+	}
+	if err := unknownRoutes("shadows", rs); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
@@ -0,0 +1,21 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golangorg
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/matttproud/yourtour/internal/env"
+)
+
+func TestShadowHandler(t *testing.T) {
+	if _, err := shadowHandler(&env.Config{Shadows: "nosuchroute=5%,codewalk=5%"}, http.NotFoundHandler()); err == nil || err.Error() != "shadows for unknown routes nosuchroute" {
+		t.Errorf("shadowHandler with shadow for unknown route = %v, want error naming it", err)
+	}
+	if _, err := shadowHandler(&env.Config{Shadows: "codewalk=5%"}, http.NotFoundHandler()); err != nil {
+		t.Errorf("shadowHandler with shadow for codewalk: %v", err)
+	}
+}
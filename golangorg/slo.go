@@ -0,0 +1,121 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golangorg
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/matttproud/yourtour/internal/env"
+	"github.com/matttproud/yourtour/internal/slo"
+)
+
+// A routeClass is a class of routes with a service level objective.
+type routeClass struct {
+	name      string
+	match     func(*http.Request) bool
+	objective string // default objective, as for slo.ParseObjectives
+}
+
+// routeClasses lists the classes of routes whose error budgets are tracked,
+// which the slos setting can override by name. A request is in the
+// first class it matches.
+var routeClasses = []routeClass{
+	{
+		// Release automation and toolchains poll the list of downloads.
+		name:      "dljson",
+		match:     func(r *http.Request) bool { return r.URL.Path == "/dl/" && r.URL.Query().Get("mode") == "json" },
+		objective: "99.95%/500ms",
+	},
+	{
+		name:      "dl",
+		match:     func(r *http.Request) bool { return r.URL.Path == "/dl" || strings.HasPrefix(r.URL.Path, "/dl/") },
+		objective: "99.9%/1s",
+	},
+	{
+		name:      "api",
+		match:     func(r *http.Request) bool { return r.URL.Path == "/graphql" || strings.HasPrefix(r.URL.Path, "/api/") },
+		objective: "99.9%/1s",
+	},
+	{
+		// Playground requests wait for the playground backend to run programs.
+		name:      "play",
+		match:     isPlayRequest,
+		objective: "99%/10s",
+	},
+	{
+		name:      "pages",
+		match:     func(r *http.Request) bool { return true },
+		objective: "99.9%/1s",
+	},
+}
+
+// streamingPrefixes are the path prefixes of the routes that stream
+// for as long as their clients want, which are not counted.
+var streamingPrefixes = []string{
+	"/golang.dl.v1.ReleaseService/WatchReleases",
+	"/ws/livereload",
+}
+
+// routeClassOf returns the name of the class of routes of r,
+// or "" if r is not counted against an objective: if it streams,
+// or is for debugging or administration, below /debug/ or /_
+// (but not the playground endpoints, below /_/).
+func routeClassOf(r *http.Request) string {
+	p := r.URL.Path
+	if strings.HasPrefix(p, "/debug/") || strings.HasPrefix(p, "/_") && !strings.HasPrefix(p, "/_/") {
+		return ""
+	}
+	for _, prefix := range streamingPrefixes {
+		if strings.HasPrefix(p, prefix) {
+			return ""
+		}
+	}
+	for _, c := range routeClasses {
+		if c.match(r) {
+			return c.name
+		}
+	}
+	return ""
+}
+
+// sloHandler wraps h, counting the requests it serves against the
+// objectives of routeClasses as overridden by cfg.SLOs,
+// and registers the report of their error budgets at /_slo on mux.
+func sloHandler(cfg *env.Config, mux *http.ServeMux, h http.Handler) (http.Handler, error) {
+	override, err := slo.ParseObjectives(cfg.SLOs)
+	if err != nil {
+		return nil, err
+	}
+	objs := make(slo.Objectives)
+	for _, c := range routeClasses {
+		o, ok := override[c.name]
+		if ok {
+			delete(override, c.name)
+		} else {
+			defaults, err := slo.ParseObjectives(c.name + "=" + c.objective)
+			if err != nil {
+				panic(err)
+			}
+			o = defaults[c.name]
+		}
+		objs[c.name] = o
+	}
+	if len(override) > 0 {
+		var unknown []string
+		for name := range override {
+			unknown = append(unknown, name)
+		}
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("objectives for unknown route classes %s", strings.Join(unknown, ", "))
+	}
+	t := slo.New(objs)
+	if token := cfg.AdminToken; token != "" {
+		mux.Handle("/_slo", env.AdminHandler(token, t.ReportHandler()))
+	}
+	return t.Handler(h, routeClassOf), nil
+}
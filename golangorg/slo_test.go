@@ -0,0 +1,66 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golangorg
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/matttproud/yourtour/internal/env"
+	"github.com/matttproud/yourtour/internal/slo"
+)
+
+func TestRouteClassOf(t *testing.T) {
+	for _, tt := range []struct {
+		method, url, class string
+	}{
+		{"GET", "/dl/?mode=json", "dljson"},
+		{"GET", "/dl/", "dl"},
+		{"GET", "/dl", "dl"},
+		{"GET", "/dl/go1.22.0.src.tar.gz", "dl"},
+		{"POST", "/graphql", "api"},
+		{"GET", "/api/excerpt", "api"},
+		{"POST", "/_/compile", "play"},
+		{"GET", "/doc/", "pages"},
+		{"GET", "/_/share?id=abc", "pages"},
+		{"GET", "/_analytics", ""},
+		{"POST", "/_beacon", ""},
+		{"GET", "/debug/pprof/profile", ""},
+		{"GET", "/ws/livereload", ""},
+		{"POST", "/golang.dl.v1.ReleaseService/WatchReleases", ""},
+	} {
+		if got := routeClassOf(httptest.NewRequest(tt.method, tt.url, nil)); got != tt.class {
+			t.Errorf("routeClassOf(%s %s) = %q, want %q", tt.method, tt.url, got, tt.class)
+		}
+	}
+}
+
+func TestSLOHandler(t *testing.T) {
+	if _, err := sloHandler(&env.Config{SLOs: "blog=99%/1s"}, http.NewServeMux(), http.NotFoundHandler()); err == nil {
+		t.Errorf("sloHandler with objective for unknown class succeeded")
+	}
+
+	mux := http.NewServeMux()
+	broken := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { http.Error(w, "broken", 500) })
+	h, err := sloHandler(&env.Config{AdminToken: "secret", SLOs: "pages=0.5/1s"}, mux, broken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/doc/", nil))
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/_slo?class=pages", nil)
+	r.Header.Set("Authorization", "Bearer secret")
+	mux.ServeHTTP(w, r)
+	var rep slo.Report
+	if err := json.Unmarshal(w.Body.Bytes(), &rep); err != nil {
+		t.Fatalf("/_slo: %d %v\n%s", w.Code, err, w.Body)
+	}
+	if len(rep.Classes) != 1 || rep.Classes[0].Target != 0.5 || rep.Classes[0].Windows[0].Errors != 1 || rep.Classes[0].AvailabilityBudget != -1 {
+		t.Errorf("/_slo = %+v, want pages with 1 error, budget overspent", rep)
+	}
+}
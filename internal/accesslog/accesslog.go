@@ -0,0 +1,285 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package accesslog logs one line for every HTTP request served,
+// in Common Log Format or as JSON.
+//
+// Besides the usual request and response details, each line records
+// the time taken to serve the request and any annotations added by
+// the handlers that served it, such as whether a cache was hit:
+//
+//	accesslog.Annotate(r.Context(), "cache", "hit")
+//
+// Lines leave out the values of query parameters that carry
+// credentials or personal information, such as key and token.
+package accesslog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/matttproud/yourtour/internal/clientip"
+	"github.com/matttproud/yourtour/internal/reqlog"
+)
+
+// A Format is the format of access log lines.
+type Format string
+
+const (
+	// Common is the Common Log Format used by many web servers,
+	// followed by the latency and any annotations as key=value pairs:
+	//
+	//	192.0.2.1 - - [14/Oct/2026:13:55:36 +0000] "GET /dl/ HTTP/1.1" 200 2326 12.5ms request_id=4f2a cache=hit
+	Common Format = "common"
+
+	// JSON writes each request as a JSON object on its own line,
+	// with the fields of an Entry.
+	JSON Format = "json"
+)
+
+// ParseFormat returns the Format named by s.
+// The empty string means Common.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(s); f {
+	case "":
+		return Common, nil
+	case Common, JSON:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown access log format %q; want common or json", s)
+}
+
+// An Entry describes one request, as logged in the JSON format.
+// The values of sensitiveParams in its URI and Referer are redacted.
+type Entry struct {
+	Time        time.Time         `json:"time"`
+	Remote      string            `json:"remote"`
+	Method      string            `json:"method"`
+	URI         string            `json:"uri"`
+	Proto       string            `json:"proto"`
+	Status      int               `json:"status"`
+	Bytes       int64             `json:"bytes"`
+	Latency     float64           `json:"latency_seconds"`
+	RequestID   string            `json:"request_id,omitempty"`
+	Referer     string            `json:"referer,omitempty"`
+	UserAgent   string            `json:"user_agent,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// A Logger writes access log lines to an io.Writer.
+// It is safe for concurrent use.
+type Logger struct {
+	format Format
+	now    func() time.Time
+
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// New returns a Logger writing lines in the given format to w.
+func New(w io.Writer, format Format) *Logger {
+	return &Logger{format: format, now: time.Now, w: w}
+}
+
+type contextKey struct{}
+
+// annotations are the annotations for one request.
+type annotations struct {
+	mu sync.Mutex
+	m  map[string]string
+}
+
+// Annotate records the annotation key=value in the access log line
+// for the request with context ctx, replacing any earlier value for key.
+// It does nothing if the request is not being logged.
+func Annotate(ctx context.Context, key, value string) {
+	a, ok := ctx.Value(contextKey{}).(*annotations)
+	if !ok {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.m == nil {
+		a.m = make(map[string]string)
+	}
+	a.m[key] = value
+}
+
+// Handler returns a handler that serves requests with h
+// and logs each one when it completes.
+func (l *Logger) Handler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := l.now()
+		a := new(annotations)
+		sw := &statusWriter{ResponseWriter: w}
+		defer func() {
+			// Log requests aborted by a panic too, then let it continue.
+			v := recover()
+			if v != nil && sw.status == 0 {
+				sw.status = http.StatusInternalServerError
+			}
+			a.mu.Lock()
+			e := l.entry(r, sw, start, a.m)
+			a.mu.Unlock()
+			l.log(e)
+			if v != nil {
+				panic(v)
+			}
+		}()
+		h.ServeHTTP(sw, r.WithContext(context.WithValue(r.Context(), contextKey{}, a)))
+	})
+}
+
+func (l *Logger) entry(r *http.Request, sw *statusWriter, start time.Time, annotations map[string]string) *Entry {
+	status := sw.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	return &Entry{
+		Time:        start,
+		Remote:      clientip.FromRequest(r),
+		Method:      r.Method,
+		URI:         redact(r.RequestURI),
+		Proto:       r.Proto,
+		Status:      status,
+		Bytes:       sw.bytes,
+		Latency:     l.now().Sub(start).Seconds(),
+		RequestID:   reqlog.ID(r.Context()),
+		Referer:     redact(r.Referer()),
+		UserAgent:   r.UserAgent(),
+		Annotations: annotations,
+	}
+}
+
+// sensitiveParams are the query parameters whose values are credentials
+// or personal information, which access log lines leave out.
+var sensitiveParams = []string{
+	"access_token",
+	"captcha",
+	"key",
+	"password",
+	"secret",
+	"sig",
+	"signature",
+	"token",
+	"user",
+}
+
+// redact returns the URL u with the values of its sensitiveParams,
+// in any case, replaced by [redacted].
+func redact(u string) string {
+	base, query, ok := strings.Cut(u, "?")
+	if !ok {
+		return u
+	}
+	params := strings.Split(query, "&")
+	for i, kv := range params {
+		k, _, ok := strings.Cut(kv, "=")
+		if name, err := url.QueryUnescape(k); ok && err == nil && slices.Contains(sensitiveParams, strings.ToLower(name)) {
+			params[i] = k + "=[redacted]"
+		}
+	}
+	return base + "?" + strings.Join(params, "&")
+}
+
+func (l *Logger) log(e *Entry) {
+	var line []byte
+	if l.format == JSON {
+		var err error
+		if line, err = json.Marshal(e); err != nil {
+			log.Printf("ERROR access log: %v", err)
+			return
+		}
+		line = append(line, '\n')
+	} else {
+		line = []byte(e.common())
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.w.Write(line); err != nil {
+		log.Printf("ERROR access log: %v", err)
+	}
+}
+
+// common returns e in the Common format.
+func (e *Entry) common() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s - - [%s] %s %d ",
+		dash(e.Remote), e.Time.Format("02/Jan/2006:15:04:05 -0700"),
+		strconv.Quote(e.Method+" "+e.URI+" "+e.Proto), e.Status)
+	if e.Bytes == 0 {
+		b.WriteString("-")
+	} else {
+		b.WriteString(strconv.FormatInt(e.Bytes, 10))
+	}
+	b.WriteString(" " + time.Duration(e.Latency*float64(time.Second)).Round(time.Microsecond).String())
+	if e.RequestID != "" {
+		b.WriteString(" request_id=" + e.RequestID)
+	}
+	var keys []string
+	for k := range e.Annotations {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v := e.Annotations[k]
+		if v == "" || strings.ContainsAny(v, " =") || strconv.Quote(v) != `"`+v+`"` {
+			v = strconv.Quote(v)
+		}
+		b.WriteString(" " + k + "=" + v)
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// A statusWriter records the status and size of a reply.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if w.status == 0 && code >= 200 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+func (w *statusWriter) Flush() {
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap returns the underlying ResponseWriter,
+// for use by http.ResponseController.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,192 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package accesslog
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matttproud/yourtour/internal/reqlog"
+)
+
+// newTestLogger returns a Logger writing to buf
+// whose requests each take 12.5ms, starting at a fixed time.
+func newTestLogger(buf *bytes.Buffer, format Format) *Logger {
+	l := New(buf, format)
+	now := time.Date(2026, time.October, 14, 13, 55, 36, 0, time.UTC)
+	l.now = func() time.Time {
+		t := now
+		now = now.Add(12500 * time.Microsecond)
+		return t
+	}
+	return l
+}
+
+func serve(h http.Handler, target string) {
+	r := httptest.NewRequest("GET", target, nil)
+	r.RemoteAddr = "192.0.2.1:1234"
+	r.Header.Set(reqlog.Header, "4f2a")
+	r.Header.Set("User-Agent", "test/1.0")
+	reqlog.Handler(h).ServeHTTP(httptest.NewRecorder(), r)
+}
+
+var dlPage = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	Annotate(r.Context(), "cache", "hit")
+	Annotate(r.Context(), "note", "two words")
+	io.WriteString(w, "hello, world\n")
+})
+
+func TestCommon(t *testing.T) {
+	var buf bytes.Buffer
+	h := newTestLogger(&buf, Common).Handler(dlPage)
+	serve(h, "/dl/?mode=json")
+	serve(newTestLogger(&buf, Common).Handler(http.NotFoundHandler()), "/missing")
+	serve(newTestLogger(&buf, Common).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})), "/empty")
+
+	want := `192.0.2.1 - - [14/Oct/2026:13:55:36 +0000] "GET /dl/?mode=json HTTP/1.1" 200 13 12.5ms request_id=4f2a cache=hit note="two words"
+192.0.2.1 - - [14/Oct/2026:13:55:36 +0000] "GET /missing HTTP/1.1" 404 19 12.5ms request_id=4f2a
+192.0.2.1 - - [14/Oct/2026:13:55:36 +0000] "GET /empty HTTP/1.1" 200 - 12.5ms request_id=4f2a
+`
+	if got := buf.String(); got != want {
+		t.Errorf("log:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	var buf bytes.Buffer
+	serve(newTestLogger(&buf, JSON).Handler(dlPage), "/dl/")
+	var e Entry
+	if err := json.Unmarshal(buf.Bytes(), &e); err != nil {
+		t.Fatalf("%v in %q", err, buf.String())
+	}
+	want := Entry{
+		Time:        time.Date(2026, time.October, 14, 13, 55, 36, 0, time.UTC),
+		Remote:      "192.0.2.1",
+		Method:      "GET",
+		URI:         "/dl/",
+		Proto:       "HTTP/1.1",
+		Status:      200,
+		Bytes:       13,
+		Latency:     0.0125,
+		RequestID:   "4f2a",
+		UserAgent:   "test/1.0",
+		Annotations: map[string]string{"cache": "hit", "note": "two words"},
+	}
+	if e.Time.Equal(want.Time) {
+		e.Time = want.Time
+	}
+	got, _ := json.Marshal(e)
+	wantJSON, _ := json.Marshal(want)
+	if !bytes.Equal(got, wantJSON) {
+		t.Errorf("entry:\n%s\nwant:\n%s", got, wantJSON)
+	}
+	if !strings.HasSuffix(buf.String(), "}\n") || strings.Count(buf.String(), "\n") != 1 {
+		t.Errorf("log %q is not one JSON line", buf.String())
+	}
+}
+
+func TestRedact(t *testing.T) {
+	for _, tt := range []struct {
+		uri, want string
+	}{
+		{"/dl/", "/dl/"},
+		{"/dl/?mode=json", "/dl/?mode=json"},
+		{"/api/shorten?key=s3cret&url=x", "/api/shorten?key=[redacted]&url=x"},
+		{"/quiz/verify?Token=abc.def", "/quiz/verify?Token=[redacted]"},
+		{"/_flags?user=gopher&password=hunter2&user=other", "/_flags?user=[redacted]&password=[redacted]&user=[redacted]"},
+		{"/x?%6Bey=s3cret&monkey=ok&key", "/x?%6Bey=[redacted]&monkey=ok&key"},
+	} {
+		if got := redact(tt.uri); got != tt.want {
+			t.Errorf("redact(%q) = %q, want %q", tt.uri, got, tt.want)
+		}
+	}
+
+	var buf bytes.Buffer
+	r := httptest.NewRequest("GET", "/s/new?key=s3cret", nil)
+	r.Header.Set("Referer", "https://go.dev/play/?token=abc")
+	newTestLogger(&buf, JSON).Handler(http.NotFoundHandler()).ServeHTTP(httptest.NewRecorder(), r)
+	if strings.Contains(buf.String(), "s3cret") || strings.Contains(buf.String(), "abc") {
+		t.Errorf("log %q shows credentials", buf.String())
+	}
+}
+
+func TestPanic(t *testing.T) {
+	var buf bytes.Buffer
+	h := newTestLogger(&buf, Common).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+	func() {
+		defer func() {
+			if v := recover(); v != http.ErrAbortHandler {
+				t.Errorf("recovered %v, want http.ErrAbortHandler", v)
+			}
+		}()
+		serve(h, "/boom")
+	}()
+	if !strings.Contains(buf.String(), `"GET /boom HTTP/1.1" 500 -`) {
+		t.Errorf("log %q does not record the aborted request", buf.String())
+	}
+}
+
+func TestAnnotateUnlogged(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	Annotate(r.Context(), "cache", "hit") // must not panic
+}
+
+func TestFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "access.log")
+	if err := os.WriteFile(path, []byte("old\n"), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	f, err := OpenFile(path, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	lines := []string{"line1\n", "line2\n", "line3\n"}
+	for i := 0; i < Backups+2; i++ {
+		for _, line := range lines {
+			if _, err := io.WriteString(f, line); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	read := func(name string) string {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+	// "old\n" plus "line1\n" fit in 10 bytes; every later line starts a new file.
+	if got := read("access.log"); got != "line3\n" {
+		t.Errorf("access.log = %q, want %q", got, "line3\n")
+	}
+	if got := read("access.log.1"); got != "line2\n" {
+		t.Errorf("access.log.1 = %q, want %q", got, "line2\n")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "access.log.6")); !os.IsNotExist(err) {
+		t.Errorf("kept more than %d backups", Backups)
+	}
+	if got := read("access.log.5"); got != "line1\n" {
+		t.Errorf("access.log.5 = %q, want %q", got, "line1\n")
+	}
+
+	f.Close()
+	if _, err := f.Write([]byte("x")); err == nil {
+		t.Errorf("Write after Close succeeded")
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package accesslog
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// DefaultMaxSize is the size at which a File is rotated
+// when opened with a maximum size of zero.
+const DefaultMaxSize = 100 << 20
+
+// Backups is the number of rotated files a File keeps.
+const Backups = 5
+
+// A File is a log file that rotates itself when it grows too large:
+// the file at path is renamed to path.1, path.1 to path.2,
+// and so on up to path.5, and a new file is started at path.
+// It is safe for concurrent use.
+type File struct {
+	path    string
+	maxSize int64
+
+	mu   sync.Mutex
+	f    *os.File
+	size int64
+}
+
+// OpenFile opens the log file at path for appending,
+// creating it if necessary, and returns a File that rotates it
+// when a write would make it larger than maxSize bytes.
+// If maxSize is zero, the size is DefaultMaxSize.
+func OpenFile(path string, maxSize int64) (*File, error) {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxSize
+	}
+	f := &File{path: path, maxSize: maxSize}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *File) open() error {
+	file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	f.f = file
+	f.size = info.Size()
+	return nil
+}
+
+// Write appends b to the file, rotating it first if necessary.
+// If rotation fails, b is still appended to the current file.
+func (f *File) Write(b []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.f == nil {
+		return 0, os.ErrClosed
+	}
+	var rotateErr error
+	if f.size > 0 && f.size+int64(len(b)) > f.maxSize {
+		rotateErr = f.rotate()
+		if f.f == nil {
+			return 0, rotateErr
+		}
+	}
+	n, err := f.f.Write(b)
+	f.size += int64(n)
+	if err == nil {
+		err = rotateErr
+	}
+	return n, err
+}
+
+// rotate renames the current file and its backups and starts a new file.
+// If renaming fails, it carries on with the current file.
+func (f *File) rotate() error {
+	if err := f.f.Close(); err != nil {
+		return err
+	}
+	f.f = nil
+	var err error
+	for i := Backups; i >= 1 && err == nil; i-- {
+		old := f.path
+		if i > 1 {
+			old = fmt.Sprintf("%s.%d", f.path, i-1)
+		}
+		if err = os.Rename(old, fmt.Sprintf("%s.%d", f.path, i)); os.IsNotExist(err) {
+			err = nil
+		}
+	}
+	if openErr := f.open(); openErr != nil {
+		return openErr
+	}
+	return err
+}
+
+// Close closes the file.
+func (f *File) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.f == nil {
+		return nil
+	}
+	err := f.f.Close()
+	f.f = nil
+	return err
+}
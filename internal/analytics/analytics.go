@@ -0,0 +1,318 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package analytics counts page views and codewalk steps
+// reported by the site's pages, for an administrative report.
+//
+// The counts are collected without cookies and without keeping
+// client addresses. A visitor is recognized within a day only by
+// a hash of their address and user agent with a random salt
+// that is replaced, and forgotten, at midnight UTC,
+// so visits on different days cannot be linked.
+// Each server instance keeps its own salt, so a visitor served
+// by several instances on the same day is counted more than once;
+// visitor counts are an upper bound.
+// Browsers sending Do Not Track or Global Privacy Control are not counted.
+//
+// Besides the events that pages report, the server's handlers count
+// release downloads, with CountDownload, and searches finding nothing,
+// with CountEmptySearch, for the feature metrics report of FeaturesHandler.
+//
+// Counts are kept in memory and added periodically to daily totals
+// in the datastore, one entity per day, event kind, path, and step.
+package analytics
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"cloud.google.com/go/datastore"
+	"github.com/matttproud/yourtour/internal/clientip"
+)
+
+const (
+	kind       = "AnalyticsCount"
+	beaconPath = "/_beacon"
+	dayFormat  = "2006-01-02"
+)
+
+// The event kinds.
+const (
+	kindView     = "view"     // a page was viewed
+	kindStep     = "step"     // a codewalk step was shown
+	kindDownload = "download" // a release file was downloaded
+	kindSearch   = "search"   // a search found no pages
+)
+
+// A Count is the number of events of a kind reported
+// for a path (and, for codewalk steps, a step) in a day.
+type Count struct {
+	Day  string // UTC date, as 2006-01-02
+	Kind string // view, step, download, or search
+	Path string // for downloads, the release version; for searches, the query
+	Step int    // codewalk step, counting from 0; 0 for views
+
+	Events   int64
+	Visitors int64 // distinct visitors, as described in the package doc
+}
+
+// Datastore is the part of a *datastore.Client used by the collector.
+type Datastore interface {
+	Get(ctx context.Context, key *datastore.Key, dst any) error
+	GetAll(ctx context.Context, q *datastore.Query, dst any) ([]*datastore.Key, error)
+	Put(ctx context.Context, key *datastore.Key, src any) (*datastore.Key, error)
+}
+
+var _ Datastore = (*datastore.Client)(nil)
+
+// Limits on what the collector accepts and remembers.
+const (
+	maxBeacon  = 1 << 10 // bytes in a beacon request
+	maxPath    = 200     // bytes in a reported path
+	maxStep    = 1000    // codewalk steps
+	maxPending = 10000   // counts not yet recorded
+	maxSeen    = 1 << 18 // visitor hashes remembered per day
+)
+
+// A countKey identifies a Count.
+type countKey struct {
+	day, kind, path string
+	step            int
+}
+
+// name returns the datastore key name for the Count identified by k.
+func (k countKey) name() string {
+	return k.day + "|" + k.kind + "|" + strconv.Itoa(k.step) + "|" + k.path
+}
+
+// A delta is an amount to add to a Count.
+type delta struct {
+	events, visitors int64
+}
+
+// A collector counts events until they are recorded in the datastore.
+type collector struct {
+	datastore Datastore
+	now       func() time.Time
+
+	mu      sync.Mutex
+	day     string   // day of salt and seen
+	salt    [16]byte // salt for visitor hashes on day
+	seen    map[[16]byte]bool
+	pending map[countKey]delta
+}
+
+func newCollector(dc Datastore) *collector {
+	return &collector{datastore: dc, now: time.Now}
+}
+
+// RegisterHandlers registers the beacon handler,
+// to which the site's pages report events, on mux.
+// It returns a function that adds the events counted since its last call
+// to the daily totals in the datastore, for running periodically.
+// From then on, the events passed to CountDownload and CountEmptySearch
+// are counted too.
+func RegisterHandlers(mux *http.ServeMux, dc Datastore) (record func(context.Context) error) {
+	c := newCollector(dc)
+	mux.HandleFunc(beaconPath, c.beaconHandler)
+	observer.Store(c)
+	return c.record
+}
+
+// observer is the collector of the events counted by the server's handlers,
+// or nil if RegisterHandlers has not been called.
+var observer atomic.Pointer[collector]
+
+// CountDownload counts a download of a file of the release version,
+// answered by r.
+func CountDownload(r *http.Request, version string) {
+	observe(r, beacon{Kind: kindDownload, Path: version})
+}
+
+// CountEmptySearch counts a search for query, answered by r,
+// that found no pages. The query is counted in lower case,
+// with runs of spaces reduced to one.
+func CountEmptySearch(r *http.Request, query string) {
+	observe(r, beacon{Kind: kindSearch, Path: strings.Join(strings.Fields(strings.ToLower(query)), " ")})
+}
+
+// observe counts the event b, observed while answering r,
+// unless r is a HEAD request or must not be counted.
+func observe(r *http.Request, b beacon) {
+	c := observer.Load()
+	if c == nil || r.Method != "GET" || b.Path == "" || len(b.Path) > maxPath || !utf8.ValidString(b.Path) || untracked(r) {
+		return
+	}
+	c.add(b, clientip.FromRequest(r)+"\x00"+r.UserAgent())
+}
+
+// A beacon is an event reported by a page.
+type beacon struct {
+	Kind string `json:"kind"`
+	Path string `json:"path"`
+	Step int    `json:"step"`
+}
+
+// check reports whether b is a valid event.
+func (b *beacon) check() error {
+	switch b.Kind {
+	case kindView:
+		if b.Step != 0 {
+			return errors.New("step in view event")
+		}
+	case kindStep:
+		if !strings.HasPrefix(b.Path, "/doc/codewalk/") {
+			return errors.New("step event for non-codewalk page")
+		}
+		if b.Step < 0 || b.Step >= maxStep {
+			return fmt.Errorf("invalid step %d", b.Step)
+		}
+	default:
+		return fmt.Errorf("unknown event kind %q", b.Kind)
+	}
+	if !strings.HasPrefix(b.Path, "/") || len(b.Path) > maxPath || strings.ContainsAny(b.Path, "?#|") || !utf8.ValidString(b.Path) {
+		return fmt.Errorf("invalid path %q", b.Path)
+	}
+	return nil
+}
+
+// beaconHandler counts the event posted in a JSON beacon:
+//
+//	POST /_beacon
+//	{"kind": "view", "path": "/doc/"}
+//	{"kind": "step", "path": "/doc/codewalk/sharemem", "step": 3}
+//
+// It answers 204 No Content, including for events it does not count
+// because the browser asked not to be tracked or appears to be a bot.
+func (c *collector) beaconHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBeacon))
+	if err != nil {
+		http.Error(w, "reading beacon: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	var b beacon
+	if err := json.Unmarshal(data, &b); err != nil {
+		http.Error(w, "invalid beacon: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := b.check(); err != nil {
+		http.Error(w, "invalid beacon: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	if !untracked(r) {
+		c.add(b, clientip.FromRequest(r)+"\x00"+r.UserAgent())
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// untracked reports whether the events in r must not be counted.
+func untracked(r *http.Request) bool {
+	if r.Header.Get("DNT") == "1" || r.Header.Get("Sec-GPC") == "1" {
+		return true
+	}
+	ua := strings.ToLower(r.UserAgent())
+	return ua == "" || strings.Contains(ua, "bot") || strings.Contains(ua, "spider") || strings.Contains(ua, "crawl")
+}
+
+// add counts the event b from the visitor identified by visitor.
+// Once maxPending counts are waiting to be recorded,
+// events for other counts are dropped.
+func (c *collector) add(b beacon, visitor string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	day := c.now().UTC().Format(dayFormat)
+	if day != c.day {
+		c.day = day
+		rand.Read(c.salt[:])
+		c.seen = make(map[[16]byte]bool)
+	}
+	k := countKey{day, b.Kind, b.Path, b.Step}
+	d, ok := c.pending[k]
+	if !ok && len(c.pending) >= maxPending {
+		return
+	}
+	d.events++
+
+	h := sha256.New()
+	h.Write(c.salt[:])
+	fmt.Fprintf(h, "%s\x00%s\x00%s\x00%d", visitor, b.Kind, b.Path, b.Step)
+	var sum [16]byte
+	copy(sum[:], h.Sum(nil))
+	if !c.seen[sum] && len(c.seen) < maxSeen {
+		c.seen[sum] = true
+		d.visitors++
+	}
+
+	if c.pending == nil {
+		c.pending = make(map[countKey]delta)
+	}
+	c.pending[k] = d
+}
+
+// take returns and resets the pending counts.
+func (c *collector) take() map[countKey]delta {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	pending := c.pending
+	c.pending = nil
+	return pending
+}
+
+// putBack returns counts that could not be recorded to the pending counts.
+func (c *collector) putBack(k countKey, d delta) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.pending == nil {
+		c.pending = make(map[countKey]delta)
+	}
+	p := c.pending[k]
+	c.pending[k] = delta{p.events + d.events, p.visitors + d.visitors}
+}
+
+// record adds the events counted since its last call to the
+// daily totals in the datastore. Counts it cannot record
+// are kept for the next call. As with short link hits,
+// the read-modify-write is not transactional, and events
+// can be lost when two servers record the same Count at once.
+func (c *collector) record(ctx context.Context) error {
+	var errs []error
+	for k, d := range c.take() {
+		key := datastore.NameKey(kind, k.name(), nil)
+		var n Count
+		err := c.datastore.Get(ctx, key, &n)
+		if err == datastore.ErrNoSuchEntity {
+			n = Count{Day: k.day, Kind: k.kind, Path: k.path, Step: k.step}
+			err = nil
+		}
+		if err == nil {
+			n.Events += d.events
+			n.Visitors += d.visitors
+			_, err = c.datastore.Put(ctx, key, &n)
+		}
+		if err != nil {
+			c.putBack(k, d)
+			errs = append(errs, fmt.Errorf("%s: %v", k.name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
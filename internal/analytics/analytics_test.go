@@ -0,0 +1,246 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analytics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/matttproud/yourtour/internal/datastoretest"
+	"github.com/matttproud/yourtour/internal/web"
+)
+
+// getCount returns the Count named name in ds.
+func getCount(ds Datastore, name string) Count {
+	var c Count
+	ds.Get(context.Background(), datastore.NameKey(kind, name, nil), &c)
+	return c
+}
+
+func TestBeaconHandler(t *testing.T) {
+	c := newCollector(new(datastoretest.Datastore))
+	post := func(body string, header ...string) int {
+		r := httptest.NewRequest("POST", beaconPath, strings.NewReader(body))
+		r.Header.Set("User-Agent", "Mozilla/5.0")
+		for i := 0; i < len(header); i += 2 {
+			r.Header.Set(header[i], header[i+1])
+		}
+		w := httptest.NewRecorder()
+		c.beaconHandler(w, r)
+		return w.Code
+	}
+
+	for _, tt := range []struct {
+		body string
+		code int
+	}{
+		{`{"kind": "view", "path": "/doc/"}`, 204},
+		{`{"kind": "step", "path": "/doc/codewalk/sharemem", "step": 3}`, 204},
+		{`{"kind": "view", "path": "/doc/", "step": 3}`, 400},
+		{`{"kind": "step", "path": "/blog/", "step": 3}`, 400},
+		{`{"kind": "step", "path": "/doc/codewalk/sharemem", "step": 1000}`, 400},
+		{`{"kind": "click", "path": "/doc/"}`, 400},
+		{`{"kind": "view", "path": "doc/"}`, 400},
+		{`{"kind": "view", "path": "/doc/?q=secret"}`, 400},
+		{`{"kind": "view", "path": "/` + strings.Repeat("x", maxPath) + `"}`, 400},
+		{`{"kind": "view", "path": "/doc/", "padding": "` + strings.Repeat("x", maxBeacon) + `"}`, 400},
+		{`{"kind":`, 400},
+	} {
+		if code := post(tt.body); code != tt.code {
+			t.Errorf("POST %.60s: %d, want %d", tt.body, code, tt.code)
+		}
+	}
+
+	// Untracked requests are answered but not counted.
+	const view = `{"kind": "view", "path": "/untracked/"}`
+	post(view, "DNT", "1")
+	post(view, "Sec-GPC", "1")
+	post(view, "User-Agent", "Googlebot/2.1")
+	post(view, "User-Agent", "")
+	for k := range c.take() {
+		if k.path == "/untracked/" {
+			t.Errorf("counted untracked view")
+		}
+	}
+
+	r := httptest.NewRequest("GET", beaconPath, nil)
+	w := httptest.NewRecorder()
+	c.beaconHandler(w, r)
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET %s: %d, want 405", beaconPath, w.Code)
+	}
+}
+
+func TestRecord(t *testing.T) {
+	ds := new(datastoretest.Datastore)
+	c := newCollector(ds)
+	now := time.Date(2026, 3, 1, 23, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	view := beacon{Kind: kindView, Path: "/doc/"}
+	c.add(view, "alice")
+	c.add(view, "alice")
+	c.add(view, "bob")
+	c.add(beacon{Kind: kindStep, Path: "/doc/codewalk/sharemem", Step: 2}, "alice")
+	if err := c.record(ctx); err != nil {
+		t.Fatal(err)
+	}
+	// Alice returns the same day, then again the next day.
+	c.add(view, "alice")
+	now = now.Add(2 * time.Hour)
+	c.add(view, "alice")
+	if err := c.record(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]Count{
+		"2026-03-01|view|0|/doc/":                  {Day: "2026-03-01", Kind: "view", Path: "/doc/", Events: 4, Visitors: 2},
+		"2026-03-01|step|2|/doc/codewalk/sharemem": {Day: "2026-03-01", Kind: "step", Path: "/doc/codewalk/sharemem", Step: 2, Events: 1, Visitors: 1},
+		"2026-03-02|view|0|/doc/":                  {Day: "2026-03-02", Kind: "view", Path: "/doc/", Events: 1, Visitors: 1},
+	}
+	if ds.Len() != len(want) {
+		t.Errorf("recorded %d counts, want %d", ds.Len(), len(want))
+	}
+	for name, w := range want {
+		if got := getCount(ds, name); got != w {
+			t.Errorf("count %s = %+v, want %+v", name, got, w)
+		}
+	}
+
+	// Counts that cannot be recorded are kept for the next time.
+	ds.FailPuts(errors.New("datastore unavailable"))
+	c.add(view, "carol")
+	if err := c.record(ctx); err == nil {
+		t.Fatal("record succeeded with failing datastore")
+	}
+	ds.FailPuts(nil)
+	if err := c.record(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := getCount(ds, "2026-03-02|view|0|/doc/"); got.Events != 2 || got.Visitors != 2 {
+		t.Errorf("after retry, count = %+v, want 2 events, 2 visitors", got)
+	}
+}
+
+func TestReport(t *testing.T) {
+	ds := new(datastoretest.Datastore)
+	for _, c := range []Count{
+		{Day: "2026-02-20", Kind: "view", Path: "/old/", Events: 100, Visitors: 100},
+		{Day: "2026-02-28", Kind: "view", Path: "/doc/", Events: 5, Visitors: 3},
+		{Day: "2026-03-01", Kind: "view", Path: "/doc/", Events: 2, Visitors: 1},
+		{Day: "2026-03-01", Kind: "view", Path: "/blog/", Events: 9, Visitors: 4},
+		{Day: "2026-03-01", Kind: "step", Path: "/doc/codewalk/sharemem", Step: 0, Events: 8, Visitors: 6},
+		{Day: "2026-03-01", Kind: "step", Path: "/doc/codewalk/sharemem", Step: 2, Events: 3, Visitors: 2},
+	} {
+		k := countKey{c.Day, c.Kind, c.Path, c.Step}
+		ds.Put(context.Background(), datastore.NameKey(kind, k.name(), nil), &c)
+	}
+
+	rep, err := makeReport(context.Background(), ds, time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC), 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rep.Since != "2026-02-23" || rep.Until != "2026-03-01" {
+		t.Errorf("report of %s to %s, want 2026-02-23 to 2026-03-01", rep.Since, rep.Until)
+	}
+	wantDays := []total{{"2026-03-01", 11, 5}, {"2026-02-28", 5, 3}}
+	if !slices.Equal(rep.Days, wantDays) {
+		t.Errorf("Days = %v, want %v", rep.Days, wantDays)
+	}
+	wantPages := []total{{"/blog/", 9, 4}, {"/doc/", 7, 4}}
+	if !slices.Equal(rep.Pages, wantPages) {
+		t.Errorf("Pages = %v, want %v", rep.Pages, wantPages)
+	}
+	wantSteps := []total{{"0", 8, 6}, {"1", 0, 0}, {"2", 3, 2}}
+	if len(rep.Codewalks) != 1 || rep.Codewalks[0].Path != "/doc/codewalk/sharemem" || !slices.Equal(rep.Codewalks[0].Steps, wantSteps) {
+		t.Errorf("Codewalks = %v, want sharemem steps %v", rep.Codewalks, wantSteps)
+	}
+
+	w := httptest.NewRecorder()
+	ReportHandler(ds).ServeHTTP(w, httptest.NewRequest("GET", "/_analytics?days=91", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("report of 91 days: %d, want 400", w.Code)
+	}
+	w = httptest.NewRecorder()
+	ReportHandler(ds).ServeHTTP(w, httptest.NewRequest("GET", "/_analytics?days=90", nil))
+	if w.Code != 200 || !strings.Contains(w.Body.String(), "<h2>Codewalk steps</h2>") {
+		t.Errorf("report of 90 days: %d\n%s", w.Code, w.Body)
+	}
+}
+
+func TestFeatures(t *testing.T) {
+	ds := new(datastoretest.Datastore)
+	c := newCollector(ds) // counting today, for FeaturesHandler to report
+	defer observer.Store(observer.Load())
+	observer.Store(c)
+
+	get := func(url, ua string) *http.Request {
+		r := httptest.NewRequest("GET", url, nil)
+		r.Header.Set("User-Agent", ua)
+		return r
+	}
+	CountDownload(get("/dl/go1.22.0.linux-amd64.tar.gz", "Firefox"), "go1.22.0")
+	CountDownload(get("/dl/go1.22.0.src.tar.gz", "Firefox"), "go1.22.0")
+	CountDownload(get("/dl/go1.21.5.src.tar.gz", "Chrome"), "go1.21.5")
+	CountDownload(get("/dl/go1.21.5.src.tar.gz", "Googlebot"), "go1.21.5")
+	CountDownload(httptest.NewRequest("HEAD", "/dl/go1.21.5.src.tar.gz", nil), "go1.21.5")
+	CountEmptySearch(get("/search?q=Generics++Tutorial", "Firefox"), "Generics  Tutorial")
+	CountEmptySearch(get("/search?q=generics+tutorial", "Chrome"), "generics tutorial")
+	c.add(beacon{Kind: kindView, Path: "/doc/codewalk/sharemem"}, "alice")
+	c.add(beacon{Kind: kindView, Path: "/doc/"}, "alice")
+	c.add(beacon{Kind: kindStep, Path: "/doc/codewalk/sharemem", Step: 0}, "alice")
+	c.add(beacon{Kind: kindStep, Path: "/doc/codewalk/sharemem", Step: 2}, "alice")
+	c.add(beacon{Kind: kindStep, Path: "/doc/codewalk/sharemem", Step: 2}, "bob")
+	if err := c.record(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	rep, err := makeReport(context.Background(), ds, c.now(), 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := features(rep)
+	if want := []total{{"go1.22.0", 2, 1}, {"go1.21.5", 1, 1}}; !slices.Equal(p.Downloads, want) {
+		t.Errorf("Downloads = %v, want %v", p.Downloads, want)
+	}
+	if want := []total{{"generics tutorial", 2, 2}}; !slices.Equal(p.EmptySearches, want) {
+		t.Errorf("EmptySearches = %v, want %v", p.EmptySearches, want)
+	}
+	if want := []total{{"/doc/codewalk/sharemem", 1, 1}}; !slices.Equal(p.Codewalks, want) {
+		t.Errorf("Codewalks = %v, want %v", p.Codewalks, want)
+	}
+	wantSteps := []stepTotal{
+		{"/doc/codewalk/sharemem", 2, total{"2", 2, 2}},
+		{"/doc/codewalk/sharemem", 0, total{"0", 1, 1}},
+	}
+	if !slices.Equal(p.Steps, wantSteps) {
+		t.Errorf("Steps = %v, want %v", p.Steps, wantSteps)
+	}
+
+	site := web.NewSite(fstest.MapFS{
+		"site.tmpl":     {Data: []byte(`{{block "layout" .}}{{end}}`)},
+		"error.tmpl":    {Data: []byte(`{{define "layout"}}error{{end}}`)},
+		"features.tmpl": {Data: []byte(`{{define "layout"}}{{.title}}{{range .Downloads}} {{.Key}}={{.Events}}{{end}}{{end}}`)},
+	})
+	w := httptest.NewRecorder()
+	FeaturesHandler(site, ds).ServeHTTP(w, httptest.NewRequest("GET", "/_features", nil))
+	if want := "go1.22.0=2 go1.21.5=1"; w.Code != 200 || !strings.Contains(w.Body.String(), want) {
+		t.Errorf("features report: %d %q, want %q", w.Code, w.Body, want)
+	}
+	w = httptest.NewRecorder()
+	FeaturesHandler(site, ds).ServeHTTP(w, httptest.NewRequest("GET", "/_features?days=0", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("features report of 0 days: %d, want 400", w.Code)
+	}
+}
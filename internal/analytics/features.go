@@ -0,0 +1,92 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analytics
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/matttproud/yourtour/internal/web"
+)
+
+// A FeaturesPage is the feature metrics report,
+// rendered with the site's features layout.
+type FeaturesPage struct {
+	Title        string `page:"title"`
+	Period       int    // number of days
+	Since, Until string // first and last day, inclusive
+
+	Codewalks     []total     // codewalk page views, most viewed first
+	Steps         []stepTotal // codewalk steps, most shown first
+	Downloads     []total     // downloads by release version, most downloaded first
+	EmptySearches []total     // searches finding nothing by query, most frequent first
+}
+
+func (FeaturesPage) Layout() string { return "features" }
+
+// A stepTotal is the number of times a codewalk step was shown.
+type stepTotal struct {
+	Path string
+	Step int
+	total
+}
+
+// FeaturesHandler serves the feature metrics report: the most viewed
+// codewalks and codewalk steps, the most downloaded release versions,
+// and the most frequent searches that found nothing, over the last days,
+// 7 by default or as given by the days parameter. The report is rendered
+// with site's features layout.
+// Be careful. It is the caller’s responsibility to ensure that the handler is
+// only exposed to authorized users.
+func FeaturesHandler(site *web.Site, dc Datastore) http.Handler {
+	site.AddContract(FeaturesPage{})
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" && r.Method != "HEAD" {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		days, err := reportDays(r)
+		if err != nil {
+			site.ServeErrorStatus(w, r, err, http.StatusBadRequest)
+			return
+		}
+		rep, err := makeReport(r.Context(), dc, time.Now(), days)
+		if err != nil {
+			site.ServeError(w, r, err)
+			return
+		}
+		w.Header().Set("Cache-Control", "no-store")
+		site.ServePage(w, r, web.PageOf(features(rep)))
+	})
+}
+
+// features returns the feature metrics report of rep.
+func features(rep *report) FeaturesPage {
+	p := FeaturesPage{
+		Title:         "Feature metrics, " + rep.Since + " to " + rep.Until,
+		Period:        rep.Period,
+		Since:         rep.Since,
+		Until:         rep.Until,
+		Codewalks:     top(rep.CodewalkViews),
+		Downloads:     top(rep.Downloads),
+		EmptySearches: top(rep.EmptySearches),
+	}
+	for _, cw := range rep.Codewalks {
+		for i, t := range cw.Steps {
+			if t.Events > 0 {
+				p.Steps = append(p.Steps, stepTotal{cw.Path, i, t})
+			}
+		}
+	}
+	sort.SliceStable(p.Steps, func(i, j int) bool { return p.Steps[i].Events > p.Steps[j].Events })
+	p.Steps = top(p.Steps)
+	return p
+}
+
+// top returns the first maxTop entries of list.
+func top[T any](list []T) []T {
+	return list[:min(len(list), maxTop)]
+}
@@ -0,0 +1,204 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analytics
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"html/template"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/datastore"
+)
+
+// Report limits.
+const (
+	defaultDays = 7
+	maxDays     = 90
+	maxPages    = 100 // pages listed in a report
+	maxTop      = 20  // entries in each list of the feature metrics report
+)
+
+// A report summarizes the Counts of recent days.
+type report struct {
+	Period       int     // number of days
+	Since, Until string  // first and last day, inclusive
+	Days         []total // page views by day, newest first
+	Pages        []total // page views by path, most viewed first
+	Codewalks    []codewalkReport
+
+	CodewalkViews []total // page views of codewalks, most viewed first
+	Downloads     []total // downloads by release version, most downloaded first
+	EmptySearches []total // searches finding nothing by query, most frequent first
+}
+
+// A total is a sum of Counts.
+// Visitors sums the daily visitor counts,
+// so a visitor returning on several days is counted on each.
+type total struct {
+	Key      string // day or path
+	Events   int64
+	Visitors int64
+}
+
+// A codewalkReport reports the steps shown of a codewalk,
+// showing how far its readers get.
+type codewalkReport struct {
+	Path  string
+	Steps []total // indexed by step; Key is the step number
+}
+
+// ReportHandler serves an administrative report of the counts
+// of the last days, 7 by default or as given by the days parameter.
+// Be careful. It is the caller’s responsibility to ensure that the handler is
+// only exposed to authorized users.
+func ReportHandler(dc Datastore) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" && r.Method != "HEAD" {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		days, err := reportDays(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		rep, err := makeReport(r.Context(), dc, time.Now(), days)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			log.Printf("ERROR analytics report: %v", err)
+			return
+		}
+		w.Header().Set("Cache-Control", "no-store")
+		if err := reportTemplate.Execute(w, rep); err != nil {
+			log.Printf("ERROR reportTemplate: %v", err)
+		}
+	})
+}
+
+// reportDays returns the number of days to report on for r,
+// 7 by default or as given by the days parameter.
+func reportDays(r *http.Request) (int, error) {
+	s := r.FormValue("days")
+	if s == "" {
+		return defaultDays, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 1 || n > maxDays {
+		return 0, errors.New("days must be between 1 and " + strconv.Itoa(maxDays))
+	}
+	return n, nil
+}
+
+var (
+	reportTemplate = template.Must(template.New("report").Parse(reportHTML))
+
+	//go:embed report.html
+	reportHTML string
+)
+
+// makeReport returns the report of the Counts of
+// the given number of days ending with the day of now.
+func makeReport(ctx context.Context, dc Datastore, now time.Time, days int) (*report, error) {
+	now = now.UTC()
+	rep := &report{
+		Period: days,
+		Since:  now.AddDate(0, 0, 1-days).Format(dayFormat),
+		Until:  now.Format(dayFormat),
+	}
+	var counts []*Count
+	q := datastore.NewQuery(kind).FilterField("Day", ">=", rep.Since)
+	if _, err := dc.GetAll(ctx, q, &counts); err != nil {
+		return nil, err
+	}
+
+	byDay := make(map[string]*total)
+	byPath := make(map[string]*total)
+	byVersion := make(map[string]*total)
+	byQuery := make(map[string]*total)
+	walks := make(map[string]*codewalkReport)
+	for _, c := range counts {
+		if c.Day < rep.Since || c.Day > rep.Until {
+			continue
+		}
+		switch c.Kind {
+		case kindView:
+			add(byDay, c.Day, c)
+			add(byPath, c.Path, c)
+		case kindStep:
+			cw := walks[c.Path]
+			if cw == nil {
+				cw = &codewalkReport{Path: c.Path}
+				walks[c.Path] = cw
+			}
+			for len(cw.Steps) <= c.Step {
+				cw.Steps = append(cw.Steps, total{Key: strconv.Itoa(len(cw.Steps))})
+			}
+			cw.Steps[c.Step].Events += c.Events
+			cw.Steps[c.Step].Visitors += c.Visitors
+		case kindDownload:
+			add(byVersion, c.Path, c)
+		case kindSearch:
+			add(byQuery, c.Path, c)
+		}
+	}
+
+	rep.Days = sorted(byDay, func(x, y *total) bool { return x.Key > y.Key })
+	rep.Pages = sorted(byPath, mostEvents)
+	for _, p := range rep.Pages {
+		if strings.HasPrefix(p.Key, "/doc/codewalk/") {
+			rep.CodewalkViews = append(rep.CodewalkViews, p)
+		}
+	}
+	rep.Downloads = sorted(byVersion, mostEvents)
+	rep.EmptySearches = sorted(byQuery, mostEvents)
+	if len(rep.Pages) > maxPages {
+		rep.Pages = rep.Pages[:maxPages]
+	}
+	for _, cw := range walks {
+		rep.Codewalks = append(rep.Codewalks, *cw)
+	}
+	sort.Slice(rep.Codewalks, func(i, j int) bool { return rep.Codewalks[i].Path < rep.Codewalks[j].Path })
+	return rep, nil
+}
+
+// mostEvents orders totals by decreasing events, then by key.
+func mostEvents(x, y *total) bool {
+	if x.Events != y.Events {
+		return x.Events > y.Events
+	}
+	return x.Key < y.Key
+}
+
+// add adds c to the total for key in m.
+func add(m map[string]*total, key string, c *Count) {
+	t := m[key]
+	if t == nil {
+		t = &total{Key: key}
+		m[key] = t
+	}
+	t.Events += c.Events
+	t.Visitors += c.Visitors
+}
+
+// sorted returns the totals in m sorted by less.
+func sorted(m map[string]*total, less func(x, y *total) bool) []total {
+	list := make([]*total, 0, len(m))
+	for _, t := range m {
+		list = append(list, t)
+	}
+	sort.Slice(list, func(i, j int) bool { return less(list[i], list[j]) })
+	out := make([]total, len(list))
+	for i, t := range list {
+		out[i] = *t
+	}
+	return out
+}
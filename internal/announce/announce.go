@@ -0,0 +1,275 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package announce shows site-wide announcements, such as new releases
+// and surveys, in a banner at the top of pages.
+//
+// Announcements are loaded from a YAML file or from datastore and
+// added to the data of every page rendered by a web.Site, as the page's
+// Announcements, for the site template to show. Each announcement is shown
+// only during its schedule and only on pages with the paths it targets.
+// Readers can dismiss an announcement; the ids of the announcements they
+// have dismissed are kept in a cookie, and dismissed announcements are
+// no longer shown to them.
+//
+// Pages showing announcements change with them, so the board's Version
+// is part of the pages' entity tags, and the pages vary with the cookie.
+package announce
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/matttproud/yourtour/internal/web"
+	"gopkg.in/yaml.v3"
+)
+
+// An Announcement is a message shown in a banner on the site's pages.
+type Announcement struct {
+	// ID identifies the announcement in readers' dismissals.
+	// Give a changed announcement a new ID to show it again
+	// to readers who dismissed the old one.
+	ID string `yaml:"id" datastore:"-"`
+
+	Text     string `yaml:"text" datastore:",noindex"` // plain text
+	Link     string `yaml:"link" datastore:",noindex"` // URL to learn more, if any
+	LinkText string `yaml:"link_text" datastore:",noindex"`
+
+	// Start and End are the times the announcement is shown between.
+	// A zero Start or End leaves the schedule open on that side.
+	Start time.Time `yaml:"start"`
+	End   time.Time `yaml:"end"`
+
+	// Paths lists the path prefixes of the pages showing the announcement,
+	// such as /doc/ or /blog/; if empty, every page shows it.
+	Paths []string `yaml:"paths"`
+
+	// Sticky announcements cannot be dismissed.
+	Sticky bool `yaml:"sticky"`
+}
+
+var validID = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// check reports whether a is a valid announcement.
+func (a *Announcement) check() error {
+	if !validID.MatchString(a.ID) {
+		return fmt.Errorf("invalid id %q; must match %s", a.ID, validID)
+	}
+	if a.Text == "" {
+		return fmt.Errorf("%s: missing text", a.ID)
+	}
+	if a.Link != "" {
+		if u, err := url.Parse(a.Link); err != nil || (u.Scheme != "" && u.Scheme != "https") {
+			return fmt.Errorf("%s: invalid link %q", a.ID, a.Link)
+		}
+	}
+	if !a.Start.IsZero() && !a.End.IsZero() && !a.End.After(a.Start) {
+		return fmt.Errorf("%s: end %v not after start %v", a.ID, a.End, a.Start)
+	}
+	for _, p := range a.Paths {
+		if !strings.HasPrefix(p, "/") {
+			return fmt.Errorf("%s: invalid path %q", a.ID, p)
+		}
+	}
+	return nil
+}
+
+// scheduled reports whether a's schedule includes time now.
+func (a *Announcement) scheduled(now time.Time) bool {
+	return (a.Start.IsZero() || !now.Before(a.Start)) && (a.End.IsZero() || now.Before(a.End))
+}
+
+// shows reports whether a is shown at time now on the page with the given path.
+func (a *Announcement) shows(now time.Time, path string) bool {
+	if !a.scheduled(now) {
+		return false
+	}
+	if len(a.Paths) == 0 {
+		return true
+	}
+	for _, p := range a.Paths {
+		if strings.HasPrefix(path, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// A Source stores announcements.
+type Source interface {
+	// Load returns the announcements in the source, in the order shown.
+	Load(ctx context.Context) ([]*Announcement, error)
+}
+
+// FileSource returns a Source that loads announcements
+// from the YAML file at path, which lists them:
+//
+//	announcements:
+//	  - id: go1-23-release
+//	    text: Go 1.23 is released!
+//	    link: /blog/go1.23
+//	    end: 2024-09-13T00:00:00Z
+//	  - id: survey-2024-h2
+//	    text: Help shape the future of Go.
+//	    link: https://google.qualtrics.com/jfe/form/SV_0000
+//	    link_text: Take the survey
+//	    start: 2024-09-09T16:00:00Z
+//	    paths: [/doc/, /blog/]
+//
+// The file is reread on every load, so editing it
+// takes effect at the next refresh.
+func FileSource(path string) Source {
+	return fileSource(path)
+}
+
+type fileSource string
+
+func (path fileSource) Load(ctx context.Context) ([]*Announcement, error) {
+	data, err := os.ReadFile(string(path))
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Announcements []*Announcement `yaml:"announcements"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return file.Announcements, nil
+}
+
+// Datastore is the part of a *datastore.Client used by DatastoreSource.
+type Datastore interface {
+	GetAll(ctx context.Context, q *datastore.Query, dst any) ([]*datastore.Key, error)
+}
+
+var _ Datastore = (*datastore.Client)(nil)
+
+// DatastoreSource returns a Source that loads announcements
+// stored as Announcement entities in datastore, keyed by their ids.
+// The announcements are shown in order of their start times.
+func DatastoreSource(dc Datastore) Source {
+	return datastoreSource{dc}
+}
+
+type datastoreSource struct {
+	dc Datastore
+}
+
+func (d datastoreSource) Load(ctx context.Context) ([]*Announcement, error) {
+	var list []*Announcement
+	keys, err := d.dc.GetAll(ctx, datastore.NewQuery("Announcement").Order("Start"), &list)
+	if err != nil {
+		return nil, err
+	}
+	for i, k := range keys {
+		list[i].ID = k.Name
+	}
+	return list, nil
+}
+
+// RefreshInterval is how often servers reload announcements from their source.
+const RefreshInterval = time.Minute
+
+// A Board holds the announcements loaded from a Source.
+type Board struct {
+	src  Source
+	now  func() time.Time
+	list atomic.Pointer[[]*Announcement]
+}
+
+// NewBoard returns a board showing the announcements in src.
+// The board is empty until the first call to Refresh.
+func NewBoard(src Source) *Board {
+	return &Board{src: src, now: time.Now}
+}
+
+// Refresh reloads the announcements from the board's source.
+// If loading fails or any announcement is invalid,
+// the board keeps the announcements it had.
+func (b *Board) Refresh(ctx context.Context) error {
+	list, err := b.src.Load(ctx)
+	if err != nil {
+		return err
+	}
+	seen := make(map[string]bool)
+	for _, a := range list {
+		if err := a.check(); err != nil {
+			return err
+		}
+		if seen[a.ID] {
+			return fmt.Errorf("duplicate announcement id %s", a.ID)
+		}
+		seen[a.ID] = true
+	}
+	b.list.Store(&list)
+	return nil
+}
+
+// Version returns the version of the announcements on the board,
+// for tagging the pages showing them. It changes when Refresh
+// loads different announcements and when an announcement's
+// schedule starts or ends.
+func (b *Board) Version() string {
+	list := b.list.Load()
+	if list == nil {
+		return "empty"
+	}
+	now := b.now()
+	h := sha256.New()
+	for _, a := range *list {
+		fmt.Fprintf(h, "%+v %v\n", *a, a.scheduled(now))
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))[:20]
+}
+
+// DismissCookie is the cookie listing the ids of the announcements
+// a reader has dismissed, separated by dots.
+// It is set by the site's JavaScript.
+const DismissCookie = "dismissed-announcements"
+
+// Active returns the announcements to show on the page at path,
+// at the current time, to a reader who sent request r.
+func (b *Board) Active(r *http.Request, path string) []*Announcement {
+	list := b.list.Load()
+	if list == nil {
+		return nil
+	}
+	var dismissed []string
+	if c, err := r.Cookie(DismissCookie); err == nil {
+		dismissed = strings.Split(c.Value, ".")
+	}
+	now := b.now()
+	var active []*Announcement
+	for _, a := range *list {
+		if a.shows(now, path) && (a.Sticky || !slices.Contains(dismissed, a.ID)) {
+			active = append(active, a)
+		}
+	}
+	return active
+}
+
+// PageData sets the announcements of the page p, which is being rendered
+// for request r, for use as a web.Site's page data function.
+// A page can set its own Announcements (for example, to an empty list,
+// to show none).
+func (b *Board) PageData(r *http.Request, p web.Page) {
+	if _, ok := p["Announcements"]; ok {
+		return
+	}
+	path, _ := p["URL"].(string)
+	if list := b.Active(r, path); list != nil {
+		p["Announcements"] = list
+	}
+}
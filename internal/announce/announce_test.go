@@ -0,0 +1,201 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package announce
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/matttproud/yourtour/internal/web"
+)
+
+const testYAML = `
+announcements:
+  - id: release
+    text: Go 1.30 is released!
+    link: /blog/go1.30
+    end: 2026-03-01T00:00:00Z
+  - id: survey
+    text: Tell us about Go.
+    link: https://example.com/survey
+    link_text: Take the survey
+    start: 2026-02-15T00:00:00Z
+    paths: [/doc/, /blog/]
+  - id: outage
+    text: Downloads are slow today.
+    paths: [/dl/]
+    sticky: true
+`
+
+func testBoard(t *testing.T, yaml string) (*Board, error) {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "announcements.yaml")
+	if err := os.WriteFile(file, []byte(yaml), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	b := NewBoard(FileSource(file))
+	return b, b.Refresh(context.Background())
+}
+
+func ids(list []*Announcement) string {
+	var s []string
+	for _, a := range list {
+		s = append(s, a.ID)
+	}
+	return strings.Join(s, ",")
+}
+
+func TestActive(t *testing.T) {
+	b, err := testBoard(t, testYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		time, path, dismissed string
+		want                  string
+	}{
+		{"2026-02-01", "/", "", "release"},
+		{"2026-02-01", "/doc/", "", "release"},
+		{"2026-02-20", "/doc/install", "", "release,survey"},
+		{"2026-02-20", "/doc/install", "survey", "release"},
+		{"2026-02-20", "/doc/install", "other.release.x", "survey"},
+		{"2026-03-01", "/blog/", "", "survey"},
+		{"2026-03-01", "/", "", ""},
+		{"2026-03-01", "/dl/", "outage", "outage"},
+	} {
+		b.now = func() time.Time {
+			tm, _ := time.Parse("2006-01-02", tt.time)
+			return tm
+		}
+		r := httptest.NewRequest("GET", tt.path, nil)
+		if tt.dismissed != "" {
+			r.AddCookie(&http.Cookie{Name: DismissCookie, Value: tt.dismissed})
+		}
+		if got := ids(b.Active(r, tt.path)); got != tt.want {
+			t.Errorf("at %s, Active(%s, dismissed %q) = %q, want %q", tt.time, tt.path, tt.dismissed, got, tt.want)
+		}
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	b, err := testBoard(t, testYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		yaml, err string
+	}{
+		{"announcements: [{id: Bad, text: x}]", `invalid id "Bad"`},
+		{"announcements: [{id: a}]", "missing text"},
+		{"announcements: [{id: a, text: x}, {id: a, text: y}]", "duplicate announcement id a"},
+		{"announcements: [{id: a, text: x, link: 'javascript:alert(1)'}]", "invalid link"},
+		{"announcements: [{id: a, text: x, start: 2026-03-01T00:00:00Z, end: 2026-02-01T00:00:00Z}]", "not after start"},
+		{"announcements: [{id: a, text: x, paths: [doc/]}]", `invalid path "doc/"`},
+		{"announcements: {id: a}", "cannot unmarshal"},
+	} {
+		file := filepath.Join(t.TempDir(), "bad.yaml")
+		os.WriteFile(file, []byte(tt.yaml), 0o666)
+		b.src = FileSource(file)
+		if err := b.Refresh(context.Background()); err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("Refresh(%q) = %v, want error containing %q", tt.yaml, err, tt.err)
+		}
+	}
+	// The board keeps the announcements it had.
+	if got := len(*b.list.Load()); got != 3 {
+		t.Errorf("after failed refreshes, board has %d announcements, want 3", got)
+	}
+}
+
+func TestVersion(t *testing.T) {
+	if v := NewBoard(nil).Version(); v == "" {
+		t.Errorf("Version of empty board is unknown")
+	}
+	b, err := testBoard(t, testYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(day string) string {
+		b.now = func() time.Time {
+			tm, _ := time.Parse("2006-01-02", day)
+			return tm
+		}
+		return b.Version()
+	}
+	// The survey starts on 2026-02-15 and the release ends on 2026-03-01.
+	if at("2026-02-01") != at("2026-02-14") {
+		t.Errorf("Version changed with no schedule boundary")
+	}
+	if at("2026-02-14") == at("2026-02-15") || at("2026-02-20") == at("2026-03-01") {
+		t.Errorf("Version did not change at a schedule boundary")
+	}
+	before := at("2026-02-20")
+	if err := b.Refresh(context.Background()); err != nil || b.Version() != before {
+		t.Errorf("Version changed on refresh with the same announcements (%v)", err)
+	}
+	file := filepath.Join(t.TempDir(), "new.yaml")
+	os.WriteFile(file, []byte(strings.Replace(testYAML, "Tell us about Go.", "Tell us more.", 1)), 0o666)
+	b.src = FileSource(file)
+	if err := b.Refresh(context.Background()); err != nil || b.Version() == before {
+		t.Errorf("Version unchanged on refresh with edited announcements (%v)", err)
+	}
+}
+
+// A testDatastore is a Datastore holding Announcements in memory.
+type testDatastore map[string]Announcement
+
+func (d testDatastore) GetAll(ctx context.Context, q *datastore.Query, dst any) ([]*datastore.Key, error) {
+	var keys []*datastore.Key
+	for name, a := range d {
+		keys = append(keys, datastore.NameKey("Announcement", name, nil))
+		*dst.(*[]*Announcement) = append(*dst.(*[]*Announcement), &a)
+	}
+	return keys, nil
+}
+
+func TestDatastoreSource(t *testing.T) {
+	b := NewBoard(DatastoreSource(testDatastore{"outage": {Text: "Downloads are slow today."}}))
+	if err := b.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := ids(b.Active(httptest.NewRequest("GET", "/", nil), "/")); got != "outage" {
+		t.Errorf("Active = %q, want outage", got)
+	}
+}
+
+func TestPageData(t *testing.T) {
+	b, err := testBoard(t, testYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.now = func() time.Time { return time.Date(2026, 2, 20, 0, 0, 0, 0, time.UTC) }
+	site := web.NewSite(fstest.MapFS{
+		"site.tmpl":     {Data: []byte(`{{range .Announcements}}[{{.Text}}]{{end}}{{.Content}}`)},
+		"doc/a.md":      {Data: []byte("A")},
+		"doc/quiet.md":  {Data: []byte("---\nAnnouncements: []\n---\nQuiet")},
+		"blog/index.md": {Data: []byte("Blog")},
+	})
+	site.AddPageData(b.PageData)
+	for _, tt := range []struct {
+		path, want string
+	}{
+		{"/doc/a", "[Go 1.30 is released!][Tell us about Go.]<p>A</p>"},
+		{"/doc/quiet", "<p>Quiet</p>"},
+		{"/blog/", "[Go 1.30 is released!][Tell us about Go.]<p>Blog</p>"},
+	} {
+		w := httptest.NewRecorder()
+		site.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+		if got := strings.TrimSpace(w.Body.String()); got != tt.want {
+			t.Errorf("GET %s = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
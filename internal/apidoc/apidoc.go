@@ -0,0 +1,86 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package apidoc serves a site's API page, /api, listing the endpoints
+// that subsystems document as they register their handlers
+// (see router.Router.Document), with their parameters and example responses.
+// The page lists the same endpoints in JSON with ?mode=json.
+//
+// The documentation is recorded from the code registering the handlers,
+// so the page lists the endpoints the server actually serves.
+package apidoc
+
+import (
+	"cmp"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/matttproud/yourtour/internal/reqlog"
+	"github.com/matttproud/yourtour/internal/router"
+	"github.com/matttproud/yourtour/internal/vhost"
+	"github.com/matttproud/yourtour/internal/web"
+)
+
+// RegisterHandlers registers the handler for the API page of h on h.
+// The page lists the endpoints documented for h's host, or for any host,
+// in h's router and in the routers in more, such as those registering
+// handlers on the mux in which h's router is mounted.
+func RegisterHandlers(h *vhost.Host, more ...*router.Router) {
+	s := &server{site: h.Site, host: h.Name, routers: append([]*router.Router{h.Router}, more...)}
+	h.Router.HandleFunc("GET", "/api", s.serveHTTP)
+	h.Router.Document("GET", "/api", router.Doc{
+		Summary: "Lists the documented API endpoints, as on this page.",
+		Params: []router.Param{
+			{Name: "mode", Description: "json to list the endpoints in JSON"},
+		},
+		Example: []router.Endpoint{{Method: "GET", Path: "/api", Summary: "Lists the documented API endpoints."}},
+	})
+}
+
+type server struct {
+	site    *web.Site
+	host    string
+	routers []*router.Router
+}
+
+// endpoints returns the endpoints documented for s's host,
+// sorted by path and method. An endpoint documented for the host
+// replaces the one for any host with the same method and path.
+func (s *server) endpoints() []router.Endpoint {
+	var list []router.Endpoint
+	for _, rt := range s.routers {
+		for _, e := range rt.Endpoints() {
+			if e.Host == "" || e.Host == s.host {
+				list = append(list, e)
+			}
+		}
+	}
+	slices.SortFunc(list, func(a, b router.Endpoint) int {
+		// The endpoint for the host sorts before the one for any host.
+		return cmp.Or(strings.Compare(a.Path, b.Path), strings.Compare(a.Method, b.Method), strings.Compare(b.Host, a.Host))
+	})
+	return slices.CompactFunc(list, func(a, b router.Endpoint) bool {
+		return a.Path == b.Path && a.Method == b.Method
+	})
+}
+
+func (s *server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	list := s.endpoints()
+	if r.FormValue("mode") == "json" {
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", " ")
+		if err := enc.Encode(list); err != nil {
+			reqlog.Logger(r.Context()).Error("rendering JSON for API endpoints", "err", err)
+		}
+		return
+	}
+	s.site.ServePage(w, r, web.Page{
+		"title":     "API",
+		"layout":    "api",
+		"endpoints": list,
+	})
+}
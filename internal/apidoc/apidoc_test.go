@@ -0,0 +1,80 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package apidoc
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/matttproud/yourtour/internal/router"
+	"github.com/matttproud/yourtour/internal/vhost"
+	"github.com/matttproud/yourtour/internal/web"
+)
+
+var testFS = fstest.MapFS{
+	"site.tmpl": {Data: []byte(`{{.title}}|{{range .endpoints}}[{{.Method}} {{.Host}}{{.Path}}: {{.Summary}}]{{end}}`)},
+	"api.tmpl":  {Data: []byte(`{{define "layout"}}{{end}}`)},
+}
+
+func ok(w http.ResponseWriter, r *http.Request) {}
+
+func TestAPI(t *testing.T) {
+	mux, siteMux := http.NewServeMux(), http.NewServeMux()
+	mux.Handle("/", siteMux)
+	rt, siteRouter := router.New(mux), router.New(siteMux)
+	var reg vhost.Registry
+	godev := reg.Add("", web.NewSite(testFS), testFS, siteRouter)
+	china := reg.Add("golang.google.cn", web.NewSite(testFS), testFS, siteRouter)
+	tip := reg.Add("tip.golang.org", web.NewSite(testFS), testFS, rt)
+	for _, h := range []*vhost.Host{godev, china, tip} {
+		h.Router.HandleFunc("GET", "/dl/", ok)
+		h.Router.Document("GET", "/dl/", router.Doc{Summary: "Lists releases on " + h.Name + "."})
+	}
+	china.Router.HandleFunc("GET", "/cn", ok)
+	china.Router.Document("GET", "/cn", router.Doc{Summary: "Serves China."})
+	rt.HandleFunc("POST", "/comments", ok)
+	rt.Document("POST", "/comments", router.Doc{Summary: "Posts a comment."})
+	RegisterHandlers(godev, rt)
+	RegisterHandlers(china, rt)
+
+	for _, tt := range []struct {
+		url, want string
+	}{
+		{"https://go.dev/api",
+			"API|[GET /api: Lists the documented API endpoints, as on this page.]" +
+				"[POST /comments: Posts a comment.][GET /dl/: Lists releases on .]"},
+		{"https://golang.google.cn/api",
+			"API|[GET golang.google.cn/api: Lists the documented API endpoints, as on this page.]" +
+				"[GET golang.google.cn/cn: Serves China.][POST /comments: Posts a comment.]" +
+				"[GET golang.google.cn/dl/: Lists releases on golang.google.cn.]"},
+	} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", tt.url, nil))
+		if got := w.Body.String(); w.Code != 200 || got != tt.want {
+			t.Errorf("GET %s: %d %q, want %q", tt.url, w.Code, got, tt.want)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "https://go.dev/api?mode=json", nil))
+	var list []router.Endpoint
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil || w.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("GET /api?mode=json: %v %q\n%s", err, w.Header().Get("Content-Type"), w.Body)
+	}
+	var paths []string
+	for _, e := range list {
+		paths = append(paths, e.Method+" "+e.Path)
+	}
+	if got, want := strings.Join(paths, ","), "GET /api,POST /comments,GET /dl/"; got != want {
+		t.Errorf("GET /api?mode=json listed %s, want %s", got, want)
+	}
+	if !strings.Contains(string(list[0].Example), `"summary": "Lists the documented API endpoints."`) {
+		t.Errorf("GET /api?mode=json: /api example = %s", list[0].Example)
+	}
+}
@@ -0,0 +1,432 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package apikey issues API keys to automated consumers of the site's
+// JSON and GraphQL endpoints and validates them, so that heavy consumers
+// can be identified and throttled apart from anonymous traffic.
+//
+// A client presents its key as a bearer token in the Authorization header
+// or in the key query parameter. A Store's Handler checks the key:
+// requests with unknown or revoked keys are refused with 401 Unauthorized,
+// and those beyond the key's quota with 429 Too Many Requests.
+// Keys not in the Store's memory are looked up in the datastore
+// at most LookupLimit times per client address, so that made-up keys
+// cannot flood the datastore with lookups.
+// Requests within it are counted in the key's usage and served,
+// marked so that Verified reports them, for the anonymous rate limits
+// to leave alone. Requests without a key are served as before.
+//
+// Keys are stored in the datastore by their SHA-256 hash, never as issued,
+// and issued, listed, and revoked through the Store's administrative API.
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/matttproud/yourtour/internal/ratelimit"
+)
+
+const (
+	kind     = "APIKey"
+	prefix   = "gok_"      // start of every key, so that leaked keys are recognizable
+	cacheTTL = time.Minute // how long a looked up key is trusted; revocations take this long
+	maxBody  = 1 << 10     // bytes in an issuance request
+	maxCache = 10000       // keys cached; the oldest is forgotten to make room
+)
+
+// LookupLimit is the rate limit, per client IP address,
+// on the keys looked up in the datastore.
+const LookupLimit = "30/m:60"
+
+// DefaultLimit is the quota of a key issued without one.
+const DefaultLimit = "600/m:1200"
+
+// A Key is an issued API key, as stored.
+type Key struct {
+	ID       string    `datastore:"-" json:"id"` // hex SHA-256 of the key
+	Owner    string    `json:"owner"`            // who the key was issued to, such as an email address
+	Limit    string    `json:"limit"`            // quota, as for ratelimit.ParseLimit
+	Created  time.Time `json:"created"`
+	Revoked  bool      `json:"revoked"`
+	Requests int64     `json:"requests"`          // requests made with the key, as of the last Flush
+	LastUsed time.Time `json:"lastUsed,omitzero"` // as of the last Flush
+
+	// Key is the key itself, set only in the reply issuing it.
+	Key string `datastore:"-" json:"key,omitempty"`
+}
+
+// Datastore is the part of a *datastore.Client used by a Store.
+type Datastore interface {
+	Get(ctx context.Context, key *datastore.Key, dst any) error
+	GetAll(ctx context.Context, q *datastore.Query, dst any) ([]*datastore.Key, error)
+	Put(ctx context.Context, key *datastore.Key, src any) (*datastore.Key, error)
+}
+
+var _ Datastore = (*datastore.Client)(nil)
+
+// A Store validates API keys against those in the datastore,
+// enforcing their quotas and counting their use.
+// It is safe for concurrent use.
+type Store struct {
+	dc      Datastore
+	now     func() time.Time
+	lookups *ratelimit.Limiter // datastore lookups, by client address
+
+	mu    sync.Mutex
+	cache map[string]*entry // by key ID
+}
+
+// An entry is a Store's memory of a key.
+type entry struct {
+	key     *Key // nil if there is no such key
+	expires time.Time
+	limiter *ratelimit.Limiter
+	used    int64 // requests since the last Flush
+	last    time.Time
+}
+
+// NewStore returns a Store of the keys in dc.
+func NewStore(dc Datastore) *Store {
+	limit, err := ratelimit.ParseLimit(LookupLimit)
+	if err != nil {
+		panic(err)
+	}
+	return &Store{
+		dc:      dc,
+		now:     time.Now,
+		lookups: ratelimit.New(limit, ratelimit.ByIP),
+		cache:   make(map[string]*entry),
+	}
+}
+
+// presented returns the API key presented by r, if any.
+func presented(r *http.Request) string {
+	if t, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return t
+	}
+	return r.URL.Query().Get("key")
+}
+
+// id returns the ID of the key k, its hex SHA-256.
+func id(k string) string {
+	sum := sha256.Sum256([]byte(k))
+	return hex.EncodeToString(sum[:])
+}
+
+type verifiedKey struct{}
+
+// Verified reports whether r was made with a valid API key,
+// as checked by a Store's Handler, returning the ID of the key if so.
+func Verified(r *http.Request) (id string, ok bool) {
+	id, ok = r.Context().Value(verifiedKey{}).(string)
+	return id, ok
+}
+
+// Handler returns a handler checking the API keys presented by
+// the requests for which match returns true, as the package
+// documentation describes, and serving the requests allowed with h.
+func (s *Store) Handler(h http.Handler, match func(*http.Request) bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		k := presented(r)
+		if k == "" || !strings.HasPrefix(k, prefix) || !match(r) {
+			h.ServeHTTP(w, r)
+			return
+		}
+		kid := id(k)
+		check := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			e, err := s.lookup(r.Context(), kid)
+			if err != nil {
+				log.Printf("ERROR looking up API key: %v", err)
+				http.Error(w, "internal server error", http.StatusInternalServerError)
+				return
+			}
+			if e == nil {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+				http.Error(w, "invalid or revoked API key", http.StatusUnauthorized)
+				return
+			}
+			e.limiter.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				s.mu.Lock()
+				e.used++
+				e.last = s.now()
+				s.mu.Unlock()
+				h.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), verifiedKey{}, kid)))
+			}), nil).ServeHTTP(w, r)
+		})
+		if !s.cached(kid) {
+			s.lookups.Handler(check, nil).ServeHTTP(w, r)
+			return
+		}
+		check(w, r)
+	})
+}
+
+// cached reports whether the Store remembers the key with the given ID,
+// valid or not, so that lookup need not load it from the datastore.
+func (s *Store) cached(kid string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e := s.cache[kid]
+	return e != nil && s.now().Before(e.expires)
+}
+
+// lookup returns the Store's entry for the valid key with the given ID,
+// loading it from the datastore if it is not cached or has expired,
+// or nil if there is no such key or it is revoked.
+func (s *Store) lookup(ctx context.Context, kid string) (*entry, error) {
+	now := s.now()
+	s.mu.Lock()
+	e := s.cache[kid]
+	if e != nil && now.Before(e.expires) {
+		s.mu.Unlock()
+		if e.key == nil {
+			return nil, nil
+		}
+		return e, nil
+	}
+	s.mu.Unlock()
+
+	var k Key
+	switch err := s.dc.Get(ctx, datastore.NameKey(kind, kid, nil), &k); err {
+	case nil:
+		k.ID = kid
+	case datastore.ErrNoSuchEntity:
+	default:
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e = s.cache[kid]; e == nil {
+		if len(s.cache) >= maxCache {
+			s.evict(now)
+		}
+		e = new(entry)
+		s.cache[kid] = e
+	}
+	e.expires = now.Add(cacheTTL)
+	e.key = nil
+	if k.ID == "" || k.Revoked {
+		return nil, nil
+	}
+	limit, err := ratelimit.ParseLimit(k.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("key %s: %v", kid, err)
+	}
+	e.key = &k
+	if e.limiter == nil || e.limiter.Limit() != limit {
+		e.limiter = ratelimit.New(limit, func(*http.Request) string { return "" })
+	}
+	return e, nil
+}
+
+// evict forgets expired entries, such as those of made-up keys,
+// unless they have requests to flush, and then, if the cache is still full,
+// the entry expiring first, preferring those with no requests to flush.
+// Its caller holds s.mu.
+func (s *Store) evict(now time.Time) {
+	for kid, e := range s.cache {
+		if e.used == 0 && !now.Before(e.expires) {
+			delete(s.cache, kid)
+		}
+	}
+	if len(s.cache) < maxCache {
+		return
+	}
+	// before reports whether a is forgotten before b.
+	before := func(a, b *entry) bool {
+		if (a.used == 0) != (b.used == 0) {
+			return a.used == 0
+		}
+		return a.expires.Before(b.expires)
+	}
+	var oldest string
+	for kid, e := range s.cache {
+		if oldest == "" || before(e, s.cache[oldest]) {
+			oldest = kid
+		}
+	}
+	delete(s.cache, oldest)
+}
+
+// Flush adds the requests counted since the last Flush
+// to the usage of their keys in the datastore.
+func (s *Store) Flush(ctx context.Context) error {
+	type use struct {
+		id   string
+		n    int64
+		last time.Time
+	}
+	var list []use
+	s.mu.Lock()
+	for kid, e := range s.cache {
+		if e.used > 0 {
+			list = append(list, use{kid, e.used, e.last})
+			e.used = 0
+		}
+	}
+	s.mu.Unlock()
+
+	for i, u := range list {
+		var k Key
+		dkey := datastore.NameKey(kind, u.id, nil)
+		err := s.dc.Get(ctx, dkey, &k)
+		if err == nil {
+			k.Requests += u.n
+			if u.last.After(k.LastUsed) {
+				k.LastUsed = u.last
+			}
+			_, err = s.dc.Put(ctx, dkey, &k)
+		}
+		if err == datastore.ErrNoSuchEntity {
+			continue
+		}
+		if err != nil {
+			// Count the requests again at the next Flush.
+			s.mu.Lock()
+			for _, u := range list[i:] {
+				if e := s.cache[u.id]; e != nil {
+					e.used += u.n
+				}
+			}
+			s.mu.Unlock()
+			return err
+		}
+	}
+	return nil
+}
+
+// APIHandler returns a handler serving a JSON API
+// for managing API keys, at path and below it:
+//
+//	GET path         list keys, newest first
+//	POST path        issue a key, {"Owner": "gopher@example.com", "Limit": "100/m"}
+//	DELETE path/id   revoke the key
+//
+// The key itself is returned only when it is issued.
+// A key issued without a limit gets DefaultLimit.
+// It is the caller's responsibility to ensure that the handler is only
+// exposed to authorized users, for example with env.AdminHandler.
+func (s *Store) APIHandler(path string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		kid := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, path), "/")
+		switch {
+		case kid == "" && r.Method == "GET":
+			s.apiList(w, r)
+		case kid == "" && r.Method == "POST":
+			s.apiIssue(w, r)
+		case kid != "" && r.Method == "DELETE":
+			s.apiRevoke(w, r, kid)
+		default:
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+func (s *Store) apiList(w http.ResponseWriter, r *http.Request) {
+	var list []*Key
+	keys, err := s.dc.GetAll(r.Context(), datastore.NewQuery(kind), &list)
+	if err != nil {
+		log.Printf("ERROR listing API keys: %v", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	s.mu.Lock()
+	for i, k := range list {
+		k.ID = keys[i].Name
+		if e := s.cache[k.ID]; e != nil {
+			k.Requests += e.used
+		}
+	}
+	s.mu.Unlock()
+	slices.SortFunc(list, func(a, b *Key) int { return b.Created.Compare(a.Created) })
+	writeJSON(w, http.StatusOK, append([]*Key{}, list...))
+}
+
+func (s *Store) apiIssue(w http.ResponseWriter, r *http.Request) {
+	var req struct{ Owner, Limit string }
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBody)).Decode(&req); err != nil {
+		http.Error(w, "invalid request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Owner = strings.TrimSpace(req.Owner); req.Owner == "" {
+		http.Error(w, "invalid request: missing owner", http.StatusBadRequest)
+		return
+	}
+	if req.Limit == "" {
+		req.Limit = DefaultLimit
+	}
+	if l, err := ratelimit.ParseLimit(req.Limit); err != nil || l.Count == 0 {
+		http.Error(w, fmt.Sprintf("invalid request: invalid limit %q; want count/period[:burst], like 100/m", req.Limit), http.StatusBadRequest)
+		return
+	}
+	secret := make([]byte, 24)
+	rand.Read(secret)
+	k := &Key{
+		Key:     prefix + hex.EncodeToString(secret),
+		Owner:   req.Owner,
+		Limit:   req.Limit,
+		Created: s.now().UTC(),
+	}
+	k.ID = id(k.Key)
+	if _, err := s.dc.Put(r.Context(), datastore.NameKey(kind, k.ID, nil), k); err != nil {
+		log.Printf("ERROR issuing API key: %v", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	log.Printf("issued API key %s to %s with limit %s", k.ID[:12], k.Owner, k.Limit)
+	writeJSON(w, http.StatusCreated, k)
+}
+
+func (s *Store) apiRevoke(w http.ResponseWriter, r *http.Request, kid string) {
+	var k Key
+	dkey := datastore.NameKey(kind, kid, nil)
+	switch err := s.dc.Get(r.Context(), dkey, &k); err {
+	case nil:
+	case datastore.ErrNoSuchEntity:
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	default:
+		log.Printf("ERROR API key %s: %v", kid, err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	k.Revoked = true
+	if _, err := s.dc.Put(r.Context(), dkey, &k); err != nil {
+		log.Printf("ERROR API key %s: %v", kid, err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	// The key is refused by this server at once and by others within cacheTTL.
+	s.mu.Lock()
+	if e := s.cache[kid]; e != nil {
+		e.key, e.expires = nil, s.now().Add(cacheTTL)
+	}
+	s.mu.Unlock()
+	log.Printf("revoked API key %s of %s", kid[:min(len(kid), 12)], k.Owner)
+	k.ID = kid
+	writeJSON(w, http.StatusOK, &k)
+}
+
+// writeJSON writes v to w as the JSON body of a reply with the given status.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("ERROR writing JSON: %v", err)
+	}
+}
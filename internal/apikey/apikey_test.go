@@ -0,0 +1,184 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package apikey
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/matttproud/yourtour/internal/datastoretest"
+)
+
+// getKey returns the key with the given ID stored in dc.
+func getKey(dc Datastore, id string) Key {
+	var k Key
+	dc.Get(context.Background(), datastore.NameKey(kind, id, nil), &k)
+	return k
+}
+
+func TestStore(t *testing.T) {
+	dc := new(datastoretest.Datastore)
+	s := NewStore(dc)
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+	api := s.APIHandler("/_apikeys")
+	call := func(method, url, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		api.ServeHTTP(w, httptest.NewRequest(method, url, strings.NewReader(body)))
+		return w
+	}
+
+	w := call("POST", "/_apikeys", `{"Owner": "gopher@example.com", "Limit": "2/h"}`)
+	var issued Key
+	if err := json.Unmarshal(w.Body.Bytes(), &issued); w.Code != http.StatusCreated || err != nil {
+		t.Fatalf("POST: %d %v\n%s", w.Code, err, w.Body)
+	}
+	if !strings.HasPrefix(issued.Key, prefix) || issued.ID != id(issued.Key) || issued.Owner != "gopher@example.com" || issued.Limit != "2/h" {
+		t.Fatalf("issued %+v", issued)
+	}
+	if k := getKey(dc, issued.ID); k.Owner != "gopher@example.com" || k.Key != "" {
+		t.Fatalf("stored %+v, want owner and no key", k)
+	}
+	for _, body := range []string{`{"Limit": "2/h"}`, `{"Owner": "x", "Limit": "2/fortnight"}`, `{"Owner": "x", "Limit": "off"}`, `{`} {
+		if w := call("POST", "/_apikeys", body); w.Code != http.StatusBadRequest {
+			t.Errorf("POST %s: %d, want 400", body, w.Code)
+		}
+	}
+
+	h := s.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, ok := Verified(r)
+		w.Header().Set("X-Key", id)
+		if !ok {
+			w.Header().Set("X-Key", "none")
+		}
+	}), func(r *http.Request) bool { return r.URL.Path == "/api" })
+	do := func(url, auth string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", url, nil)
+		if auth != "" {
+			r.Header.Set("Authorization", "Bearer "+auth)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+	for _, tt := range []struct {
+		url, auth string
+		code      int
+		key       string
+	}{
+		{"/api", "", 200, "none"},
+		{"/api", issued.Key, 200, issued.ID},
+		{"/api?key=" + issued.Key, "", 200, issued.ID},
+		{"/api", issued.Key, 429, ""},
+		{"/other", issued.Key, 200, "none"}, // not an API route
+		{"/api", "gok_made_up", 401, ""},
+		{"/api", "not-a-key", 200, "none"}, // some other token
+	} {
+		w := do(tt.url, tt.auth)
+		if w.Code != tt.code || w.Header().Get("X-Key") != tt.key {
+			t.Errorf("GET %s (%q): %d key %q, want %d key %q", tt.url, tt.auth, w.Code, w.Header().Get("X-Key"), tt.code, tt.key)
+		}
+	}
+
+	now = now.Add(time.Minute)
+	if err := s.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if k := getKey(dc, issued.ID); k.Requests != 2 || !k.LastUsed.Equal(now.Add(-time.Minute)) {
+		t.Errorf("after Flush: %+v, want 2 requests", k)
+	}
+
+	w = call("GET", "/_apikeys", "")
+	var list []*Key
+	if err := json.Unmarshal(w.Body.Bytes(), &list); w.Code != 200 || err != nil || len(list) != 1 || list[0].ID != issued.ID || list[0].Key != "" || list[0].Requests != 2 {
+		t.Errorf("GET: %d %v\n%s", w.Code, err, w.Body)
+	}
+
+	if w := call("DELETE", "/_apikeys/"+issued.ID, ""); w.Code != 200 || !getKey(dc, issued.ID).Revoked {
+		t.Fatalf("DELETE: %d %s", w.Code, w.Body)
+	}
+	if w := do("/api", issued.Key); w.Code != 401 {
+		t.Errorf("GET with revoked key: %d, want 401", w.Code)
+	}
+	if w := call("DELETE", "/_apikeys/missing", ""); w.Code != 404 {
+		t.Errorf("DELETE missing key: %d, want 404", w.Code)
+	}
+	if w := call("PUT", "/_apikeys/"+issued.ID, ""); w.Code != 405 {
+		t.Errorf("PUT: %d, want 405", w.Code)
+	}
+}
+
+// A countingDatastore is a datastoretest.Datastore counting its Gets.
+type countingDatastore struct {
+	datastoretest.Datastore
+	gets int
+}
+
+func (d *countingDatastore) Get(ctx context.Context, key *datastore.Key, dst any) error {
+	d.gets++
+	return d.Datastore.Get(ctx, key, dst)
+}
+
+func TestLookupLimit(t *testing.T) {
+	dc := new(countingDatastore)
+	s := NewStore(dc)
+	h := s.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), func(*http.Request) bool { return true })
+	do := func(key, addr string) int {
+		r := httptest.NewRequest("GET", "/api", nil)
+		r.Header.Set("Authorization", "Bearer "+key)
+		r.RemoteAddr = addr
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	burst := 60 // of LookupLimit
+	for i := range burst {
+		if code := do(fmt.Sprintf("gok_made_up_%d", i), "192.0.2.1:1234"); code != 401 {
+			t.Fatalf("made-up key %d: %d, want 401", i, code)
+		}
+	}
+	if code := do("gok_one_more", "192.0.2.1:1234"); code != 429 {
+		t.Errorf("made-up key beyond the lookup limit: %d, want 429", code)
+	}
+	if code := do("gok_made_up_0", "192.0.2.1:1234"); code != 401 {
+		t.Errorf("remembered made-up key: %d, want 401", code)
+	}
+	if code := do("gok_one_more", "198.51.100.1:1234"); code != 401 {
+		t.Errorf("made-up key from another client: %d, want 401", code)
+	}
+	if dc.gets != burst+1 {
+		t.Errorf("datastore lookups = %d, want %d", dc.gets, burst+1)
+	}
+}
+
+func TestCacheLimit(t *testing.T) {
+	s := NewStore(new(datastoretest.Datastore))
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+	ctx := context.Background()
+	for i := range maxCache + 10 {
+		if _, err := s.lookup(ctx, fmt.Sprint(i)); err != nil {
+			t.Fatal(err)
+		}
+		now = now.Add(time.Millisecond)
+	}
+	if len(s.cache) != maxCache {
+		t.Errorf("cached %d keys, want %d", len(s.cache), maxCache)
+	}
+	if _, ok := s.cache["0"]; ok {
+		t.Errorf("oldest key still cached")
+	}
+	if _, ok := s.cache[fmt.Sprint(maxCache+9)]; !ok {
+		t.Errorf("newest key not cached")
+	}
+}
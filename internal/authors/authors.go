@@ -0,0 +1,286 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package authors serves the pages of the authors of a site's
+// articles and codewalks: /authors/ lists the authors,
+// and /authors/<id> lists the articles and codewalks of the author
+// with that ID, newest first, along with their avatar and links.
+//
+// The authors are described in the site's authors.yaml, a list of entries
+// like this one:
+//
+//	# authors.yaml
+//	- id: rsc
+//	  name: Russ Cox
+//	  avatar: https://github.com/rsc.png
+//	  bio: Russ works on Go at Google.
+//	  links:
+//	  - title: GitHub
+//	    url: https://github.com/rsc
+//
+// Articles, the pages in the blog and doc trees, name their authors by ID
+// in their “authors” metadata, and codewalks in the authors attribute
+// of their codewalk element. The page data of a page naming its authors
+// holds them, as its authorList, for its layout to link to their pages.
+package authors
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/matttproud/yourtour/internal/codewalk"
+	"github.com/matttproud/yourtour/internal/vhost"
+	"github.com/matttproud/yourtour/internal/web"
+	"gopkg.in/yaml.v3"
+)
+
+// file is the name of the file describing the authors.
+const file = "authors.yaml"
+
+// prefix is the path prefix of the author pages.
+const prefix = "/authors/"
+
+// defaultAvatar is the avatar of authors who have none of their own.
+const defaultAvatar = "/images/gophers/front.svg"
+
+// articleGlobs match the pages that are articles.
+var articleGlobs = []string{"/blog/*", "/doc/*"}
+
+// validID matches valid author IDs.
+var validID = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// An Author is an author of articles or codewalks.
+type Author struct {
+	ID     string `yaml:"id"`
+	Name   string `yaml:"name"`
+	Avatar string `yaml:"avatar"` // image URL; defaults to a gopher
+	Bio    string `yaml:"bio"`
+	Links  []Link `yaml:"links"`
+}
+
+// URL returns the path of the author's page.
+func (a *Author) URL() string {
+	return prefix + a.ID
+}
+
+// A Link is a link to one of an author's other sites.
+type Link struct {
+	Title string `yaml:"title"`
+	URL   string `yaml:"url"`
+}
+
+// A Work is an article or codewalk, as listed on its authors' pages.
+type Work struct {
+	Kind    string // "article" or "codewalk"
+	URL     string
+	Title   string
+	Date    time.Time // zero for codewalks
+	Summary string
+	Authors []string // IDs
+}
+
+// RegisterHandlers registers the handler serving the author pages of h on h,
+// and sets the authors of the pages naming them, as their authorList page data.
+func RegisterHandlers(h *vhost.Host) {
+	s := &server{site: h.Site, fsys: h.FS}
+	h.Router.HandleFunc("GET", prefix, s.serveHTTP)
+	h.Site.AddPageData(s.pageData)
+	h.Site.AddSuggester(func(context.Context) ([]string, error) {
+		list, err := Load(h.FS)
+		var paths []string
+		for _, a := range list {
+			paths = append(paths, a.URL())
+		}
+		return paths, err
+	})
+}
+
+type server struct {
+	site *web.Site
+	fsys fs.FS
+}
+
+// Load returns the authors described in the authors.yaml of fsys,
+// in the order listed. It returns no authors if there is no authors.yaml.
+func Load(fsys fs.FS) ([]*Author, error) {
+	data, err := fs.ReadFile(fsys, file)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var list []*Author
+	if err := yaml.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	seen := make(map[string]bool)
+	for i, a := range list {
+		switch {
+		case !validID.MatchString(a.ID):
+			return nil, fmt.Errorf("%s: author %d: invalid id %q", file, i+1, a.ID)
+		case seen[a.ID]:
+			return nil, fmt.Errorf("%s: author %d: duplicate id %q", file, i+1, a.ID)
+		case a.Name == "":
+			return nil, fmt.Errorf("%s: author %s: missing name", file, a.ID)
+		}
+		seen[a.ID] = true
+		a.Avatar = cmp.Or(a.Avatar, defaultAvatar)
+	}
+	return list, nil
+}
+
+// Works returns the articles and codewalks of site, with file system fsys,
+// that name their authors, the newest articles first and the codewalks last.
+// Drafts are omitted unless site is in development mode.
+func Works(site *web.Site, fsys fs.FS) ([]*Work, error) {
+	var list []*Work
+	for _, glob := range articleGlobs {
+		pages, err := site.Pages(glob)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range pages {
+			ids := pageAuthors(p)
+			if ids == nil {
+				continue
+			}
+			w := &Work{Kind: "article", Authors: ids}
+			w.URL, _ = p["URL"].(string)
+			w.Title, _ = p["title"].(string)
+			w.Date, _ = p["date"].(time.Time)
+			w.Summary, _ = p["summary"].(string)
+			list = append(list, w)
+		}
+	}
+	slices.SortStableFunc(list, func(a, b *Work) int { return b.Date.Compare(a.Date) })
+
+	walks, err := codewalk.List(fsys)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range walks {
+		if c.Authors != nil {
+			list = append(list, &Work{Kind: "codewalk", URL: c.Path, Title: c.Title, Authors: c.Authors})
+		}
+	}
+	return list, nil
+}
+
+// pageAuthors returns the IDs of the authors the page p names, if any,
+// as listed in YAML metadata ([]any) or set by Go code ([]string).
+func pageAuthors(p web.Page) []string {
+	switch v := p["authors"].(type) {
+	case []string:
+		return v
+	case []any:
+		var ids []string
+		for _, id := range v {
+			ids = append(ids, fmt.Sprint(id))
+		}
+		return ids
+	}
+	return nil
+}
+
+// Validate reports whether the authors.yaml of fsys is valid and names
+// every author named by the articles and codewalks of site.
+func Validate(site *web.Site, fsys fs.FS) error {
+	list, err := Load(fsys)
+	if err != nil {
+		return err
+	}
+	works, err := Works(site, fsys)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, w := range works {
+		for _, id := range w.Authors {
+			if find(list, id) == nil {
+				errs = append(errs, fmt.Errorf("%s: unknown author %q; not in %s", w.URL, id, file))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// find returns the author in list with the given id, or nil if there is none.
+func find(list []*Author, id string) *Author {
+	i := slices.IndexFunc(list, func(a *Author) bool { return a.ID == id })
+	if i < 0 {
+		return nil
+	}
+	return list[i]
+}
+
+// pageData sets the authorList of the page p, which is being rendered
+// for request r, to the authors p names, omitting unknown ones.
+func (s *server) pageData(r *http.Request, p web.Page) {
+	ids := pageAuthors(p)
+	if ids == nil {
+		return
+	}
+	if _, ok := p["authorList"]; ok {
+		return
+	}
+	all, err := Load(s.fsys)
+	if err != nil {
+		log.Printf("ERROR loading authors: %v", err)
+		return
+	}
+	var list []*Author
+	for _, id := range ids {
+		if a := find(all, id); a != nil {
+			list = append(list, a)
+		}
+	}
+	if list != nil {
+		p["authorList"] = list
+	}
+}
+
+func (s *server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, prefix)
+	all, err := Load(s.fsys)
+	if err != nil {
+		s.site.ServeError(w, r, err)
+		return
+	}
+	if id == "" {
+		s.site.ServePage(w, r, web.Page{
+			"title":       "Authors",
+			"layout":      "authors",
+			"authorIndex": all,
+		})
+		return
+	}
+
+	a := find(all, id)
+	if a == nil {
+		s.site.ServeErrorStatus(w, r, fmt.Errorf("no author %q", id), http.StatusNotFound)
+		return
+	}
+	works, err := Works(s.site, s.fsys)
+	if err != nil {
+		s.site.ServeError(w, r, err)
+		return
+	}
+	works = slices.DeleteFunc(works, func(w *Work) bool { return !slices.Contains(w.Authors, id) })
+	s.site.ServePage(w, r, web.Page{
+		"title":  a.Name,
+		"layout": "authors",
+		"author": a,
+		"works":  works,
+	})
+}
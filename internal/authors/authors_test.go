@@ -0,0 +1,119 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package authors
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/matttproud/yourtour/internal/router"
+	"github.com/matttproud/yourtour/internal/vhost"
+	"github.com/matttproud/yourtour/internal/web"
+)
+
+const authorsYAML = `
+- id: rsc
+  name: Russ Cox
+  avatar: https://github.com/rsc.png
+  links:
+  - title: GitHub
+    url: https://github.com/rsc
+- id: gopher
+  name: The Gopher
+`
+
+func testFS() fstest.MapFS {
+	return fstest.MapFS{
+		"authors.yaml": {Data: []byte(authorsYAML)},
+		"site.tmpl": {Data: []byte(`{{.title}}|{{with .author}}{{.Avatar}}|{{end}}` +
+			`{{range .authorIndex}}[{{.URL}} {{.Name}} {{.Avatar}}]{{end}}` +
+			`{{range .works}}[{{.Kind}} {{.URL}}]{{end}}` +
+			`{{range .authorList}}({{.Name}}){{end}}`)},
+		"authors.tmpl":        {Data: []byte(`{{define "layout"}}{{end}}`)},
+		"error.tmpl":          {Data: []byte(`{{define "layout"}}{{end}}`)},
+		"blog/old.md":         {Data: []byte("---\ntitle: Old\ndate: 2010-11-10\nauthors: [rsc, gopher]\n---\n")},
+		"blog/new.md":         {Data: []byte("---\ntitle: New\ndate: 2024-05-02\nauthors: [rsc]\n---\n")},
+		"blog/anon.md":        {Data: []byte("---\ntitle: Anonymous\ndate: 2020-01-01\n---\n")},
+		"blog/draft.md":       {Data: []byte("---\ntitle: Draft\ndate: 2025-01-01\ndraft: true\nauthors: [rsc]\n---\n")},
+		"doc/guide.md":        {Data: []byte("---\ntitle: Guide\nauthors: [gopher, nobody]\n---\n")},
+		"doc/codewalk/cw.xml": {Data: []byte(`<codewalk title="Walk" authors="rsc"><step title="S" src="doc/codewalk/cw.xml"/></codewalk>`)},
+		"doc/codewalk/x.xml":  {Data: []byte(`<codewalk title="Unattributed"><step title="S" src="doc/codewalk/x.xml"/></codewalk>`)},
+	}
+}
+
+func TestServe(t *testing.T) {
+	fsys := testFS()
+	mux := http.NewServeMux()
+	var reg vhost.Registry
+	site := web.NewSite(fsys)
+	RegisterHandlers(reg.Add("", site, fsys, router.New(mux)))
+	mux.Handle("/", site)
+
+	for _, tt := range []struct {
+		url  string
+		code int
+		want string
+	}{
+		{"/authors/", 200, "Authors|[/authors/rsc Russ Cox https://github.com/rsc.png][/authors/gopher The Gopher /images/gophers/front.svg]"},
+		{"/authors/rsc", 200, "Russ Cox|https://github.com/rsc.png|[article /blog/new][article /blog/old][codewalk /doc/codewalk/cw]"},
+		{"/authors/gopher", 200, "The Gopher|/images/gophers/front.svg|[article /blog/old][article /doc/guide]"},
+		{"/authors/nobody", 404, ""},
+		{"/blog/old", 200, "Old|(Russ Cox)(The Gopher)"},
+		{"/doc/guide", 200, "Guide|(The Gopher)"}, // unknown authors are omitted
+		{"/blog/anon", 200, "Anonymous|"},
+	} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", tt.url, nil))
+		if w.Code != tt.code || tt.want != "" && w.Body.String() != tt.want {
+			t.Errorf("GET %s: %d %q, want %d %q", tt.url, w.Code, w.Body, tt.code, tt.want)
+		}
+	}
+}
+
+func TestPageData(t *testing.T) {
+	// Codewalk pages list their authors' IDs as a []string.
+	s := &server{fsys: testFS()}
+	p := web.Page{"authors": []string{"gopher"}}
+	s.pageData(nil, p)
+	if list, _ := p["authorList"].([]*Author); len(list) != 1 || list[0].Name != "The Gopher" {
+		t.Errorf("authorList = %v, want The Gopher", p["authorList"])
+	}
+}
+
+func TestLoad(t *testing.T) {
+	for _, tt := range []struct {
+		yaml string
+		err  string
+	}{
+		{"- id: a\n  name: A\n- id: b\n  name: B\n", ""},
+		{"- id: a\n  name: A\n- id: a\n  name: B\n", `author 2: duplicate id "a"`},
+		{"- id: Russ Cox\n  name: Russ Cox\n", `author 1: invalid id "Russ Cox"`},
+		{"- id: a\n", "author a: missing name"},
+		{"id: a\n", "cannot unmarshal"},
+	} {
+		_, err := Load(fstest.MapFS{"authors.yaml": {Data: []byte(tt.yaml)}})
+		if tt.err == "" && err != nil || tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+			t.Errorf("Load(%q) = %v, want error containing %q", tt.yaml, err, tt.err)
+		}
+	}
+	if list, err := Load(fstest.MapFS{}); list != nil || err != nil {
+		t.Errorf("Load without authors.yaml = %v, %v, want nil, nil", list, err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	fsys := testFS()
+	err := Validate(web.NewSite(fsys), fsys)
+	if err == nil || err.Error() != `/doc/guide: unknown author "nobody"; not in authors.yaml` {
+		t.Errorf("Validate = %v, want unknown author nobody", err)
+	}
+	delete(fsys, "doc/guide.md")
+	if err := Validate(web.NewSite(fsys), fsys); err != nil {
+		t.Errorf("Validate = %v, want nil", err)
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"io"
 	"net/http"
 	"regexp"
+	"slices"
 	"sort"
 	"strings"
 	"time"
@@ -136,6 +137,11 @@ func feedPages(site *web.Site) ([]web.Page, error) {
 	if err != nil {
 		return nil, err
 	}
+	// Drafts are listed in development mode but never syndicated.
+	pages = slices.DeleteFunc(pages, func(p web.Page) bool {
+		draft, _ := p["draft"].(bool)
+		return draft
+	})
 	sort.Slice(pages, func(i, j int) bool {
 		ti, _ := pages[i]["date"].(time.Time)
 		tj, _ := pages[j]["date"].(time.Time)
@@ -0,0 +1,127 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package blog
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/matttproud/yourtour/internal/vhost"
+	"github.com/matttproud/yourtour/internal/web"
+)
+
+// RegisterHandlers registers the blog's tag pages on the virtual host h:
+// /blog/tag/ lists the tags of the blog's articles,
+// and /blog/tag/<tag> lists the articles with that tag, newest first.
+func RegisterHandlers(h *vhost.Host) {
+	h.Router.Handle("GET", "/blog/tag/", tagHandler(h.Site))
+}
+
+// An article is a blog article as listed on a tag page.
+type article struct {
+	URL     string
+	Title   string
+	Date    time.Time
+	By      string
+	Summary string
+	Tags    []string
+}
+
+// A tagCount is a tag and the number of articles with it.
+type tagCount struct {
+	Tag   string
+	Count int
+}
+
+// articles returns the dated articles of the blog, newest first.
+// Drafts are omitted unless site is in development mode.
+func articles(site *web.Site) ([]article, error) {
+	pages, err := site.Pages("/blog/*")
+	if err != nil {
+		return nil, err
+	}
+	var list []article
+	for _, p := range pages {
+		date, _ := p["date"].(time.Time)
+		if date.IsZero() {
+			continue
+		}
+		a := article{Date: date, Tags: tags(p)}
+		a.URL, _ = p["URL"].(string)
+		a.Title, _ = p["title"].(string)
+		a.Summary, _ = p["summary"].(string)
+		a.By, _ = authors(p)
+		list = append(list, a)
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].Date.After(list[j].Date) })
+	return list, nil
+}
+
+// tags returns the tags listed in the “tags” metadata of p.
+// YAML reads some tags, such as 47 in “BCP 47”, as numbers;
+// they are formatted as written.
+func tags(p web.Page) []string {
+	var list []string
+	raw, _ := p["tags"].([]any)
+	for _, t := range raw {
+		if t != nil {
+			list = append(list, fmt.Sprint(t))
+		}
+	}
+	return list
+}
+
+// tagHandler returns a handler serving the tag pages of the blog on site.
+func tagHandler(site *web.Site) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tag := strings.TrimPrefix(r.URL.Path, "/blog/tag/")
+		list, err := articles(site)
+		if err != nil {
+			site.ServeError(w, r, err)
+			return
+		}
+
+		if tag == "" {
+			counts := make(map[string]int)
+			for _, a := range list {
+				for _, t := range a.Tags {
+					counts[t]++
+				}
+			}
+			var all []tagCount
+			for t, n := range counts {
+				all = append(all, tagCount{t, n})
+			}
+			sort.Slice(all, func(i, j int) bool { return all[i].Tag < all[j].Tag })
+			site.ServePage(w, r, web.Page{
+				"title":  "Blog Tags",
+				"layout": "/blog/tag",
+				"tags":   all,
+			})
+			return
+		}
+
+		var tagged []article
+		for _, a := range list {
+			if slices.Contains(a.Tags, tag) {
+				tagged = append(tagged, a)
+			}
+		}
+		if len(tagged) == 0 {
+			site.ServeErrorStatus(w, r, fmt.Errorf("no blog articles are tagged %q", tag), http.StatusNotFound)
+			return
+		}
+		site.ServePage(w, r, web.Page{
+			"title":    "Articles tagged “" + tag + "”",
+			"layout":   "/blog/tag",
+			"tag":      tag,
+			"articles": tagged,
+		})
+	})
+}
@@ -0,0 +1,134 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package canary sends a fraction of the requests for a route
+// to a new version of its handler, so that the new version can be
+// rolled out incrementally within one binary.
+//
+// Requests are assigned to a variant by stable bucketing: a hash of
+// the route's name and the client's address places each client in one
+// of 10000 buckets, and clients in the buckets below the rollout rate
+// are served by the canary. A client thus sees the same variant from
+// one request to the next, and raising the rate moves clients only
+// from the stable variant to the canary.
+//
+// Each request is annotated in the access log with its variant,
+// and Metrics compare the status codes and latencies of the variants.
+package canary
+
+import (
+	"hash/fnv"
+	"net/http"
+	"time"
+
+	"github.com/matttproud/yourtour/internal/accesslog"
+	"github.com/matttproud/yourtour/internal/clientip"
+)
+
+// The variants of a route.
+const (
+	Stable = "stable" // the handler in service
+	Canary = "canary" // the new version being rolled out
+)
+
+// nbuckets is the number of buckets clients are placed in.
+const nbuckets = 10000
+
+// A Handler serves the requests for a route with one of two variants.
+type Handler struct {
+	name    string
+	stable  http.Handler
+	canary  http.Handler
+	buckets int // buckets served by the canary
+	metrics Metrics
+	key     func(*http.Request) string // the client's bucketing key
+}
+
+// New returns a Handler that serves the requests of the fraction rate
+// of clients with canary, and the others with stable. The name identifies
+// the route in bucketing, logs, and metrics. If m is nil, no metrics
+// are recorded.
+func New(name string, stable, canary http.Handler, rate float64, m Metrics) *Handler {
+	return &Handler{
+		name:    name,
+		stable:  stable,
+		canary:  canary,
+		buckets: int(rate * nbuckets),
+		metrics: m,
+		key:     clientip.FromRequest,
+	}
+}
+
+// Variant returns the variant serving r.
+func (h *Handler) Variant(r *http.Request) string {
+	if h.buckets <= 0 {
+		return Stable
+	}
+	f := fnv.New64a()
+	f.Write([]byte(h.name + "\x00" + h.key(r)))
+	if int(f.Sum64()%nbuckets) < h.buckets {
+		return Canary
+	}
+	return Stable
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	variant := h.Variant(r)
+	accesslog.Annotate(r.Context(), "variant", h.name+"/"+variant)
+	next := h.stable
+	if variant == Canary {
+		next = h.canary
+	}
+	if h.metrics == nil {
+		next.ServeHTTP(w, r)
+		return
+	}
+	start := time.Now()
+	sw := &statusWriter{ResponseWriter: w}
+	defer func() {
+		p := recover()
+		status := sw.status
+		switch {
+		case p != nil:
+			status = http.StatusInternalServerError
+		case status == 0:
+			status = http.StatusOK
+		}
+		h.metrics.Request(h.name, variant, status, time.Since(start))
+		if p != nil {
+			panic(p) // for the server's panic handling
+		}
+	}()
+	next.ServeHTTP(sw, r)
+}
+
+// A statusWriter records the status of a reply.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if w.status == 0 && code >= 200 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *statusWriter) Flush() {
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap returns the underlying ResponseWriter,
+// for use by http.ResponseController.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
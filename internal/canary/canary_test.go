@@ -0,0 +1,103 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package canary
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func reply(s string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s == "broken" {
+			http.Error(w, s, 500)
+			return
+		}
+		fmt.Fprint(w, s)
+	}
+}
+
+func TestBucketing(t *testing.T) {
+	const clients = 2000
+	variants := func(rate float64) []string {
+		h := New("codewalk", reply(Stable), reply(Canary), rate, nil)
+		var list []string
+		for i := range clients {
+			r := httptest.NewRequest("GET", "/doc/codewalk/", nil)
+			r.RemoteAddr = fmt.Sprintf("10.0.%d.%d:1234", i/256, i%256)
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			if v := h.Variant(r); w.Body.String() != v {
+				t.Fatalf("client %d: served by %s, but Variant = %s", i, w.Body, v)
+			}
+			list = append(list, w.Body.String())
+		}
+		return list
+	}
+
+	if v := variants(0); strings.Contains(strings.Join(v, " "), Canary) {
+		t.Errorf("rate 0 served canary")
+	}
+	if v := variants(1); strings.Contains(strings.Join(v, " "), Stable) {
+		t.Errorf("rate 1 served stable")
+	}
+	low, high := variants(0.1), variants(0.5)
+	n := 0
+	for i := range clients {
+		if low[i] == Canary {
+			n++
+			if high[i] != Canary {
+				t.Errorf("client %d moved from canary to stable when the rate rose", i)
+			}
+		}
+	}
+	if n < clients/20 || n > clients/5 {
+		t.Errorf("rate 0.1 served canary to %d of %d clients", n, clients)
+	}
+	if again := variants(0.1); strings.Join(again, " ") != strings.Join(low, " ") {
+		t.Errorf("variants changed between runs")
+	}
+}
+
+func TestPrometheusMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m, err := NewPrometheusMetrics(reg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serve := func(h http.Handler) {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/doc/codewalk/", nil))
+	}
+	serve(New("codewalk", reply("ok"), reply("broken"), 1, m))
+	serve(New("codewalk", reply("ok"), reply("broken"), 0, m))
+	serve(New("codewalk", reply("ok"), reply("broken"), 0, m))
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("panic in canary was not passed on")
+			}
+		}()
+		serve(New("codewalk", reply("ok"), http.HandlerFunc(func(http.ResponseWriter, *http.Request) { panic("bug") }), 1, m))
+	}()
+
+	want := `
+# HELP canary_requests_total Requests for routes being rolled out, by route, variant, and status class.
+# TYPE canary_requests_total counter
+canary_requests_total{code="2xx",route="codewalk",variant="stable"} 2
+canary_requests_total{code="5xx",route="codewalk",variant="canary"} 2
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(want), "canary_requests_total"); err != nil {
+		t.Error(err)
+	}
+	if n := testutil.CollectAndCount(m.duration); n != 2 {
+		t.Errorf("duration series = %d, want 2", n)
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package canary
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics receives measurements of the requests served by Handlers.
+// Implementations must be safe for concurrent use.
+type Metrics interface {
+	// Request records one request for route, served by variant,
+	// which was answered with status after d.
+	Request(route, variant string, status int, d time.Duration)
+}
+
+// A PrometheusMetrics is a Metrics that exports its measurements
+// as Prometheus metrics:
+//
+//	canary_requests_total{route, variant, code}        counter; code is the status class, as 2xx
+//	canary_request_duration_seconds{route, variant}    histogram
+type PrometheusMetrics struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+}
+
+var _ Metrics = (*PrometheusMetrics)(nil)
+
+// NewPrometheusMetrics returns a new PrometheusMetrics
+// with its metrics registered with reg.
+func NewPrometheusMetrics(reg prometheus.Registerer) (*PrometheusMetrics, error) {
+	m := &PrometheusMetrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "canary_requests_total",
+			Help: "Requests for routes being rolled out, by route, variant, and status class.",
+		}, []string{"route", "variant", "code"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "canary_request_duration_seconds",
+			Help:    "Duration of requests for routes being rolled out, by route and variant.",
+			Buckets: []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+		}, []string{"route", "variant"}),
+	}
+	for _, c := range []prometheus.Collector{m.requests, m.duration} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+func (m *PrometheusMetrics) Request(route, variant string, status int, d time.Duration) {
+	m.requests.WithLabelValues(route, variant, strconv.Itoa(status/100)+"xx").Inc()
+	m.duration.WithLabelValues(route, variant).Observe(d.Seconds())
+}
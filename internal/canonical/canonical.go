@@ -0,0 +1,105 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package canonical redirects requests to the canonical form of their URLs.
+//
+// The canonical form of a URL uses the site's canonical host name,
+// not an alternate one such as www.go.dev, and a clean path,
+// without empty, "." or ".." elements.
+// Directory paths end in a slash; file paths do not.
+// Handler enforces the first two site-wide;
+// handlers that know whether a path names a directory or a file
+// enforce the last with Dir and File.
+package canonical
+
+import (
+	"net/http"
+	"path"
+	"strings"
+)
+
+// Hosts maps alternate host names, such as www.go.dev,
+// to the canonical host names of the sites they serve.
+type Hosts map[string]string
+
+// Handler returns a handler that redirects requests for alternate hosts
+// listed in hosts to the same URL on the canonical host,
+// redirects requests for unclean paths to the clean path,
+// and serves all other requests with h.
+func Handler(h http.Handler, hosts Hosts) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if host, ok := hosts[strings.ToLower(r.Host)]; ok {
+			u := *r.URL
+			u.Scheme = "https"
+			u.Host = host
+			redirect(w, r, u.String())
+			return
+		}
+		if !strings.HasPrefix(r.URL.Path, "/") {
+			// OPTIONS * and the like.
+			h.ServeHTTP(w, r)
+			return
+		}
+		if p := Path(r.URL.Path); p != r.URL.Path {
+			u := *r.URL
+			u.Scheme, u.Host, u.Path = "", "", p
+			redirect(w, r, u.String())
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// Path returns the clean form of the URL path p,
+// keeping the trailing slash if p has one.
+func Path(p string) string {
+	c := path.Clean("/" + p)
+	if strings.HasSuffix(p, "/") && c != "/" {
+		c += "/"
+	}
+	return c
+}
+
+// Dir redirects r to the canonical path for a directory,
+// the clean path ending in a slash, if r's path is not already that.
+// It reports whether it redirected.
+func Dir(w http.ResponseWriter, r *http.Request) (redirected bool) {
+	c := path.Clean(r.URL.Path)
+	if !strings.HasSuffix(c, "/") {
+		c += "/"
+	}
+	return redirectPath(w, r, c)
+}
+
+// File redirects r to the canonical path for a file,
+// the clean path not ending in a slash, if r's path is not already that.
+// It reports whether it redirected.
+func File(w http.ResponseWriter, r *http.Request) (redirected bool) {
+	c := strings.TrimRight(path.Clean(r.URL.Path), "/")
+	if c == "" {
+		c = "/"
+	}
+	return redirectPath(w, r, c)
+}
+
+func redirectPath(w http.ResponseWriter, r *http.Request, p string) bool {
+	if r.URL.Path == p {
+		return false
+	}
+	u := *r.URL
+	u.Path = p
+	redirect(w, r, u.String())
+	return true
+}
+
+// redirect redirects r permanently to url.
+// Requests other than GET and HEAD are redirected with 308,
+// so that clients repeat them with the same method and body.
+func redirect(w http.ResponseWriter, r *http.Request, url string) {
+	code := http.StatusMovedPermanently
+	if r.Method != "GET" && r.Method != "HEAD" {
+		code = http.StatusPermanentRedirect
+	}
+	http.Redirect(w, r, url, code)
+}
@@ -0,0 +1,69 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package canonical
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+	h := Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	}), Hosts{"www.go.dev": "go.dev"})
+
+	tests := []struct {
+		method, url string
+		code        int
+		location    string
+	}{
+		{"GET", "https://go.dev/doc/", 200, ""},
+		{"GET", "https://go.dev/doc", 200, ""},
+		{"GET", "https://www.go.dev/doc/?m=old", 301, "https://go.dev/doc/?m=old"},
+		{"GET", "http://WWW.go.dev/", 301, "https://go.dev/"},
+		{"POST", "https://www.go.dev/dl/upload", 308, "https://go.dev/dl/upload"},
+		{"GET", "https://go.dev//doc//go1.html", 301, "/doc/go1.html"},
+		{"GET", "https://go.dev/doc/./x/../", 301, "/doc/"},
+		{"GET", "https://go.dev/a/b/..?q=1", 301, "/a?q=1"},
+		{"OPTIONS", "*", 200, ""},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(tt.method, tt.url, nil))
+		if w.Code != tt.code || w.Header().Get("Location") != tt.location {
+			t.Errorf("%s %s: %d %q, want %d %q", tt.method, tt.url, w.Code, w.Header().Get("Location"), tt.code, tt.location)
+		}
+	}
+}
+
+func TestDirFile(t *testing.T) {
+	tests := []struct {
+		url       string
+		dir, file string // redirect targets, or "" for none
+	}{
+		{"/doc/codewalk/sharemem/", "", "/doc/codewalk/sharemem"},
+		{"/doc/codewalk/sharemem", "/doc/codewalk/sharemem/", ""},
+		{"/doc/codewalk//sharemem?x=1", "/doc/codewalk/sharemem/?x=1", "/doc/codewalk/sharemem?x=1"},
+		{"/", "", ""},
+	}
+	for _, tt := range tests {
+		for _, f := range []struct {
+			name string
+			fn   func(http.ResponseWriter, *http.Request) bool
+			want string
+		}{
+			{"Dir", Dir, tt.dir},
+			{"File", File, tt.file},
+		} {
+			w := httptest.NewRecorder()
+			redirected := f.fn(w, httptest.NewRequest("GET", tt.url, nil))
+			if loc := w.Header().Get("Location"); redirected != (f.want != "") || loc != f.want {
+				t.Errorf("%s(%s) = %v, Location %q, want %q", f.name, tt.url, redirected, loc, f.want)
+			}
+		}
+	}
+}
@@ -0,0 +1,303 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package changelog tracks the revision history of a site's content,
+// so that readers can tell how fresh a page is.
+//
+// The history is read from the manifest changelog.json in the content,
+// a list of revisions, newest first, written by cmd/changelog from the
+// Git log when the site is built:
+//
+//	[{"commit": "2c1209…", "time": "2026-06-02T20:43:09Z",
+//	  "subject": "_content/doc: fix typo", "files": ["doc/faq.md"]}]
+//
+// Without a manifest, the history is only the revision the binary was built
+// from, as recorded in its build information, naming no files.
+//
+// /changes.json serves the history, and the page data of each page
+// that the history names holds the time of its last change,
+// as its lastUpdated, and its most recent revisions, as its history.
+// /changes.json leaves out the private pages of the site,
+// and the revisions that change only those.
+package changelog
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/http"
+	"path"
+	"runtime/debug"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/matttproud/yourtour/internal/router"
+	"github.com/matttproud/yourtour/internal/vhost"
+	"github.com/matttproud/yourtour/internal/web"
+)
+
+// File is the name of the manifest in the content.
+const File = "changelog.json"
+
+const (
+	refresh     = time.Minute // how long a loaded history is reused
+	pageHistory = 5           // revisions in a page's history
+	maxChanges  = 100         // default limit on revisions served
+)
+
+// A Revision is one revision of the content.
+type Revision struct {
+	Commit  string    `json:"commit"`
+	Time    time.Time `json:"time"`
+	Subject string    `json:"subject,omitempty"`
+	Files   []string  `json:"files,omitempty"` // changed, relative to the content root
+}
+
+// A Log is the revision history of some content.
+type Log struct {
+	Revisions []*Revision // newest first
+	byFile    map[string][]*Revision
+}
+
+// Load returns the revision history recorded in the manifest of fsys,
+// or, if there is none, the revision recorded in the build information.
+func Load(fsys fs.FS) (*Log, error) {
+	data, err := fs.ReadFile(fsys, File)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		return newLog(buildRevision()), nil
+	}
+	var revs []*Revision
+	if err := json.Unmarshal(data, &revs); err != nil {
+		return nil, fmt.Errorf("%s: %v", File, err)
+	}
+	for i, r := range revs {
+		switch {
+		case r.Commit == "":
+			return nil, fmt.Errorf("%s: revision %d: missing commit", File, i+1)
+		case r.Time.IsZero():
+			return nil, fmt.Errorf("%s: revision %s: missing time", File, r.Commit)
+		case i > 0 && r.Time.After(revs[i-1].Time):
+			return nil, fmt.Errorf("%s: revision %s: newer than the one before it", File, r.Commit)
+		}
+	}
+	return newLog(revs), nil
+}
+
+// Validate reports whether the manifest of fsys, if any, is valid.
+func Validate(fsys fs.FS) error {
+	_, err := Load(fsys)
+	return err
+}
+
+// buildRevision returns the revision recorded in the build information
+// of the binary, if any.
+func buildRevision() []*Revision {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return nil
+	}
+	r := new(Revision)
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			r.Commit = s.Value
+		case "vcs.time":
+			r.Time, _ = time.Parse(time.RFC3339, s.Value)
+		case "vcs.modified":
+			if s.Value == "true" {
+				return nil
+			}
+		}
+	}
+	if r.Commit == "" {
+		return nil
+	}
+	return []*Revision{r}
+}
+
+func newLog(revs []*Revision) *Log {
+	l := &Log{Revisions: revs, byFile: make(map[string][]*Revision)}
+	for _, r := range revs {
+		for _, f := range r.Files {
+			l.byFile[f] = append(l.byFile[f], r)
+		}
+	}
+	return l
+}
+
+// History returns the revisions changing file, newest first.
+func (l *Log) History(file string) []*Revision {
+	return l.byFile[file]
+}
+
+// pageFiles returns the files that may hold the page with the URL path u.
+func pageFiles(u string) []string {
+	u = strings.Trim(path.Clean("/"+u), "/")
+	if u == "" {
+		return []string{"index.md", "index.html"}
+	}
+	return []string{u + ".md", u + ".html", u + "/index.md", u + "/index.html", u}
+}
+
+// urlPath returns the URL path serving file.
+func urlPath(file string) string {
+	switch ext := path.Ext(file); ext {
+	case ".md", ".html":
+		file = strings.TrimSuffix(file, ext)
+		if path.Base(file) == "index" {
+			return "/" + strings.TrimSuffix(file, "index")
+		}
+	}
+	return "/" + file
+}
+
+type server struct {
+	fsys fs.FS
+	site *web.Site // for telling private pages
+
+	mu     sync.Mutex
+	loaded time.Time
+	log    *Log
+}
+
+// RegisterHandlers registers the handler serving /changes.json
+// with the revision history of h's content on h,
+// and sets the last change and history of the pages of h it names,
+// as their lastUpdated and history page data.
+func RegisterHandlers(h *vhost.Host) {
+	s := &server{fsys: h.FS, site: h.Site}
+	h.Router.HandleFunc("GET", "/changes.json", s.serveHTTP)
+	h.Router.Document("GET", "/changes.json", router.Doc{
+		Summary: "Lists the revisions of the site content, newest first.",
+		Params: []router.Param{
+			{Name: "page", Description: "only revisions changing the page with this path, such as /doc/faq"},
+			{Name: "n", Description: fmt.Sprintf("the most revisions to list; default %d", maxChanges)},
+		},
+		Example: &Changes{
+			Revision: "2c120970",
+			Changes: []Change{{
+				Commit: "2c120970", Time: time.Date(2026, 6, 2, 20, 43, 9, 0, time.UTC),
+				Subject: "_content/doc: fix typo", Paths: []string{"/doc/faq"},
+			}},
+		},
+	})
+	h.Site.AddPageData(s.pageData)
+}
+
+// load returns the revision history, reloading it if it is out of date.
+func (s *server) load() (*Log, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.log != nil && time.Since(s.loaded) < refresh {
+		return s.log, nil
+	}
+	l, err := Load(s.fsys)
+	if err != nil {
+		return nil, err
+	}
+	s.log, s.loaded = l, time.Now()
+	return l, nil
+}
+
+// pageData sets the lastUpdated and history of the page p,
+// which is being rendered for request r, from the revisions changing it.
+func (s *server) pageData(r *http.Request, p web.Page) {
+	file, _ := p["File"].(string)
+	if file == "" {
+		return
+	}
+	l, err := s.load()
+	if err != nil {
+		log.Printf("ERROR loading %s: %v", File, err)
+		return
+	}
+	hist := l.History(file)
+	if len(hist) == 0 {
+		return
+	}
+	p["lastUpdated"] = hist[0].Time
+	p["history"] = hist[:min(len(hist), pageHistory)]
+}
+
+// Changes is the reply to /changes.json.
+type Changes struct {
+	Revision string   `json:"revision,omitempty"` // newest, as served
+	Changes  []Change `json:"changes"`
+}
+
+// A Change is a revision, as served by /changes.json.
+type Change struct {
+	Commit  string    `json:"commit"`
+	Time    time.Time `json:"time"`
+	Subject string    `json:"subject,omitempty"`
+	Paths   []string  `json:"paths,omitempty"` // URL paths of the changed files
+}
+
+func (s *server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	l, err := s.load()
+	if err != nil {
+		log.Printf("ERROR loading %s: %v", File, err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	n := maxChanges
+	if v := r.FormValue("n"); v != "" {
+		if n, err = strconv.Atoi(v); err != nil || n <= 0 {
+			http.Error(w, "invalid n", http.StatusBadRequest)
+			return
+		}
+	}
+
+	revs := l.Revisions
+	if page := r.FormValue("page"); page != "" {
+		revs = nil
+		files := pageFiles(page)
+		if s.site.Private(path.Clean("/" + page)) {
+			files = nil
+		}
+		seen := make(map[*Revision]bool)
+		for _, f := range files {
+			for _, rev := range l.History(f) {
+				if !seen[rev] {
+					seen[rev] = true
+					revs = append(revs, rev)
+				}
+			}
+		}
+		slices.SortStableFunc(revs, func(a, b *Revision) int { return b.Time.Compare(a.Time) })
+	}
+
+	c := &Changes{Changes: []Change{}}
+	if len(l.Revisions) > 0 {
+		c.Revision = l.Revisions[0].Commit
+	}
+	for _, rev := range revs {
+		if len(c.Changes) == n {
+			break
+		}
+		ch := Change{Commit: rev.Commit, Time: rev.Time, Subject: rev.Subject}
+		for _, f := range rev.Files {
+			if p := urlPath(f); !s.site.Private(p) {
+				ch.Paths = append(ch.Paths, p)
+			}
+		}
+		if len(rev.Files) > 0 && len(ch.Paths) == 0 {
+			continue // changes only private pages
+		}
+		c.Changes = append(c.Changes, ch)
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	if err := json.NewEncoder(w).Encode(c); err != nil {
+		log.Printf("ERROR writing changes: %v", err)
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package changelog
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/matttproud/yourtour/internal/router"
+	"github.com/matttproud/yourtour/internal/vhost"
+	"github.com/matttproud/yourtour/internal/web"
+)
+
+const manifest = `[
+	{"commit": "c3", "time": "2026-06-03T00:00:00Z", "subject": "doc: rename", "files": ["doc/faq/index.md", "doc/faq.md"]},
+	{"commit": "c2", "time": "2026-06-02T00:00:00Z", "subject": "blog: new post", "files": ["blog/new.md", "images/new.png"]},
+	{"commit": "c1", "time": "2026-06-01T00:00:00Z", "subject": "doc: add faq", "files": ["doc/faq.md"]}
+]`
+
+func testFS() fstest.MapFS {
+	return fstest.MapFS{
+		File:               {Data: []byte(manifest)},
+		"site.tmpl":        {Data: []byte(`{{.title}}|{{with .lastUpdated}}{{.Format "2006-01-02"}}{{end}}|{{range .history}}[{{.Commit}}]{{end}}`)},
+		"doc/faq/index.md": {Data: []byte("---\ntitle: FAQ\n---\n")},
+		"blog/new.md":      {Data: []byte("---\ntitle: New\n---\n")},
+		"blog/old.md":      {Data: []byte("---\ntitle: Old\n---\n")},
+	}
+}
+
+func TestServe(t *testing.T) {
+	fsys := testFS()
+	mux := http.NewServeMux()
+	var reg vhost.Registry
+	site := web.NewSite(fsys)
+	RegisterHandlers(reg.Add("", site, fsys, router.New(mux)))
+	mux.Handle("/", site)
+
+	for _, tt := range []struct {
+		url  string
+		code int
+		want string
+	}{
+		{"/doc/faq/", 200, "FAQ|2026-06-03|[c3]"},
+		{"/blog/new", 200, "New|2026-06-02|[c2]"},
+		{"/blog/old", 200, "Old||"},
+		{"/changes.json?n=0", 400, ""},
+		{"/changes.json?n=x", 400, ""},
+	} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", tt.url, nil))
+		if w.Code != tt.code || tt.want != "" && w.Body.String() != tt.want {
+			t.Errorf("GET %s: %d %q, want %d %q", tt.url, w.Code, w.Body, tt.code, tt.want)
+		}
+	}
+
+	for _, tt := range []struct {
+		url  string
+		want string // commits and paths
+	}{
+		{"/changes.json", "c3 /doc/faq/ /doc/faq,c2 /blog/new /images/new.png,c1 /doc/faq"},
+		{"/changes.json?n=1", "c3 /doc/faq/ /doc/faq"},
+		{"/changes.json?page=/doc/faq", "c3 /doc/faq/ /doc/faq,c1 /doc/faq"},
+		{"/changes.json?page=/doc/faq/", "c3 /doc/faq/ /doc/faq,c1 /doc/faq"},
+		{"/changes.json?page=/images/new.png", "c2 /blog/new /images/new.png"},
+		{"/changes.json?page=/blog/old", ""},
+	} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", tt.url, nil))
+		var c Changes
+		if err := json.Unmarshal(w.Body.Bytes(), &c); err != nil {
+			t.Fatalf("GET %s: %d %v\n%s", tt.url, w.Code, err, w.Body)
+		}
+		var list []string
+		for _, ch := range c.Changes {
+			list = append(list, strings.Join(append([]string{ch.Commit}, ch.Paths...), " "))
+		}
+		if got := strings.Join(list, ","); c.Revision != "c3" || got != tt.want {
+			t.Errorf("GET %s: revision %q, changes %q, want c3, %q", tt.url, c.Revision, got, tt.want)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	for _, tt := range []struct {
+		json string
+		err  string
+	}{
+		{manifest, ""},
+		{`[]`, ""},
+		{`[{"time": "2026-06-01T00:00:00Z"}]`, "revision 1: missing commit"},
+		{`[{"commit": "c1"}]`, "revision c1: missing time"},
+		{`[{"commit": "c1", "time": "2026-06-01T00:00:00Z"}, {"commit": "c2", "time": "2026-06-02T00:00:00Z"}]`, "revision c2: newer than the one before it"},
+		{`{}`, "cannot unmarshal"},
+	} {
+		err := Validate(fstest.MapFS{File: {Data: []byte(tt.json)}})
+		if tt.err == "" && err != nil || tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+			t.Errorf("Validate(%s) = %v, want error containing %q", tt.json, err, tt.err)
+		}
+	}
+
+	// Test binaries record no revision in their build information.
+	l, err := Load(fstest.MapFS{})
+	if err != nil || len(l.Revisions) != 0 || l.History("doc/faq.md") != nil {
+		t.Errorf("Load without manifest = %+v, %v, want no revisions", l, err)
+	}
+}
+
+func TestPrivate(t *testing.T) {
+	fsys := testFS()
+	fsys[File] = &fstest.MapFile{Data: []byte(`[
+	{"commit": "c3", "time": "2026-06-03T00:00:00Z", "subject": "doc: plans and faq", "files": ["doc/faq.md", "doc/team/plans.md"]},
+	{"commit": "c2", "time": "2026-06-02T00:00:00Z", "subject": "doc: secret plans", "files": ["doc/team/plans.md"]},
+	{"commit": "c1", "time": "2026-06-01T00:00:00Z", "subject": "doc: add faq", "files": ["doc/faq.md"]}
+]`)}
+	mux := http.NewServeMux()
+	var reg vhost.Registry
+	site := web.NewSite(fsys)
+	site.SetPrivate(func(p string) bool { return strings.HasPrefix(p, "/doc/team/") })
+	RegisterHandlers(reg.Add("", site, fsys, router.New(mux)))
+
+	for _, tt := range []struct {
+		url  string
+		want string // commits and paths
+	}{
+		{"/changes.json", "c3 /doc/faq,c1 /doc/faq"},
+		{"/changes.json?n=1", "c3 /doc/faq"},
+		{"/changes.json?page=/doc/team/plans", ""},
+	} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", tt.url, nil))
+		var c Changes
+		if err := json.Unmarshal(w.Body.Bytes(), &c); err != nil {
+			t.Fatalf("GET %s: %d %v\n%s", tt.url, w.Code, err, w.Body)
+		}
+		var list []string
+		for _, ch := range c.Changes {
+			list = append(list, strings.Join(append([]string{ch.Commit}, ch.Paths...), " "))
+		}
+		if got := strings.Join(list, ","); got != tt.want {
+			t.Errorf("GET %s: changes %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
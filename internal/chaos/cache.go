@@ -0,0 +1,103 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package chaos
+
+import (
+	"context"
+	"time"
+
+	"github.com/matttproud/yourtour/internal/memcache"
+)
+
+// A Cache is a memcache.Cache that injects a fault
+// into every operation before passing it to another Cache.
+type Cache struct {
+	cache memcache.Cache
+	fault Fault
+}
+
+var _ memcache.Cache = (*Cache)(nil)
+
+// NewCache returns a Cache injecting f into the operations of c.
+func NewCache(c memcache.Cache, f Fault) *Cache {
+	return &Cache{cache: c, fault: f}
+}
+
+func (c *Cache) Get(ctx context.Context, key string) ([]byte, error) {
+	if err := c.fault.Inject(ctx); err != nil {
+		return nil, err
+	}
+	return c.cache.Get(ctx, key)
+}
+
+func (c *Cache) Set(ctx context.Context, item *memcache.Item) error {
+	if err := c.fault.Inject(ctx); err != nil {
+		return err
+	}
+	return c.cache.Set(ctx, item)
+}
+
+func (c *Cache) Delete(ctx context.Context, key string) error {
+	if err := c.fault.Inject(ctx); err != nil {
+		return err
+	}
+	return c.cache.Delete(ctx, key)
+}
+
+func (c *Cache) GetMulti(ctx context.Context, keys []string) (map[string][]byte, error) {
+	if err := c.fault.Inject(ctx); err != nil {
+		return nil, err
+	}
+	return c.cache.GetMulti(ctx, keys)
+}
+
+func (c *Cache) SetMulti(ctx context.Context, items []*memcache.Item) error {
+	if err := c.fault.Inject(ctx); err != nil {
+		return err
+	}
+	return c.cache.SetMulti(ctx, items)
+}
+
+func (c *Cache) DeleteMulti(ctx context.Context, keys []string) error {
+	if err := c.fault.Inject(ctx); err != nil {
+		return err
+	}
+	return c.cache.DeleteMulti(ctx, keys)
+}
+
+func (c *Cache) Add(ctx context.Context, item *memcache.Item) error {
+	if err := c.fault.Inject(ctx); err != nil {
+		return err
+	}
+	return c.cache.Add(ctx, item)
+}
+
+func (c *Cache) GetItem(ctx context.Context, key string) (*memcache.Item, error) {
+	if err := c.fault.Inject(ctx); err != nil {
+		return nil, err
+	}
+	return c.cache.GetItem(ctx, key)
+}
+
+func (c *Cache) CompareAndSwap(ctx context.Context, item *memcache.Item) error {
+	if err := c.fault.Inject(ctx); err != nil {
+		return err
+	}
+	return c.cache.CompareAndSwap(ctx, item)
+}
+
+func (c *Cache) Touch(ctx context.Context, key string, expiration time.Duration) error {
+	if err := c.fault.Inject(ctx); err != nil {
+		return err
+	}
+	return c.cache.Touch(ctx, key, expiration)
+}
+
+func (c *Cache) GetAndTouch(ctx context.Context, key string, expiration time.Duration) ([]byte, error) {
+	if err := c.fault.Inject(ctx); err != nil {
+		return nil, err
+	}
+	return c.cache.GetAndTouch(ctx, key, expiration)
+}
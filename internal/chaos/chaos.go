@@ -0,0 +1,196 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package chaos injects latency and errors into request handling
+// and calls to dependencies, for resilience testing.
+//
+// Faults exercise the paths that only run when something is slow
+// or broken, such as request timeouts, cache circuit breaking,
+// and serving stale or embedded data when datastore fails.
+// They are for test and staging deployments only.
+package chaos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/matttproud/yourtour/internal/reqlog"
+)
+
+// ErrInjected is the error returned by calls that fail by injection.
+var ErrInjected = errors.New("chaos: injected fault")
+
+// A Fault describes what to inject into a sample of calls.
+type Fault struct {
+	// Delay is the time each affected call is held before proceeding.
+	Delay time.Duration
+
+	// Status, if nonzero, makes each affected call fail after its delay.
+	// Requests are answered with this HTTP status;
+	// calls to dependencies return ErrInjected.
+	Status int
+
+	// Rate is the fraction of calls affected.
+	Rate float64
+}
+
+// Inject injects f into a call made with ctx:
+// for the fraction f.Rate of calls, it waits f.Delay
+// and then, if f.Status is set, returns ErrInjected.
+// If ctx is done while waiting, Inject returns ctx.Err().
+func (f Fault) Inject(ctx context.Context) error {
+	if !f.affects() {
+		return nil
+	}
+	if f.Delay > 0 {
+		t := time.NewTimer(f.Delay)
+		defer t.Stop()
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if f.Status != 0 {
+		return ErrInjected
+	}
+	return nil
+}
+
+// affects reports whether f applies to a call, by sampling at f.Rate.
+func (f Fault) affects() bool {
+	return f.Rate > 0 && (f.Rate >= 1 || rand.Float64() < f.Rate)
+}
+
+// String returns f in the form read by ParseRules.
+func (f Fault) String() string {
+	var parts []string
+	if f.Delay > 0 {
+		parts = append(parts, f.Delay.String())
+	}
+	if f.Status != 0 {
+		parts = append(parts, strconv.Itoa(f.Status))
+	}
+	s := strings.Join(parts, "+")
+	if f.Rate < 1 {
+		s += "@" + strconv.FormatFloat(f.Rate*100, 'g', -1, 64) + "%"
+	}
+	return s
+}
+
+// Rules map targets to the faults injected into them.
+// A target beginning with a slash is a URL path prefix,
+// applying to requests under it; the longest matching prefix applies.
+// Other targets name dependencies, such as "memcache" or "datastore".
+type Rules map[string]Fault
+
+// ParseRules parses a comma-separated list of rules of the form
+// target=fault, where fault is a delay, an error, or both joined by "+",
+// optionally followed by "@" and the percentage of calls affected,
+// which is otherwise 100%. An error is "error", meaning status 500,
+// or an HTTP status code. For example:
+//
+//	/dl/=2s@50%,/doc/codewalk/=503@10%,memcache=100ms+error@5%,datastore=error
+func ParseRules(s string) (Rules, error) {
+	rules := make(Rules)
+	for _, f := range strings.Split(s, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		target, spec, ok := strings.Cut(f, "=")
+		if !ok || target == "" || spec == "" {
+			return nil, fmt.Errorf("invalid rule %q; want target=fault", f)
+		}
+		fault, err := parseFault(spec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rule %q: %v", f, err)
+		}
+		rules[target] = fault
+	}
+	return rules, nil
+}
+
+func parseFault(spec string) (Fault, error) {
+	fault := Fault{Rate: 1}
+	spec, rate, ok := strings.Cut(spec, "@")
+	if ok {
+		pct, ok := strings.CutSuffix(rate, "%")
+		r, err := strconv.ParseFloat(pct, 64)
+		if !ok || err != nil || r <= 0 || r > 100 {
+			return Fault{}, fmt.Errorf("bad rate %q; want a percentage like 10%%", rate)
+		}
+		fault.Rate = r / 100
+	}
+	for _, part := range strings.Split(spec, "+") {
+		if part == "error" {
+			fault.Status = http.StatusInternalServerError
+		} else if code, err := strconv.Atoi(part); err == nil {
+			if code < 400 || code > 599 {
+				return Fault{}, fmt.Errorf("bad status %d; want an error status like 503", code)
+			}
+			fault.Status = code
+		} else {
+			d, err := time.ParseDuration(part)
+			if err != nil || d <= 0 {
+				return Fault{}, fmt.Errorf("bad fault %q; want a delay like 2s, an error status like 503, or error", part)
+			}
+			fault.Delay = d
+		}
+	}
+	return fault, nil
+}
+
+// For returns the fault for a request for path, if any.
+func (rs Rules) For(path string) (Fault, bool) {
+	best := ""
+	for p := range rs {
+		if strings.HasPrefix(p, "/") && strings.HasPrefix(path, p) && len(p) > len(best) {
+			best = p
+		}
+	}
+	if best == "" {
+		return Fault{}, false
+	}
+	return rs[best], true
+}
+
+// String returns the rules in the form read by ParseRules.
+func (rs Rules) String() string {
+	var list []string
+	for target, f := range rs {
+		list = append(list, target+"="+f.String())
+	}
+	sort.Strings(list)
+	return strings.Join(list, ",")
+}
+
+// Handler returns a handler that serves requests with h
+// after injecting the faults that rules give for their paths.
+// A request that fails by injection is answered with the fault's status.
+func Handler(h http.Handler, rules Rules) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f, ok := rules.For(r.URL.Path)
+		if !ok {
+			h.ServeHTTP(w, r)
+			return
+		}
+		switch err := f.Inject(r.Context()); err {
+		case nil:
+			h.ServeHTTP(w, r)
+		case ErrInjected:
+			reqlog.Error(w, r, "Injected fault.", f.Status)
+		default:
+			// The request was canceled or timed out while delayed;
+			// there is nobody to answer, or a timeout handler has.
+		}
+	})
+}
@@ -0,0 +1,117 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package chaos
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/matttproud/yourtour/internal/memcache"
+)
+
+func TestParseRules(t *testing.T) {
+	rules, err := ParseRules("/dl/=2s@50%, /doc/codewalk/=503@10%,memcache=100ms+error@5%,datastore=error")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := rules.String(); s != "/dl/=2s@50%,/doc/codewalk/=503@10%,datastore=500,memcache=100ms+500@5%" {
+		t.Errorf("String = %q", s)
+	}
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/", ""},
+		{"/dl/", "2s@50%"},
+		{"/dl/?mode=json", "2s@50%"},
+		{"/doc/codewalk/sharemem", "503@10%"},
+		{"/doc/", ""},
+	}
+	for _, tt := range tests {
+		got := ""
+		if f, ok := rules.For(tt.path); ok {
+			got = f.String()
+		}
+		if got != tt.want {
+			t.Errorf("For(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+
+	for _, s := range []string{"/dl/", "=2s", "/dl/=", "/dl/=slow", "/dl/=200", "/dl/=-1s", "/dl/=2s@50", "/dl/=2s@0%", "/dl/=error@200%"} {
+		if _, err := ParseRules(s); err == nil {
+			t.Errorf("ParseRules(%q) succeeded, want error", s)
+		}
+	}
+}
+
+func TestInject(t *testing.T) {
+	ctx := context.Background()
+	if err := (Fault{Delay: time.Millisecond, Rate: 1}).Inject(ctx); err != nil {
+		t.Errorf("delay: Inject = %v, want nil", err)
+	}
+	if err := (Fault{Status: 500, Rate: 1}).Inject(ctx); err != ErrInjected {
+		t.Errorf("error: Inject = %v, want ErrInjected", err)
+	}
+	if err := (Fault{Status: 500, Rate: 0}).Inject(ctx); err != nil {
+		t.Errorf("rate 0: Inject = %v, want nil", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := (Fault{Delay: time.Minute, Status: 500, Rate: 1}).Inject(ctx); err != context.DeadlineExceeded {
+		t.Errorf("canceled: Inject = %v, want DeadlineExceeded", err)
+	}
+	if d := time.Since(start); d > 10*time.Second {
+		t.Errorf("canceled: Inject took %v", d)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+	h := Handler(ok, Rules{"/dl/": {Status: 503, Rate: 1}, "memcache": {Status: 500, Rate: 1}})
+	tests := []struct {
+		path string
+		code int
+	}{
+		{"/", 200},
+		{"/memcache", 200},
+		{"/dl/", 503},
+		{"/dl/go1.22.0", 503},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+		if w.Code != tt.code {
+			t.Errorf("GET %s = %d, want %d", tt.path, w.Code, tt.code)
+		}
+	}
+}
+
+func TestCache(t *testing.T) {
+	ctx := context.Background()
+	mem := memcache.NewMemory(0)
+	if err := mem.Set(ctx, &memcache.Item{Key: "k", Value: []byte("v")}); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := NewCache(mem, Fault{Delay: time.Millisecond, Rate: 1}).Get(ctx, "k"); err != nil || string(v) != "v" {
+		t.Errorf("delayed Get = %q, %v, want v", v, err)
+	}
+	c := NewCache(mem, Fault{Status: 500, Rate: 1})
+	if _, err := c.Get(ctx, "k"); err != ErrInjected {
+		t.Errorf("failing Get = %v, want ErrInjected", err)
+	}
+	if err := c.Set(ctx, &memcache.Item{Key: "k", Value: []byte("w")}); err != ErrInjected {
+		t.Errorf("failing Set = %v, want ErrInjected", err)
+	}
+	if v, _ := mem.Get(ctx, "k"); string(v) != "v" {
+		t.Errorf("failing Set changed value to %q", v)
+	}
+}
@@ -0,0 +1,186 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package clientip finds the address of the client making a request
+// to a server behind proxies.
+//
+// Proxies report the address they received a request from by appending it
+// to the X-Forwarded-For header or, as RFC 7239 specifies, the Forwarded header.
+// A client can write anything in those headers itself, so a Resolver reads
+// them only as far back as they were written by proxies it trusts.
+// Handler records the resolved address in each request's context,
+// so that rate limiting, access control, and logging agree on it;
+// they read it with FromRequest.
+package clientip
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/matttproud/yourtour/internal/ipacl"
+)
+
+// A Resolver finds the client address of requests.
+// The zero Resolver trusts no proxies and always reports
+// the address of the connection's peer.
+type Resolver struct {
+	// Trusted lists the addresses of the proxies trusted to report
+	// the addresses they received requests from.
+	Trusted []netip.Prefix
+
+	// Hops, if nonzero, is the number of proxies in front of the server,
+	// for platforms whose proxy addresses are not known, such as App Engine.
+	// The client address is then the entry that many places from the end
+	// of the forwarding header, and Trusted is not consulted.
+	Hops int
+
+	// Forwarded reports whether the proxies write the Forwarded header
+	// rather than X-Forwarded-For. Only the header the proxies write
+	// is read, since the other holds whatever the client sent.
+	Forwarded bool
+}
+
+// ParseTrusted parses a comma-separated list of IP addresses
+// and CIDR prefixes of trusted proxies, such as "10.0.0.0/8,::1".
+func ParseTrusted(s string) ([]netip.Prefix, error) {
+	var list []netip.Prefix
+	for _, f := range strings.Split(s, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		p, err := ipacl.ParsePrefix(f)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, p)
+	}
+	return list, nil
+}
+
+// ClientIP returns the IP address of the client making request r.
+func (res *Resolver) ClientIP(r *http.Request) string {
+	peer := peerIP(r)
+	if res.Hops == 0 && len(res.Trusted) == 0 {
+		return peer
+	}
+	hops := res.forwarded(r)
+	if res.Hops > 0 {
+		if len(hops) >= res.Hops {
+			return hops[len(hops)-res.Hops]
+		}
+		return peer
+	}
+	if !res.trusted(peer) {
+		return peer
+	}
+	// Walk back through the proxies until one is untrusted.
+	// If a trusted proxy reported an address that cannot be parsed,
+	// that proxy is as far back as can be known.
+	client := peer
+	for i := len(hops) - 1; i >= 0; i-- {
+		a, err := netip.ParseAddr(hops[i])
+		if err != nil {
+			break
+		}
+		client = a.Unmap().String()
+		if !res.trusted(client) {
+			break
+		}
+	}
+	return client
+}
+
+// trusted reports whether ip is the address of a trusted proxy.
+func (res *Resolver) trusted(ip string) bool {
+	a, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	a = a.Unmap()
+	for _, p := range res.Trusted {
+		if p.Contains(a) {
+			return true
+		}
+	}
+	return false
+}
+
+// peerIP returns the IP address of the connection's peer.
+func peerIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// forwarded returns the addresses in the forwarding header of r,
+// first to last.
+func (res *Resolver) forwarded(r *http.Request) []string {
+	var hops []string
+	if res.Forwarded {
+		for _, h := range r.Header.Values("Forwarded") {
+			for _, elem := range strings.Split(h, ",") {
+				hops = append(hops, forwardedFor(elem))
+			}
+		}
+		return hops
+	}
+	for _, h := range r.Header.Values("X-Forwarded-For") {
+		for _, hop := range strings.Split(h, ",") {
+			hops = append(hops, strings.TrimSpace(hop))
+		}
+	}
+	return hops
+}
+
+// forwardedFor returns the address in the for parameter
+// of one element of a Forwarded header, without any port,
+// or the empty string if there is none.
+// For example, for `for="[2001:db8::1]:4711";proto=https`
+// it returns "2001:db8::1".
+func forwardedFor(elem string) string {
+	for _, pair := range strings.Split(elem, ";") {
+		k, v, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || !strings.EqualFold(k, "for") {
+			continue
+		}
+		v = strings.Trim(v, `"`)
+		if host, _, err := net.SplitHostPort(v); err == nil {
+			return host
+		}
+		return strings.Trim(v, "[]")
+	}
+	return ""
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx recording ip
+// as the client address of its request.
+func NewContext(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, contextKey{}, ip)
+}
+
+// FromRequest returns the client address of r
+// recorded by Handler, or else the address of the connection's peer.
+func FromRequest(r *http.Request) string {
+	if ip, ok := r.Context().Value(contextKey{}).(string); ok {
+		return ip
+	}
+	return peerIP(r)
+}
+
+// Handler returns a handler that serves requests with h
+// after recording their client addresses, as found by res,
+// for FromRequest.
+func (res *Resolver) Handler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.ServeHTTP(w, r.WithContext(NewContext(r.Context(), res.ClientIP(r))))
+	})
+}
@@ -0,0 +1,78 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package clientip
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	trusted, err := ParseTrusted("10.0.0.0/8, 2001:db8::/32")
+	if err != nil {
+		t.Fatal(err)
+	}
+	byXFF := &Resolver{Trusted: trusted}
+	byForwarded := &Resolver{Trusted: trusted, Forwarded: true}
+	byHops := &Resolver{Hops: 2}
+	tests := []struct {
+		name   string
+		res    *Resolver
+		remote string
+		xff    string
+		fwd    string
+		want   string
+	}{
+		{"no proxies", &Resolver{}, "192.0.2.1:1234", "198.51.100.1", "", "192.0.2.1"},
+		{"untrusted peer", byXFF, "192.0.2.1:1234", "198.51.100.1", "", "192.0.2.1"},
+		{"trusted peer", byXFF, "10.0.0.1:1234", "198.51.100.1", "", "198.51.100.1"},
+		{"spoofed", byXFF, "10.0.0.1:1234", "203.0.113.9, 198.51.100.1", "", "198.51.100.1"},
+		{"proxy chain", byXFF, "10.0.0.1:1234", "203.0.113.9, 198.51.100.1, 10.1.1.1", "", "198.51.100.1"},
+		{"all trusted", byXFF, "10.0.0.1:1234", "10.2.2.2, 10.1.1.1", "", "10.2.2.2"},
+		{"no header", byXFF, "10.0.0.1:1234", "", "", "10.0.0.1"},
+		{"garbage", byXFF, "10.0.0.1:1234", "198.51.100.1, unknown", "", "10.0.0.1"},
+		{"ipv6 peer", byXFF, "[2001:db8::1]:1234", "198.51.100.1", "", "198.51.100.1"},
+		{"mapped", byXFF, "10.0.0.1:1234", "::ffff:198.51.100.1", "", "198.51.100.1"},
+		{"xff ignored", byForwarded, "10.0.0.1:1234", "198.51.100.1", "", "10.0.0.1"},
+		{"forwarded", byForwarded, "10.0.0.1:1234", "", `for=198.51.100.1;proto=https, for="[2001:db8::2]:4711"`, "198.51.100.1"},
+		{"forwarded ignored", byXFF, "10.0.0.1:1234", "", "for=198.51.100.1", "10.0.0.1"},
+		{"hops", byHops, "169.254.1.1:1234", "203.0.113.9, 198.51.100.1, 192.0.2.200", "", "198.51.100.1"},
+		{"too few hops", byHops, "169.254.1.1:1234", "198.51.100.1", "", "169.254.1.1"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = tt.remote
+		if tt.xff != "" {
+			r.Header.Set("X-Forwarded-For", tt.xff)
+		}
+		if tt.fwd != "" {
+			r.Header.Set("Forwarded", tt.fwd)
+		}
+		if got := tt.res.ClientIP(r); got != tt.want {
+			t.Errorf("%s: ClientIP = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	if _, err := ParseTrusted("10.0.0.0/8,proxy"); err == nil {
+		t.Errorf("ParseTrusted(proxy) succeeded, want error")
+	}
+}
+
+func TestHandler(t *testing.T) {
+	var got string
+	h := (&Resolver{Hops: 1}).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = FromRequest(r)
+	}))
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("X-Forwarded-For", "198.51.100.1")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	if got != "198.51.100.1" {
+		t.Errorf("FromRequest in handler = %q, want 198.51.100.1", got)
+	}
+	if ip := FromRequest(r); ip != "192.0.2.1" {
+		t.Errorf("FromRequest outside handler = %q, want peer 192.0.2.1", ip)
+	}
+}
@@ -0,0 +1,216 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package codewalk
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+)
+
+// BundleFile is the name of the bundle of codewalks in the content.
+const BundleFile = "doc/codewalk/bundle.json"
+
+// A bundle is the codewalks in the doc/codewalk tree of some content,
+// with their source addresses resolved and their prose sanitized,
+// so that serving them needs neither parsing nor address evaluation.
+type bundle struct {
+	Codewalks map[string]*bundledCodewalk `json:"codewalks"` // by name of XML file
+}
+
+// A bundledCodewalk is a codewalk in a bundle.
+type bundledCodewalk struct {
+	// Sum is the checksum of the codewalk's description and source files,
+	// in sources, as computed by sum, for checking that it is up to date.
+	Sum     string   `json:"sum"`
+	Sources []string `json:"sources,omitempty"`
+
+	Title   string        `json:"title"`
+	Authors string        `json:"authors,omitempty"`
+	Steps   []bundledStep `json:"steps"`
+}
+
+// A bundledStep is a step of a bundledCodewalk.
+type bundledStep struct {
+	Src     string `json:"src"`
+	Title   string `json:"title"`
+	Prose   string `json:"prose"` // sanitized HTML
+	Err     string `json:"err,omitempty"`
+	File    string `json:"file,omitempty"`
+	Lo      int    `json:"lo,omitempty"`
+	Hi      int    `json:"hi,omitempty"`
+	Excerpt string `json:"excerpt,omitempty"`
+}
+
+// BuildBundle returns the bundle of the codewalks in the
+// doc/codewalk tree of fsys, for writing to BundleFile.
+// A codewalk server reads the codewalks from the bundle
+// instead of their descriptions, as long as those and
+// their source files are unchanged.
+func BuildBundle(fsys fs.FS) ([]byte, error) {
+	names, err := fs.Glob(fsys, "doc/codewalk/*.xml")
+	if err != nil {
+		return nil, err
+	}
+	b := &bundle{Codewalks: make(map[string]*bundledCodewalk)}
+	for _, name := range names {
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		cw, err := parseCodewalk(fsys, name, data)
+		if err != nil {
+			return nil, err
+		}
+		bc := &bundledCodewalk{Title: cw.Title, Authors: cw.Authors}
+		for _, st := range cw.Step {
+			bs := bundledStep{
+				Src:     st.Src,
+				Title:   st.Title,
+				Prose:   string(st.HTML()),
+				File:    st.File,
+				Lo:      st.Lo,
+				Hi:      st.Hi,
+				Excerpt: st.excerpt,
+			}
+			if st.Err != nil {
+				bs.Err = st.Err.Error()
+			}
+			bc.Steps = append(bc.Steps, bs)
+			if f := srcFile(st.Src); !slices.Contains(bc.Sources, f) {
+				bc.Sources = append(bc.Sources, f)
+			}
+		}
+		slices.Sort(bc.Sources)
+		bc.Sum = sum(fsys, data, bc.Sources)
+		b.Codewalks[name] = bc
+	}
+	return json.Marshal(b)
+}
+
+type unbundledKey struct{}
+
+// Unbundled returns a handler that serves requests with h,
+// whose codewalk servers then render the codewalks from their
+// descriptions instead of the bundle, as they do without one.
+func Unbundled(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), unbundledKey{}, true)))
+	})
+}
+
+// readBundle returns the bundle in fsys,
+// or nil if there is none or it cannot be read.
+func readBundle(fsys fs.FS) *bundle {
+	data, err := fs.ReadFile(fsys, BundleFile)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			log.Printf("ERROR reading codewalk bundle: %v", err)
+		}
+		return nil
+	}
+	b := new(bundle)
+	if err := json.Unmarshal(data, b); err != nil {
+		log.Printf("ERROR reading codewalk bundle: %s: %v", BundleFile, err)
+		return nil
+	}
+	return b
+}
+
+// lookup returns the codewalk in b described by data, read from the named
+// XML file in fsys, or nil if b does not have it or it is out of date.
+func (b *bundle) lookup(fsys fs.FS, filename string, data []byte) *codewalk {
+	if b == nil {
+		return nil
+	}
+	bc := b.Codewalks[filename]
+	if bc == nil || bc.Sum != sum(fsys, data, bc.Sources) {
+		return nil
+	}
+	cw := &codewalk{Title: bc.Title, Authors: bc.Authors}
+	for _, bs := range bc.Steps {
+		st := &codestep{
+			Src:     bs.Src,
+			Title:   bs.Title,
+			File:    bs.File,
+			Lo:      bs.Lo,
+			Hi:      bs.Hi,
+			excerpt: bs.Excerpt,
+			prose:   template.HTML(bs.Prose),
+		}
+		if bs.Err != "" {
+			st.Err = errors.New(bs.Err)
+		}
+		cw.Step = append(cw.Step, st)
+	}
+	cw.setFiles()
+	return cw
+}
+
+// A bundleCheck records the codewalks of a bundle checked
+// against a version of the content.
+type bundleCheck struct {
+	version   string
+	codewalks map[string]*codewalk // by name of XML file; nil if out of date
+}
+
+// lookupChecked returns the codewalk of the bundle of s read from
+// the named XML file, as checked against the version of its content.
+// It reports whether the codewalk was checked: if so, a nil codewalk
+// means that the bundle does not have it up to date.
+// Nothing has been checked against the empty version.
+func (s *server) lookupChecked(version, filename string) (cw *codewalk, ok bool) {
+	if version == "" {
+		return nil, false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.checked.version != version {
+		return nil, false
+	}
+	cw, ok = s.checked.codewalks[filename]
+	return cw, ok
+}
+
+// recordChecked records cw as the result of checking the codewalk
+// of the bundle of s read from the named XML file against the version
+// of its content, forgetting the checks of other versions.
+func (s *server) recordChecked(version, filename string, cw *codewalk) {
+	if version == "" {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.checked.version != version {
+		s.checked = bundleCheck{version: version, codewalks: make(map[string]*codewalk)}
+	}
+	s.checked.codewalks[filename] = cw
+}
+
+// sum returns the checksum of the codewalk described by data
+// and of the named source files in fsys, which it reads.
+func sum(fsys fs.FS, data []byte, sources []string) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%d\n%s", len(data), data)
+	for _, name := range sources {
+		src, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			// A missing file is part of the state too.
+			fmt.Fprintf(h, "%s missing\n", strconv.Quote(name))
+			continue
+		}
+		fmt.Fprintf(h, "%s %d\n%s", strconv.Quote(name), len(src), src)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
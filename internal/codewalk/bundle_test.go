@@ -0,0 +1,149 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package codewalk
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/matttproud/yourtour/internal/web"
+)
+
+func TestBundleContent(t *testing.T) {
+	fsys := os.DirFS("../../_content")
+	data, err := BuildBundle(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bundle
+	if err := json.Unmarshal(data, &b); err != nil {
+		t.Fatal(err)
+	}
+	paths, err := Paths(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b.Codewalks) != len(paths) {
+		t.Errorf("bundle has %d codewalks, want %d", len(b.Codewalks), len(paths))
+	}
+	s := &server{fsys: fsys}
+	for name := range b.Codewalks {
+		want, err := s.loadCodewalk(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		xml, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := b.lookup(fsys, name, xml)
+		if got == nil {
+			t.Errorf("%s: bundled codewalk is out of date", name)
+			continue
+		}
+		if got.Title != want.Title || strings.Join(got.File, " ") != strings.Join(want.File, " ") || len(got.Step) != len(want.Step) {
+			t.Errorf("%s: bundled %q with files %v and %d steps, want %q with %v and %d", name, got.Title, got.File, len(got.Step), want.Title, want.File, len(want.Step))
+			continue
+		}
+		for i, st := range got.Step {
+			w := want.Step[i]
+			if st.String() != w.String() || st.HTML() != w.HTML() || st.Excerpt() != w.Excerpt() {
+				t.Errorf("%s: step %d: bundled %v, want %v", name, i, st, w)
+			}
+		}
+	}
+}
+
+func TestBundleServe(t *testing.T) {
+	fsys := fstest.MapFS{
+		"site.tmpl":             {Data: []byte(`{{block "layout" .}}{{end}}`)},
+		"codewalk.tmpl":         {Data: []byte(`{{define "layout"}}{{.title}}:{{range .codewalk.Step}} {{.}} [{{.Excerpt}}]{{end}}{{end}}`)},
+		"doc/codewalk/walk.xml": {Data: []byte(`<codewalk title="Walk"><step title="Main" src="doc/codewalk/x.go:/func main/">Hi.</step></codewalk>`)},
+		"doc/codewalk/x.go":     {Data: []byte("package main\n\nfunc main() {}\n")},
+	}
+	data, err := BuildBundle(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Retitle the bundled codewalk, to tell when it is served.
+	fsys[BundleFile] = &fstest.MapFile{Data: bytes.Replace(data, []byte(`"title":"Walk"`), []byte(`"title":"Bundled"`), 1)}
+
+	serve := func(wrap func(http.Handler) http.Handler) string {
+		w := httptest.NewRecorder()
+		wrap(NewServer(fsys, web.NewSite(fsys))).ServeHTTP(w, httptest.NewRequest("GET", "/doc/codewalk/walk/", nil))
+		return w.Body.String()
+	}
+	get := func() string {
+		return serve(func(h http.Handler) http.Handler { return h })
+	}
+	if body, want := get(), "Codewalk: Bundled: doc/codewalk/x.go:3 [func main() {}\n]"; body != want {
+		t.Errorf("with bundle, page = %q, want %q", body, want)
+	}
+	if body, want := serve(Unbundled), "Codewalk: Walk: doc/codewalk/x.go:3 [func main() {}\n]"; body != want {
+		t.Errorf("with bundle, unbundled page = %q, want %q", body, want)
+	}
+
+	fsys["doc/codewalk/x.go"] = &fstest.MapFile{Data: []byte("package main\n\n// main does nothing.\nfunc main() {}\n")}
+	if body, want := get(), "Codewalk: Walk: doc/codewalk/x.go:4 [func main() {}\n]"; body != want {
+		t.Errorf("with outdated bundle, page = %q, want %q", body, want)
+	}
+
+	fsys[BundleFile] = &fstest.MapFile{Data: []byte("{")}
+	if body, want := get(), "Codewalk: Walk: doc/codewalk/x.go:4 [func main() {}\n]"; body != want {
+		t.Errorf("with broken bundle, page = %q, want %q", body, want)
+	}
+}
+
+func TestBundleVersion(t *testing.T) {
+	fsys := fstest.MapFS{
+		"site.tmpl":             {Data: []byte(`{{block "layout" .}}{{end}}`)},
+		"codewalk.tmpl":         {Data: []byte(`{{define "layout"}}{{.title}}:{{range .codewalk.Step}} {{.}}{{end}}{{end}}`)},
+		"doc/codewalk/walk.xml": {Data: []byte(`<codewalk title="Walk"><step title="Main" src="doc/codewalk/x.go:/func main/">Hi.</step></codewalk>`)},
+		"doc/codewalk/x.go":     {Data: []byte("package main\n\nfunc main() {}\n")},
+	}
+	data, err := BuildBundle(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fsys[BundleFile] = &fstest.MapFile{Data: bytes.Replace(data, []byte(`"title":"Walk"`), []byte(`"title":"Bundled"`), 1)}
+
+	version := "v1"
+	s := newServer(fsys, web.NewSite(fsys), func() string { return version })
+	get := func() string {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest("GET", "/doc/codewalk/walk/", nil))
+		return w.Body.String()
+	}
+	if body, want := get(), "Codewalk: Bundled: doc/codewalk/x.go:3"; body != want {
+		t.Errorf("at v1, page = %q, want %q", body, want)
+	}
+
+	// Within a version, the bundled codewalk is not checked again.
+	fsys["doc/codewalk/x.go"] = &fstest.MapFile{Data: []byte("package main\n\n// main does nothing.\nfunc main() {}\n")}
+	if body, want := get(), "Codewalk: Bundled: doc/codewalk/x.go:3"; body != want {
+		t.Errorf("at v1 after edit, page = %q, want %q", body, want)
+	}
+
+	version = "v2"
+	for i := 0; i < 2; i++ {
+		if body, want := get(), "Codewalk: Walk: doc/codewalk/x.go:4"; body != want {
+			t.Errorf("at v2, request %d: page = %q, want %q", i, body, want)
+		}
+	}
+
+	// An unknown version checks the bundle on every request.
+	version = ""
+	fsys["doc/codewalk/x.go"] = &fstest.MapFile{Data: []byte("package main\n\nfunc main() {}\n")}
+	if body, want := get(), "Codewalk: Bundled: doc/codewalk/x.go:3"; body != want {
+		t.Errorf("at unknown version, page = %q, want %q", body, want)
+	}
+}
@@ -13,37 +13,178 @@
 package codewalk
 
 import (
+	"bytes"
+	"context"
 	"encoding/xml"
 	"errors"
 	"fmt"
 	"html/template"
 	"io"
 	"io/fs"
-	"log"
 	"net/http"
 	"os"
 	"path"
-	"regexp"
 	"sort"
 	"strconv"
 	"strings"
-	"unicode/utf8"
+	"sync"
 
+	"github.com/matttproud/yourtour/internal/canonical"
+	"github.com/matttproud/yourtour/internal/excerpt"
+	"github.com/matttproud/yourtour/internal/vhost"
 	"github.com/matttproud/yourtour/internal/web"
 )
 
 type server struct {
-	fsys fs.FS
-	site *web.Site
+	fsys    fs.FS
+	site    *web.Site
+	bundle  *bundle       // read at startup; nil if there is none
+	version func() string // version of fsys; nil if it is not versioned
+
+	mu      sync.Mutex
+	checked bundleCheck // the bundle's codewalks checked at the latest version
 }
 
-// NewServer returns a new server handling codewalk documents.
+// NewServer returns a new server handling codewalk documents,
+// rendering them with site, on which it records the contract of CodewalkPage.
+// The server reads the codewalks from the bundle in fsys, if there is one,
+// as long as their descriptions and source files are unchanged.
 func NewServer(fsys fs.FS, site *web.Site) http.Handler {
-	return &server{fsys, site}
+	return newServer(fsys, site, nil)
+}
+
+// newServer returns a new server as for NewServer.
+// If version is not nil, it reports the version of fsys,
+// and the server checks that a bundled codewalk is up to date
+// only once per version, instead of on every request.
+func newServer(fsys fs.FS, site *web.Site, version func() string) *server {
+	site.AddContract(CodewalkPage{})
+	return &server{fsys: fsys, site: site, bundle: readBundle(fsys), version: version}
+}
+
+// A CodewalkPage is the data of a codewalk page, rendered by codewalk.tmpl.
+type CodewalkPage struct {
+	Title    string    `page:"title"`
+	TabTitle string    `page:"tabTitle"`
+	Codewalk *codewalk `page:"codewalk"`
+	Authors  []string  `page:"authors,omitempty"` // IDs, as in the site's authors.yaml
+}
+
+func (CodewalkPage) Layout() string { return "codewalk" }
+
+// RegisterHandlers registers the server for the codewalk documents
+// of the virtual host h, in h.FS, to serve /doc/codewalk/ and below.
+func RegisterHandlers(h *vhost.Host) {
+	h.Router.Handle("GET", "/doc/codewalk/", newServer(h.FS, h.Site, h.Version))
+	h.Site.AddSuggester(func(context.Context) ([]string, error) {
+		return Paths(h.FS)
+	})
+}
+
+// Paths returns the URL paths of the codewalks in the doc/codewalk tree of fsys.
+func Paths(fsys fs.FS) ([]string, error) {
+	names, err := fs.Glob(fsys, "doc/codewalk/*.xml")
+	var list []string
+	for _, name := range names {
+		list = append(list, "/"+strings.TrimSuffix(name, ".xml"))
+	}
+	return list, err
+}
+
+// A Codewalk is a codewalk document, as returned by List and Load.
+type Codewalk struct {
+	Path    string // URL path, such as /doc/codewalk/sharemem
+	Title   string
+	Authors []string // IDs of the authors, as in the site's authors.yaml
+	Files   []string
+	Steps   []Step
+}
+
+// A Step is a single step in a Codewalk.
+type Step struct {
+	Title string
+	Src   string // source address, as written in the codewalk
+	Prose string // sanitized HTML
+	File  string // empty if Src cannot be resolved
+	Lo    int    // first line shown, or 0 for the whole file
+	Hi    int    // last line shown, or 0 for the whole file
+}
+
+// List returns the codewalks in the doc/codewalk tree of fsys, sorted by path.
+func List(fsys fs.FS) ([]*Codewalk, error) {
+	paths, err := Paths(fsys)
+	if err != nil {
+		return nil, err
+	}
+	var list []*Codewalk
+	for _, p := range paths {
+		c, err := Load(fsys, p)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, c)
+	}
+	return list, nil
+}
+
+// Load returns the codewalk with the URL path p,
+// such as /doc/codewalk/sharemem, in the doc/codewalk tree of fsys.
+func Load(fsys fs.FS, p string) (*Codewalk, error) {
+	s := &server{fsys: fsys}
+	cw, err := s.loadCodewalk(strings.TrimPrefix(p, "/") + ".xml")
+	if err != nil {
+		return nil, err
+	}
+	c := &Codewalk{Path: p, Title: cw.Title, Authors: cw.authors(), Files: cw.File}
+	for _, st := range cw.Step {
+		c.Steps = append(c.Steps, Step{
+			Title: st.Title,
+			Src:   st.Src,
+			Prose: string(st.HTML()),
+			File:  st.File,
+			Lo:    st.Lo,
+			Hi:    st.Hi,
+		})
+	}
+	return c, nil
+}
+
+// Validate loads every codewalk in the doc/codewalk tree of fsys,
+// reporting any that cannot be parsed or that have a step
+// whose source address cannot be resolved.
+func Validate(fsys fs.FS) error {
+	s := &server{fsys: fsys}
+	var errs []error
+	err := fs.WalkDir(fsys, "doc/codewalk", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(name, ".xml") {
+			return nil
+		}
+		cw, err := s.loadCodewalk(name)
+		if err != nil {
+			errs = append(errs, err)
+			return nil
+		}
+		for _, st := range cw.Step {
+			if st.Err != nil {
+				errs = append(errs, fmt.Errorf("%s: step %q (%s): %v", name, st.Title, st.Src, st.Err))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
 }
 
 // Handler for /doc/codewalk/ and below.
 func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Context().Value(unbundledKey{}) != nil {
+		s = &server{fsys: s.fsys, site: s.site}
+	}
 	relpath := path.Clean(r.URL.Path[1:])
 
 	r.ParseForm()
@@ -70,43 +211,37 @@ func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// the trailing /.
 	cw, err := s.loadCodewalk(relpath + ".xml")
 	if err != nil {
-		log.Print(err)
 		s.site.ServeError(w, r, err)
 		return
 	}
 
 	// Canonicalize the path and redirect if changed
-	if redir(w, r) {
+	if canonical.Dir(w, r) {
 		return
 	}
 
-	s.site.ServePage(w, r, web.Page{
-		"title":    "Codewalk: " + cw.Title,
-		"tabTitle": cw.Title,
-		"layout":   "codewalk",
-		"codewalk": cw,
-	})
-}
-
-func redir(w http.ResponseWriter, r *http.Request) (redirected bool) {
-	canonical := path.Clean(r.URL.Path)
-	if !strings.HasSuffix(canonical, "/") {
-		canonical += "/"
-	}
-	if r.URL.Path != canonical {
-		url := *r.URL
-		url.Path = canonical
-		http.Redirect(w, r, url.String(), http.StatusMovedPermanently)
-		redirected = true
-	}
-	return
+	s.site.ServePage(w, r, web.PageOf(CodewalkPage{
+		Title:    "Codewalk: " + cw.Title,
+		TabTitle: cw.Title,
+		Codewalk: cw,
+		Authors:  cw.authors(),
+	}))
 }
 
 // A codewalk represents a single codewalk read from an XML file.
 type codewalk struct {
-	Title string      `xml:"title,attr"`
-	File  []string    `xml:"file"`
-	Step  []*codestep `xml:"step"`
+	Title   string      `xml:"title,attr"`
+	Authors string      `xml:"authors,attr"` // space-separated author IDs
+	File    []string    `xml:"file"`
+	Step    []*codestep `xml:"step"`
+}
+
+// authors returns the IDs of the codewalk's authors, or nil if it names none.
+func (cw *codewalk) authors() []string {
+	if strings.TrimSpace(cw.Authors) == "" {
+		return nil
+	}
+	return strings.Fields(cw.Authors)
 }
 
 // A codestep is a single step in a codewalk.
@@ -117,17 +252,30 @@ type codestep struct {
 	XML   string `xml:",innerxml"`
 
 	// Derived from Src; not in XML.
-	Err    error
-	File   string
-	Lo     int
-	LoByte int
-	Hi     int
-	HiByte int
-	Data   []byte
+	Err     error
+	File    string
+	Lo      int
+	LoByte  int
+	Hi      int
+	HiByte  int
+	excerpt string // lines Lo through Hi of File
+
+	prose template.HTML // sanitized XML, if bundled
 }
 
+// HTML returns the step's prose, sanitized for inclusion in the page.
 func (c *codestep) HTML() template.HTML {
-	return template.HTML(c.XML)
+	if c.prose != "" {
+		return c.prose
+	}
+	return web.DefaultSanitizer.Sanitize(c.XML)
+}
+
+// Excerpt returns the lines of the step's file that the step is about,
+// for showing them inline, as text-only pages do instead of the code pane,
+// or the empty string if the step names no lines.
+func (c *codestep) Excerpt() string {
+	return c.excerpt
 }
 
 // String method for printing in template.
@@ -143,65 +291,83 @@ func (st *codestep) String() string {
 	return s
 }
 
-// loadCodewalk reads a codewalk from the named XML file.
+// srcFile returns the name of the file of the source address src.
+func srcFile(src string) string {
+	file, _, _ := strings.Cut(src, ":")
+	return file
+}
+
+// loadCodewalk reads a codewalk from the named XML file,
+// or from the bundle of s if it has the codewalk, up to date.
 func (s *server) loadCodewalk(filename string) (*codewalk, error) {
-	f, err := s.fsys.Open(filename)
+	version := ""
+	if s.bundle != nil && s.version != nil {
+		version = s.version()
+	}
+	cw, ok := s.lookupChecked(version, filename)
+	if cw != nil {
+		return cw, nil
+	}
+	data, err := fs.ReadFile(s.fsys, filename)
 	if err != nil {
 		return nil, err
 	}
-	defer f.Close()
+	if !ok {
+		cw = s.bundle.lookup(s.fsys, filename, data)
+		s.recordChecked(version, filename, cw)
+		if cw != nil {
+			return cw, nil
+		}
+	}
+	return parseCodewalk(s.fsys, filename, data)
+}
+
+// parseCodewalk parses the codewalk in data, read from the named XML file,
+// resolving the source addresses of its steps in fsys.
+func parseCodewalk(fsys fs.FS, filename string, data []byte) (*codewalk, error) {
 	cw := new(codewalk)
-	d := xml.NewDecoder(f)
+	d := xml.NewDecoder(bytes.NewReader(data))
 	d.Entity = xml.HTMLEntity
-	err = d.Decode(cw)
-	if err != nil {
+	if err := d.Decode(cw); err != nil {
 		return nil, &os.PathError{Op: "parsing", Path: filename, Err: err}
 	}
 
-	// Compute file list, evaluate line numbers for addresses.
-	m := make(map[string]bool)
+	// Evaluate line numbers for addresses.
 	for _, st := range cw.Step {
-		i := strings.Index(st.Src, ":")
-		if i < 0 {
-			i = len(st.Src)
-		}
-		filename := st.Src[0:i]
-		data, err := fs.ReadFile(s.fsys, filename)
+		filename, addr, ok := strings.Cut(st.Src, ":")
+		data, err := fs.ReadFile(fsys, filename)
 		if err != nil {
 			st.Err = err
 			continue
 		}
-		if i < len(st.Src) {
-			lo, hi, err := addrToByteRange(st.Src[i+1:], 0, data)
+		if ok {
+			lo, hi, err := excerpt.Lines(data, addr)
 			if err != nil {
 				st.Err = err
 				continue
 			}
-			// Expand match to line boundaries.
-			for lo > 0 && data[lo-1] != '\n' {
-				lo--
-			}
-			for hi < len(data) && (hi == 0 || data[hi-1] != '\n') {
-				hi++
-			}
-			st.Lo = byteToLine(data, lo)
-			st.Hi = byteToLine(data, hi-1)
+			st.Lo, st.Hi = lo, hi
+			st.excerpt = string(data[excerpt.LineOffset(data, lo):excerpt.LineOffset(data, hi+1)])
 		}
-		st.Data = data
 		st.File = filename
-		m[filename] = true
 	}
+	cw.setFiles()
+	return cw, nil
+}
 
-	// Make list of files
-	cw.File = make([]string, len(m))
-	i := 0
+// setFiles sets the list of files of cw to the files of its resolved steps.
+func (cw *codewalk) setFiles() {
+	m := make(map[string]bool)
+	for _, st := range cw.Step {
+		if st.File != "" {
+			m[st.File] = true
+		}
+	}
+	cw.File = make([]string, 0, len(m))
 	for f := range m {
-		cw.File[i] = f
-		i++
+		cw.File = append(cw.File, f)
 	}
 	sort.Strings(cw.File)
-
-	return cw, nil
 }
 
 // codewalkDir serves the codewalk directory listing.
@@ -215,7 +381,6 @@ func (s *server) codewalkDir(w http.ResponseWriter, r *http.Request, relpath str
 
 	dir, err := fs.ReadDir(s.fsys, relpath)
 	if err != nil {
-		log.Print(err)
 		s.site.ServeError(w, r, err)
 		return
 	}
@@ -250,7 +415,6 @@ func (s *server) codewalkFileprint(w http.ResponseWriter, r *http.Request, f str
 	relpath := strings.Trim(path.Clean(f), "/")
 	data, err := fs.ReadFile(s.fsys, relpath)
 	if err != nil {
-		log.Print(err)
 		s.site.ServeError(w, r, err)
 		return
 	}
@@ -259,8 +423,8 @@ func (s *server) codewalkFileprint(w http.ResponseWriter, r *http.Request, f str
 	if hi < lo {
 		hi = lo
 	}
-	lo = lineToByte(data, lo)
-	hi = lineToByte(data, hi+1)
+	lo = excerpt.LineOffset(data, lo)
+	hi = excerpt.LineOffset(data, hi+1)
 
 	// Put the mark 4 lines before lo, so that the iframe
 	// shows a few lines of context before the highlighted
@@ -287,240 +451,3 @@ func (s *server) codewalkFileprint(w http.ResponseWriter, r *http.Request, f str
 	template.HTMLEscape(w, data[hi:])
 	io.WriteString(w, "</pre>")
 }
-
-// addrToByteRange evaluates the given address starting at offset start in data.
-// It returns the lo and hi byte offset of the matched region within data.
-// See https://9p.io/sys/doc/sam/sam.html Table II
-// for details on the syntax.
-func addrToByteRange(addr string, start int, data []byte) (lo, hi int, err error) {
-	var (
-		dir        byte
-		prevc      byte
-		charOffset bool
-	)
-	lo = start
-	hi = start
-	for addr != "" && err == nil {
-		c := addr[0]
-		switch c {
-		default:
-			err = errors.New("invalid address syntax near " + string(c))
-		case ',':
-			if len(addr) == 1 {
-				hi = len(data)
-			} else {
-				_, hi, err = addrToByteRange(addr[1:], hi, data)
-			}
-			return
-
-		case '+', '-':
-			if prevc == '+' || prevc == '-' {
-				lo, hi, err = addrNumber(data, lo, hi, prevc, 1, charOffset)
-			}
-			dir = c
-
-		case '$':
-			lo = len(data)
-			hi = len(data)
-			if len(addr) > 1 {
-				dir = '+'
-			}
-
-		case '#':
-			charOffset = true
-
-		case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
-			var i int
-			for i = 1; i < len(addr); i++ {
-				if addr[i] < '0' || addr[i] > '9' {
-					break
-				}
-			}
-			var n int
-			n, err = strconv.Atoi(addr[0:i])
-			if err != nil {
-				break
-			}
-			lo, hi, err = addrNumber(data, lo, hi, dir, n, charOffset)
-			dir = 0
-			charOffset = false
-			prevc = c
-			addr = addr[i:]
-			continue
-
-		case '/':
-			var i, j int
-		Regexp:
-			for i = 1; i < len(addr); i++ {
-				switch addr[i] {
-				case '\\':
-					i++
-				case '/':
-					j = i + 1
-					break Regexp
-				}
-			}
-			if j == 0 {
-				j = i
-			}
-			pattern := addr[1:i]
-			lo, hi, err = addrRegexp(data, lo, hi, dir, pattern)
-			prevc = c
-			addr = addr[j:]
-			continue
-		}
-		prevc = c
-		addr = addr[1:]
-	}
-
-	if err == nil && dir != 0 {
-		lo, hi, err = addrNumber(data, lo, hi, dir, 1, charOffset)
-	}
-	if err != nil {
-		return 0, 0, err
-	}
-	return lo, hi, nil
-}
-
-// addrNumber applies the given dir, n, and charOffset to the address lo, hi.
-// dir is '+' or '-', n is the count, and charOffset is true if the syntax
-// used was #n.  Applying +n (or +#n) means to advance n lines
-// (or characters) after hi.  Applying -n (or -#n) means to back up n lines
-// (or characters) before lo.
-// The return value is the new lo, hi.
-func addrNumber(data []byte, lo, hi int, dir byte, n int, charOffset bool) (int, int, error) {
-	switch dir {
-	case 0:
-		lo = 0
-		hi = 0
-		fallthrough
-
-	case '+':
-		if charOffset {
-			pos := hi
-			for ; n > 0 && pos < len(data); n-- {
-				_, size := utf8.DecodeRune(data[pos:])
-				pos += size
-			}
-			if n == 0 {
-				return pos, pos, nil
-			}
-			break
-		}
-		// find next beginning of line
-		if hi > 0 {
-			for hi < len(data) && data[hi-1] != '\n' {
-				hi++
-			}
-		}
-		lo = hi
-		if n == 0 {
-			return lo, hi, nil
-		}
-		for ; hi < len(data); hi++ {
-			if data[hi] != '\n' {
-				continue
-			}
-			switch n--; n {
-			case 1:
-				lo = hi + 1
-			case 0:
-				return lo, hi + 1, nil
-			}
-		}
-
-	case '-':
-		if charOffset {
-			// Scan backward for bytes that are not UTF-8 continuation bytes.
-			pos := lo
-			for ; pos > 0 && n > 0; pos-- {
-				if data[pos]&0xc0 != 0x80 {
-					n--
-				}
-			}
-			if n == 0 {
-				return pos, pos, nil
-			}
-			break
-		}
-		// find earlier beginning of line
-		for lo > 0 && data[lo-1] != '\n' {
-			lo--
-		}
-		hi = lo
-		if n == 0 {
-			return lo, hi, nil
-		}
-		for ; lo >= 0; lo-- {
-			if lo > 0 && data[lo-1] != '\n' {
-				continue
-			}
-			switch n--; n {
-			case 1:
-				hi = lo
-			case 0:
-				return lo, hi, nil
-			}
-		}
-	}
-
-	return 0, 0, errors.New("address out of range")
-}
-
-// addrRegexp searches for pattern in the given direction starting at lo, hi.
-// The direction dir is '+' (search forward from hi) or '-' (search backward from lo).
-// Backward searches are unimplemented.
-func addrRegexp(data []byte, lo, hi int, dir byte, pattern string) (int, int, error) {
-	re, err := regexp.Compile(pattern)
-	if err != nil {
-		return 0, 0, err
-	}
-	if dir == '-' {
-		// Could implement reverse search using binary search
-		// through file, but that seems like overkill.
-		return 0, 0, errors.New("reverse search not implemented")
-	}
-	m := re.FindIndex(data[hi:])
-	if len(m) > 0 {
-		m[0] += hi
-		m[1] += hi
-	} else if hi > 0 {
-		// No match.  Wrap to beginning of data.
-		m = re.FindIndex(data)
-	}
-	if len(m) == 0 {
-		return 0, 0, errors.New("no match for " + pattern)
-	}
-	return m[0], m[1], nil
-}
-
-// lineToByte returns the byte index of the first byte of line n.
-// Line numbers begin at 1.
-func lineToByte(data []byte, n int) int {
-	if n <= 1 {
-		return 0
-	}
-	n--
-	for i, c := range data {
-		if c == '\n' {
-			if n--; n == 0 {
-				return i + 1
-			}
-		}
-	}
-	return len(data)
-}
-
-// byteToLine returns the number of the line containing the byte at index i.
-func byteToLine(data []byte, i int) int {
-	l := 1
-	for j, c := range data {
-		if j == i {
-			return l
-		}
-		if c == '\n' {
-			l++
-		}
-	}
-	return l
-}
@@ -0,0 +1,69 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package codewalk
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestValidate(t *testing.T) {
+	if err := Validate(os.DirFS("../../_content")); err != nil {
+		t.Errorf("Validate(_content): %v", err)
+	}
+
+	fsys := fstest.MapFS{
+		"doc/codewalk/good.xml":  {Data: []byte(`<codewalk title="Good"><step title="Main" src="doc/codewalk/x.go:/func main/">Hi.</step></codewalk>`)},
+		"doc/codewalk/x.go":      {Data: []byte("package main\n\nfunc main() {}\n")},
+		"doc/codewalk/bad.xml":   {Data: []byte(`<codewalk title="Bad"><step title="Gone" src="doc/codewalk/missing.go">Hi.</step></codewalk>`)},
+		"doc/codewalk/ugly.xml":  {Data: []byte(`<codewalk title="Ugly"><step`)},
+		"doc/codewalk/notes.txt": {Data: []byte("not a codewalk")},
+	}
+	err := Validate(fsys)
+	if err == nil {
+		t.Fatal("Validate succeeded with broken codewalks")
+	}
+	for _, want := range []string{"bad.xml: step \"Gone\"", "ugly.xml"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate error does not mention %q:\n%v", want, err)
+		}
+	}
+	if strings.Contains(err.Error(), "good.xml") {
+		t.Errorf("Validate reported good.xml:\n%v", err)
+	}
+}
+
+func TestList(t *testing.T) {
+	fsys := fstest.MapFS{
+		"doc/codewalk/b.xml": {Data: []byte(`<codewalk title="B" authors="rsc  gopher"><step title="Main" src="doc/codewalk/x.go:/func main/">Hi <i>there</i>.</step><step title="All" src="doc/codewalk/x.go">All.</step></codewalk>`)},
+		"doc/codewalk/a.xml": {Data: []byte(`<codewalk title="A"><step title="Gone" src="doc/codewalk/missing.go">Hi.</step></codewalk>`)},
+		"doc/codewalk/x.go":  {Data: []byte("package main\n\nfunc main() {}\n")},
+	}
+	list, err := List(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || list[0].Path != "/doc/codewalk/a" || list[1].Title != "B" {
+		t.Fatalf("List = %+v, want a, b", list)
+	}
+	if st := list[0].Steps[0]; st.File != "" || st.Src != "doc/codewalk/missing.go" {
+		t.Errorf("unresolved step = %+v", st)
+	}
+	b := list[1]
+	if list[0].Authors != nil || strings.Join(b.Authors, ",") != "rsc,gopher" {
+		t.Errorf("authors = %q, %q, want none, rsc and gopher", list[0].Authors, b.Authors)
+	}
+	if len(b.Files) != 1 || b.Files[0] != "doc/codewalk/x.go" {
+		t.Errorf("files = %v", b.Files)
+	}
+	if st := b.Steps[0]; st.File != "doc/codewalk/x.go" || st.Lo != 3 || st.Hi != 3 || st.Prose != "Hi <i>there</i>." {
+		t.Errorf("step 0 = %+v, want x.go lines 3-3", st)
+	}
+	if st := b.Steps[1]; st.Lo != 0 || st.Hi != 0 {
+		t.Errorf("step 1 = %+v, want whole file", st)
+	}
+}
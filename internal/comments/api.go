@@ -0,0 +1,158 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package comments
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"cloud.google.com/go/datastore"
+)
+
+// maxStatusBody is the most bytes in a moderation request.
+const maxStatusBody = 1 << 10
+
+// APIHandler returns a handler serving a JSON API
+// for moderating comments, at path and below it:
+//
+//	GET path        list comments, newest first
+//	GET path/id     get one comment
+//	PUT path/id     set the comment's moderation state, {"Status": "approved"}
+//	DELETE path/id  delete the comment
+//
+// The list is of the most recent comments on pages with paths
+// starting with the url parameter, in the moderation state given by
+// the status parameter (default pending, or all).
+// It is the caller's responsibility to ensure that the handler is only
+// exposed to authorized users, for example with env.AdminHandler.
+func (s *Server) APIHandler(path string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, path), "/")
+		switch {
+		case id == "" && r.Method == "GET":
+			s.apiList(w, r)
+		case id != "" && r.Method == "GET":
+			s.apiGet(w, r, id)
+		case id != "" && r.Method == "PUT":
+			s.apiPut(w, r, id)
+		case id != "" && r.Method == "DELETE":
+			s.apiDelete(w, r, id)
+		default:
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+func (s *Server) apiList(w http.ResponseWriter, r *http.Request) {
+	status := r.FormValue("status")
+	if status == "" {
+		status = statusPending
+	}
+	if status != "all" && !slices.Contains(statuses, status) {
+		http.Error(w, fmt.Sprintf("invalid status %q", status), http.StatusBadRequest)
+		return
+	}
+	urlPrefix := r.FormValue("url")
+
+	var all []*Comment
+	q := datastore.NewQuery(kind)
+	if status != "all" {
+		q = q.FilterField("Status", "=", status)
+	}
+	q = q.Order("-Time").Limit(maxListed)
+	keys, err := s.datastore.GetAll(r.Context(), q, &all)
+	if err != nil {
+		log.Printf("ERROR listing comments: %v", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	list := []*Comment{}
+	for i, c := range all {
+		c.ID = keys[i].ID
+		if strings.HasPrefix(c.URL, urlPrefix) {
+			list = append(list, c)
+		}
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// load loads the comment with the given ID, replying with an error if it cannot.
+func (s *Server) load(w http.ResponseWriter, r *http.Request, id string) (*Comment, bool) {
+	n, err := strconv.ParseInt(id, 10, 64)
+	if err != nil || n <= 0 {
+		http.Error(w, "not found", http.StatusNotFound)
+		return nil, false
+	}
+	var c Comment
+	switch err := s.datastore.Get(r.Context(), datastore.IDKey(kind, n, nil), &c); err {
+	case nil:
+		c.ID = n
+		return &c, true
+	case datastore.ErrNoSuchEntity:
+		http.Error(w, "not found", http.StatusNotFound)
+	default:
+		log.Printf("ERROR comment %d: %v", n, err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+	return nil, false
+}
+
+func (s *Server) apiGet(w http.ResponseWriter, r *http.Request, id string) {
+	if c, ok := s.load(w, r, id); ok {
+		writeJSON(w, http.StatusOK, c)
+	}
+}
+
+func (s *Server) apiPut(w http.ResponseWriter, r *http.Request, id string) {
+	var req struct{ Status string }
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxStatusBody)).Decode(&req); err != nil {
+		http.Error(w, "invalid request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !slices.Contains(statuses, req.Status) {
+		http.Error(w, fmt.Sprintf("invalid status %q; want one of %s", req.Status, strings.Join(statuses, ", ")), http.StatusBadRequest)
+		return
+	}
+	c, ok := s.load(w, r, id)
+	if !ok {
+		return
+	}
+	c.Status = req.Status
+	if _, err := s.datastore.Put(r.Context(), datastore.IDKey(kind, c.ID, nil), c); err != nil {
+		log.Printf("ERROR comment %d: %v", c.ID, err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	s.forget(c.URL)
+	writeJSON(w, http.StatusOK, c)
+}
+
+func (s *Server) apiDelete(w http.ResponseWriter, r *http.Request, id string) {
+	c, ok := s.load(w, r, id)
+	if !ok {
+		return
+	}
+	if err := s.datastore.Delete(r.Context(), datastore.IDKey(kind, c.ID, nil)); err != nil {
+		log.Printf("ERROR comment %d: %v", c.ID, err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	s.forget(c.URL)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeJSON writes v to w as the JSON body of a reply with the given status.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("ERROR writing JSON: %v", err)
+	}
+}
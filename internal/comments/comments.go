@@ -0,0 +1,368 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package comments serves readers' threaded comments on the pages
+// of the sections of a site where comments are enabled,
+// such as the blog and codewalks.
+//
+// Comments are posted to /comments and stored in datastore as pending,
+// to be shown only once a moderator approves them with the moderation API
+// (see Server.APIHandler). The page data of every page in an enabled section
+// holds the page's approved comments, as its comments, for the page's
+// layout to show with the site template's "comments" template, together
+// with the form for posting another. A reply is shown below the comment
+// it replies to, unless that comment is not shown.
+package comments
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"cloud.google.com/go/datastore"
+	"github.com/matttproud/yourtour/internal/router"
+	"github.com/matttproud/yourtour/internal/web"
+)
+
+const (
+	kind      = "Comment"
+	prefix    = "/comments"
+	maxBody   = 16 << 10 // bytes in a submission
+	maxPath   = 200      // bytes in a page path
+	maxAuthor = 100      // runes in an author's name
+	maxText   = 4000     // runes in a comment
+	maxLinks  = 2        // links in a comment not considered spam
+	maxListed = 1000     // comments listed by the moderation API
+	maxPage   = 500      // comments loaded for a page
+	maxDepth  = 4        // levels of replies shown
+	cacheTTL  = time.Minute
+)
+
+// A Comment is a reader's comment on a page.
+type Comment struct {
+	ID     int64  `datastore:"-"`
+	URL    string // path of the page, such as /blog/go1.22
+	Parent int64  // ID of the comment replied to, or 0
+	Author string `datastore:",noindex"`
+	Text   string `datastore:",noindex"`
+	Time   time.Time
+	Status string // moderation state: pending, approved, rejected, or spam
+}
+
+// Moderation states.
+const (
+	statusPending  = "pending"
+	statusApproved = "approved"
+	statusRejected = "rejected"
+	statusSpam     = "spam"
+)
+
+var statuses = []string{statusPending, statusApproved, statusRejected, statusSpam}
+
+// Datastore is the part of a *datastore.Client used by the comment handlers.
+type Datastore interface {
+	Get(ctx context.Context, key *datastore.Key, dst any) error
+	GetAll(ctx context.Context, q *datastore.Query, dst any) ([]*datastore.Key, error)
+	Put(ctx context.Context, key *datastore.Key, src any) (*datastore.Key, error)
+	Delete(ctx context.Context, key *datastore.Key) error
+}
+
+var _ Datastore = (*datastore.Client)(nil)
+
+// A Server serves the comments on the pages of a site.
+type Server struct {
+	datastore Datastore
+	sections  []string
+
+	mu    sync.Mutex
+	cache map[string]*cached // by page URL
+}
+
+// A cached is the discussion of a page, as recently loaded.
+type cached struct {
+	d       *Discussion
+	expires time.Time
+}
+
+// NewServer returns a Server storing comments in dc, for the pages
+// in sections, a comma-separated list of the path prefixes of the sections
+// where comments are enabled, such as "/blog/,/doc/codewalk/".
+func NewServer(dc Datastore, sections string) (*Server, error) {
+	s := &Server{datastore: dc, cache: make(map[string]*cached)}
+	for _, sec := range strings.Split(sections, ",") {
+		sec = strings.TrimSpace(sec)
+		if sec == "" {
+			continue
+		}
+		if !strings.HasPrefix(sec, "/") || !strings.HasSuffix(sec, "/") {
+			return nil, fmt.Errorf("invalid comment section %q; want /path/", sec)
+		}
+		s.sections = append(s.sections, sec)
+	}
+	return s, nil
+}
+
+// Sections returns the path prefixes of the sections where comments are enabled.
+func (s *Server) Sections() []string {
+	return s.sections
+}
+
+// Enabled reports whether comments are enabled on the page with URL path p.
+func (s *Server) Enabled(p string) bool {
+	return slices.ContainsFunc(s.sections, func(sec string) bool { return strings.HasPrefix(p, sec) })
+}
+
+// RegisterHandlers registers the handler for POST /comments on rt.
+func (s *Server) RegisterHandlers(rt *router.Router) {
+	rt.HandleFunc("POST", prefix, s.postHandler)
+	rt.Document("POST", prefix, router.Doc{
+		Summary: "Posts a comment on a page, to be shown once approved, as JSON or as a form.",
+		Params: []router.Param{
+			{Name: "url", Description: "the path of the page, such as /blog/go1.22", Required: true},
+			{Name: "parent", Description: "the ID of the comment replied to, if any"},
+			{Name: "author", Description: "the author's name, as shown", Required: true},
+			{Name: "text", Description: "the text of the comment", Required: true},
+		},
+	})
+}
+
+// A Discussion is the comments shown on a page.
+type Discussion struct {
+	URL     string    // path of the page
+	Threads []*Thread // comments not replying to others, oldest first
+	Count   int       // number of comments shown, including replies
+}
+
+// A Thread is a comment shown on a page, with its replies, oldest first.
+type Thread struct {
+	*Comment
+	Replies  []*Thread
+	CanReply bool // whether replies to the comment are shown below it
+
+	up    *Thread // thread replied to, if any
+	depth int     // 0 for comments not replying to others
+}
+
+// PageData sets the comments of the page p, which is being rendered
+// for request r, if comments are enabled on it,
+// for use as a web.Site's page data function.
+// A page can set its own comments (for example, to nil, to show none).
+func (s *Server) PageData(r *http.Request, p web.Page) {
+	if _, ok := p["comments"]; ok {
+		return
+	}
+	url, _ := p["URL"].(string)
+	if !s.Enabled(url) {
+		return
+	}
+	d, err := s.discussion(r.Context(), url)
+	if err != nil {
+		// Show the page, with the form, without its comments.
+		log.Printf("ERROR loading comments for %s: %v", url, err)
+		d = &Discussion{URL: url}
+	}
+	p["comments"] = d
+}
+
+// discussion returns the discussion of the page with URL path url,
+// loading it if it is not cached.
+func (s *Server) discussion(ctx context.Context, url string) (*Discussion, error) {
+	s.mu.Lock()
+	c := s.cache[url]
+	s.mu.Unlock()
+	if c != nil && time.Now().Before(c.expires) {
+		return c.d, nil
+	}
+
+	var list []*Comment
+	// Only approved comments count toward the limit, so that pending ones
+	// and spam cannot crowd them out (see index.yaml in cmd/golangorg).
+	q := datastore.NewQuery(kind).
+		FilterField("URL", "=", url).
+		FilterField("Status", "=", statusApproved).
+		Limit(maxPage)
+	keys, err := s.datastore.GetAll(ctx, q, &list)
+	if err != nil {
+		return nil, err
+	}
+	for i, c := range list {
+		c.ID = keys[i].ID
+	}
+	d := thread(url, list)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cache[url] = &cached{d, time.Now().Add(cacheTTL)}
+	return d, nil
+}
+
+// forget removes the discussion of the page with URL path url from the cache,
+// so that the page shows the comments as moderated.
+func (s *Server) forget(url string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.cache, url)
+}
+
+// thread returns the discussion of the page with URL path url
+// made of the approved comments in list.
+// Replies beyond maxDepth are shown as replies to the comment
+// at maxDepth that they are in reply to.
+func thread(url string, list []*Comment) *Discussion {
+	list = slices.DeleteFunc(slices.Clone(list), func(c *Comment) bool { return c.Status != statusApproved })
+	slices.SortFunc(list, func(a, b *Comment) int {
+		if n := a.Time.Compare(b.Time); n != 0 {
+			return n
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	d := &Discussion{URL: url}
+	threads := make(map[int64]*Thread)
+	for _, c := range list {
+		t := &Thread{Comment: c, CanReply: true}
+		if c.Parent != 0 {
+			up := threads[c.Parent]
+			if up == nil {
+				// Replying to a comment not shown.
+				continue
+			}
+			if up.depth == maxDepth {
+				up = up.up
+			}
+			t.up, t.depth = up, up.depth+1
+			t.CanReply = t.depth < maxDepth
+			up.Replies = append(up.Replies, t)
+		} else {
+			d.Threads = append(d.Threads, t)
+		}
+		threads[c.ID] = t
+		d.Count++
+	}
+	return d
+}
+
+// A submission is the form posted to /comments.
+type submission struct {
+	URL      string `json:"url"`
+	Parent   string `json:"parent"` // ID of the comment replied to, if any
+	Author   string `json:"author"`
+	Text     string `json:"text"`
+	Honeypot string `json:"website"` // hidden from people; filled in by bots
+}
+
+// postHandler stores the comment posted as a form or as JSON:
+//
+//	POST /comments
+//	{"url": "/blog/go1.22", "parent": "12", "author": "Gopher", "text": "Thanks!"}
+//
+// JSON submissions are answered with 202 Accepted, as the comment
+// awaits moderation; form submissions are redirected back to the page.
+func (s *Server) postHandler(w http.ResponseWriter, r *http.Request) {
+	var sub submission
+	r.Body = http.MaxBytesReader(w, r.Body, maxBody)
+	mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	isJSON := mt == "application/json"
+	if isJSON {
+		data, err := io.ReadAll(r.Body)
+		if err == nil {
+			err = json.Unmarshal(data, &sub)
+		}
+		if err != nil {
+			http.Error(w, "invalid comment: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	} else {
+		sub = submission{
+			URL:      r.FormValue("url"),
+			Parent:   r.FormValue("parent"),
+			Author:   r.FormValue("author"),
+			Text:     r.FormValue("text"),
+			Honeypot: r.FormValue("website"),
+		}
+	}
+
+	c, err := sub.comment()
+	if err != nil {
+		http.Error(w, "invalid comment: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !s.Enabled(c.URL) {
+		http.Error(w, "comments are not enabled on "+c.URL, http.StatusForbidden)
+		return
+	}
+	ctx := r.Context()
+	if c.Parent != 0 {
+		var parent Comment
+		err := s.datastore.Get(ctx, datastore.IDKey(kind, c.Parent, nil), &parent)
+		if err == datastore.ErrNoSuchEntity || err == nil && (parent.URL != c.URL || parent.Status != statusApproved) {
+			http.Error(w, "invalid comment: no comment "+sub.Parent+" to reply to", http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			log.Printf("ERROR loading comment %d: %v", c.Parent, err)
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+	}
+	if sub.Honeypot == "" {
+		c.Time = time.Now()
+		c.Status = statusPending
+		if strings.Count(c.Text, "://") > maxLinks {
+			c.Status = statusSpam
+		}
+		if _, err := s.datastore.Put(ctx, datastore.IncompleteKey(kind, nil), c); err != nil {
+			log.Printf("ERROR storing comment on %s: %v", c.URL, err)
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if isJSON {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	http.Redirect(w, r, c.URL+"#comments-pending", http.StatusSeeOther)
+}
+
+// comment returns the Comment submitted in s, checking that it is valid.
+func (s *submission) comment() (*Comment, error) {
+	if !strings.HasPrefix(s.URL, "/") || strings.HasPrefix(s.URL, "//") || len(s.URL) > maxPath ||
+		strings.ContainsAny(s.URL, "?#") || !utf8.ValidString(s.URL) {
+		return nil, fmt.Errorf("invalid url %q", s.URL)
+	}
+	c := &Comment{URL: s.URL, Author: strings.TrimSpace(s.Author), Text: strings.TrimSpace(s.Text)}
+	if s.Parent != "" {
+		id, err := strconv.ParseInt(s.Parent, 10, 64)
+		if err != nil || id <= 0 {
+			return nil, fmt.Errorf("invalid parent %q", s.Parent)
+		}
+		c.Parent = id
+	}
+	if c.Author == "" {
+		return nil, errors.New("missing author")
+	}
+	if utf8.RuneCountInString(c.Author) > maxAuthor || !utf8.ValidString(c.Author) || strings.ContainsAny(c.Author, "\r\n") {
+		return nil, fmt.Errorf("invalid author; limit %d characters on one line", maxAuthor)
+	}
+	if c.Text == "" {
+		return nil, errors.New("missing text")
+	}
+	if utf8.RuneCountInString(c.Text) > maxText || !utf8.ValidString(c.Text) {
+		return nil, fmt.Errorf("text too long; limit %d characters", maxText)
+	}
+	return c, nil
+}
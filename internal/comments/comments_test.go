@@ -0,0 +1,338 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package comments
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/matttproud/yourtour/internal/datastoretest"
+	"github.com/matttproud/yourtour/internal/router"
+	"github.com/matttproud/yourtour/internal/web"
+)
+
+// getComment returns the comment with the given ID in ds,
+// and whether there is any.
+func getComment(ds Datastore, id int64) (Comment, bool) {
+	var c Comment
+	err := ds.Get(context.Background(), datastore.IDKey(kind, id, nil), &c)
+	return c, err == nil
+}
+
+// add stores the comments in list in ds, as if posted an hour apart,
+// in order, and approved unless they have a status.
+func add(ds Datastore, list ...Comment) {
+	t := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+	for _, c := range list {
+		c.Time = t
+		t = t.Add(time.Hour)
+		if c.Status == "" {
+			c.Status = statusApproved
+		}
+		ds.Put(context.Background(), datastore.IncompleteKey(kind, nil), &c)
+	}
+}
+
+func newTestServer(t *testing.T, ds Datastore) *Server {
+	s, err := NewServer(ds, "/blog/, /doc/codewalk/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestNewServer(t *testing.T) {
+	s := newTestServer(t, nil)
+	for _, tt := range []struct {
+		path string
+		want bool
+	}{
+		{"/blog/go1.21", true},
+		{"/doc/codewalk/sharemem/", true},
+		{"/doc/install", false},
+		{"/blog", false},
+	} {
+		if got := s.Enabled(tt.path); got != tt.want {
+			t.Errorf("Enabled(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+	if _, err := NewServer(nil, "/blog"); err == nil {
+		t.Errorf("NewServer accepted section /blog")
+	}
+}
+
+func TestPostHandler(t *testing.T) {
+	ds := new(datastoretest.Datastore)
+	add(ds,
+		Comment{URL: "/blog/go1.21", Author: "A", Text: "First."},
+		Comment{URL: "/blog/go1.21", Author: "B", Text: "Unseen.", Status: statusPending},
+	)
+	mux := http.NewServeMux()
+	newTestServer(t, ds).RegisterHandlers(router.New(mux))
+	post := func(ctype, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("POST", "/comments", strings.NewReader(body))
+		r.Header.Set("Content-Type", ctype)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		return w
+	}
+	const (
+		jsonType = "application/json"
+		formType = "application/x-www-form-urlencoded"
+	)
+
+	for _, tt := range []struct {
+		ctype, body string
+		code        int
+		status      string // of the stored comment, if stored
+	}{
+		{jsonType, `{"url": "/blog/go1.21", "author": "Gopher", "text": "Thanks!"}`, 202, statusPending},
+		{formType, "url=/blog/go1.21&parent=1&author=Gopher&text=Agreed.", 303, statusPending},
+		{jsonType, `{"url": "/blog/go1.21", "author": "Bot", "text": "a://b c://d e://f"}`, 202, statusSpam},
+		{jsonType, `{"url": "/blog/go1.21", "author": "Bot", "text": "Buy now.", "website": "http://spam.example"}`, 202, ""},
+		{jsonType, `{"url": "/doc/install", "author": "Gopher", "text": "Hi."}`, 403, ""},
+		{jsonType, `{"url": "/blog/go1.21", "parent": "2", "author": "Gopher", "text": "Hi."}`, 400, ""}, // pending
+		{jsonType, `{"url": "/blog/go1.20", "parent": "1", "author": "Gopher", "text": "Hi."}`, 400, ""}, // other page
+		{jsonType, `{"url": "/blog/go1.21", "parent": "99", "author": "Gopher", "text": "Hi."}`, 400, ""},
+		{jsonType, `{"url": "/blog/go1.21", "parent": "x", "author": "Gopher", "text": "Hi."}`, 400, ""},
+		{jsonType, `{"url": "/blog/go1.21", "text": "Hi."}`, 400, ""},
+		{jsonType, `{"url": "/blog/go1.21", "author": "Go\npher", "text": "Hi."}`, 400, ""},
+		{jsonType, `{"url": "/blog/go1.21", "author": "Gopher", "text": " "}`, 400, ""},
+		{jsonType, `{"url": "/blog/go1.21", "author": "Gopher", "text": "` + strings.Repeat("x", maxText+1) + `"}`, 400, ""},
+		{jsonType, `{"url": "//example.com/blog/", "author": "Gopher", "text": "Hi."}`, 400, ""},
+		{jsonType, `{"url":`, 400, ""},
+	} {
+		before := ds.LastID()
+		w := post(tt.ctype, tt.body)
+		if w.Code != tt.code {
+			t.Errorf("POST %.80s: %d, want %d\n%s", tt.body, w.Code, tt.code, w.Body)
+		}
+		if stored := ds.LastID() > before; stored != (tt.status != "") {
+			t.Errorf("POST %.80s: stored = %v, want %v", tt.body, stored, tt.status != "")
+		} else if c, _ := getComment(ds, ds.LastID()); stored && c.Status != tt.status {
+			t.Errorf("POST %.80s: status %q, want %q", tt.body, c.Status, tt.status)
+		}
+	}
+
+	if c, _ := getComment(ds, 4); c.Parent != 1 || c.Author != "Gopher" || c.Text != "Agreed." || c.Time.IsZero() {
+		t.Errorf("reply stored as %+v", c)
+	}
+	if w := post(formType, "url=/blog/go1.21&author=Gopher&text=Hi."); w.Header().Get("Location") != "/blog/go1.21#comments-pending" {
+		t.Errorf("form post redirected to %q", w.Header().Get("Location"))
+	}
+}
+
+// outline returns the authors of the comments in threads,
+// with their replies in parentheses.
+func outline(threads []*Thread) string {
+	var b strings.Builder
+	for i, t := range threads {
+		if i > 0 {
+			b.WriteString(" ")
+		}
+		b.WriteString(t.Author)
+		if t.Replies != nil {
+			b.WriteString("(" + outline(t.Replies) + ")")
+		}
+	}
+	return b.String()
+}
+
+func TestThread(t *testing.T) {
+	ds := new(datastoretest.Datastore)
+	const url = "/blog/go1.21"
+	add(ds,
+		Comment{URL: url, Author: "a"},
+		Comment{URL: url, Author: "b"},
+		Comment{URL: url, Author: "a1", Parent: 1},
+		Comment{URL: url, Author: "a11", Parent: 3},
+		Comment{URL: url, Author: "x", Status: statusRejected},
+		Comment{URL: url, Author: "x1", Parent: 5},
+		Comment{URL: url, Author: "a111", Parent: 4},
+		Comment{URL: url, Author: "a1111", Parent: 7},
+		Comment{URL: url, Author: "a11111", Parent: 8}, // beyond maxDepth
+		Comment{URL: url, Author: "b1", Parent: 2},
+		Comment{URL: url, Author: "p", Status: statusPending},
+	)
+	s := newTestServer(t, ds)
+	d, err := s.discussion(context.Background(), url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := outline(d.Threads), "a(a1(a11(a111(a1111 a11111)))) b(b1)"; got != want {
+		t.Errorf("threads = %s, want %s", got, want)
+	}
+	if d.Count != 8 {
+		t.Errorf("Count = %d, want 8", d.Count)
+	}
+	if a1111 := d.Threads[0].Replies[0].Replies[0].Replies[0].Replies[0]; a1111.CanReply {
+		t.Errorf("can reply to %s, at maxDepth", a1111.Author)
+	}
+	if !d.Threads[0].CanReply {
+		t.Errorf("cannot reply to %s", d.Threads[0].Author)
+	}
+}
+
+func TestPageData(t *testing.T) {
+	ds := new(datastoretest.Datastore)
+	add(ds,
+		Comment{URL: "/blog/go1.21", Author: "Gopher", Text: "Great <b>release</b>!"},
+		Comment{URL: "/blog/go1.21", Author: "Reader", Text: "Agreed.", Parent: 1},
+	)
+	s := newTestServer(t, ds)
+
+	// Render with the site template's comment templates.
+	data, err := os.ReadFile("../../_content/site.tmpl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, defs, ok := strings.Cut(string(data), "\n{{define \"comments\"}}")
+	if !ok {
+		t.Fatal("site.tmpl does not define comments")
+	}
+	fsys := fstest.MapFS{
+		"site.tmpl":      {Data: []byte(`{{template "comments" .}}{{define "comments"}}` + defs)},
+		"blog/go1.21.md": {Data: []byte("---\ntitle: Go 1.21 is released!\n---\n")},
+	}
+	site := web.NewSite(fsys)
+	site.AddPageData(s.PageData)
+	w := httptest.NewRecorder()
+	site.ServeHTTP(w, httptest.NewRequest("GET", "/blog/go1.21", nil))
+	body := w.Body.String()
+	for _, want := range []string{
+		`<h2>Comments (2)</h2>`,
+		`<div class="Comment" id="comment-1">`,
+		`<b>Gopher</b> &middot; <a href="#comment-1">2 January 2026</a>`,
+		`<p class="Comment-text">Great &lt;b&gt;release&lt;/b&gt;!</p>`,
+		`<input type="hidden" name="parent" value="1">`,
+		`<b>Reader</b>`,
+		`<form class="Comments-form" method="post" action="/comments">`,
+		`<input type="hidden" name="url" value="/blog/go1.21">`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("GET /blog/go1.21 missing %s", want)
+		}
+	}
+	// One form for each comment's replies, and one for new comments.
+	if n, m := strings.Count(body, `class="Comments-form"`), strings.Count(body, `name="parent"`); n != 3 || m != 2 {
+		t.Errorf("GET /blog/go1.21 has %d forms, %d replying, want 3, 2", n, m)
+	}
+	if t.Failed() {
+		t.Logf("GET /blog/go1.21:\n%s", body)
+	}
+
+	p := web.Page{"URL": "/doc/install"}
+	s.PageData(httptest.NewRequest("GET", "/doc/install", nil), p)
+	if _, ok := p["comments"]; ok {
+		t.Errorf("comments on /doc/install")
+	}
+}
+
+func TestAPIHandler(t *testing.T) {
+	ds := new(datastoretest.Datastore)
+	add(ds,
+		Comment{URL: "/blog/go1.21", Author: "A", Text: "Approved."},
+		Comment{URL: "/blog/go1.21", Author: "B", Text: "Pending.", Status: statusPending},
+		Comment{URL: "/doc/codewalk/sharemem/", Author: "C", Text: "Pending too.", Status: statusPending},
+	)
+	s := newTestServer(t, ds)
+	h := s.APIHandler("/_comments")
+	do := func(method, url, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(method, url, strings.NewReader(body)))
+		return w
+	}
+	list := func(query string) string {
+		w := do("GET", "/_comments?"+query, "")
+		var list []*Comment
+		if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil || w.Code != 200 {
+			t.Fatalf("GET /_comments?%s: %d %v\n%s", query, w.Code, err, w.Body)
+		}
+		var authors []string
+		for _, c := range list {
+			authors = append(authors, c.Author)
+		}
+		return strings.Join(authors, " ")
+	}
+
+	for _, tt := range []struct {
+		query, want string
+	}{
+		{"", "C B"},
+		{"url=/blog/", "B"},
+		{"status=approved", "A"},
+		{"status=all", "C B A"},
+		{"status=spam", ""},
+	} {
+		if got := list(tt.query); got != tt.want {
+			t.Errorf("GET /_comments?%s = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+	if w := do("GET", "/_comments?status=new", ""); w.Code != 400 {
+		t.Errorf("GET ?status=new: %d, want 400", w.Code)
+	}
+
+	// Cache the page's comments, to check that moderation updates them.
+	ctx := context.Background()
+	if d, _ := s.discussion(ctx, "/blog/go1.21"); d.Count != 1 {
+		t.Fatalf("Count = %d before moderation, want 1", d.Count)
+	}
+	if w := do("PUT", "/_comments/2", `{"Status": "approved"}`); w.Code != 200 || !strings.Contains(w.Body.String(), `"Status":"approved"`) {
+		t.Errorf("PUT /_comments/2: %d\n%s", w.Code, w.Body)
+	}
+	if d, _ := s.discussion(ctx, "/blog/go1.21"); d.Count != 2 {
+		t.Errorf("Count = %d after approval, want 2", d.Count)
+	}
+	if w := do("GET", "/_comments/2", ""); w.Code != 200 || !strings.Contains(w.Body.String(), `"ID":2,`) {
+		t.Errorf("GET /_comments/2: %d\n%s", w.Code, w.Body)
+	}
+	if w := do("PUT", "/_comments/2", `{"Status": "hidden"}`); w.Code != 400 {
+		t.Errorf("PUT invalid status: %d, want 400", w.Code)
+	}
+	if w := do("DELETE", "/_comments/1", ""); w.Code != 204 {
+		t.Errorf("DELETE /_comments/1: %d, want 204", w.Code)
+	}
+	if _, ok := getComment(ds, 1); ok {
+		t.Errorf("DELETE did not delete")
+	}
+	if d, _ := s.discussion(ctx, "/blog/go1.21"); d.Count != 1 {
+		t.Errorf("Count = %d after deletion, want 1", d.Count)
+	}
+	for _, url := range []string{"/_comments/1", "/_comments/x", "/_comments/-1"} {
+		if w := do("GET", url, ""); w.Code != 404 {
+			t.Errorf("GET %s: %d, want 404", url, w.Code)
+		}
+	}
+	if w := do("POST", "/_comments", "{}"); w.Code != 405 {
+		t.Errorf("POST /_comments: %d, want 405", w.Code)
+	}
+}
+
+func TestAPIListStatus(t *testing.T) {
+	// A pending comment is listed even behind more than maxListed newer comments.
+	list := []Comment{{URL: "/blog/go1.21", Author: "P", Status: statusPending}}
+	for range maxListed {
+		list = append(list, Comment{URL: "/blog/go1.21", Author: "A"})
+	}
+	ds := new(datastoretest.Datastore)
+	add(ds, list...)
+	w := httptest.NewRecorder()
+	newTestServer(t, ds).APIHandler("/_comments").ServeHTTP(w, httptest.NewRequest("GET", "/_comments", nil))
+	var got []*Comment
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil || w.Code != 200 {
+		t.Fatalf("GET /_comments: %d %v\n%s", w.Code, err, w.Body)
+	}
+	if len(got) != 1 || got[0].Author != "P" || got[0].ID != 1 {
+		t.Errorf("GET /_comments = %+v, want the pending comment 1", got)
+	}
+}
@@ -0,0 +1,249 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package compress compresses HTTP responses with gzip or brotli,
+// as negotiated with the client's Accept-Encoding header.
+//
+// Only textual responses, such as HTML, JSON, JavaScript, and CSS,
+// are compressed; assets that are already compressed, like images
+// and archives, and very small responses are sent as they are.
+package compress
+
+import (
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+)
+
+// MinSize is the smallest response body that is compressed.
+// Smaller bodies gain too little to be worth the time.
+const MinSize = 1024
+
+// brotliLevel is the brotli quality level for responses.
+// Higher levels compress much more slowly for little gain,
+// which does not pay off for pages generated per request.
+const brotliLevel = 5
+
+// An encoder is a compressing writer that can be reused.
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(io.Writer)
+}
+
+var (
+	gzipPool = sync.Pool{New: func() any {
+		return gzip.NewWriter(nil)
+	}}
+	brotliPool = sync.Pool{New: func() any {
+		return brotli.NewWriterLevel(nil, brotliLevel)
+	}}
+)
+
+func pool(encoding string) *sync.Pool {
+	if encoding == "br" {
+		return &brotliPool
+	}
+	return &gzipPool
+}
+
+// Handler returns a handler that serves requests with h,
+// compressing the responses if the client accepts it.
+func Handler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encoding := negotiate(r.Header.Get("Accept-Encoding"))
+		// Compressing part of a response breaks its byte ranges.
+		if r.Method == "HEAD" || r.Header.Get("Range") != "" {
+			encoding = ""
+		}
+		cw := &writer{ResponseWriter: w, encoding: encoding, code: http.StatusOK}
+		h.ServeHTTP(cw, r)
+		// If h panics, the buffered reply is dropped on purpose,
+		// leaving the response untouched for the panic handler.
+		cw.close()
+	})
+}
+
+// negotiate returns the encoding to use for a request with
+// the given Accept-Encoding header: "br", "gzip", or "" for none.
+// Brotli is preferred when the client has no preference,
+// since it compresses text better.
+func negotiate(accept string) string {
+	q := map[string]float64{}
+	for _, f := range strings.Split(accept, ",") {
+		name, params, _ := strings.Cut(f, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		v := 1.0
+		if param, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			var err error
+			if v, err = strconv.ParseFloat(param, 64); err != nil {
+				continue
+			}
+		}
+		if name != "" {
+			q[name] = v
+		}
+	}
+	weight := func(name string) float64 {
+		if v, ok := q[name]; ok {
+			return v
+		}
+		return q["*"]
+	}
+	br, gz := weight("br"), weight("gzip")
+	switch {
+	case br > 0 && br >= gz:
+		return "br"
+	case gz > 0:
+		return "gzip"
+	}
+	return ""
+}
+
+// compressible reports whether responses with the given
+// Content-Type are worth compressing.
+func compressible(contentType string) bool {
+	typ, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	if strings.HasPrefix(typ, "text/") {
+		return true
+	}
+	switch typ {
+	case "application/json",
+		"application/javascript",
+		"application/xml",
+		"application/atom+xml",
+		"application/rss+xml",
+		"image/svg+xml":
+		return true
+	}
+	return false
+}
+
+// A writer is a ResponseWriter that compresses the reply,
+// if it turns out to be compressible.
+// It holds back the header and the first MinSize bytes of the body
+// until it has seen enough of the reply to decide.
+type writer struct {
+	http.ResponseWriter
+	encoding string // negotiated encoding, or "" for none
+
+	code    int
+	buf     []byte
+	decided bool    // header written and buf sent
+	enc     encoder // compressor, if compressing
+}
+
+func (w *writer) WriteHeader(code int) {
+	if w.decided {
+		w.ResponseWriter.WriteHeader(code) // let net/http complain
+		return
+	}
+	if code < 200 {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	w.code = code
+}
+
+func (w *writer) Write(b []byte) (int, error) {
+	if !w.decided {
+		w.buf = append(w.buf, b...)
+		if len(w.buf) < MinSize {
+			return len(b), nil
+		}
+		if err := w.decide(false); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+	if w.enc != nil {
+		return w.enc.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// decide writes the header, compressed or not, and the buffered body.
+// If final is set, the buffered body is the entire body.
+func (w *writer) decide(final bool) error {
+	w.decided = true
+	h := w.Header()
+	if h.Get("Content-Type") == "" && h.Get("Content-Encoding") == "" && len(w.buf) > 0 {
+		// net/http would sniff the type from the body anyway;
+		// do it here to know whether it is worth compressing.
+		h.Set("Content-Type", http.DetectContentType(w.buf))
+	}
+	compress := false
+	switch w.code {
+	case http.StatusNoContent, http.StatusNotModified, http.StatusPartialContent:
+	default:
+		if h.Get("Content-Encoding") == "" && compressible(h.Get("Content-Type")) {
+			h.Add("Vary", "Accept-Encoding")
+			compress = w.encoding != "" && (!final || len(w.buf) >= MinSize)
+		}
+	}
+	if compress {
+		h.Set("Content-Encoding", w.encoding)
+		h.Del("Content-Length")
+		if etag := h.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			// The compressed bytes differ from the original.
+			h.Set("ETag", "W/"+etag)
+		}
+		w.enc = pool(w.encoding).Get().(encoder)
+		w.enc.Reset(w.ResponseWriter)
+	}
+	w.ResponseWriter.WriteHeader(w.code)
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	var err error
+	if w.enc != nil {
+		_, err = w.enc.Write(buf)
+	} else {
+		_, err = w.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+// close finishes the reply, returning the compressor to its pool.
+func (w *writer) close() {
+	if !w.decided {
+		w.decide(true)
+	}
+	if w.enc != nil {
+		w.enc.Close()
+		w.enc.Reset(nil)
+		pool(w.encoding).Put(w.enc)
+		w.enc = nil
+	}
+}
+
+// Flush sends any buffered data to the client.
+// Once flushed, the reply can no longer be left uncompressed
+// for being small.
+func (w *writer) Flush() {
+	if !w.decided {
+		w.decide(false)
+	}
+	if w.enc != nil {
+		w.enc.Flush()
+	}
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap returns the underlying ResponseWriter,
+// for use by http.ResponseController.
+func (w *writer) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,167 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package compress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{"", ""},
+		{"identity", ""},
+		{"gzip", "gzip"},
+		{"gzip, deflate, br", "br"},
+		{"br;q=0.5, gzip", "gzip"},
+		{"BR, GZIP", "br"},
+		{"gzip;q=0, br;q=0", ""},
+		{"*", "br"},
+		{"*;q=0.5, br;q=0", "gzip"},
+		{"gzip;q=x", ""},
+	}
+	for _, tt := range tests {
+		if got := negotiate(tt.accept); got != tt.want {
+			t.Errorf("negotiate(%q) = %q, want %q", tt.accept, got, tt.want)
+		}
+	}
+}
+
+var page = "<!DOCTYPE html><html><body>" + strings.Repeat("<p>Hello, gophers.</p>\n", 200) + "</body></html>"
+
+func TestHandler(t *testing.T) {
+	serve := func(accept string, h http.HandlerFunc) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", "/", nil)
+		if accept != "" {
+			r.Header.Set("Accept-Encoding", accept)
+		}
+		w := httptest.NewRecorder()
+		Handler(h).ServeHTTP(w, r)
+		return w
+	}
+	html := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "12345")
+		w.Header().Set("ETag", `"v1"`)
+		for i := 0; i < len(page); i += 100 {
+			io.WriteString(w, page[i:min(i+100, len(page))])
+		}
+	}
+
+	for _, enc := range []string{"gzip", "br"} {
+		w := serve(enc, html)
+		if ce := w.Header().Get("Content-Encoding"); ce != enc {
+			t.Fatalf("%s: Content-Encoding = %q", enc, ce)
+		}
+		if w.Header().Get("Content-Length") != "" {
+			t.Errorf("%s: Content-Length not removed", enc)
+		}
+		if etag := w.Header().Get("ETag"); etag != `W/"v1"` {
+			t.Errorf("%s: ETag = %q, want weak", enc, etag)
+		}
+		if v := w.Header().Get("Vary"); v != "Accept-Encoding" {
+			t.Errorf("%s: Vary = %q", enc, v)
+		}
+		if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+			t.Errorf("%s: Content-Type = %q, want sniffed text/html", enc, ct)
+		}
+		var rd io.Reader
+		if enc == "gzip" {
+			zr, err := gzip.NewReader(w.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rd = zr
+		} else {
+			rd = brotli.NewReader(w.Body)
+		}
+		body, err := io.ReadAll(rd)
+		if err != nil || string(body) != page {
+			t.Errorf("%s: decompressed body = %d bytes, %v; want page", enc, len(body), err)
+		}
+	}
+
+	w := serve("", html)
+	if w.Header().Get("Content-Encoding") != "" || w.Body.String() != page {
+		t.Errorf("no Accept-Encoding: got encoding %q", w.Header().Get("Content-Encoding"))
+	}
+	if v := w.Header().Get("Vary"); v != "Accept-Encoding" {
+		t.Errorf("no Accept-Encoding: Vary = %q", v)
+	}
+
+	small := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"ok": true}`)
+	}
+	if w := serve("gzip", small); w.Header().Get("Content-Encoding") != "" || w.Body.String() != `{"ok": true}` {
+		t.Errorf("small response compressed")
+	}
+
+	png := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(bytes.Repeat([]byte{0}, 4*MinSize))
+	}
+	if w := serve("gzip", png); w.Header().Get("Content-Encoding") != "" || w.Header().Get("Vary") != "" || w.Body.Len() != 4*MinSize {
+		t.Errorf("image compressed")
+	}
+
+	encoded := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/javascript")
+		w.Header().Set("Content-Encoding", "gzip")
+		io.WriteString(w, page)
+	}
+	if w := serve("br", encoded); w.Header().Get("Content-Encoding") != "gzip" || w.Body.String() != page {
+		t.Errorf("already encoded response compressed again")
+	}
+
+	notFound := func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, page, http.StatusNotFound)
+	}
+	if w := serve("gzip", notFound); w.Code != 404 || w.Header().Get("Content-Encoding") != "gzip" {
+		t.Errorf("error response: %d %q, want compressed 404", w.Code, w.Header().Get("Content-Encoding"))
+	}
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	r.Header.Set("Range", "bytes=0-10")
+	rw := httptest.NewRecorder()
+	Handler(http.HandlerFunc(html)).ServeHTTP(rw, r)
+	if rw.Header().Get("Content-Encoding") != "" {
+		t.Errorf("range request compressed")
+	}
+}
+
+func TestFlush(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		io.WriteString(w, "first")
+		if err := http.NewResponseController(w).Flush(); err != nil {
+			t.Errorf("Flush: %v", err)
+		}
+		if !w.(*writer).decided {
+			t.Errorf("Flush did not send the header")
+		}
+		io.WriteString(w, " second")
+	})).ServeHTTP(w, r)
+	zr, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body, _ := io.ReadAll(zr); string(body) != "first second" {
+		t.Errorf("body = %q, want %q", body, "first second")
+	}
+}
@@ -0,0 +1,398 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package datastoretest provides an in-memory datastore for tests.
+//
+// A Datastore stores entities as datastore.Client does, through
+// datastore.SaveStruct and datastore.LoadStruct, so that struct tags
+// such as datastore:"-" apply, and it runs queries as the datastore would:
+// it honors their kind, their filters on properties with the operators
+// =, !=, <, <=, >, >=, in and not-in, their orders, offset, and limit,
+// and whether they are keys-only.
+// Without an order, entities are returned in key order.
+package datastoretest
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"sync"
+	"time"
+	"unsafe"
+
+	"cloud.google.com/go/datastore"
+)
+
+// A Datastore is an in-memory datastore, safe for concurrent use.
+// The zero Datastore is empty and ready for use.
+type Datastore struct {
+	mu       sync.Mutex
+	entities map[string]*entity // by key, as formatted by Key.String
+	lastID   int64
+	putErr   error
+}
+
+type entity struct {
+	key   *datastore.Key
+	props []datastore.Property
+}
+
+// FailPuts makes every later Put fail with err, or succeed again if err is nil.
+func (d *Datastore) FailPuts(err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.putErr = err
+}
+
+// Len returns the number of entities in d.
+func (d *Datastore) Len() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.entities)
+}
+
+// LastID returns the ID of the latest key completed by Put,
+// or 0 if there is none.
+func (d *Datastore) LastID() int64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.lastID
+}
+
+// Get loads the entity stored for key into dst,
+// or returns datastore.ErrNoSuchEntity.
+func (d *Datastore) Get(ctx context.Context, key *datastore.Key, dst any) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	e := d.entities[key.String()]
+	if e == nil {
+		return datastore.ErrNoSuchEntity
+	}
+	return load(dst, e.props)
+}
+
+// Put stores src for key, completing an incomplete key with a new ID.
+func (d *Datastore) Put(ctx context.Context, key *datastore.Key, src any) (*datastore.Key, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.putErr != nil {
+		return nil, d.putErr
+	}
+	props, err := save(src)
+	if err != nil {
+		return nil, err
+	}
+	if key.Incomplete() {
+		d.lastID++
+		key = datastore.IDKey(key.Kind, d.lastID, key.Parent)
+	}
+	if d.entities == nil {
+		d.entities = make(map[string]*entity)
+	}
+	d.entities[key.String()] = &entity{key, props}
+	return key, nil
+}
+
+// Delete deletes the entity stored for key, if any.
+func (d *Datastore) Delete(ctx context.Context, key *datastore.Key) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.entities, key.String())
+	return nil
+}
+
+// GetAll runs q, appending the entities it finds to dst,
+// which must be a pointer to a slice of structs or of pointers to structs,
+// and returns their keys. For a keys-only query, dst is ignored and may be nil.
+func (d *Datastore) GetAll(ctx context.Context, q *datastore.Query, dst any) ([]*datastore.Key, error) {
+	qq, err := readQuery(q)
+	if err != nil {
+		return nil, err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var list []*entity
+	for _, e := range d.entities {
+		if e.key.Kind == qq.kind && qq.matches(e) {
+			list = append(list, e)
+		}
+	}
+	slices.SortFunc(list, func(a, b *entity) int {
+		for _, o := range qq.orders {
+			c := compare(property(a, o.field), property(b, o.field))
+			if o.descending {
+				c = -c
+			}
+			if c != 0 {
+				return c
+			}
+		}
+		return compareKeys(a.key, b.key)
+	})
+	list = list[min(qq.offset, len(list)):]
+	if qq.limit >= 0 {
+		list = list[:min(qq.limit, len(list))]
+	}
+
+	var keys []*datastore.Key
+	if qq.keysOnly {
+		for _, e := range list {
+			keys = append(keys, e.key)
+		}
+		return keys, nil
+	}
+	slice := reflect.ValueOf(dst).Elem()
+	elem := slice.Type().Elem()
+	for _, e := range list {
+		v := reflect.New(elem)
+		if elem.Kind() == reflect.Pointer {
+			v.Elem().Set(reflect.New(elem.Elem()))
+			err = load(v.Elem().Interface(), e.props)
+		} else {
+			err = load(v.Interface(), e.props)
+		}
+		if err != nil {
+			return nil, err
+		}
+		slice.Set(reflect.Append(slice, v.Elem()))
+		keys = append(keys, e.key)
+	}
+	return keys, nil
+}
+
+func save(src any) ([]datastore.Property, error) {
+	if pls, ok := src.(datastore.PropertyLoadSaver); ok {
+		return pls.Save()
+	}
+	return datastore.SaveStruct(src)
+}
+
+func load(dst any, props []datastore.Property) error {
+	if pls, ok := dst.(datastore.PropertyLoadSaver); ok {
+		return pls.Load(props)
+	}
+	return datastore.LoadStruct(dst, props)
+}
+
+// A query is the part of a datastore.Query that a Datastore runs.
+type query struct {
+	kind     string
+	filters  []datastore.PropertyFilter
+	orders   []order
+	offset   int
+	limit    int // negative for no limit
+	keysOnly bool
+}
+
+type order struct {
+	field      string
+	descending bool
+}
+
+// readQuery returns the query q describes.
+// The fields of datastore.Query are unexported, so readQuery reads them
+// with reflection, and with package unsafe for those that hold values
+// of exported types.
+func readQuery(q *datastore.Query) (*query, error) {
+	v := reflect.ValueOf(q).Elem()
+	field := func(name string) reflect.Value {
+		f := v.FieldByName(name)
+		return reflect.NewAt(f.Type(), unsafe.Pointer(f.UnsafeAddr())).Elem()
+	}
+	if err, _ := field("err").Interface().(error); err != nil {
+		return nil, err
+	}
+	qq := &query{
+		kind:     v.FieldByName("kind").String(),
+		offset:   int(v.FieldByName("offset").Int()),
+		limit:    int(v.FieldByName("limit").Int()),
+		keysOnly: v.FieldByName("keysOnly").Bool(),
+	}
+	for _, name := range []string{"ancestor", "projection", "distinctOn", "start", "end"} {
+		if !v.FieldByName(name).IsZero() {
+			return nil, fmt.Errorf("datastoretest: query with %s is not supported", name)
+		}
+	}
+	for _, f := range field("filter").Interface().([]datastore.EntityFilter) {
+		pf, ok := f.(datastore.PropertyFilter)
+		if !ok {
+			return nil, fmt.Errorf("datastoretest: %T filters are not supported", f)
+		}
+		qq.filters = append(qq.filters, pf)
+	}
+	orders := v.FieldByName("order")
+	for i := range orders.Len() {
+		o := orders.Index(i)
+		qq.orders = append(qq.orders, order{o.FieldByName("FieldName").String(), o.FieldByName("Direction").Bool()})
+	}
+	return qq, nil
+}
+
+// matches reports whether e passes the filters of q.
+// As in the datastore, an entity without a filtered property does not pass,
+// and a list property passes if any of its values does.
+func (q *query) matches(e *entity) bool {
+	for _, f := range q.filters {
+		v, ok := lookup(e, f.FieldName)
+		if !ok {
+			return false
+		}
+		if list, ok := v.([]any); ok {
+			if !slices.ContainsFunc(list, func(v any) bool { return test(v, f.Operator, f.Value) }) {
+				return false
+			}
+		} else if !test(v, f.Operator, f.Value) {
+			return false
+		}
+	}
+	return true
+}
+
+// test reports whether the property value v passes the filter op want.
+func test(v any, op string, want any) bool {
+	switch op {
+	case "in", "not-in":
+		rv := reflect.ValueOf(want)
+		found := false
+		for i := range rv.Len() {
+			if compare(v, rv.Index(i).Interface()) == 0 {
+				found = true
+			}
+		}
+		return found == (op == "in")
+	}
+	c := compare(v, want)
+	switch op {
+	case "=":
+		return c == 0
+	case "!=":
+		return c != 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	case ">=":
+		return c >= 0
+	}
+	panic("datastoretest: unknown operator " + op)
+}
+
+// lookup returns the value of the named property of e.
+func lookup(e *entity, name string) (any, bool) {
+	for _, p := range e.props {
+		if p.Name == name {
+			return p.Value, true
+		}
+	}
+	return nil, false
+}
+
+// property returns the value of the named property of e, for ordering:
+// the first value of a list, or nil if e has no such property.
+func property(e *entity, name string) any {
+	v, _ := lookup(e, name)
+	if list, ok := v.([]any); ok {
+		if len(list) == 0 {
+			return nil
+		}
+		return list[0]
+	}
+	return v
+}
+
+// compare compares the values a and b,
+// ordering values of different types by type, as the datastore does.
+func compare(a, b any) int {
+	a, b = normalize(a), normalize(b)
+	if c := typeRank(a) - typeRank(b); c != 0 {
+		return c
+	}
+	switch a := a.(type) {
+	case int64:
+		return cmpOrdered(a, b.(int64))
+	case float64:
+		return cmpOrdered(a, b.(float64))
+	case string:
+		return cmpOrdered(a, b.(string))
+	case bool:
+		switch b := b.(bool); {
+		case a == b:
+			return 0
+		case b:
+			return -1
+		}
+		return 1
+	case time.Time:
+		return a.Compare(b.(time.Time))
+	case *datastore.Key:
+		return compareKeys(a, b.(*datastore.Key))
+	}
+	return 0
+}
+
+func cmpOrdered[T int64 | float64 | string](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// normalize returns v with the type its property value would have.
+func normalize(v any) any {
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int()
+	case reflect.Float32, reflect.Float64:
+		return rv.Float()
+	case reflect.String:
+		return rv.String()
+	}
+	return v
+}
+
+// typeRank returns the place of the type of v in the order of types.
+func typeRank(v any) int {
+	switch v.(type) {
+	case nil:
+		return 0
+	case int64:
+		return 1
+	case time.Time:
+		return 2
+	case bool:
+		return 3
+	case string:
+		return 4
+	case float64:
+		return 5
+	case *datastore.Key:
+		return 6
+	}
+	return 7
+}
+
+// compareKeys compares keys of the same kind as the datastore orders them:
+// by ancestors, then IDs before names.
+func compareKeys(a, b *datastore.Key) int {
+	if a.Parent != nil && b.Parent != nil {
+		if c := compareKeys(a.Parent, b.Parent); c != 0 {
+			return c
+		}
+	}
+	switch {
+	case a.Name == "" && b.Name == "":
+		return cmpOrdered(a.ID, b.ID)
+	case a.Name == "":
+		return -1
+	case b.Name == "":
+		return 1
+	}
+	return cmpOrdered(a.Name, b.Name)
+}
@@ -0,0 +1,111 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package datastoretest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/datastore"
+)
+
+type item struct {
+	Name   string
+	Size   int
+	Tags   []string
+	Time   time.Time
+	Secret string `datastore:"-"`
+}
+
+func TestDatastore(t *testing.T) {
+	ctx := context.Background()
+	var d Datastore
+	t0 := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+	for i, it := range []item{
+		{Name: "a", Size: 3, Tags: []string{"x"}},
+		{Name: "b", Size: 1, Tags: []string{"x", "y"}},
+		{Name: "c", Size: 2},
+		{Name: "d", Size: 2, Tags: []string{"y"}, Secret: "s3cret"},
+	} {
+		it.Time = t0.Add(time.Duration(i) * time.Hour)
+		if _, err := d.Put(ctx, datastore.IncompleteKey("Item", nil), &it); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := d.Put(ctx, datastore.NameKey("Other", "o", nil), &item{Name: "o"}); err != nil {
+		t.Fatal(err)
+	}
+	if d.Len() != 5 || d.LastID() != 4 {
+		t.Errorf("Len, LastID = %d, %d, want 5, 4", d.Len(), d.LastID())
+	}
+
+	for _, tt := range []struct {
+		q    *datastore.Query
+		want string
+	}{
+		{datastore.NewQuery("Item"), "1:a 2:b 3:c 4:d"},
+		{datastore.NewQuery("Item").FilterField("Size", "=", 2), "3:c 4:d"},
+		{datastore.NewQuery("Item").FilterField("Size", ">", 1).FilterField("Name", "!=", "c"), "1:a 4:d"},
+		{datastore.NewQuery("Item").FilterField("Tags", "=", "y"), "2:b 4:d"},
+		{datastore.NewQuery("Item").FilterField("Name", "in", []string{"a", "d"}), "1:a 4:d"},
+		{datastore.NewQuery("Item").FilterField("Time", ">=", t0.Add(2*time.Hour)), "3:c 4:d"},
+		{datastore.NewQuery("Item").Order("Size").Order("-Name"), "2:b 4:d 3:c 1:a"},
+		{datastore.NewQuery("Item").Order("-Time").Limit(2), "4:d 3:c"},
+		{datastore.NewQuery("Item").Order("-Time").Offset(1).Limit(2), "3:c 2:b"},
+		{datastore.NewQuery("Other"), "o:o"},
+	} {
+		var list []*item
+		keys, err := d.GetAll(ctx, tt.q, &list)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for i, it := range list {
+			id := keys[i].Name
+			if id == "" {
+				id = fmt.Sprint(keys[i].ID)
+			}
+			got = append(got, id+":"+it.Name)
+			if it.Secret != "" {
+				t.Errorf("entity %s was stored with its unstored field", id)
+			}
+		}
+		if strings.Join(got, " ") != tt.want {
+			t.Errorf("GetAll(%v) = %s, want %s", tt.q, strings.Join(got, " "), tt.want)
+		}
+	}
+
+	keys, err := d.GetAll(ctx, datastore.NewQuery("Item").FilterField("Size", "=", 2).KeysOnly(), nil)
+	if err != nil || len(keys) != 2 || keys[0].ID != 3 || keys[1].ID != 4 {
+		t.Errorf("keys-only GetAll = %v, %v, want keys 3 and 4", keys, err)
+	}
+
+	var it item
+	if err := d.Get(ctx, datastore.IDKey("Item", 2, nil), &it); err != nil || it.Name != "b" {
+		t.Errorf("Get(2) = %+v, %v, want b", it, err)
+	}
+	d.Delete(ctx, datastore.IDKey("Item", 2, nil))
+	if err := d.Get(ctx, datastore.IDKey("Item", 2, nil), &it); err != datastore.ErrNoSuchEntity {
+		t.Errorf("Get after Delete = %v, want ErrNoSuchEntity", err)
+	}
+
+	down := errors.New("datastore down")
+	d.FailPuts(down)
+	if _, err := d.Put(ctx, datastore.NameKey("Item", "e", nil), &item{}); err != down {
+		t.Errorf("Put after FailPuts = %v, want %v", err, down)
+	}
+	d.FailPuts(nil)
+	if _, err := d.Put(ctx, datastore.NameKey("Item", "e", nil), &item{}); err != nil {
+		t.Errorf("Put after FailPuts(nil) = %v", err)
+	}
+
+	if _, err := d.GetAll(ctx, datastore.NewQuery("Item").Order(""), &[]item{}); err == nil {
+		t.Errorf("GetAll with invalid query succeeded")
+	}
+}
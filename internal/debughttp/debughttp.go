@@ -0,0 +1,201 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package debughttp serves the Go runtime's debugging endpoints,
+// for profiling a running server:
+//
+//	/debug/pprof/   profiles, as served by net/http/pprof
+//	/debug/vars     exported variables, as served by expvar
+//	/debug/runtime  a JSON summary of goroutines, heap, and garbage collection
+//
+// Profiles reveal a great deal about a server and cost CPU to collect,
+// so the endpoints are only served to requests that pass a Guard.
+package debughttp
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"expvar"
+	"log"
+	"net/http"
+	"net/http/pprof"
+	"net/netip"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/matttproud/yourtour/internal/clientip"
+	"github.com/matttproud/yourtour/internal/ipacl"
+)
+
+// A Guard decides which requests may reach the debugging endpoints.
+// A request must carry the bearer token, if Token is set,
+// and come from an allowed address, if Allow is set.
+// A Guard with neither set refuses every request.
+type Guard struct {
+	// Token is the bearer token required in the Authorization header.
+	Token string
+
+	// Allow lists the client addresses allowed.
+	Allow []netip.Prefix
+
+	// ClientIP returns the client address of a request.
+	// If it is nil, the address is as found by clientip.FromRequest.
+	ClientIP func(*http.Request) string
+}
+
+// ParseAllow parses a comma-separated list of IP addresses
+// and CIDR prefixes, such as "127.0.0.1,10.0.0.0/8,::1".
+func ParseAllow(s string) ([]netip.Prefix, error) {
+	var list []netip.Prefix
+	for _, f := range strings.Split(s, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		p, err := ipacl.ParsePrefix(f)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, p)
+	}
+	return list, nil
+}
+
+// Enabled reports whether g allows any requests at all.
+func (g *Guard) Enabled() bool {
+	return g.Token != "" || len(g.Allow) > 0
+}
+
+// allowed reports whether r passes the guard,
+// and if not, the status code with which to refuse it.
+func (g *Guard) allowed(r *http.Request) (bool, int) {
+	if !g.Enabled() {
+		return false, http.StatusNotFound
+	}
+	if len(g.Allow) > 0 && !g.allowAddr(r) {
+		return false, http.StatusForbidden
+	}
+	if g.Token != "" {
+		auth, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(auth), []byte(g.Token)) != 1 {
+			return false, http.StatusUnauthorized
+		}
+	}
+	return true, 0
+}
+
+func (g *Guard) allowAddr(r *http.Request) bool {
+	ip := clientip.FromRequest(r)
+	if g.ClientIP != nil {
+		ip = g.ClientIP(r)
+	}
+	a, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	a = a.Unmap()
+	for _, p := range g.Allow {
+		if p.Contains(a) {
+			return true
+		}
+	}
+	return false
+}
+
+// Handler returns a handler that serves requests with h
+// if they pass the guard, refusing all others.
+func (g *Guard) Handler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ok, code := g.allowed(r); !ok {
+			http.Error(w, http.StatusText(code), code)
+			return
+		}
+		w.Header().Set("Cache-Control", "no-store")
+		h.ServeHTTP(w, r)
+	})
+}
+
+// Register registers the debugging endpoints in mux,
+// guarded by g. Profiles and traces longer than the server's
+// write timeout extend their write deadlines to last.
+func Register(mux *http.ServeMux, g *Guard) {
+	mux.Handle("/debug/pprof/", g.Handler(http.HandlerFunc(pprof.Index)))
+	mux.Handle("/debug/pprof/cmdline", g.Handler(http.HandlerFunc(pprof.Cmdline)))
+	mux.Handle("/debug/pprof/profile", g.Handler(http.HandlerFunc(pprof.Profile)))
+	mux.Handle("/debug/pprof/symbol", g.Handler(http.HandlerFunc(pprof.Symbol)))
+	mux.Handle("/debug/pprof/trace", g.Handler(http.HandlerFunc(pprof.Trace)))
+	mux.Handle("/debug/vars", g.Handler(expvar.Handler()))
+	mux.Handle("/debug/runtime", g.Handler(http.HandlerFunc(serveRuntime)))
+}
+
+// Runtime is the summary served at /debug/runtime.
+type Runtime struct {
+	GoVersion  string `json:"go_version"`
+	GOMAXPROCS int    `json:"gomaxprocs"`
+	NumCPU     int    `json:"num_cpu"`
+	Goroutines int    `json:"goroutines"`
+	Sys        uint64 `json:"sys_bytes"` // bytes obtained from the OS in total
+
+	Heap struct {
+		Alloc      uint64 `json:"alloc_bytes"`       // bytes of live and not yet collected objects
+		Inuse      uint64 `json:"inuse_bytes"`       // bytes in in-use spans
+		Sys        uint64 `json:"sys_bytes"`         // bytes obtained from the OS for the heap
+		Objects    uint64 `json:"objects"`           // live and not yet collected objects
+		TotalAlloc uint64 `json:"total_alloc_bytes"` // bytes allocated over the process lifetime
+	} `json:"heap"`
+
+	GC struct {
+		Count        uint32          `json:"count"`
+		Last         time.Time       `json:"last"`
+		PauseTotal   time.Duration   `json:"pause_total_ns"`
+		RecentPauses []time.Duration `json:"recent_pauses_ns"` // most recent first
+		NextTarget   uint64          `json:"next_target_bytes"`
+	} `json:"gc"`
+}
+
+// recentPauses is the number of recent GC pauses reported.
+const recentPauses = 10
+
+// ReadRuntime returns the current runtime summary.
+// It briefly stops the world to read memory statistics.
+func ReadRuntime() *Runtime {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	var gc debug.GCStats
+	debug.ReadGCStats(&gc)
+
+	rt := &Runtime{
+		GoVersion:  runtime.Version(),
+		GOMAXPROCS: runtime.GOMAXPROCS(0),
+		NumCPU:     runtime.NumCPU(),
+		Goroutines: runtime.NumGoroutine(),
+		Sys:        ms.Sys,
+	}
+	rt.Heap.Alloc = ms.HeapAlloc
+	rt.Heap.Inuse = ms.HeapInuse
+	rt.Heap.Sys = ms.HeapSys
+	rt.Heap.Objects = ms.HeapObjects
+	rt.Heap.TotalAlloc = ms.TotalAlloc
+	rt.GC.Count = ms.NumGC
+	rt.GC.Last = gc.LastGC.UTC()
+	rt.GC.PauseTotal = gc.PauseTotal
+	rt.GC.RecentPauses = gc.Pause[:min(len(gc.Pause), recentPauses)]
+	rt.GC.NextTarget = ms.NextGC
+	return rt
+}
+
+func serveRuntime(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" && r.Method != "HEAD" {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", " ")
+	if err := enc.Encode(ReadRuntime()); err != nil {
+		log.Printf("ERROR rendering JSON for runtime statistics: %v", err)
+	}
+}
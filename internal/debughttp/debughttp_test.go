@@ -0,0 +1,139 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package debughttp
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseAllow(t *testing.T) {
+	list, err := ParseAllow(" 127.0.0.1, 10.1.2.3/8,::1 ,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []netip.Prefix{
+		netip.MustParsePrefix("127.0.0.1/32"),
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("::1/128"),
+	}
+	if len(list) != len(want) {
+		t.Fatalf("ParseAllow = %v, want %v", list, want)
+	}
+	for i := range want {
+		if list[i] != want[i] {
+			t.Errorf("ParseAllow[%d] = %v, want %v", i, list[i], want[i])
+		}
+	}
+
+	for _, s := range []string{"localhost", "10.0.0.0/33", "1.2.3"} {
+		if _, err := ParseAllow(s); err == nil {
+			t.Errorf("ParseAllow(%q) succeeded, want error", s)
+		}
+	}
+}
+
+func TestGuard(t *testing.T) {
+	allow, _ := ParseAllow("10.0.0.0/8")
+	tests := []struct {
+		name   string
+		guard  Guard
+		remote string
+		auth   string
+		want   int
+	}{
+		{"disabled", Guard{}, "10.0.0.1:1", "", 404},
+		{"token", Guard{Token: "t0k"}, "192.0.2.1:1", "Bearer t0k", 200},
+		{"bad token", Guard{Token: "t0k"}, "192.0.2.1:1", "Bearer nope", 401},
+		{"no token", Guard{Token: "t0k"}, "192.0.2.1:1", "", 401},
+		{"allowed", Guard{Allow: allow}, "10.9.8.7:1", "", 200},
+		{"allowed v4-in-v6", Guard{Allow: allow}, "[::ffff:10.9.8.7]:1", "", 200},
+		{"not allowed", Guard{Allow: allow}, "192.0.2.1:1", "", 403},
+		{"both", Guard{Token: "t0k", Allow: allow}, "10.9.8.7:1", "Bearer t0k", 200},
+		{"both without token", Guard{Token: "t0k", Allow: allow}, "10.9.8.7:1", "", 401},
+		{"both from outside", Guard{Token: "t0k", Allow: allow}, "192.0.2.1:1", "Bearer t0k", 403},
+		{"client ip", Guard{Allow: allow, ClientIP: func(*http.Request) string { return "10.0.0.2" }}, "192.0.2.1:1", "", 200},
+	}
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/debug/runtime", nil)
+		r.RemoteAddr = tt.remote
+		if tt.auth != "" {
+			r.Header.Set("Authorization", tt.auth)
+		}
+		w := httptest.NewRecorder()
+		tt.guard.Handler(ok).ServeHTTP(w, r)
+		if w.Code != tt.want {
+			t.Errorf("%s: status %d, want %d", tt.name, w.Code, tt.want)
+		}
+	}
+}
+
+func TestEndpoints(t *testing.T) {
+	mux := http.NewServeMux()
+	Register(mux, &Guard{Token: "t0k"})
+	get := func(path string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", path, nil)
+		r.Header.Set("Authorization", "Bearer t0k")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		if w.Code != 200 {
+			t.Fatalf("GET %s: status %d\n%s", path, w.Code, w.Body)
+		}
+		return w
+	}
+
+	if body := get("/debug/pprof/").Body.String(); !strings.Contains(body, "goroutine") {
+		t.Errorf("/debug/pprof/ does not list profiles:\n%s", body)
+	}
+	if body := get("/debug/pprof/goroutine?debug=1").Body.String(); !strings.Contains(body, "goroutine profile") {
+		t.Errorf("/debug/pprof/goroutine is not a goroutine profile:\n%.200s", body)
+	}
+	var vars map[string]any
+	if err := json.Unmarshal(get("/debug/vars").Body.Bytes(), &vars); err != nil || vars["memstats"] == nil {
+		t.Errorf("/debug/vars: %v, memstats missing", err)
+	}
+
+	runtime.GC()
+	var rt Runtime
+	w := get("/debug/runtime")
+	if err := json.Unmarshal(w.Body.Bytes(), &rt); err != nil {
+		t.Fatal(err)
+	}
+	if rt.GoVersion != runtime.Version() || rt.Goroutines == 0 || rt.Heap.Alloc == 0 || rt.GC.Count == 0 || len(rt.GC.RecentPauses) == 0 {
+		t.Errorf("/debug/runtime = %+v, missing statistics", rt)
+	}
+	if cc := w.Header().Get("Cache-Control"); cc != "no-store" {
+		t.Errorf("Cache-Control = %q, want no-store", cc)
+	}
+}
+
+func TestLongProfile(t *testing.T) {
+	mux := http.NewServeMux()
+	Register(mux, &Guard{Token: "t0k"})
+	srv := httptest.NewUnstartedServer(mux)
+	srv.Config.WriteTimeout = 500 * time.Millisecond // shorter than the trace
+	srv.Start()
+	defer srv.Close()
+
+	req, _ := http.NewRequest("GET", srv.URL+"/debug/pprof/trace?seconds=1", nil)
+	req.Header.Set("Authorization", "Bearer t0k")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if resp.StatusCode != 200 || err != nil || len(body) == 0 {
+		t.Errorf("GET /debug/pprof/trace?seconds=1: %s, %d bytes, %v\n%.200s", resp.Status, len(body), err, body)
+	}
+}
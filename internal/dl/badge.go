@@ -0,0 +1,105 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dl
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/matttproud/yourtour/internal/reqlog"
+)
+
+// badgeMaxAge is how long caches, such as those proxying images in READMEs,
+// may keep a badge, which changes only when a release is made.
+const badgeMaxAge = time.Hour
+
+// Badge colors.
+const (
+	badgeStable   = "#00add8" // Go blue
+	badgeUnstable = "#e37c00"
+	badgeNone     = "#9f9f9f"
+)
+
+// badgeHandler serves /dl/badge.svg, an SVG badge showing the latest
+// stable release, for READMEs and other pages to embed, and its channel
+// variants: /dl/badge-unstable.svg for the latest beta or release candidate,
+// and, for example, /dl/badge-go1.22.svg for the latest release of Go 1.22.
+func (h server) badgeHandler(w http.ResponseWriter, r *http.Request, name string) {
+	channel := strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(name, "badge"), "-"), ".svg")
+	d, err := h.listData(r.Context())
+	if err != nil {
+		reqlog.Logger(r.Context()).Error("listing downloads", "err", err)
+		reqlog.Error(w, r, "Could not get release badge. Try again in a few minutes.", http.StatusInternalServerError)
+		return
+	}
+	var rel *Release
+	color := badgeStable
+	switch {
+	case channel == "":
+		if len(d.Stable) > 0 {
+			rel = &d.Stable[0]
+		}
+	case channel == "unstable":
+		color = badgeUnstable
+		if len(d.Unstable) > 0 {
+			rel = &d.Unstable[0]
+		}
+	case goGetRe.MatchString(channel) && strings.Count(channel, ".") == 1:
+		rel = latestOf(channel, d.Stable, d.Archive)
+		if rel == nil {
+			h.site.ServeErrorStatus(w, r, fmt.Errorf("no release of %s", channel), http.StatusNotFound)
+			return
+		}
+	default:
+		h.site.ServeErrorStatus(w, r, fmt.Errorf("download %s not found", name), http.StatusNotFound)
+		return
+	}
+	msg := "none"
+	if rel != nil {
+		msg = strings.TrimPrefix(rel.Version, "go")
+	} else {
+		color = badgeNone
+	}
+	w.Header().Set("Content-Type", "image/svg+xml")
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(badgeMaxAge/time.Second)))
+	w.Header().Set("ETag", `"`+msg+`"`)
+	svg := badgeSVG("go", msg, color)
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(svg))
+}
+
+// latestOf returns the newest of the releases of the major version v,
+// such as go1.22, in lists sorted newest first, or nil if there are none.
+func latestOf(v string, lists ...[]Release) *Release {
+	for _, list := range lists {
+		for i := range list {
+			if rel := &list[i]; rel.Version == v || strings.HasPrefix(rel.Version, v+".") {
+				return rel
+			}
+		}
+	}
+	return nil
+}
+
+// badgeSVG returns an SVG badge reading label and then msg,
+// the latter on a background of color.
+func badgeSVG(label, msg, color string) []byte {
+	// The widths estimate 11px Verdana, as badges usually use.
+	lw, mw := 10+7*len(label), 10+7*len(msg)
+	label, msg = html.EscapeString(label), html.EscapeString(msg)
+	return []byte(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%[1]d" height="20" role="img" aria-label="%[3]s: %[4]s">
+<title>%[3]s: %[4]s</title>
+<rect width="%[2]d" height="20" fill="#555"/>
+<rect x="%[2]d" width="%[6]d" height="20" fill="%[5]s"/>
+<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">
+<text x="%[7]d" y="14">%[3]s</text>
+<text x="%[8]d" y="14">%[4]s</text>
+</g>
+</svg>
+`, lw+mw, lw, label, msg, color, mw, lw/2, lw+mw/2))
+}
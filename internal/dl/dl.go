@@ -24,6 +24,23 @@
 //	https://go.dev/dl/?mode=json&include=all
 //
 // Releases returned in JSON modes are sorted by version, newest to oldest.
+//
+// A Metalink descriptor of each file, listing its size, hash, and sources
+// for download managers, is served by adding .meta4 to the file's URL:
+//
+//	https://go.dev/dl/{file}.meta4
+//
+// An Atom feed of the latest releases, updated as their files are uploaded,
+// is served at:
+//
+//	https://go.dev/dl/feed.atom
+//
+// An SVG badge showing the latest stable release, for READMEs to embed,
+// is served at /dl/badge.svg, with variants for the latest prerelease
+// and for the latest release of a major version:
+//
+//	https://go.dev/dl/badge-unstable.svg
+//	https://go.dev/dl/badge-go1.22.svg
 package dl
 
 import (
@@ -36,8 +53,13 @@ import (
 )
 
 const (
-	cacheKey      = "download_list_5" // increment if listTemplateData changes
+	cacheKey      = "download_list_6" // increment if listTemplateData changes
 	cacheDuration = time.Hour
+	staleDuration = 24 * time.Hour // serve stale data this long if datastore fails
+
+	// Documents built from the download list, cached along with it.
+	toolchainCacheKey = "toolchain_list_1"
+	feedCacheKey      = "download_feed_1"
 )
 
 // File represents a file on the go.dev downloads page.
@@ -283,6 +305,18 @@ func isStable(v string) bool {
 	return !strings.Contains(v, "beta") && !strings.Contains(v, "rc")
 }
 
+// fileVersion returns the version of the release file named name,
+// as in go1.22rc1 for go1.22rc1.linux-amd64.tar.gz:
+// the elements of name up to the first one not starting with a digit.
+func fileVersion(name string) string {
+	elems := strings.Split(name, ".")
+	n := 1
+	for n < len(elems) && elems[n] != "" && '0' <= elems[n][0] && elems[n][0] <= '9' {
+		n++
+	}
+	return strings.Join(elems[:n], ".")
+}
+
 type fileOrder []File
 
 func (s fileOrder) Len() int      { return len(s) }
@@ -29,6 +29,20 @@ func TestParseVersion(t *testing.T) {
 	}
 }
 
+func TestFileVersion(t *testing.T) {
+	for name, want := range map[string]string{
+		"go1.22.0.linux-amd64.tar.gz":     "go1.22.0",
+		"go1.22rc1.windows-386.msi":       "go1.22rc1",
+		"go1.21.5.src.tar.gz":             "go1.21.5",
+		"go1.4.darwin-amd64-osx10.8.pkg":  "go1.4",
+		"go1.22.0.linux-amd64.tar.gz.asc": "go1.22.0",
+	} {
+		if got := fileVersion(name); got != want {
+			t.Errorf("fileVersion(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
 func TestFileOrder(t *testing.T) {
 	fs := []File{
 		{Filename: "go1.16.src.tar.gz", Version: "go1.16", OS: "", Arch: "", Kind: "source"},
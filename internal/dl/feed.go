@@ -0,0 +1,114 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dl
+
+import (
+	"encoding/xml"
+	"fmt"
+	"html"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/matttproud/yourtour/internal/blog/atom"
+	"github.com/matttproud/yourtour/internal/reqlog"
+)
+
+const (
+	feedID      = "tag:go.dev,2026:dl"
+	feedEntries = 20 // releases listed in the feed
+)
+
+// feedHandler serves /dl/feed.atom, an Atom feed of the latest releases,
+// so that feed readers and release tooling learn of a release when its
+// files are uploaded.
+func (h server) feedHandler(w http.ResponseWriter, r *http.Request) {
+	data, err := h.cached(r.Context(), feedCacheKey, feed)
+	if err != nil {
+		reqlog.Logger(r.Context()).Error("building download feed", "err", err)
+		reqlog.Error(w, r, "Could not get download feed. Try again in a few minutes.", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+	w.Write(data)
+}
+
+// feed returns the Atom feed of the releases in d uploaded last,
+// newest first, each entry listing the release's files.
+// A release is dated by the upload of its last file.
+func feed(d *listTemplateData) ([]byte, error) {
+	type dated struct {
+		rel Release
+		t   time.Time
+	}
+	var list []dated
+	for _, rels := range [][]Release{d.Stable, d.Unstable, d.Archive} {
+		for _, rel := range rels {
+			var t time.Time
+			for _, f := range rel.Files {
+				if f.Uploaded.After(t) {
+					t = f.Uploaded
+				}
+			}
+			list = append(list, dated{rel, t})
+		}
+	}
+	slices.SortStableFunc(list, func(a, b dated) int {
+		if c := b.t.Compare(a.t); c != 0 {
+			return c
+		}
+		return strings.Compare(b.rel.Version, a.rel.Version)
+	})
+	if len(list) > feedEntries {
+		list = list[:feedEntries]
+	}
+
+	const baseURL = "https://go.dev"
+	f := &atom.Feed{
+		Title: "Go releases",
+		ID:    feedID,
+		Link: []atom.Link{
+			{Rel: "self", Href: baseURL + "/dl/feed.atom"},
+			{Rel: "alternate", Href: baseURL + "/dl/"},
+		},
+	}
+	if len(list) > 0 {
+		f.Updated = atom.Time(list[0].t)
+	}
+	for _, x := range list {
+		var content strings.Builder
+		content.WriteString("<ul>\n")
+		for _, file := range x.rel.Files {
+			fmt.Fprintf(&content, "<li><a href=\"%s\">%s</a>", html.EscapeString(baseURL+file.URL()), html.EscapeString(file.Filename))
+			if size := file.PrettySize(); size != "" {
+				fmt.Fprintf(&content, " (%s)", size)
+			}
+			if sum := file.PrettyChecksum(); sum != "" {
+				fmt.Fprintf(&content, " %s <code>%s</code>", file.ChecksumType(), html.EscapeString(sum))
+			}
+			content.WriteString("</li>\n")
+		}
+		content.WriteString("</ul>\n")
+		title := x.rel.Version
+		if !x.rel.Stable {
+			title += " (unstable)"
+		}
+		f.Entry = append(f.Entry, &atom.Entry{
+			Title:     title,
+			ID:        feedID + ":" + x.rel.Version,
+			Link:      []atom.Link{{Rel: "alternate", Href: baseURL + "/dl/#" + x.rel.Version}},
+			Published: atom.Time(x.t),
+			Updated:   atom.Time(x.t),
+			Author:    &atom.Person{Name: "The Go Authors"},
+			Content:   &atom.Text{Type: "html", Body: content.String()},
+		})
+	}
+	data, err := xml.MarshalIndent(f, "", "\t")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(data, '\n')...), nil
+}
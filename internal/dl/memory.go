@@ -0,0 +1,122 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dl
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"sync"
+
+	"cloud.google.com/go/datastore"
+)
+
+// A MemoryDatastore is a Datastore holding File entities in process memory,
+// so that the download pages and uploads can be exercised end to end
+// without a datastore. It is meant for development and tests:
+// uploaded files are lost when the process exits.
+//
+// A MemoryDatastore implements only the operations of the download server:
+// GetAll supports only its query, for every File below the file root,
+// and Get, Put, and Delete support only the keys of those File entities.
+type MemoryDatastore struct {
+	mu    sync.Mutex
+	files map[string]File // by Filename
+}
+
+var _ Datastore = (*MemoryDatastore)(nil)
+
+// NewMemoryDatastore returns a MemoryDatastore holding the files
+// in the embedded snapshot of release data.
+func NewMemoryDatastore() (*MemoryDatastore, error) {
+	d, err := snapshot()
+	if err != nil {
+		return nil, err
+	}
+	m := &MemoryDatastore{files: make(map[string]File)}
+	for _, rels := range [][]Release{d.Stable, d.Unstable, d.Archive} {
+		for _, r := range rels {
+			for _, f := range r.Files {
+				m.files[f.Filename] = f
+			}
+		}
+	}
+	return m, nil
+}
+
+// checkKey checks that key is the key of a File entity.
+func checkKey(key *datastore.Key) error {
+	if key == nil || key.Kind != "File" || !key.Parent.Equal(rootKey) || key.Name == "" {
+		return fmt.Errorf("dl.MemoryDatastore: unsupported key %v", key)
+	}
+	return nil
+}
+
+// Get loads the File entity for key into dst, which must be a *File.
+func (m *MemoryDatastore) Get(ctx context.Context, key *datastore.Key, dst any) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	f, ok := dst.(*File)
+	if !ok {
+		return fmt.Errorf("dl.MemoryDatastore: Get into %T, want *dl.File", dst)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	file, ok := m.files[key.Name]
+	if !ok {
+		return datastore.ErrNoSuchEntity
+	}
+	*f = file
+	return nil
+}
+
+// GetAll appends every File entity to dst, which must be a *[]File,
+// in order of their keys, and returns the keys.
+func (m *MemoryDatastore) GetAll(ctx context.Context, q *datastore.Query, dst any) ([]*datastore.Key, error) {
+	if !reflect.DeepEqual(q, fileQuery()) {
+		return nil, fmt.Errorf("dl.MemoryDatastore: unsupported query %+v", q)
+	}
+	list, ok := dst.(*[]File)
+	if !ok {
+		return nil, fmt.Errorf("dl.MemoryDatastore: GetAll into %T, want *[]dl.File", dst)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var keys []*datastore.Key
+	for _, name := range slices.Sorted(maps.Keys(m.files)) {
+		*list = append(*list, m.files[name])
+		keys = append(keys, fileKey(name))
+	}
+	return keys, nil
+}
+
+// Put stores src, which must be a *File, as the File entity for key.
+func (m *MemoryDatastore) Put(ctx context.Context, key *datastore.Key, src any) (*datastore.Key, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+	f, ok := src.(*File)
+	if !ok {
+		return nil, fmt.Errorf("dl.MemoryDatastore: Put of %T, want *dl.File", src)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.files[key.Name] = *f
+	return key, nil
+}
+
+// Delete deletes the File entity for key, if any.
+func (m *MemoryDatastore) Delete(ctx context.Context, key *datastore.Key) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.files, key.Name)
+	return nil
+}
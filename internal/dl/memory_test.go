@@ -0,0 +1,177 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dl
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/matttproud/yourtour/internal/env"
+	"github.com/matttproud/yourtour/internal/memcache"
+)
+
+func TestMemoryDatastore(t *testing.T) {
+	ctx := context.Background()
+	m, err := NewMemoryDatastore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	snap, err := snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	latest := snap.Stable[0].Files[0]
+
+	var f File
+	if err := m.Get(ctx, fileKey(latest.Filename), &f); err != nil || f != latest {
+		t.Errorf("Get(%s) = %+v, %v, want snapshot file", latest.Filename, f, err)
+	}
+	if err := m.Delete(ctx, fileKey(latest.Filename)); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Get(ctx, fileKey(latest.Filename), &f); err != datastore.ErrNoSuchEntity {
+		t.Errorf("Get after Delete: %v, want ErrNoSuchEntity", err)
+	}
+	if _, err := m.Put(ctx, fileKey(latest.Filename), &latest); err != nil {
+		t.Fatal(err)
+	}
+
+	var fs []File
+	keys, err := m.GetAll(ctx, fileQuery(), &fs)
+	if err != nil || len(keys) != len(fs) || len(fs) == 0 {
+		t.Fatalf("GetAll: %d keys, %d files, %v", len(keys), len(fs), err)
+	}
+	for i, k := range keys {
+		if k.Name != fs[i].Filename || (i > 0 && keys[i-1].Name >= k.Name) {
+			t.Fatalf("GetAll: key %v for %s, want keys in order", k, fs[i].Filename)
+		}
+	}
+
+	if _, err := m.GetAll(ctx, datastore.NewQuery("File"), &fs); err == nil {
+		t.Errorf("GetAll without ancestor succeeded")
+	}
+	if err := m.Get(ctx, datastore.NameKey("File", latest.Filename, nil), &f); err == nil {
+		t.Errorf("Get of key without parent succeeded")
+	}
+	if _, err := m.Put(ctx, fileKey("x"), &fs); err == nil {
+		t.Errorf("Put of %T succeeded", &fs)
+	}
+}
+
+func TestMemoryUpload(t *testing.T) {
+	defer env.Set(nil)
+	defer env.SetSecrets(nil)
+	env.Set(&env.Config{})
+	env.SetSecrets(secretMap{})
+	ctx := context.Background()
+	m, err := NewMemoryDatastore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(d time.Duration) { warmDelay = d }(warmDelay)
+	warmDelay = time.Millisecond
+	h := server{datastore: m, memcache: memcache.NewCodecClient(memcache.NewMemory(0), memcache.Gob), warmer: new(warmer)}
+	if _, err := h.listData(ctx); err != nil { // fill the cache
+		t.Fatal(err)
+	}
+
+	key, err := h.userKey(ctx, "relui")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := `{"filename": "go1.99rc1.linux-amd64.tar.gz", "os": "linux", "arch": "amd64", "version": "go1.99rc1", "sha256": "abc", "size": 1, "kind": "archive"}`
+	w := httptest.NewRecorder()
+	h.uploadHandler(w, httptest.NewRequest("POST", "/dl/upload?"+url.Values{"user": {"relui"}, "key": {key}}.Encode(), strings.NewReader(body)))
+	if w.Code != 200 || w.Body.String() != "OK" {
+		t.Fatalf("upload: %d %s", w.Code, w.Body)
+	}
+	h.warmer.wait()
+
+	// The upload rebuilt the cached download list and the documents derived
+	// from it, which are served from the cache without the datastore.
+	h.datastore = failingListDatastore{m}
+	d, err := h.listData(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Unstable) == 0 || d.Unstable[0].Version != "go1.99rc1" || d.Unstable[0].Files[0].Uploaded.IsZero() {
+		t.Errorf("after upload, unstable releases are %+v, want go1.99rc1", d.Unstable)
+	}
+	for _, x := range derived {
+		data, err := h.cached(ctx, x.key, x.build)
+		if err != nil || !strings.Contains(string(data), "go1.99rc1") {
+			t.Errorf("after upload, %s = %.100q, %v, want go1.99rc1", x.key, data, err)
+		}
+	}
+
+	// Uploads that cannot warm the cache leave it empty, so that the next
+	// request rebuilds the list rather than serve the one without the upload.
+	w = httptest.NewRecorder()
+	body = strings.ReplaceAll(body, "linux-amd64", "darwin-arm64")
+	h.uploadHandler(w, httptest.NewRequest("POST", "/dl/upload?"+url.Values{"user": {"relui"}, "key": {key}}.Encode(), strings.NewReader(body)))
+	if w.Code != 200 {
+		t.Fatalf("second upload: %d %s", w.Code, w.Body)
+	}
+	h.warmer.wait()
+	h.datastore = m
+	if d, err := h.listData(ctx); err != nil || len(d.Unstable[0].Files) != 2 {
+		t.Errorf("after second upload, listData = %+v, %v, want two go1.99rc1 files", d.Unstable, err)
+	}
+}
+
+func TestWarmer(t *testing.T) {
+	defer func(d time.Duration) { warmDelay = d }(warmDelay)
+	warmDelay = 20 * time.Millisecond
+
+	// A burst of uploads is followed by one rebuild.
+	var w warmer
+	var mu sync.Mutex
+	runs, cached := 0, 0
+	rebuild := func(ctx context.Context, stale func() bool) {
+		mu.Lock()
+		defer mu.Unlock()
+		runs++
+		if !stale() {
+			cached++
+		}
+	}
+	for range 5 {
+		w.schedule(rebuild)
+	}
+	w.wait()
+	if runs != 1 || cached != 1 {
+		t.Errorf("after a burst of uploads, %d rebuilds cached %d lists, want 1 and 1", runs, cached)
+	}
+
+	// A rebuild under way when another upload arrives leaves the caching to the next.
+	runs, cached = 0, 0
+	started := make(chan bool)
+	w.schedule(func(ctx context.Context, stale func() bool) {
+		started <- true
+		<-started
+		rebuild(ctx, stale)
+	})
+	<-started
+	w.schedule(rebuild)
+	started <- true
+	w.wait()
+	if runs != 2 || cached != 1 {
+		t.Errorf("with an upload during a rebuild, %d rebuilds cached %d lists, want 2 and 1", runs, cached)
+	}
+}
+
+// A failingListDatastore is a Datastore whose GetAll fails.
+type failingListDatastore struct{ *MemoryDatastore }
+
+func (failingListDatastore) GetAll(ctx context.Context, q *datastore.Query, dst any) ([]*datastore.Key, error) {
+	return nil, errors.New("broken")
+}
@@ -0,0 +1,121 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dl
+
+import (
+	"encoding/xml"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/matttproud/yourtour/internal/env"
+	"github.com/matttproud/yourtour/internal/reqlog"
+)
+
+// downloadBaseURL is the base URL of the download files.
+//
+// The redirect target is an internal implementation detail and may change
+// if there is a good reason to do so. Last time was in CL 76971 (in 2017).
+const downloadBaseURL = "https://dl.google.com/go/"
+
+// metalinkMaxAge is how long caches may keep a Metalink descriptor.
+// A file's size and hash never change, but its mirrors may.
+const metalinkMaxAge = 24 * time.Hour
+
+// A metalink is a Metalink 4 document (RFC 5854).
+type metalink struct {
+	XMLName   xml.Name       `xml:"urn:ietf:params:xml:ns:metalink metalink"`
+	Generator string         `xml:"generator"`
+	Published string         `xml:"published,omitempty"`
+	Files     []metalinkFile `xml:"file"`
+}
+
+type metalinkFile struct {
+	Name string         `xml:"name,attr"`
+	Size int64          `xml:"size"`
+	Hash []metalinkHash `xml:"hash"`
+	URLs []metalinkURL  `xml:"url"`
+}
+
+type metalinkHash struct {
+	Type string `xml:"type,attr"`
+	Sum  string `xml:",chardata"`
+}
+
+type metalinkURL struct {
+	Priority int    `xml:"priority,attr"`
+	URL      string `xml:",chardata"`
+}
+
+// metalinkHandler serves /dl/{file}.meta4, a Metalink descriptor
+// of the download file named name, listing its size, SHA-256 hash,
+// and the URLs of its copies on dl.google.com and the configured mirrors,
+// so that download managers can fetch it from several sources at once
+// and resume interrupted downloads.
+func (h server) metalinkHandler(w http.ResponseWriter, r *http.Request, name string) {
+	d, err := h.listData(r.Context())
+	if err != nil {
+		reqlog.Logger(r.Context()).Error("listing downloads", "err", err)
+		reqlog.Error(w, r, "Could not get download descriptor. Try again in a few minutes.", http.StatusInternalServerError)
+		return
+	}
+	f, ok := findFile(d, name)
+	if !ok || f.ChecksumSHA256 == "" {
+		h.site.ServeErrorStatus(w, r, fmt.Errorf("download %s not found", name), http.StatusNotFound)
+		return
+	}
+	ml := metalink{
+		Generator: "go.dev",
+		Files: []metalinkFile{{
+			Name: f.Filename,
+			Size: f.Size,
+			Hash: []metalinkHash{{Type: "sha-256", Sum: f.ChecksumSHA256}},
+		}},
+	}
+	if !f.Uploaded.IsZero() {
+		ml.Published = f.Uploaded.UTC().Format(time.RFC3339)
+	}
+	for i, base := range append([]string{downloadBaseURL}, mirrors()...) {
+		ml.Files[0].URLs = append(ml.Files[0].URLs, metalinkURL{Priority: i + 1, URL: base + f.Filename})
+	}
+	data, err := xml.MarshalIndent(ml, "", "\t")
+	if err != nil {
+		h.site.ServeError(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/metalink4+xml")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": f.Filename + ".meta4"}))
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(metalinkMaxAge/time.Second)))
+	w.Write([]byte(xml.Header))
+	w.Write(data)
+	w.Write([]byte("\n"))
+}
+
+// findFile returns the file named name in the releases of d.
+func findFile(d *listTemplateData, name string) (File, bool) {
+	for _, rels := range [][]Release{d.Stable, d.Unstable, d.Archive} {
+		for _, rel := range rels {
+			for _, f := range rel.Files {
+				if f.Filename == name {
+					return f, true
+				}
+			}
+		}
+	}
+	return File{}, false
+}
+
+// mirrors returns the base URLs of the configured download mirrors.
+func mirrors() []string {
+	var list []string
+	for _, m := range strings.Split(env.Get().DLMirrors, ",") {
+		if m = strings.TrimSpace(m); m != "" {
+			list = append(list, m)
+		}
+	}
+	return list
+}
@@ -0,0 +1,214 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/matttproud/yourtour/internal/env"
+	"github.com/matttproud/yourtour/internal/reqlog"
+	"github.com/matttproud/yourtour/internal/signed"
+)
+
+// Prefs are a reader's preferences for the download page,
+// kept in a signed cookie set by POST /dl/prefs.
+type Prefs struct {
+	OS      string `json:"os,omitempty"`   // preferred GOOS, such as linux
+	Arch    string `json:"arch,omitempty"` // preferred GOARCH, such as arm64; empty for any
+	Archive bool   `json:"archive"`        // whether to list the archived releases
+}
+
+const (
+	prefsPath   = "/dl/prefs"
+	prefsCookie = "dl-prefs"
+	prefsTTL    = 365 * 24 * time.Hour
+)
+
+// PrefsSecretName is the name of the secret
+// with which preference cookies are signed.
+const PrefsSecretName = "dl-prefs-key"
+
+// prefsValueRE matches the preferred OSes and architectures accepted,
+// which are as in file names, like those of fileRe.
+var prefsValueRE = regexp.MustCompile(`^[a-z0-9]{0,16}$`)
+
+var errBadPrefs = errors.New("invalid preferences")
+
+// prefsSecret returns the secret with which preference cookies are signed.
+// As with builderSecret, a missing secret is replaced outside production
+// by a well-known development key. In production, without the secret,
+// the download page is served without preferences.
+func prefsSecret(ctx context.Context) (string, error) {
+	secret, err := env.GetSecrets().Secret(ctx, PrefsSecretName)
+	if err == env.ErrSecretNotFound && !env.RequireDLSecretKey() {
+		return "gophers choose", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("loading %s: %w", PrefsSecretName, err)
+	}
+	return secret, nil
+}
+
+// signPrefs returns the value of a cookie holding p, signed with key.
+func signPrefs(key string, p Prefs) string {
+	v, err := signed.Encode(key, prefsCookie, &p)
+	if err != nil {
+		panic(err) // Prefs always marshal
+	}
+	return v
+}
+
+// verifyPrefs returns the preferences in the cookie value v if it is signed with key.
+func verifyPrefs(key, v string) (*Prefs, error) {
+	p := new(Prefs)
+	if err := signed.Decode(key, prefsCookie, v, p); err != nil || !p.valid() {
+		return nil, errBadPrefs
+	}
+	return p, nil
+}
+
+func (p *Prefs) valid() bool {
+	return prefsValueRE.MatchString(p.OS) && prefsValueRE.MatchString(p.Arch) && (p.OS != "" || p.Arch == "")
+}
+
+// prefs returns the preferences in r's cookie, or nil if it has none
+// or they cannot be verified.
+func (h server) prefs(r *http.Request) *Prefs {
+	c, err := r.Cookie(prefsCookie)
+	if err != nil {
+		return nil
+	}
+	key, err := prefsSecret(r.Context())
+	if err != nil {
+		if !errors.Is(err, env.ErrSecretNotFound) {
+			reqlog.Logger(r.Context()).Error("reading preferences", "err", err)
+		}
+		return nil
+	}
+	p, err := verifyPrefs(key, c.Value)
+	if err != nil {
+		return nil
+	}
+	return p
+}
+
+// prefsHandler serves /dl/prefs. GET returns the reader's preferences
+// in JSON, or null if there are none. POST sets them from the form
+// values os, arch, and archive, or clears them if the form sets none,
+// and then redirects to the download page. Without the secret
+// signing the cookie, it sets no preferences.
+func (h server) prefsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Vary", "Cookie")
+	w.Header().Set("Cache-Control", "private, no-store")
+	if r.Method == "GET" {
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", " ")
+		if err := enc.Encode(h.prefs(r)); err != nil {
+			reqlog.Logger(r.Context()).Error("rendering JSON for preferences", "err", err)
+		}
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, 1<<10)
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "bad form", http.StatusBadRequest)
+		return
+	}
+	p := Prefs{
+		OS:      r.PostFormValue("os"),
+		Arch:    r.PostFormValue("arch"),
+		Archive: r.PostFormValue("archive") != "",
+	}
+	if !p.valid() {
+		http.Error(w, "bad os or arch", http.StatusBadRequest)
+		return
+	}
+	cookie := &http.Cookie{
+		Name:     prefsCookie,
+		Path:     "/dl/",
+		Secure:   r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" || r.URL.Scheme == "https",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+	if p == (Prefs{}) {
+		cookie.MaxAge = -1
+	} else {
+		key, err := prefsSecret(r.Context())
+		if errors.Is(err, env.ErrSecretNotFound) {
+			reqlog.Logger(r.Context()).Warn("not setting preferences", "err", err)
+			http.Redirect(w, r, "/dl/", http.StatusSeeOther)
+			return
+		}
+		if err != nil {
+			reqlog.Logger(r.Context()).Error("signing preferences", "err", err)
+			reqlog.Error(w, r, "Preferences are not available.", http.StatusServiceUnavailable)
+			return
+		}
+		cookie.Value = signPrefs(key, p)
+		cookie.MaxAge = int(prefsTTL / time.Second)
+	}
+	http.SetCookie(w, cookie)
+	http.Redirect(w, r, "/dl/", http.StatusSeeOther)
+}
+
+// matches reports whether f is for the preferred platform.
+func (p *Prefs) matches(f File) bool {
+	return p.OS != "" && f.OS == p.OS && (p.Arch == "" || f.Arch == p.Arch)
+}
+
+// personalize returns a copy of d for a reader with preferences p:
+// the featured downloads and the files of each release for the preferred
+// platform come first, and the archived releases are left out
+// unless p asks for them. It leaves d unchanged.
+func (d *listTemplateData) personalize(p *Prefs) *listTemplateData {
+	first := func(a, b File) int {
+		switch ma, mb := p.matches(a), p.matches(b); {
+		case ma && !mb:
+			return -1
+		case mb && !ma:
+			return +1
+		}
+		return 0
+	}
+	releases := func(list []Release) []Release {
+		list = slices.Clone(list)
+		for i := range list {
+			list[i].Files = slices.Clone(list[i].Files)
+			slices.SortStableFunc(list[i].Files, first)
+		}
+		return list
+	}
+
+	nd := &listTemplateData{
+		Featured: slices.Clone(d.Featured),
+		Stable:   releases(d.Stable),
+		Unstable: releases(d.Unstable),
+	}
+	if p.Archive {
+		nd.Archive = releases(d.Archive)
+	}
+	slices.SortStableFunc(nd.Featured, func(a, b Feature) int { return first(a.File, b.File) })
+	if len(d.Stable) > 0 && !slices.ContainsFunc(nd.Featured, func(f Feature) bool { return p.matches(f.File) }) {
+		// Feature a download for the preferred platform, an installer if there is one.
+		files := nd.Stable[0].Files
+		i := slices.IndexFunc(files, func(f File) bool { return p.matches(f) && f.Kind == "installer" })
+		if i < 0 {
+			i = slices.IndexFunc(files, func(f File) bool { return p.matches(f) && f.Kind == "archive" })
+		}
+		if i >= 0 {
+			f := files[i]
+			nd.Featured = slices.Insert(nd.Featured, 0, Feature{File: f, Platform: f.PrettyOS() + " " + f.PrettyArch()})
+		}
+	}
+	return nd
+}
@@ -0,0 +1,188 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dl
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/matttproud/yourtour/internal/env"
+	"github.com/matttproud/yourtour/internal/web"
+)
+
+func TestVerifyPrefs(t *testing.T) {
+	p := Prefs{OS: "linux", Arch: "arm64", Archive: true}
+	v := signPrefs("key", p)
+	if got, err := verifyPrefs("key", v); err != nil || *got != p {
+		t.Errorf("verifyPrefs(signPrefs(%+v)) = %+v, %v", p, got, err)
+	}
+	payload, _, _ := strings.Cut(v, ".")
+	forged, _, _ := strings.Cut(signPrefs("key", Prefs{OS: "../x"}), ".")
+	for _, bad := range []string{
+		"",
+		payload,
+		payload + ".",
+		forged + v[len(payload):],
+		signPrefs("other key", p),
+		signPrefs("key", Prefs{OS: "../x"}),
+		signPrefs("key", Prefs{Arch: "amd64"}),
+	} {
+		if got, err := verifyPrefs("key", bad); err == nil {
+			t.Errorf("verifyPrefs(%q) = %+v, want error", bad, got)
+		}
+	}
+}
+
+func TestPersonalize(t *testing.T) {
+	files := []File{
+		{Filename: "go1.2.src.tar.gz", Kind: "source"},
+		{Filename: "go1.2.linux-amd64.tar.gz", OS: "linux", Arch: "amd64", Kind: "archive"},
+		{Filename: "go1.2.freebsd-amd64.tar.gz", OS: "freebsd", Arch: "amd64", Kind: "archive"},
+		{Filename: "go1.2.freebsd-arm64.tar.gz", OS: "freebsd", Arch: "arm64", Kind: "archive"},
+	}
+	d := &listTemplateData{
+		Featured: filesToFeatured(files),
+		Stable:   []Release{{Version: "go1.2", Stable: true, Files: files}},
+		Archive:  []Release{{Version: "go1.1", Stable: true, Files: files}},
+	}
+	names := func(fs []File) []string {
+		var list []string
+		for _, f := range fs {
+			list = append(list, f.Filename)
+		}
+		return list
+	}
+
+	for _, tt := range []struct {
+		prefs    Prefs
+		featured []string
+		files    []string
+		archive  bool
+	}{
+		{
+			prefs:    Prefs{OS: "freebsd", Arch: "arm64"},
+			featured: []string{"go1.2.freebsd-arm64.tar.gz", "go1.2.linux-amd64.tar.gz", "go1.2.src.tar.gz"},
+			files:    []string{"go1.2.freebsd-arm64.tar.gz", "go1.2.src.tar.gz", "go1.2.linux-amd64.tar.gz", "go1.2.freebsd-amd64.tar.gz"},
+		},
+		{
+			prefs:    Prefs{OS: "freebsd", Archive: true},
+			featured: []string{"go1.2.freebsd-amd64.tar.gz", "go1.2.linux-amd64.tar.gz", "go1.2.src.tar.gz"},
+			files:    []string{"go1.2.freebsd-amd64.tar.gz", "go1.2.freebsd-arm64.tar.gz", "go1.2.src.tar.gz", "go1.2.linux-amd64.tar.gz"},
+			archive:  true,
+		},
+		{
+			prefs:    Prefs{OS: "linux"},
+			featured: []string{"go1.2.linux-amd64.tar.gz", "go1.2.src.tar.gz"},
+			files:    []string{"go1.2.linux-amd64.tar.gz", "go1.2.src.tar.gz", "go1.2.freebsd-amd64.tar.gz", "go1.2.freebsd-arm64.tar.gz"},
+		},
+		{
+			prefs:    Prefs{OS: "plan9"},
+			featured: []string{"go1.2.linux-amd64.tar.gz", "go1.2.src.tar.gz"},
+			files:    names(files),
+		},
+	} {
+		nd := d.personalize(&tt.prefs)
+		var featured []string
+		for _, f := range nd.Featured {
+			featured = append(featured, f.Filename)
+		}
+		if !reflect.DeepEqual(featured, tt.featured) {
+			t.Errorf("%+v: featured %q, want %q", tt.prefs, featured, tt.featured)
+		}
+		if got := names(nd.Stable[0].Files); !reflect.DeepEqual(got, tt.files) {
+			t.Errorf("%+v: files %q, want %q", tt.prefs, got, tt.files)
+		}
+		if got := len(nd.Archive) > 0; got != tt.archive {
+			t.Errorf("%+v: archive listed = %v, want %v", tt.prefs, got, tt.archive)
+		}
+	}
+	if got := names(d.Stable[0].Files); !reflect.DeepEqual(got, names(files)) || len(d.Featured) != 2 || len(d.Archive) != 1 {
+		t.Errorf("personalize changed the download list")
+	}
+}
+
+func TestPrefsHandler(t *testing.T) {
+	defer env.Set(nil)
+	defer env.SetSecrets(nil)
+	env.Set(&env.Config{})
+	env.SetSecrets(secretMap{PrefsSecretName: "key"})
+	h := server{site: web.NewSite(fstest.MapFS{
+		"site.tmpl": {Data: []byte(`{{block "layout" .}}{{.Content}}{{end}}`)},
+		"dl.tmpl":   {Data: []byte(`{{define "layout"}}{{range .dl.Featured}}{{.Filename}} {{end}}archive={{len .dl.Archive}}{{end}}`)},
+	})}
+	post := func(form url.Values) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("POST", "/dl/prefs", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		h.prefsHandler(w, r)
+		return w
+	}
+	get := func(path string, cookies []*http.Cookie) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", path, nil)
+		for _, c := range cookies {
+			r.AddCookie(c)
+		}
+		w := httptest.NewRecorder()
+		if path == "/dl/prefs" {
+			h.prefsHandler(w, r)
+		} else {
+			h.listHandler(w, r)
+		}
+		return w
+	}
+
+	w := post(url.Values{"os": {"darwin"}, "arch": {"arm64"}})
+	cookies := w.Result().Cookies()
+	if w.Code != http.StatusSeeOther || w.Header().Get("Location") != "/dl/" || len(cookies) != 1 || cookies[0].Name != prefsCookie || !cookies[0].HttpOnly {
+		t.Fatalf("POST /dl/prefs = %d to %q with cookies %v, want 303 to /dl/ with the preferences cookie", w.Code, w.Header().Get("Location"), cookies)
+	}
+	if w := get("/dl/prefs", cookies); strings.TrimSpace(w.Body.String()) != "{\n \"os\": \"darwin\",\n \"arch\": \"arm64\",\n \"archive\": false\n}" {
+		t.Errorf("GET /dl/prefs = %s", w.Body)
+	}
+	if w := get("/dl/prefs", nil); strings.TrimSpace(w.Body.String()) != "null" {
+		t.Errorf("GET /dl/prefs without cookie = %s, want null", w.Body)
+	}
+
+	d, err := snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain := get("/dl/", nil)
+	if !strings.HasPrefix(plain.Body.String(), d.Featured[0].Filename+" ") || strings.HasSuffix(plain.Body.String(), "archive=0") {
+		t.Errorf("GET /dl/ = %s, want the featured downloads in order and the archive", plain.Body)
+	}
+	w = get("/dl/", cookies)
+	if body := w.Body.String(); !strings.Contains(body, ".darwin-arm64.pkg ") || strings.HasPrefix(body, d.Featured[0].Filename+" ") || !strings.HasSuffix(body, "archive=0") {
+		t.Errorf("GET /dl/ with preferences = %s, want the darwin/arm64 download first and no archive", body)
+	}
+	if vary := w.Header().Values("Vary"); !strings.Contains(strings.Join(vary, ","), "Cookie") {
+		t.Errorf("GET /dl/ Vary = %q, want Cookie", vary)
+	}
+
+	env.SetSecrets(secretMap{PrefsSecretName: "new key"})
+	if w := get("/dl/", cookies); w.Body.String() != plain.Body.String() {
+		t.Errorf("GET /dl/ with preferences signed with an old key = %s, want the plain page", w.Body)
+	}
+
+	if w := post(url.Values{"os": {"../etc"}}); w.Code != http.StatusBadRequest {
+		t.Errorf("POST /dl/prefs with bad os = %d, want 400", w.Code)
+	}
+	w = post(url.Values{})
+	if cookies := w.Result().Cookies(); w.Code != http.StatusSeeOther || len(cookies) != 1 || cookies[0].MaxAge >= 0 {
+		t.Errorf("POST /dl/prefs with none = %d with cookies %v, want 303 clearing the cookie", w.Code, cookies)
+	}
+
+	env.Set(&env.Config{RequireDLSecretKey: true})
+	env.SetSecrets(secretMap{})
+	w = post(url.Values{"os": {"linux"}})
+	if cookies := w.Result().Cookies(); w.Code != http.StatusSeeOther || len(cookies) != 0 {
+		t.Errorf("POST /dl/prefs with missing required secret = %d with cookies %v, want 303 setting none", w.Code, cookies)
+	}
+}
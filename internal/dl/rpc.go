@@ -0,0 +1,437 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dl
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/matttproud/yourtour/internal/reqlog"
+)
+
+// The release service of release.proto is served with the Connect protocol,
+// described at https://connectrpc.com/docs/protocol.
+// Only the JSON codec is supported, and without compression,
+// which is all that internal tooling needs and keeps this free of generated code.
+
+// rpcService is the full name of the release service.
+const rpcService = "golang.dl.v1.ReleaseService"
+
+// A channel is the Channel enum of release.proto.
+type channel int32
+
+const (
+	channelUnspecified channel = iota
+	channelStable
+	channelUnstable
+	channelArchive
+)
+
+var channelNames = []string{"CHANNEL_UNSPECIFIED", "CHANNEL_STABLE", "CHANNEL_UNSTABLE", "CHANNEL_ARCHIVE"}
+
+func (c channel) MarshalJSON() ([]byte, error) {
+	if c < 0 || int(c) >= len(channelNames) {
+		return json.Marshal(int32(c))
+	}
+	return json.Marshal(channelNames[c])
+}
+
+// UnmarshalJSON accepts a channel's name or number, as protobuf JSON does.
+func (c *channel) UnmarshalJSON(data []byte) error {
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	switch v := v.(type) {
+	case nil:
+		*c = channelUnspecified
+		return nil
+	case string:
+		for i, name := range channelNames {
+			if v == name {
+				*c = channel(i)
+				return nil
+			}
+		}
+	case float64:
+		if v >= 0 && int(v) < len(channelNames) && v == float64(int(v)) {
+			*c = channel(v)
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid channel %s", data)
+}
+
+// Messages of release.proto, in their protobuf JSON form.
+type (
+	rpcRelease struct {
+		Version string    `json:"version,omitempty"`
+		Stable  bool      `json:"stable,omitempty"`
+		Channel channel   `json:"channel,omitempty"`
+		Files   []rpcFile `json:"files,omitempty"`
+	}
+	rpcFile struct {
+		Filename string `json:"filename,omitempty"`
+		OS       string `json:"os,omitempty"`
+		Arch     string `json:"arch,omitempty"`
+		Version  string `json:"version,omitempty"`
+		SHA256   string `json:"sha256,omitempty"`
+		Size     int64  `json:"size,omitempty,string"`
+		Kind     string `json:"kind,omitempty"`
+	}
+	listReleasesRequest struct {
+		Channel channel `json:"channel"`
+	}
+	listReleasesResponse struct {
+		Releases []rpcRelease `json:"releases,omitempty"`
+	}
+	getReleaseRequest struct {
+		Version string `json:"version"`
+	}
+	getReleaseResponse struct {
+		Release *rpcRelease `json:"release,omitempty"`
+	}
+)
+
+// WatchReleases uses the ListReleases messages,
+// as release.proto's WatchReleasesRequest and Response are the same.
+type (
+	watchReleasesRequest  = listReleasesRequest
+	watchReleasesResponse = listReleasesResponse
+)
+
+// An rpcError is a Connect error.
+type rpcError struct {
+	Code    string `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+func (e *rpcError) Error() string { return e.Code + ": " + e.Message }
+
+// rpcStatus maps the Connect error codes used here to HTTP status codes.
+var rpcStatus = map[string]int{
+	"invalid_argument":   http.StatusBadRequest,
+	"not_found":          http.StatusNotFound,
+	"resource_exhausted": http.StatusTooManyRequests,
+	"unimplemented":      http.StatusNotImplemented,
+	"unavailable":        http.StatusServiceUnavailable,
+	"deadline_exceeded":  http.StatusGatewayTimeout,
+}
+
+func rpcErrorf(code, format string, args ...any) *rpcError {
+	return &rpcError{code, fmt.Sprintf(format, args...)}
+}
+
+// maxRPCRequest is the largest accepted request message.
+const maxRPCRequest = 4 << 10
+
+// rpcHandler serves the methods of the release service,
+// at /golang.dl.v1.ReleaseService/<method>.
+func (h server) rpcHandler(w http.ResponseWriter, r *http.Request) {
+	if v := r.Header.Get("Connect-Protocol-Version"); v != "" && v != "1" {
+		writeRPCError(w, rpcErrorf("invalid_argument", "unsupported Connect-Protocol-Version %q", v))
+		return
+	}
+	ctx := r.Context()
+	switch method := strings.TrimPrefix(r.URL.Path, "/"+rpcService+"/"); method {
+	case "ListReleases":
+		var req listReleasesRequest
+		if !readUnary(w, r, &req) {
+			return
+		}
+		rels, err := h.releases(ctx, req.Channel)
+		if err != nil {
+			writeRPCError(w, err)
+			return
+		}
+		writeUnary(w, &listReleasesResponse{rels})
+	case "GetRelease":
+		var req getReleaseRequest
+		if !readUnary(w, r, &req) {
+			return
+		}
+		if req.Version == "" {
+			writeRPCError(w, rpcErrorf("invalid_argument", "missing version"))
+			return
+		}
+		rels, err := h.releases(ctx, channelUnspecified)
+		if err != nil {
+			writeRPCError(w, err)
+			return
+		}
+		for i := range rels {
+			if rels[i].Version == req.Version {
+				writeUnary(w, &getReleaseResponse{&rels[i]})
+				return
+			}
+		}
+		writeRPCError(w, rpcErrorf("not_found", "no release %s", req.Version))
+	case "WatchReleases":
+		h.watchReleases(w, r)
+	default:
+		writeRPCError(w, rpcErrorf("unimplemented", "%s has no method %q", rpcService, method))
+	}
+}
+
+// releases returns the releases in channel ch,
+// or all releases if ch is channelUnspecified.
+func (h server) releases(ctx context.Context, ch channel) ([]rpcRelease, error) {
+	d, err := h.listData(ctx)
+	if err != nil {
+		reqlog.Logger(ctx).Error("listing downloads", "err", err)
+		return nil, rpcErrorf("unavailable", "release list unavailable")
+	}
+	var list []rpcRelease
+	for _, c := range []struct {
+		ch   channel
+		rels []Release
+	}{
+		{channelUnstable, d.Unstable},
+		{channelStable, d.Stable},
+		{channelArchive, d.Archive},
+	} {
+		if ch != channelUnspecified && ch != c.ch {
+			continue
+		}
+		for _, rel := range c.rels {
+			r := rpcRelease{Version: rel.Version, Stable: rel.Stable, Channel: c.ch}
+			for _, f := range rel.Files {
+				r.Files = append(r.Files, rpcFile{
+					Filename: f.Filename,
+					OS:       f.OS,
+					Arch:     f.Arch,
+					Version:  f.Version,
+					SHA256:   f.ChecksumSHA256,
+					Size:     f.Size,
+					Kind:     f.Kind,
+				})
+			}
+			list = append(list, r)
+		}
+	}
+	return list, nil
+}
+
+// readUnary reads the request message of a unary call into req.
+// If the request is invalid, readUnary replies with an error
+// and returns false.
+func readUnary(w http.ResponseWriter, r *http.Request, req any) bool {
+	if mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mt != "application/json" {
+		w.Header().Set("Accept-Post", "application/json")
+		http.Error(w, "unsupported content type; want application/json", http.StatusUnsupportedMediaType)
+		return false
+	}
+	if e := r.Header.Get("Content-Encoding"); e != "" && e != "identity" {
+		writeRPCError(w, rpcErrorf("unimplemented", "unsupported Content-Encoding %q", e))
+		return false
+	}
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRPCRequest))
+	if err == nil {
+		err = decodeMessage(data, req)
+	}
+	if err != nil {
+		writeRPCError(w, rpcErrorf("invalid_argument", "invalid request: %v", err))
+		return false
+	}
+	return true
+}
+
+// decodeMessage decodes the JSON message data into m,
+// rejecting unknown fields as protobuf JSON does.
+func decodeMessage(data []byte, m any) error {
+	if len(bytes.TrimSpace(data)) == 0 {
+		data = []byte("{}")
+	}
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.DisallowUnknownFields()
+	return d.Decode(m)
+}
+
+func writeUnary(w http.ResponseWriter, resp any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// writeRPCError replies to a unary call with err,
+// which is an internal error unless it is an *rpcError.
+func writeRPCError(w http.ResponseWriter, err error) {
+	e, ok := err.(*rpcError)
+	if !ok {
+		e = &rpcError{"internal", err.Error()}
+	}
+	status, ok := rpcStatus[e.Code]
+	if !ok {
+		status = http.StatusInternalServerError
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(e)
+}
+
+// maxWatch is the longest a WatchReleases stream lasts.
+const maxWatch = time.Hour
+
+// watchSlack is how long past its timeout a WatchReleases stream
+// may take writing its end, beyond the server's write timeout.
+const watchSlack = 10 * time.Second
+
+// maxWatchers is the most WatchReleases streams served at once.
+const maxWatchers = 500
+
+// watchers counts the WatchReleases streams being served.
+var watchers atomic.Int32
+
+// watchPoll is how often WatchReleases checks for changed releases.
+// It is a variable for testing.
+var watchPoll = time.Minute
+
+// Flags of Connect streaming envelopes.
+const (
+	flagCompressed = 0x01
+	flagEndStream  = 0x02
+)
+
+// watchReleases serves the WatchReleases streaming method.
+func (h server) watchReleases(w http.ResponseWriter, r *http.Request) {
+	if mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mt != "application/connect+json" {
+		w.Header().Set("Accept-Post", "application/connect+json")
+		http.Error(w, "unsupported content type; want application/connect+json", http.StatusUnsupportedMediaType)
+		return
+	}
+	ctx := r.Context()
+	timeout := maxWatch
+	clientDeadline := false
+	if ms := r.Header.Get("Connect-Timeout-Ms"); ms != "" {
+		n, err := strconv.ParseInt(ms, 10, 64)
+		if err != nil || n <= 0 || len(ms) > 10 {
+			writeRPCError(w, rpcErrorf("invalid_argument", "invalid Connect-Timeout-Ms %q", ms))
+			return
+		}
+		if d := time.Duration(n) * time.Millisecond; d < timeout {
+			timeout, clientDeadline = d, true
+		}
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// Errors in streams are sent at the end of the stream, after a 200 OK.
+	w.Header().Set("Content-Type", "application/connect+json")
+	var req watchReleasesRequest
+	err := readEnvelope(http.MaxBytesReader(w, r.Body, maxRPCRequest+5), &req)
+	if err != nil {
+		endStream(w, err)
+		return
+	}
+	if watchers.Add(1) > maxWatchers {
+		watchers.Add(-1)
+		endStream(w, rpcErrorf("resource_exhausted", "too many watchers; try again later"))
+		return
+	}
+	defer watchers.Add(-1)
+
+	rc := http.NewResponseController(w)
+	// The stream outlasts the server's write timeout, which is
+	// for ordinary replies; extend the deadline to the stream's own.
+	// If the deadline cannot be set, the stream ends when the timeout cuts it.
+	rc.SetWriteDeadline(time.Now().Add(timeout + watchSlack))
+	var last []byte
+	ticker := time.NewTicker(watchPoll)
+	defer ticker.Stop()
+	for {
+		rels, err := h.releases(ctx, req.Channel)
+		if err != nil && ctx.Err() == nil {
+			endStream(w, err)
+			return
+		}
+		if err == nil {
+			msg, err := json.Marshal(&watchReleasesResponse{rels})
+			if err != nil {
+				endStream(w, err)
+				return
+			}
+			if !bytes.Equal(msg, last) {
+				if err := writeEnvelope(w, 0, msg); err != nil {
+					return // client gone
+				}
+				rc.Flush()
+				last = msg
+			}
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			switch {
+			case r.Context().Err() != nil:
+				// Client gone; nothing to say.
+			case clientDeadline:
+				endStream(w, rpcErrorf("deadline_exceeded", "deadline exceeded"))
+			default:
+				endStream(w, nil)
+			}
+			return
+		}
+	}
+}
+
+// readEnvelope reads the single enveloped message of a streaming request into m.
+func readEnvelope(r io.Reader, m any) error {
+	var hdr [5]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return rpcErrorf("invalid_argument", "reading request: %v", err)
+	}
+	if hdr[0]&flagCompressed != 0 {
+		return rpcErrorf("unimplemented", "compressed messages are not supported")
+	}
+	n := binary.BigEndian.Uint32(hdr[1:])
+	if n > maxRPCRequest {
+		return rpcErrorf("resource_exhausted", "request message too large")
+	}
+	data := make([]byte, n)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return rpcErrorf("invalid_argument", "reading request: %v", err)
+	}
+	if err := decodeMessage(data, m); err != nil {
+		return rpcErrorf("invalid_argument", "invalid request: %v", err)
+	}
+	return nil
+}
+
+// writeEnvelope writes the message msg to w in a streaming envelope with the given flags.
+func writeEnvelope(w io.Writer, flags byte, msg []byte) error {
+	var hdr [5]byte
+	hdr[0] = flags
+	binary.BigEndian.PutUint32(hdr[1:], uint32(len(msg)))
+	if _, err := w.Write(hdr[:]); err != nil {
+		return err
+	}
+	_, err := w.Write(msg)
+	return err
+}
+
+// endStream ends a streaming response, reporting err if it is not nil.
+func endStream(w io.Writer, err error) {
+	var end struct {
+		Error *rpcError `json:"error,omitempty"`
+	}
+	if err != nil {
+		e, ok := err.(*rpcError)
+		if !ok {
+			e = &rpcError{"internal", err.Error()}
+		}
+		end.Error = e
+	}
+	msg, _ := json.Marshal(&end)
+	writeEnvelope(w, flagEndStream, msg)
+}
@@ -0,0 +1,197 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dl
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/matttproud/yourtour/internal/memcache"
+)
+
+func rpc(h http.Handler, method, ctype, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest("POST", "/"+rpcService+"/"+method, strings.NewReader(body))
+	r.Header.Set("Content-Type", ctype)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestUnaryRPC(t *testing.T) {
+	s := server{}
+	h := http.HandlerFunc(s.rpcHandler)
+	snap, err := snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := rpc(h, "ListReleases", "application/json", `{"channel": "CHANNEL_STABLE"}`)
+	var list listReleasesResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil || w.Code != 200 {
+		t.Fatalf("ListReleases: %d %v\n%s", w.Code, err, w.Body)
+	}
+	if len(list.Releases) != len(snap.Stable) || list.Releases[0].Version != snap.Stable[0].Version {
+		t.Errorf("ListReleases(stable) listed %d releases, want %d", len(list.Releases), len(snap.Stable))
+	}
+	for _, rel := range list.Releases {
+		if rel.Channel != channelStable || len(rel.Files) == 0 {
+			t.Errorf("stable release %s: channel %v, %d files", rel.Version, rel.Channel, len(rel.Files))
+		}
+	}
+	if !strings.Contains(w.Body.String(), `"channel":"CHANNEL_STABLE"`) || !strings.Contains(w.Body.String(), `"size":"`) {
+		t.Errorf("ListReleases response not in protobuf JSON form:\n%.500s", w.Body)
+	}
+
+	w = rpc(h, "ListReleases", "application/json", `{}`)
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatal(err)
+	}
+	if want := len(snap.Stable) + len(snap.Unstable) + len(snap.Archive); len(list.Releases) != want {
+		t.Errorf("ListReleases(all) listed %d releases, want %d", len(list.Releases), want)
+	}
+
+	v := snap.Archive[0].Version
+	w = rpc(h, "GetRelease", "application/json", `{"version": "`+v+`"}`)
+	var get getReleaseResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &get); err != nil || w.Code != 200 {
+		t.Fatalf("GetRelease: %d %v\n%s", w.Code, err, w.Body)
+	}
+	if get.Release == nil || get.Release.Version != v || get.Release.Channel != channelArchive {
+		t.Errorf("GetRelease(%s) = %+v", v, get.Release)
+	}
+
+	for _, tt := range []struct {
+		method, ctype, body string
+		code                int
+		want                string
+	}{
+		{"GetRelease", "application/json", `{"version": "go0.1"}`, 404, `{"code":"not_found","message":"no release go0.1"}`},
+		{"GetRelease", "application/json", `{}`, 400, `"code":"invalid_argument"`},
+		{"GetRelease", "application/json", `{"version": "go1", "bogus": 1}`, 400, `unknown field`},
+		{"ListReleases", "application/json", `{"channel": "CHANNEL_NIGHTLY"}`, 400, `invalid channel`},
+		{"ListReleases", "application/json", `{"channel": 3}`, 200, `"channel":"CHANNEL_ARCHIVE"`},
+		{"ListReleases", "application/proto", ``, http.StatusUnsupportedMediaType, ``},
+		{"DeleteRelease", "application/json", `{}`, http.StatusNotImplemented, `"code":"unimplemented"`},
+	} {
+		w := rpc(h, tt.method, tt.ctype, tt.body)
+		if w.Code != tt.code || !strings.Contains(w.Body.String(), tt.want) {
+			t.Errorf("%s %s: %d %.200s\nwant %d with %s", tt.method, tt.body, w.Code, w.Body, tt.code, tt.want)
+		}
+	}
+}
+
+// fileDatastore is a Datastore holding Files.
+type fileDatastore struct {
+	mu    sync.Mutex
+	files []File
+}
+
+func (d *fileDatastore) GetAll(ctx context.Context, q *datastore.Query, dst any) ([]*datastore.Key, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	*dst.(*[]File) = append([]File(nil), d.files...)
+	return nil, nil
+}
+
+func (d *fileDatastore) Put(ctx context.Context, key *datastore.Key, src any) (*datastore.Key, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.files = append(d.files, *src.(*File))
+	return key, nil
+}
+
+func envelope(flags byte, msg string) []byte {
+	b := []byte{flags, 0, 0, 0, 0}
+	binary.BigEndian.PutUint32(b[1:], uint32(len(msg)))
+	return append(b, msg...)
+}
+
+// readEnvelopeFrom reads a streaming envelope from r.
+func readEnvelopeFrom(t *testing.T, r io.Reader) (flags byte, msg string) {
+	t.Helper()
+	var hdr [5]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		t.Fatalf("reading envelope: %v", err)
+	}
+	data := make([]byte, binary.BigEndian.Uint32(hdr[1:]))
+	if _, err := io.ReadFull(r, data); err != nil {
+		t.Fatalf("reading envelope: %v", err)
+	}
+	return hdr[0], string(data)
+}
+
+func TestWatchReleases(t *testing.T) {
+	defer func(d time.Duration) { watchPoll = d }(watchPoll)
+	watchPoll = 10 * time.Millisecond
+
+	ds := &fileDatastore{files: []File{{Filename: "go1.30.0.src.tar.gz", Version: "go1.30.0", Kind: "source"}}}
+	s := server{datastore: ds, memcache: memcache.NewCodecClient(memcache.NewMemory(0), memcache.Gob)}
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(s.rpcHandler))
+	srv.Config.WriteTimeout = 500 * time.Millisecond // shorter than the stream
+	srv.Start()
+	defer srv.Close()
+
+	req, _ := http.NewRequest("POST", srv.URL+"/"+rpcService+"/WatchReleases", bytes.NewReader(envelope(0, `{"channel":"CHANNEL_STABLE"}`)))
+	req.Header.Set("Content-Type", "application/connect+json")
+	req.Header.Set("Connect-Timeout-Ms", "2000")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 || resp.Header.Get("Content-Type") != "application/connect+json" {
+		t.Fatalf("WatchReleases: %s, %s", resp.Status, resp.Header.Get("Content-Type"))
+	}
+
+	flags, msg := readEnvelopeFrom(t, resp.Body)
+	if flags != 0 || !strings.Contains(msg, `"version":"go1.30.0"`) {
+		t.Fatalf("first message: %#x %s", flags, msg)
+	}
+
+	ds.Put(context.Background(), nil, &File{Filename: "go1.31.0.src.tar.gz", Version: "go1.31.0", Kind: "source"})
+	s.memcache.Delete(context.Background(), cacheKey)
+	flags, msg = readEnvelopeFrom(t, resp.Body)
+	if flags != 0 || !strings.Contains(msg, `"version":"go1.31.0"`) {
+		t.Fatalf("message after release: %#x %s", flags, msg)
+	}
+
+	flags, msg = readEnvelopeFrom(t, resp.Body)
+	if flags != flagEndStream || msg != `{"error":{"code":"deadline_exceeded","message":"deadline exceeded"}}` {
+		t.Errorf("end of stream: %#x %s", flags, msg)
+	}
+}
+
+func TestWatchReleasesErrors(t *testing.T) {
+	h := http.HandlerFunc(server{}.rpcHandler)
+	for _, tt := range []struct {
+		ctype string
+		body  []byte
+		code  int
+		want  string
+	}{
+		{"application/json", []byte(`{}`), http.StatusUnsupportedMediaType, ""},
+		{"application/connect+json", envelope(flagCompressed, `{}`), 200, `"code":"unimplemented"`},
+		{"application/connect+json", envelope(0, `{"channel":`), 200, `"code":"invalid_argument"`},
+		{"application/connect+json", []byte{0, 0}, 200, `"code":"invalid_argument"`},
+	} {
+		w := rpc(h, "WatchReleases", tt.ctype, string(tt.body))
+		if w.Code != tt.code || !strings.Contains(w.Body.String(), tt.want) {
+			t.Errorf("WatchReleases %q: %d %q, want %d with %s", tt.body, w.Code, w.Body, tt.code, tt.want)
+		}
+		if tt.code == 200 && w.Body.Bytes()[0] != flagEndStream {
+			t.Errorf("WatchReleases %q: no end of stream", tt.body)
+		}
+	}
+}
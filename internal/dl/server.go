@@ -12,54 +12,192 @@ import (
 	_ "embed"
 	"encoding/gob"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html"
 	"io"
-	"log"
 	"net/http"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	"cloud.google.com/go/datastore"
+	"github.com/matttproud/yourtour/internal/accesslog"
+	"github.com/matttproud/yourtour/internal/analytics"
 	"github.com/matttproud/yourtour/internal/env"
+	"github.com/matttproud/yourtour/internal/jsonld"
 	"github.com/matttproud/yourtour/internal/memcache"
+	"github.com/matttproud/yourtour/internal/reqlog"
+	"github.com/matttproud/yourtour/internal/router"
+	"github.com/matttproud/yourtour/internal/vhost"
 	"github.com/matttproud/yourtour/internal/web"
 )
 
 type server struct {
 	site      *web.Site
-	datastore *datastore.Client
+	datastore Datastore
 	memcache  *memcache.CodecClient
+	warmer    *warmer // rebuilds the cache after uploads
 }
 
-func RegisterHandlers(mux *http.ServeMux, site *web.Site, host string, dc *datastore.Client, mc *memcache.Client) {
+// Datastore is the part of a *datastore.Client used by the download server.
+type Datastore interface {
+	GetAll(ctx context.Context, q *datastore.Query, dst any) ([]*datastore.Key, error)
+	Put(ctx context.Context, key *datastore.Key, src any) (*datastore.Key, error)
+}
+
+var _ Datastore = (*datastore.Client)(nil)
+
+// ErrUnavailable is returned, possibly wrapped, by a Datastore
+// that is down for planned maintenance. While it is, the download pages
+// list the releases last cached, even if stale, or else those in the
+// embedded snapshot, and uploads fail with 503 Service Unavailable.
+var ErrUnavailable = errors.New("datastore unavailable for maintenance")
+
+// RegisterHandlers registers the download server's handlers
+// for the virtual host h, rendering its pages with h's site,
+// along with the release service of release.proto.
+// If dc is nil (rather than holding a nil *datastore.Client),
+// the download pages list the releases in an embedded snapshot of release data.
+func RegisterHandlers(h *vhost.Host, dc Datastore, mc memcache.Cache) {
+	s := newServer(h.Site, dc, mc)
+	r := h.Router
+	r.HandleFunc("GET", "/dl", s.getHandler)
+	r.HandleFunc("GET", "/dl/", s.getHandler) // also serves listHandler
+	r.HandleFunc("OPTIONS", "/dl/", s.getHandler)
+	r.HandleFunc("GET", "/dl/badge.svg", s.getHandler) // also serves the other badges, below /dl/
+	r.HandleFunc("GET", "/dl/feed.atom", s.getHandler)
+	r.HandleFunc("GET", "/dl/mod/golang.org/toolchain/@v/", s.toolchainRedirect)
+	r.HandleFunc("OPTIONS", "/dl/mod/golang.org/toolchain/@v/", s.toolchainRedirect)
+	r.HandleFunc("GET", "/dl/mod/golang.org/toolchain/@v/list", s.toolchainList)
+	r.HandleFunc("POST", "/dl/upload", s.uploadHandler)
+	r.HandleFunc("GET", prefsPath, s.prefsHandler)
+	r.HandleFunc("POST", prefsPath, s.prefsHandler)
+	r.HandleFunc("POST", "/"+rpcService+"/", s.rpcHandler)
+	r.Document("GET", "/dl/", router.Doc{
+		Summary: "Lists the releases of Go and their files, with ?mode=json; otherwise serves the download page.",
+		Params: []router.Param{
+			{Name: "mode", Description: "json to list the releases in JSON", Required: true},
+			{Name: "include", Description: "all to list every release, not only the supported ones"},
+		},
+		Example: []Release{{
+			Version: "go1.22.0",
+			Stable:  true,
+			Files: []File{{
+				Filename:       "go1.22.0.linux-amd64.tar.gz",
+				OS:             "linux",
+				Arch:           "amd64",
+				Version:        "go1.22.0",
+				ChecksumSHA256: "f6c8a87aa03b92c4b0bf3d558e28ea03006eb29db78917daec5cfb6ec1046265",
+				Size:           68988925,
+				Kind:           "archive",
+			}},
+		}},
+	})
+	r.Document("POST", prefsPath, router.Doc{
+		Summary: "Sets the reader's preferences for the download page in a signed cookie, " +
+			"listing the files for the preferred platform first and leaving out the archived releases unless asked for; " +
+			"posting none clears them, and GET returns them.",
+		Params: []router.Param{
+			{Name: "os", Description: "the preferred GOOS, such as linux"},
+			{Name: "arch", Description: "the preferred GOARCH, such as arm64; default any"},
+			{Name: "archive", Description: "non-empty to list the archived releases"},
+		},
+		Example: &Prefs{OS: "linux", Arch: "arm64"},
+	})
+	r.Document("GET", "/dl/badge.svg", router.Doc{
+		Summary: "Serves an SVG badge showing the latest stable release, for READMEs; " +
+			"/dl/badge-unstable.svg shows the latest prerelease, and /dl/badge-go1.N.svg the latest release of Go 1.N.",
+	})
+	r.Document("GET", "/dl/feed.atom", router.Doc{
+		Summary: "Serves an Atom feed of the latest releases, each entry listing the release's files.",
+	})
+	r.Document("GET", "/dl/mod/golang.org/toolchain/@v/list", router.Doc{
+		Summary: "Lists the versions of the golang.org/toolchain module, one per line, as a Go module proxy does.",
+	})
+	h.Site.AddSuggester(s.suggestions)
+	h.Site.AddPrecacher(s.precache)
+	h.Site.AddContract(DownloadsPage{})
+}
+
+func newServer(site *web.Site, dc Datastore, mc memcache.Cache) server {
 	var gob *memcache.CodecClient
 	if mc != nil {
-		gob = mc.WithCodec(memcache.Gob)
+		gob = memcache.NewCodecClient(mc, memcache.Gob)
+	}
+	return server{site, dc, gob, new(warmer)}
+}
+
+// Releases returns the stable, unstable, and archived releases
+// listed on the download pages served from dc and mc by RegisterHandlers,
+// each newest first.
+func Releases(ctx context.Context, dc Datastore, mc memcache.Cache) (stable, unstable, archive []Release, err error) {
+	d, err := newServer(nil, dc, mc).listData(ctx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return d.Stable, d.Unstable, d.Archive, nil
+}
+
+// precache returns the entry for the JSON list of stable releases,
+// the embedded snapshot when there is no datastore, for the service
+// worker to fetch so that the list is at hand offline.
+func (h server) precache(ctx context.Context) ([]web.PrecacheEntry, error) {
+	d, err := h.listData(ctx)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(d.Stable)
+	if err != nil {
+		return nil, err
 	}
-	s := server{site, dc, gob}
-	mux.HandleFunc(host+"/dl", s.getHandler)
-	mux.HandleFunc(host+"/dl/", s.getHandler) // also serves listHandler
-	mux.HandleFunc(host+"/dl/mod/golang.org/toolchain/@v/", s.toolchainRedirect)
-	mux.HandleFunc(host+"/dl/mod/golang.org/toolchain/@v/list", s.toolchainList)
-	mux.HandleFunc(host+"/dl/upload", s.uploadHandler)
+	return []web.PrecacheEntry{{URL: "/dl/?mode=json", Revision: web.Revision(data)}}, nil
+}
+
+// suggestions returns the download paths of the release versions
+// and of the files in the stable and unstable releases,
+// for suggesting on 404 pages.
+func (h server) suggestions(ctx context.Context) ([]string, error) {
+	d, err := h.listData(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var list []string
+	for _, rels := range [][]Release{d.Stable, d.Unstable, d.Archive} {
+		for _, rel := range rels {
+			list = append(list, "/dl/"+rel.Version)
+		}
+	}
+	for _, rels := range [][]Release{d.Stable, d.Unstable} {
+		for _, rel := range rels {
+			for _, f := range rel.Files {
+				list = append(list, "/dl/"+f.Filename)
+			}
+		}
+	}
+	return list, nil
 }
 
 // rootKey is the ancestor of all File entities.
 var rootKey = datastore.NameKey("FileRoot", "root", nil)
 
-func (h server) listHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != "GET" && r.Method != "OPTIONS" {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
+// fileKey returns the key of the File entity for the named file.
+func fileKey(name string) *datastore.Key {
+	return datastore.NameKey("File", name, rootKey)
+}
+
+// fileQuery returns the query for all File entities.
+func fileQuery() *datastore.Query {
+	return datastore.NewQuery("File").Ancestor(rootKey)
+}
 
+func (h server) listHandler(w http.ResponseWriter, r *http.Request) {
 	d, err := h.listData(r.Context())
 	if err != nil {
-		log.Printf("ERROR listing downloads: %v", err)
-		http.Error(w, "Could not get download page. Try again in a few minutes.", 500)
+		reqlog.Logger(r.Context()).Error("listing downloads", "err", err)
+		reqlog.Error(w, r, "Could not get download page. Try again in a few minutes.", 500)
 		return
 	}
 
@@ -68,22 +206,65 @@ func (h server) listHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	h.site.ServePage(w, r, web.Page{
-		"title":  "All releases",
-		"layout": "dl",
-		"dl":     d,
-	})
+	// The page lists the files for the reader's preferred platform first.
+	w.Header().Add("Vary", "Cookie")
+	page := DownloadsPage{
+		Title:    "All releases",
+		DL:       d,
+		Releases: describeReleases(d.Stable),
+	}
+	if p := h.prefs(r); p != nil {
+		page.DL = d.personalize(p)
+		page.Prefs = p
+	}
+	h.site.ServePage(w, r, web.PageOf(page))
+}
+
+// A DownloadsPage is the data of the download page, rendered by dl.tmpl.
+type DownloadsPage struct {
+	Title    string            `page:"title"`
+	DL       *listTemplateData `page:"dl"`
+	Releases []jsonld.Release  `page:"releases"`        // for the page's structured data
+	Prefs    *Prefs            `page:"prefs,omitempty"` // the reader's preferences, if any
+}
+
+func (DownloadsPage) Layout() string { return "dl" }
+
+// describeReleases returns the descriptions of the releases,
+// for the download page's structured data.
+func describeReleases(releases []Release) []jsonld.Release {
+	list := []jsonld.Release{}
+	for _, r := range releases {
+		var oses []string
+		for _, f := range r.Files {
+			if f.OS != "" && !slices.Contains(oses, f.OS) {
+				oses = append(oses, f.OS)
+			}
+		}
+		slices.Sort(oses)
+		list = append(list, jsonld.Release{
+			Name:        "Go",
+			Version:     r.Version,
+			DownloadURL: "/dl/#" + r.Version,
+			OS:          oses,
+		})
+	}
+	return list
 }
 
 // toolchainList serves the toolchain module version list.
 func (h server) toolchainList(w http.ResponseWriter, r *http.Request) {
-	d, err := h.listData(r.Context())
+	data, err := h.cached(r.Context(), toolchainCacheKey, toolchainVersions)
 	if err != nil {
-		log.Printf("ERROR listing downloads: %v", err)
-		http.Error(w, "Could not get module list. Try again in a few minutes.", 500)
+		reqlog.Logger(r.Context()).Error("listing downloads", "err", err)
+		reqlog.Error(w, r, "Could not get module list. Try again in a few minutes.", 500)
 		return
 	}
+	w.Write(data)
+}
 
+// toolchainVersions returns the toolchain module version list of the releases in d.
+func toolchainVersions(d *listTemplateData) ([]byte, error) {
 	var buf bytes.Buffer
 	for _, l := range [][]Release{d.Stable, d.Unstable, d.Archive} {
 		for _, r := range l {
@@ -105,7 +286,7 @@ func (h server) toolchainList(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 	}
-	w.Write(buf.Bytes())
+	return buf.Bytes(), nil
 }
 
 // dl.gob was generated 2021-11-08 from the live server data, for offline testing.
@@ -114,44 +295,213 @@ func (h server) toolchainList(w http.ResponseWriter, r *http.Request) {
 var dlGob []byte
 
 func (h server) listData(ctx context.Context) (*listTemplateData, error) {
-	var d listTemplateData
 	if h.datastore == nil {
 		// Use fake embedded data.
-		err := gob.NewDecoder(bytes.NewReader(dlGob)).Decode(&d)
-		if err != nil {
-			return nil, err
-		}
-		if len(d.Stable) > 0 {
-			d.Featured = filesToFeatured(d.Stable[0].Files)
-		}
-		return &d, nil
+		return snapshot()
 	}
 
-	err := h.memcache.Get(ctx, cacheKey, &d)
+	var cached listTemplateData
+	stale, err := h.memcache.GetStale(ctx, cacheKey, &cached)
+	if err == nil && !stale {
+		accesslog.Annotate(ctx, "cache", "hit")
+		return &cached, nil
+	}
 	if err == nil {
-		return &d, nil
+		accesslog.Annotate(ctx, "cache", "stale")
+	} else {
+		accesslog.Annotate(ctx, "cache", "miss")
 	}
-	if err != memcache.ErrCacheMiss {
-		log.Printf("ERROR cache get error: %v", err)
+	if err != nil && err != memcache.ErrCacheMiss {
+		reqlog.Logger(ctx).Error("cache get", "err", err)
 		// NOTE(cbro): continue to hit datastore if the memcache is down.
 	}
 
+	d, dsErr := h.query(ctx)
+	if dsErr != nil {
+		if stale {
+			reqlog.Logger(ctx).Error("serving stale download list after datastore error", "err", dsErr)
+			return &cached, nil
+		}
+		if errors.Is(dsErr, ErrUnavailable) {
+			reqlog.Logger(ctx).Warn("serving embedded download list during maintenance")
+			return snapshot()
+		}
+		return nil, dsErr
+	}
+
+	item := &memcache.Item{Key: cacheKey, Object: d, Expiration: staleDuration}
+	if err := h.memcache.SetStale(ctx, item, cacheDuration); err != nil {
+		reqlog.Logger(ctx).Error("cache set", "err", err)
+	}
+
+	return d, nil
+}
+
+// query returns the download list built from the File entities in the datastore.
+func (h server) query(ctx context.Context) (*listTemplateData, error) {
 	var fs []File
-	q := datastore.NewQuery("File").Ancestor(rootKey)
-	if _, err := h.datastore.GetAll(ctx, q, &fs); err != nil {
+	if _, err := h.datastore.GetAll(ctx, fileQuery(), &fs); err != nil {
 		return nil, err
 	}
-
+	var d listTemplateData
 	d.Stable, d.Unstable, d.Archive = filesToReleases(fs)
 	if len(d.Stable) > 0 {
 		d.Featured = filesToFeatured(d.Stable[0].Files)
 	}
+	return &d, nil
+}
 
-	item := &memcache.Item{Key: cacheKey, Object: &d, Expiration: cacheDuration}
-	if err := h.memcache.Set(ctx, item); err != nil {
-		log.Printf("ERROR cache set error: %v", err)
+// derived lists the documents built from the download list
+// and cached along with it, by cache key.
+var derived = []struct {
+	key   string
+	build func(*listTemplateData) ([]byte, error)
+}{
+	{toolchainCacheKey, toolchainVersions},
+	{feedCacheKey, feed},
+}
+
+// cached returns the document cached under key, building it from
+// the download list with build, and caching it, if it is not cached.
+func (h server) cached(ctx context.Context, key string, build func(*listTemplateData) ([]byte, error)) ([]byte, error) {
+	if h.datastore == nil {
+		// The embedded data never changes; there is nothing to cache.
+		d, err := snapshot()
+		if err != nil {
+			return nil, err
+		}
+		return build(d)
+	}
+	var data []byte
+	err := h.memcache.Get(ctx, key, &data)
+	if err == nil {
+		return data, nil
+	}
+	if err != memcache.ErrCacheMiss {
+		reqlog.Logger(ctx).Error("cache get", "err", err)
+	}
+	d, err := h.listData(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if data, err = build(d); err != nil {
+		return nil, err
 	}
+	if err := h.memcache.Set(ctx, &memcache.Item{Key: key, Object: data, Expiration: cacheDuration}); err != nil {
+		reqlog.Logger(ctx).Error("cache set", "err", err)
+	}
+	return data, nil
+}
 
+// invalidate deletes the download list and the documents derived from it
+// from the cache, to be rebuilt by the next request or by warm.
+func (h server) invalidate(ctx context.Context) {
+	keys := []string{cacheKey}
+	for _, x := range derived {
+		keys = append(keys, x.key)
+	}
+	if err := h.memcache.DeleteMulti(ctx, keys); err != nil {
+		reqlog.Logger(ctx).Error("cache delete", "err", err)
+	}
+}
+
+// warm rebuilds the download list and the documents derived from it
+// after uploads, and caches them, so that the first requests after
+// a release do not wait for them to be rebuilt. If stale reports that
+// a later upload has scheduled another rebuild, warm leaves the caching
+// to that one. If it cannot rebuild them, it deletes them from the cache,
+// to be rebuilt by the next request.
+func (h server) warm(ctx context.Context, stale func() bool) {
+	err := func() error {
+		if stale() {
+			return nil
+		}
+		d, err := h.query(ctx)
+		if err != nil || stale() {
+			return err
+		}
+		item := &memcache.Item{Key: cacheKey, Object: d, Expiration: staleDuration}
+		if err := h.memcache.SetStale(ctx, item, cacheDuration); err != nil {
+			return err
+		}
+		var items []*memcache.Item
+		for _, x := range derived {
+			data, err := x.build(d)
+			if err != nil {
+				return err
+			}
+			items = append(items, &memcache.Item{Key: x.key, Object: data, Expiration: cacheDuration})
+		}
+		return h.memcache.SetMulti(ctx, items)
+	}()
+	if err != nil {
+		reqlog.Logger(ctx).Error("warming download cache", "err", err)
+		h.invalidate(ctx)
+	}
+}
+
+// warmDelay is how long after the last of a burst of uploads,
+// as during a release, the download list is rebuilt.
+// It is a variable for testing.
+var warmDelay = 2 * time.Second
+
+// warmTimeout bounds the time a rebuild of the download list may take.
+const warmTimeout = time.Minute
+
+// A warmer runs the rebuilds of the download list in the background,
+// warmDelay after the latest upload, so that a burst of uploads
+// is followed by one rebuild. Rebuilds run one at a time,
+// and a rebuild learns when a later upload has scheduled another,
+// which must cache its newer list instead.
+// The zero warmer is ready to use.
+type warmer struct {
+	mu      sync.Mutex
+	gen     int         // uploads scheduling rebuilds so far
+	timer   *time.Timer // starts the scheduled rebuild
+	running sync.Mutex  // held by the rebuild in progress
+	pending sync.WaitGroup
+}
+
+// schedule schedules rebuild to run warmDelay from now,
+// replacing the rebuild scheduled before, if it has not started.
+// The rebuild's stale function reports whether another has been scheduled since.
+func (w *warmer) schedule(rebuild func(ctx context.Context, stale func() bool)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.gen++
+	gen := w.gen
+	if w.timer != nil && w.timer.Stop() {
+		w.pending.Done()
+	}
+	w.pending.Add(1)
+	w.timer = time.AfterFunc(warmDelay, func() {
+		defer w.pending.Done()
+		w.running.Lock()
+		defer w.running.Unlock()
+		ctx, cancel := context.WithTimeout(context.Background(), warmTimeout)
+		defer cancel()
+		rebuild(ctx, func() bool {
+			w.mu.Lock()
+			defer w.mu.Unlock()
+			return w.gen != gen
+		})
+	})
+}
+
+// wait waits for the scheduled rebuilds to finish.
+func (w *warmer) wait() {
+	w.pending.Wait()
+}
+
+// snapshot returns the download list in the embedded snapshot.
+func snapshot() (*listTemplateData, error) {
+	var d listTemplateData
+	if err := gob.NewDecoder(bytes.NewReader(dlGob)).Decode(&d); err != nil {
+		return nil, err
+	}
+	if len(d.Stable) > 0 {
+		d.Featured = filesToFeatured(d.Stable[0].Files)
+	}
 	return &d, nil
 }
 
@@ -180,15 +530,11 @@ func serveJSON(w http.ResponseWriter, r *http.Request, d *listTemplateData) {
 	enc := json.NewEncoder(w)
 	enc.SetIndent("", " ")
 	if err := enc.Encode(releases); err != nil {
-		log.Printf("ERROR rendering JSON for releases: %v", err)
+		reqlog.Logger(r.Context()).Error("rendering JSON for releases", "err", err)
 	}
 }
 
 func (h server) uploadHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != "POST" {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
 	ctx := r.Context()
 
 	// Authenticate using a user token (same as gomote).
@@ -197,7 +543,13 @@ func (h server) uploadHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "bad user", http.StatusForbidden)
 		return
 	}
-	if r.FormValue("key") != h.userKey(ctx, user) {
+	key, err := h.userKey(ctx, user)
+	if err != nil {
+		reqlog.Logger(ctx).Error("deriving upload key", "err", err)
+		reqlog.Error(w, r, "Something broke", http.StatusInternalServerError)
+		return
+	}
+	if !hmac.Equal([]byte(r.FormValue("key")), []byte(key)) {
 		http.Error(w, "bad key", http.StatusForbidden)
 		return
 	}
@@ -205,8 +557,8 @@ func (h server) uploadHandler(w http.ResponseWriter, r *http.Request) {
 	var f File
 	defer r.Body.Close()
 	if err := json.NewDecoder(r.Body).Decode(&f); err != nil {
-		log.Printf("ERROR decoding upload JSON: %v", err)
-		http.Error(w, "Something broke", http.StatusInternalServerError)
+		reqlog.Logger(ctx).Error("decoding upload JSON", "err", err)
+		reqlog.Error(w, r, "Something broke", http.StatusInternalServerError)
 		return
 	}
 	if f.Filename == "" {
@@ -216,29 +568,26 @@ func (h server) uploadHandler(w http.ResponseWriter, r *http.Request) {
 	if f.Uploaded.IsZero() {
 		f.Uploaded = time.Now()
 	}
-	k := datastore.NameKey("File", f.Filename, rootKey)
-	if _, err := h.datastore.Put(ctx, k, &f); err != nil {
-		log.Printf("ERROR File entity: %v", err)
-		http.Error(w, "could not put File entity", http.StatusInternalServerError)
+	if _, err := h.datastore.Put(ctx, fileKey(f.Filename), &f); err != nil {
+		if errors.Is(err, ErrUnavailable) {
+			reqlog.Error(w, r, "Uploads are paused for maintenance.", http.StatusServiceUnavailable)
+			return
+		}
+		reqlog.Logger(ctx).Error("putting File entity", "file", f.Filename, "err", err)
+		reqlog.Error(w, r, "could not put File entity", http.StatusInternalServerError)
 		return
 	}
-	if err := h.memcache.Delete(ctx, cacheKey); err != nil {
-		log.Printf("ERROR delete error: %v", err)
-	}
+	// Drop the cached list without the upload now, and rebuild it
+	// once the uploads of the release under way are in.
+	h.invalidate(ctx)
+	h.warmer.schedule(h.warm)
 	io.WriteString(w, "OK")
 }
 
+// getHandler serves /dl/ and the pages below it.
+// The go-import meta tags that the go command reads for golang.org/dl,
+// which redirects here, are served by the vanity import path server.
 func (h server) getHandler(w http.ResponseWriter, r *http.Request) {
-	isGoGet := (r.Method == "GET" || r.Method == "HEAD") && r.FormValue("go-get") == "1"
-	// For go get, we need to serve the same meta tags at /dl for cmd/go to
-	// validate against the import path.
-	if r.URL.Path == "/dl" && isGoGet {
-		w.Header().Set("Content-Type", "text/html; charset=utf-8")
-		fmt.Fprintf(w, `<!DOCTYPE html><html><head>
-<meta name="go-import" content="golang.org/dl git https://go.googlesource.com/dl">
-</head></html>`)
-		return
-	}
 	if r.URL.Path == "/dl" {
 		http.Redirect(w, r, "/dl/", http.StatusFound)
 		return
@@ -250,13 +599,19 @@ func (h server) getHandler(w http.ResponseWriter, r *http.Request) {
 	case name == "":
 		h.listHandler(w, r)
 		return
+	case name == "feed.atom":
+		h.feedHandler(w, r)
+		return
+	case strings.HasPrefix(name, "badge") && strings.HasSuffix(name, ".svg"):
+		h.badgeHandler(w, r, name)
+		return
+	case strings.HasSuffix(name, ".meta4") && fileRe.MatchString(strings.TrimSuffix(name, ".meta4")):
+		h.metalinkHandler(w, r, strings.TrimSuffix(name, ".meta4"))
+		return
 	case fileRe.MatchString(name):
 		// This is a /dl/{file} request to download a file. It's implemented by
 		// redirecting to another host, which serves the bytes more efficiently.
-		//
-		// The redirect target is an internal implementation detail and may change
-		// if there is a good reason to do so. Last time was in CL 76971 (in 2017).
-		const downloadBaseURL = "https://dl.google.com/go/"
+		analytics.CountDownload(r, fileVersion(name))
 		http.Redirect(w, r, downloadBaseURL+name, http.StatusFound)
 		return
 	case name == "gotip":
@@ -264,17 +619,14 @@ func (h server) getHandler(w http.ResponseWriter, r *http.Request) {
 	case goGetRe.MatchString(name):
 		redirectURL = "/dl/#" + name
 	default:
-		http.NotFound(w, r)
+		h.site.ServeErrorStatus(w, r, fmt.Errorf("download %s not found", name), http.StatusNotFound)
 		return
 	}
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	if !isGoGet {
-		w.Header().Set("Location", redirectURL)
-	}
+	w.Header().Set("Location", redirectURL)
 	fmt.Fprintf(w, `<!DOCTYPE html>
 <html>
 <head>
-<meta name="go-import" content="golang.org/dl git https://go.googlesource.com/dl">
 <meta http-equiv="refresh" content="0; url=%s">
 </head>
 <body>
@@ -286,11 +638,6 @@ func (h server) getHandler(w http.ResponseWriter, r *http.Request) {
 
 // toolchainRedirect redirects /dl/mod/golang.org/toolchain/@v/v___ to https://dl.google.com/go/v___.
 func (server) toolchainRedirect(w http.ResponseWriter, r *http.Request) {
-	if r.Method != "GET" && r.Method != "HEAD" && r.Method != "OPTIONS" {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
 	_, file, _ := strings.Cut(r.URL.Path, "/@v/")
 	if (!strings.HasPrefix(file, "v0.") && !strings.HasPrefix(file, "v1.")) || strings.Contains(file, "/") {
 		http.NotFound(w, r)
@@ -300,60 +647,66 @@ func (server) toolchainRedirect(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, "https://dl.google.com/go/"+file, http.StatusFound)
 }
 
-func (h server) userKey(c context.Context, user string) string {
-	hash := hmac.New(md5.New, []byte(h.secret(c)))
+func (h server) userKey(ctx context.Context, user string) (string, error) {
+	secret, err := builderSecret(ctx)
+	if err != nil {
+		return "", err
+	}
+	hash := hmac.New(md5.New, []byte(secret))
 	hash.Write([]byte("user-" + user))
-	return fmt.Sprintf("%x", hash.Sum(nil))
+	return fmt.Sprintf("%x", hash.Sum(nil)), nil
 }
 
-// Code below copied from x/build/app/key
+// BuilderSecretName is the name of the secret from which
+// the upload keys of gomote users are derived.
+const BuilderSecretName = "dl-builder-key"
 
-var theKey struct {
-	sync.RWMutex
-	builderKey
+// builderSecret returns the secret from which upload keys are derived.
+// Outside production, where the secret is not required,
+// a missing secret is replaced by a well-known development key,
+// which is not the real key.
+func builderSecret(ctx context.Context) (string, error) {
+	secret, err := env.GetSecrets().Secret(ctx, BuilderSecretName)
+	if err == env.ErrSecretNotFound && !env.RequireDLSecretKey() {
+		return "gophers rule", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("loading %s: %w", BuilderSecretName, err)
+	}
+	return secret, nil
 }
 
-type builderKey struct {
-	Secret string
+// BuilderKeySecrets returns Secrets that provide BuilderSecretName
+// from the BuilderKey entity in client, where the builder key
+// was kept before it moved to the secret providers.
+// It is the last provider to consult until the key
+// is in one of the others and the entity is deleted.
+func BuilderKeySecrets(client *datastore.Client) env.Secrets {
+	return builderKeySecrets{client}
 }
 
-func (k *builderKey) Key() *datastore.Key {
-	return datastore.NameKey("BuilderKey", "root", nil)
+type builderKeySecrets struct {
+	client interface {
+		Get(ctx context.Context, key *datastore.Key, dst any) error
+	}
 }
 
-func (h server) secret(ctx context.Context) string {
-	// check with rlock
-	theKey.RLock()
-	k := theKey.Secret
-	theKey.RUnlock()
-	if k != "" {
-		return k
-	}
+// builderKey is the datastore entity holding the builder key.
+type builderKey struct {
+	Secret string
+}
 
-	// prepare to fill; check with lock and keep lock
-	theKey.Lock()
-	defer theKey.Unlock()
-	if theKey.Secret != "" {
-		return theKey.Secret
+func (b builderKeySecrets) Secret(ctx context.Context, name string) (string, error) {
+	if name != BuilderSecretName {
+		return "", env.ErrSecretNotFound
 	}
-
-	// fill
-	if err := h.datastore.Get(ctx, theKey.Key(), &theKey.builderKey); err != nil {
-		if err == datastore.ErrNoSuchEntity {
-			// If the key is not stored in datastore, write it.
-			// This only happens at the beginning of a new deployment.
-			// The code is left here for SDK use and in case a fresh
-			// deployment is ever needed.  "gophers rule" is not the
-			// real key.
-			if env.RequireDLSecretKey() {
-				panic("lost key from datastore")
-			}
-			theKey.Secret = "gophers rule"
-			h.datastore.Put(ctx, theKey.Key(), &theKey.builderKey)
-			return theKey.Secret
-		}
-		panic("cannot load builder key: " + err.Error())
+	var k builderKey
+	err := b.client.Get(ctx, datastore.NameKey("BuilderKey", "root", nil), &k)
+	if err == datastore.ErrNoSuchEntity || err == nil && k.Secret == "" {
+		return "", env.ErrSecretNotFound
 	}
-
-	return theKey.Secret
+	if err != nil {
+		return "", err
+	}
+	return k.Secret, nil
 }
@@ -5,12 +5,23 @@
 package dl
 
 import (
+	"context"
 	"encoding/json"
+	"encoding/xml"
 	"fmt"
 	"math/rand"
 	"net/http/httptest"
+	"reflect"
 	"sort"
+	"strings"
 	"testing"
+	"testing/fstest"
+
+	"cloud.google.com/go/datastore"
+	"github.com/matttproud/yourtour/internal/blog/atom"
+	"github.com/matttproud/yourtour/internal/env"
+	"github.com/matttproud/yourtour/internal/memcache"
+	"github.com/matttproud/yourtour/internal/web"
 )
 
 func TestServeJSON(t *testing.T) {
@@ -136,3 +147,218 @@ func TestSortedJSON(t *testing.T) {
 		}
 	}
 }
+
+type secretMap map[string]string
+
+func (m secretMap) Secret(ctx context.Context, name string) (string, error) {
+	v, ok := m[name]
+	if !ok {
+		return "", env.ErrSecretNotFound
+	}
+	return v, nil
+}
+
+func TestUserKey(t *testing.T) {
+	defer env.Set(nil)
+	defer env.SetSecrets(nil)
+	ctx := context.Background()
+	var h server
+
+	env.Set(&env.Config{RequireDLSecretKey: true})
+	env.SetSecrets(secretMap{})
+	if key, err := h.userKey(ctx, "gopher"); err == nil {
+		t.Errorf("userKey with missing required secret = %q, want error", key)
+	}
+
+	env.Set(&env.Config{})
+	dev, err := h.userKey(ctx, "gopher")
+	if err != nil {
+		t.Fatalf("userKey with development key: %v", err)
+	}
+
+	env.SetSecrets(secretMap{BuilderSecretName: "real key"})
+	real, err := h.userKey(ctx, "gopher")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if real == dev {
+		t.Errorf("userKey with real secret = development key %q", dev)
+	}
+	if other, _ := h.userKey(ctx, "other"); other == real {
+		t.Errorf("userKey is the same for different users")
+	}
+}
+
+// entityGetter is a datastore holding the entities in a map by key name.
+type entityGetter map[string]any
+
+func (g entityGetter) Get(ctx context.Context, key *datastore.Key, dst any) error {
+	v, ok := g[key.Kind+"/"+key.Name]
+	if !ok {
+		return datastore.ErrNoSuchEntity
+	}
+	reflect.ValueOf(dst).Elem().Set(reflect.ValueOf(v))
+	return nil
+}
+
+func TestBuilderKeySecrets(t *testing.T) {
+	ctx := context.Background()
+	s := builderKeySecrets{entityGetter{}}
+	if v, err := s.Secret(ctx, BuilderSecretName); err != env.ErrSecretNotFound {
+		t.Errorf("Secret without the entity = %q, %v, want ErrSecretNotFound", v, err)
+	}
+	s = builderKeySecrets{entityGetter{"BuilderKey/root": builderKey{Secret: "legacy key"}}}
+	if v, err := s.Secret(ctx, BuilderSecretName); v != "legacy key" || err != nil {
+		t.Errorf("Secret = %q, %v, want %q", v, err, "legacy key")
+	}
+	if v, err := s.Secret(ctx, "dl-prefs-key"); err != env.ErrSecretNotFound {
+		t.Errorf("Secret(dl-prefs-key) = %q, %v, want ErrSecretNotFound", v, err)
+	}
+	v, err := env.FirstSecrets(secretMap{}, s).Secret(ctx, BuilderSecretName)
+	if v != "legacy key" || err != nil {
+		t.Errorf("FirstSecrets falling back to datastore = %q, %v", v, err)
+	}
+}
+
+// downDatastore is a Datastore down for maintenance.
+type downDatastore struct{}
+
+func (downDatastore) GetAll(ctx context.Context, q *datastore.Query, dst any) ([]*datastore.Key, error) {
+	return nil, fmt.Errorf("GetAll: %w", ErrUnavailable)
+}
+
+func (downDatastore) Put(ctx context.Context, key *datastore.Key, src any) (*datastore.Key, error) {
+	return nil, fmt.Errorf("Put: %w", ErrUnavailable)
+}
+
+func TestListDataMaintenance(t *testing.T) {
+	ctx := context.Background()
+	h := server{datastore: downDatastore{}, memcache: memcache.NewCodecClient(memcache.NewMemory(0), memcache.Gob)}
+	d, err := h.listData(ctx)
+	if err != nil {
+		t.Fatalf("listData during maintenance: %v", err)
+	}
+	want, err := snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Stable) == 0 || d.Stable[0].Version != want.Stable[0].Version {
+		t.Errorf("listData during maintenance did not list the embedded snapshot")
+	}
+}
+
+func TestBadge(t *testing.T) {
+	d, err := snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := server{site: web.NewSite(fstest.MapFS{
+		"site.tmpl":  {Data: []byte(`{{block "layout" .}}{{.Content}}{{end}}`)},
+		"error.tmpl": {Data: []byte(`{{define "layout"}}{{.error}}{{end}}`)},
+	})}
+	stable := strings.TrimPrefix(d.Stable[0].Version, "go")
+	unstable := ">none</text>"
+	if len(d.Unstable) > 0 {
+		unstable = ">" + strings.TrimPrefix(d.Unstable[0].Version, "go") + "</text>"
+	}
+	line := d.Stable[len(d.Stable)-1].Version
+	if i := strings.LastIndex(line, "."); strings.Count(line, ".") == 2 {
+		line = line[:i]
+	}
+	for _, tt := range []struct {
+		name string
+		code int
+		want string
+	}{
+		{"badge.svg", 200, ">" + stable + "</text>"},
+		{"badge-" + line + ".svg", 200, ">" + strings.TrimPrefix(latestOf(line, d.Stable).Version, "go") + "</text>"},
+		{"badge-unstable.svg", 200, unstable},
+		{"badge-go1.2.3.svg", 404, ""},
+		{"badge-go0.1.svg", 404, ""},
+		{"badge-nightly.svg", 404, ""},
+	} {
+		w := httptest.NewRecorder()
+		h.badgeHandler(w, httptest.NewRequest("GET", "/dl/"+tt.name, nil), tt.name)
+		if w.Code != tt.code || tt.code == 200 && (w.Header().Get("Content-Type") != "image/svg+xml" || !strings.Contains(w.Body.String(), tt.want)) {
+			t.Errorf("GET /dl/%s: %d %q\n%s\nwant %d with %q", tt.name, w.Code, w.Header().Get("Content-Type"), w.Body, tt.code, tt.want)
+		}
+	}
+
+	r := httptest.NewRequest("GET", "/dl/badge.svg", nil)
+	r.Header.Set("If-None-Match", `"`+stable+`"`)
+	w := httptest.NewRecorder()
+	h.badgeHandler(w, r, "badge.svg")
+	if w.Code != 304 {
+		t.Errorf("GET /dl/badge.svg with If-None-Match: %d, want 304", w.Code)
+	}
+}
+
+func TestMetalink(t *testing.T) {
+	d, err := snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := d.Stable[0].Files[0]
+	env.Set(&env.Config{DLMirrors: "https://mirror.example/golang/"})
+	defer env.Set(nil)
+	h := server{site: web.NewSite(fstest.MapFS{
+		"site.tmpl":  {Data: []byte(`{{block "layout" .}}{{.Content}}{{end}}`)},
+		"error.tmpl": {Data: []byte(`{{define "layout"}}{{.error}}{{end}}`)},
+	})}
+	w := httptest.NewRecorder()
+	h.metalinkHandler(w, httptest.NewRequest("GET", "/dl/"+f.Filename+".meta4", nil), f.Filename)
+	if w.Code != 200 || w.Header().Get("Content-Type") != "application/metalink4+xml" {
+		t.Fatalf("GET /dl/%s.meta4: %d %q\n%s", f.Filename, w.Code, w.Header().Get("Content-Type"), w.Body)
+	}
+	if disp, want := w.Header().Get("Content-Disposition"), "attachment; filename="+f.Filename+".meta4"; disp != want {
+		t.Errorf("GET /dl/%s.meta4: Content-Disposition %q, want %q", f.Filename, disp, want)
+	}
+	var ml metalink
+	if err := xml.Unmarshal(w.Body.Bytes(), &ml); err != nil {
+		t.Fatal(err)
+	}
+	want := []metalinkFile{{
+		Name: f.Filename,
+		Size: f.Size,
+		Hash: []metalinkHash{{Type: "sha-256", Sum: f.ChecksumSHA256}},
+		URLs: []metalinkURL{{1, "https://dl.google.com/go/" + f.Filename}, {2, "https://mirror.example/golang/" + f.Filename}},
+	}}
+	if !reflect.DeepEqual(ml.Files, want) {
+		t.Errorf("GET /dl/%s.meta4: files\n%+v\nwant\n%+v", f.Filename, ml.Files, want)
+	}
+
+	w = httptest.NewRecorder()
+	h.metalinkHandler(w, httptest.NewRequest("GET", "/dl/go0.1.linux-amd64.tar.gz.meta4", nil), "go0.1.linux-amd64.tar.gz")
+	if w.Code != 404 {
+		t.Errorf("GET /dl/go0.1.linux-amd64.tar.gz.meta4: %d, want 404", w.Code)
+	}
+}
+
+func TestFeed(t *testing.T) {
+	d, err := snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var h server
+	w := httptest.NewRecorder()
+	h.feedHandler(w, httptest.NewRequest("GET", "/dl/feed.atom", nil))
+	if w.Code != 200 || w.Header().Get("Content-Type") != "application/atom+xml; charset=utf-8" {
+		t.Fatalf("GET /dl/feed.atom: %d %q\n%s", w.Code, w.Header().Get("Content-Type"), w.Body)
+	}
+	var feed atom.Feed
+	if err := xml.Unmarshal(w.Body.Bytes(), &feed); err != nil {
+		t.Fatal(err)
+	}
+	if len(feed.Entry) != feedEntries {
+		t.Fatalf("feed has %d entries, want %d", len(feed.Entry), feedEntries)
+	}
+	latest := d.Stable[0]
+	if e := feed.Entry[0]; e.Title != latest.Version || feed.Updated != e.Updated || !strings.Contains(e.Content.Body, latest.Files[0].ChecksumSHA256) {
+		t.Errorf("first entry %q updated %s, feed updated %s; want %s with its files", e.Title, e.Updated, feed.Updated, latest.Version)
+	}
+	for i := 1; i < len(feed.Entry); i++ {
+		if feed.Entry[i-1].Updated < feed.Entry[i].Updated {
+			t.Errorf("entry %s before newer %s", feed.Entry[i-1].Title, feed.Entry[i].Title)
+		}
+	}
+}
@@ -0,0 +1,245 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package boot runs preflight checks of the golangorg server's
+// dependencies at startup and reports whether the server is ready.
+//
+// A server keeps its checks in a Checker, with which its subsystems
+// Register checks as they are set up; the server then calls Run once
+// and serves the Checker's Report from its readiness endpoint,
+// calling Recheck from time to time until the failed checks pass,
+// so that a dependency that was down at startup does not keep
+// the server out of rotation for good.
+package boot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/matttproud/yourtour/internal/codewalk"
+	"github.com/matttproud/yourtour/internal/env"
+	"github.com/matttproud/yourtour/internal/memcache"
+)
+
+// Timeout is the time allowed for each check by Run.
+const Timeout = 10 * time.Second
+
+// A Check is a single preflight check.
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// A Result is the outcome of one Check.
+type Result struct {
+	Name     string        `json:"name"`
+	OK       bool          `json:"ok"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration_ns"`
+}
+
+// A Report is the outcome of a set of checks.
+type Report struct {
+	Ready   bool      `json:"ready"` // all checks passed
+	Time    time.Time `json:"time"`
+	Results []Result  `json:"results"`
+}
+
+// A Checker holds a server's preflight checks and their latest report.
+// The zero value is a Checker with no checks.
+type Checker struct {
+	mu     sync.Mutex
+	checks []Check
+	ran    []Check // checks of report, in order
+	report *Report // nil until Run
+}
+
+// Register adds c to the checks run by Run.
+func (k *Checker) Register(c Check) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.checks = append(k.checks, c)
+}
+
+// Run runs the registered checks concurrently,
+// allowing each at most timeout, and logs any failures.
+// Its report becomes the Checker's Report.
+func (k *Checker) Run(ctx context.Context, timeout time.Duration) *Report {
+	k.mu.Lock()
+	list := append([]Check(nil), k.checks...)
+	k.mu.Unlock()
+	rep := RunChecks(ctx, list, timeout)
+	k.mu.Lock()
+	k.ran, k.report = list, rep
+	k.mu.Unlock()
+	return rep
+}
+
+// Recheck runs again the checks that failed in the Checker's Report,
+// as Run does, and returns the report with their new results,
+// which becomes the Checker's Report. If the report is ready,
+// Recheck returns it as it is; if there is none, Recheck calls Run.
+func (k *Checker) Recheck(ctx context.Context, timeout time.Duration) *Report {
+	k.mu.Lock()
+	old, ran := k.report, k.ran
+	k.mu.Unlock()
+	if old == nil {
+		return k.Run(ctx, timeout)
+	}
+	if old.Ready {
+		return old
+	}
+	var failed []int
+	var list []Check
+	for i, r := range old.Results {
+		if !r.OK {
+			failed = append(failed, i)
+			list = append(list, ran[i])
+		}
+	}
+	again := RunChecks(ctx, list, timeout)
+	rep := &Report{Ready: true, Time: again.Time, Results: slices.Clone(old.Results)}
+	for j, i := range failed {
+		rep.Results[i] = again.Results[j]
+	}
+	for _, r := range rep.Results {
+		if !r.OK {
+			rep.Ready = false
+		}
+	}
+	k.mu.Lock()
+	if k.report == old {
+		k.report = rep
+	}
+	k.mu.Unlock()
+	return rep
+}
+
+// Report returns the report of the last Run or Recheck,
+// or nil if Run has not been called.
+func (k *Checker) Report() *Report {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.report
+}
+
+// RunChecks runs the given checks concurrently,
+// allowing each at most timeout, and logs any failures.
+// The results are in the order of the checks.
+func RunChecks(ctx context.Context, list []Check, timeout time.Duration) *Report {
+	rep := &Report{Ready: true, Time: time.Now(), Results: make([]Result, len(list))}
+	var wg sync.WaitGroup
+	for i, c := range list {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			start := time.Now()
+			err := c.Run(ctx)
+			r := Result{Name: c.Name, OK: err == nil, Duration: time.Since(start)}
+			if err != nil {
+				r.Error = err.Error()
+				log.Printf("ERROR preflight check %s: %v", c.Name, err)
+			}
+			rep.Results[i] = r
+		}()
+	}
+	wg.Wait()
+	for _, r := range rep.Results {
+		if !r.OK {
+			rep.Ready = false
+		}
+	}
+	return rep
+}
+
+// Handler returns a handler that, for requests to path,
+// responds with 503 and rep as JSON if rep is not ready,
+// and otherwise passes requests to h.
+// Putting Handler in front of a readiness check
+// keeps a server with failed preflight checks out of rotation.
+func Handler(rep *Report, path string, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != path || rep.Ready {
+			h.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", " ")
+		if err := enc.Encode(rep); err != nil {
+			log.Printf("ERROR rendering JSON for preflight report: %v", err)
+		}
+	})
+}
+
+// Datastore returns a check that client can reach datastore.
+func Datastore(client *datastore.Client) Check {
+	return Check{"datastore", func(ctx context.Context) error {
+		err := client.Get(ctx, datastore.NameKey("Preflight", "ping", nil), &struct{}{})
+		if err == datastore.ErrNoSuchEntity {
+			err = nil
+		}
+		return err
+	}}
+}
+
+// Memcache returns a check that c can reach its cache.
+func Memcache(c memcache.Cache) Check {
+	return Check{"memcache", func(ctx context.Context) error {
+		_, err := c.Get(ctx, "preflight:ping")
+		if err == memcache.ErrCacheMiss {
+			err = nil
+		}
+		return err
+	}}
+}
+
+// Content returns a check that the codewalks in fsys are well formed.
+func Content(fsys fs.FS) Check {
+	return Check{"content", func(ctx context.Context) error {
+		return codewalk.Validate(fsys)
+	}}
+}
+
+// Secret returns a check that the named secret is available from s.
+func Secret(s env.Secrets, name string) Check {
+	return Check{"secret " + name, func(ctx context.Context) error {
+		v, err := s.Secret(ctx, name)
+		if err != nil {
+			return err
+		}
+		if v == "" {
+			return errors.New("empty secret")
+		}
+		return nil
+	}}
+}
+
+// String returns a one-line summary of the report, such as
+// "ready (4 checks)" or "not ready: datastore, memcache failed".
+func (rep *Report) String() string {
+	if rep.Ready {
+		return fmt.Sprintf("ready (%d checks)", len(rep.Results))
+	}
+	var failed []string
+	for _, r := range rep.Results {
+		if !r.OK {
+			failed = append(failed, r.Name)
+		}
+	}
+	return "not ready: " + strings.Join(failed, ", ") + " failed"
+}
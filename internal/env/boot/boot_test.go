@@ -0,0 +1,141 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package boot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/matttproud/yourtour/internal/memcache"
+)
+
+func TestRunChecks(t *testing.T) {
+	list := []Check{
+		{"ok", func(ctx context.Context) error { return nil }},
+		{"broken", func(ctx context.Context) error { return errors.New("no route to host") }},
+		{"slow", func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}},
+	}
+	rep := RunChecks(context.Background(), list, 50*time.Millisecond)
+	if rep.Ready {
+		t.Errorf("Ready = true with failing checks")
+	}
+	want := []Result{
+		{Name: "ok", OK: true},
+		{Name: "broken", Error: "no route to host"},
+		{Name: "slow", Error: context.DeadlineExceeded.Error()},
+	}
+	for i, r := range rep.Results {
+		r.Duration = 0
+		if r != want[i] {
+			t.Errorf("Results[%d] = %+v, want %+v", i, r, want[i])
+		}
+	}
+	if s, want := rep.String(), "not ready: broken, slow failed"; s != want {
+		t.Errorf("String() = %q, want %q", s, want)
+	}
+
+	rep = RunChecks(context.Background(), list[:1], time.Second)
+	if !rep.Ready {
+		t.Errorf("Ready = false with passing checks")
+	}
+}
+
+func TestChecker(t *testing.T) {
+	var a, b Checker
+	a.Register(Check{"broken", func(ctx context.Context) error { return errors.New("broken") }})
+	b.Register(Check{"ok", func(ctx context.Context) error { return nil }})
+	if rep := a.Run(context.Background(), time.Second); rep.Ready || len(rep.Results) != 1 {
+		t.Errorf("a.Run() = %v, want only the broken check", rep)
+	}
+	if rep := b.Run(context.Background(), time.Second); !rep.Ready || len(rep.Results) != 1 {
+		t.Errorf("b.Run() = %v, want only the ok check", rep)
+	}
+}
+
+func TestRecheck(t *testing.T) {
+	var k Checker
+	if rep := k.Report(); rep != nil {
+		t.Errorf("Report() before Run = %v, want nil", rep)
+	}
+	runs := make(map[string]int)
+	var mu sync.Mutex
+	down := true
+	check := func(name string, fail func() bool) Check {
+		return Check{name, func(ctx context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+			runs[name]++
+			if fail() {
+				return errors.New("down")
+			}
+			return nil
+		}}
+	}
+	k.Register(check("ok", func() bool { return false }))
+	k.Register(check("flaky", func() bool { return down }))
+
+	if rep := k.Run(context.Background(), time.Second); rep.Ready || k.Report() != rep {
+		t.Fatalf("Run() = %v, want not ready and the Report", rep)
+	}
+	if rep := k.Recheck(context.Background(), time.Second); rep.Ready {
+		t.Errorf("Recheck() with the check still failing = %v, want not ready", rep)
+	}
+	down = false
+	rep := k.Recheck(context.Background(), time.Second)
+	if !rep.Ready || k.Report() != rep || len(rep.Results) != 2 || rep.Results[0].Name != "ok" || !rep.Results[1].OK {
+		t.Errorf("Recheck() with the check passing = %+v, want ready", rep)
+	}
+	if again := k.Recheck(context.Background(), time.Second); again != rep {
+		t.Errorf("Recheck() when ready = %v, want the same report", again)
+	}
+	if runs["ok"] != 1 || runs["flaky"] != 3 {
+		t.Errorf("runs = %v, want ok run once and flaky three times", runs)
+	}
+}
+
+func TestMemcache(t *testing.T) {
+	ctx := context.Background()
+	if err := Memcache(memcache.NewMemory(0)).Run(ctx); err != nil {
+		t.Errorf("Memcache check on empty cache: %v", err)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok\n"))
+	})
+	var tests = []struct {
+		ready bool
+		path  string
+		code  int
+	}{
+		{true, "/_readycheck", http.StatusOK},
+		{false, "/_readycheck", http.StatusServiceUnavailable},
+		{false, "/doc/", http.StatusOK},
+	}
+	for _, tt := range tests {
+		rep := &Report{Ready: tt.ready, Results: []Result{{Name: "datastore", OK: tt.ready}}}
+		w := httptest.NewRecorder()
+		Handler(rep, "/_readycheck", next).ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+		if w.Code != tt.code {
+			t.Errorf("ready=%v GET %s: %d, want %d", tt.ready, tt.path, w.Code, tt.code)
+		}
+		if w.Code == http.StatusServiceUnavailable {
+			var got Report
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil || got.Ready || len(got.Results) != 1 {
+				t.Errorf("report = %s (%v)", w.Body, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package env
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSettings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	file := "analytics: UA-1\nhot_reload: false\nadmin_token: hunter2\n"
+	if err := os.WriteFile(path, []byte(file), 0666); err != nil {
+		t.Fatal(err)
+	}
+	environ := map[string]string{
+		"GOLANGORG_REDIS_ADDR": "redis://cache:pa55@a:6379,b:6379",
+		"GOLANGORG_NOINDEX":    "false",
+	}
+	c, err := load(path, func(name string) (string, bool) {
+		v, ok := environ[name]
+		return v, ok
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(map[string]Setting)
+	for _, s := range c.Settings() {
+		got[s.Env] = s
+	}
+	var tests = []struct {
+		env    string
+		value  string
+		source Source
+	}{
+		{"GOLANGORG_PROFILE", "dev", SourceDefault},
+		{"GOLANGORG_ANALYTICS", "UA-1", SourceFile},
+		{"GOLANGORG_HOT_RELOAD", "false", SourceFile},
+		{"GOLANGORG_DEV_MODE", "true", SourceProfile},
+		{"GOLANGORG_NOINDEX", "false", SourceEnv},
+		{"GOLANGORG_REDIS_ADDR", "redis://cache:xxxxx@a:6379,b:6379", SourceEnv},
+		{"GOLANGORG_ADMIN_TOKEN", "********", SourceFile},
+		{"GOLANGORG_CACHE_BACKEND", "", SourceDefault},
+	}
+	for _, tt := range tests {
+		s, ok := got[tt.env]
+		if !ok {
+			t.Errorf("no setting for %s", tt.env)
+			continue
+		}
+		if s.Value != tt.value || s.Source != tt.source {
+			t.Errorf("%s = %q from %s, want %q from %s", tt.env, s.Value, s.Source, tt.value, tt.source)
+		}
+	}
+}
+
+func TestConfigHandler(t *testing.T) {
+	defer Set(nil)
+	c, err := load("", func(name string) (string, bool) {
+		if name == "GOLANGORG_ADMIN_TOKEN" {
+			return "hunter2", true
+		}
+		return "", false
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	Set(c)
+	h := ConfigHandler("hunter2")
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/debug/config", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("unauthenticated request: %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+
+	r := httptest.NewRequest("GET", "/debug/config", nil)
+	r.Header.Set("Authorization", "Bearer hunter2")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("authenticated request: %d %s", w.Code, w.Body)
+	}
+	if strings.Contains(w.Body.String(), "hunter2") {
+		t.Errorf("response reveals admin token:\n%s", w.Body)
+	}
+	var settings []Setting
+	if err := json.Unmarshal(w.Body.Bytes(), &settings); err != nil {
+		t.Fatal(err)
+	}
+	if len(settings) == 0 || settings[0].Env != "GOLANGORG_PROFILE" {
+		t.Errorf("settings = %+v, want list starting with profile", settings)
+	}
+}
@@ -4,33 +4,710 @@
 
 // Package env provides environment information for the golangorg server
 // running on golang.org.
+//
+// The server's settings are collected in a Config, loaded by Load
+// from an optional YAML file and the environment, with environment
+// variables overriding the file. Packages read the process-wide
+// configuration with Get.
+//
+// The Profile setting selects the defaults for the other settings,
+// so that a single switch runs the server locally the way it runs
+// in development, staging, or production.
 package env
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"log"
+	"net/url"
 	"os"
+	"reflect"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/matttproud/yourtour/internal/accesslog"
+	"github.com/matttproud/yourtour/internal/chaos"
+	"github.com/matttproud/yourtour/internal/clientip"
+	"github.com/matttproud/yourtour/internal/debughttp"
+	"github.com/matttproud/yourtour/internal/errreport"
+	"github.com/matttproud/yourtour/internal/ipacl"
+	"github.com/matttproud/yourtour/internal/private"
+	"github.com/matttproud/yourtour/internal/ratelimit"
+	"github.com/matttproud/yourtour/internal/rates"
+	"github.com/matttproud/yourtour/internal/slo"
+	"github.com/matttproud/yourtour/internal/tenant"
+	"github.com/matttproud/yourtour/internal/timeout"
+	"gopkg.in/yaml.v3"
 )
 
-var requireDLSecretKey = boolEnv("GOLANGORG_REQUIRE_DL_SECRET_KEY")
+// A Config holds the server's settings.
+//
+// Each field can be set in the YAML configuration file using the key
+// in its yaml tag, or in the environment using the variable in its env tag.
+type Config struct {
+	// Profile selects the defaults for the settings below.
+	// It defaults to Prod on App Engine and Dev elsewhere.
+	Profile Profile `yaml:"profile" env:"GOLANGORG_PROFILE"`
 
-// RequireDLSecretKey reports whether the download server secret key
-// is expected to already exist, and the download server should panic
-// on missing key instead of creating a new one.
-func RequireDLSecretKey() bool {
-	return requireDLSecretKey
+	// Analytics is the Google Analytics ID to include in pages, if any.
+	Analytics string `yaml:"analytics" env:"GOLANGORG_ANALYTICS"`
+
+	// RequireDLSecretKey reports whether the download server secret keys
+	// must be provided by the configured Secrets, and the download server
+	// should refuse uploads and serve the download page without preferences
+	// when they are missing instead of using development keys.
+	// Without the upload key, the server is not ready.
+	RequireDLSecretKey bool `yaml:"require_dl_secret_key" env:"GOLANGORG_REQUIRE_DL_SECRET_KEY"`
+
+	// CacheBackend is the memcache backend: "redis" (the default) or "memory".
+	CacheBackend string `yaml:"cache_backend" env:"GOLANGORG_CACHE_BACKEND"`
+
+	// RedisAddr is the address of the Redis server or servers
+	// used by the redis cache backend, in any form accepted by memcache.Open.
+	RedisAddr string `yaml:"redis_addr" env:"GOLANGORG_REDIS_ADDR"`
+
+	// ServeMetrics reports whether to export Prometheus metrics at /_metrics.
+	ServeMetrics bool `yaml:"serve_metrics" env:"GOLANGORG_SERVE_METRICS"`
+
+	// DevMode reports whether sites show development diagnostics,
+	// such as verbose template error pages, and whether pages served
+	// from on-disk content reload in browsers when it is edited.
+	DevMode bool `yaml:"dev_mode" env:"GOLANGORG_DEV_MODE"`
+
+	// FakeDLData reports whether the download pages serve the embedded
+	// snapshot of release data instead of reading datastore.
+	FakeDLData bool `yaml:"fake_dl_data" env:"GOLANGORG_FAKE_DL_DATA"`
+
+	// DLBackend is where the download pages read release data and
+	// uploads write it: "datastore" (the default) or "memory",
+	// which keeps it in process memory, starting from the embedded
+	// snapshot, so that uploads and listing can be tried locally.
+	// Setting it to memory overrides FakeDLData.
+	DLBackend string `yaml:"dl_backend" env:"GOLANGORG_DL_BACKEND"`
+
+	// DLMirrors is a comma-separated list of the base URLs of mirrors
+	// of the release downloads, such as "https://mirror.example/golang/",
+	// which the downloads' Metalink descriptors list after dl.google.com.
+	DLMirrors string `yaml:"dl_mirrors" env:"GOLANGORG_DL_MIRRORS"`
+
+	// HotReload reports whether to serve an on-disk _content directory,
+	// when one is found, so that edits appear without restarting.
+	HotReload bool `yaml:"hot_reload" env:"GOLANGORG_HOT_RELOAD"`
+
+	// ContentOverlay is a directory whose files shadow those of the
+	// site content, so that contributors can edit individual pages,
+	// templates, and codewalks on disk while the rest of the site
+	// is served from the embedded copy. It is empty to use no overlay.
+	ContentOverlay string `yaml:"content_overlay" env:"GOLANGORG_CONTENT_OVERLAY"`
+
+	// Sections mounts sections of the site maintained apart from its
+	// content, each served from a content root directory of its own,
+	// with its own layouts, redirects, and search index, in the format
+	// read by tenant.ParseMounts: for example,
+	// "/tour=/srv/tour,docs.example.com=/srv/docs" serves go.dev/tour/
+	// from /srv/tour and the host docs.example.com from /srv/docs.
+	// A section takes precedence over the content of the host it is
+	// mounted on. It is read at startup.
+	Sections string `yaml:"sections" env:"GOLANGORG_SECTIONS"`
+
+	// ContentRepo is the URL of a Git repository holding the site content,
+	// such as https://github.com/golang/website. If it is set, the pushes to
+	// ContentBranch that its Git forge reports to /_content/webhook deploy
+	// the content of the pushed commits: their _content directories,
+	// or their roots if those hold a site.tmpl. The webhook requests
+	// must be signed with the content-webhook-secret secret.
+	// With a datastore, pushes whose deploys fail are retried,
+	// and those failing repeatedly are listed at /_webhooks.
+	// Each push is deployed only by the server instance receiving it,
+	// so set ContentRepo only for servers run as a single instance.
+	ContentRepo string `yaml:"content_repo" env:"GOLANGORG_CONTENT_REPO"`
+
+	// ContentBranch is the branch of ContentRepo whose pushes are deployed.
+	// If it is empty, the branch is master.
+	ContentBranch string `yaml:"content_branch" env:"GOLANGORG_CONTENT_BRANCH"`
+
+	// NoIndex reports whether responses ask search engines
+	// not to index them.
+	NoIndex bool `yaml:"noindex" env:"GOLANGORG_NOINDEX"`
+
+	// FlagsFile is the YAML file holding feature flags, if any.
+	// When it is empty, servers with a datastore keep flags there.
+	FlagsFile string `yaml:"flags_file" env:"GOLANGORG_FLAGS_FILE"`
+
+	// FlagsRefresh is how often feature flags are reloaded from their source.
+	// If it is zero, the interval is FlagRefreshInterval.
+	FlagsRefresh time.Duration `yaml:"flags_refresh" env:"GOLANGORG_FLAGS_REFRESH"`
+
+	// AdminToken is the bearer token required by administrative endpoints,
+	// such as /_flags, /debug/config, and the runtime debugging endpoints.
+	// If it is empty, /_flags and /debug/config are disabled.
+	AdminToken string `yaml:"admin_token" env:"GOLANGORG_ADMIN_TOKEN" secret:"true"`
+
+	// AdminAllowIPs is a comma-separated list of IP addresses and
+	// CIDR prefixes, such as "10.0.0.0/8,::1", from which the runtime
+	// debugging endpoints under /debug/ may be reached.
+	// Those endpoints are served only if AdminToken or AdminAllowIPs
+	// is set, and require both when both are.
+	AdminAllowIPs string `yaml:"admin_allow_ips" env:"GOLANGORG_ADMIN_ALLOW_IPS"`
+
+	// TrustedProxies is a comma-separated list of the IP addresses and
+	// CIDR prefixes of the proxies in front of the server, such as
+	// "10.0.0.0/8", which are trusted to report the addresses of the
+	// clients they forward requests for in TrustedProxyHeader.
+	// Rate limits, access lists, and logs use the client address so found.
+	// On App Engine, whose front end's addresses are not known, the client
+	// address is instead the one the front end adds to X-Forwarded-For.
+	// It is read at startup.
+	TrustedProxies string `yaml:"trusted_proxies" env:"GOLANGORG_TRUSTED_PROXIES"`
+
+	// TrustedProxyHeader is the header in which the trusted proxies
+	// report client addresses: "X-Forwarded-For", the default,
+	// or "Forwarded". It is read at startup.
+	TrustedProxyHeader string `yaml:"trusted_proxy_header" env:"GOLANGORG_TRUSTED_PROXY_HEADER"`
+
+	// IPAccess limits the client addresses that may reach sensitive
+	// routes, in the format read by ipacl.ParseRules: for example,
+	// "upload=10.0.0.0/8 !10.9.0.0/16,admin=127.0.0.1 ::1".
+	// The routes are upload (/dl/upload), admin (/_analytics, /_apikeys,
+	// /_comments, /_content, /_features, /_feedback, /_flags, /_links,
+	// /_metrics, /_redirects, /_slo, /_validate, /_webhooks, and /debug/config),
+	// webhook (/_content/webhook), and debug (the rest of /debug/).
+	// Client addresses are found as TrustedProxies says.
+	// It is read at startup.
+	IPAccess string `yaml:"ip_access" env:"GOLANGORG_IP_ACCESS"`
+
+	// PlaygroundURL is the base URL of the playground backend to which
+	// the playground endpoints (/_/compile, /_/fmt, and /_/share) proxy,
+	// such as "https://play.golang.org", the default. Other Go versions
+	// are served by backends whose host names add a prefix to its host name,
+	// such as gotipplay.golang.org.
+	PlaygroundURL string `yaml:"playground_url" env:"GOLANGORG_PLAYGROUND_URL"`
+
+	// RedirectsFile is a YAML file of additional redirects, if any,
+	// in the format read by redirect.Rules.
+	RedirectsFile string `yaml:"redirects_file" env:"GOLANGORG_REDIRECTS_FILE"`
+
+	// VanityFile is a YAML file of vanity import paths, in the format
+	// read by vanity.Paths, replacing the built-in list if set.
+	VanityFile string `yaml:"vanity_file" env:"GOLANGORG_VANITY_FILE"`
+
+	// AnnouncementsFile is a YAML file of site-wide announcements,
+	// in the format read by announce.FileSource. If empty,
+	// announcements are read from datastore, when there is one.
+	AnnouncementsFile string `yaml:"announcements_file" env:"GOLANGORG_ANNOUNCEMENTS_FILE"`
+
+	// FeedbackCaptchaURL is the siteverify endpoint of the CAPTCHA
+	// service checking comments posted to /feedback, such as
+	// "https://www.google.com/recaptcha/api/siteverify". Its key is the
+	// feedback-captcha-secret secret. If empty, comments need no CAPTCHA.
+	FeedbackCaptchaURL string `yaml:"feedback_captcha_url" env:"GOLANGORG_FEEDBACK_CAPTCHA_URL"`
+
+	// CommentSections is a comma-separated list of the path prefixes
+	// of the site sections whose pages take readers' comments, such as
+	// "/blog/,/doc/codewalk/". Comments are stored in datastore; without one,
+	// or if CommentSections is empty, no pages take comments.
+	// It is read at startup.
+	CommentSections string `yaml:"comment_sections" env:"GOLANGORG_COMMENT_SECTIONS"`
+
+	// PrivateSections is a comma-separated list of the path prefixes
+	// of the site sections that only readers who sign in can read, such as
+	// "/internal-docs/". Readers sign in with the private-sections-password
+	// secret; without it, no one can. It is read at startup.
+	PrivateSections string `yaml:"private_sections" env:"GOLANGORG_PRIVATE_SECTIONS"`
+
+	// SecretsDir is a directory holding secrets, one per file, if any.
+	SecretsDir string `yaml:"secrets_dir" env:"GOLANGORG_SECRETS_DIR"`
+
+	// SecretsProject is the Google Cloud project whose Secret Manager
+	// holds secrets, if any.
+	SecretsProject string `yaml:"secrets_project" env:"GOLANGORG_SECRETS_PROJECT"`
+
+	// SecretsTTL is how long secrets are cached before being fetched again
+	// to pick up rotations. If it is zero, the TTL is DefaultSecretTTL.
+	SecretsTTL time.Duration `yaml:"secrets_ttl" env:"GOLANGORG_SECRETS_TTL"`
+
+	// TLSHosts is a comma-separated list of host names for which to serve
+	// HTTPS directly, using certificates obtained automatically from
+	// Let's Encrypt. If it is empty, the server serves plain HTTP
+	// and expects a proxy, such as App Engine's, to terminate TLS.
+	TLSHosts string `yaml:"tls_hosts" env:"GOLANGORG_TLS_HOSTS"`
+
+	// TLSAddr is the address on which to serve HTTPS when TLSHosts is set.
+	// If it is empty, the address is ":443".
+	TLSAddr string `yaml:"tls_addr" env:"GOLANGORG_TLS_ADDR"`
+
+	// TLSCacheDir is the directory in which to store certificates
+	// when TLSHosts is set, so that they survive restarts.
+	TLSCacheDir string `yaml:"tls_cache_dir" env:"GOLANGORG_TLS_CACHE_DIR"`
+
+	// ACMEEmail is the contact address given to Let's Encrypt, if any.
+	ACMEEmail string `yaml:"acme_email" env:"GOLANGORG_ACME_EMAIL"`
+
+	// SearchIndexDir is the directory in which to store the search index
+	// of each site, so that it survives restarts and only the pages changed
+	// since are indexed again. If it is empty, indexes are kept in memory
+	// and built from scratch at startup.
+	SearchIndexDir string `yaml:"search_index_dir" env:"GOLANGORG_SEARCH_INDEX_DIR"`
+
+	// H2C reports whether to accept HTTP/2 without TLS (h2c),
+	// as spoken by load balancers that terminate TLS in front of the server.
+	// HTTP/2 is always offered over TLS, when TLSHosts is set.
+	H2C bool `yaml:"h2c" env:"GOLANGORG_H2C"`
+
+	// ServerReadHeaderTimeout, ServerReadTimeout, ServerWriteTimeout,
+	// and ServerIdleTimeout bound the time a connection may spend
+	// sending request headers, sending a whole request, receiving
+	// a response, and waiting for its next request, as the http.Server
+	// fields of the same names do. If one is zero, its default applies:
+	// 10s, 2m, 5m, and 11m, which outlasts the idle connections of
+	// Google Cloud load balancers. They are read at startup.
+	ServerReadHeaderTimeout time.Duration `yaml:"server_read_header_timeout" env:"GOLANGORG_SERVER_READ_HEADER_TIMEOUT"`
+	ServerReadTimeout       time.Duration `yaml:"server_read_timeout" env:"GOLANGORG_SERVER_READ_TIMEOUT"`
+	ServerWriteTimeout      time.Duration `yaml:"server_write_timeout" env:"GOLANGORG_SERVER_WRITE_TIMEOUT"`
+	ServerIdleTimeout       time.Duration `yaml:"server_idle_timeout" env:"GOLANGORG_SERVER_IDLE_TIMEOUT"`
+
+	// ServerMaxHeaderBytes is the largest size of request headers accepted.
+	// If it is zero, the limit is 64 kB. It is read at startup.
+	ServerMaxHeaderBytes int `yaml:"server_max_header_bytes" env:"GOLANGORG_SERVER_MAX_HEADER_BYTES"`
+
+	// RateLimits overrides the default request rate limits of
+	// abuse-prone routes, in the format read by ratelimit.ParseRules:
+	// for example, "upload=10/m@token,fileprint=off,play=10/m".
+	// Requests to the API routes, graphql and dljson (/dl/?mode=json),
+	// made with valid API keys are limited by the keys' quotas instead.
+	// It is read at startup.
+	RateLimits string `yaml:"rate_limits" env:"GOLANGORG_RATE_LIMITS"`
+
+	// Shadows sets the fraction of read-only requests for routes being
+	// rewritten that are mirrored to the rewrite, to compare its replies
+	// with those served, in the format read by rates.Parse:
+	// for example, "codewalk=5%". By default no requests are mirrored.
+	// It is read at startup.
+	Shadows string `yaml:"shadows" env:"GOLANGORG_SHADOWS"`
+
+	// Canaries sets the fraction of clients whose requests for routes
+	// being rolled out are served by the new version of their handlers,
+	// in the format read by rates.Parse: for example, "codewalk=5%".
+	// Clients are assigned to a version by their address, so each keeps
+	// seeing the same one. By default no requests are served by new versions.
+	// It is read at startup.
+	Canaries string `yaml:"canaries" env:"GOLANGORG_CANARIES"`
+
+	// Chaos injects latency and errors into requests under URL path
+	// prefixes and into calls to the datastore and memcache dependencies,
+	// for resilience testing, in the format read by chaos.ParseRules:
+	// for example, "/dl/=2s@50%,datastore=error". It must not be set
+	// in production. It is read at startup.
+	Chaos string `yaml:"chaos" env:"GOLANGORG_CHAOS"`
+
+	// ErrorReportURL is the endpoint to which server errors are posted
+	// as JSON, such as the collector of an error-reporting service.
+	// If it is empty, errors are only logged.
+	ErrorReportURL string `yaml:"error_report_url" env:"GOLANGORG_ERROR_REPORT_URL" secret:"true"`
+
+	// ErrorSampleRate is the fraction of errors sent to ErrorReportURL,
+	// such as 0.25 or 25%. Panics are always sent. If it is empty, all are.
+	// Both are read at startup.
+	ErrorSampleRate string `yaml:"error_sample_rate" env:"GOLANGORG_ERROR_SAMPLE_RATE"`
+
+	// Timeouts overrides the time allowed for serving requests,
+	// by URL path prefix, in the format read by timeout.ParseRules:
+	// for example, "*=20s,/dl/=10s". By default requests have 30 seconds,
+	// except for profiles. It is read at startup.
+	Timeouts string `yaml:"timeouts" env:"GOLANGORG_TIMEOUTS"`
+
+	// SLOs overrides the service level objectives of classes of routes,
+	// whose error budgets are reported at /_slo, by class name,
+	// in the format read by slo.ParseObjectives: for example,
+	// "pages=99.5%/2s,dljson=99.99%/300ms". The classes are dljson
+	// (/dl/?mode=json), dl, api (/api/ and /graphql), play, and pages.
+	// It is read at startup.
+	SLOs string `yaml:"slos" env:"GOLANGORG_SLOS"`
+
+	// AccessLog is where to write a line for every request served:
+	// "stdout", or the path of a file, which is rotated when it reaches
+	// AccessLogMaxSize. If it is empty, requests are not logged.
+	AccessLog string `yaml:"access_log" env:"GOLANGORG_ACCESS_LOG"`
+
+	// AccessLogFormat is the format of access log lines:
+	// "common" (the default) or "json".
+	AccessLogFormat string `yaml:"access_log_format" env:"GOLANGORG_ACCESS_LOG_FORMAT"`
+
+	// AccessLogMaxSize is the size in megabytes at which the access
+	// log file is rotated. If it is zero, the size is 100.
+	AccessLogMaxSize int `yaml:"access_log_max_size" env:"GOLANGORG_ACCESS_LOG_MAX_SIZE"`
+
+	// Port is the port App Engine asks the server to listen on.
+	// It is empty when not running on App Engine.
+	Port string `yaml:"-" env:"PORT"`
+
+	// sources records where each field's value came from,
+	// keyed by field name. Fields not present have their zero value.
+	sources map[string]Source
 }
 
-func boolEnv(key string) bool {
-	v := os.Getenv(key)
-	if v == "" {
-		// TODO(dmitshur): In the future, consider detecting if running in App Engine,
-		// and if so, making the environment variables mandatory rather than optional.
-		return false
+// A Profile names a bundle of default settings for an environment.
+type Profile string
+
+const (
+	// Dev serves the embedded download data, reloads content from disk,
+	// shows verbose errors, and asks not to be indexed.
+	Dev Profile = "dev"
+
+	// Staging behaves like Prod, but serves the embedded download data
+	// and asks not to be indexed: it is production minus external data.
+	Staging Profile = "staging"
+
+	// Prod is the configuration of the live site.
+	Prod Profile = "prod"
+)
+
+// defaults returns the default configuration for profile p.
+func (p Profile) defaults() *Config {
+	c := &Config{Profile: p}
+	switch p {
+	case Dev:
+		c.DevMode = true
+		c.FakeDLData = true
+		c.HotReload = true
+		c.NoIndex = true
+	case Staging:
+		c.FakeDLData = true
+		c.NoIndex = true
 	}
-	b, err := strconv.ParseBool(v)
-	if err != nil {
-		log.Fatalf("environment variable %s (%q) must be a boolean", key, v)
+	return c
+}
+
+// ConfigFileEnv is the environment variable naming the configuration file
+// read by Get when the configuration was not set with Set.
+const ConfigFileEnv = "GOLANGORG_CONFIG"
+
+// OnAppEngine reports whether the server is running on App Engine.
+func (c *Config) OnAppEngine() bool {
+	return c.Port != ""
+}
+
+// Load returns the configuration read from the YAML file at path,
+// if path is not empty, overridden by settings in the environment.
+// It reports every invalid setting, not just the first.
+func Load(path string) (*Config, error) {
+	return load(path, os.LookupEnv)
+}
+
+func load(path string, lookup func(string) (string, bool)) (*Config, error) {
+	var data []byte
+	if path != "" {
+		var err error
+		data, err = os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var problems []string
+	// decode decodes the file into c, returning false if the file
+	// is not valid YAML and so cannot be decoded at all.
+	decode := func(c *Config, report bool) bool {
+		if data == nil {
+			return true
+		}
+		dec := yaml.NewDecoder(strings.NewReader(string(data)))
+		dec.KnownFields(true)
+		err := dec.Decode(c)
+		if terr, ok := err.(*yaml.TypeError); ok {
+			// The rest of the file was decoded; report each bad setting.
+			if report {
+				for _, msg := range terr.Errors {
+					problems = append(problems, path+": "+msg)
+				}
+			}
+			return true
+		}
+		if err != nil && err != io.EOF {
+			problems = append(problems, path+": "+err.Error())
+			return false
+		}
+		return true
+	}
+
+	// Determine the profile first, so that the file
+	// and environment can override its defaults.
+	var file Config
+	if !decode(&file, false) {
+		return nil, &ValidationError{problems}
+	}
+	profile := file.Profile
+	if s, ok := lookup("GOLANGORG_PROFILE"); ok && s != "" {
+		profile = Profile(s)
+	}
+	if profile == "" {
+		profile = Dev
+		if port, _ := lookup("PORT"); port != "" {
+			profile = Prod
+		}
+	}
+	c := profile.defaults()
+	c.sources = make(map[string]Source)
+	c.recordSources(SourceProfile, func(f reflect.StructField, v reflect.Value) bool {
+		return f.Name != "Profile" && !v.IsZero()
+	})
+	decode(c, true)
+	c.Profile = profile
+	if data != nil {
+		var keys map[string]any
+		yaml.Unmarshal(data, &keys)
+		c.recordSources(SourceFile, func(f reflect.StructField, v reflect.Value) bool {
+			_, ok := keys[f.Tag.Get("yaml")]
+			return ok
+		})
+	}
+
+	v := reflect.ValueOf(c).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Type().Field(i)
+		name := f.Tag.Get("env")
+		if name == "" {
+			continue
+		}
+		s, ok := lookup(name)
+		if !ok || s == "" {
+			continue
+		}
+		c.sources[f.Name] = SourceEnv
+		if err := setField(v.Field(i), s); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: invalid value %q; want %s", settingName(f), s, err))
+		}
+	}
+	if err := c.Validate(); err != nil {
+		problems = append(problems, err.(*ValidationError).Problems...)
+	}
+	if len(problems) > 0 {
+		return nil, &ValidationError{problems}
+	}
+	return c, nil
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// setField sets v to the value of the environment variable setting s.
+// If s is malformed, the error describes the expected format.
+func setField(v reflect.Value, s string) error {
+	switch {
+	case v.Type() == durationType:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return errors.New("a duration like 30s or 5m")
+		}
+		v.SetInt(int64(d))
+	case v.Kind() == reflect.String:
+		v.SetString(s)
+	case v.Kind() == reflect.Int:
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return errors.New("an integer")
+		}
+		v.SetInt(int64(n))
+	case v.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return errors.New("true or false")
+		}
+		v.SetBool(b)
+	default:
+		panic("env: unsupported Config field type " + v.Type().String())
+	}
+	return nil
+}
+
+// settingName returns the name used for field f in error messages,
+// giving both its configuration file key and environment variable.
+func settingName(f reflect.StructField) string {
+	key, env := f.Tag.Get("yaml"), f.Tag.Get("env")
+	if key == "" || key == "-" {
+		return env
+	}
+	return key + " (" + env + ")"
+}
+
+// A ValidationError reports every problem found in a configuration.
+type ValidationError struct {
+	// Problems describes each problem, naming the setting,
+	// the bad value, and the expected format.
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	if len(e.Problems) == 1 {
+		return e.Problems[0]
+	}
+	return fmt.Sprintf("%d problems:\n\t%s", len(e.Problems), strings.Join(e.Problems, "\n\t"))
+}
+
+// Validate reports any invalid or inconsistent settings in c.
+// The error, if any, is a *ValidationError listing every problem.
+func (c *Config) Validate() error {
+	var problems []string
+	bad := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+	switch c.Profile {
+	case Dev, Staging, Prod:
+	default:
+		bad("profile (GOLANGORG_PROFILE): unknown profile %q; want dev, staging, or prod", c.Profile)
+	}
+	switch c.CacheBackend {
+	case "", "redis", "memory":
+		if c.CacheBackend != "memory" && c.OnAppEngine() && c.RedisAddr == "" {
+			bad("redis_addr (GOLANGORG_REDIS_ADDR): missing; want host:port or a redis:// URL when using the redis cache backend on App Engine")
+		}
+	default:
+		bad("cache_backend (GOLANGORG_CACHE_BACKEND): unknown backend %q; want redis or memory", c.CacheBackend)
+	}
+	switch c.DLBackend {
+	case "", "datastore":
+	case "memory":
+		if c.Profile == Prod {
+			bad("dl_backend (GOLANGORG_DL_BACKEND): memory in prod; want datastore, since uploads kept in memory are lost on restart")
+		}
+	default:
+		bad("dl_backend (GOLANGORG_DL_BACKEND): unknown backend %q; want datastore or memory", c.DLBackend)
+	}
+	for _, t := range []struct {
+		key string
+		d   time.Duration
+	}{
+		{"server_read_header_timeout (GOLANGORG_SERVER_READ_HEADER_TIMEOUT)", c.ServerReadHeaderTimeout},
+		{"server_read_timeout (GOLANGORG_SERVER_READ_TIMEOUT)", c.ServerReadTimeout},
+		{"server_write_timeout (GOLANGORG_SERVER_WRITE_TIMEOUT)", c.ServerWriteTimeout},
+		{"server_idle_timeout (GOLANGORG_SERVER_IDLE_TIMEOUT)", c.ServerIdleTimeout},
+	} {
+		if t.d < 0 {
+			bad("%s: negative duration %v; want a positive duration like 1m, or 0 for the default", t.key, t.d)
+		}
+	}
+	if c.ServerMaxHeaderBytes < 0 {
+		bad("server_max_header_bytes (GOLANGORG_SERVER_MAX_HEADER_BYTES): negative size %d; want a size in bytes, or 0 for the default", c.ServerMaxHeaderBytes)
+	}
+	if c.H2C && c.TLSHosts != "" {
+		bad("h2c (GOLANGORG_H2C): set with tls_hosts; want only one, since h2c is for servers behind a load balancer that terminates TLS")
 	}
-	return b
+	if c.FlagsRefresh < 0 {
+		bad("flags_refresh (GOLANGORG_FLAGS_REFRESH): negative duration %v; want a positive duration like 1m, or 0 for the default", c.FlagsRefresh)
+	}
+	if c.SecretsTTL < 0 {
+		bad("secrets_ttl (GOLANGORG_SECRETS_TTL): negative duration %v; want a positive duration like 10m, or 0 for the default", c.SecretsTTL)
+	}
+	if c.TLSHosts != "" {
+		if c.OnAppEngine() {
+			bad("tls_hosts (GOLANGORG_TLS_HOSTS): set on App Engine; want empty, since App Engine terminates TLS")
+		}
+		if c.TLSCacheDir == "" {
+			bad("tls_cache_dir (GOLANGORG_TLS_CACHE_DIR): missing; want a directory for certificates when tls_hosts is set")
+		}
+	}
+	if c.PlaygroundURL != "" {
+		if u, err := url.Parse(c.PlaygroundURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			bad("playground_url (GOLANGORG_PLAYGROUND_URL): invalid URL %q; want an http or https URL like https://play.golang.org", c.PlaygroundURL)
+		}
+	}
+	for _, m := range strings.Split(c.DLMirrors, ",") {
+		if m = strings.TrimSpace(m); m == "" {
+			continue
+		}
+		if u, err := url.Parse(m); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || !strings.HasSuffix(u.Path, "/") {
+			bad("dl_mirrors (GOLANGORG_DL_MIRRORS): invalid mirror %q; want an http or https URL ending in /", m)
+		}
+	}
+	if _, err := private.ParseSections(c.PrivateSections); err != nil {
+		bad("private_sections (GOLANGORG_PRIVATE_SECTIONS): %v", err)
+	}
+	if _, err := tenant.ParseMounts(c.Sections); err != nil {
+		bad("sections (GOLANGORG_SECTIONS): %v", err)
+	}
+	if _, err := ratelimit.ParseRules(c.RateLimits); err != nil {
+		bad("rate_limits (GOLANGORG_RATE_LIMITS): %v", err)
+	}
+	if _, err := chaos.ParseRules(c.Chaos); err != nil {
+		bad("chaos (GOLANGORG_CHAOS): %v", err)
+	}
+	if _, err := rates.Parse(c.Shadows); err != nil {
+		bad("shadows (GOLANGORG_SHADOWS): %v", err)
+	}
+	if _, err := rates.Parse(c.Canaries); err != nil {
+		bad("canaries (GOLANGORG_CANARIES): %v", err)
+	}
+	if _, err := slo.ParseObjectives(c.SLOs); err != nil {
+		bad("slos (GOLANGORG_SLOS): %v", err)
+	}
+	if _, err := timeout.ParseRules(c.Timeouts); err != nil {
+		bad("timeouts (GOLANGORG_TIMEOUTS): %v", err)
+	}
+	if _, err := debughttp.ParseAllow(c.AdminAllowIPs); err != nil {
+		bad("admin_allow_ips (GOLANGORG_ADMIN_ALLOW_IPS): %v", err)
+	}
+	if _, err := clientip.ParseTrusted(c.TrustedProxies); err != nil {
+		bad("trusted_proxies (GOLANGORG_TRUSTED_PROXIES): %v", err)
+	}
+	if h := c.TrustedProxyHeader; h != "" && !strings.EqualFold(h, "X-Forwarded-For") && !strings.EqualFold(h, "Forwarded") {
+		bad("trusted_proxy_header (GOLANGORG_TRUSTED_PROXY_HEADER): unknown header %q; want X-Forwarded-For or Forwarded", c.TrustedProxyHeader)
+	}
+	if _, err := ipacl.ParseRules(c.IPAccess); err != nil {
+		bad("ip_access (GOLANGORG_IP_ACCESS): %v", err)
+	}
+	if _, err := accesslog.ParseFormat(c.AccessLogFormat); err != nil {
+		bad("access_log_format (GOLANGORG_ACCESS_LOG_FORMAT): %v", err)
+	}
+	if _, err := errreport.ParseRate(c.ErrorSampleRate); err != nil {
+		bad("error_sample_rate (GOLANGORG_ERROR_SAMPLE_RATE): %v", err)
+	}
+	if c.AccessLogMaxSize < 0 {
+		bad("access_log_max_size (GOLANGORG_ACCESS_LOG_MAX_SIZE): negative size %d; want a size in megabytes, or 0 for the default", c.AccessLogMaxSize)
+	}
+	if c.Port != "" {
+		if _, err := strconv.ParseUint(c.Port, 10, 16); err != nil {
+			bad("PORT: invalid value %q; want a port number", c.Port)
+		}
+	}
+	if problems == nil {
+		return nil
+	}
+	return &ValidationError{problems}
+}
+
+var (
+	mu      sync.Mutex
+	current *Config
+)
+
+// Set sets the process-wide configuration returned by Get.
+// Servers call Set at startup with the result of Load.
+func Set(c *Config) {
+	mu.Lock()
+	defer mu.Unlock()
+	current = c
+}
+
+// Get returns the process-wide configuration.
+// If Set has not been called, Get loads the configuration from
+// the environment and the file named by $GOLANGORG_CONFIG, if set,
+// exiting the program if the configuration is invalid.
+func Get() *Config {
+	mu.Lock()
+	defer mu.Unlock()
+	if current == nil {
+		c, err := Load(os.Getenv(ConfigFileEnv))
+		if err != nil {
+			log.Fatalf("invalid configuration: %v", err)
+		}
+		current = c
+	}
+	return current
+}
+
+// RequireDLSecretKey reports whether the download server secret keys
+// must be provided by the configured Secrets.
+func RequireDLSecretKey() bool {
+	return Get().RequireDLSecretKey
 }
@@ -0,0 +1,296 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoad(t *testing.T) {
+	var tests = []struct {
+		name    string
+		file    string
+		env     map[string]string
+		want    Config
+		wantErr []string
+	}{
+		{
+			name: "defaults",
+			want: Config{Profile: Dev, DevMode: true, FakeDLData: true, HotReload: true, NoIndex: true},
+		},
+		{
+			name: "app engine",
+			env:  map[string]string{"PORT": "8080", "GOLANGORG_REDIS_ADDR": "10.0.0.4:6379"},
+			want: Config{Profile: Prod, Port: "8080", RedisAddr: "10.0.0.4:6379"},
+		},
+		{
+			name: "staging",
+			env:  map[string]string{"GOLANGORG_PROFILE": "staging"},
+			want: Config{Profile: Staging, FakeDLData: true, NoIndex: true},
+		},
+		{
+			name: "profile from file",
+			file: "profile: prod\n",
+			want: Config{Profile: Prod},
+		},
+		{
+			name: "env profile overrides file",
+			file: "profile: prod\n",
+			env:  map[string]string{"GOLANGORG_PROFILE": "staging"},
+			want: Config{Profile: Staging, FakeDLData: true, NoIndex: true},
+		},
+		{
+			name: "settings override profile",
+			file: "profile: dev\nhot_reload: false\n",
+			env:  map[string]string{"GOLANGORG_NOINDEX": "false"},
+			want: Config{Profile: Dev, DevMode: true, FakeDLData: true},
+		},
+		{
+			name: "file",
+			file: "profile: prod\nanalytics: UA-1\ncache_backend: memory\nserve_metrics: true\n",
+			want: Config{Profile: Prod, Analytics: "UA-1", CacheBackend: "memory", ServeMetrics: true},
+		},
+		{
+			name: "env overrides file",
+			file: "profile: prod\ncache_backend: memory\nredis_addr: file:6379\ndev_mode: true\n",
+			env: map[string]string{
+				"GOLANGORG_CACHE_BACKEND": "redis",
+				"GOLANGORG_REDIS_ADDR":    "env:6379",
+				"GOLANGORG_DEV_MODE":      "false",
+			},
+			want: Config{Profile: Prod, CacheBackend: "redis", RedisAddr: "env:6379"},
+		},
+		{
+			name: "durations",
+			file: "flags_refresh: 30s\nsecrets_ttl: 5m\n",
+			env:  map[string]string{"GOLANGORG_FLAGS_REFRESH": "2m"},
+			want: Config{Profile: Dev, DevMode: true, FakeDLData: true, HotReload: true, NoIndex: true, FlagsRefresh: 2 * time.Minute, SecretsTTL: 5 * time.Minute},
+		},
+		{
+			name: "empty file",
+			file: "\n",
+			want: Config{Profile: Dev, DevMode: true, FakeDLData: true, HotReload: true, NoIndex: true},
+		},
+		{
+			name:    "malformed file",
+			file:    "analytics: [\n",
+			wantErr: []string{"config.yaml: yaml:"},
+		},
+		{
+			name:    "unknown file key",
+			file:    "cache: memory\n",
+			wantErr: []string{"field cache not found"},
+		},
+		{
+			name:    "unknown profile",
+			env:     map[string]string{"GOLANGORG_PROFILE": "qa"},
+			wantErr: []string{`unknown profile "qa"`},
+		},
+		{
+			name:    "bad bool",
+			env:     map[string]string{"GOLANGORG_SERVE_METRICS": "yes please"},
+			wantErr: []string{`serve_metrics (GOLANGORG_SERVE_METRICS): invalid value "yes please"; want true or false`},
+		},
+		{
+			name:    "bad duration",
+			env:     map[string]string{"GOLANGORG_SECRETS_TTL": "10"},
+			wantErr: []string{`secrets_ttl (GOLANGORG_SECRETS_TTL): invalid value "10"; want a duration like 30s or 5m`},
+		},
+		{
+			name:    "negative duration",
+			file:    "flags_refresh: -1m\n",
+			wantErr: []string{"flags_refresh (GOLANGORG_FLAGS_REFRESH): negative duration -1m0s"},
+		},
+		{
+			name: "tls",
+			env:  map[string]string{"GOLANGORG_PROFILE": "prod", "GOLANGORG_TLS_HOSTS": "go.example", "GOLANGORG_TLS_CACHE_DIR": "/var/cache/golangorg"},
+			want: Config{Profile: Prod, TLSHosts: "go.example", TLSCacheDir: "/var/cache/golangorg"},
+		},
+		{
+			name:    "tls without cache",
+			env:     map[string]string{"GOLANGORG_TLS_HOSTS": "go.example"},
+			wantErr: []string{"tls_cache_dir (GOLANGORG_TLS_CACHE_DIR): missing"},
+		},
+		{
+			name:    "tls on app engine",
+			env:     map[string]string{"PORT": "8080", "GOLANGORG_CACHE_BACKEND": "memory", "GOLANGORG_TLS_HOSTS": "go.example", "GOLANGORG_TLS_CACHE_DIR": "/tmp"},
+			wantErr: []string{"tls_hosts (GOLANGORG_TLS_HOSTS): set on App Engine"},
+		},
+		{
+			name:    "bad rate limits",
+			env:     map[string]string{"GOLANGORG_RATE_LIMITS": "upload=10/fortnight"},
+			wantErr: []string{`rate_limits (GOLANGORG_RATE_LIMITS): invalid rule "upload=10/fortnight"`},
+		},
+		{
+			name:    "bad sections",
+			env:     map[string]string{"GOLANGORG_SECTIONS": "/tour=/srv/tour,/tour/=/srv/other"},
+			wantErr: []string{`sections (GOLANGORG_SECTIONS): invalid mount "/tour/=/srv/other": /tour already mounted`},
+		},
+		{
+			name:    "bad private sections",
+			env:     map[string]string{"GOLANGORG_PRIVATE_SECTIONS": "/internal-docs"},
+			wantErr: []string{`private_sections (GOLANGORG_PRIVATE_SECTIONS): invalid private section "/internal-docs"; want /path/`},
+		},
+		{
+			name:    "bad dl mirrors",
+			env:     map[string]string{"GOLANGORG_DL_MIRRORS": "https://mirror.example/golang/, ftp://mirror.example/go"},
+			wantErr: []string{`dl_mirrors (GOLANGORG_DL_MIRRORS): invalid mirror "ftp://mirror.example/go"; want an http or https URL ending in /`},
+		},
+		{
+			name: "access log",
+			env:  map[string]string{"GOLANGORG_PROFILE": "prod", "GOLANGORG_ACCESS_LOG": "stdout", "GOLANGORG_ACCESS_LOG_FORMAT": "json", "GOLANGORG_ACCESS_LOG_MAX_SIZE": "10"},
+			want: Config{Profile: Prod, AccessLog: "stdout", AccessLogFormat: "json", AccessLogMaxSize: 10},
+		},
+		{
+			name:    "bad access log",
+			file:    "access_log_format: apache\n",
+			env:     map[string]string{"GOLANGORG_ACCESS_LOG_MAX_SIZE": "big"},
+			wantErr: []string{`access_log_max_size (GOLANGORG_ACCESS_LOG_MAX_SIZE): invalid value "big"; want an integer`, `access_log_format (GOLANGORG_ACCESS_LOG_FORMAT): unknown access log format "apache"`},
+		},
+		{
+			name:    "bad admin allowlist",
+			env:     map[string]string{"GOLANGORG_ADMIN_ALLOW_IPS": "10.0.0.0/8,localhost"},
+			wantErr: []string{`admin_allow_ips (GOLANGORG_ADMIN_ALLOW_IPS): invalid address "localhost"`},
+		},
+		{
+			name:    "bad trusted proxies",
+			env:     map[string]string{"GOLANGORG_TRUSTED_PROXIES": "10.0.0.0/8,proxy"},
+			wantErr: []string{`trusted_proxies (GOLANGORG_TRUSTED_PROXIES): invalid address "proxy"`},
+		},
+		{
+			name:    "bad trusted proxy header",
+			env:     map[string]string{"GOLANGORG_TRUSTED_PROXY_HEADER": "X-Real-IP"},
+			wantErr: []string{`trusted_proxy_header (GOLANGORG_TRUSTED_PROXY_HEADER): unknown header "X-Real-IP"`},
+		},
+		{
+			name:    "bad ip access",
+			env:     map[string]string{"GOLANGORG_IP_ACCESS": "upload=10.0.0.0/33"},
+			wantErr: []string{`ip_access (GOLANGORG_IP_ACCESS): invalid rule "upload=10.0.0.0/33": invalid address range "10.0.0.0/33"`},
+		},
+		{
+			name:    "bad error sample rate",
+			env:     map[string]string{"GOLANGORG_ERROR_SAMPLE_RATE": "150%"},
+			wantErr: []string{`error_sample_rate (GOLANGORG_ERROR_SAMPLE_RATE): invalid sample rate "150%"`},
+		},
+		{
+			name:    "negative server timeout",
+			env:     map[string]string{"GOLANGORG_SERVER_IDLE_TIMEOUT": "-1m"},
+			wantErr: []string{"server_idle_timeout (GOLANGORG_SERVER_IDLE_TIMEOUT): negative duration -1m0s"},
+		},
+		{
+			name:    "h2c with tls",
+			env:     map[string]string{"GOLANGORG_H2C": "true", "GOLANGORG_TLS_HOSTS": "go.example", "GOLANGORG_TLS_CACHE_DIR": "/tmp"},
+			wantErr: []string{"h2c (GOLANGORG_H2C): set with tls_hosts"},
+		},
+		{
+			name:    "bad playground url",
+			env:     map[string]string{"GOLANGORG_PLAYGROUND_URL": "play.golang.org"},
+			wantErr: []string{`playground_url (GOLANGORG_PLAYGROUND_URL): invalid URL "play.golang.org"`},
+		},
+		{
+			name:    "bad chaos",
+			env:     map[string]string{"GOLANGORG_CHAOS": "/dl/=slow"},
+			wantErr: []string{`chaos (GOLANGORG_CHAOS): invalid rule "/dl/=slow"`},
+		},
+		{
+			name:    "bad shadows",
+			env:     map[string]string{"GOLANGORG_SHADOWS": "codewalk=5"},
+			wantErr: []string{`shadows (GOLANGORG_SHADOWS): invalid rate "codewalk=5"`},
+		},
+		{
+			name:    "bad canaries",
+			env:     map[string]string{"GOLANGORG_CANARIES": "codewalk=half"},
+			wantErr: []string{`canaries (GOLANGORG_CANARIES): invalid rate "codewalk=half"`},
+		},
+		{
+			name:    "bad slos",
+			env:     map[string]string{"GOLANGORG_SLOS": "pages=100%/1s"},
+			wantErr: []string{`slos (GOLANGORG_SLOS): invalid objective "pages=100%/1s": bad target`},
+		},
+		{
+			name:    "bad timeouts",
+			env:     map[string]string{"GOLANGORG_TIMEOUTS": "dl=10s"},
+			wantErr: []string{`timeouts (GOLANGORG_TIMEOUTS): invalid rule "dl=10s"`},
+		},
+		{
+			name:    "bad dl backend",
+			env:     map[string]string{"GOLANGORG_DL_BACKEND": "gob"},
+			wantErr: []string{`dl_backend (GOLANGORG_DL_BACKEND): unknown backend "gob"`},
+		},
+		{
+			name:    "memory dl backend in prod",
+			env:     map[string]string{"GOLANGORG_PROFILE": "prod", "GOLANGORG_DL_BACKEND": "memory", "GOLANGORG_CACHE_BACKEND": "memory"},
+			wantErr: []string{"dl_backend (GOLANGORG_DL_BACKEND): memory in prod"},
+		},
+		{
+			name:    "missing redis on app engine",
+			env:     map[string]string{"PORT": "8080"},
+			wantErr: []string{"redis_addr (GOLANGORG_REDIS_ADDR): missing"},
+		},
+		{
+			name: "all errors",
+			file: "serve_metrics: maybe\ncache: memory\n",
+			env: map[string]string{
+				"GOLANGORG_REQUIRE_DL_SECRET_KEY": "maybe",
+				"GOLANGORG_CACHE_BACKEND":         "memcached",
+				"PORT":                            "http",
+			},
+			wantErr: []string{
+				"5 problems:",
+				"line 1: cannot unmarshal !!str `maybe` into bool",
+				"line 2: field cache not found",
+				"require_dl_secret_key (GOLANGORG_REQUIRE_DL_SECRET_KEY)",
+				`unknown backend "memcached"`,
+				`PORT: invalid value "http"`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := ""
+			if tt.file != "" {
+				path = filepath.Join(t.TempDir(), "config.yaml")
+				if err := os.WriteFile(path, []byte(tt.file), 0666); err != nil {
+					t.Fatal(err)
+				}
+			}
+			lookup := func(name string) (string, bool) {
+				v, ok := tt.env[name]
+				return v, ok
+			}
+			c, err := load(path, lookup)
+			if tt.wantErr != nil {
+				if err == nil {
+					t.Fatalf("load succeeded with %+v, want error", c)
+				}
+				for _, want := range tt.wantErr {
+					if !strings.Contains(err.Error(), want) {
+						t.Errorf("error %q does not mention %q", err, want)
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			c.sources = nil // tested by TestSettings
+			if !reflect.DeepEqual(*c, tt.want) {
+				t.Errorf("load = %+v, want %+v", *c, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	_, err := load(filepath.Join(t.TempDir(), "missing.yaml"), func(string) (string, bool) { return "", false })
+	if err == nil {
+		t.Fatal("load succeeded with missing file")
+	}
+}
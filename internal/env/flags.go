@@ -0,0 +1,233 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package env
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/matttproud/yourtour/internal/clientip"
+	"gopkg.in/yaml.v3"
+)
+
+// FlagRefreshInterval is how often servers reload feature flags from their source.
+const FlagRefreshInterval = time.Minute
+
+// ErrFlagsReadOnly is returned when setting a flag in a source
+// that cannot be changed at run time.
+var ErrFlagsReadOnly = errors.New("feature flags are read-only")
+
+// A FlagSource stores feature flag settings.
+type FlagSource interface {
+	// LoadFlags returns the current setting of every flag in the source.
+	LoadFlags(ctx context.Context) (map[string]bool, error)
+
+	// SetFlag records the setting of the named flag.
+	SetFlag(ctx context.Context, name string, enabled bool) error
+}
+
+// Flags is a set of feature flags loaded from a FlagSource.
+// Features check Enabled on each use, so that a flag can be toggled
+// without redeploying. Flags not present in the source are disabled.
+type Flags struct {
+	src FlagSource
+
+	mu     sync.RWMutex
+	values map[string]bool
+}
+
+// NewFlags returns a set of flags read from src.
+// The flags are all disabled until the first call to Refresh.
+func NewFlags(src FlagSource) *Flags {
+	return &Flags{src: src}
+}
+
+// Enabled reports whether the named flag is enabled.
+// It is safe to call on a nil *Flags, which has every flag disabled.
+func (f *Flags) Enabled(name string) bool {
+	if f == nil {
+		return false
+	}
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.values[name]
+}
+
+// All returns a copy of the current flag settings.
+func (f *Flags) All() map[string]bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	m := make(map[string]bool, len(f.values))
+	for k, v := range f.values {
+		m[k] = v
+	}
+	return m
+}
+
+// Refresh reloads the flags from their source.
+// On error, the previous settings are kept.
+func (f *Flags) Refresh(ctx context.Context) error {
+	values, err := f.src.LoadFlags(ctx)
+	if err != nil {
+		return err
+	}
+	f.mu.Lock()
+	f.values = values
+	f.mu.Unlock()
+	return nil
+}
+
+// Set sets the named flag in the source and in f.
+// Other servers sharing the source see the change at their next refresh.
+func (f *Flags) Set(ctx context.Context, name string, enabled bool) error {
+	if err := f.src.SetFlag(ctx, name, enabled); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	values := make(map[string]bool, len(f.values)+1)
+	for k, v := range f.values {
+		values[k] = v
+	}
+	values[name] = enabled
+	f.values = values
+	return nil
+}
+
+// Handler returns a handler that lists the flags in response to GET
+// and sets a flag in response to a POST with form values name and enabled.
+// Requests must be authorized with token, as for AdminHandler.
+func (f *Flags) Handler(token string) http.Handler {
+	return AdminHandler(token, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET", "HEAD":
+		case "POST":
+			name := r.FormValue("name")
+			if name == "" {
+				http.Error(w, "missing flag name", http.StatusBadRequest)
+				return
+			}
+			enabled, err := strconv.ParseBool(r.FormValue("enabled"))
+			if err != nil {
+				http.Error(w, "enabled must be true or false", http.StatusBadRequest)
+				return
+			}
+			if err := f.Set(r.Context(), name, enabled); err != nil {
+				log.Printf("ERROR setting feature flag %s: %v", name, err)
+				code := http.StatusInternalServerError
+				if err == ErrFlagsReadOnly {
+					code = http.StatusConflict
+				}
+				http.Error(w, err.Error(), code)
+				return
+			}
+			log.Printf("feature flag %s set to %v by %s", name, enabled, clientip.FromRequest(r))
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", " ")
+		if err := enc.Encode(f.All()); err != nil {
+			log.Printf("ERROR rendering JSON for feature flags: %v", err)
+		}
+	}))
+}
+
+// FileFlags returns a read-only FlagSource that loads flags
+// from the YAML file at path, which maps flag names to booleans:
+//
+//	new-codewalk: true
+//	dl-unstable-channel: false
+//
+// The file is reread on every load, so editing it
+// takes effect at the next refresh.
+func FileFlags(path string) FlagSource {
+	return fileFlags(path)
+}
+
+type fileFlags string
+
+func (path fileFlags) LoadFlags(ctx context.Context) (map[string]bool, error) {
+	data, err := os.ReadFile(string(path))
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]bool)
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return values, nil
+}
+
+func (fileFlags) SetFlag(ctx context.Context, name string, enabled bool) error {
+	return ErrFlagsReadOnly
+}
+
+// DatastoreFlags returns a FlagSource that stores each flag
+// as a FeatureFlag entity in datastore, keyed by the flag name.
+func DatastoreFlags(client *datastore.Client) FlagSource {
+	return datastoreFlags{client}
+}
+
+type datastoreFlags struct {
+	client *datastore.Client
+}
+
+// featureFlag is the datastore entity holding a flag setting.
+type featureFlag struct {
+	Enabled bool
+	Updated time.Time
+}
+
+func (d datastoreFlags) LoadFlags(ctx context.Context) (map[string]bool, error) {
+	var flags []featureFlag
+	keys, err := d.client.GetAll(ctx, datastore.NewQuery("FeatureFlag"), &flags)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]bool, len(keys))
+	for i, k := range keys {
+		values[k.Name] = flags[i].Enabled
+	}
+	return values, nil
+}
+
+func (d datastoreFlags) SetFlag(ctx context.Context, name string, enabled bool) error {
+	k := datastore.NameKey("FeatureFlag", name, nil)
+	_, err := d.client.Put(ctx, k, &featureFlag{Enabled: enabled, Updated: time.Now()})
+	return err
+}
+
+var (
+	flagsMu sync.Mutex
+	flags   *Flags
+)
+
+// SetFlags sets the process-wide feature flags consulted by Enabled.
+func SetFlags(f *Flags) {
+	flagsMu.Lock()
+	defer flagsMu.Unlock()
+	flags = f
+}
+
+// Enabled reports whether the named process-wide feature flag is enabled.
+// All flags are disabled if SetFlags has not been called.
+func Enabled(name string) bool {
+	flagsMu.Lock()
+	f := flags
+	flagsMu.Unlock()
+	return f.Enabled(name)
+}
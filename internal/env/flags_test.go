@@ -0,0 +1,141 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package env
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// mapFlags is a FlagSource held in memory.
+type mapFlags struct {
+	values map[string]bool
+	err    error
+}
+
+func (m *mapFlags) LoadFlags(ctx context.Context) (map[string]bool, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	values := make(map[string]bool)
+	for k, v := range m.values {
+		values[k] = v
+	}
+	return values, nil
+}
+
+func (m *mapFlags) SetFlag(ctx context.Context, name string, enabled bool) error {
+	m.values[name] = enabled
+	return nil
+}
+
+func TestFlagsRefresh(t *testing.T) {
+	ctx := context.Background()
+	src := &mapFlags{values: map[string]bool{"a": true, "b": false}}
+	f := NewFlags(src)
+	if f.Enabled("a") {
+		t.Errorf("Enabled(a) before Refresh = true, want false")
+	}
+	if err := f.Refresh(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if !f.Enabled("a") || f.Enabled("b") || f.Enabled("c") {
+		t.Errorf("after Refresh, flags = %v", f.All())
+	}
+
+	src.values["b"] = true
+	src.err = errors.New("datastore down")
+	if err := f.Refresh(ctx); err == nil {
+		t.Errorf("Refresh succeeded with failing source")
+	}
+	if f.Enabled("b") {
+		t.Errorf("failed Refresh changed flags")
+	}
+	src.err = nil
+	if err := f.Refresh(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if !f.Enabled("b") {
+		t.Errorf("Refresh did not pick up change to b")
+	}
+
+	var nilFlags *Flags
+	if nilFlags.Enabled("a") {
+		t.Errorf("nil Flags has a enabled")
+	}
+}
+
+func TestFileFlags(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "flags.yaml")
+	if err := os.WriteFile(path, []byte("new-codewalk: true\nold: false\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	f := NewFlags(FileFlags(path))
+	if err := f.Refresh(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if !f.Enabled("new-codewalk") || f.Enabled("old") {
+		t.Errorf("flags = %v", f.All())
+	}
+	if err := f.Set(ctx, "old", true); err != ErrFlagsReadOnly {
+		t.Errorf("Set = %v, want ErrFlagsReadOnly", err)
+	}
+}
+
+func TestFlagsHandler(t *testing.T) {
+	src := &mapFlags{values: map[string]bool{"a": true}}
+	f := NewFlags(src)
+	if err := f.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	h := f.Handler("secret")
+
+	var tests = []struct {
+		method string
+		auth   string
+		form   url.Values
+		code   int
+		body   string
+	}{
+		{"GET", "", nil, http.StatusUnauthorized, "unauthorized"},
+		{"GET", "Bearer wrong", nil, http.StatusUnauthorized, "unauthorized"},
+		{"GET", "Bearer secret", nil, http.StatusOK, `"a": true`},
+		{"POST", "Bearer secret", url.Values{"name": {"b"}, "enabled": {"true"}}, http.StatusOK, `"b": true`},
+		{"POST", "Bearer secret", url.Values{"name": {"a"}, "enabled": {"false"}}, http.StatusOK, `"a": false`},
+		{"POST", "Bearer secret", url.Values{"name": {"a"}, "enabled": {"sure"}}, http.StatusBadRequest, "true or false"},
+		{"POST", "Bearer secret", url.Values{"enabled": {"true"}}, http.StatusBadRequest, "missing flag name"},
+		{"DELETE", "Bearer secret", nil, http.StatusMethodNotAllowed, "method not allowed"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(tt.method, "/_flags", strings.NewReader(tt.form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if tt.auth != "" {
+			r.Header.Set("Authorization", tt.auth)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != tt.code || !strings.Contains(w.Body.String(), tt.body) {
+			t.Errorf("%s %v with %q: %d %q, want %d containing %q", tt.method, tt.form, tt.auth, w.Code, w.Body, tt.code, tt.body)
+		}
+	}
+	if src.values["a"] || !src.values["b"] {
+		t.Errorf("source flags = %v, want a=false b=true", src.values)
+	}
+
+	r := httptest.NewRequest("GET", "/_flags", nil)
+	w := httptest.NewRecorder()
+	f.Handler("").ServeHTTP(w, r)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("handler with empty token: %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+}
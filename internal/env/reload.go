@@ -0,0 +1,117 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package env
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/matttproud/yourtour/internal/errreport"
+)
+
+// ReloadInterval is how often WatchReload checks
+// whether the configuration file has changed.
+const ReloadInterval = 10 * time.Second
+
+var (
+	subMu   sync.Mutex
+	subs    = make(map[int]func(*Config))
+	nextSub int
+)
+
+// Subscribe arranges for f to be called with the new configuration
+// after each successful Reload, so that subsystems can apply changes
+// without a restart. It returns a function that cancels the subscription.
+//
+// Subscribers are called one at a time, in no particular order,
+// and must not call Subscribe or Reload.
+func Subscribe(f func(*Config)) (cancel func()) {
+	subMu.Lock()
+	defer subMu.Unlock()
+	id := nextSub
+	nextSub++
+	subs[id] = f
+	return func() {
+		subMu.Lock()
+		defer subMu.Unlock()
+		delete(subs, id)
+	}
+}
+
+// Reload loads the configuration from path and the environment, as Load does,
+// makes it the process-wide configuration, and notifies subscribers.
+// If the new configuration is invalid, Reload keeps the current one
+// and returns the error.
+//
+// Settings read only at startup, such as DevMode and Port,
+// take effect at the next restart.
+func Reload(path string) error {
+	c, err := Load(path)
+	if err != nil {
+		return err
+	}
+	subMu.Lock()
+	defer subMu.Unlock()
+	Set(c)
+	for _, f := range subs {
+		f(c)
+	}
+	return nil
+}
+
+// WatchReload calls Reload(path) when the process receives SIGHUP,
+// and, if path is not empty, when the file's modification time changes,
+// checking every interval. It logs the outcome of each reload
+// and returns when ctx is done.
+func WatchReload(ctx context.Context, path string, interval time.Duration) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	mtime := modTime(path)
+
+	reload := func(why string) {
+		if err := Reload(path); err != nil {
+			log.Printf("ERROR reloading configuration after %s: %v", why, err)
+			errreport.Job("reloading configuration", err)
+			return
+		}
+		log.Printf("reloaded configuration after %s", why)
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			mtime = modTime(path)
+			reload("SIGHUP")
+		case <-t.C:
+			if m := modTime(path); !m.Equal(mtime) {
+				mtime = m
+				reload("change to " + path)
+			}
+		}
+	}
+}
+
+// modTime returns the modification time of the file at path,
+// or the zero time if path is empty or the file cannot be read.
+func modTime(path string) time.Time {
+	if path == "" {
+		return time.Time{}
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return fi.ModTime()
+}
@@ -0,0 +1,105 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package env
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReload(t *testing.T) {
+	defer Set(nil)
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(s string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(s), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got := make(chan *Config, 10)
+	cancel := Subscribe(func(c *Config) { got <- c })
+	defer cancel()
+
+	write("analytics: UA-1\n")
+	if err := Reload(path); err != nil {
+		t.Fatal(err)
+	}
+	if c := <-got; c.Analytics != "UA-1" {
+		t.Errorf("subscriber got Analytics %q, want UA-1", c.Analytics)
+	}
+	if a := Get().Analytics; a != "UA-1" {
+		t.Errorf("Get().Analytics = %q, want UA-1", a)
+	}
+
+	write("cache_backend: memcached\n")
+	if err := Reload(path); err == nil {
+		t.Errorf("Reload of invalid configuration succeeded")
+	}
+	if a := Get().Analytics; a != "UA-1" {
+		t.Errorf("after failed Reload, Get().Analytics = %q, want UA-1", a)
+	}
+	if len(got) != 0 {
+		t.Errorf("subscriber notified of failed Reload")
+	}
+
+	cancel()
+	write("analytics: UA-2\n")
+	if err := Reload(path); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 0 {
+		t.Errorf("canceled subscriber notified")
+	}
+}
+
+func TestWatchReload(t *testing.T) {
+	defer Set(nil)
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("analytics: UA-1\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	got := make(chan *Config, 10)
+	defer Subscribe(func(c *Config) { got <- c })()
+
+	ctx, stop := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		WatchReload(ctx, path, 10*time.Millisecond)
+		close(done)
+	}()
+	defer func() {
+		stop()
+		<-done
+	}()
+
+	if err := os.WriteFile(path, []byte("analytics: UA-2\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	// WatchReload may not have recorded the original modification time yet,
+	// so keep changing it until the reload happens.
+	tick := time.NewTicker(20 * time.Millisecond)
+	defer tick.Stop()
+	timeout := time.After(5 * time.Second)
+	for i := 1; ; i++ {
+		select {
+		case c := <-got:
+			if c.Analytics != "UA-2" {
+				t.Errorf("reloaded Analytics = %q, want UA-2", c.Analytics)
+			}
+			return
+		case <-timeout:
+			t.Fatal("configuration not reloaded after file change")
+		case <-tick.C:
+			mtime := time.Now().Add(time.Duration(i) * time.Hour)
+			if err := os.Chtimes(path, mtime, mtime); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,223 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package env
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/api/googleapi"
+	secretmanager "google.golang.org/api/secretmanager/v1"
+)
+
+// DefaultSecretTTL is how long CachedSecrets keeps a secret
+// before fetching it again to pick up rotations.
+const DefaultSecretTTL = 10 * time.Minute
+
+// ErrSecretNotFound is returned by Secrets that do not hold the named secret.
+var ErrSecretNotFound = errors.New("secret not found")
+
+// Secrets provides secret values, such as keys, by name.
+// Names are lower-case words separated by dashes, like "dl-builder-key".
+type Secrets interface {
+	Secret(ctx context.Context, name string) (string, error)
+}
+
+// EnvSecrets returns Secrets read from environment variables.
+// The variable for a secret is prefix followed by the secret name
+// in upper case with dashes replaced by underscores:
+// with prefix "GOLANGORG_SECRET_", "dl-builder-key"
+// is read from $GOLANGORG_SECRET_DL_BUILDER_KEY.
+func EnvSecrets(prefix string) Secrets {
+	return envSecrets{prefix, os.LookupEnv}
+}
+
+type envSecrets struct {
+	prefix string
+	lookup func(string) (string, bool)
+}
+
+func (e envSecrets) Secret(ctx context.Context, name string) (string, error) {
+	v, ok := e.lookup(e.prefix + strings.ToUpper(strings.ReplaceAll(name, "-", "_")))
+	if !ok || v == "" {
+		return "", ErrSecretNotFound
+	}
+	return v, nil
+}
+
+// FileSecrets returns Secrets read from files in dir,
+// one secret per file named after the secret,
+// as when secrets are mounted as a volume.
+// A trailing newline in a file is ignored.
+func FileSecrets(dir string) Secrets {
+	return fileSecrets(dir)
+}
+
+type fileSecrets string
+
+func (dir fileSecrets) Secret(ctx context.Context, name string) (string, error) {
+	if !validSecretName(name) {
+		return "", fmt.Errorf("invalid secret name %q", name)
+	}
+	data, err := os.ReadFile(filepath.Join(string(dir), name))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", ErrSecretNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(string(data), "\n"), nil
+}
+
+// validSecretName reports whether name is a well-formed secret name,
+// so that it cannot name a file outside a FileSecrets directory.
+func validSecretName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, c := range name {
+		if !('a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// SecretManager returns Secrets read from the latest version
+// of the secrets in Google Secret Manager in the given project.
+func SecretManager(ctx context.Context, project string) (Secrets, error) {
+	svc, err := secretmanager.NewService(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &secretManager{svc, project}, nil
+}
+
+type secretManager struct {
+	svc     *secretmanager.Service
+	project string
+}
+
+func (s *secretManager) Secret(ctx context.Context, name string) (string, error) {
+	if !validSecretName(name) {
+		return "", fmt.Errorf("invalid secret name %q", name)
+	}
+	path := "projects/" + s.project + "/secrets/" + name + "/versions/latest"
+	resp, err := s.svc.Projects.Secrets.Versions.Access(path).Context(ctx).Do()
+	if err != nil {
+		var gerr *googleapi.Error
+		if errors.As(err, &gerr) && gerr.Code == http.StatusNotFound {
+			return "", ErrSecretNotFound
+		}
+		return "", err
+	}
+	data, err := base64.StdEncoding.DecodeString(resp.Payload.Data)
+	if err != nil {
+		return "", fmt.Errorf("secret %s: %v", name, err)
+	}
+	return string(data), nil
+}
+
+// FirstSecrets returns Secrets that looks up each secret in list in turn,
+// returning the first value found.
+func FirstSecrets(list ...Secrets) Secrets {
+	return firstSecrets(list)
+}
+
+type firstSecrets []Secrets
+
+func (list firstSecrets) Secret(ctx context.Context, name string) (string, error) {
+	for _, s := range list {
+		v, err := s.Secret(ctx, name)
+		if err != ErrSecretNotFound {
+			return v, err
+		}
+	}
+	return "", ErrSecretNotFound
+}
+
+// CachedSecrets returns Secrets that cache the secrets read from s for ttl,
+// so that rotated values are picked up within ttl of the rotation.
+// If ttl is zero, it is DefaultSecretTTL.
+// If fetching a rotated value fails, the cached value remains in use
+// until a fetch succeeds.
+func CachedSecrets(s Secrets, ttl time.Duration) Secrets {
+	if ttl <= 0 {
+		ttl = DefaultSecretTTL
+	}
+	return &cachedSecrets{s: s, ttl: ttl, now: time.Now, cache: make(map[string]cachedSecret)}
+}
+
+type cachedSecrets struct {
+	s   Secrets
+	ttl time.Duration
+	now func() time.Time
+
+	mu    sync.Mutex
+	cache map[string]cachedSecret
+}
+
+type cachedSecret struct {
+	value   string
+	fetched time.Time
+}
+
+func (c *cachedSecrets) Secret(ctx context.Context, name string) (string, error) {
+	c.mu.Lock()
+	cached, ok := c.cache[name]
+	c.mu.Unlock()
+	if ok && c.now().Sub(cached.fetched) < c.ttl {
+		return cached.value, nil
+	}
+
+	v, err := c.s.Secret(ctx, name)
+	if err != nil {
+		if ok && err != ErrSecretNotFound {
+			log.Printf("ERROR refreshing secret %s, using cached value: %v", name, err)
+			return cached.value, nil
+		}
+		return "", err
+	}
+	c.mu.Lock()
+	c.cache[name] = cachedSecret{v, c.now()}
+	c.mu.Unlock()
+	return v, nil
+}
+
+var (
+	secretsMu sync.Mutex
+	secrets   Secrets
+)
+
+// SetSecrets sets the process-wide Secrets returned by GetSecrets.
+func SetSecrets(s Secrets) {
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+	secrets = s
+}
+
+// GetSecrets returns the process-wide Secrets.
+// If SetSecrets has not been called, secrets are read
+// from environment variables prefixed with GOLANGORG_SECRET_.
+func GetSecrets() Secrets {
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+	if secrets == nil {
+		secrets = EnvSecrets(SecretEnvPrefix)
+	}
+	return secrets
+}
+
+// SecretEnvPrefix is the prefix of environment variables holding secrets.
+const SecretEnvPrefix = "GOLANGORG_SECRET_"
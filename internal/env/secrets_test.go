@@ -0,0 +1,128 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package env
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestEnvSecrets(t *testing.T) {
+	s := envSecrets{"GOLANGORG_SECRET_", func(name string) (string, bool) {
+		if name == "GOLANGORG_SECRET_DL_BUILDER_KEY" {
+			return "shh", true
+		}
+		return "", false
+	}}
+	ctx := context.Background()
+	if v, err := s.Secret(ctx, "dl-builder-key"); v != "shh" || err != nil {
+		t.Errorf("Secret(dl-builder-key) = %q, %v, want %q, nil", v, err, "shh")
+	}
+	if _, err := s.Secret(ctx, "other"); err != ErrSecretNotFound {
+		t.Errorf("Secret(other) error = %v, want ErrSecretNotFound", err)
+	}
+}
+
+func TestFileSecrets(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "dl-builder-key"), []byte("shh\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	s := FileSecrets(dir)
+	ctx := context.Background()
+	if v, err := s.Secret(ctx, "dl-builder-key"); v != "shh" || err != nil {
+		t.Errorf("Secret(dl-builder-key) = %q, %v, want %q, nil", v, err, "shh")
+	}
+	if _, err := s.Secret(ctx, "missing"); err != ErrSecretNotFound {
+		t.Errorf("Secret(missing) error = %v, want ErrSecretNotFound", err)
+	}
+	if _, err := s.Secret(ctx, "../dl-builder-key"); err == nil || err == ErrSecretNotFound {
+		t.Errorf("Secret(../dl-builder-key) error = %v, want invalid name", err)
+	}
+}
+
+// countingSecrets is a Secrets held in memory that counts lookups.
+type countingSecrets struct {
+	values map[string]string
+	err    error
+	calls  int
+}
+
+func (c *countingSecrets) Secret(ctx context.Context, name string) (string, error) {
+	c.calls++
+	if c.err != nil {
+		return "", c.err
+	}
+	v, ok := c.values[name]
+	if !ok {
+		return "", ErrSecretNotFound
+	}
+	return v, nil
+}
+
+func TestFirstSecrets(t *testing.T) {
+	a := &countingSecrets{values: map[string]string{"x": "a"}}
+	b := &countingSecrets{values: map[string]string{"x": "b", "y": "b"}}
+	s := FirstSecrets(a, b)
+	ctx := context.Background()
+	for _, tt := range []struct{ name, want string }{{"x", "a"}, {"y", "b"}} {
+		if v, err := s.Secret(ctx, tt.name); v != tt.want || err != nil {
+			t.Errorf("Secret(%s) = %q, %v, want %q, nil", tt.name, v, err, tt.want)
+		}
+	}
+	if _, err := s.Secret(ctx, "z"); err != ErrSecretNotFound {
+		t.Errorf("Secret(z) error = %v, want ErrSecretNotFound", err)
+	}
+
+	a.err = errors.New("unavailable")
+	if _, err := s.Secret(ctx, "y"); err != a.err {
+		t.Errorf("Secret(y) with failing first source: error = %v, want %v", err, a.err)
+	}
+}
+
+func TestCachedSecrets(t *testing.T) {
+	src := &countingSecrets{values: map[string]string{"key": "v1"}}
+	c := CachedSecrets(src, time.Minute).(*cachedSecrets)
+	now := time.Unix(1e9, 0)
+	c.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	check := func(want string, calls int) {
+		t.Helper()
+		v, err := c.Secret(ctx, "key")
+		if v != want || err != nil {
+			t.Errorf("Secret = %q, %v, want %q, nil", v, err, want)
+		}
+		if src.calls != calls {
+			t.Errorf("source called %d times, want %d", src.calls, calls)
+		}
+	}
+	check("v1", 1)
+	check("v1", 1)
+
+	// Rotation is picked up once the cache expires.
+	src.values["key"] = "v2"
+	now = now.Add(30 * time.Second)
+	check("v1", 1)
+	now = now.Add(time.Minute)
+	check("v2", 2)
+
+	// A failed refresh keeps the cached value.
+	src.err = errors.New("unavailable")
+	now = now.Add(2 * time.Minute)
+	check("v2", 3)
+
+	// A deleted secret is reported, not served from cache.
+	src.err = nil
+	delete(src.values, "key")
+	now = now.Add(2 * time.Minute)
+	if _, err := c.Secret(ctx, "key"); err != ErrSecretNotFound {
+		t.Errorf("Secret after deletion: error = %v, want ErrSecretNotFound", err)
+	}
+}
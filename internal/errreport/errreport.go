@@ -0,0 +1,142 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package errreport sends server errors to an error-reporting service,
+// so that errors in production are aggregated rather than only logged.
+//
+// The server installs a Reporter with Set. Error pages report with
+// Request, panic recovery with Panic, and background jobs with Job;
+// until a Reporter is set, reports are discarded.
+// Reports are scrubbed of personal information and secrets,
+// such as email and IP addresses and tokens, before they are sent.
+package errreport
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/matttproud/yourtour/internal/reqlog"
+)
+
+// A Report describes one error.
+type Report struct {
+	Time    time.Time `json:"time"`
+	Message string    `json:"message"`
+	Stack   string    `json:"stack,omitempty"`
+	Panic   bool      `json:"panic,omitempty"`
+
+	// Job names the background job that failed,
+	// for errors not arising from a request.
+	Job string `json:"job,omitempty"`
+
+	// The request being served, for errors arising from one.
+	RequestID string `json:"request_id,omitempty"`
+	Method    string `json:"method,omitempty"`
+	URL       string `json:"url,omitempty"`
+	UserAgent string `json:"user_agent,omitempty"`
+	Status    int    `json:"status,omitempty"`
+}
+
+// A Reporter sends reports to an error-reporting service.
+// Report is called while serving requests, so it must not block.
+type Reporter interface {
+	Report(rep *Report)
+}
+
+var (
+	mu       sync.RWMutex
+	reporter Reporter
+	rate     = 1.0
+)
+
+// Set installs r as the Reporter for future reports,
+// sending the fraction sampleRate of errors other than panics,
+// which are always sent. If r is nil, reports are discarded.
+func Set(r Reporter, sampleRate float64) {
+	mu.Lock()
+	defer mu.Unlock()
+	reporter, rate = r, sampleRate
+}
+
+// ParseRate parses a sample rate: a fraction between 0 and 1,
+// such as 0.25, or a percentage, such as 25%.
+// The empty string means 1, reporting every error.
+func ParseRate(s string) (float64, error) {
+	if s == "" {
+		return 1, nil
+	}
+	num, pct := strings.CutSuffix(s, "%")
+	f, err := strconv.ParseFloat(num, 64)
+	if pct {
+		f /= 100
+	}
+	if err != nil || f < 0 || f > 1 {
+		return 0, fmt.Errorf("invalid sample rate %q; want a fraction like 0.25 or a percentage like 25%%", s)
+	}
+	return f, nil
+}
+
+// send scrubs rep and sends it to the current Reporter, if any,
+// subject to sampling unless always is set.
+func send(rep *Report, always bool) {
+	mu.RLock()
+	r, f := reporter, rate
+	mu.RUnlock()
+	if r == nil || !always && rand.Float64() >= f {
+		return
+	}
+	rep.Time = time.Now().UTC()
+	rep.Message = Scrub(rep.Message)
+	rep.Stack = Scrub(rep.Stack)
+	rep.URL = Scrub(rep.URL)
+	r.Report(rep)
+}
+
+// Request reports err, which caused the reply with the given status to r.
+func Request(r *http.Request, err error, status int) {
+	rep := requestReport(r)
+	rep.Message = err.Error()
+	rep.Status = status
+	send(rep, false)
+}
+
+// Panic reports the panic with value v and stack trace stack
+// that happened while serving r.
+func Panic(r *http.Request, v any, stack []byte) {
+	rep := requestReport(r)
+	rep.Message = fmt.Sprint(v)
+	rep.Stack = string(stack)
+	rep.Panic = true
+	rep.Status = http.StatusInternalServerError
+	send(rep, true)
+}
+
+// Job reports err, a failure of the named background job.
+func Job(name string, err error) {
+	send(&Report{Job: name, Message: err.Error()}, false)
+}
+
+// JobPanic reports the panic with value v and stack trace stack
+// that happened in the named background job.
+func JobPanic(name string, v any, stack []byte) {
+	send(&Report{Job: name, Message: fmt.Sprint(v), Stack: string(stack), Panic: true}, true)
+}
+
+func requestReport(r *http.Request) *Report {
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" || r.URL.Scheme == "https" {
+		scheme = "https"
+	}
+	return &Report{
+		RequestID: reqlog.ID(r.Context()),
+		Method:    r.Method,
+		URL:       scheme + "://" + r.Host + r.URL.RequestURI(),
+		UserAgent: r.UserAgent(),
+	}
+}
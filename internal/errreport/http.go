@@ -0,0 +1,107 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package errreport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// queueSize is the number of reports an HTTPReporter holds
+// while waiting to send them. Reports beyond that are dropped,
+// so that an outage of the service does not back up the server.
+const queueSize = 100
+
+// An HTTPReporter is a Reporter that posts each report as JSON
+// to an HTTP endpoint, such as a collector for an error-reporting service.
+// Reports are sent one at a time in the background.
+type HTTPReporter struct {
+	url    string
+	client *http.Client
+	queue  chan *Report
+	done   chan struct{}
+
+	mu     sync.Mutex
+	closed bool
+}
+
+// NewHTTP returns an HTTPReporter posting reports to endpoint.
+func NewHTTP(endpoint string) *HTTPReporter {
+	h := &HTTPReporter{
+		url:    endpoint,
+		client: &http.Client{Timeout: 10 * time.Second},
+		queue:  make(chan *Report, queueSize),
+		done:   make(chan struct{}),
+	}
+	go h.run()
+	return h
+}
+
+// Report queues rep to be sent, dropping it if the queue is full
+// or h has been closed.
+func (h *HTTPReporter) Report(rep *Report) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return
+	}
+	select {
+	case h.queue <- rep:
+	default:
+		log.Printf("ERROR error report queue full; dropping report: %s", rep.Message)
+	}
+}
+
+// Close stops accepting reports and waits until the queued ones
+// have been sent or ctx is done.
+func (h *HTTPReporter) Close(ctx context.Context) error {
+	h.mu.Lock()
+	if !h.closed {
+		h.closed = true
+		close(h.queue)
+	}
+	h.mu.Unlock()
+	select {
+	case <-h.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (h *HTTPReporter) run() {
+	defer close(h.done)
+	for rep := range h.queue {
+		if err := h.send(rep); err != nil {
+			log.Printf("ERROR sending error report: %v", err)
+		}
+	}
+}
+
+func (h *HTTPReporter) send(rep *Report) error {
+	data, err := json.Marshal(rep)
+	if err != nil {
+		return err
+	}
+	resp, err := h.client.Post(h.url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		if uerr, ok := err.(*url.Error); ok {
+			err = uerr.Err // omit the URL, which may hold a key
+		}
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("%s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,235 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package etag validates responses with entity tags
+// derived from the version of the content they are built from.
+//
+// Pages rendered from the site content change only when the content does:
+// when a new binary embedding it is deployed, new content is deployed
+// to the running server, or a file in a content directory served live
+// is edited. A Version combines the versions of each such source,
+// and Handler tags every reply with it, answering a request from
+// a client that already holds the current version with 304 Not Modified.
+package etag
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"path/filepath"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A Version is the version of content built from several sources,
+// each identified by name. It is safe for concurrent use.
+// The zero Version has no sources and is unknown.
+type Version struct {
+	mu    sync.Mutex
+	parts map[string]func() string
+}
+
+// Set records version as the version of the named source.
+func (v *Version) Set(name, version string) {
+	v.SetFunc(name, func() string { return version })
+}
+
+// SetFunc records that f returns the version of the named source,
+// for sources that change on their own, such as directories served live.
+func (v *Version) SetFunc(name string, f func() string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.parts == nil {
+		v.parts = make(map[string]func() string)
+	}
+	v.parts[name] = f
+}
+
+// String returns the combined version of all sources,
+// or the empty string if any source's version is unknown,
+// meaning that it returned the empty string,
+// or there are no sources.
+func (v *Version) String() string {
+	v.mu.Lock()
+	parts := make(map[string]func() string, len(v.parts))
+	for name, f := range v.parts {
+		parts[name] = f
+	}
+	v.mu.Unlock()
+	if len(parts) == 0 {
+		return ""
+	}
+	var names []string
+	for name := range parts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	h := sha256.New()
+	for _, name := range names {
+		version := parts[name]()
+		if version == "" {
+			return ""
+		}
+		fmt.Fprintf(h, "%s=%s\n", name, version)
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))[:20]
+}
+
+// BuildVersion returns the version control revision
+// the running binary was built from, for identifying embedded content,
+// or the empty string if the revision is unknown or the build
+// included uncommitted changes.
+func BuildVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	var rev string
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			rev = s.Value
+		case "vcs.modified":
+			if s.Value == "true" {
+				return ""
+			}
+		}
+	}
+	return rev
+}
+
+// dirVersionTTL is how long DirVersion's result is reused
+// before the directory is walked again.
+// It bounds how long an edit goes unnoticed.
+const dirVersionTTL = 5 * time.Second
+
+// DirVersion returns a function reporting the version of the files
+// in the directory dir, which is served live, by fingerprinting their
+// names, sizes, and modification times. The function returns
+// the empty string if dir cannot be read.
+func DirVersion(dir string) func() string {
+	var (
+		mu      sync.Mutex
+		version string
+		checked time.Time
+	)
+	return func() string {
+		mu.Lock()
+		defer mu.Unlock()
+		if now := time.Now(); now.Sub(checked) >= dirVersionTTL {
+			version = fingerprint(dir)
+			checked = now
+		}
+		return version
+	}
+}
+
+// fingerprint returns a digest of the names, sizes,
+// and modification times of the files in dir.
+func fingerprint(dir string) string {
+	h := sha256.New()
+	err := filepath.WalkDir(dir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(h, "%s %d %d\n", filepath.ToSlash(name), info.Size(), info.ModTime().UnixNano())
+		return nil
+	})
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// Handler returns a handler that serves requests with h,
+// tagging each successful reply to a GET or HEAD request
+// with an ETag derived from version(). A request whose If-None-Match
+// header lists the current tag is answered with 304 Not Modified
+// without calling h. If version returns the empty string,
+// replies are not tagged.
+//
+// Handler is for replies derived only from content that version identifies
+// and from the request URL. Compressing the reply later weakens the tag,
+// which still matches, since If-None-Match uses weak comparison.
+func Handler(h http.Handler, version func() string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" && r.Method != "HEAD" {
+			h.ServeHTTP(w, r)
+			return
+		}
+		v := version()
+		if v == "" {
+			h.ServeHTTP(w, r)
+			return
+		}
+		tag := `"` + v + `"`
+		w.Header().Set("ETag", tag)
+		if match(r.Header.Get("If-None-Match"), tag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		// Setting the tag before calling h lets http.FileServer
+		// compare it with If-Range for range requests.
+		h.ServeHTTP(&writer{ResponseWriter: w}, r)
+	})
+}
+
+// match reports whether the If-None-Match header value list
+// matches tag, using weak comparison.
+func match(list, tag string) bool {
+	list = strings.TrimSpace(list)
+	if list == "*" {
+		return true
+	}
+	for _, t := range strings.Split(list, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(t), "W/") == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// A writer removes the ETag from unsuccessful replies,
+// which are not derived from the tagged content.
+type writer struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (w *writer) WriteHeader(code int) {
+	if !w.wroteHeader && code >= 200 {
+		w.wroteHeader = true
+		switch code {
+		case http.StatusOK, http.StatusPartialContent, http.StatusNotModified:
+		default:
+			w.Header().Del("ETag")
+		}
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *writer) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *writer) Flush() {
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap returns the underlying ResponseWriter,
+// for use by http.ResponseController.
+func (w *writer) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
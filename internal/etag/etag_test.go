@@ -0,0 +1,118 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package etag
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestVersion(t *testing.T) {
+	var v Version
+	if s := v.String(); s != "" {
+		t.Errorf("zero Version = %q, want empty", s)
+	}
+	v.Set("content", "abc")
+	v1 := v.String()
+	if v1 == "" {
+		t.Fatalf("Version with content = empty")
+	}
+	v.Set("overlay", "def")
+	v2 := v.String()
+	if v2 == "" || v2 == v1 {
+		t.Errorf("Version with overlay = %q, want new version", v2)
+	}
+	v.Set("overlay", "")
+	if s := v.String(); s != "" {
+		t.Errorf("Version with unknown overlay = %q, want empty", s)
+	}
+	v.Set("overlay", "def")
+	if s := v.String(); s != v2 {
+		t.Errorf("Version = %q, want %q again", s, v2)
+	}
+}
+
+func TestDirVersion(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "index.md")
+	if err := os.WriteFile(file, []byte("old"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	old := fingerprint(dir)
+	if old == "" {
+		t.Fatalf("fingerprint(%s) = empty", dir)
+	}
+	if err := os.WriteFile(file, []byte("newer"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if v := fingerprint(dir); v == old {
+		t.Errorf("fingerprint after edit = %q, unchanged", v)
+	}
+	if v := fingerprint(filepath.Join(dir, "missing")); v != "" {
+		t.Errorf("fingerprint(missing) = %q, want empty", v)
+	}
+
+	f := DirVersion(dir)
+	v1 := f()
+	if err := os.Chtimes(file, time.Time{}, time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if v := f(); v != v1 {
+		t.Errorf("DirVersion rewalked within %v", dirVersionTTL)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	version := "v1"
+	h := Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/missing":
+			http.NotFound(w, r)
+		default:
+			w.Write([]byte("page"))
+		}
+	}), func() string { return version })
+
+	tests := []struct {
+		method, path, ifNoneMatch string
+		code                      int
+		etag                      string
+	}{
+		{"GET", "/", "", 200, `"v1"`},
+		{"HEAD", "/", "", 200, `"v1"`},
+		{"GET", "/", `"v1"`, 304, `"v1"`},
+		{"GET", "/", `W/"v1"`, 304, `"v1"`},
+		{"GET", "/", `"v0", "v1"`, 304, `"v1"`},
+		{"GET", "/", `*`, 304, `"v1"`},
+		{"GET", "/", `"v0"`, 200, `"v1"`},
+		{"POST", "/", `"v1"`, 200, ""},
+		{"GET", "/missing", "", 404, ""},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(tt.method, tt.path, nil)
+		if tt.ifNoneMatch != "" {
+			r.Header.Set("If-None-Match", tt.ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != tt.code || w.Header().Get("ETag") != tt.etag {
+			t.Errorf("%s %s If-None-Match %s: %d ETag %q, want %d %q",
+				tt.method, tt.path, tt.ifNoneMatch, w.Code, w.Header().Get("ETag"), tt.code, tt.etag)
+		}
+	}
+
+	version = ""
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("If-None-Match", "*")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != 200 || w.Header().Get("ETag") != "" {
+		t.Errorf("GET / with unknown version: %d ETag %q, want 200 untagged", w.Code, w.Header().Get("ETag"))
+	}
+}
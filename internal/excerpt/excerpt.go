@@ -0,0 +1,184 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package excerpt resolves addresses of excerpts of text files,
+// such as the lines of code a codewalk step is about,
+// and serves the excerpts of a site's files at /api/excerpt,
+// for pages showing code from the content, such as tour lessons.
+//
+// Addresses use the syntax of the addresses of the sam editor:
+// see https://9p.io/sys/doc/sam/sam.html Table II. For example,
+// /func main/ addresses the first line mentioning func main,
+// 12,20 addresses lines 12 through 20, and /func main/,/\n}/
+// addresses the lines from func main to the next closing brace
+// at the start of a line.
+package excerpt
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/matttproud/yourtour/internal/router"
+	"github.com/matttproud/yourtour/internal/texthtml"
+	"github.com/matttproud/yourtour/internal/vhost"
+	"github.com/matttproud/yourtour/internal/web"
+)
+
+// Path is the URL path of the excerpt API.
+const Path = "/api/excerpt"
+
+// maxAddr is the length of the longest address resolved.
+const maxAddr = 1000
+
+// Lines returns the numbers of the first and last lines of data
+// containing the text that addr selects. Line numbers begin at 1.
+func Lines(data []byte, addr string) (lo, hi int, err error) {
+	b0, b1, err := addrToByteRange(addr, 0, data)
+	if err != nil {
+		return 0, 0, err
+	}
+	// Expand match to line boundaries.
+	for b0 > 0 && data[b0-1] != '\n' {
+		b0--
+	}
+	for b1 < len(data) && (b1 == 0 || data[b1-1] != '\n') {
+		b1++
+	}
+	return byteToLine(data, b0), byteToLine(data, b1-1), nil
+}
+
+// An Excerpt is the text of the lines of a file that an address selects.
+type Excerpt struct {
+	File string `json:"file"`           // name of the file in the site's file system
+	Addr string `json:"addr,omitempty"` // address, or empty for the whole file
+	Lo   int    `json:"lo"`             // first line, numbered from 1
+	Hi   int    `json:"hi"`             // last line
+	Text string `json:"text"`
+	HTML string `json:"html"` // text formatted as HTML, with line numbers and marked Go comments
+}
+
+// An AddrError reports an address that cannot be resolved in a file.
+type AddrError struct {
+	File string
+	Addr string
+	Err  error
+}
+
+func (e *AddrError) Error() string {
+	return fmt.Sprintf("%s: address %q: %v", e.File, e.Addr, e.Err)
+}
+
+func (e *AddrError) Unwrap() error { return e.Err }
+
+// Find returns the excerpt of the named file in fsys that addr selects,
+// or the whole file if addr is empty. If the address cannot be
+// resolved, the error is an *AddrError.
+func Find(fsys fs.FS, file, addr string) (*Excerpt, error) {
+	data, err := fs.ReadFile(fsys, file)
+	if err != nil {
+		return nil, err
+	}
+	lo, hi := 1, byteToLine(data, len(data)-1)
+	if addr != "" {
+		if lo, hi, err = Lines(data, addr); err != nil {
+			return nil, &AddrError{file, addr, err}
+		}
+	}
+	text := data[LineOffset(data, lo):LineOffset(data, hi+1)]
+	// Format numbers the empty line after a final newline too.
+	html := texthtml.Format(bytes.TrimSuffix(text, []byte("\n")), texthtml.Config{Line: lo, GoComments: path.Ext(file) == ".go"})
+	return &Excerpt{File: file, Addr: addr, Lo: lo, Hi: hi, Text: string(text), HTML: string(html)}, nil
+}
+
+// RegisterHandlers registers the excerpt API for the files of the
+// virtual host h, in h.FS, that its site serves to anyone,
+// leaving out those of its private pages and those in the
+// directories reserved, which other handlers own, such as quiz.Dir.
+func RegisterHandlers(h *vhost.Host, reserved ...string) {
+	s := &server{fsys: h.FS, site: h.Site, reserved: reserved}
+	h.Router.HandleFunc("GET", Path, s.serveHTTP)
+	h.Router.Document("GET", Path, router.Doc{
+		Summary: "Returns the lines of a text file of the site that an address selects, as text and as HTML.",
+		Params: []router.Param{
+			{Name: "file", Description: "the name of the file, such as doc/codewalk/urlpoll.go", Required: true},
+			{Name: "addr", Description: "the address of the lines, as in codewalks, such as /func main/ or 12,20; default the whole file"},
+		},
+		Example: &Excerpt{
+			File: "doc/codewalk/pig.go", Addr: "/win  /", Lo: 13, Hi: 13,
+			Text: "\twin            = 100 // The winning score in a game of Pig\n",
+			HTML: `<span id="L13" class="ln">    13&nbsp;&nbsp;</span>` + "\twin            = 100 " +
+				`<span class="comment">// The winning score in a game of Pig</span>` + "\n",
+		},
+	})
+}
+
+type server struct {
+	fsys     fs.FS
+	site     *web.Site
+	reserved []string // directories whose files are not served
+}
+
+func (s *server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	file := strings.TrimPrefix(r.FormValue("file"), "/")
+	addr := r.FormValue("addr")
+	switch {
+	case file == "":
+		http.Error(w, "missing file", http.StatusBadRequest)
+		return
+	case len(addr) > maxAddr:
+		http.Error(w, "address too long", http.StatusBadRequest)
+		return
+	case !fs.ValidPath(file) || hidden(file) || s.isReserved(file) ||
+		s.site.Private("/"+file) || !s.site.ServesFile(file):
+		http.Error(w, "file not found", http.StatusNotFound)
+		return
+	}
+	e, err := Find(s.fsys, file, addr)
+	var aerr *AddrError
+	switch {
+	case errors.As(err, &aerr):
+		http.Error(w, "invalid address: "+aerr.Err.Error(), http.StatusBadRequest)
+		return
+	case err != nil:
+		// Directories and missing files alike.
+		http.Error(w, "file not found", http.StatusNotFound)
+		return
+	case !utf8.ValidString(e.Text) || strings.ContainsRune(e.Text, 0):
+		http.Error(w, "not a text file", http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", " ")
+	if err := enc.Encode(e); err != nil {
+		log.Printf("ERROR encoding excerpt of %s: %v", file, err)
+	}
+}
+
+// isReserved reports whether the named file is in one of s.reserved.
+func (s *server) isReserved(file string) bool {
+	return slices.ContainsFunc(s.reserved, func(dir string) bool {
+		return strings.HasPrefix(file, dir+"/")
+	})
+}
+
+// hidden reports whether the named file, or a directory containing it,
+// is hidden, with a name beginning with a dot.
+func hidden(file string) bool {
+	for _, elem := range strings.Split(file, "/") {
+		if strings.HasPrefix(elem, ".") {
+			return true
+		}
+	}
+	return false
+}
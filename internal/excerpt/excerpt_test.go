@@ -0,0 +1,131 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package excerpt
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/matttproud/yourtour/internal/router"
+	"github.com/matttproud/yourtour/internal/vhost"
+	"github.com/matttproud/yourtour/internal/web"
+)
+
+const prog = `package main
+
+import "fmt"
+
+// main says hello.
+func main() {
+	fmt.Println("hello")
+}
+`
+
+func TestLines(t *testing.T) {
+	for _, tt := range []struct {
+		addr   string
+		lo, hi int
+		err    string
+	}{
+		{addr: "3", lo: 3, hi: 3},
+		{addr: "3,5", lo: 3, hi: 5},
+		{addr: "/func main/", lo: 6, hi: 6},
+		{addr: "/func main/,/}/", lo: 6, hi: 8},
+		{addr: "/func main/+1", lo: 7, hi: 7},
+		{addr: "/import/+#3", lo: 3, hi: 3},
+		{addr: "/missing/", err: "no match for missing"},
+		{addr: "20", err: "address out of range"},
+		{addr: "/(/", err: "missing closing )"},
+		{addr: "?main?", err: "invalid address syntax"},
+	} {
+		lo, hi, err := Lines([]byte(prog), tt.addr)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("Lines(%q) = %d, %d, %v, want error %q", tt.addr, lo, hi, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || lo != tt.lo || hi != tt.hi {
+			t.Errorf("Lines(%q) = %d, %d, %v, want %d, %d", tt.addr, lo, hi, err, tt.lo, tt.hi)
+		}
+	}
+}
+
+func TestFind(t *testing.T) {
+	fsys := fstest.MapFS{"doc/hello.go": {Data: []byte(prog)}}
+	e, err := Find(fsys, "doc/hello.go", "/\\/\\/ main/,/}/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Lo != 5 || e.Hi != 8 || e.Text != "// main says hello.\nfunc main() {\n\tfmt.Println(\"hello\")\n}\n" {
+		t.Errorf("Find = %+v, want lines 5-8", e)
+	}
+	if !strings.HasPrefix(e.HTML, `<span id="L5" class="ln">     5&nbsp;&nbsp;</span><span class="comment">// main says hello.</span>`) || strings.Contains(e.HTML, `id="L9"`) {
+		t.Errorf("Find HTML = %q, want lines 5-8 with comment marked", e.HTML)
+	}
+
+	if e, err := Find(fsys, "doc/hello.go", ""); err != nil || e.Lo != 1 || e.Hi != 8 || e.Text != prog {
+		t.Errorf("Find of whole file = %+v, %v, want lines 1-8", e, err)
+	}
+	if _, err := Find(fsys, "doc/hello.go", "/nowhere/"); err == nil || !strings.Contains(err.Error(), `doc/hello.go: address "/nowhere/"`) {
+		t.Errorf("Find with unresolved address: %v", err)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	fsys := fstest.MapFS{
+		"site.tmpl":            {Data: []byte(`{{block "layout" .}}{{end}}`)},
+		"doc/hello.go":         {Data: []byte(prog)},
+		"doc/logo.png":         {Data: []byte("\x89PNG\r\n\x1a\n\x00\x00")},
+		"doc/.notes.txt":       {Data: []byte("hidden\n")},
+		"internal-docs/key.go": {Data: []byte("package key\n")},
+		"doc/intro.md":         {Data: []byte("# Intro\n")},
+		"quiz/basics.yaml":     {Data: []byte("answer: 42\n")},
+	}
+	site := web.NewSite(fsys)
+	site.SetPrivate(func(p string) bool { return strings.HasPrefix(p, "/internal-docs/") })
+	var vhosts vhost.Registry
+	mux := http.NewServeMux()
+	rt := router.New(mux)
+	RegisterHandlers(vhosts.Add("", site, fsys, rt), "quiz")
+
+	for _, tt := range []struct {
+		url  string
+		code int
+		lo   int
+	}{
+		{"/api/excerpt?file=doc/hello.go&addr=/func+main/", 200, 6},
+		{"/api/excerpt?file=/doc/hello.go", 200, 1},
+		{"/api/excerpt", 400, 0},
+		{"/api/excerpt?file=doc/hello.go&addr=/nowhere/", 400, 0},
+		{"/api/excerpt?file=doc/hello.go&addr=" + strings.Repeat("+", maxAddr+1), 400, 0},
+		{"/api/excerpt?file=doc/logo.png", 400, 0},
+		{"/api/excerpt?file=doc/missing.go", 404, 0},
+		{"/api/excerpt?file=doc", 404, 0},
+		{"/api/excerpt?file=../doc/hello.go", 404, 0},
+		{"/api/excerpt?file=doc/.notes.txt", 404, 0},
+		{"/api/excerpt?file=internal-docs/key.go", 404, 0},
+		{"/api/excerpt?file=doc/intro.md", 404, 0},
+		{"/api/excerpt?file=quiz/basics.yaml", 404, 0},
+	} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", tt.url, nil))
+		if w.Code != tt.code {
+			t.Errorf("GET %s = %d, want %d\n%s", tt.url, w.Code, tt.code, w.Body)
+			continue
+		}
+		if tt.code != 200 {
+			continue
+		}
+		var e Excerpt
+		if err := json.Unmarshal(w.Body.Bytes(), &e); err != nil || e.File != "doc/hello.go" || e.Lo != tt.lo {
+			t.Errorf("GET %s = %s, want excerpt of doc/hello.go from line %d", tt.url, w.Body, tt.lo)
+		}
+	}
+}
@@ -0,0 +1,369 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package feedback stores readers' answers to "Was this page helpful?"
+// and their comments on pages, posted to /feedback,
+// and serves an administrative handler for moderating them.
+//
+// Submissions are throttled by the server's rate limits. In addition,
+// submissions filling in the form's hidden honeypot field, or with
+// comments containing many links, are accepted but not stored, and
+// comments can be required to carry a CAPTCHA response (see Verifier).
+package feedback
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"cloud.google.com/go/datastore"
+	"github.com/matttproud/yourtour/internal/clientip"
+)
+
+const (
+	kind      = "Feedback"
+	prefix    = "/feedback"
+	maxBody   = 16 << 10 // bytes in a submission
+	maxPath   = 200      // bytes in a page path
+	maxText   = 2000     // runes in a comment
+	maxLinks  = 2        // links in a comment not considered spam
+	maxListed = 1000     // submissions shown by the admin handler
+)
+
+// Feedback is a reader's submission about a page.
+type Feedback struct {
+	ID      int64  `datastore:"-"`
+	URL     string // path of the page, such as /doc/effective_go
+	Helpful int    // 1 if the page was helpful, -1 if not, 0 if not said
+	Comment string `datastore:",noindex"`
+	Time    time.Time
+	Status  string // Status of a comment: new, approved, or hidden
+}
+
+// Comment statuses.
+const (
+	statusNew      = "new"
+	statusApproved = "approved"
+	statusHidden   = "hidden"
+)
+
+// Datastore is the part of a *datastore.Client used by the feedback handlers.
+type Datastore interface {
+	Get(ctx context.Context, key *datastore.Key, dst any) error
+	GetAll(ctx context.Context, q *datastore.Query, dst any) ([]*datastore.Key, error)
+	Put(ctx context.Context, key *datastore.Key, src any) (*datastore.Key, error)
+	Delete(ctx context.Context, key *datastore.Key) error
+}
+
+var _ Datastore = (*datastore.Client)(nil)
+
+// A Verifier checks the CAPTCHA response token sent with a comment
+// by the client at address ip, returning an error if it is not valid.
+type Verifier func(ctx context.Context, token, ip string) error
+
+type server struct {
+	datastore Datastore
+	verify    Verifier // nil if comments need no CAPTCHA
+}
+
+// RegisterHandlers registers the handler for POST /feedback on mux,
+// storing feedback in dc. If verify is non-nil,
+// comments must carry a CAPTCHA response that it accepts.
+func RegisterHandlers(mux *http.ServeMux, dc Datastore, verify Verifier) {
+	s := &server{datastore: dc, verify: verify}
+	mux.HandleFunc(prefix, s.postHandler)
+}
+
+// A submission is the form posted to /feedback.
+type submission struct {
+	URL      string `json:"url"`
+	Helpful  string `json:"helpful"` // yes, no, or empty
+	Comment  string `json:"comment"`
+	Captcha  string `json:"captcha"` // CAPTCHA response token
+	Honeypot string `json:"website"` // hidden from people; filled in by bots
+}
+
+// postHandler stores the feedback posted as a form or as JSON:
+//
+//	POST /feedback
+//	{"url": "/doc/", "helpful": "no", "comment": "Please explain X."}
+//
+// JSON submissions are answered with 204 No Content;
+// form submissions are redirected back to the page.
+func (h *server) postHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var sub submission
+	r.Body = http.MaxBytesReader(w, r.Body, maxBody)
+	mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	isJSON := mt == "application/json"
+	if isJSON {
+		data, err := io.ReadAll(r.Body)
+		if err == nil {
+			err = json.Unmarshal(data, &sub)
+		}
+		if err != nil {
+			http.Error(w, "invalid feedback: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	} else {
+		sub = submission{
+			URL:      r.FormValue("url"),
+			Helpful:  r.FormValue("helpful"),
+			Comment:  r.FormValue("comment"),
+			Captcha:  r.FormValue("captcha"),
+			Honeypot: r.FormValue("website"),
+		}
+	}
+
+	fb, err := sub.feedback()
+	if err != nil {
+		http.Error(w, "invalid feedback: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	ctx := r.Context()
+	if fb.Comment != "" && h.verify != nil {
+		if err := h.verify(ctx, sub.Captcha, clientip.FromRequest(r)); err != nil {
+			http.Error(w, "CAPTCHA check failed: "+err.Error(), http.StatusForbidden)
+			return
+		}
+	}
+	if !sub.spam() {
+		fb.Time = time.Now()
+		if fb.Comment != "" {
+			fb.Status = statusNew
+		}
+		if _, err := h.datastore.Put(ctx, datastore.IncompleteKey(kind, nil), fb); err != nil {
+			log.Printf("ERROR storing feedback for %s: %v", fb.URL, err)
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if isJSON {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	http.Redirect(w, r, fb.URL+"#feedback-thanks", http.StatusSeeOther)
+}
+
+// feedback returns the Feedback submitted in s, checking that it is valid.
+func (s *submission) feedback() (*Feedback, error) {
+	if !strings.HasPrefix(s.URL, "/") || strings.HasPrefix(s.URL, "//") || len(s.URL) > maxPath ||
+		strings.ContainsAny(s.URL, "?#") || !utf8.ValidString(s.URL) {
+		return nil, fmt.Errorf("invalid url %q", s.URL)
+	}
+	fb := &Feedback{URL: s.URL, Comment: strings.TrimSpace(s.Comment)}
+	switch s.Helpful {
+	case "yes":
+		fb.Helpful = 1
+	case "no":
+		fb.Helpful = -1
+	case "":
+	default:
+		return nil, fmt.Errorf("invalid helpful %q; want yes or no", s.Helpful)
+	}
+	if utf8.RuneCountInString(fb.Comment) > maxText || !utf8.ValidString(fb.Comment) {
+		return nil, fmt.Errorf("comment too long; limit %d characters", maxText)
+	}
+	if fb.Helpful == 0 && fb.Comment == "" {
+		return nil, errors.New("no vote or comment")
+	}
+	return fb, nil
+}
+
+// spam reports whether s looks like spam,
+// to be accepted without being stored.
+func (s *submission) spam() bool {
+	return s.Honeypot != "" || strings.Count(s.Comment, "://") > maxLinks
+}
+
+// AdminHandler serves an administrative interface listing feedback
+// and its totals by page, and approving, hiding, and deleting comments.
+// Be careful. It is the caller’s responsibility to ensure that the handler is
+// only exposed to authorized users.
+func AdminHandler(dc Datastore) http.HandlerFunc {
+	s := &server{datastore: dc}
+	return s.adminHandler
+}
+
+var (
+	adminTemplate = template.Must(template.New("admin").Parse(adminHTML))
+
+	//go:embed admin.html
+	adminHTML string
+)
+
+// A pageTotal totals the feedback on a page.
+type pageTotal struct {
+	URL           string
+	Yes, No       int
+	Comments, New int
+}
+
+// adminHandler serves the administrative interface.
+// The list is of the most recent maxListed submissions,
+// for pages with paths starting with the url parameter,
+// showing comments with the status parameter (default new, or all).
+func (h *server) adminHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var doErr error
+	if r.Method == "POST" {
+		doErr = h.moderate(ctx, r.FormValue("id"), r.FormValue("do"))
+		if doErr == nil {
+			// Show the list again, without the form resubmitting on reload.
+			http.Redirect(w, r, r.URL.String(), http.StatusSeeOther)
+			return
+		}
+	}
+
+	status := r.FormValue("status")
+	if status == "" {
+		status = statusNew
+	}
+	urlPrefix := r.FormValue("url")
+
+	var list []*Feedback
+	q := datastore.NewQuery(kind).Order("-Time").Limit(maxListed)
+	keys, err := h.datastore.GetAll(ctx, q, &list)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Printf("ERROR %v", err)
+		return
+	}
+	totals := make(map[string]*pageTotal)
+	var comments []*Feedback
+	for i, fb := range list {
+		fb.ID = keys[i].ID
+		if !strings.HasPrefix(fb.URL, urlPrefix) {
+			continue
+		}
+		t := totals[fb.URL]
+		if t == nil {
+			t = &pageTotal{URL: fb.URL}
+			totals[fb.URL] = t
+		}
+		switch fb.Helpful {
+		case 1:
+			t.Yes++
+		case -1:
+			t.No++
+		}
+		if fb.Comment != "" {
+			t.Comments++
+			if fb.Status == statusNew {
+				t.New++
+			}
+			if status == "all" || fb.Status == status {
+				comments = append(comments, fb)
+			}
+		}
+	}
+	var pages []*pageTotal
+	for _, t := range totals {
+		pages = append(pages, t)
+	}
+	sort.Slice(pages, func(i, j int) bool {
+		if pages[i].Yes+pages[i].No != pages[j].Yes+pages[j].No {
+			return pages[i].Yes+pages[i].No > pages[j].Yes+pages[j].No
+		}
+		return pages[i].URL < pages[j].URL
+	})
+
+	data := struct {
+		URL, Status string
+		Statuses    []string
+		Pages       []*pageTotal
+		Comments    []*Feedback
+		Error       error
+	}{urlPrefix, status, []string{statusNew, statusApproved, statusHidden, "all"}, pages, comments, doErr}
+	if err := adminTemplate.Execute(w, &data); err != nil {
+		log.Printf("ERROR adminTemplate: %v", err)
+	}
+}
+
+// moderate applies the moderation action do to the feedback
+// with the given ID.
+func (h *server) moderate(ctx context.Context, id, do string) error {
+	n, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid id %q", id)
+	}
+	k := datastore.IDKey(kind, n, nil)
+	if do == "Delete" {
+		return h.datastore.Delete(ctx, k)
+	}
+	var fb Feedback
+	if err := h.datastore.Get(ctx, k, &fb); err != nil {
+		return err
+	}
+	switch do {
+	case "Approve":
+		fb.Status = statusApproved
+	case "Hide":
+		fb.Status = statusHidden
+	default:
+		return fmt.Errorf("unknown action %q", do)
+	}
+	_, err = h.datastore.Put(ctx, k, &fb)
+	return err
+}
+
+// CaptchaSecretName is the name of the secret holding the
+// server's key for the CAPTCHA service used by SiteVerify.
+const CaptchaSecretName = "feedback-captcha-secret"
+
+// SiteVerify returns a Verifier checking tokens with the siteverify
+// protocol shared by reCAPTCHA, hCaptcha, and Turnstile, POSTing them
+// to verifyURL with the secret returned by secret.
+func SiteVerify(verifyURL string, secret func(context.Context) (string, error)) Verifier {
+	return func(ctx context.Context, token, ip string) error {
+		if token == "" {
+			return errors.New("missing CAPTCHA response")
+		}
+		key, err := secret(ctx)
+		if err != nil {
+			return fmt.Errorf("loading %s: %w", CaptchaSecretName, err)
+		}
+		form := url.Values{"secret": {key}, "response": {token}, "remoteip": {ip}}
+		req, err := http.NewRequestWithContext(ctx, "POST", verifyURL, strings.NewReader(form.Encode()))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		var result struct {
+			Success bool     `json:"success"`
+			Errors  []string `json:"error-codes"`
+		}
+		if err := json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&result); err != nil {
+			return fmt.Errorf("reading CAPTCHA verification: %v", err)
+		}
+		if !result.Success {
+			return fmt.Errorf("CAPTCHA rejected: %s", strings.Join(result.Errors, ", "))
+		}
+		return nil
+	}
+}
@@ -0,0 +1,181 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package feedback
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"cloud.google.com/go/datastore"
+	"github.com/matttproud/yourtour/internal/datastoretest"
+)
+
+// getFeedback returns the feedback with the given ID in ds,
+// and whether there is any.
+func getFeedback(ds Datastore, id int64) (Feedback, bool) {
+	var fb Feedback
+	err := ds.Get(context.Background(), datastore.IDKey(kind, id, nil), &fb)
+	return fb, err == nil
+}
+
+func TestPostHandler(t *testing.T) {
+	ds := new(datastoretest.Datastore)
+	mux := http.NewServeMux()
+	RegisterHandlers(mux, ds, func(ctx context.Context, token, ip string) error {
+		if token != "human" {
+			return errors.New("not a human")
+		}
+		return nil
+	})
+	post := func(ctype, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("POST", "/feedback", strings.NewReader(body))
+		r.Header.Set("Content-Type", ctype)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		return w
+	}
+	const (
+		jsonType = "application/json"
+		formType = "application/x-www-form-urlencoded"
+	)
+
+	for _, tt := range []struct {
+		ctype, body string
+		code        int
+		stored      bool
+	}{
+		{jsonType, `{"url": "/doc/", "helpful": "yes"}`, 204, true},
+		{formType, "url=/doc/&helpful=no", 303, true},
+		{jsonType, `{"url": "/doc/", "helpful": "no", "comment": "Too short.", "captcha": "human"}`, 204, true},
+		{jsonType, `{"url": "/doc/", "comment": "Buy now.", "captcha": "robot"}`, 403, false},
+		{jsonType, `{"url": "/doc/", "comment": "Buy now."}`, 403, false},
+		{jsonType, `{"url": "/doc/", "helpful": "yes", "website": "http://spam.example"}`, 204, false},
+		{jsonType, `{"url": "/doc/", "comment": "a://b c://d e://f", "captcha": "human"}`, 204, false},
+		{jsonType, `{"url": "/doc/", "helpful": "maybe"}`, 400, false},
+		{jsonType, `{"url": "/doc/"}`, 400, false},
+		{jsonType, `{"url": "https://example.com/", "helpful": "yes"}`, 400, false},
+		{jsonType, `{"url": "//example.com/", "helpful": "yes"}`, 400, false},
+		{jsonType, `{"url": "/doc/?q=1", "helpful": "yes"}`, 400, false},
+		{jsonType, `{"url": "/doc/", "comment": "` + strings.Repeat("x", maxText+1) + `", "captcha": "human"}`, 400, false},
+		{jsonType, `{"url":`, 400, false},
+	} {
+		before := ds.Len()
+		w := post(tt.ctype, tt.body)
+		if w.Code != tt.code {
+			t.Errorf("POST %.80s: %d, want %d\n%s", tt.body, w.Code, tt.code, w.Body)
+		}
+		if stored := ds.Len() > before; stored != tt.stored {
+			t.Errorf("POST %.80s: stored = %v, want %v", tt.body, stored, tt.stored)
+		}
+	}
+
+	if fb, _ := getFeedback(ds, 1); fb.URL != "/doc/" || fb.Helpful != 1 || fb.Status != "" || fb.Time.IsZero() {
+		t.Errorf("vote stored as %+v", fb)
+	}
+	if fb, _ := getFeedback(ds, 3); fb.Helpful != -1 || fb.Comment != "Too short." || fb.Status != statusNew {
+		t.Errorf("comment stored as %+v", fb)
+	}
+	if w := post(formType, "url=/doc/&helpful=yes"); w.Header().Get("Location") != "/doc/#feedback-thanks" {
+		t.Errorf("form post redirected to %q", w.Header().Get("Location"))
+	}
+}
+
+func TestAdminHandler(t *testing.T) {
+	ds := new(datastoretest.Datastore)
+	ctx := context.Background()
+	for _, fb := range []Feedback{
+		{URL: "/doc/", Helpful: 1},
+		{URL: "/doc/", Helpful: -1, Comment: "Unclear <b>intro</b>.", Status: statusNew},
+		{URL: "/doc/faq", Helpful: 1},
+		{URL: "/blog/", Comment: "Old comment.", Status: statusApproved},
+	} {
+		ds.Put(ctx, datastore.IncompleteKey(kind, nil), &fb)
+	}
+	h := AdminHandler(ds)
+	get := func(query string) string {
+		w := httptest.NewRecorder()
+		h(w, httptest.NewRequest("GET", "/_feedback?"+query, nil))
+		if w.Code != 200 {
+			t.Fatalf("GET ?%s: %d\n%s", query, w.Code, w.Body)
+		}
+		return w.Body.String()
+	}
+
+	body := get("url=/doc/")
+	for _, want := range []string{
+		`<td><a href="/doc/">/doc/</a></td>
+		<td class="n">1</td>
+		<td class="n">1</td>
+		<td class="n">1</td>
+		<td class="n">1</td>`,
+		`<td class="comment">Unclear &lt;b&gt;intro&lt;/b&gt;.</td>`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("GET ?url=/doc/ missing %s\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "/blog/") || strings.Contains(body, "Old comment.") {
+		t.Errorf("GET ?url=/doc/ lists /blog/ feedback")
+	}
+	if body := get("status=approved"); !strings.Contains(body, "Old comment.") || strings.Contains(body, "Unclear") {
+		t.Errorf("GET ?status=approved does not list only approved comments\n%s", body)
+	}
+
+	moderate := func(id int64, do string) int {
+		form := url.Values{"id": {fmt.Sprint(id)}, "do": {do}}
+		r := httptest.NewRequest("POST", "/_feedback?url=/doc/", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		h(w, r)
+		return w.Code
+	}
+	if code := moderate(2, "Hide"); code != http.StatusSeeOther {
+		t.Errorf("Hide: %d, want 303", code)
+	}
+	if fb, _ := getFeedback(ds, 2); fb.Status != statusHidden {
+		t.Errorf("after Hide, status = %q", fb.Status)
+	}
+	if code := moderate(4, "Delete"); code != http.StatusSeeOther {
+		t.Errorf("Delete: %d, want 303", code)
+	}
+	if _, ok := getFeedback(ds, 4); ok {
+		t.Errorf("Delete did not delete")
+	}
+	if code := moderate(1, "Promote"); code != 200 {
+		t.Errorf("unknown action: %d, want 200 with error", code)
+	}
+}
+
+func TestSiteVerify(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("secret") != "key" || r.FormValue("remoteip") != "192.0.2.1" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		if r.FormValue("response") == "good" {
+			fmt.Fprint(w, `{"success": true}`)
+		} else {
+			fmt.Fprint(w, `{"success": false, "error-codes": ["invalid-input-response"]}`)
+		}
+	}))
+	defer srv.Close()
+	verify := SiteVerify(srv.URL, func(context.Context) (string, error) { return "key", nil })
+	ctx := context.Background()
+	if err := verify(ctx, "good", "192.0.2.1"); err != nil {
+		t.Errorf("good token: %v", err)
+	}
+	if err := verify(ctx, "bad", "192.0.2.1"); err == nil || !strings.Contains(err.Error(), "invalid-input-response") {
+		t.Errorf("bad token: %v, want invalid-input-response", err)
+	}
+	if err := verify(ctx, "", "192.0.2.1"); err == nil {
+		t.Errorf("missing token accepted")
+	}
+}
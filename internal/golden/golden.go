@@ -0,0 +1,200 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package golden implements golden-file testing of web servers.
+//
+// A test lists the routes to check, each a request with a name.
+// Test sends each request to an http.Handler and compares the canonical
+// form of the response, described below, with the one recorded in the
+// golden file named for the route. Running the test with the flag
+// -golden.update records the current responses instead, so that a change
+// meant to leave the responses alone, such as a refactoring of the handlers,
+// can be checked by running the tests before and after it, and a change
+// meant to alter them can be reviewed as a diff of the golden files.
+//
+// # Canonical Responses
+//
+// The canonical form of a response is a text file holding the request line,
+// the status code, the header lines, sorted, and, after a blank line, the body.
+// The value of the X-Request-Id header, which differs from one response
+// to the next, is replaced by {request-id} in the headers and the body.
+//
+// A body that is not UTF-8 text is recorded as its length and SHA-256 hash.
+// A text body longer than MaxBody bytes is recorded up to the end of the
+// line reaching MaxBody, followed by the length and hash of the whole body,
+// so that a change to its end is detected but only a change to its start
+// is shown in a diff.
+package golden
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var update = flag.Bool("golden.update", false, "record responses in golden files instead of checking them")
+
+// MaxBody is the length of the longest body recorded in full.
+const MaxBody = 64 << 10
+
+// A Route is a request whose response is recorded in a golden file.
+type Route struct {
+	Name   string // golden file name, without the .golden suffix
+	Method string // request method; default GET
+	URL    string // request URL, which is absolute to select a host
+}
+
+// Test runs a subtest for each route, comparing the canonical form
+// of h's response to the route's request with the golden file
+// holding it in dir. It also reports golden files in dir for which
+// there is no route. With -golden.update, Test instead writes the golden
+// files, creating dir if needed, and removes those for which there is no route.
+func Test(t *testing.T, h http.Handler, dir string, routes []Route) {
+	names := make(map[string]bool)
+	for _, r := range routes {
+		if names[r.Name] {
+			t.Fatalf("duplicate route %s", r.Name)
+		}
+		names[r.Name] = true
+	}
+	if *update {
+		if err := os.MkdirAll(dir, 0777); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stale, err := stale(dir, names)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range stale {
+		if !*update {
+			t.Errorf("%s: no route for golden file; run with -golden.update to remove", file)
+			continue
+		}
+		if err := os.Remove(file); err != nil {
+			t.Error(err)
+		}
+	}
+
+	for _, r := range routes {
+		t.Run(r.Name, func(t *testing.T) {
+			if err := check(h, dir, r, *update); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+// stale returns the golden files in dir not named in names.
+func stale(dir string, names map[string]bool) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.golden"))
+	if err != nil {
+		return nil, err
+	}
+	var list []string
+	for _, file := range files {
+		if !names[strings.TrimSuffix(filepath.Base(file), ".golden")] {
+			list = append(list, file)
+		}
+	}
+	return list, nil
+}
+
+// check compares the canonical form of h's response to the route's request
+// with the route's golden file in dir, or, if write is true, writes the file.
+func check(h http.Handler, dir string, r Route, write bool) error {
+	got, err := Record(h, r)
+	if err != nil {
+		return err
+	}
+	file := filepath.Join(dir, r.Name+".golden")
+	if write {
+		return os.WriteFile(file, got, 0666)
+	}
+	want, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%s: no golden file; run with -golden.update to create", file)
+	}
+	if err != nil {
+		return err
+	}
+	if diff := cmp.Diff(string(want), string(got)); diff != "" {
+		return fmt.Errorf("%s %s: response differs from %s (-want +got):\n%s\nrun with -golden.update if the change is intended", r.method(), r.URL, file, diff)
+	}
+	return nil
+}
+
+func (r Route) method() string {
+	if r.Method == "" {
+		return "GET"
+	}
+	return r.Method
+}
+
+// Record returns the canonical form of h's response to the route's request.
+func Record(h http.Handler, r Route) ([]byte, error) {
+	req, err := http.NewRequest(r.method(), r.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return canonical(req, w.Result(), w.Body.Bytes()), nil
+}
+
+// canonical returns the canonical form of the response to req.
+func canonical(req *http.Request, resp *http.Response, body []byte) []byte {
+	scrub := func(b []byte) []byte { return b }
+	if id := resp.Header.Get("X-Request-Id"); id != "" {
+		scrub = func(b []byte) []byte { return bytes.ReplaceAll(b, []byte(id), []byte("{request-id}")) }
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s %s\n%d\n", req.Method, req.URL, resp.StatusCode)
+	keys := make([]string, 0, len(resp.Header))
+	for k := range resp.Header {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		for _, v := range resp.Header[k] {
+			fmt.Fprintf(&buf, "%s: %s\n", k, scrub([]byte(v)))
+		}
+	}
+	buf.WriteString("\n")
+
+	body = scrub(body)
+	sum := func() { fmt.Fprintf(&buf, "{%d bytes, sha256 %x}\n", len(body), sha256.Sum256(body)) }
+	switch {
+	case !utf8.Valid(body):
+		sum()
+	case len(body) > MaxBody:
+		n := MaxBody
+		if i := bytes.IndexByte(body[n:], '\n'); i >= 0 {
+			n += i + 1
+		} else {
+			n = len(body)
+		}
+		buf.Write(body[:n])
+		if n < len(body) {
+			buf.WriteString("\n")
+			sum()
+		}
+	default:
+		buf.Write(body)
+	}
+	return buf.Bytes()
+}
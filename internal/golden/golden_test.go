@@ -0,0 +1,95 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golden
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRecord(t *testing.T) {
+	id := 0
+	long := strings.Repeat("x", MaxBody-1) + "\nrest\nmore\n"
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id++
+		w.Header().Set("X-Request-Id", fmt.Sprintf("req%d", id))
+		w.Header().Set("Content-Type", "text/plain")
+		w.Header().Set("Vary", "Accept-Encoding")
+		w.Header().Add("Link", "</b>")
+		w.Header().Add("Link", "</a>")
+		switch r.URL.Path {
+		case "/binary":
+			w.Write([]byte{0xff, 0xfe})
+		case "/long":
+			fmt.Fprint(w, long)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprintf(w, "%s %s: no such page (request req%d)\n", r.Method, r.Host, id)
+		}
+	})
+	const header = "Content-Type: text/plain\nLink: </b>\nLink: </a>\nVary: Accept-Encoding\nX-Request-Id: {request-id}\n\n"
+	for _, tt := range []struct {
+		route Route
+		want  string
+	}{
+		{
+			Route{Name: "missing", Method: "HEAD", URL: "https://go.dev/missing"},
+			"HEAD https://go.dev/missing\n404\n" + header + "HEAD go.dev: no such page (request {request-id})\n",
+		},
+		{
+			Route{Name: "binary", URL: "/binary"},
+			fmt.Sprintf("GET /binary\n200\n"+header+"{2 bytes, sha256 %x}\n", sha256.Sum256([]byte{0xff, 0xfe})),
+		},
+		{
+			Route{Name: "long", URL: "/long"},
+			fmt.Sprintf("GET /long\n200\n"+header+"%s\n{%d bytes, sha256 %x}\n", long[:MaxBody+5], len(long), sha256.Sum256([]byte(long))),
+		},
+	} {
+		got, err := Record(h, tt.route)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("Record(%s) =\n%.300q\nwant:\n%.300q", tt.route.Name, got, tt.want)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	page := "hello\n"
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { fmt.Fprint(w, page) })
+	dir := t.TempDir()
+	home := Route{Name: "home", URL: "/"}
+
+	if err := check(h, dir, home, false); err == nil || !strings.Contains(err.Error(), "no golden file") {
+		t.Errorf("check before writing = %v, want no golden file", err)
+	}
+	if err := check(h, dir, home, true); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "home.golden"))
+	if want := "GET /\n200\nContent-Type: text/plain; charset=utf-8\n\nhello\n"; err != nil || string(data) != want {
+		t.Fatalf("home.golden = %q, %v, want %q", data, err, want)
+	}
+	if err := check(h, dir, home, false); err != nil {
+		t.Errorf("check unchanged page: %v", err)
+	}
+	page = "goodbye\n"
+	if err := check(h, dir, home, false); err == nil || !strings.Contains(err.Error(), "goodbye") {
+		t.Errorf("check changed page = %v, want diff", err)
+	}
+
+	os.WriteFile(filepath.Join(dir, "old.golden"), nil, 0666)
+	list, err := stale(dir, map[string]bool{"home": true})
+	if want := []string{filepath.Join(dir, "old.golden")}; err != nil || !reflect.DeepEqual(list, want) {
+		t.Errorf("stale = %q, %v, want %q", list, err, want)
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package graceful runs HTTP servers that shut down cleanly,
+// draining in-flight requests before the process exits,
+// so that deploys do not truncate responses.
+package graceful
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// DefaultDrainTimeout is how long a Runner with no DrainTimeout
+// waits for in-flight requests to finish. It fits within the
+// grace period App Engine allows between SIGTERM and SIGKILL.
+const DefaultDrainTimeout = 25 * time.Second
+
+// A Runner runs an HTTP server until it is told to stop,
+// serving HTTPS if the server has a TLSConfig,
+// then shuts it down gracefully:
+// it stops accepting connections, waits up to DrainTimeout
+// for in-flight requests to finish, and then runs
+// its shutdown hooks, such as stopping background work.
+type Runner struct {
+	Server       *http.Server
+	DrainTimeout time.Duration // if zero, DefaultDrainTimeout
+
+	mu    sync.Mutex
+	hooks []func()
+}
+
+// OnShutdown registers f to be called after the server has drained.
+// Hooks run in the reverse of the order they were registered.
+func (r *Runner) OnShutdown(f func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.hooks = append(r.hooks, f)
+}
+
+// ListenAndServe listens on r.Server.Addr and serves requests
+// until ctx is done or the process receives SIGTERM or SIGINT.
+// It then shuts down as described for Serve.
+func (r *Runner) ListenAndServe(ctx context.Context) error {
+	ln, err := net.Listen("tcp", r.addr())
+	if err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGTERM, os.Interrupt)
+	defer stop()
+	return r.Serve(ctx, ln)
+}
+
+func (r *Runner) addr() string {
+	if r.Server.Addr == "" {
+		if r.Server.TLSConfig != nil {
+			return ":https"
+		}
+		return ":http"
+	}
+	return r.Server.Addr
+}
+
+// Serve serves requests on ln until ctx is done,
+// then stops accepting connections, drains in-flight requests,
+// and runs the shutdown hooks.
+// It returns nil after a clean shutdown, or an error if the server
+// failed or in-flight requests had to be cut off at the drain deadline.
+func (r *Runner) Serve(ctx context.Context, ln net.Listener) error {
+	errc := make(chan error, 1)
+	go func() {
+		if r.Server.TLSConfig != nil {
+			// The certificates come from the TLSConfig.
+			errc <- r.Server.ServeTLS(ln, "", "")
+			return
+		}
+		errc <- r.Server.Serve(ln)
+	}()
+
+	var err error
+	select {
+	case err = <-errc:
+		// The server failed on its own; there is nothing to drain.
+	case <-ctx.Done():
+		timeout := r.DrainTimeout
+		if timeout == 0 {
+			timeout = DefaultDrainTimeout
+		}
+		log.Printf("shutting down: draining requests for up to %v", timeout)
+		dctx, cancel := context.WithTimeout(context.Background(), timeout)
+		err = r.Server.Shutdown(dctx)
+		cancel()
+		if err != nil {
+			log.Printf("ERROR draining requests: %v; closing remaining connections", err)
+			r.Server.Close()
+		}
+		if serr := <-errc; serr != http.ErrServerClosed && err == nil {
+			err = serr
+		}
+	}
+
+	r.mu.Lock()
+	hooks := r.hooks
+	r.mu.Unlock()
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hooks[i]()
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		err = nil
+	}
+	if err == nil {
+		log.Printf("shutdown complete")
+	}
+	os.Stderr.Sync() // flush logs before the process exits
+	return err
+}
@@ -0,0 +1,139 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graceful
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// start starts r serving on a local port, returning its URL,
+// a function to trigger shutdown, and a channel receiving Serve's result.
+func start(t *testing.T, r *Runner) (string, context.CancelFunc, chan error) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- r.Serve(ctx, ln) }()
+	return "http://" + ln.Addr().String(), cancel, done
+}
+
+func TestDrain(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	r := &Runner{Server: &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		close(started)
+		<-release
+		io.WriteString(w, "complete")
+	})}}
+	var order []string
+	r.OnShutdown(func() { order = append(order, "first") })
+	r.OnShutdown(func() { order = append(order, "second") })
+	url, shutdown, done := start(t, r)
+
+	body := make(chan string, 1)
+	go func() {
+		resp, err := http.Get(url)
+		if err != nil {
+			body <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		body <- string(b)
+	}()
+	<-started
+	shutdown()
+
+	// The server must wait for the in-flight request.
+	select {
+	case err := <-done:
+		t.Fatalf("Serve returned %v with a request in flight", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	if b := <-body; b != "complete" {
+		t.Errorf("in-flight request got %q, want %q", b, "complete")
+	}
+	if err := <-done; err != nil {
+		t.Errorf("Serve = %v, want nil", err)
+	}
+	if want := []string{"second", "first"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("hooks ran in order %v, want %v", order, want)
+	}
+}
+
+func TestDrainTimeout(t *testing.T) {
+	started := make(chan struct{})
+	stuck := make(chan struct{})
+	defer close(stuck)
+	r := &Runner{
+		Server: &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			close(started)
+			<-stuck
+		})},
+		DrainTimeout: 20 * time.Millisecond,
+	}
+	hookRan := false
+	r.OnShutdown(func() { hookRan = true })
+	url, shutdown, done := start(t, r)
+
+	go http.Get(url)
+	<-started
+	shutdown()
+	if err := <-done; err != context.DeadlineExceeded {
+		t.Errorf("Serve = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if !hookRan {
+		t.Errorf("shutdown hook did not run after drain timeout")
+	}
+}
+
+func TestServeTLS(t *testing.T) {
+	// Borrow the test server's certificate and a client that trusts it.
+	ts := httptest.NewTLSServer(nil)
+	defer ts.Close()
+	r := &Runner{Server: &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if req.TLS == nil {
+				t.Errorf("request not served over TLS")
+			}
+			io.WriteString(w, "secure")
+		}),
+		TLSConfig: &tls.Config{Certificates: ts.TLS.Certificates},
+	}}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, shutdown := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- r.Serve(ctx, ln) }()
+	defer func() {
+		shutdown()
+		if err := <-done; err != nil {
+			t.Errorf("Serve = %v", err)
+		}
+	}()
+
+	resp, err := ts.Client().Get("https://" + ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if b, _ := io.ReadAll(resp.Body); string(b) != "secure" {
+		t.Errorf("body = %q, want %q", b, "secure")
+	}
+}
@@ -0,0 +1,563 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"reflect"
+	"time"
+)
+
+// An executor executes one operation of a validated document.
+type executor struct {
+	schema *Schema
+	doc    *document
+	op     *operation
+	vars   map[string]any // coerced variable values
+	errs   []*Error
+
+	selected  int // selections checked so far, counting fragments where used
+	maxFields int // the most selections allowed
+
+	stopped bool // execution stopped, with an error recorded
+}
+
+// prepare validates the operation named op in doc
+// and returns an executor for it with the given variables.
+func (s *Schema) prepare(doc *document, op string, vars map[string]any) (*executor, error) {
+	e := &executor{schema: s, doc: doc}
+	for _, o := range doc.ops {
+		if op == "" && len(doc.ops) > 1 {
+			return nil, errors.New("query has several operations; operationName is required")
+		}
+		if op == "" || o.name == op {
+			e.op = o
+			break
+		}
+	}
+	if e.op == nil {
+		return nil, fmt.Errorf("no operation named %q", op)
+	}
+	if e.op.kind != "query" {
+		return nil, fmt.Errorf("%s operations are not supported", e.op.kind)
+	}
+
+	e.vars = make(map[string]any)
+	declared := make(map[string]bool)
+	for _, v := range e.op.vars {
+		if declared[v.name] {
+			return nil, fmt.Errorf("variable $%s declared twice", v.name)
+		}
+		declared[v.name] = true
+		t, err := s.inputType(v.typ)
+		if err != nil {
+			return nil, fmt.Errorf("variable $%s: %v", v.name, err)
+		}
+		val, ok := vars[v.name]
+		if !ok {
+			val = v.def
+		}
+		if !ok && v.def == nil {
+			if _, nn := t.(nonNull); nn {
+				return nil, fmt.Errorf("variable $%s of type %s is required", v.name, t)
+			}
+			continue
+		}
+		c, err := coerce(t, val, nil)
+		if err != nil {
+			return nil, fmt.Errorf("variable $%s: %v", v.name, err)
+		}
+		e.vars[v.name] = c
+	}
+
+	max := s.MaxDepth
+	if max == 0 {
+		max = DefaultMaxDepth
+	}
+	e.maxFields = s.MaxFields
+	if e.maxFields == 0 {
+		e.maxFields = DefaultMaxFields
+	}
+	if err := e.check(s.Query, e.op.sel, 1, max, declared, nil); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// inputType returns the schema type for a variable type.
+func (s *Schema) inputType(t *typeRef) (Type, error) {
+	var typ Type
+	if t.elem != nil {
+		elem, err := s.inputType(t.elem)
+		if err != nil {
+			return nil, err
+		}
+		typ = List(elem)
+	} else {
+		for _, sc := range []*Scalar{String, Int, Float, Boolean} {
+			if t.name == sc.Name {
+				typ = sc
+			}
+		}
+		if typ == nil {
+			return nil, fmt.Errorf("unknown input type %s", t.name)
+		}
+	}
+	if t.nonNull {
+		typ = NonNull(typ)
+	}
+	return typ, nil
+}
+
+// errorAt returns an error at the position of sel.
+func (e *executor) errorAt(sel *selection, format string, args ...any) error {
+	line, col := lineCol(e.doc.src, sel.pos)
+	return fmt.Errorf("%d:%d: %s", line, col, fmt.Sprintf(format, args...))
+}
+
+// check validates the selections sel of an object of type obj
+// at the given depth, which must not exceed max.
+// declared holds the declared variables;
+// inFragments holds the fragments being expanded, to detect cycles.
+// Fragments are checked each time they are used, so that the selections
+// counted against e.maxFields are those that executing the query makes.
+func (e *executor) check(obj *Object, sel []*selection, depth, max int, declared map[string]bool, inFragments []string) error {
+	if depth > max {
+		return e.errorAt(sel[0], "query is nested more than %d levels deep", max)
+	}
+	for _, s := range sel {
+		if e.selected++; e.selected > e.maxFields {
+			return e.errorAt(s, "query selects more than %d fields", e.maxFields)
+		}
+		switch {
+		case s.spread != "":
+			f := e.doc.fragments[s.spread]
+			if f == nil {
+				return e.errorAt(s, "unknown fragment %s", s.spread)
+			}
+			for _, name := range inFragments {
+				if name == f.name {
+					return e.errorAt(s, "fragment %s spreads itself", f.name)
+				}
+			}
+			if f.on != obj.Name {
+				return e.errorAt(s, "fragment %s on %s cannot apply to %s", f.name, f.on, obj.Name)
+			}
+			if err := e.check(obj, f.sel, depth, max, declared, append(inFragments, f.name)); err != nil {
+				return err
+			}
+		case s.inline:
+			if s.on != "" && s.on != obj.Name {
+				return e.errorAt(s, "fragment on %s cannot apply to %s", s.on, obj.Name)
+			}
+			if err := e.check(obj, s.sel, depth, max, declared, inFragments); err != nil {
+				return err
+			}
+		case s.name == "__typename":
+			if len(s.args) > 0 || s.sel != nil {
+				return e.errorAt(s, "__typename takes no arguments or selections")
+			}
+		default:
+			f := obj.field(s.name)
+			if f == nil {
+				return e.errorAt(s, "type %s has no field %s", obj.Name, s.name)
+			}
+			if err := e.checkArgs(f, s, declared); err != nil {
+				return err
+			}
+			o, isObj := named(f.Type).(*Object)
+			switch {
+			case isObj && s.sel == nil:
+				return e.errorAt(s, "field %s of type %s needs a selection of subfields", s.name, f.Type)
+			case !isObj && s.sel != nil:
+				return e.errorAt(s, "field %s of type %s has no subfields", s.name, f.Type)
+			case isObj:
+				if err := e.check(o, s.sel, depth+1, max, declared, inFragments); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// checkArgs checks the arguments given to the field f in s.
+func (e *executor) checkArgs(f *Field, s *selection, declared map[string]bool) error {
+	given := make(map[string]bool)
+	for _, a := range s.args {
+		if given[a.name] {
+			return e.errorAt(s, "argument %s given twice", a.name)
+		}
+		given[a.name] = true
+		var def *Arg
+		for _, d := range f.Args {
+			if d.Name == a.name {
+				def = d
+			}
+		}
+		if def == nil {
+			return e.errorAt(s, "field %s has no argument %s", f.Name, a.name)
+		}
+		if v, ok := a.val.(variable); ok && !declared[string(v)] {
+			return e.errorAt(s, "undeclared variable $%s", v)
+		}
+		if _, err := coerce(def.Type, a.val, e.vars); err != nil {
+			return e.errorAt(s, "argument %s: %v", a.name, err)
+		}
+	}
+	for _, d := range f.Args {
+		if _, nn := d.Type.(nonNull); nn && !given[d.Name] && d.Default == nil {
+			return e.errorAt(s, "field %s requires argument %s", f.Name, d.Name)
+		}
+	}
+	return nil
+}
+
+// named returns the named type within t.
+func named(t Type) Type {
+	for {
+		switch u := t.(type) {
+		case listType:
+			t = u.of
+		case nonNull:
+			t = u.of
+		default:
+			return t
+		}
+	}
+}
+
+// coerce coerces the input value v, from a query or from JSON variables,
+// to the Go value for type t.
+// Variables in v are replaced with their values in vars.
+func coerce(t Type, v any, vars map[string]any) (any, error) {
+	if name, ok := v.(variable); ok {
+		v = vars[string(name)]
+		if v == nil {
+			if _, nn := t.(nonNull); nn {
+				return nil, fmt.Errorf("variable $%s is null, want %s", name, t)
+			}
+			return nil, nil
+		}
+		c, err := coerce(t, v, nil)
+		if err != nil {
+			return nil, fmt.Errorf("variable $%s: %v", name, err)
+		}
+		return c, nil
+	}
+	if nn, ok := t.(nonNull); ok {
+		if v == nil {
+			return nil, fmt.Errorf("null value for %s", t)
+		}
+		return coerce(nn.of, v, vars)
+	}
+	if v == nil {
+		return nil, nil
+	}
+	switch t := t.(type) {
+	case listType:
+		list, ok := v.([]any)
+		if !ok {
+			list = []any{v}
+		}
+		out := make([]any, len(list))
+		for i, x := range list {
+			c, err := coerce(t.of, x, vars)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = c
+		}
+		return out, nil
+	case *Scalar:
+		switch t {
+		case String:
+			if s, ok := v.(string); ok {
+				return s, nil
+			}
+		case Boolean:
+			if b, ok := v.(bool); ok {
+				return b, nil
+			}
+		case Int:
+			switch n := v.(type) {
+			case int:
+				return n, nil
+			case float64: // from JSON
+				if n == math.Trunc(n) && math.Abs(n) <= math.MaxInt32 {
+					return int(n), nil
+				}
+			}
+		case Float:
+			switch n := v.(type) {
+			case int:
+				return float64(n), nil
+			case float64:
+				return n, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("invalid value %v for %s", v, t)
+}
+
+// run executes the operation.
+func (e *executor) run(ctx context.Context) *Response {
+	data := e.selectObject(ctx, e.schema.Query, nil, e.op.sel, nil)
+	if data == nil {
+		// The error propagated to the root; data is null.
+		return &Response{Data: json.RawMessage("null"), Errors: e.errs}
+	}
+	return &Response{Data: data, Errors: e.errs}
+}
+
+// An object is an object in a response: a list of keys and values,
+// marshaled to JSON in order.
+type object []keyValue
+
+type keyValue struct {
+	key   string
+	value any
+}
+
+func (o object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, kv := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, _ := json.Marshal(kv.key)
+		buf.Write(k)
+		buf.WriteByte(':')
+		v, err := json.Marshal(kv.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// errNull marks a null value for a non-null field,
+// which makes its parent null in turn.
+var errNull = errors.New("null")
+
+// collect returns the fields selected by sel, expanding fragments,
+// grouped by response key, in order of first appearance.
+func (e *executor) collect(sel []*selection, keys []string, groups map[string][]*selection) ([]string, map[string][]*selection) {
+	if groups == nil {
+		groups = make(map[string][]*selection)
+	}
+	for _, s := range sel {
+		switch {
+		case s.spread != "":
+			keys, groups = e.collect(e.doc.fragments[s.spread].sel, keys, groups)
+		case s.inline:
+			keys, groups = e.collect(s.sel, keys, groups)
+		default:
+			k := s.key()
+			if groups[k] == nil {
+				keys = append(keys, k)
+			}
+			groups[k] = append(groups[k], s)
+		}
+	}
+	return keys, groups
+}
+
+// selectObject returns the selected fields of source, an object of type obj,
+// at path in the response, or nil if a non-null field is null.
+func (e *executor) selectObject(ctx context.Context, obj *Object, source any, sel []*selection, path []any) object {
+	if err := ctx.Err(); err != nil {
+		// Leave the rest of the response null.
+		if !e.stopped {
+			e.errs = append(e.errs, &Error{Message: err.Error(), Path: path})
+			e.stopped = true
+		}
+		return nil
+	}
+	keys, groups := e.collect(sel, nil, nil)
+	out := make(object, 0, len(keys))
+	for _, k := range keys {
+		fields := groups[k]
+		s := fields[0]
+		if s.name == "__typename" {
+			out = append(out, keyValue{k, obj.Name})
+			continue
+		}
+		// Merge the subselections of fields with the same key.
+		var sub []*selection
+		for _, f := range fields {
+			sub = append(sub, f.sel...)
+		}
+		f := obj.field(s.name)
+		fpath := append(path[:len(path):len(path)], k)
+		v, err := e.resolve(ctx, f, source, s)
+		if err != nil {
+			e.errs = append(e.errs, &Error{Message: err.Error(), Path: fpath})
+			v = nil
+		}
+		v, err = e.complete(ctx, f.Type, v, sub, fpath)
+		if err != nil {
+			return nil
+		}
+		out = append(out, keyValue{k, v})
+	}
+	return out
+}
+
+// resolve returns the value of field f of source, as selected by s.
+func (e *executor) resolve(ctx context.Context, f *Field, source any, s *selection) (v any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("ERROR graphql: resolving %s: %v", f.Name, r)
+			v, err = nil, fmt.Errorf("internal error resolving %s", f.Name)
+		}
+	}()
+	if f.Resolve == nil {
+		return defaultResolve(f.Name, source)
+	}
+	args := make(map[string]any)
+	for _, d := range f.Args {
+		if d.Default != nil {
+			args[d.Name] = d.Default
+		} else {
+			args[d.Name] = nil
+		}
+	}
+	for _, a := range s.args {
+		var d *Arg
+		for _, x := range f.Args {
+			if x.Name == a.name {
+				d = x
+			}
+		}
+		c, err := coerce(d.Type, a.val, e.vars)
+		if err != nil {
+			return nil, err
+		}
+		if _, isVar := a.val.(variable); isVar && c == nil && d.Default != nil {
+			continue // unset variable: use the default
+		}
+		args[a.name] = c
+	}
+	return f.Resolve(ctx, source, args)
+}
+
+// complete converts the Go value v to a response value of type t,
+// selecting sel from objects, at path in the response.
+// It returns errNull if v is null but t is non-null.
+func (e *executor) complete(ctx context.Context, t Type, v any, sel []*selection, path []any) (any, error) {
+	if nn, ok := t.(nonNull); ok {
+		c, err := e.complete(ctx, nn.of, v, sel, path)
+		if err == nil && c == nil {
+			if !e.hasError(path) {
+				e.errs = append(e.errs, &Error{Message: "null value for non-null field", Path: path})
+			}
+			err = errNull
+		}
+		return c, err
+	}
+	if isNil(v) {
+		return nil, nil
+	}
+	switch t := t.(type) {
+	case listType:
+		rv := reflect.ValueOf(v)
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+			e.errs = append(e.errs, &Error{Message: fmt.Sprintf("internal error: %T is not a list", v), Path: path})
+			return nil, nil
+		}
+		out := make([]any, rv.Len())
+		for i := range out {
+			c, err := e.complete(ctx, t.of, rv.Index(i).Interface(), sel, append(path[:len(path):len(path)], i))
+			if err != nil {
+				return nil, nil // null list for null non-null element
+			}
+			out[i] = c
+		}
+		return out, nil
+	case *Object:
+		o := e.selectObject(ctx, t, v, sel, path)
+		if o == nil {
+			return nil, nil
+		}
+		return o, nil
+	case *Scalar:
+		c, err := serialize(t, v)
+		if err != nil {
+			e.errs = append(e.errs, &Error{Message: err.Error(), Path: path})
+			return nil, nil
+		}
+		return c, nil
+	}
+	return nil, fmt.Errorf("unknown type %v", t)
+}
+
+// hasError reports whether an error has been recorded at path or below it.
+func (e *executor) hasError(path []any) bool {
+	for _, err := range e.errs {
+		if len(err.Path) >= len(path) && reflect.DeepEqual(err.Path[:len(path)], path) {
+			return true
+		}
+	}
+	return false
+}
+
+func isNil(v any) bool {
+	if v == nil {
+		return true
+	}
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice, reflect.Interface:
+		return rv.IsNil()
+	}
+	return false
+}
+
+// serialize converts the Go value v to a response value of scalar type t.
+// Strings are also accepted from fmt.Stringers and time.Times,
+// formatted in RFC 3339 form.
+func serialize(t *Scalar, v any) (any, error) {
+	rv := reflect.ValueOf(v)
+	switch t {
+	case String:
+		switch v := v.(type) {
+		case time.Time:
+			return v.Format(time.RFC3339), nil
+		case fmt.Stringer:
+			return v.String(), nil
+		}
+		if rv.Kind() == reflect.String {
+			return rv.String(), nil
+		}
+	case Boolean:
+		if rv.Kind() == reflect.Bool {
+			return rv.Bool(), nil
+		}
+	case Int:
+		if rv.CanInt() && rv.Int() >= math.MinInt32 && rv.Int() <= math.MaxInt32 {
+			return rv.Int(), nil
+		}
+		if rv.CanUint() && rv.Uint() <= math.MaxInt32 {
+			return rv.Uint(), nil
+		}
+	case Float:
+		switch {
+		case rv.CanFloat():
+			return rv.Float(), nil
+		case rv.CanInt():
+			return float64(rv.Int()), nil
+		}
+	}
+	return nil, fmt.Errorf("cannot represent %T value as %s", v, t)
+}
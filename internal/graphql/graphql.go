@@ -0,0 +1,328 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package graphql implements the subset of GraphQL (https://spec.graphql.org/)
+// needed to serve read-only site data to client apps.
+//
+// A Schema is built from Go values: Objects, whose Fields have Go resolver
+// functions, and the built-in scalars String, Int, Float, and Boolean.
+// Queries may use variables, aliases, arguments, and named and inline
+// fragments, and may ask for __typename.
+// Mutations, subscriptions, directives, and introspection are not supported;
+// instead, the schema is published in the GraphQL schema language,
+// as printed by Schema.String.
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// A Type is a GraphQL type: a *Scalar, an *Object,
+// or a type returned by List or NonNull.
+type Type interface {
+	String() string
+}
+
+// A Scalar is a scalar type. The only scalars are the built-in ones.
+type Scalar struct {
+	Name string
+}
+
+func (s *Scalar) String() string { return s.Name }
+
+// The built-in scalar types.
+var (
+	String  = &Scalar{"String"}
+	Int     = &Scalar{"Int"}
+	Float   = &Scalar{"Float"}
+	Boolean = &Scalar{"Boolean"}
+)
+
+// An Object is an object type.
+type Object struct {
+	Name   string
+	Doc    string
+	Fields []*Field
+}
+
+func (o *Object) String() string { return o.Name }
+
+// field returns the field of o with the given name, or nil.
+func (o *Object) field(name string) *Field {
+	for _, f := range o.Fields {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// List returns the type of lists of values of type of.
+func List(of Type) Type { return listType{of} }
+
+// NonNull returns the type of non-null values of type of.
+func NonNull(of Type) Type { return nonNull{of} }
+
+type listType struct {
+	of Type
+}
+
+func (l listType) String() string { return "[" + l.of.String() + "]" }
+
+type nonNull struct {
+	of Type
+}
+
+func (n nonNull) String() string { return n.of.String() + "!" }
+
+// A Field is a field of an object type.
+type Field struct {
+	Name string
+	Doc  string
+	Args []*Arg
+	Type Type
+
+	// Resolve returns the value of the field of source,
+	// the Go value of the object, given the field's arguments,
+	// which have been checked and coerced to Go values:
+	// string, int, float64, bool, and []any for lists,
+	// or nil for a missing argument of nullable type with no default.
+	// Object values may be any Go value accepted by the object's fields' resolvers;
+	// list values must be slices.
+	//
+	// If Resolve is nil, the value is that of source's exported struct field
+	// named like the GraphQL field ignoring case, or of its map entry
+	// for the field name, if source is a map[string]any.
+	Resolve func(ctx context.Context, source any, args map[string]any) (any, error)
+}
+
+// An Arg is an argument to a field.
+type Arg struct {
+	Name    string
+	Doc     string
+	Type    Type
+	Default any // if non-nil, the value used when the argument is missing
+}
+
+// A Schema is a GraphQL schema, serving queries from its Query object.
+type Schema struct {
+	Query *Object
+
+	// MaxDepth is the maximum depth of nested selections in a query,
+	// counting fields within fragments where they are used.
+	// Zero means DefaultMaxDepth.
+	MaxDepth int
+
+	// MaxFields is the maximum number of selections in a query,
+	// counting those within fragments each time they are used.
+	// Zero means DefaultMaxFields.
+	MaxFields int
+}
+
+// DefaultMaxDepth is the maximum query depth of a Schema with no MaxDepth.
+const DefaultMaxDepth = 8
+
+// DefaultMaxFields is the maximum number of selections
+// in a query to a Schema with no MaxFields.
+const DefaultMaxFields = 1000
+
+// String returns the schema in the GraphQL schema language.
+func (s *Schema) String() string {
+	var b strings.Builder
+	b.WriteString("schema {\n  query: " + s.Query.Name + "\n}\n")
+	for _, o := range s.objects() {
+		b.WriteString("\n")
+		writeDoc(&b, "", o.Doc)
+		fmt.Fprintf(&b, "type %s {\n", o.Name)
+		for _, f := range o.Fields {
+			writeDoc(&b, "  ", f.Doc)
+			b.WriteString("  " + f.Name)
+			if len(f.Args) > 0 {
+				b.WriteString("(")
+				for i, a := range f.Args {
+					if i > 0 {
+						b.WriteString(", ")
+					}
+					fmt.Fprintf(&b, "%s: %s", a.Name, a.Type)
+					if a.Default != nil {
+						def, _ := json.Marshal(a.Default)
+						b.WriteString(" = " + string(def))
+					}
+				}
+				b.WriteString(")")
+			}
+			fmt.Fprintf(&b, ": %s\n", f.Type)
+		}
+		b.WriteString("}\n")
+	}
+	return b.String()
+}
+
+// writeDoc writes doc to b as a GraphQL description, indented by indent.
+func writeDoc(b *strings.Builder, indent, doc string) {
+	if doc == "" {
+		return
+	}
+	b.WriteString(indent + `"""` + "\n")
+	for _, line := range strings.Split(doc, "\n") {
+		b.WriteString(indent + line + "\n")
+	}
+	b.WriteString(indent + `"""` + "\n")
+}
+
+// objects returns the object types reachable from s.Query,
+// starting with s.Query and then sorted by name.
+func (s *Schema) objects() []*Object {
+	seen := map[*Object]bool{}
+	var list []*Object
+	var walk func(Type)
+	walk = func(t Type) {
+		switch t := t.(type) {
+		case listType:
+			walk(t.of)
+		case nonNull:
+			walk(t.of)
+		case *Object:
+			if seen[t] {
+				return
+			}
+			seen[t] = true
+			list = append(list, t)
+			for _, f := range t.Fields {
+				walk(f.Type)
+			}
+		}
+	}
+	walk(s.Query)
+	sort.Slice(list[1:], func(i, j int) bool { return list[1+i].Name < list[1+j].Name })
+	return list
+}
+
+// A request is a GraphQL request, as sent in JSON over HTTP.
+type request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// An Error is an error reported in a GraphQL response.
+type Error struct {
+	Message string `json:"message"`
+	Path    []any  `json:"path,omitempty"`
+}
+
+func (e *Error) Error() string { return e.Message }
+
+// A Response is the result of executing a query.
+type Response struct {
+	Data   any      `json:"data,omitempty"`
+	Errors []*Error `json:"errors,omitempty"`
+}
+
+// Do executes the operation named op (which may be empty if the query has
+// only one operation) in the query, with the given variables.
+// If the query is invalid, the response has no data.
+func (s *Schema) Do(ctx context.Context, query, op string, vars map[string]any) *Response {
+	doc, err := parse(query)
+	if err != nil {
+		return &Response{Errors: []*Error{{Message: err.Error()}}}
+	}
+	e, err := s.prepare(doc, op, vars)
+	if err != nil {
+		return &Response{Errors: []*Error{{Message: err.Error()}}}
+	}
+	return e.run(ctx)
+}
+
+// maxRequestBody is the largest accepted request body.
+const maxRequestBody = 64 << 10
+
+// ServeHTTP serves queries sent as described at
+// https://graphql.github.io/graphql-over-http/:
+// in GET query parameters, or in the body of a POST
+// with content type application/json or application/graphql.
+// A GET without a query serves the schema in the GraphQL schema language.
+// Invalid queries are answered with 400 Bad Request.
+func (s *Schema) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req request
+	switch r.Method {
+	case "GET", "HEAD":
+		q := r.URL.Query()
+		req.Query = q.Get("query")
+		req.OperationName = q.Get("operationName")
+		if req.Query == "" {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			io.WriteString(w, s.String())
+			return
+		}
+		if v := q.Get("variables"); v != "" {
+			if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+				http.Error(w, "invalid variables: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+	case "POST":
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBody))
+		if err != nil {
+			http.Error(w, "reading request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		switch mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mt {
+		case "application/json":
+			if err := json.Unmarshal(body, &req); err != nil {
+				http.Error(w, "invalid request: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+		case "application/graphql":
+			req.Query = string(body)
+		default:
+			http.Error(w, "unsupported content type; want application/json", http.StatusUnsupportedMediaType)
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, HEAD, POST")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	resp := s.Do(r.Context(), req.Query, req.OperationName, req.Variables)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if resp.Data == nil {
+		w.WriteHeader(http.StatusBadRequest)
+	}
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Printf("ERROR writing GraphQL response: %v", err)
+	}
+}
+
+// defaultResolve resolves a field with no Resolve function.
+func defaultResolve(name string, source any) (any, error) {
+	if m, ok := source.(map[string]any); ok {
+		return m[name], nil
+	}
+	v := reflect.ValueOf(source)
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("cannot resolve field %s of %T", name, source)
+	}
+	f := v.FieldByNameFunc(func(s string) bool { return strings.EqualFold(s, name) })
+	if !f.IsValid() || !f.CanInterface() {
+		return nil, fmt.Errorf("%T has no field %s", source, name)
+	}
+	return f.Interface(), nil
+}
@@ -0,0 +1,274 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+type book struct {
+	Title  string
+	Pages  int
+	Author *author
+	Tags   []string
+}
+
+type author struct {
+	Name string
+}
+
+var books = []*book{
+	{"The Go Programming Language", 380, &author{"Donovan"}, []string{"go", "programming"}},
+	{"Structure and Interpretation", 657, nil, nil},
+}
+
+func testSchema() *Schema {
+	authorType := &Object{
+		Name:   "Author",
+		Fields: []*Field{{Name: "name", Type: NonNull(String)}},
+	}
+	bookType := &Object{
+		Name: "Book",
+		Doc:  "A Book is a book.",
+		Fields: []*Field{
+			{Name: "title", Type: NonNull(String)},
+			{Name: "pages", Type: Int},
+			{Name: "author", Type: authorType},
+			{Name: "tags", Type: List(NonNull(String))},
+			{
+				Name: "broken",
+				Type: String,
+				Resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+					return nil, errors.New("broken field")
+				},
+			},
+			{
+				Name: "required",
+				Type: NonNull(String),
+				Resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+					return nil, nil
+				},
+			},
+		},
+	}
+	bookType.Fields = append(bookType.Fields, &Field{
+		Name: "related",
+		Type: List(bookType),
+		Resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+			return books, nil
+		},
+	})
+	return &Schema{
+		MaxDepth: 3,
+		Query: &Object{
+			Name: "Query",
+			Fields: []*Field{
+				{
+					Name: "books",
+					Doc:  "books lists the books.",
+					Args: []*Arg{{Name: "limit", Type: Int, Default: 10}},
+					Type: List(NonNull(bookType)),
+					Resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+						n := args["limit"].(int)
+						if n > len(books) {
+							n = len(books)
+						}
+						return books[:n], nil
+					},
+				},
+				{
+					Name: "book",
+					Args: []*Arg{{Name: "title", Type: NonNull(String)}},
+					Type: bookType,
+					Resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+						for _, b := range books {
+							if b.Title == args["title"] {
+								return b, nil
+							}
+						}
+						return nil, nil
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestDo(t *testing.T) {
+	s := testSchema()
+	for _, tt := range []struct {
+		query string
+		vars  map[string]any
+		want  string
+	}{
+		{
+			`{ books { title pages } }`, nil,
+			`{"data":{"books":[{"title":"The Go Programming Language","pages":380},{"title":"Structure and Interpretation","pages":657}]}}`,
+		},
+		{
+			`query Q($n: Int) { first: books(limit: $n) { __typename title author { name } tags } }`,
+			map[string]any{"n": 1.0},
+			`{"data":{"first":[{"__typename":"Book","title":"The Go Programming Language","author":{"name":"Donovan"},"tags":["go","programming"]}]}}`,
+		},
+		{
+			`query ($n: Int) { books(limit: $n) { title } }`, nil,
+			`{"data":{"books":[{"title":"The Go Programming Language"},{"title":"Structure and Interpretation"}]}}`,
+		},
+		{
+			`{ book(title: "none") { title } }`, nil,
+			`{"data":{"book":null}}`,
+		},
+		{
+			`{ book(title: """
+			    Structure and Interpretation
+			""") { ...F ... on Book { pages } } } fragment F on Book { title author { name } }`, nil,
+			`{"data":{"book":{"title":"Structure and Interpretation","author":null,"pages":657}}}`,
+		},
+		{
+			`{ books(limit: 1) { title broken } }`, nil,
+			`{"data":{"books":[{"title":"The Go Programming Language","broken":null}]},"errors":[{"message":"broken field","path":["books",0,"broken"]}]}`,
+		},
+		{
+			`{ books(limit: 1) { title required } }`, nil,
+			`{"data":{"books":null},"errors":[{"message":"null value for non-null field","path":["books",0,"required"]}]}`,
+		},
+		{`{ books { nosuch } }`, nil, `{"errors":[{"message":"1:11: type Book has no field nosuch"}]}`},
+		{`{ books }`, nil, `{"errors":[{"message":"1:3: field books of type [Book!] needs a selection of subfields"}]}`},
+		{`{ books { title { x } } }`, nil, `{"errors":[{"message":"1:11: field title of type String! has no subfields"}]}`},
+		{`{ book { title } }`, nil, `{"errors":[{"message":"1:3: field book requires argument title"}]}`},
+		{`{ books(limit: "x") { title } }`, nil, `{"errors":[{"message":"1:3: argument limit: invalid value x for Int"}]}`},
+		{`{ books(limit: $n) { title } }`, nil, `{"errors":[{"message":"1:3: undeclared variable $n"}]}`},
+		{`query ($t: String!) { book(title: $t) { title } }`, nil, `{"errors":[{"message":"variable $t of type String! is required"}]}`},
+		{`{ books { author { name } } }`, nil, `{"data":{"books":[{"author":{"name":"Donovan"}},{"author":null}]}}`},
+		{`{ books { ... on Book { author { ... on Author { __typename } } } } }`, nil, `{"data":{"books":[{"author":{"__typename":"Author"}},{"author":null}]}}`},
+		{`{ books { ...F } } fragment F on Book { ...F }`, nil, `{"errors":[{"message":"1:41: fragment F spreads itself"}]}`},
+		{`{ book(title: "x") { title } books { related { related { title } } } }`, nil, `{"errors":[{"message":"1:58: query is nested more than 3 levels deep"}]}`},
+		{`{ books(limit: 1) { related { title } } }`, nil, `{"data":{"books":[{"related":[{"title":"The Go Programming Language"},{"title":"Structure and Interpretation"}]}]}}`},
+		{`mutation { books { title } }`, nil, `{"errors":[{"message":"mutation operations are not supported"}]}`},
+		{`{ books { title } `, nil, `{"errors":[{"message":"syntax error at 1:19: expected name, found end of query"}]}`},
+		{`{ books @skip { title } }`, nil, `{"errors":[{"message":"syntax error at 1:9: directives are not supported"}]}`},
+	} {
+		resp := s.Do(context.Background(), tt.query, "", tt.vars)
+		js, err := json.Marshal(resp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(js) != tt.want {
+			t.Errorf("Do(%q):\nhave %s\nwant %s", tt.query, js, tt.want)
+		}
+	}
+}
+
+func TestMaxFields(t *testing.T) {
+	// Each fragment spreads the one before it ten times,
+	// so that F7 selects 10⁷ fields in a query of a few hundred bytes.
+	var query strings.Builder
+	query.WriteString(`{ books { ...F7 } } fragment F0 on Book { title }`)
+	for i := 1; i <= 7; i++ {
+		fmt.Fprintf(&query, " fragment F%d on Book {", i)
+		for range 10 {
+			fmt.Fprintf(&query, " ...F%d", i-1)
+		}
+		query.WriteString(" }")
+	}
+	js, _ := json.Marshal(testSchema().Do(context.Background(), query.String(), "", nil))
+	if want := `{"errors":[{"message":"1:43: query selects more than 1000 fields"}]}`; string(js) != want {
+		t.Errorf("Do(%q) = %s, want %s", query.String(), js, want)
+	}
+}
+
+func TestCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	js, _ := json.Marshal(testSchema().Do(ctx, `{ books { title } }`, "", nil))
+	if want := `{"data":null,"errors":[{"message":"context canceled"}]}`; string(js) != want {
+		t.Errorf("Do with canceled context = %s, want %s", js, want)
+	}
+}
+
+func TestOperationName(t *testing.T) {
+	s := testSchema()
+	q := `query A { books(limit: 1) { title } } query B { books(limit: 1) { pages } }`
+	if resp := s.Do(context.Background(), q, "", nil); resp.Data != nil {
+		t.Errorf("Do with two operations and no name succeeded")
+	}
+	js, _ := json.Marshal(s.Do(context.Background(), q, "B", nil))
+	if want := `{"data":{"books":[{"pages":380}]}}`; string(js) != want {
+		t.Errorf("Do(B) = %s, want %s", js, want)
+	}
+}
+
+func TestServeHTTP(t *testing.T) {
+	s := testSchema()
+	for _, tt := range []struct {
+		method, url, ctype, body string
+		code                     int
+		want                     string
+	}{
+		{"GET", "/graphql?query=" + url.QueryEscape(`{books(limit:1){title}}`), "", "", 200, `{"data":{"books":[{"title":"The Go Programming Language"}]}}`},
+		{"GET", "/graphql?query=" + url.QueryEscape(`query($n:Int){books(limit:$n){pages}}`) + "&variables=" + url.QueryEscape(`{"n":1}`), "", "", 200, `"pages":380`},
+		{"POST", "/graphql", "application/json", `{"query": "query($t: String!) { book(title: $t) { pages } }", "variables": {"t": "Structure and Interpretation"}}`, 200, `{"data":{"book":{"pages":657}}}`},
+		{"POST", "/graphql", "application/graphql", `{ books(limit: 1) { pages } }`, 200, `{"data":{"books":[{"pages":380}]}}`},
+		{"POST", "/graphql", "application/json", `{"query": "{ nosuch }"}`, 400, `has no field nosuch`},
+		{"POST", "/graphql", "text/plain", `{ books { title } }`, http.StatusUnsupportedMediaType, ""},
+		{"POST", "/graphql", "application/json", `{`, 400, "invalid request"},
+		{"DELETE", "/graphql", "", "", http.StatusMethodNotAllowed, ""},
+		{"GET", "/graphql", "", "", 200, "type Book {\n  title: String!\n"},
+		{"GET", "/graphql", "", "", 200, "  books(limit: Int = 10): [Book!]\n"},
+	} {
+		r := httptest.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+		if tt.ctype != "" {
+			r.Header.Set("Content-Type", tt.ctype)
+		}
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, r)
+		if w.Code != tt.code || !strings.Contains(w.Body.String(), tt.want) {
+			t.Errorf("%s %s %s: %d %s\nwant %d with %s", tt.method, tt.url, tt.body, w.Code, w.Body, tt.code, tt.want)
+		}
+	}
+}
+
+func TestSchemaString(t *testing.T) {
+	want := `schema {
+  query: Query
+}
+
+type Query {
+  """
+  books lists the books.
+  """
+  books(limit: Int = 10): [Book!]
+  book(title: String!): Book
+}
+
+type Author {
+  name: String!
+}
+
+"""
+A Book is a book.
+"""
+type Book {
+  title: String!
+  pages: Int
+  author: Author
+  tags: [String!]
+  broken: String
+  required: String!
+  related: [Book]
+}
+`
+	if have := testSchema().String(); have != want {
+		t.Errorf("String() =\n%s\nwant:\n%s", have, want)
+	}
+}
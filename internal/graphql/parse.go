@@ -0,0 +1,493 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphql
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A document is a parsed GraphQL query document.
+type document struct {
+	src       string
+	ops       []*operation
+	fragments map[string]*fragment
+}
+
+// An operation is an operation definition in a document.
+type operation struct {
+	kind string // "query", "mutation", or "subscription"
+	name string
+	vars []*varDef
+	sel  []*selection
+}
+
+// A varDef is a variable definition.
+type varDef struct {
+	name string
+	typ  *typeRef
+	def  any // default value, or nil
+}
+
+// A typeRef is a type as written in a query.
+type typeRef struct {
+	name    string   // named type, if elem is nil
+	elem    *typeRef // element type of a list type
+	nonNull bool
+}
+
+func (t *typeRef) String() string {
+	s := t.name
+	if t.elem != nil {
+		s = "[" + t.elem.String() + "]"
+	}
+	if t.nonNull {
+		s += "!"
+	}
+	return s
+}
+
+// A fragment is a named fragment definition.
+type fragment struct {
+	name string
+	on   string
+	sel  []*selection
+}
+
+// A selection is a field, a fragment spread, or an inline fragment.
+type selection struct {
+	pos int // offset in query
+
+	// For a field:
+	alias, name string
+	args        []*argument
+
+	// For a fragment spread:
+	spread string
+
+	// For an inline fragment:
+	inline bool
+	on     string // optional type condition
+
+	sel []*selection // for a field or an inline fragment
+}
+
+// key returns the response key of the field s.
+func (s *selection) key() string {
+	if s.alias != "" {
+		return s.alias
+	}
+	return s.name
+}
+
+// An argument is an argument given to a field.
+type argument struct {
+	name string
+	val  any
+}
+
+// Values in a query are represented as Go values:
+// string, int, float64, bool, nil for null, []any, map[string]any,
+// and these for enum values and variables.
+type (
+	enumValue string
+	variable  string
+)
+
+// A parser parses a query document.
+type parser struct {
+	src string
+	pos int // offset of current token
+	end int // offset after current token
+	tok string
+	typ tokenType
+}
+
+type tokenType int
+
+const (
+	tokEOF tokenType = iota
+	tokPunct
+	tokName
+	tokInt
+	tokFloat
+	tokString
+)
+
+// A syntaxError is an error in the syntax of a query.
+type syntaxError struct {
+	line, col int
+	msg       string
+}
+
+func (e *syntaxError) Error() string {
+	return fmt.Sprintf("syntax error at %d:%d: %s", e.line, e.col, e.msg)
+}
+
+// lineCol returns the 1-based line and column of offset pos in src.
+func lineCol(src string, pos int) (line, col int) {
+	line = 1 + strings.Count(src[:pos], "\n")
+	col = 1 + pos - (strings.LastIndex(src[:pos], "\n") + 1)
+	return line, col
+}
+
+// parse parses the query document src.
+func parse(src string) (doc *document, err error) {
+	p := &parser{src: src}
+	defer func() {
+		if e := recover(); e != nil {
+			se, ok := e.(*syntaxError)
+			if !ok {
+				panic(e)
+			}
+			doc, err = nil, se
+		}
+	}()
+	p.next()
+	doc = &document{src: src, fragments: make(map[string]*fragment)}
+	for p.typ != tokEOF {
+		switch {
+		case p.tok == "{":
+			doc.ops = append(doc.ops, &operation{kind: "query", sel: p.selectionSet()})
+		case p.typ == tokName && (p.tok == "query" || p.tok == "mutation" || p.tok == "subscription"):
+			doc.ops = append(doc.ops, p.operation())
+		case p.typ == tokName && p.tok == "fragment":
+			f := p.fragment()
+			if doc.fragments[f.name] != nil {
+				p.errorf("duplicate fragment %s", f.name)
+			}
+			doc.fragments[f.name] = f
+		default:
+			p.errorf("unexpected %s", p.desc())
+		}
+	}
+	if len(doc.ops) == 0 {
+		return nil, &syntaxError{1, 1, "no operations in query"}
+	}
+	return doc, nil
+}
+
+func (p *parser) errorf(format string, args ...any) {
+	line, col := lineCol(p.src, p.pos)
+	panic(&syntaxError{line, col, fmt.Sprintf(format, args...)})
+}
+
+// desc describes the current token for error messages.
+func (p *parser) desc() string {
+	if p.typ == tokEOF {
+		return "end of query"
+	}
+	return strconv.Quote(p.tok)
+}
+
+// next advances to the next token.
+func (p *parser) next() {
+	s := p.src
+	i := p.end
+	for i < len(s) {
+		switch c := s[i]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			i++
+			continue
+		case c == '#':
+			for i < len(s) && s[i] != '\n' {
+				i++
+			}
+			continue
+		case strings.HasPrefix(s[i:], "\ufeff"):
+			i += len("\ufeff")
+			continue
+		}
+		break
+	}
+	p.pos = i
+	if i == len(s) {
+		p.typ, p.tok, p.end = tokEOF, "", i
+		return
+	}
+	j := i + 1
+	switch c := s[i]; {
+	case strings.ContainsRune("!$&()=:@[]{}|", rune(c)):
+		p.typ = tokPunct
+	case c == '.':
+		if !strings.HasPrefix(s[i:], "...") {
+			p.errorf("unexpected .")
+		}
+		p.typ, j = tokPunct, i+3
+	case isNameStart(c):
+		for j < len(s) && (isNameStart(s[j]) || isDigit(s[j])) {
+			j++
+		}
+		p.typ = tokName
+	case c == '-' || isDigit(c):
+		p.typ = tokInt
+		for j < len(s) && (isDigit(s[j]) || strings.IndexByte(".eE+-", s[j]) >= 0) {
+			if !isDigit(s[j]) {
+				p.typ = tokFloat
+			}
+			j++
+		}
+	case c == '"':
+		if strings.HasPrefix(s[i:], `"""`) {
+			k := strings.Index(s[i+3:], `"""`)
+			if k < 0 {
+				p.errorf("unterminated block string")
+			}
+			j = i + 3 + k + 3
+		} else {
+			for j < len(s) && s[j] != '"' && s[j] != '\n' {
+				if s[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(s) || s[j] != '"' {
+				p.errorf("unterminated string")
+			}
+			j++
+		}
+		p.typ = tokString
+	default:
+		p.errorf("unexpected character %q", c)
+	}
+	p.tok, p.end = s[i:j], j
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+// expect consumes the punctuator tok.
+func (p *parser) expect(tok string) {
+	if p.typ != tokPunct || p.tok != tok {
+		p.errorf("expected %s, found %s", tok, p.desc())
+	}
+	p.next()
+}
+
+// accept consumes the punctuator tok, if it is next,
+// reporting whether it was.
+func (p *parser) accept(tok string) bool {
+	if p.typ == tokPunct && p.tok == tok {
+		p.next()
+		return true
+	}
+	return false
+}
+
+// name consumes and returns a name.
+func (p *parser) name() string {
+	if p.typ != tokName {
+		p.errorf("expected name, found %s", p.desc())
+	}
+	s := p.tok
+	p.next()
+	return s
+}
+
+func (p *parser) operation() *operation {
+	op := &operation{kind: p.name()}
+	if p.typ == tokName {
+		op.name = p.name()
+	}
+	if p.accept("(") {
+		for !p.accept(")") {
+			p.expect("$")
+			v := &varDef{name: p.name()}
+			p.expect(":")
+			v.typ = p.typeRef()
+			if p.accept("=") {
+				v.def = p.value(true)
+			}
+			op.vars = append(op.vars, v)
+		}
+	}
+	p.noDirectives()
+	op.sel = p.selectionSet()
+	return op
+}
+
+func (p *parser) fragment() *fragment {
+	p.name() // "fragment"
+	f := &fragment{name: p.name()}
+	if f.name == "on" {
+		p.errorf("fragment cannot be named on")
+	}
+	if p.name() != "on" {
+		p.errorf("expected on")
+	}
+	f.on = p.name()
+	p.noDirectives()
+	f.sel = p.selectionSet()
+	return f
+}
+
+func (p *parser) noDirectives() {
+	if p.typ == tokPunct && p.tok == "@" {
+		p.errorf("directives are not supported")
+	}
+}
+
+func (p *parser) typeRef() *typeRef {
+	t := new(typeRef)
+	if p.accept("[") {
+		t.elem = p.typeRef()
+		p.expect("]")
+	} else {
+		t.name = p.name()
+	}
+	t.nonNull = p.accept("!")
+	return t
+}
+
+func (p *parser) selectionSet() []*selection {
+	p.expect("{")
+	var list []*selection
+	for !p.accept("}") {
+		list = append(list, p.selection())
+	}
+	if len(list) == 0 {
+		p.errorf("empty selection set")
+	}
+	return list
+}
+
+func (p *parser) selection() *selection {
+	s := &selection{pos: p.pos}
+	if p.accept("...") {
+		if p.typ == tokName && p.tok != "on" {
+			s.spread = p.name()
+			p.noDirectives()
+			return s
+		}
+		s.inline = true
+		if p.typ == tokName {
+			p.name() // "on"
+			s.on = p.name()
+		}
+		p.noDirectives()
+		s.sel = p.selectionSet()
+		return s
+	}
+	s.name = p.name()
+	if p.accept(":") {
+		s.alias, s.name = s.name, p.name()
+	}
+	if p.accept("(") {
+		for !p.accept(")") {
+			a := &argument{name: p.name()}
+			p.expect(":")
+			a.val = p.value(false)
+			s.args = append(s.args, a)
+		}
+	}
+	p.noDirectives()
+	if p.typ == tokPunct && p.tok == "{" {
+		s.sel = p.selectionSet()
+	}
+	return s
+}
+
+// value parses a value. If isConst is set, variables are not allowed.
+func (p *parser) value(isConst bool) any {
+	tok := p.tok
+	switch p.typ {
+	case tokPunct:
+		switch tok {
+		case "$":
+			if isConst {
+				p.errorf("variable not allowed in constant value")
+			}
+			p.next()
+			return variable(p.name())
+		case "[":
+			p.next()
+			list := []any{}
+			for !p.accept("]") {
+				list = append(list, p.value(isConst))
+			}
+			return list
+		case "{":
+			p.next()
+			obj := map[string]any{}
+			for !p.accept("}") {
+				name := p.name()
+				p.expect(":")
+				obj[name] = p.value(isConst)
+			}
+			return obj
+		}
+	case tokName:
+		p.next()
+		switch tok {
+		case "true":
+			return true
+		case "false":
+			return false
+		case "null":
+			return nil
+		}
+		return enumValue(tok)
+	case tokInt:
+		n, err := strconv.ParseInt(tok, 10, 32)
+		if err != nil {
+			p.errorf("invalid Int %s", tok)
+		}
+		p.next()
+		return int(n)
+	case tokFloat:
+		f, err := strconv.ParseFloat(tok, 64)
+		if err != nil {
+			p.errorf("invalid Float %s", tok)
+		}
+		p.next()
+		return f
+	case tokString:
+		var s string
+		if strings.HasPrefix(tok, `"""`) {
+			s = blockString(tok[3 : len(tok)-3])
+		} else if err := json.Unmarshal([]byte(tok), &s); err != nil {
+			p.errorf("invalid string %s", tok)
+		}
+		p.next()
+		return s
+	}
+	p.errorf("expected value, found %s", p.desc())
+	panic("unreachable")
+}
+
+// blockString returns the value of a block string with the given body,
+// removing its common indentation and leading and trailing blank lines.
+func blockString(body string) string {
+	body = strings.ReplaceAll(body, `\"""`, `"""`)
+	lines := strings.Split(strings.ReplaceAll(body, "\r\n", "\n"), "\n")
+	indent := -1
+	for _, l := range lines[1:] {
+		t := strings.TrimLeft(l, " \t")
+		if t != "" && (indent < 0 || len(l)-len(t) < indent) {
+			indent = len(l) - len(t)
+		}
+	}
+	for i := 1; i < len(lines) && indent > 0; i++ {
+		if len(lines[i]) >= indent {
+			lines[i] = lines[i][indent:]
+		} else {
+			lines[i] = ""
+		}
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,165 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package ipacl controls access to sensitive routes by client IP address.
+//
+// An ACL allows and denies CIDR prefixes. Routes are named, such as
+// "upload" for the download upload API, so that a configuration
+// can give each its own list; see ParseRules.
+package ipacl
+
+import (
+	"fmt"
+	"net/http"
+	"net/netip"
+	"sort"
+	"strings"
+)
+
+// An ACL is an access control list of IP address prefixes.
+// An address is refused if it is in a Deny prefix;
+// otherwise it is allowed if Allow is empty or it is in an Allow prefix.
+type ACL struct {
+	Allow []netip.Prefix
+	Deny  []netip.Prefix
+}
+
+// Allows reports whether acl allows the address addr,
+// which may be in the IPv4-mapped IPv6 form.
+func (acl *ACL) Allows(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, p := range acl.Deny {
+		if p.Contains(addr) {
+			return false
+		}
+	}
+	if len(acl.Allow) == 0 {
+		return true
+	}
+	for _, p := range acl.Allow {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsIP is like Allows but takes the address as a string.
+// It reports false for a string that is not an IP address.
+func (acl *ACL) AllowsIP(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	return err == nil && acl.Allows(addr)
+}
+
+// String returns acl in the form read by ParseACL.
+func (acl *ACL) String() string {
+	var list []string
+	for _, p := range acl.Allow {
+		list = append(list, prefixString(p))
+	}
+	for _, p := range acl.Deny {
+		list = append(list, "!"+prefixString(p))
+	}
+	return strings.Join(list, " ")
+}
+
+// prefixString returns p, omitting the length for a single address.
+func prefixString(p netip.Prefix) string {
+	if p.IsSingleIP() {
+		return p.Addr().String()
+	}
+	return p.String()
+}
+
+// Handler returns a handler that serves requests from the addresses
+// acl allows with h, refusing others with 403 Forbidden.
+// The clientIP function returns the client address of a request,
+// such as clientip.FromRequest, which trusts only the forwarding
+// headers written by the server's own proxies.
+func (acl *ACL) Handler(h http.Handler, clientIP func(*http.Request) string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !acl.AllowsIP(clientIP(r)) {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// ParsePrefix parses an IP address or CIDR prefix,
+// such as "10.0.0.0/8" or "::1".
+func ParsePrefix(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		p, err := netip.ParsePrefix(s)
+		if err != nil {
+			return netip.Prefix{}, fmt.Errorf("invalid address range %q; want an IP address or CIDR prefix like 10.0.0.0/8", s)
+		}
+		return p.Masked(), nil
+	}
+	a, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("invalid address %q; want an IP address or CIDR prefix like 10.0.0.0/8", s)
+	}
+	return netip.PrefixFrom(a, a.BitLen()), nil
+}
+
+// ParseACL parses a space-separated list of IP addresses and CIDR
+// prefixes to allow, with those to deny marked by a leading "!".
+// For example:
+//
+//	10.0.0.0/8 !10.9.0.0/16 ::1
+func ParseACL(s string) (*ACL, error) {
+	acl := new(ACL)
+	for _, f := range strings.Fields(s) {
+		deny := strings.HasPrefix(f, "!")
+		p, err := ParsePrefix(strings.TrimPrefix(f, "!"))
+		if err != nil {
+			return nil, err
+		}
+		if deny {
+			acl.Deny = append(acl.Deny, p)
+		} else {
+			acl.Allow = append(acl.Allow, p)
+		}
+	}
+	return acl, nil
+}
+
+// Rules map route names to their access control lists.
+type Rules map[string]*ACL
+
+// ParseRules parses a comma-separated list of rules of the form
+// route=acl, where acl is in the form read by ParseACL. For example:
+//
+//	upload=10.0.0.0/8 !10.9.0.0/16,debug=127.0.0.1 ::1
+func ParseRules(s string) (Rules, error) {
+	rules := make(Rules)
+	for _, f := range strings.Split(s, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		name, spec, ok := strings.Cut(f, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" || strings.TrimSpace(spec) == "" {
+			return nil, fmt.Errorf("invalid rule %q; want route=addresses", f)
+		}
+		acl, err := ParseACL(spec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rule %q: %v", f, err)
+		}
+		rules[name] = acl
+	}
+	return rules, nil
+}
+
+// String returns the rules in the form read by ParseRules.
+func (rs Rules) String() string {
+	var list []string
+	for name, acl := range rs {
+		list = append(list, name+"="+acl.String())
+	}
+	sort.Strings(list)
+	return strings.Join(list, ",")
+}
@@ -0,0 +1,70 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ipacl
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseRules(t *testing.T) {
+	rules, err := ParseRules("upload=10.0.0.0/8 !10.9.0.0/16 ::1, debug=127.0.0.1,admin=!192.0.2.0/24")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := rules.String(); s != "admin=!192.0.2.0/24,debug=127.0.0.1,upload=10.0.0.0/8 ::1 !10.9.0.0/16" {
+		t.Errorf("String = %q", s)
+	}
+	for _, s := range []string{"upload", "=10.0.0.0/8", "upload=", "upload=localhost", "upload=10.0.0.0/33", "upload=!"} {
+		if _, err := ParseRules(s); err == nil {
+			t.Errorf("ParseRules(%q) succeeded, want error", s)
+		}
+	}
+}
+
+func TestAllows(t *testing.T) {
+	rules, err := ParseRules("upload=10.0.0.0/8 !10.9.0.0/16 ::1,admin=!192.0.2.0/24")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		route, ip string
+		want      bool
+	}{
+		{"upload", "10.1.2.3", true},
+		{"upload", "::ffff:10.1.2.3", true},
+		{"upload", "10.9.0.1", false},
+		{"upload", "::1", true},
+		{"upload", "192.0.2.1", false},
+		{"upload", "", false},
+		{"upload", "not an address", false},
+		{"admin", "192.0.2.1", false},
+		{"admin", "198.51.100.1", true},
+	}
+	for _, tt := range tests {
+		if got := rules[tt.route].AllowsIP(tt.ip); got != tt.want {
+			t.Errorf("%s AllowsIP(%q) = %v, want %v", tt.route, tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestHandler(t *testing.T) {
+	acl, err := ParseACL("10.0.0.0/8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	h := acl.Handler(ok, func(r *http.Request) string { return r.Header.Get("Client-IP") })
+	for ip, code := range map[string]int{"10.0.0.1": 200, "192.0.2.1": 403} {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Client-IP", ip)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != code {
+			t.Errorf("GET from %s: %d, want %d", ip, w.Code, code)
+		}
+	}
+}
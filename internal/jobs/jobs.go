@@ -0,0 +1,145 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package jobs runs a server's periodic background work,
+// such as refreshing caches and feature flags, under supervision.
+//
+// A Manager runs each Job on its schedule, with random jitter
+// so that many servers do not act in lockstep. It recovers from
+// panics, logs and reports failures, records metrics for every run,
+// and at shutdown cancels the jobs and waits for them to return.
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/matttproud/yourtour/internal/errreport"
+)
+
+// A Job is a unit of background work.
+type Job struct {
+	// Name identifies the job in logs, reports, and metrics.
+	Name string
+
+	// Run does the work. Its context is canceled at shutdown.
+	Run func(ctx context.Context) error
+
+	// Every is the time from the end of one run to the start of the next.
+	// If it is zero, Run is called only once.
+	Every time.Duration
+
+	// Retry, if nonzero, replaces Every as the wait after a failed run,
+	// so that a job can retry a failure sooner, or later, than usual.
+	Retry time.Duration
+
+	// Delay is the wait before the first run.
+	Delay time.Duration
+
+	// Jitter is the largest random time added to every wait.
+	Jitter time.Duration
+}
+
+// errPanic is the error recorded for a run that panicked.
+var errPanic = errors.New("panic")
+
+// A Manager runs jobs until it is shut down.
+type Manager struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	metrics Metrics
+	wg      sync.WaitGroup
+}
+
+// NewManager returns a Manager that runs jobs until ctx is done
+// or Shutdown is called, recording their runs in metrics if it is not nil.
+func NewManager(ctx context.Context, metrics Metrics) *Manager {
+	ctx, cancel := context.WithCancel(ctx)
+	return &Manager{ctx: ctx, cancel: cancel, metrics: metrics}
+}
+
+// Start starts running j in the background.
+func (m *Manager) Start(j Job) {
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		wait := j.Delay
+		for {
+			if !m.sleep(wait, j.Jitter) {
+				return
+			}
+			err := m.run(j)
+			if j.Every == 0 || m.ctx.Err() != nil {
+				return
+			}
+			wait = j.Every
+			if err != nil && j.Retry != 0 {
+				wait = j.Retry
+			}
+		}
+	}()
+}
+
+// sleep waits for d plus up to jitter,
+// reporting false if the manager is shut down first.
+func (m *Manager) sleep(d, jitter time.Duration) bool {
+	if jitter > 0 {
+		d += rand.N(jitter)
+	}
+	if d <= 0 {
+		return m.ctx.Err() == nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-m.ctx.Done():
+		return false
+	}
+}
+
+// run runs j once, recovering from any panic,
+// and logs, reports, and records the outcome.
+func (m *Manager) run(j Job) (err error) {
+	start := time.Now()
+	defer func() {
+		if v := recover(); v != nil {
+			stack := debug.Stack()
+			log.Printf("ERROR job %s panic: %v\n%s", j.Name, v, stack)
+			errreport.JobPanic(j.Name, v, stack)
+			err = fmt.Errorf("%w: %v", errPanic, v)
+		} else if err != nil && m.ctx.Err() == nil {
+			log.Printf("ERROR job %s: %v", j.Name, err)
+			errreport.Job(j.Name, err)
+		}
+		if m.metrics != nil {
+			m.metrics.Run(j.Name, time.Since(start), err)
+		}
+	}()
+	return j.Run(m.ctx)
+}
+
+// Shutdown cancels the jobs' contexts, stops scheduling runs,
+// and waits until running jobs return or ctx is done.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.cancel()
+	done := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jobs
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+type recorder struct {
+	mu   sync.Mutex
+	runs []string
+}
+
+func (r *recorder) Run(job string, d time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	result := "ok"
+	if err != nil {
+		result = err.Error()
+	}
+	r.runs = append(r.runs, job+":"+result)
+}
+
+func (r *recorder) list() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.runs...)
+}
+
+func TestManager(t *testing.T) {
+	var rec recorder
+	m := NewManager(context.Background(), &rec)
+
+	// A job that fails, panics, and then succeeds, retrying quickly.
+	n := 0
+	done := make(chan bool)
+	m.Start(Job{
+		Name: "flaky",
+		Run: func(ctx context.Context) error {
+			n++
+			switch n {
+			case 1:
+				return errors.New("failed")
+			case 2:
+				panic("boom")
+			case 3:
+				close(done)
+			}
+			return nil
+		},
+		Every: time.Hour,
+		Retry: time.Millisecond,
+	})
+
+	// A job that runs once, after a delay.
+	once := make(chan bool)
+	m.Start(Job{Name: "once", Delay: time.Millisecond, Jitter: time.Millisecond, Run: func(ctx context.Context) error {
+		close(once)
+		return nil
+	}})
+
+	// A job that runs until shut down.
+	stopped := make(chan error, 1)
+	m.Start(Job{Name: "long", Run: func(ctx context.Context) error {
+		<-ctx.Done()
+		stopped <- ctx.Err()
+		return ctx.Err()
+	}, Every: time.Millisecond})
+
+	<-done
+	<-once
+	if err := m.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-stopped; err != context.Canceled {
+		t.Errorf("long job context: %v, want context.Canceled", err)
+	}
+
+	got := strings.Join(rec.list(), " ")
+	for _, want := range []string{"flaky:failed", "flaky:panic: boom", "flaky:ok", "once:ok", "long:context canceled"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("runs %q do not include %q", got, want)
+		}
+	}
+	if n != 3 {
+		t.Errorf("flaky job ran %d times, want 3", n)
+	}
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	m := NewManager(context.Background(), nil)
+	release := make(chan bool)
+	defer close(release)
+	started := make(chan bool)
+	m.Start(Job{Name: "stuck", Run: func(ctx context.Context) error {
+		close(started)
+		<-release
+		return nil
+	}})
+	<-started
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := m.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Shutdown = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestPrometheusMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m, err := NewPrometheusMetrics(reg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Run("flags", time.Second, nil)
+	m.Run("flags", time.Second, errors.New("bad"))
+	m.Run("flags", time.Second, errPanic)
+	for result, want := range map[string]float64{"ok": 1, "error": 1, "panic": 1} {
+		if got := testutil.ToFloat64(m.runs.WithLabelValues("flags", result)); got != want {
+			t.Errorf("runs{flags,%s} = %v, want %v", result, got, want)
+		}
+	}
+	if got := testutil.ToFloat64(m.lastSuccess.WithLabelValues("flags")); got == 0 {
+		t.Errorf("last success not recorded")
+	}
+}
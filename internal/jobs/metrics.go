@@ -0,0 +1,74 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jobs
+
+import (
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics receives measurements of job runs from a Manager.
+// Implementations must be safe for concurrent use.
+type Metrics interface {
+	// Run records one completed run of the named job,
+	// which took d and returned err.
+	Run(job string, d time.Duration, err error)
+}
+
+// A PrometheusMetrics is a Metrics that exports its measurements
+// as Prometheus metrics:
+//
+//	jobs_runs_total{job, result}               counter; result is "ok", "error", or "panic"
+//	jobs_run_duration_seconds{job}             histogram
+//	jobs_last_success_timestamp_seconds{job}   gauge
+type PrometheusMetrics struct {
+	runs        *prometheus.CounterVec
+	duration    *prometheus.HistogramVec
+	lastSuccess *prometheus.GaugeVec
+}
+
+var _ Metrics = (*PrometheusMetrics)(nil)
+
+// NewPrometheusMetrics returns a new PrometheusMetrics
+// with its metrics registered with reg.
+func NewPrometheusMetrics(reg prometheus.Registerer) (*PrometheusMetrics, error) {
+	m := &PrometheusMetrics{
+		runs: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "jobs_runs_total",
+			Help: "Background job runs by job and result.",
+		}, []string{"job", "result"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "jobs_run_duration_seconds",
+			Help:    "Duration of background job runs.",
+			Buckets: []float64{.01, .05, .1, .5, 1, 5, 10, 30, 60, 300},
+		}, []string{"job"}),
+		lastSuccess: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "jobs_last_success_timestamp_seconds",
+			Help: "Time of the last successful run of each background job.",
+		}, []string{"job"}),
+	}
+	for _, c := range []prometheus.Collector{m.runs, m.duration, m.lastSuccess} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+func (m *PrometheusMetrics) Run(job string, d time.Duration, err error) {
+	result := "ok"
+	switch {
+	case err == nil:
+		m.lastSuccess.WithLabelValues(job).SetToCurrentTime()
+	case errors.Is(err, errPanic):
+		result = "panic"
+	default:
+		result = "error"
+	}
+	m.runs.WithLabelValues(job, result).Inc()
+	m.duration.WithLabelValues(job).Observe(d.Seconds())
+}
@@ -0,0 +1,212 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package jsonld describes the site's pages to search engines
+// in schema.org structured data, embedded in the pages as JSON-LD,
+// so that layouts need not write the descriptions by hand.
+//
+// A page's description is generated from its page data: its title,
+// summary, date, authors, last-updated date, and preview image.
+// Its metadata can choose its schema.org type with “schema: name”;
+// otherwise docs and codewalks are described as TechArticles,
+// and pages listing releases of software in their releases page data,
+// such as the download page, as SoftwareApplications, one per release.
+// Other pages are not described.
+//
+// The description is set as the page's jsonLD page data,
+// a complete script element for the site template to place
+// in the page's head.
+package jsonld
+
+import (
+	"encoding/json"
+	"html/template"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/matttproud/yourtour/internal/authors"
+	"github.com/matttproud/yourtour/internal/vhost"
+	"github.com/matttproud/yourtour/internal/web"
+)
+
+// vocab is the vocabulary of the descriptions.
+const vocab = "https://schema.org"
+
+// A Release is a release of software listed on a page.
+// Pages listing releases set their releases page data to a []Release.
+type Release struct {
+	Name        string   // such as "Go"
+	Version     string   // such as "go1.22.0"
+	DownloadURL string   // URL path of the release's downloads
+	OS          []string // operating systems it is downloaded for
+}
+
+// RegisterHandlers sets the jsonLD page data of the described pages
+// of h's site. It must be called after the handlers setting
+// the page data it describes, such as authors.RegisterHandlers.
+func RegisterHandlers(h *vhost.Host) {
+	h.Site.AddPageData(pageData)
+}
+
+// pageData sets the jsonLD of the page p, if it is described.
+func pageData(r *http.Request, p web.Page) {
+	if _, ok := p["jsonLD"]; ok {
+		return
+	}
+	d := Describe(p, "https://"+r.Host)
+	if d == nil {
+		return
+	}
+	// Marshal escapes <, >, and &, so the data cannot end the element.
+	js, err := json.Marshal(d)
+	if err != nil {
+		log.Printf("ERROR describing %v: %v", p["URL"], err)
+		return
+	}
+	p["jsonLD"] = template.HTML(`<script type="application/ld+json">` + string(js) + `</script>`)
+}
+
+// Describe returns the structured data describing the page p
+// served from origin, such as https://go.dev, or nil if it is not described.
+func Describe(p web.Page, origin string) any {
+	if _, ok := p["error"]; ok {
+		return nil
+	}
+	url, _ := p["URL"].(string)
+	releases, _ := p["releases"].([]Release)
+	schema, _ := p["schema"].(string)
+	if schema == "" {
+		switch {
+		case releases != nil:
+			schema = "SoftwareApplication"
+		case p["codewalk"] != nil, strings.HasPrefix(url, "/doc/"):
+			schema = "TechArticle"
+		}
+	}
+	switch schema {
+	case "TechArticle", "Article", "BlogPosting":
+		return describeArticle(schema, p, origin)
+	case "SoftwareApplication":
+		return describeSoftware(releases, origin)
+	}
+	return nil
+}
+
+// An article is a schema.org Article, or a type derived from it.
+type article struct {
+	Context       string        `json:"@context"`
+	Type          string        `json:"@type"`
+	Headline      string        `json:"headline"`
+	Description   string        `json:"description,omitempty"`
+	URL           string        `json:"url"`
+	Image         string        `json:"image,omitempty"`
+	DatePublished string        `json:"datePublished,omitempty"`
+	DateModified  string        `json:"dateModified,omitempty"`
+	Author        []person      `json:"author,omitempty"`
+	Publisher     *organization `json:"publisher"`
+	InLanguage    string        `json:"inLanguage"`
+}
+
+// A person is a schema.org Person.
+type person struct {
+	Type string `json:"@type"`
+	Name string `json:"name"`
+	URL  string `json:"url,omitempty"`
+}
+
+// An organization is a schema.org Organization.
+type organization struct {
+	Type string `json:"@type"`
+	Name string `json:"name"`
+	URL  string `json:"url"`
+	Logo string `json:"logo"`
+}
+
+// publisher returns the organization publishing the site at origin.
+func publisher(origin string) *organization {
+	return &organization{
+		Type: "Organization",
+		Name: "The Go Programming Language",
+		URL:  origin + "/",
+		Logo: origin + "/images/go-logo-blue.svg",
+	}
+}
+
+// describeArticle returns the description of the page p as an article.
+func describeArticle(schema string, p web.Page, origin string) any {
+	title, _ := p["title"].(string)
+	if title == "" {
+		return nil
+	}
+	url, _ := p["URL"].(string)
+	a := &article{
+		Context:    vocab,
+		Type:       schema,
+		Headline:   title,
+		URL:        origin + url,
+		Publisher:  publisher(origin),
+		InLanguage: "en",
+	}
+	a.Description, _ = p["summary"].(string)
+	if img, _ := p["ogImage"].(string); img != "" {
+		a.Image = origin + img
+	}
+	if t, ok := p["date"].(time.Time); ok {
+		a.DatePublished = t.Format(time.RFC3339)
+	}
+	if t, ok := p["lastUpdated"].(time.Time); ok {
+		a.DateModified = t.Format(time.RFC3339)
+	}
+	list, _ := p["authorList"].([]*authors.Author)
+	for _, au := range list {
+		a.Author = append(a.Author, person{Type: "Person", Name: au.Name, URL: origin + au.URL()})
+	}
+	return a
+}
+
+// A software is a schema.org SoftwareApplication.
+type software struct {
+	Type                string        `json:"@type"`
+	Name                string        `json:"name"`
+	SoftwareVersion     string        `json:"softwareVersion"`
+	ApplicationCategory string        `json:"applicationCategory"`
+	OperatingSystem     string        `json:"operatingSystem,omitempty"`
+	DownloadURL         string        `json:"downloadUrl"`
+	Offers              offer         `json:"offers"`
+	Publisher           *organization `json:"publisher"`
+}
+
+// An offer is a schema.org Offer.
+type offer struct {
+	Type          string `json:"@type"`
+	Price         string `json:"price"`
+	PriceCurrency string `json:"priceCurrency"`
+}
+
+// describeSoftware returns the description of releases,
+// a graph of the applications released.
+func describeSoftware(releases []Release, origin string) any {
+	if len(releases) == 0 {
+		return nil
+	}
+	var graph []software
+	for _, r := range releases {
+		graph = append(graph, software{
+			Type:                "SoftwareApplication",
+			Name:                r.Name,
+			SoftwareVersion:     r.Version,
+			ApplicationCategory: "DeveloperApplication",
+			OperatingSystem:     strings.Join(r.OS, ", "),
+			DownloadURL:         origin + r.DownloadURL,
+			Offers:              offer{Type: "Offer", Price: "0", PriceCurrency: "USD"},
+			Publisher:           publisher(origin),
+		})
+	}
+	return struct {
+		Context string     `json:"@context"`
+		Graph   []software `json:"@graph"`
+	}{vocab, graph}
+}
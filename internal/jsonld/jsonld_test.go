@@ -0,0 +1,101 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jsonld
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/matttproud/yourtour/internal/authors"
+	"github.com/matttproud/yourtour/internal/router"
+	"github.com/matttproud/yourtour/internal/vhost"
+	"github.com/matttproud/yourtour/internal/web"
+)
+
+func TestDescribe(t *testing.T) {
+	updated := time.Date(2026, 6, 3, 0, 0, 0, 0, time.UTC)
+	for _, tt := range []struct {
+		name string
+		page web.Page
+		want string
+	}{
+		{
+			name: "doc",
+			page: web.Page{"URL": "/doc/install", "title": "Download and install", "summary": "Install Go.", "lastUpdated": updated,
+				"authorList": []*authors.Author{{ID: "gopher", Name: "The Gopher"}}},
+			want: `{"@context":"https://schema.org","@type":"TechArticle","headline":"Download and install","description":"Install Go.",` +
+				`"url":"https://go.dev/doc/install","dateModified":"2026-06-03T00:00:00Z",` +
+				`"author":[{"@type":"Person","name":"The Gopher","url":"https://go.dev/authors/gopher"}],` +
+				`"publisher":{"@type":"Organization","name":"The Go Programming Language","url":"https://go.dev/","logo":"https://go.dev/images/go-logo-blue.svg"},` +
+				`"inLanguage":"en"}`,
+		},
+		{
+			name: "codewalk",
+			page: web.Page{"URL": "/doc/codewalk/sharemem/", "title": "Codewalk: Share Memory", "codewalk": true, "ogImage": "/_og/doc/codewalk/sharemem.png"},
+			want: `{"@context":"https://schema.org","@type":"TechArticle","headline":"Codewalk: Share Memory",` +
+				`"url":"https://go.dev/doc/codewalk/sharemem/","image":"https://go.dev/_og/doc/codewalk/sharemem.png",` +
+				`"publisher":{"@type":"Organization","name":"The Go Programming Language","url":"https://go.dev/","logo":"https://go.dev/images/go-logo-blue.svg"},` +
+				`"inLanguage":"en"}`,
+		},
+		{
+			name: "blog post by schema",
+			page: web.Page{"URL": "/blog/x", "title": "X", "schema": "BlogPosting", "date": updated},
+			want: `{"@context":"https://schema.org","@type":"BlogPosting","headline":"X","url":"https://go.dev/blog/x","datePublished":"2026-06-03T00:00:00Z",` +
+				`"publisher":{"@type":"Organization","name":"The Go Programming Language","url":"https://go.dev/","logo":"https://go.dev/images/go-logo-blue.svg"},` +
+				`"inLanguage":"en"}`,
+		},
+		{
+			name: "releases",
+			page: web.Page{"URL": "/dl/", "title": "All releases", "releases": []Release{{Name: "Go", Version: "go1.22.0", DownloadURL: "/dl/#go1.22.0", OS: []string{"darwin", "linux"}}}},
+			want: `{"@context":"https://schema.org","@graph":[{"@type":"SoftwareApplication","name":"Go","softwareVersion":"go1.22.0",` +
+				`"applicationCategory":"DeveloperApplication","operatingSystem":"darwin, linux","downloadUrl":"https://go.dev/dl/#go1.22.0",` +
+				`"offers":{"@type":"Offer","price":"0","priceCurrency":"USD"},` +
+				`"publisher":{"@type":"Organization","name":"The Go Programming Language","url":"https://go.dev/","logo":"https://go.dev/images/go-logo-blue.svg"}}]}`,
+		},
+		{name: "no releases", page: web.Page{"URL": "/dl/", "title": "All releases", "releases": []Release{}}},
+		{name: "untitled doc", page: web.Page{"URL": "/doc/partial"}},
+		{name: "error page", page: web.Page{"URL": "/doc/missing", "title": "Not found", "error": "not found"}},
+		{name: "other page", page: web.Page{"URL": "/learn/", "title": "Learn"}},
+	} {
+		var got string
+		if d := Describe(tt.page, "https://go.dev"); d != nil {
+			js, err := json.Marshal(d)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = string(js)
+		}
+		if got != tt.want {
+			t.Errorf("%s: Describe =\n%s\nwant:\n%s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPageData(t *testing.T) {
+	fsys := fstest.MapFS{
+		"site.tmpl":    {Data: []byte(`{{.jsonLD}}`)},
+		"doc/index.md": {Data: []byte("---\ntitle: <Docs> & more\n---\n")},
+	}
+	site := web.NewSite(fsys)
+	var reg vhost.Registry
+	RegisterHandlers(reg.Add("", site, fsys, router.New(http.NewServeMux())))
+	w := httptest.NewRecorder()
+	site.ServeHTTP(w, httptest.NewRequest("GET", "https://go.dev/doc/", nil))
+	body := w.Body.String()
+	js, ok := strings.CutPrefix(body, `<script type="application/ld+json">`)
+	js, ok2 := strings.CutSuffix(js, `</script>`)
+	if !ok || !ok2 || strings.ContainsAny(js, "<>") {
+		t.Fatalf("GET /doc/: %q, want escaped JSON-LD script element", body)
+	}
+	var a article
+	if err := json.Unmarshal([]byte(js), &a); err != nil || a.Headline != "<Docs> & more" || a.URL != "https://go.dev/doc/" {
+		t.Errorf("GET /doc/: %+v, %v, want TechArticle for the page", a, err)
+	}
+}
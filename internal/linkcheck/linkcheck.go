@@ -0,0 +1,278 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package linkcheck watches the external links in a site's content
+// for rot, so that broken links are found before readers report them.
+//
+// A Checker's Check, run periodically as a background job, renders
+// the content of every page of the site, collects the absolute
+// http and https links in it, and requests each of them, recording
+// the result in the datastore, one LinkStatus per link.
+// Links that fail, with a network error or a status of 400 or more,
+// are logged with their details as structured warnings and listed,
+// with the pages linking to them, on an administrative report.
+// Statuses for links no longer in the content are deleted.
+package linkcheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/matttproud/yourtour/internal/web"
+	"golang.org/x/net/html"
+)
+
+const kind = "LinkStatus"
+
+// Interval is how often the links are checked.
+const Interval = 24 * time.Hour
+
+// Limits on the work of a check.
+const (
+	maxLinks    = 5000             // links checked
+	maxURL      = 1000             // bytes in a checked URL
+	maxPages    = 10               // pages remembered per link
+	concurrency = 8                // requests in flight
+	timeout     = 15 * time.Second // per request
+)
+
+// A LinkStatus is the outcome of the latest check of a link.
+type LinkStatus struct {
+	URL   string
+	Pages []string // URL paths of pages linking to it, at most maxPages
+
+	Checked time.Time
+	Code    int    // HTTP status, or 0 if the request failed
+	Err     string `datastore:",noindex"` // request error, if any
+
+	// FailingSince is the time of the first of the consecutive failed checks,
+	// and Failures their number. Both are zero for working links.
+	FailingSince time.Time
+	Failures     int
+}
+
+// Failing reports whether the link failed its latest check.
+func (s *LinkStatus) Failing() bool {
+	return s.Failures > 0
+}
+
+// Datastore is the part of a *datastore.Client used by the checker.
+type Datastore interface {
+	GetAll(ctx context.Context, q *datastore.Query, dst any) ([]*datastore.Key, error)
+	Put(ctx context.Context, key *datastore.Key, src any) (*datastore.Key, error)
+	Delete(ctx context.Context, key *datastore.Key) error
+}
+
+var _ Datastore = (*datastore.Client)(nil)
+
+// A Checker checks the external links of a site's pages.
+type Checker struct {
+	site   *web.Site
+	fsys   fs.FS
+	tmpl   string
+	dc     Datastore
+	client *http.Client
+}
+
+// NewChecker returns a checker of the links in the pages of site,
+// whose content is fsys, rendered with the base template tmpl,
+// such as "blogfeed.tmpl", recording their statuses in dc.
+// The template should render only the content of a page,
+// so that the links of the site's navigation are not checked for every page.
+func NewChecker(site *web.Site, fsys fs.FS, tmpl string, dc Datastore) *Checker {
+	return &Checker{
+		site:   site,
+		fsys:   fsys,
+		tmpl:   tmpl,
+		dc:     dc,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// Check checks the links in the site's pages and records their statuses.
+// It returns an error only if the statuses cannot be read or recorded.
+func (c *Checker) Check(ctx context.Context) error {
+	links, err := c.links()
+	if err != nil {
+		return err
+	}
+	var old []*LinkStatus
+	keys, err := c.dc.GetAll(ctx, datastore.NewQuery(kind), &old)
+	if err != nil {
+		return fmt.Errorf("reading link statuses: %v", err)
+	}
+	prev := make(map[string]*LinkStatus)
+	for _, s := range old {
+		prev[s.URL] = s
+	}
+
+	list := make([]*LinkStatus, 0, len(links))
+	for u, pages := range links {
+		list = append(list, &LinkStatus{URL: u, Pages: pages})
+	}
+	slices.SortFunc(list, func(x, y *LinkStatus) int { return strings.Compare(x.URL, y.URL) })
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+	for _, s := range list {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			c.check(ctx, s, prev[s.URL])
+		}()
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, s := range list {
+		if s.Failing() {
+			slog.Warn("failing link", "url", s.URL, "code", s.Code, "err", s.Err,
+				"pages", s.Pages, "since", s.FailingSince, "failures", s.Failures)
+		}
+		if _, err := c.dc.Put(ctx, datastore.NameKey(kind, s.URL, nil), s); err != nil {
+			errs = append(errs, fmt.Errorf("recording %s: %v", s.URL, err))
+		}
+	}
+	for i, s := range old {
+		if _, ok := links[s.URL]; !ok {
+			if err := c.dc.Delete(ctx, keys[i]); err != nil {
+				errs = append(errs, fmt.Errorf("deleting %s: %v", s.URL, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// check requests the link of s, setting the outcome in s.
+// The link's status at the previous check, if any, is prev.
+func (c *Checker) check(ctx context.Context, s, prev *LinkStatus) {
+	s.Checked = time.Now().UTC()
+	code, err := c.fetch(ctx, "HEAD", s.URL)
+	if err == nil && (code == http.StatusMethodNotAllowed || code == http.StatusNotImplemented || code == http.StatusForbidden) {
+		// Some servers answer HEAD requests badly; ask again with GET.
+		code, err = c.fetch(ctx, "GET", s.URL)
+	}
+	s.Code = code
+	if err != nil {
+		s.Err = err.Error()
+	}
+	// Being rate limited says nothing about whether the link works.
+	if err == nil && (code < 400 || code == http.StatusTooManyRequests) {
+		return
+	}
+	s.FailingSince, s.Failures = s.Checked, 1
+	if prev != nil && prev.Failing() {
+		s.FailingSince, s.Failures = prev.FailingSince, prev.Failures+1
+	}
+}
+
+// fetch requests u with method, returning the status of the reply.
+func (c *Checker) fetch(ctx context.Context, method, u string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("User-Agent", "golangorg-linkcheck")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// links returns the external links in the site's pages,
+// each with the sorted URL paths of the pages linking to it.
+func (c *Checker) links() (map[string][]string, error) {
+	links := make(map[string][]string)
+	err := fs.WalkDir(c.fsys, ".", func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || path.Ext(file) != ".md" && path.Ext(file) != ".html" {
+			return nil
+		}
+		pages, err := c.site.Pages(file)
+		if err != nil {
+			slog.Warn("reading page for link check", "file", file, "err", err)
+			return nil
+		}
+		for _, p := range pages {
+			content, err := c.site.RenderContent(p, c.tmpl)
+			if err != nil {
+				slog.Warn("rendering page for link check", "file", file, "err", err)
+				continue
+			}
+			page, _ := p["URL"].(string)
+			for _, u := range extract(string(content)) {
+				list, ok := links[u]
+				if !ok && len(links) >= maxLinks {
+					continue
+				}
+				if len(list) < maxPages && !slices.Contains(list, page) {
+					links[u] = append(list, page)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("collecting links: %v", err)
+	}
+	for _, pages := range links {
+		slices.Sort(pages)
+	}
+	return links, nil
+}
+
+// extract returns the absolute http and https links in the HTML text,
+// without their fragments.
+func extract(text string) []string {
+	var list []string
+	z := html.NewTokenizer(strings.NewReader(text))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return list
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			if string(name) != "a" {
+				continue
+			}
+			for hasAttr {
+				var key, val []byte
+				key, val, hasAttr = z.TagAttr()
+				if string(key) != "href" {
+					continue
+				}
+				u, err := url.Parse(strings.TrimSpace(string(val)))
+				if err != nil || u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+					continue
+				}
+				u.Fragment, u.RawFragment = "", ""
+				if s := u.String(); len(s) <= maxURL && !slices.Contains(list, s) {
+					list = append(list, s)
+				}
+			}
+		}
+	}
+}
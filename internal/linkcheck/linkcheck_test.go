@@ -0,0 +1,130 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package linkcheck
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"cloud.google.com/go/datastore"
+	"github.com/matttproud/yourtour/internal/datastoretest"
+	"github.com/matttproud/yourtour/internal/web"
+)
+
+// getStatus returns the status recorded in dc for the link to url,
+// and whether there is any.
+func getStatus(dc *datastoretest.Datastore, url string) (LinkStatus, bool) {
+	var s LinkStatus
+	err := dc.Get(context.Background(), datastore.NameKey(kind, url, nil), &s)
+	return s, err == nil
+}
+
+func TestExtract(t *testing.T) {
+	got := extract(`<p><a href="https://example.com/a#x">a</a> <a href="/doc/">doc</a>
+		<a href="mailto:gopher@example.com">mail</a> <a href=" http://example.com/b ">b</a>
+		<a href="https://example.com/a">again</a> <img src="https://example.com/c.png"></p>`)
+	want := []string{"https://example.com/a", "http://example.com/b"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("extract = %q, want %q", got, want)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		gone = map[string]bool{"/gone": true}
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case gone[r.URL.Path]:
+			http.NotFound(w, r)
+		case r.URL.Path == "/nohead" && r.Method == "HEAD":
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		case r.URL.Path == "/busy":
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer srv.Close()
+
+	fsys := fstest.MapFS{
+		"site.tmpl":    {Data: []byte(`<a href="https://nav.example/">nav</a>{{.Content}}`)},
+		"content.tmpl": {Data: []byte(`{{.Content}}`)},
+		"index.md":     {Data: []byte("[ok](" + srv.URL + "/ok) [gone](" + srv.URL + "/gone#top) [local](/doc/)")},
+		"doc/index.md": {Data: []byte("[gone](" + srv.URL + "/gone) [nohead](" + srv.URL + "/nohead) [busy](" + srv.URL + "/busy)")},
+		"doc/old.md":   {Data: []byte("---\ndraft: true\n---\n[old](" + srv.URL + "/old)")},
+	}
+	dc := new(datastoretest.Datastore)
+	c := NewChecker(web.NewSite(fsys), fsys, "content.tmpl", dc)
+	ctx := context.Background()
+	if err := c.Check(ctx); err != nil {
+		t.Fatal(err)
+	}
+	keys, err := dc.GetAll(ctx, datastore.NewQuery(kind).KeysOnly(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, key := range keys {
+		names = append(names, strings.TrimPrefix(key.Name, srv.URL))
+	}
+	if want := []string{"/busy", "/gone", "/nohead", "/ok"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("checked %q, want %q", names, want)
+	}
+	s, _ := getStatus(dc, srv.URL+"/gone")
+	if !s.Failing() || s.Code != 404 || s.Failures != 1 || !reflect.DeepEqual(s.Pages, []string{"/", "/doc/"}) {
+		t.Errorf("/gone: %+v, want failing once, linked from / and /doc/", s)
+	}
+	for _, name := range []string{"/ok", "/nohead", "/busy"} {
+		if s, _ := getStatus(dc, srv.URL+name); s.Failing() {
+			t.Errorf("%s: %+v, want working", name, s)
+		}
+	}
+
+	// A link keeps failing from its first failure until it works again.
+	first := s.FailingSince
+	mu.Lock()
+	gone["/ok"] = true
+	mu.Unlock()
+	if err := c.Check(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if s, _ := getStatus(dc, srv.URL+"/gone"); s.Failures != 2 || !s.FailingSince.Equal(first) {
+		t.Errorf("/gone after second check: %+v, want 2 failures since %v", s, first)
+	}
+	if s, _ := getStatus(dc, srv.URL+"/ok"); s.Failures != 1 {
+		t.Errorf("/ok after second check: %+v, want failing", s)
+	}
+
+	// Links no longer in the content are forgotten.
+	fsys["index.md"] = &fstest.MapFile{Data: []byte("Nothing here.")}
+	c = NewChecker(web.NewSite(fsys), fsys, "content.tmpl", dc)
+	if err := c.Check(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := getStatus(dc, srv.URL+"/ok"); ok {
+		t.Errorf("/ok still recorded after its link was removed")
+	}
+
+	w := httptest.NewRecorder()
+	ReportHandler(dc).ServeHTTP(w, httptest.NewRequest("GET", "/_links", nil))
+	if body := w.Body.String(); w.Code != 200 || !strings.Contains(body, srv.URL+"/gone") || strings.Contains(body, srv.URL+"/busy") {
+		t.Errorf("GET /_links: %d\n%s\nwant report listing only /gone", w.Code, body)
+	}
+	w = httptest.NewRecorder()
+	ReportHandler(dc).ServeHTTP(w, httptest.NewRequest("GET", "/_links?mode=json", nil))
+	var list []LinkStatus
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil || len(list) != 1 || list[0].URL != srv.URL+"/gone" {
+		t.Errorf("GET /_links?mode=json: %v %s, want /gone", err, w.Body)
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package linkcheck
+
+import (
+	"cmp"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"html/template"
+	"log"
+	"net/http"
+	"slices"
+	"time"
+
+	"cloud.google.com/go/datastore"
+)
+
+// A report lists the failing links found by the latest checks.
+type report struct {
+	Links   int           // links checked
+	Checked time.Time     // time of the latest check
+	Failing []*LinkStatus // longest failing first
+}
+
+// ReportHandler serves an administrative report of the failing links,
+// as a page or, with ?mode=json, as a JSON list of their LinkStatuses.
+// Be careful. It is the caller’s responsibility to ensure that the handler is
+// only exposed to authorized users.
+func ReportHandler(dc Datastore) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" && r.Method != "HEAD" {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		rep, err := makeReport(r.Context(), dc)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			log.Printf("ERROR link report: %v", err)
+			return
+		}
+		w.Header().Set("Cache-Control", "no-store")
+		if r.FormValue("mode") == "json" {
+			w.Header().Set("Content-Type", "application/json")
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", " ")
+			if err := enc.Encode(rep.Failing); err != nil {
+				log.Printf("ERROR link report JSON: %v", err)
+			}
+			return
+		}
+		if err := reportTemplate.Execute(w, rep); err != nil {
+			log.Printf("ERROR reportTemplate: %v", err)
+		}
+	})
+}
+
+var (
+	reportTemplate = template.Must(template.New("report").Parse(reportHTML))
+
+	//go:embed report.html
+	reportHTML string
+)
+
+// makeReport returns the report of the link statuses in dc.
+func makeReport(ctx context.Context, dc Datastore) (*report, error) {
+	var list []*LinkStatus
+	if _, err := dc.GetAll(ctx, datastore.NewQuery(kind), &list); err != nil {
+		return nil, err
+	}
+	rep := &report{Links: len(list), Failing: []*LinkStatus{}}
+	for _, s := range list {
+		if s.Checked.After(rep.Checked) {
+			rep.Checked = s.Checked
+		}
+		if s.Failing() {
+			rep.Failing = append(rep.Failing, s)
+		}
+	}
+	slices.SortFunc(rep.Failing, func(x, y *LinkStatus) int {
+		return cmp.Or(x.FailingSince.Compare(y.FailingSince), cmp.Compare(x.URL, y.URL))
+	})
+	return rep, nil
+}
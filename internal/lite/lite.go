@@ -0,0 +1,266 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package lite serves the site's pages in a text-only form
+// for readers on slow or metered connections and in text browsers.
+//
+// A request asks for lite pages with the query parameter lite=1,
+// or with the header Save-Data: on, which browsers send when their
+// user asks to save data; lite=0 declines them despite the header.
+// The page data of a lite page sets lite, so that the site's base
+// layout frames it minimally, without webfonts, images, or scripts,
+// and Handler removes the images and embedded frames from its HTML,
+// leaving their descriptions and links to them in their place.
+// The links of a page requested with lite=1 keep the parameter,
+// so that readers stay on lite pages as they browse.
+package lite
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/matttproud/yourtour/internal/vhost"
+	"github.com/matttproud/yourtour/internal/web"
+	"golang.org/x/net/html"
+)
+
+// Param is the query parameter asking for or declining lite pages.
+const Param = "lite"
+
+// tagSuffix marks the entity tags of lite replies,
+// which differ from those of the full ones.
+const tagSuffix = "-lite"
+
+// Requested reports whether r asks for lite pages.
+func Requested(r *http.Request) bool {
+	switch r.URL.Query().Get(Param) {
+	case "1":
+		return true
+	case "0":
+		return false
+	}
+	return strings.EqualFold(strings.TrimSpace(r.Header.Get("Save-Data")), "on")
+}
+
+// RegisterHandlers sets lite in the page data of the lite pages of h.
+func RegisterHandlers(h *vhost.Host) {
+	h.Site.AddPageData(func(r *http.Request, p web.Page) {
+		if Requested(r) {
+			p["lite"] = true
+		}
+	})
+}
+
+// Handler returns a handler that serves requests with h,
+// removing images and embedded frames from the HTML replies
+// to requests for lite pages.
+//
+// Since the Save-Data header chooses the reply, replies say so in Vary,
+// and the entity tags of lite replies are distinguished from those
+// of full ones, so that caches and conditional requests keep them apart.
+func Handler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Save-Data")
+		lite := Requested(r)
+		if inm := r.Header.Get("If-None-Match"); inm != "" {
+			r = r.Clone(r.Context())
+			r.Header.Set("If-None-Match", matchTags(inm, lite))
+		}
+		if !lite {
+			h.ServeHTTP(w, r)
+			return
+		}
+		lw := &writer{ResponseWriter: w, keepLite: r.URL.Query().Get(Param) == "1"}
+		h.ServeHTTP(lw, r)
+		lw.close()
+	})
+}
+
+// matchTags returns the If-None-Match header value list
+// with only the tags of lite replies, without their marks,
+// if lite is true, or else with only the tags of full replies.
+func matchTags(list string, lite bool) string {
+	if strings.TrimSpace(list) == "*" {
+		return list
+	}
+	var tags []string
+	for _, t := range strings.Split(list, ",") {
+		t = strings.TrimSpace(t)
+		if strings.HasSuffix(t, tagSuffix+`"`) != lite {
+			continue
+		}
+		tags = append(tags, strings.Replace(t, tagSuffix+`"`, `"`, 1))
+	}
+	return strings.Join(tags, ", ")
+}
+
+// A writer holds the HTML reply to a request for a lite page
+// for rewriting, passing other replies through.
+// Since handlers may set the status before the content type is known,
+// the status is written along with the first data.
+type writer struct {
+	http.ResponseWriter
+	keepLite bool // add lite=1 to links
+	code     int
+	started  bool
+	buf      *bytes.Buffer // HTML reply, if any
+}
+
+func (w *writer) WriteHeader(code int) {
+	if w.code == 0 {
+		w.code = code
+	}
+}
+
+func (w *writer) Write(b []byte) (int, error) {
+	if !w.started {
+		w.start(b)
+	}
+	if w.buf != nil {
+		return w.buf.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// start writes the status and headers of the reply,
+// whose data begins with b, deciding whether to rewrite it.
+func (w *writer) start(b []byte) {
+	w.started = true
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	hdr := w.Header()
+	if tag := hdr.Get("ETag"); strings.HasSuffix(tag, `"`) {
+		hdr.Set("ETag", strings.TrimSuffix(tag, `"`)+tagSuffix+`"`)
+	}
+	if hdr.Get("Content-Type") == "" && len(b) > 0 {
+		hdr.Set("Content-Type", http.DetectContentType(b))
+	}
+	if mt, _, _ := mime.ParseMediaType(hdr.Get("Content-Type")); mt == "text/html" && w.code != http.StatusNotModified {
+		w.buf = new(bytes.Buffer)
+		hdr.Del("Content-Length")
+	}
+	w.ResponseWriter.WriteHeader(w.code)
+}
+
+// close finishes the reply, writing the rewritten HTML, if any.
+func (w *writer) close() {
+	if !w.started {
+		w.start(nil)
+	}
+	if w.buf != nil {
+		rewrite(w.ResponseWriter, w.buf.Bytes(), w.keepLite)
+	}
+}
+
+// Unwrap returns the underlying ResponseWriter,
+// for use by http.ResponseController.
+func (w *writer) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// rewrite writes the HTML page data to w without its images,
+// embedded frames, and webfonts, adding lite=1 to the links
+// to other pages of the site if keepLite is set.
+func rewrite(w io.Writer, data []byte, keepLite bool) {
+	z := html.NewTokenizer(bytes.NewReader(data))
+	skip := 0 // depth inside a skipped element
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			return
+		}
+		// Token unescapes the text of the token that Raw returns in place.
+		raw := bytes.Clone(z.Raw())
+		tok := z.Token()
+		name := tok.Data
+		if skip > 0 {
+			switch {
+			case tt == html.StartTagToken && dropped[name]:
+				skip++
+			case tt == html.EndTagToken && dropped[name]:
+				skip--
+			}
+			continue
+		}
+		switch {
+		case (tt == html.StartTagToken || tt == html.SelfClosingTagToken) && name == "img":
+			if alt := attr(tok, "alt"); alt != "" {
+				io.WriteString(w, html.EscapeString("["+alt+"]"))
+			}
+			continue
+		case (tt == html.StartTagToken || tt == html.SelfClosingTagToken) && name == "source":
+			continue
+		case tt == html.StartTagToken && dropped[name]:
+			if src := attr(tok, "src"); src != "" {
+				desc := attr(tok, "title")
+				if desc == "" {
+					desc = "embedded " + name
+				}
+				io.WriteString(w, `<a href="`+html.EscapeString(src)+`">[`+html.EscapeString(desc)+`]</a>`)
+			}
+			skip = 1
+			continue
+		case tt == html.StartTagToken && name == "link" && isWebfont(attr(tok, "href")):
+			continue
+		case tt == html.StartTagToken && name == "a" && keepLite:
+			if href, ok := liteLink(attr(tok, "href")); ok {
+				setAttr(&tok, "href", href)
+				io.WriteString(w, tok.String())
+				continue
+			}
+		}
+		w.Write(raw)
+	}
+}
+
+// dropped lists the elements removed from lite pages with their content.
+var dropped = map[string]bool{
+	"audio":  true,
+	"iframe": true,
+	"svg":    true,
+	"video":  true,
+}
+
+// isWebfont reports whether href is the URL of a webfont stylesheet.
+func isWebfont(href string) bool {
+	u, err := url.Parse(href)
+	return err == nil && (u.Host == "fonts.googleapis.com" || strings.HasSuffix(u.Path, "/fonts.css"))
+}
+
+// liteLink returns the link href with lite=1 added,
+// if it links to another page of the site.
+func liteLink(href string) (string, bool) {
+	u, err := url.Parse(href)
+	if err != nil || u.Scheme != "" || u.Host != "" || !strings.HasPrefix(u.Path, "/") || u.Query().Has(Param) {
+		return "", false
+	}
+	q := u.Query()
+	q.Set(Param, "1")
+	u.RawQuery = q.Encode()
+	return u.String(), true
+}
+
+// attr returns the value of the attribute key of tok.
+func attr(tok html.Token, key string) string {
+	for _, a := range tok.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// setAttr sets the value of the attribute key of tok.
+func setAttr(tok *html.Token, key, val string) {
+	for i := range tok.Attr {
+		if tok.Attr[i].Key == key {
+			tok.Attr[i].Val = val
+		}
+	}
+}
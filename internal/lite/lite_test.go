@@ -0,0 +1,137 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lite
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/matttproud/yourtour/internal/etag"
+	"github.com/matttproud/yourtour/internal/router"
+	"github.com/matttproud/yourtour/internal/vhost"
+	"github.com/matttproud/yourtour/internal/web"
+)
+
+func TestRequested(t *testing.T) {
+	for _, tt := range []struct {
+		url, saveData string
+		want          bool
+	}{
+		{"/doc/", "", false},
+		{"/doc/?lite=1", "", true},
+		{"/doc/", "on", true},
+		{"/doc/", " On", true},
+		{"/doc/", "off", false},
+		{"/doc/?lite=0", "on", false},
+		{"/doc/?lite=yes", "", false},
+	} {
+		r := httptest.NewRequest("GET", tt.url, nil)
+		if tt.saveData != "" {
+			r.Header.Set("Save-Data", tt.saveData)
+		}
+		if got := Requested(r); got != tt.want {
+			t.Errorf("Requested(%s, Save-Data: %q) = %v, want %v", tt.url, tt.saveData, got, tt.want)
+		}
+	}
+}
+
+const page = `<html><head><link rel="stylesheet" href="https://fonts.googleapis.com/css?family=Material+Icons">` +
+	`<link rel="stylesheet" href="/css/styles.css"></head><body>` +
+	`<a href="/"><img src="/images/go-logo.svg" alt="Go"></a>` +
+	`<picture><source srcset="/images/big.webp"><img src="/images/big.png"></picture>` +
+	`<iframe src="https://www.youtube.com/embed/x" title="A talk"><p>no frames</p></iframe>` +
+	`<svg viewBox="0 0 1 1"><svg><path d="M0"/></svg></svg>` +
+	`<p>See <a href="/doc/faq#why">the FAQ</a>, <a href="https://pkg.go.dev/std">the library</a>, and <a href="#top">the top</a>.</p>` +
+	`<pre>x &lt; y</pre></body></html>`
+
+func TestHandler(t *testing.T) {
+	fsys := fstest.MapFS{
+		"site.tmpl": {Data: []byte(`{{if .lite}}lite {{end}}{{.Content}}`)},
+		"index.md":  {Data: []byte("Hello")},
+	}
+	site := web.NewSite(fsys)
+	mux := http.NewServeMux()
+	var reg vhost.Registry
+	RegisterHandlers(reg.Add("", site, fsys, router.New(mux)))
+	version := new(etag.Version)
+	version.Set("content", "v1")
+	mux.Handle("/", etag.Handler(site, version.String))
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		// Write the status before the content type is known, as etag.Handler does.
+		w.WriteHeader(http.StatusOK)
+		io.WriteString(w, page)
+	})
+	mux.HandleFunc("/data", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"img": "<img>"}`)
+	})
+	h := Handler(mux)
+
+	get := func(url, saveData, inm string) *httptest.ResponseRecorder {
+		t.Helper()
+		r := httptest.NewRequest("GET", url, nil)
+		if saveData != "" {
+			r.Header.Set("Save-Data", saveData)
+		}
+		if inm != "" {
+			r.Header.Set("If-None-Match", inm)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Header().Get("Vary") != "Save-Data" {
+			t.Errorf("GET %s: Vary: %q, want Save-Data", url, w.Header().Get("Vary"))
+		}
+		return w
+	}
+
+	v := version.String()
+	if w := get("/", "", ""); w.Body.String() != "<p>Hello</p>\n" || w.Header().Get("ETag") != `"`+v+`"` {
+		t.Errorf("GET /: %q ETag %s, want full page tagged %q", w.Body, w.Header().Get("ETag"), v)
+	}
+	if w := get("/", "on", ""); w.Body.String() != "lite <p>Hello</p>\n" || w.Header().Get("ETag") != `"`+v+`-lite"` {
+		t.Errorf("GET / with Save-Data: %q ETag %s, want lite page tagged %q", w.Body, w.Header().Get("ETag"), v+"-lite")
+	}
+	for _, tt := range []struct {
+		saveData, inm string
+		code          int
+	}{
+		{"", `"v"`, 304},
+		{"", `W/"v-lite"`, 200},
+		{"on", `W/"v-lite"`, 304},
+		{"on", `"v"`, 200},
+		{"on", `"old-lite", "v"`, 200},
+		{"on", `*`, 304},
+	} {
+		tt.inm = strings.ReplaceAll(tt.inm, `"v`, `"`+v)
+		if w := get("/", tt.saveData, tt.inm); w.Code != tt.code {
+			t.Errorf("GET / with Save-Data: %q, If-None-Match: %s = %d, want %d", tt.saveData, tt.inm, w.Code, tt.code)
+		}
+	}
+
+	if w := get("/page", "", ""); w.Body.String() != page {
+		t.Errorf("GET /page rewrote the full page:\n%s", w.Body)
+	}
+	const lite = `<html><head>` +
+		`<link rel="stylesheet" href="/css/styles.css"></head><body>` +
+		`<a href="/">[Go]</a>` +
+		`<picture></picture>` +
+		`<a href="https://www.youtube.com/embed/x">[A talk]</a>` +
+		`<p>See <a href="/doc/faq#why">the FAQ</a>, <a href="https://pkg.go.dev/std">the library</a>, and <a href="#top">the top</a>.</p>` +
+		`<pre>x &lt; y</pre></body></html>`
+	if w := get("/page", "on", ""); w.Body.String() != lite {
+		t.Errorf("GET /page with Save-Data:\n%s\nwant:\n%s", w.Body, lite)
+	}
+	kept := strings.NewReplacer(`href="/"`, `href="/?lite=1"`, `href="/doc/faq#why"`, `href="/doc/faq?lite=1#why"`).Replace(lite)
+	if w := get("/page?lite=1", "", ""); w.Body.String() != kept {
+		t.Errorf("GET /page?lite=1:\n%s\nwant:\n%s", w.Body, kept)
+	}
+	if w := get("/data?lite=1", "", ""); w.Body.String() != `{"img": "<img>"}` {
+		t.Errorf("GET /data?lite=1 rewrote JSON: %s", w.Body)
+	}
+}
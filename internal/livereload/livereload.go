@@ -0,0 +1,227 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package livereload tells the browsers of authors previewing pages
+// that the content they are served from has changed, so that they
+// reload the pages as the authors edit them.
+//
+// A Watcher polls the directories served live, such as a content overlay,
+// for changed files, and broadcasts each change as an Event in JSON
+// to the browsers connected to its WebSocket channel, /ws/livereload.
+// Pages are given the channel's path as their liveReload page data,
+// for the site template to load the script that connects to it.
+//
+// Live reload is meant for development servers only.
+package livereload
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/matttproud/yourtour/internal/web"
+	"golang.org/x/net/websocket"
+)
+
+// Path is the path of the WebSocket channel.
+const Path = "/ws/livereload"
+
+// Interval is how often a Watcher's directories should be checked.
+const Interval = time.Second
+
+// pingInterval is how often idle connections are pinged,
+// so that idle proxies do not close them.
+const pingInterval = 30 * time.Second
+
+// An Event is a change to the files in a Watcher's directories.
+type Event struct {
+	// Kind is "template" if a template changed,
+	// which may change any page, or else "content".
+	// It is "ping" for the messages keeping idle connections open,
+	// which browsers ignore.
+	Kind string `json:"kind"`
+
+	// Files lists the changed files, slash-separated
+	// and relative to their directories, in sorted order.
+	Files []string `json:"files,omitempty"`
+}
+
+// A Watcher watches a set of directories for changes
+// and broadcasts them to the connected browsers.
+// It is safe for concurrent use.
+type Watcher struct {
+	dirs []string
+
+	mu      sync.Mutex
+	files   map[fileKey]stamp // nil until the first Check
+	gen     int               // number of changes seen
+	clients map[chan Event]bool
+}
+
+// A fileKey identifies a file by the index of its directory
+// in a Watcher's dirs and its slash-separated name in that directory.
+type fileKey struct {
+	dir  int
+	name string
+}
+
+// A stamp is the size and modification time of a file,
+// which change when the file is edited.
+type stamp struct {
+	size    int64
+	modTime time.Time
+}
+
+// NewWatcher returns a Watcher watching the files in dirs.
+func NewWatcher(dirs ...string) *Watcher {
+	return &Watcher{dirs: dirs, clients: make(map[chan Event]bool)}
+}
+
+// Check looks for files changed since the last Check,
+// broadcasting any changes found. It has the signature of a
+// jobs.Job's Run, to be run every Interval. The first Check
+// records the files as they are, broadcasting nothing.
+func (w *Watcher) Check(ctx context.Context) error {
+	files := make(map[fileKey]stamp)
+	for i, dir := range w.dirs {
+		err := filepath.WalkDir(dir, func(name string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(dir, name)
+			if err != nil {
+				return err
+			}
+			files[fileKey{i, filepath.ToSlash(rel)}] = stamp{info.Size(), info.ModTime()}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	old := w.files
+	w.files = files
+	if old == nil {
+		return nil
+	}
+	var changed []string
+	for k, st := range files {
+		if old[k] != st {
+			changed = append(changed, k.name)
+		}
+	}
+	for k := range old {
+		if _, ok := files[k]; !ok {
+			changed = append(changed, k.name)
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+	slices.Sort(changed)
+	changed = slices.Compact(changed)
+	ev := Event{Kind: "content", Files: changed}
+	if slices.ContainsFunc(changed, func(name string) bool { return strings.HasSuffix(name, ".tmpl") }) {
+		ev.Kind = "template"
+	}
+	w.gen++
+	for c := range w.clients {
+		select {
+		case c <- ev:
+		default:
+			// The browser has changes waiting to be sent,
+			// and it reloads on any of them.
+		}
+	}
+	return nil
+}
+
+// Version returns the number of changes the Watcher has seen,
+// for the etag.Version of the pages served from its directories,
+// so that a browser reloading a page after an Event is sent
+// the changed page, not told that its copy is still current.
+func (w *Watcher) Version() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return strconv.Itoa(w.gen)
+}
+
+// PageData sets the liveReload of the page p to Path,
+// for use as a web.Site's page data function.
+func (w *Watcher) PageData(r *http.Request, p web.Page) {
+	p["liveReload"] = Path
+}
+
+// ServeHTTP serves the WebSocket channel on which
+// the Watcher's events are sent.
+// Requests for it must not be given a timeout.
+func (w *Watcher) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	websocket.Handler(w.serveConn).ServeHTTP(hijacker{rw}, r)
+}
+
+// A hijacker lets the websocket package, which requires an http.Hijacker,
+// take over the connection underlying the ResponseWriters of middleware
+// that wrap the server's, which provide Unwrap methods instead.
+type hijacker struct {
+	http.ResponseWriter
+}
+
+func (h hijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(h.ResponseWriter).Hijack()
+}
+
+// serveConn sends the Watcher's events on the connection ws
+// until the browser closes it.
+func (w *Watcher) serveConn(ws *websocket.Conn) {
+	c := make(chan Event, 1)
+	w.mu.Lock()
+	w.clients[c] = true
+	w.mu.Unlock()
+	defer func() {
+		w.mu.Lock()
+		delete(w.clients, c)
+		w.mu.Unlock()
+	}()
+
+	// Browsers send nothing; reading only finds when they go away.
+	closed := make(chan struct{})
+	go func() {
+		io.Copy(io.Discard, ws)
+		close(closed)
+	}()
+
+	ping := time.NewTicker(pingInterval)
+	defer ping.Stop()
+	for {
+		var ev Event
+		select {
+		case <-closed:
+			return
+		case <-ws.Request().Context().Done():
+			return
+		case <-ping.C:
+			ev = Event{Kind: "ping"}
+		case ev = <-c:
+		}
+		if err := websocket.JSON.Send(ws, ev); err != nil {
+			return
+		}
+	}
+}
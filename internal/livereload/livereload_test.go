@@ -0,0 +1,123 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package livereload
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matttproud/yourtour/internal/web"
+	"golang.org/x/net/websocket"
+)
+
+// wrapper is a ResponseWriter of middleware, which hides
+// the server's http.Hijacker behind an Unwrap method.
+type wrapper struct {
+	w http.ResponseWriter
+}
+
+func (w wrapper) Header() http.Header         { return w.w.Header() }
+func (w wrapper) Write(b []byte) (int, error) { return w.w.Write(b) }
+func (w wrapper) WriteHeader(code int)        { w.w.WriteHeader(code) }
+func (w wrapper) Unwrap() http.ResponseWriter { return w.w }
+
+func write(t *testing.T, name, data string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(name), 0777); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(name, []byte(data), 0666); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	content, overlay := t.TempDir(), t.TempDir()
+	write(t, filepath.Join(content, "doc/install.md"), "Install.")
+	write(t, filepath.Join(content, "site.tmpl"), "site")
+	write(t, filepath.Join(overlay, "doc/codewalk/walk.xml"), "<codewalk/>")
+
+	w := NewWatcher(content, overlay)
+	ctx := context.Background()
+	if err := w.Check(ctx); err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		w.ServeHTTP(wrapper{rw}, r)
+	}))
+	defer srv.Close()
+	ws, err := websocket.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+Path, "", srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		w.mu.Lock()
+		n := len(w.clients)
+		w.mu.Unlock()
+		if n == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("browser never connected")
+		}
+	}
+	ws.SetDeadline(time.Now().Add(5 * time.Second))
+
+	for _, tt := range []struct {
+		edit func()
+		want Event
+	}{
+		{func() { write(t, filepath.Join(overlay, "doc/codewalk/walk.xml"), "<codewalk></codewalk>") },
+			Event{Kind: "content", Files: []string{"doc/codewalk/walk.xml"}}},
+		{func() {
+			write(t, filepath.Join(content, "doc/new.md"), "New.")
+			os.Remove(filepath.Join(content, "doc/install.md"))
+		},
+			Event{Kind: "content", Files: []string{"doc/install.md", "doc/new.md"}}},
+		{func() { write(t, filepath.Join(overlay, "site.tmpl"), "overlaid site") },
+			Event{Kind: "template", Files: []string{"site.tmpl"}}},
+	} {
+		version := w.Version()
+		tt.edit()
+		if err := w.Check(ctx); err != nil {
+			t.Fatal(err)
+		}
+		var ev Event
+		if err := websocket.JSON.Receive(ws, &ev); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(ev, tt.want) {
+			t.Errorf("event = %+v, want %+v", ev, tt.want)
+		}
+		if w.Version() == version {
+			t.Errorf("version %s unchanged by %+v", version, ev)
+		}
+	}
+
+	// Without changes, there are no events.
+	version := w.Version()
+	if err := w.Check(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if w.Version() != version {
+		t.Errorf("version changed from %s to %s without changes", version, w.Version())
+	}
+}
+
+func TestPageData(t *testing.T) {
+	p := web.Page{}
+	NewWatcher().PageData(nil, p)
+	if p["liveReload"] != Path {
+		t.Errorf("liveReload = %v, want %s", p["liveReload"], Path)
+	}
+}
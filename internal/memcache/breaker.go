@@ -0,0 +1,180 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package memcache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned by a Breaker's operations
+// while its circuit is open.
+var ErrCircuitOpen = errors.New("memcache: circuit open")
+
+// Defaults used by NewBreaker when passed zero values.
+const (
+	DefaultBreakerThreshold = 5
+	DefaultBreakerCooldown  = 30 * time.Second
+)
+
+// A Breaker is a Cache that wraps another Cache with a circuit breaker.
+//
+// After threshold consecutive failed operations, the circuit opens
+// and every operation fails immediately with ErrCircuitOpen
+// for the cooldown period, instead of waiting for the backend to time out.
+// Once the cooldown has passed, the next operation is let through as a probe:
+// if it succeeds the circuit closes, and if it fails the circuit
+// stays open for another cooldown period.
+// While the probe is in flight, other operations continue to fail fast.
+//
+// A cache miss or a failed Add or CompareAndSwap is not a failure,
+// and neither is an operation abandoned because its own context was canceled.
+type Breaker struct {
+	cache     Cache
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time // for testing
+
+	mu       sync.Mutex
+	failures int       // consecutive failures
+	openTill time.Time // circuit is open until this time; zero if closed
+	probing  bool      // a half-open probe is in flight
+}
+
+var _ Cache = (*Breaker)(nil)
+
+// NewBreaker returns a Breaker wrapping c.
+// A zero threshold or cooldown selects
+// DefaultBreakerThreshold or DefaultBreakerCooldown.
+func NewBreaker(c Cache, threshold int, cooldown time.Duration) *Breaker {
+	if threshold <= 0 {
+		threshold = DefaultBreakerThreshold
+	}
+	if cooldown <= 0 {
+		cooldown = DefaultBreakerCooldown
+	}
+	return &Breaker{cache: c, threshold: threshold, cooldown: cooldown, now: time.Now}
+}
+
+// Open reports whether the circuit is currently open.
+func (b *Breaker) Open() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return !b.openTill.IsZero() && (b.probing || b.now().Before(b.openTill))
+}
+
+// allow reports whether an operation may proceed,
+// and whether that operation is the half-open probe.
+func (b *Breaker) allow() (ok, probe bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.openTill.IsZero() {
+		return true, false
+	}
+	if b.probing || b.now().Before(b.openTill) {
+		return false, false
+	}
+	b.probing = true
+	return true, true
+}
+
+// done records the outcome of an operation run in ctx.
+func (b *Breaker) done(ctx context.Context, probe bool, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if probe {
+		b.probing = false
+	}
+	if err == nil || errors.Is(err, ErrCacheMiss) || errors.Is(err, ErrNotStored) || errors.Is(err, ErrCASConflict) {
+		b.failures = 0
+		b.openTill = time.Time{}
+		return
+	}
+	if ctx.Err() != nil {
+		// The caller gave up; that says nothing about the backend.
+		return
+	}
+	b.failures++
+	if probe || b.failures >= b.threshold {
+		b.openTill = b.now().Add(b.cooldown)
+	}
+}
+
+// do runs op through the breaker.
+func (b *Breaker) do(ctx context.Context, op func() error) error {
+	ok, probe := b.allow()
+	if !ok {
+		return ErrCircuitOpen
+	}
+	err := op()
+	b.done(ctx, probe, err)
+	return err
+}
+
+func (b *Breaker) Get(ctx context.Context, key string) ([]byte, error) {
+	var v []byte
+	err := b.do(ctx, func() (err error) {
+		v, err = b.cache.Get(ctx, key)
+		return err
+	})
+	return v, err
+}
+
+func (b *Breaker) Set(ctx context.Context, item *Item) error {
+	return b.do(ctx, func() error { return b.cache.Set(ctx, item) })
+}
+
+func (b *Breaker) Delete(ctx context.Context, key string) error {
+	return b.do(ctx, func() error { return b.cache.Delete(ctx, key) })
+}
+
+func (b *Breaker) GetMulti(ctx context.Context, keys []string) (map[string][]byte, error) {
+	var m map[string][]byte
+	err := b.do(ctx, func() (err error) {
+		m, err = b.cache.GetMulti(ctx, keys)
+		return err
+	})
+	return m, err
+}
+
+func (b *Breaker) SetMulti(ctx context.Context, items []*Item) error {
+	return b.do(ctx, func() error { return b.cache.SetMulti(ctx, items) })
+}
+
+func (b *Breaker) DeleteMulti(ctx context.Context, keys []string) error {
+	return b.do(ctx, func() error { return b.cache.DeleteMulti(ctx, keys) })
+}
+
+func (b *Breaker) Add(ctx context.Context, item *Item) error {
+	return b.do(ctx, func() error { return b.cache.Add(ctx, item) })
+}
+
+func (b *Breaker) GetItem(ctx context.Context, key string) (*Item, error) {
+	var item *Item
+	err := b.do(ctx, func() (err error) {
+		item, err = b.cache.GetItem(ctx, key)
+		return err
+	})
+	return item, err
+}
+
+func (b *Breaker) CompareAndSwap(ctx context.Context, item *Item) error {
+	return b.do(ctx, func() error { return b.cache.CompareAndSwap(ctx, item) })
+}
+
+func (b *Breaker) Touch(ctx context.Context, key string, expiration time.Duration) error {
+	return b.do(ctx, func() error { return b.cache.Touch(ctx, key, expiration) })
+}
+
+func (b *Breaker) GetAndTouch(ctx context.Context, key string, expiration time.Duration) ([]byte, error) {
+	var v []byte
+	err := b.do(ctx, func() (err error) {
+		v, err = b.cache.GetAndTouch(ctx, key, expiration)
+		return err
+	})
+	return v, err
+}
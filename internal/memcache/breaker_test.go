@@ -0,0 +1,102 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package memcache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// flakyCache is a Cache that fails with err when it is non-nil.
+type flakyCache struct {
+	mapCache
+	err   error
+	calls int
+}
+
+func (f *flakyCache) Get(ctx context.Context, key string) ([]byte, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	return f.mapCache.Get(ctx, key)
+}
+
+func TestBreaker(t *testing.T) {
+	ctx := context.Background()
+	f := &flakyCache{mapCache: make(mapCache)}
+	b := NewBreaker(f, 3, time.Minute)
+	now := time.Unix(1e9, 0)
+	b.now = func() time.Time { return now }
+
+	// Misses do not count as failures.
+	for i := 0; i < 5; i++ {
+		if _, err := b.Get(ctx, "k"); err != ErrCacheMiss {
+			t.Fatalf("Get = %v, want ErrCacheMiss", err)
+		}
+	}
+	if b.Open() {
+		t.Fatalf("circuit open after cache misses")
+	}
+
+	f.err = errors.New("connection refused")
+	for i := 0; i < 3; i++ {
+		if _, err := b.Get(ctx, "k"); err != f.err {
+			t.Fatalf("Get #%d = %v, want %v", i, err, f.err)
+		}
+	}
+	if !b.Open() {
+		t.Fatalf("circuit closed after 3 failures")
+	}
+	calls := f.calls
+	if _, err := b.Get(ctx, "k"); err != ErrCircuitOpen {
+		t.Errorf("Get with open circuit = %v, want ErrCircuitOpen", err)
+	}
+	if f.calls != calls {
+		t.Errorf("open circuit called backend")
+	}
+
+	// After the cooldown, a failed probe reopens the circuit.
+	now = now.Add(time.Minute)
+	if _, err := b.Get(ctx, "k"); err != f.err {
+		t.Errorf("probe Get = %v, want %v", err, f.err)
+	}
+	if _, err := b.Get(ctx, "k"); err != ErrCircuitOpen {
+		t.Errorf("Get after failed probe = %v, want ErrCircuitOpen", err)
+	}
+
+	// A successful probe closes it.
+	now = now.Add(time.Minute)
+	f.err = nil
+	if _, err := b.Get(ctx, "k"); err != ErrCacheMiss {
+		t.Errorf("probe Get = %v, want ErrCacheMiss", err)
+	}
+	if b.Open() {
+		t.Errorf("circuit open after successful probe")
+	}
+}
+
+func TestBreakerCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	f := &flakyCache{mapCache: make(mapCache), err: context.Canceled}
+	b := NewBreaker(f, 1, time.Minute)
+	b.Get(ctx, "k")
+	if b.Open() {
+		t.Errorf("circuit opened by canceled request")
+	}
+}
+
+func TestBreakerWrappedMiss(t *testing.T) {
+	f := &flakyCache{mapCache: make(mapCache), err: fmt.Errorf("chunk 2: %w", ErrCacheMiss)}
+	b := NewBreaker(f, 1, time.Minute)
+	b.Get(context.Background(), "k")
+	if b.Open() {
+		t.Errorf("circuit opened by wrapped cache miss")
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package memcache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// The compare-and-swap token of an item is its value as read by GetItem:
+// CompareAndSwap succeeds if the stored value is still byte-for-byte the same.
+// That means a value changed and then changed back is not a conflict,
+// which is harmless for the counters and sets CAS is meant for.
+
+// casSeconds returns expiration in whole seconds
+// for Add, CompareAndSwap, Touch, and GetAndTouch.
+// Unlike Set, which deletes an item whose expiration rounds down
+// to zero seconds, these round it up to one second,
+// so that they always leave an item behind when they succeed.
+func casSeconds(expiration time.Duration) int64 {
+	exp := int64(expiration.Seconds())
+	if expiration > 0 && exp == 0 {
+		exp = 1
+	}
+	return exp
+}
+
+// Add sets the item only if its key is not already present,
+// returning ErrNotStored if it is.
+func (c *Client) Add(ctx context.Context, item *Item) error {
+	if item.Value == nil {
+		return errors.New("nil item value")
+	}
+	conn, err := c.pool.GetContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	args := []interface{}{item.Key, item.Value, "NX"}
+	if exp := casSeconds(item.Expiration); exp > 0 {
+		args = append(args, "EX", exp)
+	}
+	_, err = redis.String(c.do(ctx, conn, "SET", args...))
+	if err == redis.ErrNil {
+		err = ErrNotStored
+	}
+	return err
+}
+
+// GetItem gets the item, recording in it the token
+// that CompareAndSwap uses to detect concurrent modification.
+func (c *Client) GetItem(ctx context.Context, key string) (*Item, error) {
+	b, err := c.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if b == nil {
+		b = []byte{} // a nil token means the item was not read by GetItem
+	}
+	return &Item{Key: key, Value: b, casID: b}, nil
+}
+
+// casScript sets KEYS[1] to ARGV[2] with expiration ARGV[3] seconds (0 for none)
+// if its value is still ARGV[1].
+// It returns 1 on success, 0 on conflict, and -1 if the key is missing.
+const casScript = `
+local v = redis.call('GET', KEYS[1])
+if not v then return -1 end
+if v ~= ARGV[1] then return 0 end
+if ARGV[3] == '0' then
+	redis.call('SET', KEYS[1], ARGV[2])
+else
+	redis.call('SET', KEYS[1], ARGV[2], 'EX', ARGV[3])
+end
+return 1
+`
+
+// CompareAndSwap sets an item previously returned by GetItem,
+// provided it has not been modified since.
+// It returns ErrCASConflict if the item was modified
+// and ErrNotStored if it was deleted or expired.
+func (c *Client) CompareAndSwap(ctx context.Context, item *Item) error {
+	if item.Value == nil {
+		return errors.New("nil item value")
+	}
+	if item.casID == nil {
+		return errors.New("CompareAndSwap: item not obtained from GetItem")
+	}
+	conn, err := c.pool.GetContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	n, err := redis.Int(c.do(ctx, conn, "EVAL", casScript, 1, item.Key, item.casID, item.Value, casSeconds(item.Expiration)))
+	if err != nil {
+		return err
+	}
+	return casResult(n)
+}
+
+func casResult(n int) error {
+	switch n {
+	case 1:
+		return nil
+	case 0:
+		return ErrCASConflict
+	}
+	return ErrNotStored
+}
+
+// Add encodes and sets the item only if its key is not already present,
+// returning ErrNotStored if it is.
+func (c *CodecClient) Add(ctx context.Context, item *Item) error {
+	encoded, err := c.encode(ctx, item)
+	if err != nil {
+		return err
+	}
+	return c.cache.Add(ctx, encoded)
+}
+
+// GetItem gets the item, decoding its value into v.
+// The returned item can be passed to CompareAndSwap
+// after setting its Object to the new value.
+func (c *CodecClient) GetItem(ctx context.Context, key string, v interface{}) (*Item, error) {
+	prefix, err := c.prefix(ctx)
+	if err != nil {
+		return nil, err
+	}
+	item, err := c.cache.GetItem(ctx, prefix+key)
+	if err != nil {
+		return nil, err
+	}
+	if isNotFound(item.Value) {
+		return nil, ErrNotFound
+	}
+	if err := c.codec.Unmarshal(item.Value, v); err != nil {
+		return nil, err
+	}
+	item.Key = key
+	item.Object = v
+	return item, nil
+}
+
+// CompareAndSwap encodes and sets an item previously returned by GetItem,
+// provided it has not been modified since.
+func (c *CodecClient) CompareAndSwap(ctx context.Context, item *Item) error {
+	encoded, err := c.encode(ctx, item)
+	if err != nil {
+		return err
+	}
+	encoded.casID = item.casID
+	return c.cache.CompareAndSwap(ctx, encoded)
+}
+
+// encode returns the cache item that stores item.Object.
+func (c *CodecClient) encode(ctx context.Context, item *Item) (*Item, error) {
+	if item.Object == nil {
+		return nil, errors.New("nil object value")
+	}
+	b, err := c.codec.Marshal(item.Object)
+	if err != nil {
+		return nil, err
+	}
+	prefix, err := c.prefix(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &Item{Key: prefix + item.Key, Value: b, Expiration: item.Expiration}, nil
+}
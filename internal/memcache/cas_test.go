@@ -0,0 +1,97 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package memcache
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+func TestCASMemory(t *testing.T) {
+	testCAS(t, NewMemory(0))
+}
+
+func TestCASRedis(t *testing.T) {
+	c := getClient(t)
+	c.DeleteMulti(context.Background(), []string{"cas-a", "cas-counter"})
+	testCAS(t, c)
+}
+
+func testCAS(t *testing.T, cache Cache) {
+	ctx := context.Background()
+	c := NewCodecClient(cache, JSON)
+
+	if err := c.Add(ctx, &Item{Key: "cas-a", Object: 1}); err != nil {
+		t.Fatalf("Add = %v", err)
+	}
+	if err := c.Add(ctx, &Item{Key: "cas-a", Object: 2}); err != ErrNotStored {
+		t.Fatalf("second Add = %v, want ErrNotStored", err)
+	}
+
+	var v int
+	item, err := c.GetItem(ctx, "cas-a", &v)
+	if err != nil || v != 1 {
+		t.Fatalf("GetItem = %d, %v, want 1, nil", v, err)
+	}
+	stale, err := c.GetItem(ctx, "cas-a", new(int))
+	if err != nil {
+		t.Fatal(err)
+	}
+	item.Object = 10
+	if err := c.CompareAndSwap(ctx, item); err != nil {
+		t.Fatalf("CompareAndSwap = %v", err)
+	}
+	stale.Object = 20
+	if err := c.CompareAndSwap(ctx, stale); err != ErrCASConflict {
+		t.Errorf("stale CompareAndSwap = %v, want ErrCASConflict", err)
+	}
+	if err := c.Get(ctx, "cas-a", &v); err != nil || v != 10 {
+		t.Errorf("Get = %d, %v, want 10, nil", v, err)
+	}
+
+	if err := c.Delete(ctx, "cas-a"); err != nil {
+		t.Fatal(err)
+	}
+	item.Object = 30
+	if err := c.CompareAndSwap(ctx, item); err != ErrNotStored {
+		t.Errorf("CompareAndSwap after Delete = %v, want ErrNotStored", err)
+	}
+	if err := c.CompareAndSwap(ctx, &Item{Key: "cas-a", Object: 1}); err == nil {
+		t.Errorf("CompareAndSwap of item not from GetItem succeeded")
+	}
+
+	// Concurrent increments with retry on conflict lose no updates.
+	if err := c.Add(ctx, &Item{Key: "cas-counter", Object: 0}); err != nil {
+		t.Fatal(err)
+	}
+	const n = 20
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				var count int
+				item, err := c.GetItem(ctx, "cas-counter", &count)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				item.Object = count + 1
+				if err := c.CompareAndSwap(ctx, item); err != ErrCASConflict {
+					if err != nil {
+						t.Error(err)
+					}
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if err := c.Get(ctx, "cas-counter", &v); err != nil || v != n {
+		t.Errorf("counter = %d, %v, want %d, nil", v, err, n)
+	}
+}
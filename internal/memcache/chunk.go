@@ -0,0 +1,314 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package memcache
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultChunkSize is the largest value a Chunked cache stores in a single
+// item when created with a chunk size of zero. It matches the default
+// item size limit of memcached and of many managed cache services.
+const DefaultChunkSize = 1 << 20
+
+// chunkMagic starts the manifest stored in place of a chunked value.
+const chunkMagic = "\x00memcache-chunks\x00"
+
+// A Chunked is a Cache that stores values larger than its chunk size
+// by splitting them across several items of the underlying cache,
+// recording the list of chunks in a manifest stored at the item's own key.
+// Reading the item reassembles the value; if any chunk has been evicted,
+// the read reports a cache miss.
+//
+// Values that fit in one chunk are stored unchanged,
+// so a Chunked can read items written to the underlying cache directly.
+//
+// Chunks have the same expiration as their item. Chunks of a value that is
+// overwritten are left behind for the cache to expire or evict;
+// only Delete and DeleteMulti remove chunks eagerly.
+type Chunked struct {
+	cache Cache
+	size  int
+}
+
+var _ Cache = (*Chunked)(nil)
+
+// NewChunked returns a Chunked cache storing items in c,
+// splitting values larger than size bytes.
+// If size is zero, the size is DefaultChunkSize.
+func NewChunked(c Cache, size int) *Chunked {
+	if size <= 0 {
+		size = DefaultChunkSize
+	}
+	return &Chunked{cache: c, size: size}
+}
+
+// A manifest describes the chunks of a value.
+type manifest struct {
+	id string
+	n  int
+}
+
+func (m manifest) key(key string, i int) string {
+	return key + "#chunk/" + m.id + "/" + strconv.Itoa(i)
+}
+
+func (m manifest) keys(key string) []string {
+	keys := make([]string, m.n)
+	for i := range keys {
+		keys[i] = m.key(key, i)
+	}
+	return keys
+}
+
+// parseManifest parses b as a manifest,
+// reporting whether it is one.
+func parseManifest(b []byte) (manifest, bool) {
+	rest, ok := bytes.CutPrefix(b, []byte(chunkMagic))
+	if !ok {
+		return manifest{}, false
+	}
+	id, count, ok := strings.Cut(string(rest), "/")
+	n, err := strconv.Atoi(count)
+	if !ok || err != nil || n < 1 {
+		return manifest{}, false
+	}
+	return manifest{id, n}, true
+}
+
+// split returns the items to store for item:
+// the item to store at item.Key and any chunks it refers to.
+func (c *Chunked) split(item *Item) (*Item, []*Item, error) {
+	v := item.Value
+	if len(v) <= c.size && !bytes.HasPrefix(v, []byte(chunkMagic)) {
+		return item, nil, nil
+	}
+	var id [8]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return nil, nil, err
+	}
+	m := manifest{id: hex.EncodeToString(id[:]), n: (len(v) + c.size - 1) / c.size}
+	var chunks []*Item
+	for i := 0; i < m.n; i++ {
+		chunk := v[i*c.size : min((i+1)*c.size, len(v))]
+		chunks = append(chunks, &Item{Key: m.key(item.Key, i), Value: chunk, Expiration: item.Expiration})
+	}
+	top := *item
+	top.Value = []byte(chunkMagic + m.id + "/" + strconv.Itoa(m.n))
+	return &top, chunks, nil
+}
+
+// assemble returns the value of the item at key, whose stored value is b.
+func (c *Chunked) assemble(ctx context.Context, key string, b []byte) ([]byte, error) {
+	m, ok := parseManifest(b)
+	if !ok {
+		return b, nil
+	}
+	keys := m.keys(key)
+	found, err := c.cache.GetMulti(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+	var v []byte
+	for _, k := range keys {
+		chunk, ok := found[k]
+		if !ok {
+			return nil, ErrCacheMiss
+		}
+		v = append(v, chunk...)
+	}
+	return v, nil
+}
+
+// chunkKeys returns the keys of the chunks referred to by
+// the stored values in found, a map from item key to stored value.
+func chunkKeys(found map[string][]byte) []string {
+	var keys []string
+	for k, b := range found {
+		if m, ok := parseManifest(b); ok {
+			keys = append(keys, m.keys(k)...)
+		}
+	}
+	return keys
+}
+
+func (c *Chunked) Get(ctx context.Context, key string) ([]byte, error) {
+	b, err := c.cache.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return c.assemble(ctx, key, b)
+}
+
+func (c *Chunked) Set(ctx context.Context, item *Item) error {
+	if item.Value == nil {
+		return errors.New("nil item value")
+	}
+	top, chunks, err := c.split(item)
+	if err != nil {
+		return err
+	}
+	if err := c.cache.SetMulti(ctx, chunks); err != nil {
+		return err
+	}
+	return c.cache.Set(ctx, top)
+}
+
+func (c *Chunked) Delete(ctx context.Context, key string) error {
+	return c.DeleteMulti(ctx, []string{key})
+}
+
+// GetMulti gets the items with the given keys.
+// Items with an evicted chunk are reported missing.
+func (c *Chunked) GetMulti(ctx context.Context, keys []string) (map[string][]byte, error) {
+	found, err := c.cache.GetMulti(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+	for k, b := range found {
+		v, err := c.assemble(ctx, k, b)
+		switch err {
+		case nil:
+			found[k] = v
+		case ErrCacheMiss:
+			delete(found, k)
+		default:
+			return nil, err
+		}
+	}
+	return found, nil
+}
+
+func (c *Chunked) SetMulti(ctx context.Context, items []*Item) error {
+	var tops, chunks []*Item
+	for _, item := range items {
+		if item.Value == nil {
+			return fmt.Errorf("nil item value for key %q", item.Key)
+		}
+		top, ch, err := c.split(item)
+		if err != nil {
+			return err
+		}
+		tops = append(tops, top)
+		chunks = append(chunks, ch...)
+	}
+	if err := c.cache.SetMulti(ctx, chunks); err != nil {
+		return err
+	}
+	return c.cache.SetMulti(ctx, tops)
+}
+
+// DeleteMulti deletes the items with the given keys and their chunks.
+func (c *Chunked) DeleteMulti(ctx context.Context, keys []string) error {
+	found, err := c.cache.GetMulti(ctx, keys)
+	if err != nil {
+		return err
+	}
+	all := append(append([]string(nil), keys...), chunkKeys(found)...)
+	return c.cache.DeleteMulti(ctx, all)
+}
+
+func (c *Chunked) Add(ctx context.Context, item *Item) error {
+	if item.Value == nil {
+		return errors.New("nil item value")
+	}
+	top, chunks, err := c.split(item)
+	if err != nil {
+		return err
+	}
+	if err := c.cache.SetMulti(ctx, chunks); err != nil {
+		return err
+	}
+	err = c.cache.Add(ctx, top)
+	if err != nil && len(chunks) > 0 {
+		c.deleteChunks(ctx, chunks)
+	}
+	return err
+}
+
+// GetItem gets the item, reassembling its value.
+// The compare-and-swap token is that of the stored manifest,
+// which changes on every write of a chunked value.
+func (c *Chunked) GetItem(ctx context.Context, key string) (*Item, error) {
+	item, err := c.cache.GetItem(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	v, err := c.assemble(ctx, key, item.Value)
+	if err != nil {
+		return nil, err
+	}
+	item.Value = v
+	return item, nil
+}
+
+func (c *Chunked) CompareAndSwap(ctx context.Context, item *Item) error {
+	if item.Value == nil {
+		return errors.New("nil item value")
+	}
+	top, chunks, err := c.split(item)
+	if err != nil {
+		return err
+	}
+	if err := c.cache.SetMulti(ctx, chunks); err != nil {
+		return err
+	}
+	err = c.cache.CompareAndSwap(ctx, top)
+	if err != nil && len(chunks) > 0 {
+		c.deleteChunks(ctx, chunks)
+	}
+	return err
+}
+
+func (c *Chunked) Touch(ctx context.Context, key string, expiration time.Duration) error {
+	b, err := c.cache.GetAndTouch(ctx, key, expiration)
+	if err != nil {
+		return err
+	}
+	return c.touchChunks(ctx, key, b, expiration)
+}
+
+func (c *Chunked) GetAndTouch(ctx context.Context, key string, expiration time.Duration) ([]byte, error) {
+	b, err := c.cache.GetAndTouch(ctx, key, expiration)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.touchChunks(ctx, key, b, expiration); err != nil {
+		return nil, err
+	}
+	return c.assemble(ctx, key, b)
+}
+
+// touchChunks sets the expiration of the chunks of the item at key,
+// whose stored value is b.
+func (c *Chunked) touchChunks(ctx context.Context, key string, b []byte, expiration time.Duration) error {
+	m, ok := parseManifest(b)
+	if !ok {
+		return nil
+	}
+	for _, k := range m.keys(key) {
+		if err := c.cache.Touch(ctx, k, expiration); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteChunks deletes chunks that were written for a failed update.
+func (c *Chunked) deleteChunks(ctx context.Context, chunks []*Item) {
+	keys := make([]string, len(chunks))
+	for i, ch := range chunks {
+		keys[i] = ch.Key
+	}
+	c.cache.DeleteMulti(ctx, keys)
+}
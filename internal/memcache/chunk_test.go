@@ -0,0 +1,107 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package memcache
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestChunked(t *testing.T) {
+	ctx := context.Background()
+	mem := NewMemory(0)
+	c := NewChunked(mem, 10)
+
+	small := []byte("small")
+	large := bytes.Repeat([]byte("0123456789"), 5)
+	large = append(large, "tail"...)
+	if err := c.SetMulti(ctx, []*Item{{Key: "small", Value: small}, {Key: "large", Value: large}}); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := mem.Get(ctx, "small"); !bytes.Equal(b, small) {
+		t.Errorf("small value stored as %q, want unchanged", b)
+	}
+	if n := mem.Len(); n != 2+6 {
+		t.Errorf("underlying cache holds %d items, want 8 (2 items, 6 chunks)", n)
+	}
+	if b, err := c.Get(ctx, "large"); err != nil || !bytes.Equal(b, large) {
+		t.Errorf("Get(large) = %q, %v, want %q", b, err, large)
+	}
+	found, err := c.GetMulti(ctx, []string{"small", "large", "missing"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 2 || !bytes.Equal(found["small"], small) || !bytes.Equal(found["large"], large) {
+		t.Errorf("GetMulti = %q", found)
+	}
+
+	// A value that looks like a manifest is chunked rather than misread.
+	fake := []byte(chunkMagic + "0/1")
+	if err := c.Set(ctx, &Item{Key: "fake", Value: fake}); err != nil {
+		t.Fatal(err)
+	}
+	if b, err := c.Get(ctx, "fake"); err != nil || !bytes.Equal(b, fake) {
+		t.Errorf("Get(fake) = %q, %v, want %q", b, err, fake)
+	}
+
+	// CompareAndSwap works on chunked values.
+	item, err := c.GetItem(ctx, "large")
+	if err != nil || !bytes.Equal(item.Value, large) {
+		t.Fatalf("GetItem = %q, %v", item.Value, err)
+	}
+	item.Value = bytes.Repeat([]byte("x"), 25)
+	if err := c.CompareAndSwap(ctx, item); err != nil {
+		t.Fatalf("CompareAndSwap = %v", err)
+	}
+	if err := c.CompareAndSwap(ctx, item); err != ErrCASConflict {
+		t.Errorf("second CompareAndSwap = %v, want ErrCASConflict", err)
+	}
+	if err := c.Add(ctx, &Item{Key: "large", Value: large}); err != ErrNotStored {
+		t.Errorf("Add of existing key = %v, want ErrNotStored", err)
+	}
+
+	// Deleting removes the chunks too.
+	if err := c.DeleteMulti(ctx, []string{"small", "large", "fake"}); err != nil {
+		t.Fatal(err)
+	}
+	if n := mem.Len(); n != 6 {
+		// Only the chunks of the value replaced by CompareAndSwap remain.
+		t.Errorf("after DeleteMulti, underlying cache holds %d items, want 6", n)
+	}
+
+	// Losing a chunk is a cache miss.
+	c.Set(ctx, &Item{Key: "large", Value: large})
+	b, _ := mem.Get(ctx, "large")
+	m, _ := parseManifest(b)
+	mem.Delete(ctx, m.key("large", 2))
+	if _, err := c.Get(ctx, "large"); err != ErrCacheMiss {
+		t.Errorf("Get with missing chunk = %v, want ErrCacheMiss", err)
+	}
+	if found, _ := c.GetMulti(ctx, []string{"large"}); len(found) != 0 {
+		t.Errorf("GetMulti with missing chunk = %q, want nothing", found)
+	}
+}
+
+func TestChunkedTouch(t *testing.T) {
+	ctx := context.Background()
+	mem := NewMemory(0)
+	now := time.Unix(1e9, 0)
+	mem.now = func() time.Time { return now }
+	c := NewChunked(mem, 4)
+
+	if err := c.Set(ctx, &Item{Key: "k", Value: []byte("0123456789"), Expiration: time.Minute}); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(45 * time.Second)
+	if err := c.Touch(ctx, "k", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(45 * time.Second)
+	if b, err := c.GetAndTouch(ctx, "k", time.Minute); err != nil || string(b) != "0123456789" {
+		t.Errorf("GetAndTouch after Touch = %q, %v", b, err)
+	}
+}
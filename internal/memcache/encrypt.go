@@ -0,0 +1,66 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package memcache
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+)
+
+// Encrypted returns a Codec that encodes values with c
+// and then seals them with aead, so that values are not stored
+// in plaintext on shared cache infrastructure.
+// Each value is sealed with a fresh random nonce,
+// which is stored in front of the ciphertext.
+//
+// Sealing authenticates but does not bind a value to its key:
+// anyone able to write to the cache can still move a sealed value
+// from one key to another. Callers that care should include
+// the key in the value and check it after decoding.
+func Encrypted(c Codec, aead cipher.AEAD) Codec {
+	return Codec{
+		Marshal: func(v interface{}) ([]byte, error) {
+			b, err := c.Marshal(v)
+			if err != nil {
+				return nil, err
+			}
+			nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(b)+aead.Overhead())
+			if _, err := rand.Read(nonce); err != nil {
+				return nil, err
+			}
+			return aead.Seal(nonce, nonce, b, nil), nil
+		},
+		Unmarshal: func(data []byte, v interface{}) error {
+			n := aead.NonceSize()
+			if len(data) < n {
+				return errors.New("memcache: encrypted value too short")
+			}
+			b, err := aead.Open(nil, data[:n], data[n:], nil)
+			if err != nil {
+				return fmt.Errorf("memcache: decrypting value: %v", err)
+			}
+			return c.Unmarshal(b, v)
+		},
+	}
+}
+
+// EncryptedAESGCM returns a Codec that encodes values with c
+// and then seals them using AES-GCM with the given key,
+// which must be 16, 24, or 32 bytes long.
+// See Encrypted for details.
+func EncryptedAESGCM(c Codec, key []byte) (Codec, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return Codec{}, fmt.Errorf("memcache: %v", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return Codec{}, fmt.Errorf("memcache: %v", err)
+	}
+	return Encrypted(c, aead), nil
+}
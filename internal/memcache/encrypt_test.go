@@ -0,0 +1,94 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package memcache
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestEncrypted(t *testing.T) {
+	ctx := context.Background()
+	key := bytes.Repeat([]byte{7}, 32)
+	codec, err := EncryptedAESGCM(JSON, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mem := NewMemory(0)
+	c := NewCodecClient(mem, codec)
+
+	const secret = "session-token-1234"
+	if err := c.Set(ctx, &Item{Key: "k", Object: secret}); err != nil {
+		t.Fatal(err)
+	}
+	stored, _ := mem.Get(ctx, "k")
+	if bytes.Contains(stored, []byte(secret)) {
+		t.Errorf("stored value %q contains plaintext", stored)
+	}
+	var v string
+	if err := c.Get(ctx, "k", &v); err != nil || v != secret {
+		t.Errorf("Get = %q, %v, want %q, nil", v, err, secret)
+	}
+
+	// Tampered values and values sealed with another key are rejected.
+	stored[len(stored)-1] ^= 1
+	mem.Set(ctx, &Item{Key: "k", Value: stored})
+	if err := c.Get(ctx, "k", &v); err == nil {
+		t.Errorf("Get of tampered value succeeded")
+	}
+	other, _ := EncryptedAESGCM(JSON, bytes.Repeat([]byte{8}, 32))
+	NewCodecClient(mem, other).Set(ctx, &Item{Key: "k", Object: secret})
+	if err := c.Get(ctx, "k", &v); err == nil {
+		t.Errorf("Get of value sealed with another key succeeded")
+	}
+	mem.Set(ctx, &Item{Key: "k", Value: []byte("x")})
+	if err := c.Get(ctx, "k", &v); err == nil {
+		t.Errorf("Get of short value succeeded")
+	}
+
+	if _, err := EncryptedAESGCM(JSON, []byte("short")); err == nil {
+		t.Errorf("EncryptedAESGCM with 5-byte key succeeded")
+	}
+}
+
+func TestWrapCodecs(t *testing.T) {
+	ctx := context.Background()
+	key := bytes.Repeat([]byte{7}, 32)
+	encrypted := func(c Codec) Codec {
+		enc, err := EncryptedAESGCM(c, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return enc
+	}
+	mem := NewMemory(0)
+	cache := WrapCodecs(mem, encrypted)
+	c := NewCodecClient(cache, JSON)
+
+	const secret = "session-token-1234"
+	if err := c.Set(ctx, &Item{Key: "k", Object: secret}); err != nil {
+		t.Fatal(err)
+	}
+	stored, _ := mem.Get(ctx, "k")
+	if bytes.Contains(stored, []byte(secret)) {
+		t.Errorf("stored value %q contains plaintext", stored)
+	}
+	var v string
+	if err := NewCodecClient(mem, encrypted(JSON)).Get(ctx, "k", &v); err != nil || v != secret {
+		t.Errorf("Get with encrypting codec = %q, %v, want %q, nil", v, err, secret)
+	}
+	if err := c.Get(ctx, "k", &v); err != nil || v != secret {
+		t.Errorf("Get = %q, %v, want %q, nil", v, err, secret)
+	}
+
+	// Raw items are stored as they are.
+	if err := cache.Set(ctx, &Item{Key: "raw", Value: []byte(secret)}); err != nil {
+		t.Fatal(err)
+	}
+	if b, err := mem.Get(ctx, "raw"); err != nil || string(b) != secret {
+		t.Errorf("raw item = %q, %v, want %q", b, err, secret)
+	}
+}
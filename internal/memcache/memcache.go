@@ -3,16 +3,26 @@
 // license that can be found in the LICENSE file.
 
 // Package memcache provides a minimally compatible interface for
-// google.golang.org/appengine/memcache
-// and stores the data in Redis (e.g., via Cloud Memorystore).
+// google.golang.org/appengine/memcache.
+//
+// The Cache interface describes the operations on raw byte values
+// that every backend provides; CodecClient layers encoding of Go values
+// on top of any Cache. Client is the Redis backend (e.g., via Cloud Memorystore),
+// Memory is a process-local backend for tests and single-node runs,
+// and Open selects a backend by name, for use in configuration.
 package memcache
 
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/gob"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
 	"time"
 
 	"github.com/gomodule/redigo/redis"
@@ -20,25 +30,255 @@ import (
 
 var ErrCacheMiss = errors.New("memcache: cache miss")
 
-func New(addr string) *Client {
+var (
+	// ErrNotStored is returned by Add when the key is already present,
+	// and by CompareAndSwap when the key is no longer present.
+	ErrNotStored = errors.New("memcache: item not stored")
+
+	// ErrCASConflict is returned by CompareAndSwap when the item
+	// was modified since it was read.
+	ErrCASConflict = errors.New("memcache: compare-and-swap conflict")
+)
+
+// A Cache stores byte values by key.
+// Get reports a missing key with ErrCacheMiss.
+// Set stores item.Value; item.Object is only used by CodecClient.
+type Cache interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Set(ctx context.Context, item *Item) error
+	Delete(ctx context.Context, key string) error
+	GetMulti(ctx context.Context, keys []string) (map[string][]byte, error)
+	SetMulti(ctx context.Context, items []*Item) error
+	DeleteMulti(ctx context.Context, keys []string) error
+
+	// Add sets the item only if its key is not already present.
+	Add(ctx context.Context, item *Item) error
+	// GetItem gets the item, recording a compare-and-swap token in it.
+	GetItem(ctx context.Context, key string) (*Item, error)
+	// CompareAndSwap sets an item read by GetItem,
+	// only if it has not been modified since.
+	CompareAndSwap(ctx context.Context, item *Item) error
+
+	// Touch sets the expiration of the item without changing its value.
+	Touch(ctx context.Context, key string, expiration time.Duration) error
+	// GetAndTouch gets the item and sets its expiration.
+	GetAndTouch(ctx context.Context, key string, expiration time.Duration) ([]byte, error)
+}
+
+var _ Cache = (*Client)(nil)
+
+// Open returns a Cache using the named backend at addr.
+// The backends are "redis" (the default, also selected by the empty name),
+// which connects to the Redis server at addr, and "memory",
+// which keeps items in process memory (see Memory) and ignores addr.
+//
+// For the redis backend, addr is either host:port or a URL of the form
+//
+//	redis://[user[:password]@]host:port
+//	rediss://[user[:password]@]host:port
+//
+// where the rediss scheme selects TLS and the user information,
+// if present, is used as by WithAuth. The options are passed to New.
+// A comma-separated list of addresses selects a Ring of those servers.
+func Open(backend, addr string, opts ...Option) (Cache, error) {
+	switch backend {
+	case "memory":
+		return NewMemory(0), nil
+	case "", "redis":
+		if addr == "" {
+			return nil, errors.New("memcache: redis backend requires an address")
+		}
+		if strings.Contains(addr, ",") {
+			return NewRing(strings.Split(addr, ","), opts...)
+		}
+		return dialAddr(addr, opts)
+	}
+	return nil, fmt.Errorf("memcache: unknown backend %q", backend)
+}
+
+// DefaultTimeout is the per-operation timeout used by clients
+// created without the WithTimeout option.
+const DefaultTimeout = 1 * time.Second
+
+// An Option configures a Client created by New.
+type Option func(*Client)
+
+// WithTimeout sets the maximum time a single cache operation may take,
+// including connecting, writing the request, and reading the reply.
+// An operation that runs out of time fails with an error,
+// which callers typically treat like a cache miss,
+// rather than stalling the request that issued it.
+// A timeout of zero means operations are bounded only by their contexts.
+func WithTimeout(d time.Duration) Option {
+	return func(c *Client) { c.timeout = d }
+}
+
+// New returns a new Client for the Redis server at addr.
+func New(addr string, opts ...Option) *Client {
 	const maxConns = 20
 
-	pool := redis.NewPool(func() (redis.Conn, error) {
-		return redis.Dial("tcp", addr)
+	c := &Client{timeout: DefaultTimeout}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.pool = redis.NewPool(func() (redis.Conn, error) {
+		return c.dial(addr)
 	}, maxConns)
+	return c
+}
 
-	return &Client{
-		pool: pool,
+type Client struct {
+	pool    *redis.Pool
+	timeout time.Duration // per-operation timeout; 0 for none
+	tls     *tls.Config   // if non-nil, connect using TLS
+	user    string        // see WithAuth
+	pass    string
+}
+
+// WithTLS makes the client connect to the server using TLS.
+// If config is nil, the client uses the default configuration,
+// verifying the server's certificate against its host name.
+func WithTLS(config *tls.Config) Option {
+	return func(c *Client) {
+		if config == nil {
+			config = new(tls.Config)
+		}
+		c.tls = config
 	}
 }
 
-type Client struct {
-	pool *redis.Pool
+// WithAuth makes the client authenticate to the server on each new connection.
+// If user is empty, the client uses password-only authentication
+// (AUTH password); otherwise it authenticates as the named
+// user (AUTH user password), as required by servers with access control lists.
+func WithAuth(user, password string) Option {
+	return func(c *Client) { c.user, c.pass = user, password }
+}
+
+// dial connects to the Redis server at addr.
+func (c *Client) dial(addr string) (redis.Conn, error) {
+	opts := []redis.DialOption{
+		redis.DialConnectTimeout(c.timeout),
+		redis.DialWriteTimeout(c.timeout),
+	}
+	if c.tls != nil {
+		opts = append(opts, redis.DialUseTLS(true), redis.DialTLSConfig(c.tls))
+	}
+	if c.user == "" && c.pass != "" {
+		opts = append(opts, redis.DialPassword(c.pass))
+	}
+	conn, err := redis.Dial("tcp", addr, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if c.user != "" {
+		// The pinned redigo has no username option; authenticate directly.
+		if _, err := redis.DoWithTimeout(conn, c.timeout, "AUTH", c.user, c.pass); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+// dialAddr returns a Client for addr, which is host:port or a URL as for Open.
+func dialAddr(addr string, opts []Option) (*Client, error) {
+	if strings.Contains(addr, "://") {
+		var err error
+		addr, opts, err = parseURL(addr, opts)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return New(addr, opts...), nil
+}
+
+// Ping checks that the server is reachable and responding.
+func (c *Client) Ping(ctx context.Context) error {
+	conn, err := c.pool.GetContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = c.do(ctx, conn, "PING")
+	return err
+}
+
+// Close closes the client's idle connections
+// and makes further operations fail.
+func (c *Client) Close() error {
+	return c.pool.Close()
+}
+
+// parseURL parses a redis:// or rediss:// URL for Open,
+// returning the server address and opts extended
+// with the options the URL implies.
+func parseURL(rawurl string, opts []Option) (string, []Option, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return "", nil, fmt.Errorf("memcache: %v", err)
+	}
+	switch u.Scheme {
+	case "redis":
+	case "rediss":
+		opts = append(opts, WithTLS(nil))
+	default:
+		return "", nil, fmt.Errorf("memcache: invalid redis URL scheme %q", u.Scheme)
+	}
+	if u.Port() == "" || (u.Path != "" && u.Path != "/") {
+		return "", nil, fmt.Errorf("memcache: invalid redis URL %q: want scheme://[user[:password]@]host:port", u.Redacted())
+	}
+	if u.User != nil {
+		pass, _ := u.User.Password()
+		opts = append(opts, WithAuth(u.User.Username(), pass))
+	}
+	return u.Host, opts, nil
+}
+
+// opTimeout returns the read timeout for an operation running in ctx:
+// the client's timeout, shortened to the context deadline if that is sooner.
+// It returns an error if ctx is already done.
+func (c *Client) opTimeout(ctx context.Context) (time.Duration, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	d := c.timeout
+	if deadline, ok := ctx.Deadline(); ok {
+		left := time.Until(deadline)
+		if left <= 0 {
+			return 0, context.DeadlineExceeded
+		}
+		if d == 0 || left < d {
+			d = left
+		}
+	}
+	return d, nil
+}
+
+// do runs the command on conn, honoring the client timeout and ctx.
+func (c *Client) do(ctx context.Context, conn redis.Conn, cmd string, args ...interface{}) (interface{}, error) {
+	d, err := c.opTimeout(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return redis.DoWithTimeout(conn, d, cmd, args...)
+}
+
+// receive reads the next pipelined reply from conn, honoring the client timeout and ctx.
+func (c *Client) receive(ctx context.Context, conn redis.Conn) (interface{}, error) {
+	d, err := c.opTimeout(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return redis.ReceiveWithTimeout(conn, d)
 }
 
+// A CodecClient stores Go values in a Cache, encoding them with a Codec.
 type CodecClient struct {
-	client *Client
-	codec  Codec
+	cache     Cache
+	codec     Codec
+	namespace string // see WithPrefix
 }
 
 type Item struct {
@@ -46,12 +286,37 @@ type Item struct {
 	Value      []byte
 	Object     interface{}   // Used with Codec.
 	Expiration time.Duration // Read-only.
+
+	casID []byte // compare-and-swap token, set by GetItem
 }
 
 func (c *Client) WithCodec(codec Codec) *CodecClient {
-	return &CodecClient{
-		c, codec,
+	return NewCodecClient(c, codec)
+}
+
+// NewCodecClient returns a CodecClient storing values in cache using codec,
+// as wrapped by WrapCodecs if cache was returned by it.
+func NewCodecClient(cache Cache, codec Codec) *CodecClient {
+	if c, ok := cache.(*codecCache); ok {
+		codec = c.wrap(codec)
 	}
+	return &CodecClient{cache: cache, codec: codec}
+}
+
+// WrapCodecs returns a Cache storing items in cache, with which
+// NewCodecClient replaces the codec it is given by wrap(codec).
+// It lets the owner of a cache wrap the codecs of every CodecClient
+// using it, for example to encrypt their values with Encrypted,
+// without the help of the packages creating those clients.
+// Items set in the Cache directly, without a CodecClient, are stored as they are.
+func WrapCodecs(cache Cache, wrap func(Codec) Codec) Cache {
+	return &codecCache{cache, wrap}
+}
+
+// A codecCache is a Cache whose CodecClients wrap their codecs.
+type codecCache struct {
+	Cache
+	wrap func(Codec) Codec
 }
 
 func (c *Client) Delete(ctx context.Context, key string) error {
@@ -61,12 +326,16 @@ func (c *Client) Delete(ctx context.Context, key string) error {
 	}
 	defer conn.Close()
 
-	_, err = conn.Do("DEL", key)
+	_, err = c.do(ctx, conn, "DEL", key)
 	return err
 }
 
 func (c *CodecClient) Delete(ctx context.Context, key string) error {
-	return c.client.Delete(ctx, key)
+	prefix, err := c.prefix(ctx)
+	if err != nil {
+		return err
+	}
+	return c.cache.Delete(ctx, prefix+key)
 }
 
 func (c *Client) Set(ctx context.Context, item *Item) error {
@@ -77,14 +346,11 @@ func (c *Client) Set(ctx context.Context, item *Item) error {
 }
 
 func (c *CodecClient) Set(ctx context.Context, item *Item) error {
-	if item.Object == nil {
-		return errors.New("nil object value")
-	}
-	b, err := c.codec.Marshal(item.Object)
+	encoded, err := c.encode(ctx, item)
 	if err != nil {
 		return err
 	}
-	return c.client.set(ctx, item.Key, b, item.Expiration)
+	return c.cache.Set(ctx, encoded)
 }
 
 func (c *Client) set(ctx context.Context, key string, value []byte, expiration time.Duration) error {
@@ -94,20 +360,109 @@ func (c *Client) set(ctx context.Context, key string, value []byte, expiration t
 	}
 	defer conn.Close()
 
+	cmd, args := setCommand(key, value, expiration)
+	_, err = c.do(ctx, conn, cmd, args...)
+	return err
+}
+
+// setCommand returns the redis command and arguments
+// storing value at key with the given expiration.
+func setCommand(key string, value []byte, expiration time.Duration) (string, []interface{}) {
 	if expiration == 0 {
-		_, err := conn.Do("SET", key, value)
-		return err
+		return "SET", []interface{}{key, value}
 	}
 
 	// NOTE(cbro): redis does not support expiry in units more granular than a second.
 	exp := int64(expiration.Seconds())
 	if exp == 0 {
 		// Redis doesn't allow a zero expiration, delete the key instead.
-		_, err := conn.Do("DEL", key)
+		return "DEL", []interface{}{key}
+	}
+	return "SETEX", []interface{}{key, exp, value}
+}
+
+// GetMulti gets the items with the given keys in a single round trip.
+// The returned map holds only the keys that were found;
+// missing keys are not an error.
+func (c *Client) GetMulti(ctx context.Context, keys []string) (map[string][]byte, error) {
+	found := make(map[string][]byte)
+	if len(keys) == 0 {
+		return found, nil
+	}
+	conn, err := c.pool.GetContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	args := make([]interface{}, len(keys))
+	for i, k := range keys {
+		args[i] = k
+	}
+	values, err := redis.ByteSlices(c.do(ctx, conn, "MGET", args...))
+	if err != nil {
+		return nil, err
+	}
+	for i, v := range values {
+		if v != nil {
+			found[keys[i]] = v
+		}
+	}
+	return found, nil
+}
+
+// SetMulti sets the items in a single round trip.
+// Like Set, it requires every item to have a non-nil Value.
+func (c *Client) SetMulti(ctx context.Context, items []*Item) error {
+	for _, item := range items {
+		if item.Value == nil {
+			return fmt.Errorf("nil item value for key %q", item.Key)
+		}
+	}
+	if len(items) == 0 {
+		return nil
+	}
+	conn, err := c.pool.GetContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	for _, item := range items {
+		cmd, args := setCommand(item.Key, item.Value, item.Expiration)
+		if err := conn.Send(cmd, args...); err != nil {
+			return err
+		}
+	}
+	if err := conn.Flush(); err != nil {
+		return err
+	}
+	var firstErr error
+	for range items {
+		if _, err := c.receive(ctx, conn); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// DeleteMulti deletes the items with the given keys in a single round trip.
+// Deleting keys that do not exist is not an error.
+func (c *Client) DeleteMulti(ctx context.Context, keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	conn, err := c.pool.GetContext(ctx)
+	if err != nil {
 		return err
 	}
+	defer conn.Close()
 
-	_, err = conn.Do("SETEX", key, exp, value)
+	args := make([]interface{}, len(keys))
+	for i, k := range keys {
+		args[i] = k
+	}
+	_, err = c.do(ctx, conn, "DEL", args...)
 	return err
 }
 
@@ -119,7 +474,7 @@ func (c *Client) Get(ctx context.Context, key string) ([]byte, error) {
 	}
 	defer conn.Close()
 
-	b, err := redis.Bytes(conn.Do("GET", key))
+	b, err := redis.Bytes(c.do(ctx, conn, "GET", key))
 	if err == redis.ErrNil {
 		err = ErrCacheMiss
 	}
@@ -127,18 +482,100 @@ func (c *Client) Get(ctx context.Context, key string) ([]byte, error) {
 }
 
 func (c *CodecClient) Get(ctx context.Context, key string, v interface{}) error {
-	b, err := c.client.Get(ctx, key)
+	prefix, err := c.prefix(ctx)
 	if err != nil {
 		return err
 	}
+	b, err := c.cache.Get(ctx, prefix+key)
+	if err != nil {
+		return err
+	}
+	if isNotFound(b) {
+		return ErrNotFound
+	}
 	return c.codec.Unmarshal(b, v)
 }
 
+// GetMulti gets the items with the given keys in a single round trip,
+// decoding each value found into a new element of the map m,
+// which must be a non-nil map with string keys.
+// Missing keys are not an error; they are simply absent from m,
+// as are keys recorded with SetNotFound.
+func (c *CodecClient) GetMulti(ctx context.Context, keys []string, m interface{}) error {
+	mv := reflect.ValueOf(m)
+	if mv.Kind() != reflect.Map || mv.IsNil() || mv.Type().Key().Kind() != reflect.String {
+		return fmt.Errorf("GetMulti: have %T, want non-nil map with string keys", m)
+	}
+	prefix, err := c.prefix(ctx)
+	if err != nil {
+		return err
+	}
+	found, err := c.cache.GetMulti(ctx, prefixed(prefix, keys))
+	if err != nil {
+		return err
+	}
+	elem := mv.Type().Elem()
+	for k, b := range found {
+		if isNotFound(b) {
+			continue
+		}
+		k = strings.TrimPrefix(k, prefix)
+		v := reflect.New(elem)
+		if err := c.codec.Unmarshal(b, v.Interface()); err != nil {
+			return fmt.Errorf("decoding %q: %v", k, err)
+		}
+		mv.SetMapIndex(reflect.ValueOf(k).Convert(mv.Type().Key()), v.Elem())
+	}
+	return nil
+}
+
+// SetMulti encodes and sets the items in a single round trip.
+// Like Set, it requires every item to have a non-nil Object.
+func (c *CodecClient) SetMulti(ctx context.Context, items []*Item) error {
+	for _, item := range items {
+		if item.Object == nil {
+			return fmt.Errorf("nil object value for key %q", item.Key)
+		}
+	}
+	prefix, err := c.prefix(ctx)
+	if err != nil {
+		return err
+	}
+	encoded := make([]*Item, len(items))
+	for i, item := range items {
+		b, err := c.codec.Marshal(item.Object)
+		if err != nil {
+			return err
+		}
+		encoded[i] = &Item{Key: prefix + item.Key, Value: b, Expiration: item.Expiration}
+	}
+	return c.cache.SetMulti(ctx, encoded)
+}
+
+// DeleteMulti deletes the items with the given keys in a single round trip.
+func (c *CodecClient) DeleteMulti(ctx context.Context, keys []string) error {
+	prefix, err := c.prefix(ctx)
+	if err != nil {
+		return err
+	}
+	return c.cache.DeleteMulti(ctx, prefixed(prefix, keys))
+}
+
+// Codecs for use with Client.WithCodec.
 var (
-	Gob  = Codec{gobMarshal, gobUnmarshal}
+	// Gob encodes values with encoding/gob.
+	// It round-trips all exported fields, including those with json:"-" tags,
+	// but its values can only be read by Go programs.
+	Gob = Codec{gobMarshal, gobUnmarshal}
+
+	// JSON encodes values with encoding/json.
+	// Its values can be read by non-Go consumers and inspected
+	// with standard tools (for example, redis-cli GET key | jq),
+	// at the cost of honoring json struct tags, which may omit fields.
 	JSON = Codec{json.Marshal, json.Unmarshal}
 )
 
+// A Codec converts between Go values and the bytes stored in the cache.
 type Codec struct {
 	Marshal   func(interface{}) ([]byte, error)
 	Unmarshal func([]byte, interface{}) error
@@ -6,6 +6,7 @@ package memcache
 
 import (
 	"context"
+	"net"
 	"os"
 	"testing"
 	"time"
@@ -81,3 +82,256 @@ func TestShortExpiry(t *testing.T) {
 		t.Errorf("GetBytes: got %v, want ErrCacheMiss", err)
 	}
 }
+
+func TestCodecs(t *testing.T) {
+	type value struct {
+		Name  string
+		Count int
+		When  time.Time
+	}
+	in := value{"gopher", 42, time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)}
+	for name, codec := range map[string]Codec{"Gob": Gob, "JSON": JSON} {
+		b, err := codec.Marshal(&in)
+		if err != nil {
+			t.Fatalf("%s.Marshal: %v", name, err)
+		}
+		var out value
+		if err := codec.Unmarshal(b, &out); err != nil {
+			t.Fatalf("%s.Unmarshal: %v", name, err)
+		}
+		if out != in {
+			t.Errorf("%s round trip = %+v, want %+v", name, out, in)
+		}
+	}
+
+	b, err := JSON.Marshal(&in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"Name":"gopher","Count":42,"When":"2026-01-02T03:04:05Z"}`; string(b) != want {
+		t.Errorf("JSON.Marshal = %s, want %s", b, want)
+	}
+}
+
+func TestMulti(t *testing.T) {
+	c := getClient(t)
+	ctx := context.Background()
+
+	items := []*Item{
+		{Key: "testmulti1", Value: []byte("one")},
+		{Key: "testmulti2", Value: []byte("two"), Expiration: time.Minute},
+	}
+	if err := c.SetMulti(ctx, items); err != nil {
+		t.Fatalf("SetMulti: %v", err)
+	}
+	got, err := c.GetMulti(ctx, []string{"testmulti1", "testmulti2", "doesnotexist"})
+	if err != nil {
+		t.Fatalf("GetMulti: %v", err)
+	}
+	if len(got) != 2 || string(got["testmulti1"]) != "one" || string(got["testmulti2"]) != "two" {
+		t.Errorf("GetMulti = %q, want testmulti1=one testmulti2=two", got)
+	}
+
+	cc := c.WithCodec(JSON)
+	if err := cc.SetMulti(ctx, []*Item{{Key: "testmulti3", Object: 3}}); err != nil {
+		t.Fatalf("CodecClient.SetMulti: %v", err)
+	}
+	nums := make(map[string]int)
+	if err := cc.GetMulti(ctx, []string{"testmulti3", "doesnotexist"}, nums); err != nil {
+		t.Fatalf("CodecClient.GetMulti: %v", err)
+	}
+	if len(nums) != 1 || nums["testmulti3"] != 3 {
+		t.Errorf("CodecClient.GetMulti = %v, want testmulti3=3", nums)
+	}
+
+	if err := c.DeleteMulti(ctx, []string{"testmulti1", "testmulti2", "testmulti3"}); err != nil {
+		t.Fatalf("DeleteMulti: %v", err)
+	}
+	if got, err := c.GetMulti(ctx, []string{"testmulti1", "testmulti2", "testmulti3"}); err != nil || len(got) != 0 {
+		t.Errorf("GetMulti after DeleteMulti = %q, %v; want empty", got, err)
+	}
+}
+
+// hungServer returns the address of a server that accepts
+// connections but never replies.
+func hungServer(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			t.Cleanup(func() { c.Close() })
+		}
+	}()
+	return l.Addr().String()
+}
+
+func TestTimeout(t *testing.T) {
+	addr := hungServer(t)
+
+	c := New(addr, WithTimeout(50*time.Millisecond))
+	start := time.Now()
+	if _, err := c.Get(context.Background(), "key"); err == nil {
+		t.Errorf("Get from hung server succeeded")
+	}
+	if d := time.Since(start); d > 2*time.Second {
+		t.Errorf("Get from hung server took %v, want about 50ms", d)
+	}
+
+	// The context deadline applies when it is sooner than the client timeout.
+	c = New(addr, WithTimeout(time.Hour))
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start = time.Now()
+	if err := c.Delete(ctx, "key"); err == nil {
+		t.Errorf("Delete from hung server succeeded")
+	}
+	if d := time.Since(start); d > 2*time.Second {
+		t.Errorf("Delete from hung server took %v, want about 50ms", d)
+	}
+
+	// A context that is already done fails immediately.
+	cancel()
+	if _, err := c.Get(ctx, "key"); err != context.Canceled && err != context.DeadlineExceeded {
+		t.Errorf("Get with done context = %v, want context error", err)
+	}
+}
+
+// mapCache is a Cache backed by a map, for testing CodecClient.
+type mapCache map[string][]byte
+
+func (m mapCache) Get(ctx context.Context, key string) ([]byte, error) {
+	b, ok := m[key]
+	if !ok {
+		return nil, ErrCacheMiss
+	}
+	return b, nil
+}
+
+func (m mapCache) Set(ctx context.Context, item *Item) error {
+	m[item.Key] = item.Value
+	return nil
+}
+
+func (m mapCache) Delete(ctx context.Context, key string) error {
+	delete(m, key)
+	return nil
+}
+
+func (m mapCache) GetMulti(ctx context.Context, keys []string) (map[string][]byte, error) {
+	found := make(map[string][]byte)
+	for _, k := range keys {
+		if b, ok := m[k]; ok {
+			found[k] = b
+		}
+	}
+	return found, nil
+}
+
+func (m mapCache) SetMulti(ctx context.Context, items []*Item) error {
+	for _, item := range items {
+		m[item.Key] = item.Value
+	}
+	return nil
+}
+
+func (m mapCache) DeleteMulti(ctx context.Context, keys []string) error {
+	for _, k := range keys {
+		delete(m, k)
+	}
+	return nil
+}
+
+func (m mapCache) Add(ctx context.Context, item *Item) error {
+	if _, ok := m[item.Key]; ok {
+		return ErrNotStored
+	}
+	return m.Set(ctx, item)
+}
+
+func (m mapCache) GetItem(ctx context.Context, key string) (*Item, error) {
+	b, err := m.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return &Item{Key: key, Value: b, casID: b}, nil
+}
+
+func (m mapCache) CompareAndSwap(ctx context.Context, item *Item) error {
+	b, ok := m[item.Key]
+	if !ok {
+		return ErrNotStored
+	}
+	if string(b) != string(item.casID) {
+		return ErrCASConflict
+	}
+	return m.Set(ctx, item)
+}
+
+func (m mapCache) Touch(ctx context.Context, key string, expiration time.Duration) error {
+	_, err := m.Get(ctx, key)
+	return err
+}
+
+func (m mapCache) GetAndTouch(ctx context.Context, key string, expiration time.Duration) ([]byte, error) {
+	return m.Get(ctx, key)
+}
+
+func TestCodecClientCache(t *testing.T) {
+	ctx := context.Background()
+	m := make(mapCache)
+	c := NewCodecClient(m, JSON)
+
+	if err := c.Set(ctx, &Item{Key: "a", Object: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if got := string(m["a"]); got != "1" {
+		t.Errorf("stored %q, want %q", got, "1")
+	}
+	if err := c.SetMulti(ctx, []*Item{{Key: "b", Object: 2}, {Key: "c", Object: 3}}); err != nil {
+		t.Fatal(err)
+	}
+	var v int
+	if err := c.Get(ctx, "b", &v); err != nil || v != 2 {
+		t.Errorf("Get(b) = %d, %v, want 2, nil", v, err)
+	}
+	got := make(map[string]int)
+	if err := c.GetMulti(ctx, []string{"a", "c", "missing"}, got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got["a"] != 1 || got["c"] != 3 {
+		t.Errorf("GetMulti = %v, want map[a:1 c:3]", got)
+	}
+	if err := c.DeleteMulti(ctx, []string{"a", "b"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Get(ctx, "a", &v); err != ErrCacheMiss {
+		t.Errorf("Get(a) after delete = %v, want ErrCacheMiss", err)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	if c, err := Open("redis", "127.0.0.1:6379"); err != nil {
+		t.Errorf("Open(redis) = %v", err)
+	} else if _, ok := c.(*Client); !ok {
+		t.Errorf("Open(redis) = %T, want *Client", c)
+	}
+	if _, err := Open("redis", ""); err == nil {
+		t.Errorf("Open(redis) without address succeeded")
+	}
+	if c, err := Open("memory", ""); err != nil {
+		t.Errorf("Open(memory) = %v", err)
+	} else if _, ok := c.(*Memory); !ok {
+		t.Errorf("Open(memory) = %T, want *Memory", c)
+	}
+	if _, err := Open("bogus", "127.0.0.1:6379"); err == nil {
+		t.Errorf("Open(bogus) succeeded")
+	}
+}
@@ -0,0 +1,289 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package memcache
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultMemoryItems is the item limit used by NewMemory
+// when passed a limit of zero.
+const DefaultMemoryItems = 10000
+
+// A Memory is a Cache that stores items in process memory.
+// It is meant for tests and single-node deployments:
+// items are not shared between processes and do not survive restarts.
+//
+// A Memory holds at most a fixed number of items,
+// evicting the least recently used item to make room for a new one.
+// Expired items are dropped when next accessed or evicted.
+type Memory struct {
+	mu    sync.Mutex
+	max   int
+	lru   *list.List // of *memEntry, most recently used first
+	items map[string]*list.Element
+	now   func() time.Time // for testing
+}
+
+type memEntry struct {
+	key     string
+	value   []byte
+	expires time.Time // zero for no expiration
+}
+
+var _ Cache = (*Memory)(nil)
+
+// NewMemory returns a new Memory holding at most maxItems items.
+// If maxItems is zero, the limit is DefaultMemoryItems.
+func NewMemory(maxItems int) *Memory {
+	if maxItems <= 0 {
+		maxItems = DefaultMemoryItems
+	}
+	return &Memory{
+		max:   maxItems,
+		lru:   list.New(),
+		items: make(map[string]*list.Element),
+		now:   time.Now,
+	}
+}
+
+// lookup returns the live element for key, dropping it if it has expired.
+// The caller must hold m.mu.
+func (m *Memory) lookup(key string) *list.Element {
+	e := m.items[key]
+	if e == nil {
+		return nil
+	}
+	ent := e.Value.(*memEntry)
+	if !ent.expires.IsZero() && !m.now().Before(ent.expires) {
+		m.remove(e)
+		return nil
+	}
+	m.lru.MoveToFront(e)
+	return e
+}
+
+// remove removes e from the cache. The caller must hold m.mu.
+func (m *Memory) remove(e *list.Element) {
+	m.lru.Remove(e)
+	delete(m.items, e.Value.(*memEntry).key)
+}
+
+// set stores value at key. The caller must hold m.mu.
+func (m *Memory) set(key string, value []byte, expiration time.Duration) {
+	if expiration != 0 && int64(expiration.Seconds()) == 0 {
+		// Match the Redis backend, which deletes items
+		// whose expiration rounds down to zero seconds.
+		if e := m.items[key]; e != nil {
+			m.remove(e)
+		}
+		return
+	}
+	ent := &memEntry{key: key, value: append([]byte(nil), value...)}
+	if expiration > 0 {
+		ent.expires = m.now().Add(expiration)
+	}
+	if e := m.items[key]; e != nil {
+		e.Value = ent
+		m.lru.MoveToFront(e)
+		return
+	}
+	m.items[key] = m.lru.PushFront(ent)
+	for m.lru.Len() > m.max {
+		m.remove(m.lru.Back())
+	}
+}
+
+// Get gets the item.
+func (m *Memory) Get(ctx context.Context, key string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e := m.lookup(key)
+	if e == nil {
+		return nil, ErrCacheMiss
+	}
+	return append([]byte(nil), e.Value.(*memEntry).value...), nil
+}
+
+func (m *Memory) Set(ctx context.Context, item *Item) error {
+	if item.Value == nil {
+		return errors.New("nil item value")
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.set(item.Key, item.Value, item.Expiration)
+	return nil
+}
+
+func (m *Memory) Delete(ctx context.Context, key string) error {
+	return m.DeleteMulti(ctx, []string{key})
+}
+
+// GetMulti gets the items with the given keys.
+// The returned map holds only the keys that were found.
+func (m *Memory) GetMulti(ctx context.Context, keys []string) (map[string][]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	found := make(map[string][]byte)
+	for _, k := range keys {
+		if e := m.lookup(k); e != nil {
+			found[k] = append([]byte(nil), e.Value.(*memEntry).value...)
+		}
+	}
+	return found, nil
+}
+
+// SetMulti sets the items.
+// Like Set, it requires every item to have a non-nil Value.
+func (m *Memory) SetMulti(ctx context.Context, items []*Item) error {
+	for _, item := range items {
+		if item.Value == nil {
+			return fmt.Errorf("nil item value for key %q", item.Key)
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, item := range items {
+		m.set(item.Key, item.Value, item.Expiration)
+	}
+	return nil
+}
+
+// DeleteMulti deletes the items with the given keys.
+// Deleting keys that do not exist is not an error.
+func (m *Memory) DeleteMulti(ctx context.Context, keys []string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, k := range keys {
+		if e := m.items[k]; e != nil {
+			m.remove(e)
+		}
+	}
+	return nil
+}
+
+// Add sets the item only if its key is not already present,
+// returning ErrNotStored if it is.
+func (m *Memory) Add(ctx context.Context, item *Item) error {
+	if item.Value == nil {
+		return errors.New("nil item value")
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.lookup(item.Key) != nil {
+		return ErrNotStored
+	}
+	m.set(item.Key, item.Value, casExpiration(item.Expiration))
+	return nil
+}
+
+// GetItem gets the item, recording in it the token
+// that CompareAndSwap uses to detect concurrent modification.
+func (m *Memory) GetItem(ctx context.Context, key string) (*Item, error) {
+	b, err := m.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if b == nil {
+		b = []byte{} // a nil token means the item was not read by GetItem
+	}
+	return &Item{Key: key, Value: b, casID: b}, nil
+}
+
+// CompareAndSwap sets an item previously returned by GetItem,
+// provided it has not been modified since.
+func (m *Memory) CompareAndSwap(ctx context.Context, item *Item) error {
+	if item.Value == nil {
+		return errors.New("nil item value")
+	}
+	if item.casID == nil {
+		return errors.New("CompareAndSwap: item not obtained from GetItem")
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e := m.lookup(item.Key)
+	if e == nil {
+		return ErrNotStored
+	}
+	if !bytes.Equal(e.Value.(*memEntry).value, item.casID) {
+		return ErrCASConflict
+	}
+	m.set(item.Key, item.Value, casExpiration(item.Expiration))
+	return nil
+}
+
+// casExpiration returns expiration rounded as by casSeconds.
+func casExpiration(expiration time.Duration) time.Duration {
+	return time.Duration(casSeconds(expiration)) * time.Second
+}
+
+// Touch sets the expiration of the item without changing its value,
+// returning ErrCacheMiss if it is not present.
+func (m *Memory) Touch(ctx context.Context, key string, expiration time.Duration) error {
+	_, err := m.GetAndTouch(ctx, key, expiration)
+	return err
+}
+
+// GetAndTouch gets the item and sets its expiration.
+func (m *Memory) GetAndTouch(ctx context.Context, key string, expiration time.Duration) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e := m.lookup(key)
+	if e == nil {
+		return nil, ErrCacheMiss
+	}
+	ent := e.Value.(*memEntry)
+	ent.expires = time.Time{}
+	if exp := casExpiration(expiration); exp > 0 {
+		ent.expires = m.now().Add(exp)
+	}
+	return append([]byte(nil), ent.value...), nil
+}
+
+// Len returns the number of items in the cache,
+// including expired items that have not yet been dropped.
+func (m *Memory) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.lru.Len()
+}
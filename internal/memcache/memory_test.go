@@ -0,0 +1,100 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package memcache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryExpiry(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory(0)
+	now := time.Unix(1e9, 0)
+	m.now = func() time.Time { return now }
+
+	if err := m.Set(ctx, &Item{Key: "short", Value: []byte("a"), Expiration: time.Minute}); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Set(ctx, &Item{Key: "forever", Value: []byte("b")}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Get(ctx, "short"); err != nil {
+		t.Errorf("Get(short) before expiry = %v", err)
+	}
+	now = now.Add(time.Minute)
+	if _, err := m.Get(ctx, "short"); err != ErrCacheMiss {
+		t.Errorf("Get(short) after expiry = %v, want ErrCacheMiss", err)
+	}
+	if b, err := m.Get(ctx, "forever"); err != nil || string(b) != "b" {
+		t.Errorf("Get(forever) = %q, %v, want %q, nil", b, err, "b")
+	}
+
+	// Like Redis, an expiration under a second deletes the item.
+	if err := m.Set(ctx, &Item{Key: "forever", Value: []byte("c"), Expiration: time.Millisecond}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Get(ctx, "forever"); err != ErrCacheMiss {
+		t.Errorf("Get after sub-second Set = %v, want ErrCacheMiss", err)
+	}
+}
+
+func TestMemoryLRU(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory(2)
+
+	set := func(key string) {
+		t.Helper()
+		if err := m.Set(ctx, &Item{Key: key, Value: []byte(key)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	set("a")
+	set("b")
+	if _, err := m.Get(ctx, "a"); err != nil { // a is now most recently used
+		t.Fatal(err)
+	}
+	set("c") // evicts b
+	if m.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", m.Len())
+	}
+	got, err := m.GetMulti(ctx, []string{"a", "b", "c"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := got["b"]; ok || len(got) != 2 {
+		t.Errorf("GetMulti after eviction = %q, want a and c", got)
+	}
+}
+
+func TestMemoryCodec(t *testing.T) {
+	ctx := context.Background()
+	c := NewCodecClient(NewMemory(0), Gob)
+
+	type T struct{ Name string }
+	if err := c.Set(ctx, &Item{Key: "k", Object: &T{"gopher"}}); err != nil {
+		t.Fatal(err)
+	}
+	var v T
+	if err := c.Get(ctx, "k", &v); err != nil || v.Name != "gopher" {
+		t.Errorf("Get = %+v, %v, want {gopher}, nil", v, err)
+	}
+	if err := c.Delete(ctx, "k"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Get(ctx, "k", &v); err != ErrCacheMiss {
+		t.Errorf("Get after Delete = %v, want ErrCacheMiss", err)
+	}
+
+	// Values are copied, so callers cannot modify cached data.
+	m := NewMemory(0)
+	b := []byte("x")
+	m.Set(ctx, &Item{Key: "k", Value: b})
+	b[0] = 'y'
+	if got, _ := m.Get(ctx, "k"); string(got) != "x" {
+		t.Errorf("Get after modifying stored slice = %q, want %q", got, "x")
+	}
+}
@@ -0,0 +1,206 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package memcache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics receives measurements of cache operations from an Instrumented cache.
+// Implementations must be safe for concurrent use.
+type Metrics interface {
+	// Operation records one completed operation, such as "get" or "setmulti",
+	// which took d and returned err.
+	// A cache miss is reported with an err matching ErrCacheMiss.
+	Operation(op string, d time.Duration, err error)
+
+	// Lookups records the outcome of the keys read by one operation.
+	Lookups(hits, misses int)
+}
+
+// An Instrumented is a Cache that reports the operations
+// on another Cache to a Metrics.
+type Instrumented struct {
+	cache   Cache
+	metrics Metrics
+}
+
+var _ Cache = (*Instrumented)(nil)
+
+// NewInstrumented returns a Cache that reports
+// the operations on c to m.
+func NewInstrumented(c Cache, m Metrics) *Instrumented {
+	return &Instrumented{c, m}
+}
+
+func (c *Instrumented) observe(op string, start time.Time, err error) {
+	c.metrics.Operation(op, time.Since(start), err)
+}
+
+func (c *Instrumented) Get(ctx context.Context, key string) ([]byte, error) {
+	start := time.Now()
+	v, err := c.cache.Get(ctx, key)
+	c.observe("get", start, err)
+	switch {
+	case err == nil:
+		c.metrics.Lookups(1, 0)
+	case errors.Is(err, ErrCacheMiss):
+		c.metrics.Lookups(0, 1)
+	}
+	return v, err
+}
+
+func (c *Instrumented) Set(ctx context.Context, item *Item) error {
+	start := time.Now()
+	err := c.cache.Set(ctx, item)
+	c.observe("set", start, err)
+	return err
+}
+
+func (c *Instrumented) Delete(ctx context.Context, key string) error {
+	start := time.Now()
+	err := c.cache.Delete(ctx, key)
+	c.observe("delete", start, err)
+	return err
+}
+
+func (c *Instrumented) GetMulti(ctx context.Context, keys []string) (map[string][]byte, error) {
+	start := time.Now()
+	m, err := c.cache.GetMulti(ctx, keys)
+	c.observe("getmulti", start, err)
+	if err == nil {
+		c.metrics.Lookups(len(m), len(keys)-len(m))
+	}
+	return m, err
+}
+
+func (c *Instrumented) SetMulti(ctx context.Context, items []*Item) error {
+	start := time.Now()
+	err := c.cache.SetMulti(ctx, items)
+	c.observe("setmulti", start, err)
+	return err
+}
+
+func (c *Instrumented) DeleteMulti(ctx context.Context, keys []string) error {
+	start := time.Now()
+	err := c.cache.DeleteMulti(ctx, keys)
+	c.observe("deletemulti", start, err)
+	return err
+}
+
+func (c *Instrumented) Add(ctx context.Context, item *Item) error {
+	start := time.Now()
+	err := c.cache.Add(ctx, item)
+	c.observe("add", start, err)
+	return err
+}
+
+func (c *Instrumented) GetItem(ctx context.Context, key string) (*Item, error) {
+	start := time.Now()
+	item, err := c.cache.GetItem(ctx, key)
+	c.observe("getitem", start, err)
+	switch {
+	case err == nil:
+		c.metrics.Lookups(1, 0)
+	case errors.Is(err, ErrCacheMiss):
+		c.metrics.Lookups(0, 1)
+	}
+	return item, err
+}
+
+func (c *Instrumented) CompareAndSwap(ctx context.Context, item *Item) error {
+	start := time.Now()
+	err := c.cache.CompareAndSwap(ctx, item)
+	c.observe("cas", start, err)
+	return err
+}
+
+func (c *Instrumented) Touch(ctx context.Context, key string, expiration time.Duration) error {
+	start := time.Now()
+	err := c.cache.Touch(ctx, key, expiration)
+	c.observe("touch", start, err)
+	return err
+}
+
+func (c *Instrumented) GetAndTouch(ctx context.Context, key string, expiration time.Duration) ([]byte, error) {
+	start := time.Now()
+	v, err := c.cache.GetAndTouch(ctx, key, expiration)
+	c.observe("getandtouch", start, err)
+	switch {
+	case err == nil:
+		c.metrics.Lookups(1, 0)
+	case errors.Is(err, ErrCacheMiss):
+		c.metrics.Lookups(0, 1)
+	}
+	return v, err
+}
+
+// A PrometheusMetrics is a Metrics that exports its measurements
+// as Prometheus metrics:
+//
+//	memcache_operations_total{op, result}      counter; result is "ok", "miss", "notstored", or "error"
+//	memcache_operation_duration_seconds{op}    histogram
+//	memcache_lookups_total{result}             counter; result is "hit" or "miss"
+type PrometheusMetrics struct {
+	ops      *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	lookups  *prometheus.CounterVec
+}
+
+var _ Metrics = (*PrometheusMetrics)(nil)
+
+// NewPrometheusMetrics returns a new PrometheusMetrics
+// with its metrics registered with reg.
+func NewPrometheusMetrics(reg prometheus.Registerer) (*PrometheusMetrics, error) {
+	m := &PrometheusMetrics{
+		ops: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "memcache_operations_total",
+			Help: "Cache operations by operation and result.",
+		}, []string{"op", "result"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "memcache_operation_duration_seconds",
+			Help:    "Latency of cache operations.",
+			Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+		}, []string{"op"}),
+		lookups: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "memcache_lookups_total",
+			Help: "Keys read from the cache by result.",
+		}, []string{"result"}),
+	}
+	for _, c := range []prometheus.Collector{m.ops, m.duration, m.lookups} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+func (m *PrometheusMetrics) Operation(op string, d time.Duration, err error) {
+	result := "ok"
+	switch {
+	case err == nil:
+	case errors.Is(err, ErrCacheMiss):
+		result = "miss"
+	case errors.Is(err, ErrNotStored), errors.Is(err, ErrCASConflict):
+		result = "notstored"
+	default:
+		result = "error"
+	}
+	m.ops.WithLabelValues(op, result).Inc()
+	m.duration.WithLabelValues(op).Observe(d.Seconds())
+}
+
+func (m *PrometheusMetrics) Lookups(hits, misses int) {
+	if hits > 0 {
+		m.lookups.WithLabelValues("hit").Add(float64(hits))
+	}
+	if misses > 0 {
+		m.lookups.WithLabelValues("miss").Add(float64(misses))
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package memcache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestPrometheusMetrics(t *testing.T) {
+	ctx := context.Background()
+	reg := prometheus.NewRegistry()
+	m, err := NewPrometheusMetrics(reg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &flakyCache{mapCache: make(mapCache)}
+	c := NewInstrumented(f, m)
+
+	c.Set(ctx, &Item{Key: "a", Value: []byte("1")})
+	c.Get(ctx, "a")
+	c.Get(ctx, "b")
+	c.GetMulti(ctx, []string{"a", "b", "c"})
+	f.err = errors.New("down")
+	c.Get(ctx, "a")
+	f.err = fmt.Errorf("chunk 2: %w", ErrCacheMiss)
+	c.Get(ctx, "a")
+
+	want := `
+# HELP memcache_lookups_total Keys read from the cache by result.
+# TYPE memcache_lookups_total counter
+memcache_lookups_total{result="hit"} 2
+memcache_lookups_total{result="miss"} 4
+# HELP memcache_operations_total Cache operations by operation and result.
+# TYPE memcache_operations_total counter
+memcache_operations_total{op="get",result="error"} 1
+memcache_operations_total{op="get",result="miss"} 2
+memcache_operations_total{op="get",result="ok"} 1
+memcache_operations_total{op="getmulti",result="ok"} 1
+memcache_operations_total{op="set",result="ok"} 1
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(want), "memcache_lookups_total", "memcache_operations_total"); err != nil {
+		t.Error(err)
+	}
+	if n := testutil.CollectAndCount(m.duration); n != 3 {
+		t.Errorf("duration histograms = %d, want 3 (get, getmulti, set)", n)
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package memcache
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+)
+
+// WithPrefix returns a CodecClient that stores its items
+// in the named namespace of c's cache, replacing any namespace of c.
+// Keys used with the returned client are transparently prefixed,
+// so that subsystems sharing one cache cannot collide,
+// and all items in the namespace can be invalidated at once
+// with InvalidateNamespace.
+//
+// The namespace's current generation is itself stored in the cache,
+// so every operation on a namespaced client costs one extra cache read.
+func (c *CodecClient) WithPrefix(namespace string) *CodecClient {
+	return &CodecClient{cache: c.cache, codec: c.codec, namespace: namespace}
+}
+
+// genKey returns the key holding the namespace's generation.
+func (c *CodecClient) genKey() string {
+	return c.namespace + ":gen"
+}
+
+// prefix returns the prefix for the keys of items in c's namespace,
+// or the empty string if c has no namespace.
+func (c *CodecClient) prefix(ctx context.Context) (string, error) {
+	if c.namespace == "" {
+		return "", nil
+	}
+	gen, err := c.generation(ctx)
+	if err != nil {
+		return "", err
+	}
+	return c.namespace + ":" + strconv.FormatInt(gen, 10) + ":", nil
+}
+
+// generation returns the current generation of c's namespace.
+// If the cache has no generation for the namespace,
+// for example because it was evicted, generation starts a new one
+// based on the current time, so that it cannot revive items
+// stored under an earlier generation. Clients starting one at once
+// agree on the generation of the first to add it.
+func (c *CodecClient) generation(ctx context.Context) (int64, error) {
+	gen, err := c.readGeneration(ctx)
+	if !errors.Is(err, ErrCacheMiss) {
+		return gen, err
+	}
+	gen = time.Now().UnixNano()
+	err = c.cache.Add(ctx, c.generationItem(gen))
+	if errors.Is(err, ErrNotStored) {
+		// Another client started a generation first.
+		return c.readGeneration(ctx)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return gen, nil
+}
+
+// readGeneration returns the generation of c's namespace stored in the cache,
+// or ErrCacheMiss if there is none.
+func (c *CodecClient) readGeneration(ctx context.Context) (int64, error) {
+	b, err := c.cache.Get(ctx, c.genKey())
+	if err != nil {
+		return 0, err
+	}
+	gen, err := strconv.ParseInt(string(b), 10, 64)
+	if err != nil {
+		// Replace the corrupt generation with a new one.
+		gen = time.Now().UnixNano()
+		return gen, c.setGeneration(ctx, gen)
+	}
+	return gen, nil
+}
+
+func (c *CodecClient) generationItem(gen int64) *Item {
+	return &Item{Key: c.genKey(), Value: []byte(strconv.FormatInt(gen, 10))}
+}
+
+func (c *CodecClient) setGeneration(ctx context.Context, gen int64) error {
+	return c.cache.Set(ctx, c.generationItem(gen))
+}
+
+// InvalidateNamespace invalidates every item in c's namespace
+// by advancing the namespace's generation.
+// The invalidated items are not deleted, but they can no longer be read
+// and are left for the cache to expire or evict.
+func (c *CodecClient) InvalidateNamespace(ctx context.Context) error {
+	if c.namespace == "" {
+		return errors.New("InvalidateNamespace: client has no namespace")
+	}
+	gen, err := c.generation(ctx)
+	if err != nil {
+		return err
+	}
+	return c.setGeneration(ctx, gen+1)
+}
+
+// prefixed returns keys with prefix added to each.
+func prefixed(prefix string, keys []string) []string {
+	if prefix == "" {
+		return keys
+	}
+	p := make([]string, len(keys))
+	for i, k := range keys {
+		p[i] = prefix + k
+	}
+	return p
+}
@@ -0,0 +1,92 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package memcache
+
+import (
+	"context"
+	"testing"
+)
+
+func TestWithPrefix(t *testing.T) {
+	ctx := context.Background()
+	mem := NewMemory(0)
+	base := NewCodecClient(mem, JSON)
+	dl := base.WithPrefix("dl")
+	short := base.WithPrefix("short")
+
+	for _, c := range []*CodecClient{base, dl, short} {
+		if err := c.Set(ctx, &Item{Key: "k", Object: c.namespace}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, c := range []*CodecClient{base, dl, short} {
+		var v string
+		if err := c.Get(ctx, "k", &v); err != nil || v != c.namespace {
+			t.Errorf("namespace %q: Get = %q, %v, want %q, nil", c.namespace, v, err, c.namespace)
+		}
+	}
+
+	got := make(map[string]string)
+	if err := dl.GetMulti(ctx, []string{"k", "missing"}, got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got["k"] != "dl" {
+		t.Errorf("GetMulti = %v, want map[k:dl]", got)
+	}
+
+	if err := dl.InvalidateNamespace(ctx); err != nil {
+		t.Fatal(err)
+	}
+	var v string
+	if err := dl.Get(ctx, "k", &v); err != ErrCacheMiss {
+		t.Errorf("Get after InvalidateNamespace = %q, %v, want ErrCacheMiss", v, err)
+	}
+	if err := short.Get(ctx, "k", &v); err != nil || v != "short" {
+		t.Errorf("other namespace after InvalidateNamespace: Get = %q, %v", v, err)
+	}
+	if err := base.InvalidateNamespace(ctx); err == nil {
+		t.Errorf("InvalidateNamespace without namespace succeeded")
+	}
+
+	// Losing the generation must not revive invalidated items.
+	if err := dl.Set(ctx, &Item{Key: "k", Object: "new"}); err != nil {
+		t.Fatal(err)
+	}
+	mem.Delete(ctx, dl.genKey())
+	if err := dl.Get(ctx, "k", &v); err != ErrCacheMiss {
+		t.Errorf("Get after losing generation = %q, %v, want ErrCacheMiss", v, err)
+	}
+}
+
+// A lateCache is a Cache whose first Get of key misses, as if another
+// client added key between the Get and the Add that follows it.
+type lateCache struct {
+	Cache
+	key    string
+	missed bool
+}
+
+func (c *lateCache) Get(ctx context.Context, key string) ([]byte, error) {
+	if key == c.key && !c.missed {
+		c.missed = true
+		return nil, ErrCacheMiss
+	}
+	return c.Cache.Get(ctx, key)
+}
+
+func TestGenerationRace(t *testing.T) {
+	ctx := context.Background()
+	mem := NewMemory(0)
+	first := NewCodecClient(mem, JSON).WithPrefix("dl")
+	if err := first.Set(ctx, &Item{Key: "k", Object: "v"}); err != nil {
+		t.Fatal(err)
+	}
+	// The second client starts a generation just after the first.
+	second := NewCodecClient(&lateCache{Cache: mem, key: first.genKey()}, JSON).WithPrefix("dl")
+	var v string
+	if err := second.Get(ctx, "k", &v); err != nil || v != "v" {
+		t.Errorf("Get by client losing the race for the generation = %q, %v, want %q", v, err, "v")
+	}
+}
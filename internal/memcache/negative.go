@@ -0,0 +1,44 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package memcache
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrNotFound is returned by CodecClient.Get, GetItem, GetAndTouch,
+// and GetStale for a key recorded with SetNotFound: the cache knows
+// that the origin has no value for the key, as opposed to not knowing
+// anything (ErrCacheMiss).
+var ErrNotFound = errors.New("memcache: cached not-found result")
+
+// DefaultNotFoundTTL is a typical expiration for not-found results.
+// It should be short, since the value may start existing at any time.
+const DefaultNotFoundTTL = time.Minute
+
+// notFound is stored in place of a value to record a not-found result.
+// No codec produces it: gob values start with a nonzero length,
+// JSON values with printable text, and encrypted values with a random nonce.
+var notFound = []byte("\x00memcache-not-found\x00")
+
+// SetNotFound records that the origin has no value for key,
+// so that for the next ttl, Get reports ErrNotFound
+// instead of the caller consulting the origin again.
+// Setting a real value for key, or deleting it, clears the record.
+func (c *CodecClient) SetNotFound(ctx context.Context, key string, ttl time.Duration) error {
+	prefix, err := c.prefix(ctx)
+	if err != nil {
+		return err
+	}
+	return c.cache.Set(ctx, &Item{Key: prefix + key, Value: notFound, Expiration: ttl})
+}
+
+// isNotFound reports whether b records a not-found result.
+func isNotFound(b []byte) bool {
+	return bytes.Equal(b, notFound)
+}
@@ -0,0 +1,58 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package memcache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestNotFound(t *testing.T) {
+	ctx := context.Background()
+	mem := NewMemory(0)
+	now := time.Unix(1e9, 0)
+	mem.now = func() time.Time { return now }
+
+	for _, codec := range []Codec{Gob, JSON} {
+		c := NewCodecClient(mem, codec).WithPrefix("neg")
+		var v string
+		if err := c.Get(ctx, "k", &v); err != ErrCacheMiss {
+			t.Fatalf("Get before SetNotFound = %v, want ErrCacheMiss", err)
+		}
+		if err := c.SetNotFound(ctx, "k", time.Minute); err != nil {
+			t.Fatal(err)
+		}
+		if err := c.Get(ctx, "k", &v); err != ErrNotFound {
+			t.Errorf("Get after SetNotFound = %v, want ErrNotFound", err)
+		}
+		if _, err := c.GetItem(ctx, "k", &v); err != ErrNotFound {
+			t.Errorf("GetItem after SetNotFound = %v, want ErrNotFound", err)
+		}
+		if _, err := c.GetStale(ctx, "k", &v); err != ErrNotFound {
+			t.Errorf("GetStale after SetNotFound = %v, want ErrNotFound", err)
+		}
+		if err := c.GetAndTouch(ctx, "k", time.Minute, &v); err != ErrNotFound {
+			t.Errorf("GetAndTouch after SetNotFound = %v, want ErrNotFound", err)
+		}
+		m := make(map[string]string)
+		if err := c.GetMulti(ctx, []string{"k"}, m); err != nil || len(m) != 0 {
+			t.Errorf("GetMulti after SetNotFound = %v, %v, want empty map", m, err)
+		}
+		if err := c.Set(ctx, &Item{Key: "k", Object: "found"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := c.Get(ctx, "k", &v); err != nil || v != "found" {
+			t.Errorf("Get after Set = %q, %v, want %q, nil", v, err, "found")
+		}
+
+		// Not-found results expire.
+		c.SetNotFound(ctx, "k", time.Minute)
+		now = now.Add(time.Minute)
+		if err := c.Get(ctx, "k", &v); err != ErrCacheMiss {
+			t.Errorf("Get after not-found result expired = %v, want ErrCacheMiss", err)
+		}
+	}
+}
@@ -0,0 +1,343 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package memcache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNoServers is returned by a Ring's operations
+// when none of its servers is healthy.
+var ErrNoServers = errors.New("memcache: no healthy servers")
+
+// HealthInterval is how often a Ring checks the health of its servers.
+const HealthInterval = 10 * time.Second
+
+// ringPoints is the number of points each server occupies on a Ring.
+// More points spread keys more evenly between servers.
+const ringPoints = 128
+
+// A Ring is a Cache that spreads keys across several servers
+// using consistent hashing, so that adding or removing a server
+// moves only the keys that server owned.
+//
+// A Ring periodically checks the health of each server.
+// While a server is unhealthy, its keys are served by the next healthy
+// server on the ring; they come back once the server recovers.
+// Losing a server thus loses only its share of the keyspace,
+// rather than disabling the cache.
+type Ring struct {
+	nodes  []*ringNode
+	points []ringPoint // sorted by hash
+	stop   chan struct{}
+	done   chan struct{} // closed when health checks stop; nil if never started
+}
+
+type ringNode struct {
+	name  string
+	cache Cache
+	ping  func(context.Context) error
+
+	mu      sync.Mutex
+	healthy bool
+}
+
+type ringPoint struct {
+	hash uint32
+	node *ringNode
+}
+
+var _ Cache = (*Ring)(nil)
+
+// NewRing returns a Ring of the Redis servers at addrs,
+// each of which is host:port or a URL as for Open.
+// The options are passed to New for each server.
+// The caller must call Close to stop the Ring's health checks.
+func NewRing(addrs []string, opts ...Option) (*Ring, error) {
+	var names []string
+	var nodes []*Client
+	for _, addr := range addrs {
+		addr = strings.TrimSpace(addr)
+		if addr == "" {
+			continue
+		}
+		c, err := dialAddr(addr, opts)
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, addr)
+		nodes = append(nodes, c)
+	}
+	if len(nodes) == 0 {
+		return nil, errors.New("memcache: ring requires at least one address")
+	}
+	r := newRing()
+	for i, c := range nodes {
+		r.add(names[i], c, c.Ping)
+	}
+	r.start(HealthInterval)
+	return r, nil
+}
+
+func newRing() *Ring {
+	return &Ring{stop: make(chan struct{})}
+}
+
+// add adds the cache named name to the ring.
+// Names, not the caches, determine key placement,
+// so a server keeps its keys across restarts.
+func (r *Ring) add(name string, c Cache, ping func(context.Context) error) {
+	n := &ringNode{name: name, cache: c, ping: ping, healthy: true}
+	r.nodes = append(r.nodes, n)
+	for i := 0; i < ringPoints; i++ {
+		h := crc32.ChecksumIEEE([]byte(name + "#" + strconv.Itoa(i)))
+		r.points = append(r.points, ringPoint{h, n})
+	}
+	sort.Slice(r.points, func(i, j int) bool { return r.points[i].hash < r.points[j].hash })
+}
+
+// start starts checking the health of the ring's servers every interval.
+func (r *Ring) start(interval time.Duration) {
+	r.done = make(chan struct{})
+	go func() {
+		defer close(r.done)
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-r.stop:
+				return
+			case <-t.C:
+				r.CheckHealth(context.Background())
+			}
+		}
+	}()
+}
+
+// CheckHealth pings every server, updating which are considered healthy.
+// A Ring calls CheckHealth periodically; calling it directly
+// is only necessary to react to a change sooner.
+func (r *Ring) CheckHealth(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, n := range r.nodes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := n.ping(ctx)
+			n.mu.Lock()
+			defer n.mu.Unlock()
+			if healthy := err == nil; healthy != n.healthy {
+				if healthy {
+					log.Printf("memcache: server %s is healthy again", n.name)
+				} else {
+					log.Printf("ERROR memcache: server %s is unhealthy: %v", n.name, err)
+				}
+				n.healthy = healthy
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// Healthy returns the names of the servers currently considered healthy.
+func (r *Ring) Healthy() []string {
+	var names []string
+	for _, n := range r.nodes {
+		if n.isHealthy() {
+			names = append(names, n.name)
+		}
+	}
+	return names
+}
+
+func (n *ringNode) isHealthy() bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.healthy
+}
+
+// Close stops the ring's health checks and closes its servers' connections.
+func (r *Ring) Close() error {
+	select {
+	case <-r.stop:
+		return nil
+	default:
+	}
+	close(r.stop)
+	if r.done != nil {
+		<-r.done
+	}
+	var firstErr error
+	for _, n := range r.nodes {
+		if c, ok := n.cache.(*Client); ok {
+			if err := c.Close(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// node returns the healthy node that owns key.
+func (r *Ring) node(key string) (*ringNode, error) {
+	h := crc32.ChecksumIEEE([]byte(key))
+	i := sort.Search(len(r.points), func(i int) bool { return r.points[i].hash >= h })
+	for j := 0; j < len(r.points); j++ {
+		n := r.points[(i+j)%len(r.points)].node
+		if n.isHealthy() {
+			return n, nil
+		}
+	}
+	return nil, ErrNoServers
+}
+
+// Owner returns the name of the server currently holding key.
+func (r *Ring) Owner(key string) (string, error) {
+	n, err := r.node(key)
+	if err != nil {
+		return "", err
+	}
+	return n.name, nil
+}
+
+// group splits keys by the node that owns them.
+func (r *Ring) group(keys []string) (map[*ringNode][]string, error) {
+	m := make(map[*ringNode][]string)
+	for _, k := range keys {
+		n, err := r.node(k)
+		if err != nil {
+			return nil, err
+		}
+		m[n] = append(m[n], k)
+	}
+	return m, nil
+}
+
+func (r *Ring) Get(ctx context.Context, key string) ([]byte, error) {
+	n, err := r.node(key)
+	if err != nil {
+		return nil, err
+	}
+	return n.cache.Get(ctx, key)
+}
+
+func (r *Ring) Set(ctx context.Context, item *Item) error {
+	n, err := r.node(item.Key)
+	if err != nil {
+		return err
+	}
+	return n.cache.Set(ctx, item)
+}
+
+func (r *Ring) Delete(ctx context.Context, key string) error {
+	n, err := r.node(key)
+	if err != nil {
+		return err
+	}
+	return n.cache.Delete(ctx, key)
+}
+
+// GetMulti gets the items with the given keys,
+// making one request to each server that holds any of them.
+func (r *Ring) GetMulti(ctx context.Context, keys []string) (map[string][]byte, error) {
+	groups, err := r.group(keys)
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[string][]byte)
+	for n, keys := range groups {
+		m, err := n.cache.GetMulti(ctx, keys)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", n.name, err)
+		}
+		for k, v := range m {
+			found[k] = v
+		}
+	}
+	return found, nil
+}
+
+// SetMulti sets the items,
+// making one request to each server that holds any of them.
+func (r *Ring) SetMulti(ctx context.Context, items []*Item) error {
+	groups := make(map[*ringNode][]*Item)
+	for _, item := range items {
+		n, err := r.node(item.Key)
+		if err != nil {
+			return err
+		}
+		groups[n] = append(groups[n], item)
+	}
+	for n, items := range groups {
+		if err := n.cache.SetMulti(ctx, items); err != nil {
+			return fmt.Errorf("%s: %w", n.name, err)
+		}
+	}
+	return nil
+}
+
+// DeleteMulti deletes the items with the given keys,
+// making one request to each server that holds any of them.
+func (r *Ring) DeleteMulti(ctx context.Context, keys []string) error {
+	groups, err := r.group(keys)
+	if err != nil {
+		return err
+	}
+	for n, keys := range groups {
+		if err := n.cache.DeleteMulti(ctx, keys); err != nil {
+			return fmt.Errorf("%s: %w", n.name, err)
+		}
+	}
+	return nil
+}
+
+func (r *Ring) Add(ctx context.Context, item *Item) error {
+	n, err := r.node(item.Key)
+	if err != nil {
+		return err
+	}
+	return n.cache.Add(ctx, item)
+}
+
+func (r *Ring) GetItem(ctx context.Context, key string) (*Item, error) {
+	n, err := r.node(key)
+	if err != nil {
+		return nil, err
+	}
+	return n.cache.GetItem(ctx, key)
+}
+
+func (r *Ring) CompareAndSwap(ctx context.Context, item *Item) error {
+	n, err := r.node(item.Key)
+	if err != nil {
+		return err
+	}
+	return n.cache.CompareAndSwap(ctx, item)
+}
+
+func (r *Ring) Touch(ctx context.Context, key string, expiration time.Duration) error {
+	n, err := r.node(key)
+	if err != nil {
+		return err
+	}
+	return n.cache.Touch(ctx, key, expiration)
+}
+
+func (r *Ring) GetAndTouch(ctx context.Context, key string, expiration time.Duration) ([]byte, error) {
+	n, err := r.node(key)
+	if err != nil {
+		return nil, err
+	}
+	return n.cache.GetAndTouch(ctx, key, expiration)
+}
@@ -0,0 +1,137 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package memcache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// testRing returns a Ring of n Memory caches, with pings that fail
+// for the servers marked down in the returned map.
+func testRing(n int) (*Ring, map[string]*Memory, map[string]bool) {
+	r := newRing()
+	mems := make(map[string]*Memory)
+	down := make(map[string]bool)
+	for i := 0; i < n; i++ {
+		name := fmt.Sprintf("10.0.0.%d:6379", i+1)
+		m := NewMemory(0)
+		mems[name] = m
+		r.add(name, m, func(context.Context) error {
+			if down[name] {
+				return errors.New("down")
+			}
+			return nil
+		})
+	}
+	return r, mems, down
+}
+
+func TestRing(t *testing.T) {
+	ctx := context.Background()
+	r, mems, down := testRing(3)
+
+	const n = 300
+	var keys []string
+	for i := 0; i < n; i++ {
+		keys = append(keys, fmt.Sprintf("key%d", i))
+	}
+	var items []*Item
+	for _, k := range keys {
+		items = append(items, &Item{Key: k, Value: []byte(k)})
+	}
+	if err := r.SetMulti(ctx, items); err != nil {
+		t.Fatal(err)
+	}
+	for name, m := range mems {
+		// Each server should hold a reasonable share of the keys.
+		if l := m.Len(); l < n/10 {
+			t.Errorf("server %s holds %d of %d keys", name, l, n)
+		}
+	}
+	found, err := r.GetMulti(ctx, keys)
+	if err != nil || len(found) != n {
+		t.Fatalf("GetMulti found %d of %d keys (err %v)", len(found), n, err)
+	}
+
+	// Taking one server down loses only its keys.
+	victim, err := r.Owner("key0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lost := mems[victim].Len()
+	down[victim] = true
+	r.CheckHealth(ctx)
+	if h := r.Healthy(); len(h) != 2 {
+		t.Errorf("Healthy() = %v, want 2 servers", h)
+	}
+	found, err = r.GetMulti(ctx, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != n-lost {
+		t.Errorf("with %s down, found %d keys, want %d", victim, len(found), n-lost)
+	}
+	if owner, _ := r.Owner("key0"); owner == victim {
+		t.Errorf("unhealthy server %s still owns key0", victim)
+	}
+	if err := r.Set(ctx, &Item{Key: "key0", Value: []byte("new")}); err != nil {
+		t.Fatal(err)
+	}
+
+	// When it recovers, it owns its keys again.
+	down[victim] = false
+	r.CheckHealth(ctx)
+	if b, err := r.Get(ctx, "key0"); err != nil || string(b) != "key0" {
+		t.Errorf("Get(key0) after recovery = %q, %v, want %q", b, err, "key0")
+	}
+
+	for name := range mems {
+		down[name] = true
+	}
+	r.CheckHealth(ctx)
+	if _, err := r.Get(ctx, "key0"); err != ErrNoServers {
+		t.Errorf("Get with all servers down = %v, want ErrNoServers", err)
+	}
+}
+
+func TestRingStable(t *testing.T) {
+	// Adding a server moves only the keys it takes over.
+	r3, _, _ := testRing(3)
+	r4, _, _ := testRing(4)
+	const n = 1000
+	moved := 0
+	for i := 0; i < n; i++ {
+		k := fmt.Sprintf("key%d", i)
+		o3, _ := r3.Owner(k)
+		o4, _ := r4.Owner(k)
+		if o3 != o4 {
+			if o4 != "10.0.0.4:6379" {
+				t.Fatalf("%s moved from %s to %s, not to the new server", k, o3, o4)
+			}
+			moved++
+		}
+	}
+	if moved > n/2 {
+		t.Errorf("adding a fourth server moved %d of %d keys", moved, n)
+	}
+}
+
+func TestOpenRing(t *testing.T) {
+	c, err := Open("redis", "127.0.0.1:6379, 127.0.0.1:6380")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, ok := c.(*Ring)
+	if !ok {
+		t.Fatalf("Open with two addresses = %T, want *Ring", c)
+	}
+	defer r.Close()
+	if len(r.nodes) != 2 {
+		t.Errorf("ring has %d servers, want 2", len(r.nodes))
+	}
+}
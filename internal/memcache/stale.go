@@ -0,0 +1,61 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package memcache
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"time"
+)
+
+// Stale-on-error items carry a soft expiration inside the stored value,
+// in addition to the hard expiration enforced by the cache.
+// Between the two, GetStale still returns the value, but reports it stale,
+// so that a caller can try to refresh it and fall back to the stale value
+// if the refresh fails, instead of failing the request.
+// Items stored with SetStale must only be read with GetStale.
+
+// timeNow is time.Now, replaced in tests.
+var timeNow = time.Now
+
+// SetStale encodes and sets the item like Set,
+// recording that its value becomes stale after softTTL.
+// The item's Expiration, which should be longer than softTTL,
+// bounds how long the stale value remains available.
+func (c *CodecClient) SetStale(ctx context.Context, item *Item, softTTL time.Duration) error {
+	encoded, err := c.encode(ctx, item)
+	if err != nil {
+		return err
+	}
+	v := make([]byte, 8, 8+len(encoded.Value))
+	binary.BigEndian.PutUint64(v, uint64(timeNow().Add(softTTL).UnixNano()))
+	encoded.Value = append(v, encoded.Value...)
+	return c.cache.Set(ctx, encoded)
+}
+
+// GetStale gets an item stored by SetStale, decoding its value into v.
+// It reports whether the value is past its soft expiration.
+func (c *CodecClient) GetStale(ctx context.Context, key string, v interface{}) (stale bool, err error) {
+	prefix, err := c.prefix(ctx)
+	if err != nil {
+		return false, err
+	}
+	b, err := c.cache.Get(ctx, prefix+key)
+	if err != nil {
+		return false, err
+	}
+	if isNotFound(b) {
+		return false, ErrNotFound
+	}
+	if len(b) < 8 {
+		return false, errors.New("GetStale: item not stored by SetStale")
+	}
+	soft := time.Unix(0, int64(binary.BigEndian.Uint64(b)))
+	if err := c.codec.Unmarshal(b[8:], v); err != nil {
+		return false, err
+	}
+	return !timeNow().Before(soft), nil
+}
@@ -0,0 +1,43 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package memcache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestStale(t *testing.T) {
+	now := time.Unix(1e9, 0)
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	ctx := context.Background()
+	c := NewCodecClient(NewMemory(0), Gob)
+
+	if err := c.SetStale(ctx, &Item{Key: "k", Object: "v", Expiration: time.Hour}, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	var v string
+	stale, err := c.GetStale(ctx, "k", &v)
+	if err != nil || stale || v != "v" {
+		t.Errorf("GetStale = %v, %v (value %q), want fresh %q", stale, err, v, "v")
+	}
+	now = now.Add(time.Minute)
+	v = ""
+	stale, err = c.GetStale(ctx, "k", &v)
+	if err != nil || !stale || v != "v" {
+		t.Errorf("GetStale after soft TTL = %v, %v (value %q), want stale %q", stale, err, v, "v")
+	}
+
+	c.cache.Set(ctx, &Item{Key: "short", Value: []byte("x")})
+	if _, err := c.GetStale(ctx, "short", &v); err == nil {
+		t.Errorf("GetStale of item not stored by SetStale succeeded")
+	}
+	if _, err := c.GetStale(ctx, "missing", &v); err != ErrCacheMiss {
+		t.Errorf("GetStale of missing item = %v, want ErrCacheMiss", err)
+	}
+}
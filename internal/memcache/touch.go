@@ -0,0 +1,85 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package memcache
+
+import (
+	"context"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// Touch sets the expiration of the item without changing its value,
+// returning ErrCacheMiss if it is not present.
+// An expiration of zero means the item does not expire.
+func (c *Client) Touch(ctx context.Context, key string, expiration time.Duration) error {
+	conn, err := c.pool.GetContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var n int
+	if exp := casSeconds(expiration); exp > 0 {
+		n, err = redis.Int(c.do(ctx, conn, "EXPIRE", key, exp))
+	} else {
+		// PERSIST reports 0 for an item that has no expiration,
+		// so check for existence explicitly.
+		if _, err = c.do(ctx, conn, "PERSIST", key); err == nil {
+			n, err = redis.Int(c.do(ctx, conn, "EXISTS", key))
+		}
+	}
+	if err == nil && n == 0 {
+		err = ErrCacheMiss
+	}
+	return err
+}
+
+// GetAndTouch gets the item and sets its expiration in one operation,
+// implementing a sliding expiration for frequently read items.
+// An expiration of zero means the item does not expire.
+func (c *Client) GetAndTouch(ctx context.Context, key string, expiration time.Duration) ([]byte, error) {
+	conn, err := c.pool.GetContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	var b []byte
+	if exp := casSeconds(expiration); exp > 0 {
+		b, err = redis.Bytes(c.do(ctx, conn, "GETEX", key, "EX", exp))
+	} else {
+		b, err = redis.Bytes(c.do(ctx, conn, "GETEX", key, "PERSIST"))
+	}
+	if err == redis.ErrNil {
+		err = ErrCacheMiss
+	}
+	return b, err
+}
+
+// Touch sets the expiration of the item without changing its value.
+func (c *CodecClient) Touch(ctx context.Context, key string, expiration time.Duration) error {
+	prefix, err := c.prefix(ctx)
+	if err != nil {
+		return err
+	}
+	return c.cache.Touch(ctx, prefix+key, expiration)
+}
+
+// GetAndTouch gets the item, decoding its value into v, and sets its expiration.
+func (c *CodecClient) GetAndTouch(ctx context.Context, key string, expiration time.Duration, v interface{}) error {
+	prefix, err := c.prefix(ctx)
+	if err != nil {
+		return err
+	}
+	b, err := c.cache.GetAndTouch(ctx, prefix+key, expiration)
+	if err != nil {
+		return err
+	}
+	if isNotFound(b) {
+		return ErrNotFound
+	}
+	return c.codec.Unmarshal(b, v)
+}
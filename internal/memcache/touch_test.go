@@ -0,0 +1,91 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package memcache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+func TestTouchMemory(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory(0)
+	now := time.Unix(1e9, 0)
+	m.now = func() time.Time { return now }
+	c := NewCodecClient(m, JSON)
+
+	if err := c.Set(ctx, &Item{Key: "k", Object: "v", Expiration: time.Minute}); err != nil {
+		t.Fatal(err)
+	}
+	// Reading with GetAndTouch every 45 seconds keeps the item alive.
+	for i := 0; i < 4; i++ {
+		now = now.Add(45 * time.Second)
+		var v string
+		if err := c.GetAndTouch(ctx, "k", time.Minute, &v); err != nil || v != "v" {
+			t.Fatalf("GetAndTouch #%d = %q, %v, want %q, nil", i, v, err, "v")
+		}
+	}
+	now = now.Add(time.Minute)
+	if err := c.Touch(ctx, "k", time.Minute); err != ErrCacheMiss {
+		t.Errorf("Touch of expired item = %v, want ErrCacheMiss", err)
+	}
+
+	// Touching with zero expiration removes the expiration.
+	c.Set(ctx, &Item{Key: "k", Object: "v", Expiration: time.Minute})
+	if err := c.Touch(ctx, "k", 0); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(time.Hour)
+	var v string
+	if err := c.Get(ctx, "k", &v); err != nil {
+		t.Errorf("Get after Touch(0) = %v", err)
+	}
+}
+
+func TestTouchRedis(t *testing.T) {
+	c := getClient(t)
+	ctx := context.Background()
+
+	ttl := func() int {
+		t.Helper()
+		conn := c.pool.Get()
+		defer conn.Close()
+		n, err := redis.Int(conn.Do("TTL", "touch"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	if err := c.Set(ctx, &Item{Key: "touch", Value: []byte("v"), Expiration: time.Hour}); err != nil {
+		t.Fatal(err)
+	}
+	b, err := c.GetAndTouch(ctx, "touch", 10*time.Second)
+	if err != nil || string(b) != "v" {
+		t.Fatalf("GetAndTouch = %q, %v, want %q, nil", b, err, "v")
+	}
+	if n := ttl(); n > 10 || n <= 0 {
+		t.Errorf("TTL after GetAndTouch = %d, want about 10", n)
+	}
+	if err := c.Touch(ctx, "touch", 0); err != nil {
+		t.Fatal(err)
+	}
+	if n := ttl(); n != -1 {
+		t.Errorf("TTL after Touch(0) = %d, want -1 (no expiration)", n)
+	}
+	c.Delete(ctx, "touch")
+	if err := c.Touch(ctx, "touch", time.Minute); err != ErrCacheMiss {
+		t.Errorf("Touch of missing item = %v, want ErrCacheMiss", err)
+	}
+	if err := c.Touch(ctx, "touch", 0); err != ErrCacheMiss {
+		t.Errorf("Touch(0) of missing item = %v, want ErrCacheMiss", err)
+	}
+	if _, err := c.GetAndTouch(ctx, "touch", time.Minute); err != ErrCacheMiss {
+		t.Errorf("GetAndTouch of missing item = %v, want ErrCacheMiss", err)
+	}
+}
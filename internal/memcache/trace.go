@@ -0,0 +1,184 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package memcache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName is the instrumentation name of the spans a Traced cache emits.
+const tracerName = "github.com/matttproud/yourtour/internal/memcache"
+
+// A Traced is a Cache that records an OpenTelemetry span
+// for each operation on another Cache, so that the time a request
+// spends in the cache can be told apart from time spent elsewhere.
+// Spans are named "memcache.<op>" and carry the attributes
+//
+//	memcache.key   the key, or the first key of a multi-key operation
+//	memcache.keys  the number of keys, for multi-key operations
+//	memcache.hit   whether a read found its item
+//	memcache.size  the number of bytes read or written
+//
+// Operation errors other than cache misses and failed Add or
+// CompareAndSwap calls mark the span as failed.
+type Traced struct {
+	cache  Cache
+	tracer trace.Tracer
+}
+
+var _ Cache = (*Traced)(nil)
+
+// NewTraced returns a Cache that traces the operations on c
+// using tracers from tp. With a no-op TracerProvider,
+// such as the global provider when no tracing is configured,
+// the overhead is negligible.
+func NewTraced(c Cache, tp trace.TracerProvider) *Traced {
+	return &Traced{c, tp.Tracer(tracerName)}
+}
+
+// start starts the span for operation op on keys.
+func (c *Traced) start(ctx context.Context, op string, keys ...string) (context.Context, trace.Span) {
+	attrs := []attribute.KeyValue{attribute.String("memcache.op", op)}
+	if len(keys) > 0 {
+		attrs = append(attrs, attribute.String("memcache.key", keys[0]))
+	}
+	if len(keys) != 1 {
+		attrs = append(attrs, attribute.Int("memcache.keys", len(keys)))
+	}
+	return c.tracer.Start(ctx, "memcache."+op, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+// end ends span, recording err.
+func end(span trace.Span, err error) {
+	switch {
+	case err == nil:
+	case errors.Is(err, ErrCacheMiss), errors.Is(err, ErrNotStored), errors.Is(err, ErrCASConflict):
+		span.SetAttributes(attribute.String("memcache.result", err.Error()))
+	default:
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// read records the result of reading v into span.
+func read(span trace.Span, v []byte, err error) {
+	span.SetAttributes(attribute.Bool("memcache.hit", err == nil))
+	if err == nil {
+		span.SetAttributes(attribute.Int("memcache.size", len(v)))
+	}
+}
+
+func itemKeys(items []*Item) ([]string, int) {
+	keys := make([]string, len(items))
+	size := 0
+	for i, item := range items {
+		keys[i] = item.Key
+		size += len(item.Value)
+	}
+	return keys, size
+}
+
+func (c *Traced) Get(ctx context.Context, key string) ([]byte, error) {
+	ctx, span := c.start(ctx, "get", key)
+	v, err := c.cache.Get(ctx, key)
+	read(span, v, err)
+	end(span, err)
+	return v, err
+}
+
+func (c *Traced) Set(ctx context.Context, item *Item) error {
+	ctx, span := c.start(ctx, "set", item.Key)
+	span.SetAttributes(attribute.Int("memcache.size", len(item.Value)))
+	err := c.cache.Set(ctx, item)
+	end(span, err)
+	return err
+}
+
+func (c *Traced) Delete(ctx context.Context, key string) error {
+	ctx, span := c.start(ctx, "delete", key)
+	err := c.cache.Delete(ctx, key)
+	end(span, err)
+	return err
+}
+
+func (c *Traced) GetMulti(ctx context.Context, keys []string) (map[string][]byte, error) {
+	ctx, span := c.start(ctx, "getmulti", keys...)
+	m, err := c.cache.GetMulti(ctx, keys)
+	if err == nil {
+		size := 0
+		for _, v := range m {
+			size += len(v)
+		}
+		span.SetAttributes(attribute.Int("memcache.hits", len(m)), attribute.Int("memcache.size", size))
+	}
+	end(span, err)
+	return m, err
+}
+
+func (c *Traced) SetMulti(ctx context.Context, items []*Item) error {
+	keys, size := itemKeys(items)
+	ctx, span := c.start(ctx, "setmulti", keys...)
+	span.SetAttributes(attribute.Int("memcache.size", size))
+	err := c.cache.SetMulti(ctx, items)
+	end(span, err)
+	return err
+}
+
+func (c *Traced) DeleteMulti(ctx context.Context, keys []string) error {
+	ctx, span := c.start(ctx, "deletemulti", keys...)
+	err := c.cache.DeleteMulti(ctx, keys)
+	end(span, err)
+	return err
+}
+
+func (c *Traced) Add(ctx context.Context, item *Item) error {
+	ctx, span := c.start(ctx, "add", item.Key)
+	span.SetAttributes(attribute.Int("memcache.size", len(item.Value)))
+	err := c.cache.Add(ctx, item)
+	end(span, err)
+	return err
+}
+
+func (c *Traced) GetItem(ctx context.Context, key string) (*Item, error) {
+	ctx, span := c.start(ctx, "getitem", key)
+	item, err := c.cache.GetItem(ctx, key)
+	var v []byte
+	if item != nil {
+		v = item.Value
+	}
+	read(span, v, err)
+	end(span, err)
+	return item, err
+}
+
+func (c *Traced) CompareAndSwap(ctx context.Context, item *Item) error {
+	ctx, span := c.start(ctx, "cas", item.Key)
+	span.SetAttributes(attribute.Int("memcache.size", len(item.Value)))
+	err := c.cache.CompareAndSwap(ctx, item)
+	end(span, err)
+	return err
+}
+
+func (c *Traced) Touch(ctx context.Context, key string, expiration time.Duration) error {
+	ctx, span := c.start(ctx, "touch", key)
+	err := c.cache.Touch(ctx, key, expiration)
+	end(span, err)
+	return err
+}
+
+func (c *Traced) GetAndTouch(ctx context.Context, key string, expiration time.Duration) ([]byte, error) {
+	ctx, span := c.start(ctx, "getandtouch", key)
+	v, err := c.cache.GetAndTouch(ctx, key, expiration)
+	read(span, v, err)
+	end(span, err)
+	return v, err
+}
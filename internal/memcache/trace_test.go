@@ -0,0 +1,78 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package memcache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTraced(t *testing.T) {
+	ctx := context.Background()
+	rec := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+	f := &flakyCache{mapCache: make(mapCache)}
+	c := NewTraced(f, tp)
+
+	c.Set(ctx, &Item{Key: "a", Value: []byte("hello")})
+	c.Get(ctx, "a")
+	c.Get(ctx, "b")
+	f.err = errors.New("down")
+	c.Get(ctx, "a")
+
+	spans := rec.Ended()
+	if len(spans) != 4 {
+		t.Fatalf("recorded %d spans, want 4", len(spans))
+	}
+	attrs := func(i int) map[attribute.Key]attribute.Value {
+		m := make(map[attribute.Key]attribute.Value)
+		for _, kv := range spans[i].Attributes() {
+			m[kv.Key] = kv.Value
+		}
+		return m
+	}
+	for i, want := range []struct {
+		name string
+		hit  string // "" if not recorded
+		size int64  // -1 if not recorded
+		err  bool
+	}{
+		{"memcache.set", "", 5, false},
+		{"memcache.get", "true", 5, false},
+		{"memcache.get", "false", -1, false},
+		{"memcache.get", "false", -1, true},
+	} {
+		s, a := spans[i], attrs(i)
+		if s.Name() != want.name {
+			t.Errorf("span %d: name %q, want %q", i, s.Name(), want.name)
+		}
+		if a["memcache.key"].AsString() == "" {
+			t.Errorf("span %d: no memcache.key", i)
+		}
+		if hit, ok := a["memcache.hit"]; (want.hit == "") == ok || (ok && hit.Emit() != want.hit) {
+			t.Errorf("span %d: memcache.hit = %v (present %v), want %q", i, hit.Emit(), ok, want.hit)
+		}
+		if size, ok := a["memcache.size"]; (want.size >= 0) != ok || (ok && size.AsInt64() != want.size) {
+			t.Errorf("span %d: memcache.size = %v (present %v), want %d", i, size.AsInt64(), ok, want.size)
+		}
+		if failed := s.Status().Code == codes.Error; failed != want.err {
+			t.Errorf("span %d: failed = %v, want %v", i, failed, want.err)
+		}
+	}
+
+	// Wrapped cache misses do not fail their spans.
+	f.err = fmt.Errorf("chunk 2: %w", ErrCacheMiss)
+	c.Get(ctx, "a")
+	if s := rec.Ended()[4]; s.Status().Code == codes.Error {
+		t.Errorf("span of wrapped cache miss failed")
+	}
+}
@@ -0,0 +1,223 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package mirror serves a manifest of a site's content
+// for third parties keeping verified mirrors of the site.
+//
+// The manifest, served at ManifestPath as JSON, lists the URL path,
+// size, and SHA-256 hash of every file the site serves as it is,
+// such as its styles, scripts, and images, and of the pages rendered
+// from data other than plain files, such as the codewalks and the
+// release list. A mirror fetches only the paths whose hashes changed
+// since its last copy, and checks what it fetched against the hashes.
+// The manifest's ETag changes only when its entries do, so that a mirror
+// polling with If-None-Match finds out cheaply that nothing changed.
+//
+// Pages of private sections are never listed.
+package mirror
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/matttproud/yourtour/internal/codewalk"
+	"github.com/matttproud/yourtour/internal/reqlog"
+	"github.com/matttproud/yourtour/internal/router"
+	"github.com/matttproud/yourtour/internal/vhost"
+	"github.com/matttproud/yourtour/internal/web"
+)
+
+// ManifestPath is the path of a site's mirror manifest.
+const ManifestPath = "/mirror/manifest.json"
+
+// ttl is how long a manifest is reused before it is built again,
+// since the rendered pages it lists are not known to change.
+const ttl = 10 * time.Minute
+
+// An Entry is a path listed in the manifest.
+type Entry struct {
+	Path   string `json:"path"`   // URL path, with any query
+	Size   int64  `json:"size"`   // bytes served at Path
+	SHA256 string `json:"sha256"` // hex SHA-256 of the bytes served at Path
+}
+
+// A manifest is the content of a mirror manifest.
+type manifest struct {
+	Entries []Entry `json:"entries"`
+}
+
+// A server serves the manifest of a host.
+type server struct {
+	site    *web.Site
+	content fs.FS
+	render  http.Handler
+	pages   []string
+
+	mu    sync.Mutex
+	built time.Time
+	data  []byte // JSON manifest
+	etag  string
+	sums  map[string]fileSum // by file name
+}
+
+// A fileSum is the hash of a file, valid while its size and time are unchanged.
+type fileSum struct {
+	size    int64
+	modTime time.Time
+	sum     string
+}
+
+// RegisterHandlers registers the handler for ManifestPath on h,
+// listing the files in content that h's site serves as they are,
+// the codewalks in content, and the URL paths in pages,
+// such as "/dl/?mode=json&include=all", rendered by render.
+func RegisterHandlers(h *vhost.Host, content fs.FS, render http.Handler, pages ...string) {
+	s := &server{site: h.Site, content: content, render: render, pages: pages}
+	h.Router.HandleFunc("GET", ManifestPath, s.serveHTTP)
+	h.Router.Document("GET", ManifestPath, router.Doc{
+		Summary: "Lists the path, size, and SHA-256 hash of every file and generated page a mirror of the site keeps, as JSON.",
+	})
+}
+
+func (s *server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	data, etag, err := s.manifest(r)
+	if err != nil {
+		s.site.ServeError(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("ETag", etag)
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(data))
+}
+
+// manifest returns the JSON manifest and its ETag,
+// building it again if it is older than ttl.
+func (s *server) manifest(r *http.Request) ([]byte, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.data != nil && time.Since(s.built) < ttl {
+		return s.data, s.etag, nil
+	}
+	entries, err := s.files()
+	if err != nil {
+		return nil, "", err
+	}
+	entries = append(entries, s.rendered(r)...)
+	slices.SortFunc(entries, func(x, y Entry) int { return strings.Compare(x.Path, y.Path) })
+	// Paths are kept as they are, not escaped for HTML, such as & in queries.
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(manifest{Entries: entries}); err != nil {
+		return nil, "", err
+	}
+	data := buf.Bytes()
+	s.data, s.etag, s.built = data, `"`+hash(data)[:16]+`"`, time.Now()
+	return s.data, s.etag, nil
+}
+
+// files returns the entries for the files in the content
+// that the site serves as they are.
+func (s *server) files() ([]Entry, error) {
+	var entries []Entry
+	sums := make(map[string]fileSum)
+	err := fs.WalkDir(s.content, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(d.Name(), ".") && name != "." {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || s.site.Private("/"+name) || !s.site.ServesRaw(name) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		fsum, ok := s.sums[name]
+		if !ok || fsum.size != info.Size() || !fsum.modTime.Equal(info.ModTime()) {
+			data, err := fs.ReadFile(s.content, name)
+			if err != nil {
+				return err
+			}
+			fsum = fileSum{info.Size(), info.ModTime(), hash(data)}
+		}
+		sums[name] = fsum
+		entries = append(entries, Entry{Path: "/" + name, Size: fsum.size, SHA256: fsum.sum})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing files: %v", err)
+	}
+	s.sums = sums
+	return entries, nil
+}
+
+// rendered returns the entries for the codewalks and the pages listed
+// when the manifest was registered, rendered for the host of r.
+// Pages that cannot be rendered are logged and left out.
+func (s *server) rendered(r *http.Request) []Entry {
+	paths, err := codewalk.Paths(s.content)
+	if err != nil {
+		reqlog.Logger(r.Context()).Error("listing codewalks for mirror manifest", "err", err)
+	}
+	var entries []Entry
+	for _, p := range append(paths, s.pages...) {
+		if s.site.Private(p) {
+			continue
+		}
+		u, data, err := s.get(r, p)
+		if err != nil {
+			reqlog.Logger(r.Context()).Error("rendering page for mirror manifest", "path", p, "err", err)
+			continue
+		}
+		entries = append(entries, Entry{Path: u, Size: int64(len(data)), SHA256: hash(data)})
+	}
+	return entries
+}
+
+// get returns the body of the reply to a GET of p on the host of r,
+// and the path it was served at. A redirect to p with a final slash
+// is followed, so that a page is listed by its canonical path.
+func (s *server) get(r *http.Request, p string) (string, []byte, error) {
+	w := s.do(r, p)
+	if loc := w.Header().Get("Location"); w.Code == http.StatusMovedPermanently && loc == p+"/" {
+		p = loc
+		w = s.do(r, p)
+	}
+	if w.Code != http.StatusOK {
+		return "", nil, fmt.Errorf("GET %s: status %d", p, w.Code)
+	}
+	return p, w.Body.Bytes(), nil
+}
+
+// do serves a GET of p on the host of r.
+func (s *server) do(r *http.Request, p string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", p, nil).WithContext(r.Context())
+	req.Host = r.Host
+	s.render.ServeHTTP(w, req)
+	return w
+}
+
+// hash returns the hex SHA-256 hash of data.
+func hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,82 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mirror
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/matttproud/yourtour/internal/router"
+	"github.com/matttproud/yourtour/internal/vhost"
+	"github.com/matttproud/yourtour/internal/web"
+)
+
+func sum(s string) string {
+	h := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(h[:])
+}
+
+func TestManifest(t *testing.T) {
+	fsys := fstest.MapFS{
+		"site.tmpl":                {Data: []byte(`{{.Content}}`)},
+		"texthtml.tmpl":            {Data: []byte(`{{define "layout"}}{{.texthtml}}{{end}}`)},
+		"index.md":                 {Data: []byte("Home")},
+		"css/styles.css":           {Data: []byte("body {}")},
+		"images/logo.svg":          {Data: []byte("<svg/>")},
+		"doc/codewalk/walk.xml":    {Data: []byte(`<codewalk title="Walk"></codewalk>`)},
+		"internal-docs/secret.css": {Data: []byte("secret")},
+		".git/config":              {Data: []byte("[core]")},
+	}
+	site := web.NewSite(fsys)
+	site.SetPrivate(func(p string) bool { return strings.HasPrefix(p, "/internal-docs/") })
+	mux := http.NewServeMux()
+	mux.HandleFunc("/doc/codewalk/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/doc/codewalk/walk" {
+			http.Redirect(w, r, "/doc/codewalk/walk/", http.StatusMovedPermanently)
+			return
+		}
+		w.Write([]byte("walk on " + r.Host))
+	})
+	mux.HandleFunc("/dl/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"version":"go1.22.0"}]`))
+	})
+	var reg vhost.Registry
+	RegisterHandlers(reg.Add("", site, fsys, router.New(mux)), fsys, mux, "/dl/?mode=json", "/missing")
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "https://go.dev"+ManifestPath, nil))
+	if w.Code != 200 || w.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("GET %s: %d %q\n%s", ManifestPath, w.Code, w.Header().Get("Content-Type"), w.Body)
+	}
+	var m manifest
+	if err := json.Unmarshal(w.Body.Bytes(), &m); err != nil {
+		t.Fatal(err)
+	}
+	want := []Entry{
+		{"/css/styles.css", 7, sum("body {}")},
+		{"/dl/?mode=json", 24, sum(`[{"version":"go1.22.0"}]`)},
+		{"/doc/codewalk/walk/", 14, sum("walk on go.dev")},
+		{"/images/logo.svg", 6, sum("<svg/>")},
+	}
+	if !reflect.DeepEqual(m.Entries, want) {
+		t.Errorf("GET %s: entries\n%+v\nwant\n%+v", ManifestPath, m.Entries, want)
+	}
+
+	etag := w.Header().Get("ETag")
+	r := httptest.NewRequest("GET", "https://go.dev"+ManifestPath, nil)
+	r.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	if etag == "" || w.Code != http.StatusNotModified {
+		t.Errorf("GET %s with If-None-Match %s: %d, want 304", ManifestPath, etag, w.Code)
+	}
+}
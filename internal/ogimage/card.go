@@ -0,0 +1,239 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ogimage
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"strings"
+	"sync"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// The size of preview images, as recommended for OpenGraph.
+const (
+	Width  = 1200
+	Height = 630
+)
+
+// A Card is the content of a preview image.
+type Card struct {
+	Section string // section of the site, such as “Codewalk”
+	Title   string
+	Detail  string // such as “12 steps” or “Released August 13, 2024”
+}
+
+var (
+	goBlue   = color.RGBA{0x00, 0xad, 0xd8, 0xff}
+	darkBlue = color.RGBA{0x00, 0x7d, 0x9c, 0xff}
+	ink      = color.RGBA{0x20, 0x22, 0x24, 0xff}
+	subtle   = color.RGBA{0x55, 0x5f, 0x6a, 0xff}
+	white    = color.RGBA{0xff, 0xff, 0xff, 0xff}
+)
+
+// Placement of the card's contents.
+const (
+	margin     = 80
+	titleSize  = 72
+	titleLead  = 88 // distance between title baselines
+	titleLines = 3  // most lines of title shown
+)
+
+// A box is a rectangle filled with a color.
+type box struct {
+	r     image.Rectangle
+	color color.RGBA
+}
+
+// A label is a line of text, drawn starting at (x, y),
+// the left end of its baseline, or ending there if right is set.
+type label struct {
+	x, y  int
+	size  float64
+	bold  bool
+	right bool
+	color color.RGBA
+	text  string
+}
+
+// goFonts returns the parsed regular and bold Go fonts.
+var goFonts = sync.OnceValues(func() ([2]*opentype.Font, error) {
+	var f [2]*opentype.Font
+	for i, ttf := range [][]byte{goregular.TTF, gobold.TTF} {
+		var err error
+		if f[i], err = opentype.Parse(ttf); err != nil {
+			return f, err
+		}
+	}
+	return f, nil
+})
+
+// A faces is a set of font faces, for measuring and drawing labels.
+type faces struct {
+	fonts [2]*opentype.Font
+	faces map[label]font.Face // keyed by size and boldness
+}
+
+func newFaces() (*faces, error) {
+	fonts, err := goFonts()
+	if err != nil {
+		return nil, err
+	}
+	return &faces{fonts: fonts, faces: make(map[label]font.Face)}, nil
+}
+
+// face returns the face for the size and boldness of l.
+func (f *faces) face(l label) font.Face {
+	key := label{size: l.size, bold: l.bold}
+	if face, ok := f.faces[key]; ok {
+		return face
+	}
+	i := 0
+	if l.bold {
+		i = 1
+	}
+	face, err := opentype.NewFace(f.fonts[i], &opentype.FaceOptions{Size: l.size, DPI: 72, Hinting: font.HintingFull})
+	if err != nil {
+		// Only invalid options are reported.
+		panic(err)
+	}
+	f.faces[key] = face
+	return face
+}
+
+// width returns the width of l's text in pixels.
+func (f *faces) width(l label) int {
+	return font.MeasureString(f.face(l), l.text).Ceil()
+}
+
+// layout returns the boxes and labels making up the card's image.
+func (c *Card) layout(f *faces) ([]box, []label) {
+	boxes := []box{
+		{image.Rect(0, 0, Width, Height), white},
+		{image.Rect(0, 0, Width, 16), goBlue},
+		{image.Rect(0, Height-16, Width, Height), goBlue},
+	}
+	labels := []label{
+		{x: margin, y: 150, size: 36, bold: true, color: goBlue, text: c.Section},
+	}
+	title := label{x: margin, size: titleSize, bold: true, color: ink, text: c.Title}
+	for i, line := range wrap(f, title, Width-2*margin, titleLines) {
+		title.y = 250 + i*titleLead
+		title.text = line
+		labels = append(labels, title)
+	}
+	labels = append(labels,
+		label{x: margin, y: Height - 80, size: 36, color: subtle, text: c.Detail},
+		label{x: Width - margin, y: Height - 80, size: 40, bold: true, right: true, color: darkBlue, text: "go.dev"},
+	)
+	return boxes, labels
+}
+
+// wrap splits the text of l into at most n lines fitting in width,
+// breaking lines between words and ending a truncated text with “…”.
+func wrap(f *faces, l label, width, n int) []string {
+	var lines []string
+	words := strings.Fields(l.text)
+	for len(words) > 0 && len(lines) < n {
+		// Take as many words as fit, but at least one.
+		k := 1
+		for ; k < len(words); k++ {
+			l.text = strings.Join(words[:k+1], " ")
+			if f.width(l) > width {
+				break
+			}
+		}
+		lines = append(lines, strings.Join(words[:k], " "))
+		words = words[k:]
+	}
+	for i, line := range lines {
+		l.text = line
+		if (i == len(lines)-1 && len(words) > 0) || f.width(l) > width {
+			lines[i] = ellipsize(f, l, width)
+		}
+	}
+	return lines
+}
+
+// ellipsize shortens the text of l to fit in width with a “…” appended.
+func ellipsize(f *faces, l label, width int) string {
+	text := []rune(l.text)
+	for {
+		l.text = strings.TrimRight(string(text), " ") + "…"
+		if len(text) == 0 || f.width(l) <= width {
+			return l.text
+		}
+		text = text[:len(text)-1]
+	}
+}
+
+// PNG returns the card's image in PNG format.
+func (c *Card) PNG() ([]byte, error) {
+	f, err := newFaces()
+	if err != nil {
+		return nil, err
+	}
+	boxes, labels := c.layout(f)
+	img := image.NewRGBA(image.Rect(0, 0, Width, Height))
+	for _, b := range boxes {
+		draw.Draw(img, b.r, image.NewUniform(b.color), image.Point{}, draw.Src)
+	}
+	for _, l := range labels {
+		x := l.x
+		if l.right {
+			x -= f.width(l)
+		}
+		d := &font.Drawer{Dst: img, Src: image.NewUniform(l.color), Face: f.face(l), Dot: fixed.P(x, l.y)}
+		d.DrawString(l.text)
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// SVG returns the card's image in SVG format.
+// Its text is set in the Go fonts, where available, as in the PNG image.
+func (c *Card) SVG() ([]byte, error) {
+	f, err := newFaces()
+	if err != nil {
+		return nil, err
+	}
+	boxes, labels := c.layout(f)
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n", Width, Height, Width, Height)
+	for _, b := range boxes {
+		fmt.Fprintf(&buf, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"/>`+"\n", b.r.Min.X, b.r.Min.Y, b.r.Dx(), b.r.Dy(), hex(b.color))
+	}
+	for _, l := range labels {
+		fmt.Fprintf(&buf, `<text x="%d" y="%d" font-family="Go, sans-serif" font-size="%g"`, l.x, l.y, l.size)
+		if l.bold {
+			buf.WriteString(` font-weight="bold"`)
+		}
+		if l.right {
+			buf.WriteString(` text-anchor="end"`)
+		}
+		fmt.Fprintf(&buf, ` fill="%s">%s</text>`+"\n", hex(l.color), escaper.Replace(l.text))
+	}
+	buf.WriteString("</svg>\n")
+	return buf.Bytes(), nil
+}
+
+var escaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// hex returns c in the form #rrggbb.
+func hex(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
@@ -0,0 +1,209 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package ogimage renders the social preview images of pages:
+// the images that chat apps and social networks show with links to the pages,
+// as named by the pages' OpenGraph og:image metadata.
+//
+// Codewalks and release notes have preview images of their own,
+// drawn with their titles, sections, and details such as step counts
+// and release dates, and served at /_og/ followed by the page's path
+// and .png, such as /_og/doc/codewalk/sharemem.png. The same images
+// are served in SVG format with .svg in place of .png.
+// Other pages use the site's default preview image.
+package ogimage
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/matttproud/yourtour/internal/codewalk"
+	"github.com/matttproud/yourtour/internal/history"
+	"github.com/matttproud/yourtour/internal/vhost"
+	"github.com/matttproud/yourtour/internal/web"
+)
+
+// prefix is the path prefix of preview images.
+const prefix = "/_og"
+
+// maxCache is the most rendered images kept in memory.
+const maxCache = 512
+
+// cacheControl is the Cache-Control header of preview images.
+// Images change only when their pages' titles do,
+// and crawlers fetch them rarely, so they are cached for a day.
+const cacheControl = "public, max-age=86400"
+
+// RegisterHandlers registers the handler serving the preview images
+// of the pages in h's file system on h, and sets the og:image of
+// the pages with preview images, as their ogImage page data.
+func RegisterHandlers(h *vhost.Host) {
+	s := &server{site: h.Site, fsys: h.FS, cache: make(map[cacheKey][]byte)}
+	h.Router.HandleFunc("GET", prefix+"/", s.serveHTTP)
+	h.Site.AddPageData(s.pageData)
+}
+
+type server struct {
+	site *web.Site
+	fsys fs.FS
+
+	mu    sync.Mutex
+	cache map[cacheKey][]byte
+}
+
+// A cacheKey identifies a rendered image.
+type cacheKey struct {
+	card Card
+	ext  string
+}
+
+// pageData sets the ogImage of the page p,
+// the path of its preview image, if it has one.
+func (s *server) pageData(r *http.Request, p web.Page) {
+	if _, ok := p["ogImage"]; ok {
+		return
+	}
+	// Codewalks are served at paths ending in a slash,
+	// but their images are not.
+	url, _ := p["URL"].(string)
+	url = strings.TrimSuffix(url, "/")
+	if file := cardFile(url); file != "" {
+		if _, err := fs.Stat(s.fsys, file); err == nil {
+			p["ogImage"] = prefix + url + ".png"
+		}
+	}
+}
+
+// serveHTTP serves the preview image named by r.
+func (s *server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, prefix)
+	ext := path.Ext(name)
+	if ext != ".png" && ext != ".svg" {
+		s.site.ServeErrorStatus(w, r, fmt.Errorf("no preview image %s", r.URL.Path), http.StatusNotFound)
+		return
+	}
+	card, err := s.card(strings.TrimSuffix(name, ext))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, fs.ErrNotExist) {
+			status = http.StatusNotFound
+		}
+		s.site.ServeErrorStatus(w, r, err, status)
+		return
+	}
+
+	key := cacheKey{*card, ext}
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%q", key)))
+	etag := fmt.Sprintf(`"%x"`, sum[:12])
+	w.Header().Set("Cache-Control", cacheControl)
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	data, err := s.render(key)
+	if err != nil {
+		s.site.ServeError(w, r, err)
+		return
+	}
+	if ext == ".svg" {
+		w.Header().Set("Content-Type", "image/svg+xml")
+	} else {
+		w.Header().Set("Content-Type", "image/png")
+	}
+	w.Write(data)
+}
+
+// render returns the image for key, rendering it if not cached.
+func (s *server) render(key cacheKey) ([]byte, error) {
+	s.mu.Lock()
+	data, ok := s.cache[key]
+	s.mu.Unlock()
+	if ok {
+		return data, nil
+	}
+
+	var err error
+	if key.ext == ".svg" {
+		data, err = key.card.SVG()
+	} else {
+		data, err = key.card.PNG()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.cache) >= maxCache {
+		// Start over rather than track use;
+		// the images in use are soon rendered again.
+		clear(s.cache)
+	}
+	s.cache[key] = data
+	return data, nil
+}
+
+// releasePage matches the paths of release notes pages,
+// capturing the release's major version, such as 1.22.
+var releasePage = regexp.MustCompile(`^/doc/(?:relnotes/)?go(1\.[0-9]+)$`)
+
+// cardFile returns the file from which the preview card
+// of the page with URL path p, without any trailing slash, is made,
+// or "" if such a page has no preview card.
+func cardFile(p string) string {
+	if name, ok := strings.CutPrefix(p, "/doc/codewalk/"); ok && name != "" && !strings.Contains(name, "/") {
+		return "doc/codewalk/" + name + ".xml"
+	}
+	if releasePage.MatchString(p) {
+		if strings.HasPrefix(p, "/doc/relnotes/") {
+			return strings.TrimPrefix(p, "/") // directory of fragments
+		}
+		return strings.TrimPrefix(p, "/") + ".md"
+	}
+	return ""
+}
+
+// card returns the preview card for the page with URL path p.
+// The error is fs.ErrNotExist if there is no such page
+// or it has no preview card.
+func (s *server) card(p string) (*Card, error) {
+	file := cardFile(p)
+	if file == "" {
+		return nil, fs.ErrNotExist
+	}
+	if _, err := fs.Stat(s.fsys, file); err != nil {
+		return nil, err
+	}
+
+	if m := releasePage.FindStringSubmatch(p); m != nil {
+		detail := "Upcoming release"
+		for _, major := range history.Majors {
+			if major.Version.MajorPrefix() == m[1] {
+				if !major.Future {
+					detail = "Released " + major.Date.Format("January 2, 2006")
+				}
+				break
+			}
+		}
+		return &Card{Section: "Release Notes", Title: "Go " + m[1], Detail: detail}, nil
+	}
+
+	cw, err := codewalk.Load(s.fsys, p)
+	if err != nil {
+		return nil, err
+	}
+	detail := fmt.Sprintf("%d steps", len(cw.Steps))
+	if len(cw.Steps) == 1 {
+		detail = "1 step"
+	}
+	return &Card{Section: "Codewalk", Title: cw.Title, Detail: detail}, nil
+}
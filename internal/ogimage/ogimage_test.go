@@ -0,0 +1,175 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ogimage
+
+import (
+	"bytes"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/matttproud/yourtour/internal/router"
+	"github.com/matttproud/yourtour/internal/vhost"
+	"github.com/matttproud/yourtour/internal/web"
+)
+
+var testFS = fstest.MapFS{
+	"site.tmpl":  {Data: []byte(`{{.ogImage}}`)},
+	"error.tmpl": {Data: []byte(`{{define "layout"}}{{.error}}{{end}}`)},
+	"doc/codewalk/walk.xml": {Data: []byte(`<codewalk title="Share Memory &amp; Communicate">` +
+		`<step title="Main" src="doc/codewalk/x.go:/func main/">Hi.</step>` +
+		`<step title="All" src="doc/codewalk/x.go">All.</step></codewalk>`)},
+	"doc/codewalk/one.xml":           {Data: []byte(`<codewalk title="One"><step title="All" src="doc/codewalk/x.go">All.</step></codewalk>`)},
+	"doc/codewalk/x.go":              {Data: []byte("package main\n\nfunc main() {}\n")},
+	"doc/go1.22.md":                  {Data: []byte("---\ntitle: Go 1.22 Release Notes\n---\n")},
+	"doc/relnotes/go1.99/1-intro.md": {Data: []byte("Coming soon.\n")},
+	"doc/install.md":                 {Data: []byte("---\ntitle: Install\n---\n")},
+}
+
+func newTestServer() (*server, http.Handler) {
+	mux := http.NewServeMux()
+	var reg vhost.Registry
+	h := reg.Add("", web.NewSite(testFS), testFS, router.New(mux))
+	RegisterHandlers(h)
+	return &server{site: h.Site, fsys: testFS, cache: make(map[cacheKey][]byte)}, mux
+}
+
+func TestCard(t *testing.T) {
+	s, _ := newTestServer()
+	for _, tt := range []struct {
+		path string
+		want *Card
+	}{
+		{"/doc/codewalk/walk", &Card{"Codewalk", "Share Memory & Communicate", "2 steps"}},
+		{"/doc/codewalk/one", &Card{"Codewalk", "One", "1 step"}},
+		{"/doc/go1.22", &Card{"Release Notes", "Go 1.22", "Released February 6, 2024"}},
+		{"/doc/relnotes/go1.99", &Card{"Release Notes", "Go 1.99", "Upcoming release"}},
+		{"/doc/codewalk/missing", nil},
+		{"/doc/codewalk/", nil},
+		{"/doc/go1.98", nil},
+		{"/doc/install", nil},
+	} {
+		c, err := s.card(tt.path)
+		if tt.want == nil {
+			if err == nil {
+				t.Errorf("card(%q) = %+v, want error", tt.path, c)
+			}
+			continue
+		}
+		if err != nil || *c != *tt.want {
+			t.Errorf("card(%q) = %+v, %v, want %+v", tt.path, c, err, tt.want)
+		}
+	}
+}
+
+func TestServe(t *testing.T) {
+	_, mux := newTestServer()
+	get := func(path, etag string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", path, nil)
+		if etag != "" {
+			r.Header.Set("If-None-Match", etag)
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		return w
+	}
+
+	w := get("/_og/doc/codewalk/walk.png", "")
+	if w.Code != 200 || w.Header().Get("Content-Type") != "image/png" || w.Header().Get("Cache-Control") != cacheControl {
+		t.Fatalf("GET png: %d %v", w.Code, w.Header())
+	}
+	img, err := png.Decode(bytes.NewReader(w.Body.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != Width || b.Dy() != Height {
+		t.Errorf("png is %dx%d, want %dx%d", b.Dx(), b.Dy(), Width, Height)
+	}
+	etag := w.Header().Get("ETag")
+	if w := get("/_og/doc/codewalk/walk.png", etag); w.Code != http.StatusNotModified {
+		t.Errorf("GET png with ETag %s: %d, want 304", etag, w.Code)
+	}
+	if w := get("/_og/doc/codewalk/walk.svg", ""); w.Header().Get("ETag") == etag {
+		t.Errorf("png and svg have the same ETag %s", etag)
+	}
+
+	w = get("/_og/doc/go1.22.svg", "")
+	if w.Code != 200 || w.Header().Get("Content-Type") != "image/svg+xml" {
+		t.Fatalf("GET svg: %d %v", w.Code, w.Header())
+	}
+	for _, want := range []string{
+		`<svg xmlns="http://www.w3.org/2000/svg" width="1200" height="630" viewBox="0 0 1200 630">`,
+		`fill="#00add8">Release Notes</text>`,
+		`fill="#202224">Go 1.22</text>`,
+		`>Released February 6, 2024</text>`,
+		`text-anchor="end" fill="#007d9c">go.dev</text>`,
+	} {
+		if !strings.Contains(w.Body.String(), want) {
+			t.Errorf("svg missing %s\n%s", want, w.Body)
+		}
+	}
+	if w := get("/_og/doc/codewalk/walk.svg", ""); !strings.Contains(w.Body.String(), ">Share Memory &amp;</text>") {
+		t.Errorf("svg title not escaped:\n%s", w.Body)
+	}
+
+	for _, path := range []string{"/_og/doc/install.png", "/_og/doc/codewalk/walk.jpg", "/_og/doc/codewalk/walk", "/_og/"} {
+		if w := get(path, ""); w.Code != 404 {
+			t.Errorf("GET %s: %d, want 404", path, w.Code)
+		}
+	}
+}
+
+func TestPageData(t *testing.T) {
+	s, _ := newTestServer()
+	for _, tt := range []struct {
+		url, want string
+	}{
+		{"/doc/codewalk/walk/", "/_og/doc/codewalk/walk.png"},
+		{"/doc/codewalk/", ""},
+		{"/doc/go1.22", "/_og/doc/go1.22.png"},
+		{"/doc/relnotes/go1.99", "/_og/doc/relnotes/go1.99.png"},
+		{"/doc/codewalk/missing", ""},
+		{"/doc/install", ""},
+	} {
+		p := web.Page{"URL": tt.url}
+		s.pageData(nil, p)
+		if got, _ := p["ogImage"].(string); got != tt.want {
+			t.Errorf("ogImage of %s = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestWrap(t *testing.T) {
+	f, err := newFaces()
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := label{size: titleSize, bold: true}
+	for _, tt := range []struct {
+		text string
+		want []string
+	}{
+		{"Go 1.22", []string{"Go 1.22"}},
+		{"Share Memory By Communicating", []string{"Share Memory By", "Communicating"}},
+		{strings.Repeat("word ", 20), []string{"word word word word word", "word word word word word", "word word word word word…"}},
+		{strings.Repeat("x", 60), []string{strings.Repeat("x", 24) + "…"}},
+		{"", nil},
+	} {
+		l.text = tt.text
+		got := wrap(f, l, Width-2*margin, titleLines)
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("wrap(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+		for _, line := range got {
+			l.text = line
+			if w := f.width(l); w > Width-2*margin {
+				t.Errorf("wrap(%q): line %q is %d wide", tt.text, line, w)
+			}
+		}
+	}
+}
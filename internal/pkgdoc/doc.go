@@ -449,7 +449,6 @@ func (d *docs) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 	info := d.open("src/"+relpath, mode, r.FormValue("GOOS"), r.FormValue("GOARCH"))
 	if info.Err != nil {
-		log.Print(info.Err)
 		d.site.ServeError(w, r, info.Err)
 		return
 	}
@@ -5,6 +5,7 @@
 package play
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -36,7 +37,7 @@ func playHandler(site *web.Site) http.Handler {
 			return
 		}
 		if strings.HasSuffix(r.URL.Path, ".go") {
-			simpleProxy(w, r, "https://"+backend(r)+strings.TrimPrefix(r.URL.Path, "/play"))
+			simpleProxy(w, r, backendURL(backend(r), strings.TrimPrefix(r.URL.Path, "/play")))
 			return
 		}
 		site.ServePage(w, r, web.Page{
@@ -55,11 +56,9 @@ func init() {
 		{Name: "Go previous release", Backend: "goprev"},
 		{Name: "Go dev branch", Backend: "gotip"},
 	})
-
-	go watchVersions()
 }
 
-func readVersions() (list []playVersion, err error) {
+func readVersions(ctx context.Context) (list []playVersion, err error) {
 	defer func() {
 		if e := recover(); e != nil {
 			list = nil
@@ -73,7 +72,11 @@ func readVersions() (list []playVersion, err error) {
 
 	list = append([]playVersion(nil), playVersions.Load().([]playVersion)...)
 	for i, v := range list {
-		resp, err := client.Get("https://" + v.Backend + "play.golang.org/version")
+		req, err := http.NewRequestWithContext(ctx, "GET", backendURL(v.Backend, "/version"), nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := client.Do(req)
 		if err != nil {
 			log.Printf("readVersions: %v", err)
 			continue
@@ -100,13 +103,13 @@ func readVersions() (list []playVersion, err error) {
 	return list, nil
 }
 
-func watchVersions() {
-	for ; ; time.Sleep(1 * time.Minute) {
-		list, err := readVersions()
-		if err != nil {
-			log.Print(err)
-			continue
-		}
-		playVersions.Store(list)
+// RefreshVersions updates the Go versions offered by the playground
+// to those reported by its backends. The server calls it periodically.
+func RefreshVersions(ctx context.Context) error {
+	list, err := readVersions(ctx)
+	if err != nil {
+		return err
 	}
+	playVersions.Store(list)
+	return nil
 }
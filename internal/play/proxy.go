@@ -7,19 +7,26 @@ package play
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"net/url"
 	"regexp"
 	"strings"
 	"time"
 
+	"github.com/matttproud/yourtour/internal/accesslog"
+	"github.com/matttproud/yourtour/internal/env"
+	"github.com/matttproud/yourtour/internal/memcache"
 	"github.com/matttproud/yourtour/internal/web"
 )
 
-const playgroundURL = "https://play.golang.org"
+// DefaultBackend is the playground backend proxied
+// when the configuration names none; see env.Config.PlaygroundURL.
+const DefaultBackend = "https://play.golang.org"
 
 type Request struct {
 	Body    string
@@ -41,20 +48,44 @@ type Event struct {
 const expires = 7 * 24 * time.Hour // 1 week
 var cacheControlHeader = fmt.Sprintf("public, max-age=%d", int(expires.Seconds()))
 
+// A proxy serves the playground endpoints by forwarding them to the backend.
+type proxy struct {
+	cache *memcache.CodecClient // nil if not caching replies
+}
+
 // RegisterHandlers registers handlers for the playground endpoints.
-func RegisterHandlers(mux *http.ServeMux, godevSite, chinaSite *web.Site) {
+// The /compile, /fmt, and /share endpoints are served under /_/
+// on every host, for the tour and other pages with runnable examples,
+// except that shared snippets are not visible in China.
+// If mc is not nil, the replies to compile and format requests
+// and shared snippets are cached in mc, since they never change.
+func RegisterHandlers(mux *http.ServeMux, godevSite, chinaSite *web.Site, mc memcache.Cache) {
+	p := new(proxy)
+	if mc != nil {
+		p.cache = memcache.NewCodecClient(mc, memcache.Gob)
+	}
 	mux.Handle("/play/", playHandler(godevSite))
 	mux.Handle("golang.google.cn/play/", playHandler(chinaSite))
-	for _, pattern := range []string{"golang.org", "go.dev/_", "golang.google.cn/_"} {
-		mux.HandleFunc(pattern+"/compile", compile)
+	for _, pattern := range []string{"golang.org", "go.dev/_", "golang.google.cn/_", "/_"} {
+		mux.HandleFunc(pattern+"/compile", p.compile)
 		if pattern != "golang.google.cn/_" {
-			mux.HandleFunc(pattern+"/share", share)
+			mux.HandleFunc(pattern+"/share", p.share)
 		}
-		mux.HandleFunc(pattern+"/fmt", fmtHandler)
+		mux.HandleFunc(pattern+"/fmt", p.format)
 	}
+	mux.Handle("golang.google.cn/_/share", http.NotFoundHandler())
 }
 
-func compile(w http.ResponseWriter, r *http.Request) {
+// cacheTTL is how long replies are cached.
+const cacheTTL = 24 * time.Hour
+
+// cacheKey returns the cache key for the reply to a request
+// described by parts.
+func cacheKey(parts ...string) string {
+	return fmt.Sprintf("play:%x", sha256.Sum256([]byte(strings.Join(parts, "\x00"))))
+}
+
+func (p *proxy) compile(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "POST" {
 		http.Error(w, "I only answer to POST requests.", http.StatusMethodNotAllowed)
 		return
@@ -64,10 +95,10 @@ func compile(w http.ResponseWriter, r *http.Request) {
 
 	body := r.FormValue("body")
 	withVet := r.FormValue("withVet")
-	res := &Response{}
 	req := &Request{Body: body, WithVet: withVet == "true"}
-	if err := makeCompileRequest(ctx, backend(r), req, res); err != nil {
-		log.Printf("ERROR compile error %s: %v", backend(r), err)
+	res, err := p.compileResponse(ctx, backend(r), req)
+	if err != nil {
+		log.Printf("ERROR compile error %s: %v", backendURL(backend(r), ""), err)
 		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 		return
 	}
@@ -95,14 +126,42 @@ func compile(w http.ResponseWriter, r *http.Request) {
 	w.Write(b)
 }
 
+// compileResponse returns the response of the playground compile endpoint
+// of the given backend to req, from the cache if possible.
+func (p *proxy) compileResponse(ctx context.Context, backend string, req *Request) (*Response, error) {
+	key := cacheKey("compile", backend, req.Body, fmt.Sprint(req.WithVet))
+	res := new(Response)
+	if p.cache != nil {
+		err := p.cache.Get(ctx, key, res)
+		if err == nil {
+			accesslog.Annotate(ctx, "cache", "hit")
+			return res, nil
+		}
+		accesslog.Annotate(ctx, "cache", "miss")
+		if err != memcache.ErrCacheMiss {
+			log.Printf("ERROR compile cache get: %v", err)
+		}
+	}
+	if err := makeCompileRequest(ctx, backend, req, res); err != nil {
+		return nil, err
+	}
+	if p.cache != nil {
+		item := &memcache.Item{Key: key, Object: res, Expiration: cacheTTL}
+		if err := p.cache.Set(ctx, item); err != nil {
+			log.Printf("ERROR compile cache set: %v", err)
+		}
+	}
+	return res, nil
+}
+
 // makeCompileRequest sends the given Request to the playground compile
-// endpoint and stores the response in the given Response.
+// endpoint of the given backend and stores the response in the given Response.
 func makeCompileRequest(ctx context.Context, backend string, req *Request, res *Response) error {
 	reqJ, err := json.Marshal(req)
 	if err != nil {
 		return fmt.Errorf("marshaling request: %v", err)
 	}
-	hReq, _ := http.NewRequest("POST", "https://"+backend+"/compile", bytes.NewReader(reqJ))
+	hReq, _ := http.NewRequest("POST", backendURL(backend, "/compile"), bytes.NewReader(reqJ))
 	hReq.Header.Set("Content-Type", "application/json")
 	hReq = hReq.WithContext(ctx)
 
@@ -137,20 +196,77 @@ func flatten(seq []Event) string {
 
 var validID = regexp.MustCompile(`^[A-Za-z0-9_\-]+$`)
 
-func share(w http.ResponseWriter, r *http.Request) {
+func (p *proxy) share(w http.ResponseWriter, r *http.Request) {
 	if id := r.FormValue("id"); r.Method == "GET" && validID.MatchString(id) {
-		simpleProxy(w, r, playgroundURL+"/p/"+id+".go")
+		p.cachedProxy(w, r, backendURL("", "/p/"+id+".go"), cacheKey("share", id))
 		return
 	}
 
-	simpleProxy(w, r, playgroundURL+"/share")
+	simpleProxy(w, r, backendURL("", "/share"))
 }
 
-func fmtHandler(w http.ResponseWriter, r *http.Request) {
-	simpleProxy(w, r, "https://"+backend(r)+"/fmt")
+func (p *proxy) format(w http.ResponseWriter, r *http.Request) {
+	url := backendURL(backend(r), "/fmt")
+	if r.Method != "POST" {
+		simpleProxy(w, r, url)
+		return
+	}
+	p.cachedProxy(w, r, url, cacheKey("fmt", backend(r), r.FormValue("body"), r.FormValue("imports")))
+}
+
+// A reply is a reply from the playground backend.
+type reply struct {
+	Status      int
+	ContentType string
+	Body        []byte
+}
+
+// cachedProxy is like simpleProxy but answers from the cache, under key,
+// if it can, and caches successful replies there.
+func (p *proxy) cachedProxy(w http.ResponseWriter, r *http.Request, url, key string) {
+	if p.cache == nil {
+		simpleProxy(w, r, url)
+		return
+	}
+	ctx := r.Context()
+	var rep reply
+	err := p.cache.Get(ctx, key, &rep)
+	if err == nil {
+		accesslog.Annotate(ctx, "cache", "hit")
+		rep.write(w)
+		return
+	}
+	accesslog.Annotate(ctx, "cache", "miss")
+	if err != memcache.ErrCacheMiss {
+		log.Printf("ERROR playground cache get: %v", err)
+	}
+	rp, err := fetch(r, url)
+	if err != nil {
+		log.Printf("ERROR playground proxy error: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	if rp.Status == http.StatusOK {
+		item := &memcache.Item{Key: key, Object: rp, Expiration: cacheTTL}
+		if err := p.cache.Set(ctx, item); err != nil {
+			log.Printf("ERROR playground cache set: %v", err)
+		}
+	}
+	rp.write(w)
 }
 
 func simpleProxy(w http.ResponseWriter, r *http.Request, url string) {
+	rp, err := fetch(r, url)
+	if err != nil {
+		log.Printf("ERROR playground proxy error: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	rp.write(w)
+}
+
+// fetch forwards r to url, returning the reply.
+func fetch(r *http.Request, url string) (*reply, error) {
 	if r.Method == "GET" {
 		r.Body = nil
 	} else if len(r.Form) > 0 {
@@ -161,28 +277,51 @@ func simpleProxy(w http.ResponseWriter, r *http.Request, url string) {
 	req = req.WithContext(r.Context())
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		log.Printf("ERROR share error: %v", err)
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-		return
-	}
-	copyHeader := func(k string) {
-		if v := resp.Header.Get(k); v != "" {
-			w.Header().Set(k, v)
-		}
+		return nil, err
 	}
-	copyHeader("Content-Type")
-	copyHeader("Content-Length")
 	defer resp.Body.Close()
-	w.WriteHeader(resp.StatusCode)
-	io.Copy(w, resp.Body)
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &reply{resp.StatusCode, resp.Header.Get("Content-Type"), body}, nil
 }
 
+func (rp *reply) write(w http.ResponseWriter) {
+	if rp.ContentType != "" {
+		w.Header().Set("Content-Type", rp.ContentType)
+	}
+	w.WriteHeader(rp.Status)
+	w.Write(rp.Body)
+}
+
+// backend returns the prefix of the playground backend
+// selected by r's backend parameter, such as "gotip",
+// or the empty string for the default backend.
 func backend(r *http.Request) string {
 	b := r.URL.Query().Get("backend")
 	if !isDomainElem(b) {
-		return "play.golang.org"
+		return ""
+	}
+	return b
+}
+
+// backendURL returns the URL of path on the playground backend
+// with the given prefix, whose host name is the prefix followed
+// by that of the configured backend.
+func backendURL(prefix, path string) string {
+	base := env.Get().PlaygroundURL
+	if base == "" {
+		base = DefaultBackend
+	}
+	u, err := url.Parse(base)
+	if err != nil {
+		// Rejected by env.Config.Validate.
+		u, _ = url.Parse(DefaultBackend)
 	}
-	return b + "play.golang.org"
+	u.Host = prefix + u.Host
+	u.Path = strings.TrimSuffix(u.Path, "/") + path
+	return u.String()
 }
 
 func isDomainElem(s string) bool {
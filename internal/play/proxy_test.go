@@ -0,0 +1,94 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package play
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/matttproud/yourtour/internal/env"
+	"github.com/matttproud/yourtour/internal/memcache"
+	"github.com/matttproud/yourtour/internal/web"
+)
+
+func TestBackendURL(t *testing.T) {
+	defer env.Set(nil)
+	tests := []struct {
+		base, prefix, path, want string
+	}{
+		{"", "", "/compile", "https://play.golang.org/compile"},
+		{"", "gotip", "/fmt", "https://gotipplay.golang.org/fmt"},
+		{"http://127.0.0.1:8081/", "", "/p/abc.go", "http://127.0.0.1:8081/p/abc.go"},
+		{"https://play.example.com/api", "goprev", "/version", "https://goprevplay.example.com/api/version"},
+	}
+	for _, tt := range tests {
+		env.Set(&env.Config{PlaygroundURL: tt.base})
+		if got := backendURL(tt.prefix, tt.path); got != tt.want {
+			t.Errorf("backendURL(%q, %q) with backend %q = %q, want %q", tt.prefix, tt.path, tt.base, got, tt.want)
+		}
+	}
+}
+
+func TestProxyCache(t *testing.T) {
+	calls := make(map[string]int)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls[r.URL.Path]++
+		switch r.URL.Path {
+		case "/compile":
+			var req Request
+			json.NewDecoder(r.Body).Decode(&req)
+			json.NewEncoder(w).Encode(&Response{Events: []Event{{Message: "ran " + req.Body, Kind: "stdout"}}})
+		case "/fmt":
+			r.ParseForm()
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]string{"Body": strings.ToUpper(r.Form.Get("body"))})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+	defer env.Set(nil)
+	env.Set(&env.Config{PlaygroundURL: ts.URL})
+
+	mux := http.NewServeMux()
+	site := web.NewSite(nil)
+	RegisterHandlers(mux, site, site, memcache.NewMemory(0))
+	post := func(path, body string) string {
+		t.Helper()
+		r := httptest.NewRequest("POST", "http://"+path, strings.NewReader(url.Values{"body": {body}}.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		if w.Code != 200 {
+			t.Fatalf("POST %s: %d %s", path, w.Code, w.Body)
+		}
+		data, _ := io.ReadAll(w.Body)
+		return string(data)
+	}
+
+	for range 2 {
+		if out := post("example.com/_/compile", "main"); !strings.Contains(out, "ran main") {
+			t.Errorf("compile: %s, want program output", out)
+		}
+		if out := post("example.com/_/fmt", "x"); !strings.Contains(out, `"X"`) {
+			t.Errorf("fmt: %s, want formatted body", out)
+		}
+	}
+	post("go.dev/_/compile", "other")
+	if calls["/compile"] != 2 || calls["/fmt"] != 1 {
+		t.Errorf("backend calls = %v, want 2 compiles and 1 fmt", calls)
+	}
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "http://golang.google.cn/_/share?id=abc", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("China share: %d, want 404", w.Code)
+	}
+}
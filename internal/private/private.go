@@ -0,0 +1,262 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package private limits sections of a site to readers who sign in.
+//
+// A Gate guards the pages under the path prefixes of its sections,
+// such as /internal-docs/. A request for a guarded page from a reader
+// who has not signed in is redirected to the sign-in page, /_signin,
+// which asks for the sections' password. Given it, the gate sets
+// a session cookie signed with the password and sends the reader back
+// to the page, which then renders as usual, marked so that neither
+// shared caches nor the site's service worker keep it.
+// A session lasts SessionTTL, until the reader signs out with
+// POST /_signout, or until the password changes.
+//
+// The sites of the hosts the gate is registered on treat the guarded
+// pages as private (see web.Site.SetPrivate), leaving them out of
+// their search indexes, 404 suggestions, and offline manifests.
+package private
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/matttproud/yourtour/internal/router"
+	"github.com/matttproud/yourtour/internal/vhost"
+	"github.com/matttproud/yourtour/internal/web"
+)
+
+const (
+	signinPath  = "/_signin"
+	signoutPath = "/_signout"
+	cookieName  = "private_session"
+	maxBody     = 4 << 10 // bytes in a sign-in form
+)
+
+// SessionTTL is how long a reader stays signed in.
+const SessionTTL = 12 * time.Hour
+
+// PasswordSecretName is the name of the secret
+// from which the private sections' password is read.
+const PasswordSecretName = "private-sections-password"
+
+// A Password returns the password of the private sections,
+// or the empty string if no reader can sign in.
+type Password func(ctx context.Context) (string, error)
+
+// A Gate guards the pages of private sections.
+type Gate struct {
+	sections []string
+	password Password
+}
+
+// ParseSections parses a comma-separated list of the path prefixes
+// of private sections, such as "/internal-docs/,/drafts/".
+func ParseSections(s string) ([]string, error) {
+	var list []string
+	for _, sec := range strings.Split(s, ",") {
+		sec = strings.TrimSpace(sec)
+		if sec == "" {
+			continue
+		}
+		if !strings.HasPrefix(sec, "/") || !strings.HasSuffix(sec, "/") || sec == "/" {
+			return nil, fmt.Errorf("invalid private section %q; want /path/", sec)
+		}
+		list = append(list, sec)
+	}
+	return list, nil
+}
+
+// NewGate returns a gate guarding the sections listed in sections,
+// as read by ParseSections, whose readers sign in with password.
+func NewGate(sections string, password Password) (*Gate, error) {
+	list, err := ParseSections(sections)
+	if err != nil {
+		return nil, err
+	}
+	return &Gate{sections: list, password: password}, nil
+}
+
+// Sections returns the path prefixes of the private sections.
+func (g *Gate) Sections() []string {
+	return g.sections
+}
+
+// Private reports whether the page with URL path p is in a private section.
+func (g *Gate) Private(p string) bool {
+	return slices.ContainsFunc(g.sections, func(sec string) bool {
+		return strings.HasPrefix(p, sec) || p == strings.TrimSuffix(sec, "/")
+	})
+}
+
+// RegisterHandlers registers the sign-in and sign-out handlers on h,
+// rendering the sign-in page with the layout “signin”,
+// and marks the private pages of h's site.
+// Private pages shown to signed-in readers have signedIn set
+// in their page data, for their layouts to offer signing out.
+func (g *Gate) RegisterHandlers(h *vhost.Host) {
+	h.Site.SetPrivate(g.Private)
+	h.Site.AddPageData(func(r *http.Request, p web.Page) {
+		if url, _ := p["URL"].(string); g.Private(url) && g.signedIn(r) {
+			p["signedIn"] = true
+		}
+	})
+	site := h.Site
+	h.Router.HandleFunc("GET", signinPath, func(w http.ResponseWriter, r *http.Request) {
+		g.serveSignin(w, r, site, "", http.StatusOK)
+	})
+	h.Router.HandleFunc("POST", signinPath, func(w http.ResponseWriter, r *http.Request) {
+		g.signinHandler(w, r, site)
+	})
+	h.Router.HandleFunc("POST", signoutPath, g.signoutHandler)
+	h.Router.Document("POST", signinPath, router.Doc{
+		Summary: "Signs in to the site's private sections with their password, as a form, redirecting to the page next.",
+		Params: []router.Param{
+			{Name: "password", Description: "the password of the private sections", Required: true},
+			{Name: "next", Description: "the path of the page to go to, such as /internal-docs/"},
+		},
+	})
+	h.Router.Document("POST", signoutPath, router.Doc{
+		Summary: "Signs out of the site's private sections, redirecting to the home page.",
+	})
+}
+
+// Handler returns a handler that serves requests with h,
+// redirecting the requests for private pages from readers who have not
+// signed in to the sign-in page, and refusing those that are not reads.
+func (g *Gate) Handler(h http.Handler) http.Handler {
+	if len(g.sections) == 0 {
+		return h
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !g.Private(r.URL.Path) {
+			h.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Add("Vary", "Cookie")
+		w.Header().Set("Cache-Control", "private, no-store")
+		if !g.signedIn(r) {
+			if r.Method != "GET" && r.Method != "HEAD" {
+				http.Error(w, "Forbidden", http.StatusForbidden)
+				return
+			}
+			http.Redirect(w, r, signinPath+"?next="+url.QueryEscape(r.URL.RequestURI()), http.StatusSeeOther)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// signedIn reports whether r carries a valid session cookie.
+func (g *Gate) signedIn(r *http.Request) bool {
+	c, err := r.Cookie(cookieName)
+	if err != nil {
+		return false
+	}
+	expires, sig, ok := strings.Cut(c.Value, ".")
+	t, err := strconv.ParseInt(expires, 10, 64)
+	if !ok || err != nil || time.Now().Unix() >= t {
+		return false
+	}
+	password, err := g.password(r.Context())
+	if err != nil {
+		log.Printf("ERROR reading private sections password: %v", err)
+		return false
+	}
+	b, err := base64.RawURLEncoding.DecodeString(sig)
+	return err == nil && password != "" && hmac.Equal(b, mac(password, expires))
+}
+
+// serveSignin serves the sign-in page with the message msg, if any.
+func (g *Gate) serveSignin(w http.ResponseWriter, r *http.Request, site *web.Site, msg string, status int) {
+	w.Header().Set("Cache-Control", "no-store")
+	site.ServePage(w, r, web.Page{
+		"URL":     signinPath,
+		"title":   "Sign in",
+		"layout":  "signin",
+		"next":    next(r.FormValue("next")),
+		"message": msg,
+		"status":  status,
+	})
+}
+
+// signinHandler serves POST /_signin,
+// starting a session if the password is right.
+func (g *Gate) signinHandler(w http.ResponseWriter, r *http.Request, site *web.Site) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxBody)
+	if err := r.ParseForm(); err != nil {
+		site.ServeErrorStatus(w, r, err, http.StatusBadRequest)
+		return
+	}
+	password, err := g.password(r.Context())
+	if err != nil {
+		site.ServeError(w, r, fmt.Errorf("reading private sections password: %v", err))
+		return
+	}
+	if password == "" {
+		g.serveSignin(w, r, site, "Signing in is not available.", http.StatusServiceUnavailable)
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(r.PostFormValue("password")), []byte(password)) != 1 {
+		g.serveSignin(w, r, site, "Wrong password.", http.StatusUnauthorized)
+		return
+	}
+	expires := strconv.FormatInt(time.Now().Add(SessionTTL).Unix(), 10)
+	http.SetCookie(w, &http.Cookie{
+		Name:     cookieName,
+		Value:    expires + "." + base64.RawURLEncoding.EncodeToString(mac(password, expires)),
+		Path:     "/",
+		MaxAge:   int(SessionTTL / time.Second),
+		Secure:   isHTTPS(r),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, next(r.PostFormValue("next")), http.StatusSeeOther)
+}
+
+// signoutHandler serves POST /_signout, ending the reader's session.
+func (g *Gate) signoutHandler(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     cookieName,
+		Path:     "/",
+		MaxAge:   -1,
+		Secure:   isHTTPS(r),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+// next returns the path of the page to go to after signing in,
+// which must be on the site, or else the home page.
+func next(p string) string {
+	if !strings.HasPrefix(p, "/") || strings.HasPrefix(p, "//") || strings.HasPrefix(p, `/\`) {
+		return "/"
+	}
+	return p
+}
+
+// isHTTPS reports whether r arrived over HTTPS, perhaps at a proxy.
+func isHTTPS(r *http.Request) bool {
+	return r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" || r.URL.Scheme == "https"
+}
+
+// mac returns the signature of a session expiring at the Unix time expires.
+func mac(password, expires string) []byte {
+	h := hmac.New(sha256.New, []byte(password))
+	h.Write([]byte(cookieName + "|" + expires))
+	return h.Sum(nil)
+}
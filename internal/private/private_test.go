@@ -0,0 +1,137 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package private
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/matttproud/yourtour/internal/router"
+	"github.com/matttproud/yourtour/internal/vhost"
+	"github.com/matttproud/yourtour/internal/web"
+)
+
+func TestParseSections(t *testing.T) {
+	for _, tt := range []struct {
+		in   string
+		want string
+		err  string
+	}{
+		{"", "", ""},
+		{"/internal-docs/, /drafts/", "/internal-docs/,/drafts/", ""},
+		{"/internal-docs", "", `invalid private section "/internal-docs"`},
+		{"internal/", "", `invalid private section "internal/"`},
+		{"/", "", `invalid private section "/"`},
+	} {
+		list, err := ParseSections(tt.in)
+		if got := strings.Join(list, ","); got != tt.want || tt.err == "" && err != nil || tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+			t.Errorf("ParseSections(%q) = %q, %v, want %q, error containing %q", tt.in, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestGate(t *testing.T) {
+	fsys := fstest.MapFS{
+		"site.tmpl":                {Data: []byte(`{{if .signedIn}}in {{end}}{{block "layout" .}}{{.Content}}{{end}}`)},
+		"signin.tmpl":              {Data: []byte(`{{define "layout"}}signin next={{.next}} {{.message}}{{end}}`)},
+		"error.tmpl":               {Data: []byte(`{{define "layout"}}{{.error}}{{end}}`)},
+		"index.md":                 {Data: []byte("Home")},
+		"internal-docs/index.md":   {Data: []byte("Secrets")},
+		"internal-docs/roadmap.md": {Data: []byte("Plans")},
+	}
+	password := "opensesame"
+	gate, err := NewGate("/internal-docs/", func(ctx context.Context) (string, error) { return password, nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	site := web.NewSite(fsys)
+	mux := http.NewServeMux()
+	var reg vhost.Registry
+	gate.RegisterHandlers(reg.Add("", site, fsys, router.New(mux)))
+	mux.Handle("/", site)
+	h := gate.Handler(mux)
+
+	do := func(method, target, form string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+		t.Helper()
+		var r *http.Request
+		if form != "" {
+			r = httptest.NewRequest(method, target, strings.NewReader(form))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		} else {
+			r = httptest.NewRequest(method, target, nil)
+		}
+		for _, c := range cookies {
+			r.AddCookie(c)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	if w := do("GET", "/", ""); w.Code != 200 || strings.TrimSpace(w.Body.String()) != "<p>Home</p>" {
+		t.Errorf("GET /: %d %q, want public page", w.Code, w.Body)
+	}
+	w := do("GET", "/internal-docs/roadmap?x=1", "")
+	if loc := w.Header().Get("Location"); w.Code != http.StatusSeeOther || loc != "/_signin?next="+url.QueryEscape("/internal-docs/roadmap?x=1") {
+		t.Errorf("GET /internal-docs/roadmap signed out: %d Location %q, want redirect to sign in", w.Code, loc)
+	}
+	if w := do("POST", "/internal-docs/roadmap", "x=1"); w.Code != http.StatusForbidden {
+		t.Errorf("POST /internal-docs/roadmap signed out: %d, want 403", w.Code)
+	}
+	if w := do("GET", "/_signin?next=/internal-docs/", ""); w.Code != 200 || !strings.Contains(w.Body.String(), "signin next=/internal-docs/") {
+		t.Errorf("GET /_signin: %d %q", w.Code, w.Body)
+	}
+	if w := do("POST", "/_signin", "password=wrong&next=/internal-docs/"); w.Code != http.StatusUnauthorized || !strings.Contains(w.Body.String(), "Wrong password.") || len(w.Result().Cookies()) != 0 {
+		t.Errorf("POST /_signin with wrong password: %d %q, cookies %v", w.Code, w.Body, w.Result().Cookies())
+	}
+
+	w = do("POST", "/_signin", "password=opensesame&next=//evil.example/")
+	if loc := w.Header().Get("Location"); w.Code != http.StatusSeeOther || loc != "/" {
+		t.Errorf("POST /_signin with next //evil.example/: %d Location %q, want redirect to /", w.Code, loc)
+	}
+	w = do("POST", "/_signin", "password=opensesame&next=/internal-docs/")
+	cookies := w.Result().Cookies()
+	if loc := w.Header().Get("Location"); w.Code != http.StatusSeeOther || loc != "/internal-docs/" || len(cookies) != 1 || !cookies[0].HttpOnly {
+		t.Fatalf("POST /_signin: %d Location %q, cookies %v, want redirect to /internal-docs/ with session", w.Code, loc, cookies)
+	}
+	session := cookies[0]
+	w = do("GET", "/internal-docs/", "", session)
+	if w.Code != 200 || strings.TrimSpace(w.Body.String()) != "in <p>Secrets</p>" {
+		t.Errorf("GET /internal-docs/ signed in: %d %q, want private page", w.Code, w.Body)
+	}
+	if cc := w.Header().Get("Cache-Control"); cc != "private, no-store" {
+		t.Errorf("GET /internal-docs/ signed in: Cache-Control %q, want private, no-store", cc)
+	}
+	if w := do("GET", "/", "", session); strings.Contains(w.Body.String(), "in ") {
+		t.Errorf("GET / signed in: %q, want no signedIn on public page", w.Body)
+	}
+
+	forged := &http.Cookie{Name: cookieName, Value: "99999999999." + strings.Split(session.Value, ".")[1]}
+	if w := do("GET", "/internal-docs/", "", forged); w.Code != http.StatusSeeOther {
+		t.Errorf("GET /internal-docs/ with forged session: %d, want redirect", w.Code)
+	}
+	password = "changed"
+	if w := do("GET", "/internal-docs/", "", session); w.Code != http.StatusSeeOther {
+		t.Errorf("GET /internal-docs/ after password change: %d, want redirect", w.Code)
+	}
+	password = ""
+	if w := do("POST", "/_signin", "password="); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("POST /_signin without a password set: %d, want 503", w.Code)
+	}
+
+	w = do("POST", "/_signout", "", session)
+	if cookies := w.Result().Cookies(); w.Code != http.StatusSeeOther || len(cookies) != 1 || cookies[0].MaxAge >= 0 {
+		t.Errorf("POST /_signout: %d, cookies %v, want session cleared", w.Code, cookies)
+	}
+
+	if !site.Private("/internal-docs/roadmap") || !site.Private("/internal-docs") || site.Private("/internal-docsx") || site.Private("/") {
+		t.Errorf("site.Private does not match the gate's sections")
+	}
+}
@@ -0,0 +1,447 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package quiz serves quizzes checking readers' understanding
+// of the tour and codewalks, grading their answers on the server.
+//
+// Each quiz is a bank of multiple-choice questions, loaded from
+// quiz/<name>.yaml in the site's content. /quiz/ lists the quizzes,
+// /quiz/<name> shows one, and answers are posted back to it.
+// The scores are stored anonymously in datastore, for judging the
+// questions. A reader passing a quiz is given a completion token,
+// signed by the server, which anyone can check at /quiz/verify.
+//
+// A quiz can name the page it concludes, such as the codewalk it is about,
+// whose page data then holds the quiz, as its quiz,
+// for the page's layout to link to it.
+package quiz
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"mime"
+	"net/http"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/matttproud/yourtour/internal/router"
+	"github.com/matttproud/yourtour/internal/vhost"
+	"github.com/matttproud/yourtour/internal/web"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	kind        = "QuizScore"
+	prefix      = "/quiz/"
+	verifyPath  = prefix + "verify"
+	defaultPass = 80       // percent of questions to answer correctly to pass
+	maxBody     = 64 << 10 // bytes in a submission
+)
+
+// Dir is the directory of the question banks in the site's content.
+// The banks hold the answers, so no other handler should serve their files.
+const Dir = "quiz"
+
+// validName matches valid quiz names.
+var validName = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// A Quiz is a bank of questions, as loaded from quiz/<name>.yaml.
+type Quiz struct {
+	Name      string      `yaml:"-"` // from the file name
+	Title     string      `yaml:"title"`
+	After     string      `yaml:"after"` // path of the page the quiz concludes, if any
+	Pass      int         `yaml:"pass"`  // percent to pass; default 80
+	Questions []*Question `yaml:"questions"`
+}
+
+// URL returns the path of the quiz's page.
+func (q *Quiz) URL() string {
+	return prefix + q.Name
+}
+
+// A Question is a multiple-choice question.
+type Question struct {
+	ID      string   `yaml:"id"`
+	Text    string   `yaml:"text"`
+	Code    string   `yaml:"code"` // code the question is about, if any
+	Choices []string `yaml:"choices"`
+	Answer  string   `yaml:"answer"`  // the correct choice; never shown before grading
+	Explain string   `yaml:"explain"` // shown after grading
+}
+
+// A Score is a stored result of taking a quiz.
+// It records nothing about who took it.
+type Score struct {
+	Quiz    string
+	Correct int
+	Total   int
+	Passed  bool
+	Time    time.Time
+}
+
+// Datastore is the part of a *datastore.Client used to store scores.
+type Datastore interface {
+	Put(ctx context.Context, key *datastore.Key, src any) (*datastore.Key, error)
+}
+
+var _ Datastore = (*datastore.Client)(nil)
+
+// A Key returns the secret key signing completion tokens,
+// or the empty string if no tokens are to be issued.
+type Key func(ctx context.Context) (string, error)
+
+// KeySecretName is the name of the secret
+// from which the completion token key is read.
+const KeySecretName = "quiz-token-key"
+
+type server struct {
+	site      *web.Site
+	fsys      fs.FS
+	datastore Datastore // nil if scores are not stored
+	key       Key       // nil if no tokens are issued
+}
+
+// RegisterHandlers registers the quiz handlers on h, serving the quizzes
+// in h's file system, storing scores in dc if it is not nil, and signing
+// completion tokens with the key returned by key if it is not nil.
+// It also sets the quiz of the pages that quizzes conclude,
+// as their quiz page data.
+func RegisterHandlers(h *vhost.Host, dc Datastore, key Key) {
+	s := &server{site: h.Site, fsys: h.FS, datastore: dc, key: key}
+	h.Router.HandleFunc("GET", prefix, s.getHandler)
+	h.Router.HandleFunc("POST", prefix, s.postHandler)
+	h.Router.HandleFunc("GET", verifyPath, s.verifyHandler)
+	h.Router.Document("POST", prefix, router.Doc{
+		Summary: "Grades answers to the quiz at /quiz/<name>, posted as a form or as JSON, returning a completion token if they pass.",
+		Params: []router.Param{
+			{Name: "answers", Description: "in JSON, the chosen answer to each question, by question ID; in a form, one field per question ID", Required: true},
+		},
+		Example: &Result{
+			Quiz: "sharemem", Title: "Share Memory by Communicating",
+			Correct: 4, Total: 4, Percent: 100, Pass: 75, Passed: true,
+			Token: "eyJxdWl6Ijoic2hhcmVtZW0iLCJjb3JyZWN0Ijo0LC...",
+			Review: []Review{
+				{ID: "motto", Given: "Share memory by communicating.", Answer: "Share memory by communicating.", Right: true},
+			},
+		},
+	})
+	h.Router.Document("GET", verifyPath, router.Doc{
+		Summary: "Checks a completion token, reporting the quiz passed and the score.",
+		Params:  []router.Param{{Name: "token", Description: "the completion token", Required: true}},
+		Example: &Verification{
+			Valid: true, Quiz: "sharemem", Title: "Share Memory by Communicating",
+			Correct: 4, Total: 4, Issued: time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC),
+		},
+	})
+	h.Site.AddPageData(s.pageData)
+}
+
+// Load returns the quiz with the given name in fsys.
+func Load(fsys fs.FS, name string) (*Quiz, error) {
+	if !validName.MatchString(name) || name == path.Base(verifyPath) {
+		return nil, fmt.Errorf("invalid quiz name %q", name)
+	}
+	file := Dir + "/" + name + ".yaml"
+	data, err := fs.ReadFile(fsys, file)
+	if err != nil {
+		return nil, err
+	}
+	q := &Quiz{Name: name}
+	if err := yaml.Unmarshal(data, q); err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	if err := q.check(); err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	q.Pass = cmp.Or(q.Pass, defaultPass)
+	return q, nil
+}
+
+// check reports whether q is a valid quiz.
+func (q *Quiz) check() error {
+	if q.Title == "" {
+		return errors.New("missing title")
+	}
+	if q.Pass < 0 || q.Pass > 100 {
+		return fmt.Errorf("pass %d is not a percentage", q.Pass)
+	}
+	if q.After != "" && !strings.HasPrefix(q.After, "/") {
+		return fmt.Errorf("after %q is not a path", q.After)
+	}
+	if len(q.Questions) == 0 {
+		return errors.New("no questions")
+	}
+	seen := make(map[string]bool)
+	for i, qq := range q.Questions {
+		switch {
+		case !validName.MatchString(qq.ID):
+			return fmt.Errorf("question %d: invalid id %q", i+1, qq.ID)
+		case seen[qq.ID]:
+			return fmt.Errorf("question %d: duplicate id %q", i+1, qq.ID)
+		case qq.Text == "":
+			return fmt.Errorf("question %s: missing text", qq.ID)
+		case len(qq.Choices) < 2:
+			return fmt.Errorf("question %s: fewer than two choices", qq.ID)
+		case !slices.Contains(qq.Choices, qq.Answer):
+			return fmt.Errorf("question %s: answer %q is not a choice", qq.ID, qq.Answer)
+		}
+		seen[qq.ID] = true
+	}
+	return nil
+}
+
+// List returns the quizzes in fsys, sorted by name.
+func List(fsys fs.FS) ([]*Quiz, error) {
+	names, err := fs.Glob(fsys, Dir+"/*.yaml")
+	if err != nil {
+		return nil, err
+	}
+	var list []*Quiz
+	for _, name := range names {
+		q, err := Load(fsys, strings.TrimSuffix(path.Base(name), ".yaml"))
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, q)
+	}
+	return list, nil
+}
+
+// Validate reports whether every quiz in fsys is valid.
+func Validate(fsys fs.FS) error {
+	_, err := List(fsys)
+	return err
+}
+
+// pageData sets the quiz of the page p, which is being rendered
+// for request r, to the quiz that concludes it, if any.
+func (s *server) pageData(r *http.Request, p web.Page) {
+	if _, ok := p["quiz"]; ok {
+		return
+	}
+	url, _ := p["URL"].(string)
+	url = strings.TrimSuffix(url, "/")
+	if url == "" || strings.HasPrefix(url, prefix) {
+		return
+	}
+	list, err := List(s.fsys)
+	if err != nil {
+		log.Printf("ERROR loading quizzes: %v", err)
+		return
+	}
+	for _, q := range list {
+		if q.After == url {
+			p["quiz"] = q
+			return
+		}
+	}
+}
+
+// getHandler serves the list of quizzes, /quiz/, and each quiz, /quiz/<name>.
+func (s *server) getHandler(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, prefix)
+	if name == "" {
+		list, err := List(s.fsys)
+		if err != nil {
+			s.site.ServeError(w, r, err)
+			return
+		}
+		s.site.ServePage(w, r, web.Page{
+			"title":   "Quizzes",
+			"layout":  "quiz",
+			"quizzes": list,
+		})
+		return
+	}
+	q, ok := s.load(w, r, name)
+	if !ok {
+		return
+	}
+	s.site.ServePage(w, r, web.Page{
+		"title":  "Quiz: " + q.Title,
+		"layout": "quiz",
+		"quiz":   q,
+	})
+}
+
+// load loads the quiz with the given name, replying with an error if it cannot.
+func (s *server) load(w http.ResponseWriter, r *http.Request, name string) (*Quiz, bool) {
+	q, err := Load(s.fsys, name)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) || !validName.MatchString(name) {
+			s.site.ServeErrorStatus(w, r, fmt.Errorf("no quiz %q", name), http.StatusNotFound)
+		} else {
+			s.site.ServeError(w, r, err)
+		}
+		return nil, false
+	}
+	return q, true
+}
+
+// A Result is the grading of answers to a quiz.
+type Result struct {
+	Quiz    string   `json:"quiz"`
+	Title   string   `json:"title"`
+	Correct int      `json:"correct"`
+	Total   int      `json:"total"`
+	Percent int      `json:"percent"`
+	Pass    int      `json:"pass"` // percent needed to pass
+	Passed  bool     `json:"passed"`
+	Token   string   `json:"token,omitempty"` // completion token, if passed
+	Review  []Review `json:"review"`
+}
+
+// A Review is the grading of the answer to one question.
+type Review struct {
+	ID      string `json:"id"`
+	Text    string `json:"-"`
+	Given   string `json:"given"`
+	Answer  string `json:"answer"`
+	Right   bool   `json:"right"`
+	Explain string `json:"explain,omitempty"`
+}
+
+// grade grades the answers to q, the chosen choice by question ID.
+func grade(q *Quiz, answers map[string]string) *Result {
+	res := &Result{Quiz: q.Name, Title: q.Title, Total: len(q.Questions), Pass: q.Pass}
+	for _, qq := range q.Questions {
+		rv := Review{ID: qq.ID, Text: qq.Text, Given: answers[qq.ID], Answer: qq.Answer, Explain: qq.Explain}
+		rv.Right = rv.Given == qq.Answer
+		if rv.Right {
+			res.Correct++
+		}
+		res.Review = append(res.Review, rv)
+	}
+	res.Percent = res.Correct * 100 / res.Total
+	res.Passed = res.Percent >= q.Pass
+	return res
+}
+
+// postHandler grades the answers to a quiz, posted as a form,
+// with a field for each question, or as JSON:
+//
+//	POST /quiz/sharemem
+//	{"answers": {"motto": "Share memory by communicating.", ...}}
+//
+// JSON submissions are answered with the Result in JSON;
+// form submissions with a page showing it.
+func (s *server) postHandler(w http.ResponseWriter, r *http.Request) {
+	q, ok := s.load(w, r, strings.TrimPrefix(r.URL.Path, prefix))
+	if !ok {
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxBody)
+	mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	isJSON := mt == "application/json"
+	var sub struct {
+		Answers map[string]string `json:"answers"`
+	}
+	if isJSON {
+		data, err := io.ReadAll(r.Body)
+		if err == nil {
+			err = json.Unmarshal(data, &sub)
+		}
+		if err != nil {
+			http.Error(w, "invalid answers: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	} else {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, "invalid answers: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		sub.Answers = make(map[string]string)
+		for _, qq := range q.Questions {
+			sub.Answers[qq.ID] = r.PostForm.Get(qq.ID)
+		}
+	}
+
+	ctx := r.Context()
+	res := grade(q, sub.Answers)
+	if s.datastore != nil {
+		score := &Score{Quiz: q.Name, Correct: res.Correct, Total: res.Total, Passed: res.Passed, Time: time.Now()}
+		if _, err := s.datastore.Put(ctx, datastore.IncompleteKey(kind, nil), score); err != nil {
+			// The reader still sees the result.
+			log.Printf("ERROR storing score for quiz %s: %v", q.Name, err)
+		}
+	}
+	if res.Passed && s.key != nil {
+		if key, err := s.key(ctx); err != nil {
+			log.Printf("ERROR loading %s: %v", KeySecretName, err)
+		} else if key != "" {
+			res.Token = sign(key, claims{Quiz: q.Name, Correct: res.Correct, Total: res.Total, Issued: time.Now().Unix()})
+		}
+	}
+
+	if isJSON {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		if err := json.NewEncoder(w).Encode(res); err != nil {
+			log.Printf("ERROR writing quiz result: %v", err)
+		}
+		return
+	}
+	s.site.ServePage(w, r, web.Page{
+		"title":  "Quiz: " + q.Title,
+		"layout": "quiz",
+		"quiz":   q,
+		"result": res,
+	})
+}
+
+// A Verification is the result of checking a completion token.
+type Verification struct {
+	Valid   bool      `json:"valid"`
+	Error   string    `json:"error,omitempty"` // why the token is not valid
+	Quiz    string    `json:"quiz,omitempty"`
+	Title   string    `json:"title,omitempty"` // empty if the quiz no longer exists
+	Correct int       `json:"correct,omitempty"`
+	Total   int       `json:"total,omitempty"`
+	Issued  time.Time `json:"issued,omitzero"`
+}
+
+// verifyHandler serves /quiz/verify?token=, checking a completion token.
+func (s *server) verifyHandler(w http.ResponseWriter, r *http.Request) {
+	token := r.FormValue("token")
+	if token == "" {
+		http.Error(w, "missing token", http.StatusBadRequest)
+		return
+	}
+	var key string
+	if s.key != nil {
+		k, err := s.key(r.Context())
+		if err != nil {
+			log.Printf("ERROR loading %s: %v", KeySecretName, err)
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+		key = k
+	}
+	if key == "" {
+		http.Error(w, "completion tokens are not issued", http.StatusNotFound)
+		return
+	}
+	var v Verification
+	if c, err := verify(key, token); err != nil {
+		v.Error = err.Error()
+	} else {
+		v = Verification{Valid: true, Quiz: c.Quiz, Correct: c.Correct, Total: c.Total, Issued: time.Unix(c.Issued, 0).UTC()}
+		if q, err := Load(s.fsys, c.Quiz); err == nil {
+			v.Title = q.Title
+		}
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if err := json.NewEncoder(w).Encode(&v); err != nil {
+		log.Printf("ERROR writing token verification: %v", err)
+	}
+}
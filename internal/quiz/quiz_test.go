@@ -0,0 +1,251 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package quiz
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"cloud.google.com/go/datastore"
+	"github.com/matttproud/yourtour/internal/router"
+	"github.com/matttproud/yourtour/internal/vhost"
+	"github.com/matttproud/yourtour/internal/web"
+)
+
+const goYAML = `
+title: Go
+after: /doc/go
+pass: 50
+questions:
+- id: gopher
+  text: What is the mascot?
+  choices: [Gopher, Penguin]
+  answer: Gopher
+  explain: It is a gopher.
+- id: year
+  text: When was Go announced?
+  choices: ["2007", "2009"]
+  answer: "2009"
+`
+
+func testFS() fstest.MapFS {
+	return fstest.MapFS{
+		"quiz/go.yaml": {Data: []byte(goYAML)},
+		"site.tmpl": {Data: []byte(`{{.title}}|` +
+			`{{range .quizzes}}[{{.URL}} {{.Title}}]{{end}}` +
+			`{{with .result}}{{.Correct}}/{{.Total}} {{.Passed}} {{if .Token}}token{{end}}` +
+			`{{else}}{{with .quiz}}{{range .Questions}}[{{.ID}}{{range .Choices}} {{.}}{{end}}]{{end}}{{end}}{{end}}`)},
+		"quiz.tmpl":  {Data: []byte(`{{define "layout"}}{{end}}`)},
+		"error.tmpl": {Data: []byte(`{{define "layout"}}{{end}}`)},
+		"doc/go.md":  {Data: []byte("---\ntitle: Go\n---\n")},
+	}
+}
+
+// A fakeDatastore records the scores put in it.
+type fakeDatastore struct {
+	scores []*Score
+	err    error
+}
+
+func (d *fakeDatastore) Put(ctx context.Context, key *datastore.Key, src any) (*datastore.Key, error) {
+	if d.err != nil {
+		return nil, d.err
+	}
+	d.scores = append(d.scores, src.(*Score))
+	return key, nil
+}
+
+func setup(t *testing.T, dc Datastore, key Key) *http.ServeMux {
+	t.Helper()
+	fsys := testFS()
+	mux := http.NewServeMux()
+	var reg vhost.Registry
+	site := web.NewSite(fsys)
+	RegisterHandlers(reg.Add("", site, fsys, router.New(mux)), dc, key)
+	mux.Handle("/", site)
+	return mux
+}
+
+func testKey(context.Context) (string, error) { return "secret", nil }
+
+func TestServe(t *testing.T) {
+	mux := setup(t, nil, nil)
+	for _, tt := range []struct {
+		url  string
+		code int
+		want string
+	}{
+		{"/quiz/", 200, "Quizzes|[/quiz/go Go]"},
+		{"/quiz/go", 200, "Quiz: Go|[gopher Gopher Penguin][year 2007 2009]"},
+		{"/quiz/nope", 404, ""},
+		{"/quiz/go.yaml", 404, ""},
+		{"/quiz/verify", 400, ""},
+	} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", tt.url, nil))
+		if w.Code != tt.code || tt.want != "" && w.Body.String() != tt.want {
+			t.Errorf("GET %s: %d %q, want %d %q", tt.url, w.Code, w.Body, tt.code, tt.want)
+		}
+	}
+}
+
+func TestGrade(t *testing.T) {
+	d := new(fakeDatastore)
+	mux := setup(t, d, testKey)
+
+	// A form submission renders a page.
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/quiz/go", strings.NewReader(url.Values{"gopher": {"Gopher"}, "year": {"2007"}}.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	mux.ServeHTTP(w, r)
+	if got, want := w.Body.String(), "Quiz: Go|1/2 true token"; w.Code != 200 || got != want {
+		t.Errorf("POST form: %d %q, want %q", w.Code, got, want)
+	}
+
+	// A JSON submission gets JSON back.
+	for _, tt := range []struct {
+		answers string
+		correct int
+		passed  bool
+	}{
+		{`{"gopher": "Gopher", "year": "2009"}`, 2, true},
+		{`{"gopher": "Penguin"}`, 0, false},
+		{`{"gopher": "Gopher", "extra": "ignored"}`, 1, true},
+	} {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("POST", "/quiz/go", strings.NewReader(`{"answers": `+tt.answers+`}`))
+		r.Header.Set("Content-Type", "application/json")
+		mux.ServeHTTP(w, r)
+		var res Result
+		if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+			t.Fatalf("POST %s: %d %v\n%s", tt.answers, w.Code, err, w.Body)
+		}
+		if res.Correct != tt.correct || res.Total != 2 || res.Passed != tt.passed || (res.Token != "") != tt.passed {
+			t.Errorf("POST %s: %+v, want %d correct, passed %v", tt.answers, res, tt.correct, tt.passed)
+		}
+		if len(res.Review) != 2 || res.Review[0].Explain != "It is a gopher." {
+			t.Errorf("POST %s: review %+v", tt.answers, res.Review)
+		}
+	}
+	if len(d.scores) != 4 || d.scores[1].Correct != 2 || !d.scores[1].Passed || d.scores[2].Passed {
+		t.Errorf("stored scores %+v", d.scores)
+	}
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest("POST", "/quiz/go", strings.NewReader(`{"answers": [`))
+	r.Header.Set("Content-Type", "application/json")
+	mux.ServeHTTP(w, r)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("POST invalid JSON: %d, want 400", w.Code)
+	}
+}
+
+func TestGradeWithoutStorage(t *testing.T) {
+	// Failing to store the score or load the key still grades the answers.
+	mux := setup(t, &fakeDatastore{err: errors.New("unavailable")}, func(context.Context) (string, error) {
+		return "", errors.New("no secrets")
+	})
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/quiz/go", strings.NewReader(`{"answers": {"gopher": "Gopher", "year": "2009"}}`))
+	r.Header.Set("Content-Type", "application/json")
+	mux.ServeHTTP(w, r)
+	var res Result
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil || !res.Passed || res.Token != "" {
+		t.Errorf("POST: %d %v %+v, want passed without token", w.Code, err, res)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	mux := setup(t, nil, testKey)
+	verify := func(token string) (int, *Verification) {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", "/quiz/verify?token="+url.QueryEscape(token), nil))
+		v := new(Verification)
+		json.Unmarshal(w.Body.Bytes(), v)
+		return w.Code, v
+	}
+
+	token := sign("secret", claims{Quiz: "go", Correct: 2, Total: 2, Issued: 1700000000})
+	if code, v := verify(token); code != 200 || !v.Valid || v.Quiz != "go" || v.Title != "Go" || v.Correct != 2 || v.Issued.Unix() != 1700000000 {
+		t.Errorf("verify valid token: %d %+v", code, v)
+	}
+	if token == sign("secret", claims{Quiz: "go", Correct: 2, Total: 2, Issued: 1700000000}) {
+		t.Errorf("sign returned the same token twice")
+	}
+
+	payload, sig, _ := strings.Cut(token, ".")
+	forged := sign("other", claims{Quiz: "go", Correct: 2, Total: 2})
+	for _, bad := range []string{
+		"garbage",
+		payload + ".",
+		payload + "." + sig[1:],
+		forged,
+		strings.Split(forged, ".")[0] + "." + sig,
+	} {
+		if code, v := verify(bad); code != 200 || v.Valid || v.Error != "invalid token" {
+			t.Errorf("verify %q: %d %+v, want invalid", bad, code, v)
+		}
+	}
+
+	mux = setup(t, nil, nil)
+	if code, _ := verify(token); code != http.StatusNotFound {
+		t.Errorf("verify without key: %d, want 404", code)
+	}
+}
+
+func TestPageData(t *testing.T) {
+	fsys := testFS()
+	fsys["site.tmpl"] = &fstest.MapFile{Data: []byte(`{{.title}}|{{with .quiz}}{{.URL}}{{end}}`)}
+	fsys["doc/other.md"] = &fstest.MapFile{Data: []byte("---\ntitle: Other\n---\n")}
+	mux := http.NewServeMux()
+	var reg vhost.Registry
+	site := web.NewSite(fsys)
+	RegisterHandlers(reg.Add("", site, fsys, router.New(mux)), nil, nil)
+	mux.Handle("/", site)
+	for url, want := range map[string]string{
+		"/doc/go":    "Go|/quiz/go",
+		"/doc/other": "Other|",
+	} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", url, nil))
+		if got := w.Body.String(); w.Code != 200 || got != want {
+			t.Errorf("GET %s: %d %q, want %q", url, w.Code, got, want)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	q := "title: T\nquestions:\n- id: a\n  text: A?\n  choices: [x, y]\n  answer: x\n"
+	for _, tt := range []struct {
+		name, yaml string
+		err        string
+	}{
+		{"ok", q, ""},
+		{"verify", q, `invalid quiz name "verify"`},
+		{"ok", "questions:\n- id: a\n", "missing title"},
+		{"ok", "title: T\n", "no questions"},
+		{"ok", "title: T\npass: 101\n", "pass 101 is not a percentage"},
+		{"ok", "title: T\nafter: doc/go\n", `after "doc/go" is not a path`},
+		{"ok", strings.Replace(q, "answer: x", "answer: z", 1), `question a: answer "z" is not a choice`},
+		{"ok", strings.Replace(q, "[x, y]", "[x]", 1), "question a: fewer than two choices"},
+		{"ok", q + "- id: a\n  text: B?\n  choices: [x, y]\n  answer: y\n", `question 2: duplicate id "a"`},
+		{"ok", "title: T\nquestions: 1\n", "cannot unmarshal"},
+	} {
+		q, err := Load(fstest.MapFS{"quiz/" + tt.name + ".yaml": {Data: []byte(tt.yaml)}}, tt.name)
+		if tt.err == "" && err != nil || tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+			t.Errorf("Load(%q) = %v, want error containing %q", tt.yaml, err, tt.err)
+		}
+		if err == nil && q.Pass != defaultPass {
+			t.Errorf("Load(%q).Pass = %d, want %d", tt.yaml, q.Pass, defaultPass)
+		}
+	}
+}
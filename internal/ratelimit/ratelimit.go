@@ -0,0 +1,293 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package ratelimit limits the rate of HTTP requests to expensive
+// or abuse-prone routes, using a token bucket per client.
+//
+// A Limiter counts requests by a key derived from each request,
+// such as the client's IP address or the token it presents.
+// Requests beyond the limit get a 429 Too Many Requests response
+// with a Retry-After header saying when to try again.
+package ratelimit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/matttproud/yourtour/internal/clientip"
+)
+
+// A Limit is a token-bucket rate limit: Count requests every Per,
+// with bursts of up to Burst requests.
+// The zero Limit allows every request.
+type Limit struct {
+	Count int
+	Per   time.Duration
+	Burst int
+}
+
+// ParseLimit parses a limit of the form count/period[:burst],
+// such as "10/m", "100/h:20", or "5/30s".
+// The period is s, m, or h, or a duration; the burst defaults to count.
+// The string "off" is the zero Limit, allowing every request.
+func ParseLimit(s string) (Limit, error) {
+	if s == "off" {
+		return Limit{}, nil
+	}
+	bad := fmt.Errorf("invalid limit %q; want count/period[:burst], like 10/m or 100/h:20", s)
+	count, rest, ok := strings.Cut(s, "/")
+	if !ok {
+		return Limit{}, bad
+	}
+	period, burst, hasBurst := strings.Cut(rest, ":")
+	var l Limit
+	var err error
+	if l.Count, err = strconv.Atoi(count); err != nil || l.Count <= 0 {
+		return Limit{}, bad
+	}
+	switch period {
+	case "s":
+		l.Per = time.Second
+	case "m":
+		l.Per = time.Minute
+	case "h":
+		l.Per = time.Hour
+	default:
+		if l.Per, err = time.ParseDuration(period); err != nil || l.Per <= 0 {
+			return Limit{}, bad
+		}
+	}
+	l.Burst = l.Count
+	if hasBurst {
+		if l.Burst, err = strconv.Atoi(burst); err != nil || l.Burst <= 0 {
+			return Limit{}, bad
+		}
+	}
+	return l, nil
+}
+
+func (l Limit) String() string {
+	if l.Count == 0 {
+		return "off"
+	}
+	var per string
+	switch l.Per {
+	case time.Second:
+		per = "s"
+	case time.Minute:
+		per = "m"
+	case time.Hour:
+		per = "h"
+	default:
+		per = l.Per.String()
+	}
+	s := strconv.Itoa(l.Count) + "/" + per
+	if l.Burst != l.Count {
+		s += ":" + strconv.Itoa(l.Burst)
+	}
+	return s
+}
+
+// interval returns the time it takes to earn one token.
+func (l Limit) interval() time.Duration {
+	return l.Per / time.Duration(l.Count)
+}
+
+// A Rule configures the limit for one route:
+// the limit, and the key by which requests are counted,
+// which is "ip", "token", or "ip+token"
+// for ByIP, ByToken, or ByIPAndToken.
+type Rule struct {
+	Limit Limit
+	By    string
+}
+
+// ParseRules parses a comma-separated list of per-route rules
+// of the form route=limit[@by], where limit is as for ParseLimit
+// and by defaults to "ip". For example:
+//
+//	upload=10/m@token,fileprint=off
+func ParseRules(s string) (map[string]Rule, error) {
+	rules := make(map[string]Rule)
+	for _, f := range strings.Split(s, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		route, spec, ok := strings.Cut(f, "=")
+		if !ok || route == "" {
+			return nil, fmt.Errorf("invalid rule %q; want route=limit[@by]", f)
+		}
+		spec, by, ok := strings.Cut(spec, "@")
+		if !ok {
+			by = "ip"
+		}
+		if by != "ip" && by != "token" && by != "ip+token" {
+			return nil, fmt.Errorf("invalid rule %q: unknown key %q; want ip, token, or ip+token", f, by)
+		}
+		limit, err := ParseLimit(spec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rule %q: %v", f, err)
+		}
+		rules[route] = Rule{limit, by}
+	}
+	return rules, nil
+}
+
+// KeyFunc returns the KeyFunc counting requests as the rule says.
+func (r Rule) KeyFunc() KeyFunc {
+	switch r.By {
+	case "token":
+		return ByToken
+	case "ip+token":
+		return ByIPAndToken
+	}
+	return ByIP
+}
+
+// A KeyFunc returns the key by which a Limiter counts a request.
+// Requests with the same key share a token bucket.
+type KeyFunc func(*http.Request) string
+
+// ByIP is a KeyFunc that counts requests by client IP address,
+// as found by clientip.FromRequest.
+func ByIP(r *http.Request) string {
+	return "ip " + clientip.FromRequest(r)
+}
+
+// ByToken is a KeyFunc that counts requests by the token they present,
+// falling back to counting by IP address, as ByIP does,
+// for requests without one.
+// The token is the bearer token in the Authorization header or,
+// failing that, the key query parameter used by the download upload API.
+func ByToken(r *http.Request) string {
+	if t := token(r); t != "" {
+		return "token " + t
+	}
+	return ByIP(r)
+}
+
+// ByIPAndToken is a KeyFunc that counts requests by the combination
+// of their client IP address, as for ByIP, and token, as for ByToken.
+func ByIPAndToken(r *http.Request) string {
+	return "ip " + clientip.FromRequest(r) + " token " + token(r)
+}
+
+// token returns a digest of the token presented by r, if any,
+// so that a Limiter does not hold on to credentials.
+func token(r *http.Request) string {
+	t, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		t = r.URL.Query().Get("key")
+	}
+	if t == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(t))
+	return hex.EncodeToString(sum[:8])
+}
+
+// A Limiter limits the rate of requests sharing a key.
+// It is safe for concurrent use.
+type Limiter struct {
+	limit Limit
+	key   KeyFunc
+	now   func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	swept   time.Time // last removal of full buckets
+}
+
+// A bucket records the tokens available to a key:
+// tokens at time last, which then accrue at the limit's rate.
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// New returns a Limiter applying limit to requests counted by key.
+func New(limit Limit, key KeyFunc) *Limiter {
+	return &Limiter{
+		limit:   limit,
+		key:     key,
+		now:     time.Now,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// Limit returns the limiter's limit.
+func (l *Limiter) Limit() Limit {
+	return l.limit
+}
+
+// Allow reports whether the request r is within the limit,
+// counting it against its key's bucket if so.
+// If not, it also returns how long until the request would be allowed.
+func (l *Limiter) Allow(r *http.Request) (ok bool, retryAfter time.Duration) {
+	if l.limit.Count == 0 {
+		return true, 0
+	}
+	key := l.key(r)
+	now := l.now()
+	burst := float64(l.limit.Burst)
+	rate := float64(l.limit.Count) / l.limit.Per.Seconds()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sweep(now)
+	b := l.buckets[key]
+	if b == nil {
+		b = &bucket{tokens: burst, last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) / rate * float64(time.Second))
+}
+
+// sweep removes the buckets that have refilled completely,
+// since they are indistinguishable from new ones,
+// so that the map does not grow with every client ever seen.
+// To keep the cost down, it does so at most once per refill time.
+func (l *Limiter) sweep(now time.Time) {
+	full := time.Duration(l.limit.Burst) * l.limit.interval()
+	if now.Sub(l.swept) < full {
+		return
+	}
+	l.swept = now
+	for k, b := range l.buckets {
+		if now.Sub(b.last) >= full {
+			delete(l.buckets, k)
+		}
+	}
+}
+
+// Handler returns a handler that serves requests within the limit with h
+// and replies to the rest with 429 Too Many Requests.
+// If match is not nil, only requests for which it returns true are limited.
+func (l *Limiter) Handler(h http.Handler, match func(*http.Request) bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if match == nil || match(r) {
+			if ok, retry := l.Allow(r); !ok {
+				secs := int64(math.Ceil(retry.Seconds()))
+				w.Header().Set("Retry-After", strconv.FormatInt(max(secs, 1), 10))
+				http.Error(w, "too many requests; try again later", http.StatusTooManyRequests)
+				return
+			}
+		}
+		h.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,182 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/matttproud/yourtour/internal/clientip"
+)
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Limit
+		str  string
+	}{
+		{"10/m", Limit{10, time.Minute, 10}, "10/m"},
+		{"100/h:20", Limit{100, time.Hour, 20}, "100/h:20"},
+		{"5/30s", Limit{5, 30 * time.Second, 5}, "5/30s"},
+		{"1/s", Limit{1, time.Second, 1}, "1/s"},
+		{"off", Limit{}, "off"},
+	}
+	for _, tt := range tests {
+		l, err := ParseLimit(tt.in)
+		if err != nil || l != tt.want {
+			t.Errorf("ParseLimit(%q) = %v, %v, want %v, nil", tt.in, l, err, tt.want)
+			continue
+		}
+		if s := l.String(); s != tt.str {
+			t.Errorf("ParseLimit(%q).String() = %q, want %q", tt.in, s, tt.str)
+		}
+	}
+
+	for _, in := range []string{"", "10", "10/", "0/m", "-1/m", "10/fortnight", "10/-1s", "10/m:", "10/m:0", "x/m"} {
+		if l, err := ParseLimit(in); err == nil {
+			t.Errorf("ParseLimit(%q) = %v, want error", in, l)
+		}
+	}
+}
+
+func TestParseRules(t *testing.T) {
+	rules, err := ParseRules(" upload=10/m@token, fileprint=off,,search=5/s@ip+token,a=1/h")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]Rule{
+		"upload":    {Limit{10, time.Minute, 10}, "token"},
+		"fileprint": {Limit{}, "ip"},
+		"search":    {Limit{5, time.Second, 5}, "ip+token"},
+		"a":         {Limit{1, time.Hour, 1}, "ip"},
+	}
+	if len(rules) != len(want) {
+		t.Errorf("ParseRules = %v, want %v", rules, want)
+	}
+	for name, r := range want {
+		if rules[name] != r {
+			t.Errorf("rule %s = %v, want %v", name, rules[name], r)
+		}
+	}
+
+	for _, in := range []string{"upload", "=10/m", "upload=10", "upload=10/m@user"} {
+		if _, err := ParseRules(in); err == nil {
+			t.Errorf("ParseRules(%q) succeeded, want error", in)
+		}
+	}
+}
+
+func TestKeys(t *testing.T) {
+	req := func(remote, xff, auth, query string) *http.Request {
+		r := httptest.NewRequest("GET", "/dl/upload"+query, nil)
+		r.RemoteAddr = remote
+		if xff != "" {
+			r.Header.Set("X-Forwarded-For", xff)
+		}
+		if auth != "" {
+			r.Header.Set("Authorization", auth)
+		}
+		return r
+	}
+	resolved := func(r *http.Request, ip string) *http.Request {
+		return r.WithContext(clientip.NewContext(r.Context(), ip))
+	}
+	tests := []struct {
+		key  KeyFunc
+		r    *http.Request
+		want string
+	}{
+		{ByIP, req("192.0.2.1:1234", "198.51.100.1", "", ""), "ip 192.0.2.1"},
+		{ByIP, resolved(req("169.254.1.1:1234", "203.0.113.9, 198.51.100.1, 192.0.2.200", "", ""), "198.51.100.1"), "ip 198.51.100.1"},
+		{ByToken, req("192.0.2.1:1234", "", "", ""), "ip 192.0.2.1"},
+		{ByToken, req("192.0.2.1:1234", "", "Bearer secret", ""), "token " + token(req("", "", "Bearer secret", ""))},
+		{ByToken, req("192.0.2.1:1234", "", "", "?user=x&key=secret"), "token " + token(req("", "", "Bearer secret", ""))},
+		{ByIPAndToken, req("192.0.2.1:1234", "", "", ""), "ip 192.0.2.1 token "},
+	}
+	for _, tt := range tests {
+		if got := tt.key(tt.r); got != tt.want {
+			t.Errorf("key(%s %v) = %q, want %q", tt.r.RemoteAddr, tt.r.Header, got, tt.want)
+		}
+	}
+	if tok := token(req("", "", "Bearer secret", "")); tok == "" || tok == "secret" {
+		t.Errorf("token = %q, want a digest", tok)
+	}
+}
+
+func TestAllow(t *testing.T) {
+	now := time.Unix(1e9, 0)
+	l := New(Limit{Count: 2, Per: time.Minute, Burst: 3}, ByIP)
+	l.now = func() time.Time { return now }
+
+	req := func(remote string) *http.Request {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = remote
+		return r
+	}
+	a, b := req("192.0.2.1:1"), req("192.0.2.2:1")
+
+	for i := 0; i < 3; i++ {
+		if ok, _ := l.Allow(a); !ok {
+			t.Fatalf("request %d in burst refused", i)
+		}
+	}
+	ok, retry := l.Allow(a)
+	if ok || retry != 30*time.Second {
+		t.Fatalf("request after burst = %v, %v, want false, 30s", ok, retry)
+	}
+	if ok, _ := l.Allow(b); !ok {
+		t.Fatalf("request from other client refused")
+	}
+
+	now = now.Add(20 * time.Second)
+	if ok, retry := l.Allow(a); ok || retry != 10*time.Second {
+		t.Fatalf("request after 20s = %v, %v, want false, 10s", ok, retry)
+	}
+	now = now.Add(10 * time.Second)
+	if ok, _ := l.Allow(a); !ok {
+		t.Fatalf("request after 30s refused")
+	}
+
+	// Once refilled, buckets are forgotten.
+	now = now.Add(2 * time.Minute)
+	l.Allow(b)
+	if n := len(l.buckets); n != 1 {
+		t.Errorf("after refill, have %d buckets, want 1", n)
+	}
+
+	off := New(Limit{}, ByIP)
+	for i := 0; i < 100; i++ {
+		if ok, _ := off.Allow(a); !ok {
+			t.Fatalf("zero Limit refused request")
+		}
+	}
+}
+
+func TestHandler(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	l := New(Limit{Count: 1, Per: time.Hour, Burst: 1}, ByIP)
+	h := l.Handler(ok, func(r *http.Request) bool { return r.URL.Path == "/limited" })
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w
+	}
+	if w := get("/limited"); w.Code != 200 {
+		t.Fatalf("first request: %d, want 200", w.Code)
+	}
+	w := get("/limited")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("second request: %d, want 429", w.Code)
+	}
+	if ra := w.Header().Get("Retry-After"); ra != "3600" {
+		t.Errorf("Retry-After = %q, want 3600", ra)
+	}
+	if w := get("/other"); w.Code != 200 {
+		t.Errorf("unmatched request: %d, want 200", w.Code)
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package rates parses the per-route rates of settings
+// such as shadows and canaries.
+package rates
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Rates map route names to fractions between 0 and 1,
+// such as the fraction of a route's requests that are sampled.
+type Rates map[string]float64
+
+// Parse parses a comma-separated list of rates of the form
+// name=rate, where rate is a fraction, such as 0.05,
+// or a percentage, such as 5%. For example:
+//
+//	codewalk=5%,dl=0.5
+func Parse(s string) (Rates, error) {
+	rates := make(Rates)
+	for _, f := range strings.Split(s, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		name, spec, ok := strings.Cut(f, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid rate %q; want name=rate", f)
+		}
+		num, pct := strings.CutSuffix(spec, "%")
+		rate, err := strconv.ParseFloat(num, 64)
+		if pct {
+			rate /= 100
+		}
+		if err != nil || rate < 0 || rate > 1 {
+			return nil, fmt.Errorf("invalid rate %q: bad rate %q; want a fraction like 0.05 or a percentage like 5%%", f, spec)
+		}
+		rates[name] = rate
+	}
+	return rates, nil
+}
+
+// String returns the rates in the form read by Parse.
+func (rs Rates) String() string {
+	var list []string
+	for name, rate := range rs {
+		list = append(list, name+"="+strconv.FormatFloat(rate, 'g', -1, 64))
+	}
+	sort.Strings(list)
+	return strings.Join(list, ",")
+}
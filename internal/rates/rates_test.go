@@ -0,0 +1,22 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rates
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	rates, err := Parse("codewalk=5%, dl=0.25,pkg=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := rates.String(); s != "codewalk=0.05,dl=0.25,pkg=1" {
+		t.Errorf("String = %q", s)
+	}
+	for _, s := range []string{"codewalk", "=5%", "codewalk=5", "codewalk=-1%", "codewalk=half"} {
+		if _, err := Parse(s); err == nil {
+			t.Errorf("Parse(%q) succeeded, want error", s)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package redirect
+
+import (
+	"cmp"
+	"encoding/json"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// An Entry is a redirect exported for proxies and CDNs,
+// so that they can answer requests for redirected paths at the edge
+// instead of forwarding them to the server.
+type Entry struct {
+	Host      string `json:"host,omitempty"` // host the redirect is limited to, if any
+	Path      string `json:"path"`
+	Match     string `json:"match"` // MatchExact, MatchSubtree, or MatchPrefix
+	Target    string `json:"target"`
+	Status    int    `json:"status"`
+	KeepQuery bool   `json:"keepQuery"` // the request's query is appended to the target
+	Source    string `json:"source"`    // SourceLegacy or SourceRules
+}
+
+// How an Entry's Path matches the paths of requests.
+const (
+	// The request path is Path.
+	MatchExact = "exact"
+
+	// The request path is Path, which ends in a slash, or below it,
+	// and no other entry matches exactly or with a longer Path.
+	MatchSubtree = "subtree"
+
+	// The request path is below Path, which ends in a slash;
+	// the rest of the request path is appended to Target.
+	MatchPrefix = "prefix"
+)
+
+// Where an Entry comes from.
+const (
+	SourceLegacy = "legacy" // the built-in redirects of old and misplaced paths
+	SourceRules  = "rules"  // the redirects of a Rules file
+)
+
+// Legacy returns the redirects registered by Register that map fixed paths
+// to fixed targets, sorted by path. It leaves out those computed for
+// each request, such as those of /cl/ and /change/.
+func Legacy() []Entry {
+	var list []Entry
+	add := func(path, target string, status int) {
+		match := MatchExact
+		if strings.HasSuffix(path, "/") {
+			match = MatchSubtree
+		}
+		list = append(list, Entry{Path: path, Match: match, Target: target, Status: status, KeepQuery: true, Source: SourceLegacy})
+	}
+	for _, rd := range []struct {
+		prefix string
+		m      map[string]string
+	}{{"/pkg/", pkgRedirects}, {"/cmd/", cmdRedirects}} {
+		for source, target := range rd.m {
+			add(rd.prefix+source, rd.prefix+target+"/", http.StatusMovedPermanently)
+			add(rd.prefix+source+"/", rd.prefix+target+"/", http.StatusMovedPermanently)
+		}
+	}
+	for path, target := range redirects {
+		add(path, target, http.StatusMovedPermanently)
+	}
+	for path, target := range blogRedirects {
+		add("/blog"+path, "/blog/"+target, http.StatusMovedPermanently)
+	}
+	for prefix, target := range prefixHelpers {
+		list = append(list, Entry{Path: "/" + prefix + "/", Match: MatchPrefix, Target: target, Status: http.StatusFound, Source: SourceLegacy})
+	}
+	list = append(list, Entry{Path: "/src/pkg/", Match: MatchPrefix, Target: "/src/", Status: http.StatusMovedPermanently, KeepQuery: true, Source: SourceLegacy})
+	sortEntries(list)
+	return list
+}
+
+// Entries returns the rules as redirects limited to host,
+// or not limited if host is empty, sorted by path.
+func (r *Rules) Entries(host string) []Entry {
+	var list []Entry
+	if m := r.m.Load(); m != nil {
+		for path, target := range *m {
+			list = append(list, Entry{Host: host, Path: path, Match: MatchExact, Target: target, Status: http.StatusMovedPermanently, KeepQuery: true, Source: SourceRules})
+		}
+	}
+	sortEntries(list)
+	return list
+}
+
+// sortEntries sorts list by host and then path.
+func sortEntries(list []Entry) {
+	slices.SortFunc(list, func(x, y Entry) int {
+		return cmp.Or(strings.Compare(x.Host, y.Host), strings.Compare(x.Path, y.Path))
+	})
+}
+
+// ExportHandler serves the redirects returned by entries as JSON,
+// in an object whose "redirects" field lists them.
+// Be careful. It is the caller’s responsibility to ensure that the handler is
+// only exposed to authorized users.
+func ExportHandler(entries func() []Entry) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" && r.Method != "HEAD" {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		list := entries()
+		sortEntries(list)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", " ")
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(struct {
+			Redirects []Entry `json:"redirects"`
+		}{list}); err != nil {
+			log.Printf("ERROR exporting redirects: %v", err)
+		}
+	})
+}
@@ -0,0 +1,79 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package redirect
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+// TestLegacy checks that the exported redirects are those Register serves.
+func TestLegacy(t *testing.T) {
+	mux := http.NewServeMux()
+	Register(mux)
+	list := Legacy()
+	if len(list) == 0 {
+		t.Fatal("Legacy() is empty")
+	}
+	for _, e := range list {
+		path, want := e.Path, e.Target
+		switch e.Match {
+		case MatchSubtree:
+			path += "below"
+		case MatchPrefix:
+			path += "123"
+			want += "123"
+		}
+		if e.KeepQuery {
+			path += "?q=1"
+			want += "?q=1"
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if e.Match == MatchSubtree && w.Header().Get("Location") != want {
+			// A longer path may take precedence below this one.
+			continue
+		}
+		if w.Code != e.Status || w.Header().Get("Location") != want {
+			t.Errorf("GET %s = %d %q, but exported %+v", path, w.Code, w.Header().Get("Location"), e)
+		}
+	}
+}
+
+func TestExportHandler(t *testing.T) {
+	var rules Rules
+	if err := rules.LoadFS(fstest.MapFS{"redirects.yaml": {Data: []byte("/old: /new?a=1&b=2\n")}}, "redirects.yaml", "/tour"); err != nil {
+		t.Fatal(err)
+	}
+	h := ExportHandler(func() []Entry {
+		return append(rules.Entries("go.dev"), Entry{Path: "/doc/mem", Match: MatchExact, Target: "/ref/mem", Status: 301, KeepQuery: true, Source: SourceLegacy})
+	})
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/_redirects", nil))
+	if w.Code != 200 || w.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("GET /_redirects: %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	var got struct{ Redirects []Entry }
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	want := []Entry{
+		{Path: "/doc/mem", Match: MatchExact, Target: "/ref/mem", Status: 301, KeepQuery: true, Source: SourceLegacy},
+		{Host: "go.dev", Path: "/tour/old", Match: MatchExact, Target: "/tour/new?a=1&b=2", Status: 301, KeepQuery: true, Source: SourceRules},
+	}
+	if !reflect.DeepEqual(got.Redirects, want) {
+		t.Errorf("GET /_redirects:\n%+v\nwant\n%+v", got.Redirects, want)
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("POST", "/_redirects", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST /_redirects: %d, want 405", w.Code)
+	}
+}
@@ -5,6 +5,9 @@
 // Package redirect provides hooks to register HTTP handlers that redirect old
 // godoc paths to their new equivalents and assist in accessing the issue
 // tracker, wiki, code review system, etc.
+//
+// The redirects can also be exported, with ExportHandler,
+// for proxies and CDNs to apply at the edge.
 package redirect // import "github.com/matttproud/yourtour/internal/redirect"
 
 import (
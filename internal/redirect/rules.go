@@ -0,0 +1,115 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package redirect
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Rules is a set of redirects loaded from a file,
+// which can be reloaded while the server is running.
+//
+// The file is YAML mapping request paths to redirect targets:
+//
+//	/doc/old-page: /doc/new-page
+//	/wiki/Gone: https://example.com/gone
+type Rules struct {
+	m atomic.Pointer[map[string]string]
+}
+
+// Load replaces the rules with those in the file at path.
+// If path is empty, the rules are cleared.
+// If the file is invalid, the rules are unchanged.
+func (r *Rules) Load(path string) error {
+	m := make(map[string]string)
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if m, err = parseRules(path, data, ""); err != nil {
+			return err
+		}
+	}
+	r.m.Store(&m)
+	return nil
+}
+
+// LoadFS replaces the rules with those in the file name in fsys,
+// for content served below the path prefix, such as "/tour":
+// the rules' paths are relative to prefix, so that /old: /new
+// redirects prefix/old to prefix/new.
+// If the file does not exist, the rules are cleared.
+// If the file is invalid, the rules are unchanged.
+func (r *Rules) LoadFS(fsys fs.FS, name, prefix string) error {
+	m := make(map[string]string)
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if err == nil {
+		if m, err = parseRules(name, data, strings.TrimSuffix(prefix, "/")); err != nil {
+			return err
+		}
+	}
+	r.m.Store(&m)
+	return nil
+}
+
+// parseRules parses the rules in data, read from file,
+// prefixing their paths with prefix.
+func parseRules(file string, data []byte, prefix string) (map[string]string, error) {
+	var m map[string]string
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	rules := make(map[string]string)
+	for from, to := range m {
+		if !strings.HasPrefix(from, "/") {
+			return nil, fmt.Errorf("%s: redirect source %q does not begin with /", file, from)
+		}
+		u, err := url.Parse(to)
+		if err != nil || to == "" || (u.Scheme == "" && !strings.HasPrefix(to, "/")) {
+			return nil, fmt.Errorf("%s: invalid redirect target %q for %s", file, to, from)
+		}
+		if u.Scheme == "" {
+			to = prefix + to
+		}
+		rules[prefix+from] = to
+	}
+	return rules, nil
+}
+
+// Len returns the number of rules.
+func (r *Rules) Len() int {
+	if m := r.m.Load(); m != nil {
+		return len(*m)
+	}
+	return 0
+}
+
+// Handler returns a handler that redirects requests for paths
+// with a rule, passing other requests to h.
+// Rules take precedence over h, so they can override its redirects.
+func (r *Rules) Handler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if m := r.m.Load(); m != nil {
+			if target, ok := (*m)[req.URL.Path]; ok {
+				Handler(target).ServeHTTP(w, req)
+				return
+			}
+		}
+		h.ServeHTTP(w, req)
+	})
+}
@@ -0,0 +1,99 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package redirect
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func TestRules(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, s string) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(s), 0666); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	var rules Rules
+	h := rules.Handler(next)
+	check := func(path string, code int, location string) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if w.Code != code || w.Header().Get("Location") != location {
+			t.Errorf("GET %s = %d %q, want %d %q", path, w.Code, w.Header().Get("Location"), code, location)
+		}
+	}
+
+	check("/old", http.StatusTeapot, "")
+
+	if err := rules.Load(write("a.yaml", "/old: /new\n/ext: https://example.com/x\n")); err != nil {
+		t.Fatal(err)
+	}
+	check("/old", http.StatusMovedPermanently, "/new")
+	check("/ext?q=1", http.StatusMovedPermanently, "https://example.com/x?q=1")
+	check("/other", http.StatusTeapot, "")
+
+	for _, bad := range []string{"old: /new\n", "/old: new\n", "/old: ''\n", "- /old\n"} {
+		if err := rules.Load(write("bad.yaml", bad)); err == nil {
+			t.Errorf("Load(%q) succeeded", bad)
+		}
+	}
+	check("/old", http.StatusMovedPermanently, "/new")
+
+	if err := rules.Load(""); err != nil {
+		t.Fatal(err)
+	}
+	if n := rules.Len(); n != 0 {
+		t.Errorf("after Load(\"\"), Len() = %d, want 0", n)
+	}
+	check("/old", http.StatusTeapot, "")
+}
+
+func TestRulesFS(t *testing.T) {
+	var rules Rules
+	h := rules.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	fsys := fstest.MapFS{"redirects.yaml": {Data: []byte("/old: /new\n/ext: https://example.com/x\n")}}
+	if err := rules.LoadFS(fsys, "redirects.yaml", "/tour/"); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		path     string
+		code     int
+		location string
+	}{
+		{"/tour/old", http.StatusMovedPermanently, "/tour/new"},
+		{"/tour/ext", http.StatusMovedPermanently, "https://example.com/x"},
+		{"/old", http.StatusTeapot, ""},
+	} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+		if w.Code != tt.code || w.Header().Get("Location") != tt.location {
+			t.Errorf("GET %s = %d %q, want %d %q", tt.path, w.Code, w.Header().Get("Location"), tt.code, tt.location)
+		}
+	}
+
+	if err := rules.LoadFS(fstest.MapFS{"redirects.yaml": {Data: []byte("old: /new\n")}}, "redirects.yaml", ""); err == nil {
+		t.Errorf("LoadFS of invalid rules succeeded")
+	}
+	if n := rules.Len(); n != 2 {
+		t.Errorf("after invalid LoadFS, Len() = %d, want 2", n)
+	}
+	if err := rules.LoadFS(fstest.MapFS{}, "redirects.yaml", ""); err != nil || rules.Len() != 0 {
+		t.Errorf("LoadFS without file = %v, Len() = %d, want nil, 0", err, rules.Len())
+	}
+}
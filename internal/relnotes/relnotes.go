@@ -0,0 +1,201 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package relnotes serves release notes merged from Markdown fragments.
+//
+// The notes for each major release are written as one fragment per change
+// or topic, kept in a directory named for the release under doc/relnotes,
+// such as doc/relnotes/go1.26, and laid out as for the relnote command:
+//
+//	doc/relnotes/go1.26/1-intro.md
+//	doc/relnotes/go1.26/3-tools.md
+//	doc/relnotes/go1.26/6-stdlib/99-minor/net/http/12345.md
+//
+// The fragments are merged in lexicographic order by file name
+// and served as the page /doc/relnotes/go1.26, which links to the
+// release's entries on the download page. The page /doc/relnotes/
+// lists the releases with notes, newest first.
+package relnotes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go/version"
+	"io/fs"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/matttproud/yourtour/internal/vhost"
+	"github.com/matttproud/yourtour/internal/web"
+	"golang.org/x/build/relnote"
+	"rsc.io/markdown"
+)
+
+// dir is the directory holding the release note fragments.
+const dir = "doc/relnotes"
+
+// RegisterHandlers registers handlers serving the release notes
+// in h's file system on h.
+// The downloads function returns the versions listed on the download page,
+// such as go1.26.0 and go1.27rc1, to which the notes link.
+func RegisterHandlers(h *vhost.Host, downloads func(context.Context) ([]string, error)) {
+	s := &server{site: h.Site, fsys: h.FS, downloads: downloads}
+	h.Router.HandleFunc("GET", "/"+dir+"/", s.serveHTTP)
+}
+
+type server struct {
+	site      *web.Site
+	fsys      fs.FS
+	downloads func(context.Context) ([]string, error)
+}
+
+func (s *server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	v := strings.TrimPrefix(r.URL.Path, "/"+dir+"/")
+	if v == "" {
+		s.serveIndex(w, r)
+		return
+	}
+	if !isMajor(v) {
+		s.site.ServeErrorStatus(w, r, fmt.Errorf("no release notes for %q", v), http.StatusNotFound)
+		return
+	}
+	notes, err := s.merge(v)
+	if errors.Is(err, fs.ErrNotExist) {
+		s.site.ServeErrorStatus(w, r, fmt.Errorf("no release notes for %s", v), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		s.site.ServeError(w, r, err)
+		return
+	}
+	dl, err := s.downloadsOf(r.Context())
+	if err != nil {
+		s.site.ServeError(w, r, err)
+		return
+	}
+
+	var data strings.Builder
+	if list := dl[v]; len(list) > 0 {
+		data.WriteString("Download:")
+		for i, d := range list {
+			if i > 0 {
+				data.WriteString(",")
+			}
+			fmt.Fprintf(&data, " [%s](/dl/#%s)", d, d)
+		}
+		data.WriteString("\n\n")
+	} else {
+		fmt.Fprintf(&data, "%s is not yet released.\n\n", title(v))
+	}
+	data.WriteString(notes)
+
+	s.site.ServePage(w, r, web.Page{
+		"title":    title(v) + " Release Notes",
+		"File":     path.Join(dir, v+".md"),
+		"FileData": data.String(),
+		"layout":   "/doc/default",
+		"template": false,
+		"version":  v,
+	})
+}
+
+// serveIndex serves the list of releases with notes.
+func (s *server) serveIndex(w http.ResponseWriter, r *http.Request) {
+	versions, err := s.versions()
+	if err != nil {
+		s.site.ServeError(w, r, err)
+		return
+	}
+	dl, err := s.downloadsOf(r.Context())
+	if err != nil {
+		s.site.ServeError(w, r, err)
+		return
+	}
+
+	var data strings.Builder
+	if len(versions) == 0 {
+		data.WriteString("No release notes available.\n")
+	}
+	for _, v := range versions {
+		fmt.Fprintf(&data, "- [%s](/%s/%s)", title(v), dir, v)
+		if list := dl[v]; len(list) > 0 {
+			fmt.Fprintf(&data, " ([%s](/dl/#%s))", list[0], list[0])
+		} else {
+			data.WriteString(" (not yet released)")
+		}
+		data.WriteString("\n")
+	}
+
+	s.site.ServePage(w, r, web.Page{
+		"title":    "Release Notes",
+		"File":     path.Join(dir, "index.md"),
+		"FileData": data.String(),
+		"layout":   "/doc/default",
+		"template": false,
+	})
+}
+
+// merge returns the Markdown merged from the fragments for the release v.
+func (s *server) merge(v string) (string, error) {
+	sub, err := fs.Sub(s.fsys, path.Join(dir, v))
+	if err != nil {
+		return "", err
+	}
+	if _, err := fs.Stat(sub, "."); err != nil {
+		return "", err
+	}
+	doc, err := relnote.Merge(sub)
+	if err != nil {
+		return "", fmt.Errorf("%s: relnote.Merge: %v", v, err)
+	}
+	return markdown.ToMarkdown(doc), nil
+}
+
+// versions returns the releases with notes, newest first.
+func (s *server) versions() ([]string, error) {
+	files, err := fs.ReadDir(s.fsys, dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var list []string
+	for _, f := range files {
+		if f.IsDir() && isMajor(f.Name()) {
+			list = append(list, f.Name())
+		}
+	}
+	slices.SortFunc(list, func(x, y string) int { return version.Compare(y, x) })
+	return list, nil
+}
+
+// downloadsOf returns the versions on the download page,
+// grouped by the major release they belong to, in the order listed.
+func (s *server) downloadsOf(ctx context.Context) (map[string][]string, error) {
+	list, err := s.downloads(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing downloads: %v", err)
+	}
+	dl := make(map[string][]string)
+	for _, d := range list {
+		if v := version.Lang(d); v != "" {
+			dl[v] = append(dl[v], d)
+		}
+	}
+	return dl, nil
+}
+
+// isMajor reports whether v names a major release, such as go1.26.
+func isMajor(v string) bool {
+	return version.IsValid(v) && version.Lang(v) == v
+}
+
+// title returns the name of the release v as written in text, such as “Go 1.26”.
+func title(v string) string {
+	return "Go " + strings.TrimPrefix(v, "go")
+}
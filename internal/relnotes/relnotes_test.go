@@ -0,0 +1,99 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package relnotes
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/matttproud/yourtour/internal/router"
+	"github.com/matttproud/yourtour/internal/vhost"
+	"github.com/matttproud/yourtour/internal/web"
+)
+
+var testFS = fstest.MapFS{
+	"site.tmpl":                      {Data: []byte(`{{.title}}|{{.Content}}`)},
+	"doc/default.tmpl":               {Data: []byte(`{{define "layout"}}{{.Content}}{{end}}`)},
+	"error.tmpl":                     {Data: []byte(`{{define "layout"}}{{.error}}{{end}}`)},
+	"doc/relnotes/go1.9":             {Mode: fs.ModeDir}, // no fragments
+	"doc/relnotes/go1.10/1-intro.md": {Data: []byte("## Introduction to Go 1.10\n\nHello.\n")},
+	"doc/relnotes/go1.10/6-stdlib/99-minor/0-heading.md": {Data: []byte("### Minor changes\n")},
+	"doc/relnotes/go1.10/6-stdlib/99-minor/io/1.md":      {Data: []byte("[Reader] is new.\n")},
+	"doc/relnotes/go1.11/1-intro.md":                     {Data: []byte("Coming soon.\n")},
+	"doc/relnotes/notes.txt":                             {Data: []byte("not a release")},
+}
+
+func serve(t *testing.T, downloads func(context.Context) ([]string, error), path string) (int, string) {
+	t.Helper()
+	mux := http.NewServeMux()
+	var reg vhost.Registry
+	h := reg.Add("", web.NewSite(testFS), testFS, router.New(mux))
+	RegisterHandlers(h, downloads)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+	return w.Code, w.Body.String()
+}
+
+func testDownloads(context.Context) ([]string, error) {
+	return []string{"go1.11rc1", "go1.10.1", "go1.10", "go1.9.7"}, nil
+}
+
+func TestServe(t *testing.T) {
+	for _, tt := range []struct {
+		path string
+		code int
+		want []string
+	}{
+		{"/doc/relnotes/", 200, []string{
+			"Release Notes|",
+			`<li><a href="/doc/relnotes/go1.11">Go 1.11</a> (<a href="/dl/#go1.11rc1">go1.11rc1</a>)</li>
+<li><a href="/doc/relnotes/go1.10">Go 1.10</a> (<a href="/dl/#go1.10.1">go1.10.1</a>)</li>
+<li><a href="/doc/relnotes/go1.9">Go 1.9</a> (<a href="/dl/#go1.9.7">go1.9.7</a>)</li>`,
+		}},
+		{"/doc/relnotes/go1.10", 200, []string{
+			"Go 1.10 Release Notes|",
+			`<p>Download: <a href="/dl/#go1.10.1">go1.10.1</a>, <a href="/dl/#go1.10">go1.10</a></p>
+<h2 id="introduction-to-go-110">Introduction to Go 1.10</h2>`,
+			`<h4 id="iopkgio"><a href="/pkg/io/"><code>io</code></a></h4>`,
+			`<a href="/pkg/io#Reader"><code>Reader</code></a> is new.`,
+		}},
+		{"/doc/relnotes/go1.11", 200, []string{`<a href="/dl/#go1.11rc1">go1.11rc1</a>`, "Coming soon."}},
+		{"/doc/relnotes/go1.12", 404, nil},
+		{"/doc/relnotes/go1.10.1", 404, nil},
+		{"/doc/relnotes/go1.10/1-intro", 404, nil},
+		{"/doc/relnotes/notes.txt", 404, nil},
+	} {
+		code, body := serve(t, testDownloads, tt.path)
+		if code != tt.code {
+			t.Errorf("GET %s: %d, want %d\n%s", tt.path, code, tt.code, body)
+			continue
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(body, want) {
+				t.Errorf("GET %s: missing %s\n%s", tt.path, want, body)
+			}
+		}
+	}
+}
+
+func TestServeUnreleased(t *testing.T) {
+	none := func(context.Context) ([]string, error) { return nil, nil }
+	if _, body := serve(t, none, "/doc/relnotes/go1.10"); !strings.Contains(body, "Go 1.10 is not yet released.") {
+		t.Errorf("GET /doc/relnotes/go1.10 without downloads:\n%s", body)
+	}
+	if _, body := serve(t, none, "/doc/relnotes/"); !strings.Contains(body, "Go 1.10</a> (not yet released)") {
+		t.Errorf("GET /doc/relnotes/ without downloads:\n%s", body)
+	}
+	failing := func(context.Context) ([]string, error) { return nil, errors.New("datastore down") }
+	if code, _ := serve(t, failing, "/doc/relnotes/go1.10"); code != 500 {
+		t.Errorf("GET /doc/relnotes/go1.10 with failing downloads: %d, want 500", code)
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package reqlog assigns each HTTP request an ID and a structured logger
+// tagged with it, so that an error shown to a user can be matched
+// with the server logs for the request.
+//
+// Handler assigns the ID; code serving the request logs with
+// Logger(ctx) and can show ID(ctx) to the user.
+package reqlog
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+)
+
+// Header is the HTTP header carrying the request ID,
+// both in requests from proxies that assign IDs and in responses.
+const Header = "X-Request-ID"
+
+type contextKey struct{}
+
+type requestInfo struct {
+	id     string
+	logger *slog.Logger
+}
+
+// Handler returns a handler that assigns each request an ID,
+// reusing a well-formed ID from the request's X-Request-ID header
+// or generating a new one, and serves the request with h.
+// The ID is returned in the X-Request-ID response header,
+// and the request context carries it and a logger tagged with it.
+func Handler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(Header)
+		if !validID(id) {
+			id = newID()
+		}
+		w.Header().Set(Header, id)
+		logger := slog.Default().With("request_id", id, "method", r.Method, "path", r.URL.Path)
+		ctx := NewContext(r.Context(), id, logger)
+		h.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// NewContext returns a copy of ctx carrying the request ID id and logger.
+func NewContext(ctx context.Context, id string, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, &requestInfo{id, logger})
+}
+
+// ID returns the ID of the request with context ctx,
+// or the empty string if ctx does not come from Handler.
+func ID(ctx context.Context) string {
+	if info, ok := ctx.Value(contextKey{}).(*requestInfo); ok {
+		return info.id
+	}
+	return ""
+}
+
+// Logger returns the logger for the request with context ctx,
+// or the default logger if ctx does not come from Handler.
+func Logger(ctx context.Context) *slog.Logger {
+	if info, ok := ctx.Value(contextKey{}).(*requestInfo); ok {
+		return info.logger
+	}
+	return slog.Default()
+}
+
+// validID reports whether id is acceptable as a request ID
+// provided by a client or proxy: short, and limited to
+// characters that are safe to copy into logs and pages.
+func validID(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+	for _, c := range id {
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '-' || c == '_' || c == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+// newID returns a new random request ID.
+func newID() string {
+	var b [12]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// Error replies to the request with the plain-text error message msg
+// and HTTP status code, as http.Error does,
+// adding the request ID so that users can report it.
+func Error(w http.ResponseWriter, r *http.Request, msg string, code int) {
+	if id := ID(r.Context()); id != "" {
+		msg += " (request ID " + id + ")"
+	}
+	http.Error(w, msg, code)
+}
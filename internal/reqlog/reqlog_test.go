@@ -0,0 +1,66 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reqlog
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+	var buf bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, nil)))
+
+	var gotID string
+	h := Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotID = ID(r.Context())
+		Logger(r.Context()).Error("something broke")
+	}))
+
+	var tests = []struct {
+		header string
+		keep   bool
+	}{
+		{"", false},
+		{"abc-123_x.y", true},
+		{"bad id\nwith newline", false},
+		{strings.Repeat("a", 65), false},
+	}
+	for _, tt := range tests {
+		buf.Reset()
+		r := httptest.NewRequest("GET", "/doc/", nil)
+		if tt.header != "" {
+			r.Header.Set(Header, tt.header)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		id := w.Header().Get(Header)
+		if id == "" || id != gotID {
+			t.Errorf("header %q: response ID %q, context ID %q", tt.header, id, gotID)
+		}
+		if (id == tt.header) != tt.keep {
+			t.Errorf("header %q: got ID %q, want kept=%v", tt.header, id, tt.keep)
+		}
+		if log := buf.String(); !strings.Contains(log, "request_id="+id) || !strings.Contains(log, "path=/doc/") {
+			t.Errorf("header %q: log %q does not mention request ID %s and path", tt.header, log, id)
+		}
+	}
+}
+
+func TestNoRequest(t *testing.T) {
+	ctx := context.Background()
+	if id := ID(ctx); id != "" {
+		t.Errorf("ID(Background) = %q, want empty", id)
+	}
+	if Logger(ctx) != slog.Default() {
+		t.Errorf("Logger(Background) is not the default logger")
+	}
+}
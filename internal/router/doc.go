@@ -0,0 +1,96 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package router
+
+import (
+	"cmp"
+	"encoding/json"
+	"slices"
+	"strings"
+)
+
+// A Doc documents an endpoint of the site's API,
+// for listing on its API page (see package apidoc).
+type Doc struct {
+	Summary string  // one sentence saying what the endpoint does
+	Params  []Param // query or form parameters, in the order listed
+
+	// Example is an example response, listed encoded as JSON.
+	// It is usually a value of the type the handler replies with,
+	// so that the example follows changes to the type.
+	// Endpoints replying with something other than JSON leave it nil.
+	Example any
+}
+
+// A Param documents a parameter of an endpoint.
+type Param struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+// An Endpoint is a documented route.
+type Endpoint struct {
+	Method  string          `json:"method"`
+	Host    string          `json:"host,omitempty"` // empty for any host
+	Path    string          `json:"path"`
+	Summary string          `json:"summary"`
+	Params  []Param         `json:"params,omitempty"`
+	Example json.RawMessage `json:"example,omitempty"`
+}
+
+// Document records d as the documentation of the handler for method
+// and path on rt's host and below its prefix, which must already be registered,
+// so that subsystems document their endpoints as they register them:
+//
+//	r.HandleFunc("GET", "/dl/", s.getHandler)
+//	r.Document("GET", "/dl/", router.Doc{Summary: "Lists the releases.", ...})
+//
+// Document panics if the handler is not registered,
+// if it is already documented, or if d.Example cannot be encoded.
+func (rt *Router) Document(method, path string, d Doc) {
+	method = strings.ToUpper(method)
+	path = rt.prefix + path
+	pattern := rt.host + path
+	e := Endpoint{Method: method, Host: rt.host, Path: path, Summary: d.Summary, Params: d.Params}
+	if d.Example != nil {
+		js, err := json.MarshalIndent(d.Example, "", "  ")
+		if err != nil {
+			panic("router: encoding example for " + method + " " + pattern + ": " + err.Error())
+		}
+		e.Example = js
+	}
+
+	rt.reg.mu.Lock()
+	defer rt.reg.mu.Unlock()
+	r := rt.reg.routes[pattern]
+	if r == nil || r.handlers[method] == nil {
+		panic("router: documenting unregistered " + method + " " + pattern)
+	}
+	if r.docs == nil {
+		r.docs = make(map[string]*Endpoint)
+	}
+	if r.docs[method] != nil {
+		panic("router: multiple docs for " + method + " " + pattern)
+	}
+	r.docs[method] = &e
+}
+
+// Endpoints returns the endpoints documented in rt's mux
+// by rt and the Routers sharing its mux, sorted by path, host, and method.
+func (rt *Router) Endpoints() []Endpoint {
+	rt.reg.mu.Lock()
+	defer rt.reg.mu.Unlock()
+	var list []Endpoint
+	for _, r := range rt.reg.routes {
+		for _, e := range r.docs {
+			list = append(list, *e)
+		}
+	}
+	slices.SortFunc(list, func(a, b Endpoint) int {
+		return cmp.Or(strings.Compare(a.Path, b.Path), strings.Compare(a.Host, b.Host), strings.Compare(a.Method, b.Method))
+	})
+	return list
+}
@@ -0,0 +1,71 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package router
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestDocument(t *testing.T) {
+	r := New(http.NewServeMux())
+	r.HandleFunc("GET", "/dl/", reply("list"))
+	r.HandleFunc("POST", "/dl/upload", reply("upload"))
+	r.HandleFunc("GET", "/search", reply("search"))
+	cn := r.Host("golang.google.cn")
+	cn.HandleFunc("GET", "/dl/", reply("china list"))
+
+	r.Document("get", "/dl/", Doc{
+		Summary: "Lists releases.",
+		Params:  []Param{{Name: "mode", Description: "json", Required: true}},
+		Example: []map[string]string{{"version": "go1.22.0"}},
+	})
+	r.Document("GET", "/search", Doc{Summary: "Searches."})
+	cn.Document("GET", "/dl/", Doc{Summary: "Lists releases in China."})
+
+	want := []Endpoint{
+		{Method: "GET", Path: "/dl/", Summary: "Lists releases.",
+			Params:  []Param{{Name: "mode", Description: "json", Required: true}},
+			Example: []byte("[\n  {\n    \"version\": \"go1.22.0\"\n  }\n]")},
+		{Method: "GET", Host: "golang.google.cn", Path: "/dl/", Summary: "Lists releases in China."},
+		{Method: "GET", Path: "/search", Summary: "Searches."},
+	}
+	// Routers sharing a mux list the same endpoints.
+	for _, rt := range []*Router{r, cn, r.With(tag("a"))} {
+		if got := rt.Endpoints(); !reflect.DeepEqual(got, want) {
+			t.Errorf("Endpoints() = %+v, want %+v", got, want)
+		}
+	}
+	if got := New(http.NewServeMux()).Endpoints(); got != nil {
+		t.Errorf("Endpoints() of new Router = %+v, want nil", got)
+	}
+}
+
+func TestDocumentPanics(t *testing.T) {
+	r := New(http.NewServeMux())
+	r.HandleFunc("GET", "/dl/", reply("list"))
+	r.Document("GET", "/dl/", Doc{Summary: "Lists releases."})
+	for _, tt := range []struct {
+		name         string
+		method, path string
+		doc          Doc
+	}{
+		{"unregistered path", "GET", "/elsewhere", Doc{}},
+		{"unregistered method", "POST", "/dl/", Doc{}},
+		{"other host", "GET", "golang.google.cn/dl/", Doc{}},
+		{"duplicate", "GET", "/dl/", Doc{}},
+		{"bad example", "GET", "/dl/", Doc{Example: func() {}}},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: Document(%q, %q) did not panic", tt.name, tt.method, tt.path)
+				}
+			}()
+			r.Document(tt.method, tt.path, tt.doc)
+		}()
+	}
+}
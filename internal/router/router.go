@@ -0,0 +1,159 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package router registers HTTP handlers by method, host, and path,
+// wrapping them in middleware, on top of an http.ServeMux.
+//
+// A path registered with some methods answers requests using other
+// methods with 405 Method Not Allowed and an Allow header listing
+// the methods it does support, so handlers need not check r.Method.
+// A handler registered for GET also serves HEAD.
+// Handlers serving an API can be documented with Document,
+// for the site's API page to list.
+//
+// Typical use in a subsystem's RegisterHandlers function is:
+//
+//	r.HandleFunc("GET", "/dl/", s.getHandler)
+//	r.HandleFunc("POST", "/dl/upload", s.uploadHandler)
+//
+// where the caller chooses the host and middleware,
+// usually through a virtual host (see package vhost):
+//
+//	rt := router.New(mux).With(mw)
+//	dl.RegisterHandlers(vhosts.Add("golang.google.cn", site, fsys, rt), ...)
+package router
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// A Middleware wraps a handler in another that adds to its behavior.
+type Middleware func(http.Handler) http.Handler
+
+// A Router registers handlers in an http.ServeMux.
+// The zero Router is not usable; use New.
+type Router struct {
+	reg    *registry
+	host   string
+	prefix string
+	mw     []Middleware
+}
+
+// A registry records the routes registered in a mux
+// by a Router and the Routers derived from it.
+type registry struct {
+	mux *http.ServeMux
+
+	mu     sync.Mutex
+	routes map[string]*route // by mux pattern
+}
+
+// New returns a Router registering handlers in mux
+// for requests to any host, with no middleware.
+func New(mux *http.ServeMux) *Router {
+	return &Router{reg: &registry{mux: mux, routes: make(map[string]*route)}}
+}
+
+// Host returns a Router that registers handlers in the same mux
+// for requests to host only, or to any host if host is empty.
+func (rt *Router) Host(host string) *Router {
+	return &Router{reg: rt.reg, host: host, prefix: rt.prefix, mw: rt.mw}
+}
+
+// Prefix returns a Router that registers handlers in the same mux
+// for paths below prefix, such as "/tour", on rt's host:
+// a handler registered for "/" serves "/tour/" and below.
+func (rt *Router) Prefix(prefix string) *Router {
+	return &Router{reg: rt.reg, host: rt.host, prefix: rt.prefix + strings.TrimSuffix(prefix, "/"), mw: rt.mw}
+}
+
+// With returns a Router that registers handlers in the same mux,
+// wrapped in the middleware mw in addition to rt's.
+// The first middleware listed is the outermost.
+func (rt *Router) With(mw ...Middleware) *Router {
+	return &Router{reg: rt.reg, host: rt.host, prefix: rt.prefix, mw: append(slices.Clip(rt.mw), mw...)}
+}
+
+// Handle registers h to serve requests with the given method for path,
+// which is a path pattern as for http.ServeMux, such as "/dl/".
+// If method is empty, h serves every method not registered separately.
+// Handle panics if a handler is already registered for method and path,
+// or if path conflicts with a pattern registered in the mux directly.
+func (rt *Router) Handle(method, path string, h http.Handler) {
+	for i := len(rt.mw) - 1; i >= 0; i-- {
+		h = rt.mw[i](h)
+	}
+	method = strings.ToUpper(method)
+	pattern := rt.host + rt.prefix + path
+
+	rt.reg.mu.Lock()
+	defer rt.reg.mu.Unlock()
+	r := rt.reg.routes[pattern]
+	if r == nil {
+		r = &route{handlers: make(map[string]http.Handler)}
+		rt.reg.routes[pattern] = r
+		rt.reg.mux.Handle(pattern, r)
+	}
+	r.add(pattern, method, h)
+}
+
+// HandleFunc registers f to serve requests with the given method for path,
+// as for Handle.
+func (rt *Router) HandleFunc(method, path string, f func(http.ResponseWriter, *http.Request)) {
+	rt.Handle(method, path, http.HandlerFunc(f))
+}
+
+// A route dispatches the requests for one pattern by method.
+type route struct {
+	mu       sync.RWMutex
+	handlers map[string]http.Handler // by method; "" for any other
+	allow    string                  // value of the Allow header
+	docs     map[string]*Endpoint    // by method; guarded by the registry's mu
+}
+
+func (r *route) add(pattern, method string, h http.Handler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.handlers[method]; ok {
+		if method == "" {
+			method = "any method"
+		}
+		panic("router: multiple registrations for " + method + " " + pattern)
+	}
+	r.handlers[method] = h
+
+	var methods []string
+	for m := range r.handlers {
+		if m != "" {
+			methods = append(methods, m)
+		}
+	}
+	if r.handlers["GET"] != nil && r.handlers["HEAD"] == nil {
+		methods = append(methods, "HEAD")
+	}
+	slices.Sort(methods)
+	r.allow = strings.Join(methods, ", ")
+}
+
+func (r *route) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.RLock()
+	h := r.handlers[req.Method]
+	if h == nil && req.Method == "HEAD" {
+		h = r.handlers["GET"]
+	}
+	if h == nil {
+		h = r.handlers[""]
+	}
+	allow := r.allow
+	r.mu.RUnlock()
+	if h == nil {
+		w.Header().Set("Allow", allow)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	h.ServeHTTP(w, req)
+}
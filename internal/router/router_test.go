@@ -0,0 +1,135 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package router
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func reply(s string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, s)
+	}
+}
+
+// tag returns middleware appending s to the X-Tags response header.
+func tag(s string) Middleware {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("X-Tags", s)
+			h.ServeHTTP(w, r)
+		})
+	}
+}
+
+func TestRouter(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("/", reply("fallback"))
+	r := New(mux)
+	r.HandleFunc("GET", "/dl/", reply("list"))
+	r.HandleFunc("options", "/dl/", reply("cors"))
+	r.HandleFunc("POST", "/dl/upload", reply("upload"))
+	r.Host("golang.google.cn").HandleFunc("GET", "/dl/", reply("china list"))
+	r.HandleFunc("", "/any", reply("any"))
+	r.HandleFunc("DELETE", "/any", reply("delete"))
+	r.With(tag("a"), tag("b")).With(tag("c")).HandleFunc("GET", "/tagged", reply("tagged"))
+
+	tests := []struct {
+		method, url string
+		code        int
+		body        string
+		allow       string
+	}{
+		{"GET", "https://go.dev/dl/", 200, "list", ""},
+		{"HEAD", "https://go.dev/dl/", 200, "list", ""}, // the server discards the body
+		{"OPTIONS", "https://go.dev/dl/", 200, "cors", ""},
+		{"POST", "https://go.dev/dl/", 405, "method not allowed\n", "GET, HEAD, OPTIONS"},
+		{"GET", "https://golang.google.cn/dl/", 200, "china list", ""},
+		{"POST", "https://golang.google.cn/dl/", 405, "method not allowed\n", "GET, HEAD"},
+		{"POST", "https://go.dev/dl/upload", 200, "upload", ""},
+		{"GET", "https://go.dev/dl/upload", 405, "method not allowed\n", "POST"},
+		{"GET", "https://go.dev/any", 200, "any", ""},
+		{"PATCH", "https://go.dev/any", 200, "any", ""},
+		{"DELETE", "https://go.dev/any", 200, "delete", ""},
+		{"GET", "https://go.dev/elsewhere", 200, "fallback", ""},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(tt.method, tt.url, nil))
+		if w.Code != tt.code || w.Body.String() != tt.body || w.Header().Get("Allow") != tt.allow {
+			t.Errorf("%s %s: %d %q Allow: %q, want %d %q Allow: %q",
+				tt.method, tt.url, w.Code, w.Body, w.Header().Get("Allow"), tt.code, tt.body, tt.allow)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/tagged", nil))
+	if tags := strings.Join(w.Header().Values("X-Tags"), ","); tags != "a,b,c" {
+		t.Errorf("middleware ran in order %q, want a,b,c", tags)
+	}
+}
+
+func TestPrefix(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("/", reply("fallback"))
+	tour := New(mux).Prefix("/tour/")
+	tour.HandleFunc("GET", "/", reply("tour"))
+	tour.HandleFunc("GET", "/search", reply("tour search"))
+	tour.Host("tour.example.com").HandleFunc("GET", "/", reply("tour host"))
+	tour.Prefix("/old").HandleFunc("GET", "/", reply("old tour"))
+	tour.Document("GET", "/search", Doc{Summary: "Searches the tour."})
+
+	for url, want := range map[string]string{
+		"https://go.dev/tour/":                "tour",
+		"https://go.dev/tour/welcome/1":       "tour",
+		"https://go.dev/tour/search":          "tour search",
+		"https://go.dev/tour/old/":            "old tour",
+		"https://tour.example.com/tour/":      "tour host",
+		"https://go.dev/search":               "fallback",
+		"https://go.dev/tours":                "fallback",
+		"https://tour.example.com/":           "fallback",
+		"https://tour.example.com/tour/other": "tour host",
+	} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", url, nil))
+		if w.Body.String() != want {
+			t.Errorf("GET %s = %q, want %q", url, w.Body, want)
+		}
+	}
+	if eps := tour.Endpoints(); len(eps) != 1 || eps[0].Path != "/tour/search" {
+		t.Errorf("Endpoints() = %+v, want /tour/search", eps)
+	}
+}
+
+func TestWithDoesNotShareMiddleware(t *testing.T) {
+	mux := http.NewServeMux()
+	base := New(mux).With(tag("base"))
+	a := base.With(tag("a"))
+	b := base.With(tag("b"))
+	a.HandleFunc("GET", "/a", reply("a"))
+	b.HandleFunc("GET", "/b", reply("b"))
+	for path, want := range map[string]string{"/a": "base,a", "/b": "base,b"} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if tags := strings.Join(w.Header().Values("X-Tags"), ","); tags != want {
+			t.Errorf("GET %s: middleware %q, want %q", path, tags, want)
+		}
+	}
+}
+
+func TestDuplicate(t *testing.T) {
+	r := New(http.NewServeMux())
+	r.HandleFunc("GET", "/x", reply("x"))
+	defer func() {
+		if recover() == nil {
+			t.Errorf("duplicate registration did not panic")
+		}
+	}()
+	r.HandleFunc("get", "/x", reply("x"))
+}
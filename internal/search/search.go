@@ -0,0 +1,433 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package search serves a search of a site's pages, at /search?q=,
+// together with an OpenSearch description, at /opensearch.xml,
+// with which browsers offer the search in their address bars.
+//
+// The search index holds the titled pages of the site, so that untitled
+// fragments and partials are not found, leaving out its private pages.
+// It is rebuilt periodically, so that it finds pages added by content deploys.
+// A rebuild indexes again only the files changed since the last one;
+// the others, unchanged in size and modification time or, lacking one,
+// in hash, are reused. When env.Config.SearchIndexDir is set, the index
+// is saved there, so that a restarted server reuses it too.
+package search
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/matttproud/yourtour/internal/analytics"
+	"github.com/matttproud/yourtour/internal/env"
+	"github.com/matttproud/yourtour/internal/router"
+	"github.com/matttproud/yourtour/internal/vhost"
+	"github.com/matttproud/yourtour/internal/web"
+)
+
+// indexTTL is how long a site's search index is reused.
+const indexTTL = 10 * time.Minute
+
+// maxResults is the most results shown for a query.
+const maxResults = 50
+
+// maxSuggest is the most suggestions returned to a browser.
+const maxSuggest = 8
+
+// maxQuery is the longest query searched for.
+const maxQuery = 200
+
+// RegisterHandlers registers handlers on h
+// serving the search of the pages in h's file system.
+func RegisterHandlers(h *vhost.Host) {
+	s := &server{site: h.Site, fsys: h.FS}
+	if dir := env.Get().SearchIndexDir; dir != "" {
+		s.file = filepath.Join(dir, indexFile(h.Name, h.Prefix))
+	}
+	h.Router.HandleFunc("GET", "/search", s.searchHandler)
+	h.Router.HandleFunc("GET", "/opensearch.xml", s.descriptionHandler)
+	h.Router.Document("GET", "/search", router.Doc{
+		Summary: "Suggests the pages best matching a query, as OpenSearch suggestions, with ?format=suggest; otherwise serves the search results page.",
+		Params: []router.Param{
+			{Name: "q", Description: "the words to search for", Required: true},
+			{Name: "format", Description: "suggest to list the suggestions in JSON"},
+		},
+		Example: []any{"install", []string{"Download and install"}, []string{"Download and install Go quickly."}, []string{"https://go.dev/doc/install"}},
+	})
+	h.Router.Document("GET", "/opensearch.xml", router.Doc{
+		Summary: "Describes the site's search in OpenSearch description XML, for browsers to offer it.",
+	})
+}
+
+type server struct {
+	site *web.Site
+	fsys fs.FS
+	file string // file saving the index, if any
+
+	mu      sync.Mutex
+	idx     []*doc
+	files   map[string]*entry // by file name; nil until loaded or built
+	expires time.Time
+}
+
+// A doc is a page in the search index.
+type doc struct {
+	URL     string
+	Title   string
+	Summary string
+
+	title string // lower-case title
+	text  string // lower-case text of the page, without markup
+}
+
+// A result is a page found by a search.
+type result struct {
+	*doc
+	score int
+}
+
+// indexVersion is the version of the saved index format.
+// Increment it if entry changes or pages are indexed differently.
+const indexVersion = 1
+
+// A savedIndex is the search index as saved to its file.
+type savedIndex struct {
+	Version int               `json:"version"`
+	Files   map[string]*entry `json:"files"`
+}
+
+// An entry is the index's record of a content file,
+// reused while the file is unchanged.
+type entry struct {
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+	SHA256  string    `json:"sha256"`
+
+	// The page in the file, if it is one to index.
+	// URL is empty if it is not.
+	URL     string `json:"url,omitempty"`
+	Title   string `json:"title,omitempty"`
+	Summary string `json:"summary,omitempty"`
+	Text    string `json:"text,omitempty"` // lower-case text, without markup
+}
+
+// indexFile returns the name of the file saving the search index
+// of the host name mounted below prefix.
+func indexFile(name, prefix string) string {
+	return strings.NewReplacer("/", "_", ":", "_").Replace(cmp.Or(name, "default")+prefix) + ".json"
+}
+
+// index returns the site's search index,
+// updating it if it is missing or expired.
+func (s *server) index() ([]*doc, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.idx != nil && time.Now().Before(s.expires) {
+		return s.idx, nil
+	}
+	if s.files == nil && s.file != "" {
+		s.files = s.load()
+	}
+	files := make(map[string]*entry)
+	changed := false
+	err := fs.WalkDir(s.fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || (path.Ext(name) != ".md" && path.Ext(name) != ".html") {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			// Listed but hidden, as the root Markdown files of GOROOT are.
+			return nil
+		}
+		old := s.files[name]
+		e := old
+		if old == nil || info.ModTime().IsZero() || !info.ModTime().Equal(old.ModTime) || info.Size() != old.Size {
+			data, err := fs.ReadFile(s.fsys, name)
+			if err != nil {
+				return nil // as for d.Info
+			}
+			sum := fmt.Sprintf("%x", sha256.Sum256(data))
+			if old == nil || old.SHA256 != sum {
+				e = s.indexPage(name)
+				e.SHA256 = sum
+			} else {
+				// Touched but unchanged.
+				c := *old
+				e = &c
+			}
+			e.Size, e.ModTime = info.Size(), info.ModTime()
+			changed = changed || old == nil || *e != *old
+		}
+		files[name] = e
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	changed = changed || len(files) != len(s.files)
+
+	var idx []*doc
+	for _, e := range files {
+		if e.URL == "" || s.site.Private(e.URL) {
+			continue
+		}
+		idx = append(idx, &doc{
+			URL:     e.URL,
+			Title:   e.Title,
+			Summary: e.Summary,
+			title:   strings.ToLower(e.Title),
+			text:    e.Text,
+		})
+	}
+	slices.SortFunc(idx, func(a, b *doc) int { return strings.Compare(a.URL, b.URL) })
+	s.idx = idx
+	s.files = files
+	s.expires = time.Now().Add(indexTTL)
+	if changed && s.file != "" {
+		if err := s.save(); err != nil {
+			log.Printf("ERROR saving search index: %v", err)
+		}
+	}
+	return idx, nil
+}
+
+// indexPage returns the entry recording the page in the content file name,
+// without its size, modification time, and hash.
+func (s *server) indexPage(name string) *entry {
+	pages, err := s.site.Pages("/" + name)
+	if err != nil || len(pages) != 1 {
+		// Not a page, or a draft.
+		return &entry{}
+	}
+	p := pages[0]
+	title, _ := p["title"].(string)
+	url, _ := p["URL"].(string)
+	if _, ok := p["redirect"]; title == "" || ok {
+		return &entry{}
+	}
+	text := pageText(p)
+	summary, _ := p["summary"].(string)
+	if summary == "" {
+		summary = excerpt(text)
+	}
+	return &entry{URL: url, Title: title, Summary: summary, Text: strings.ToLower(text)}
+}
+
+// load returns the entries of the index saved in s.file,
+// or an empty map if there is none or it cannot be used.
+func (s *server) load() map[string]*entry {
+	data, err := os.ReadFile(s.file)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			log.Printf("ERROR loading search index: %v", err)
+		}
+		return map[string]*entry{}
+	}
+	var saved savedIndex
+	if err := json.Unmarshal(data, &saved); err != nil {
+		log.Printf("ERROR loading search index: %s: %v", s.file, err)
+		return map[string]*entry{}
+	}
+	if saved.Version != indexVersion || saved.Files == nil {
+		return map[string]*entry{}
+	}
+	return saved.Files
+}
+
+// save saves the index entries to s.file, replacing it atomically,
+// so that a server starting meanwhile does not read half of it.
+func (s *server) save() error {
+	data, err := json.Marshal(savedIndex{Version: indexVersion, Files: s.files})
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(s.file), filepath.Base(s.file)+".*")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if err1 := f.Close(); err == nil {
+		err = err1
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), s.file)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+var (
+	markup = regexp.MustCompile(`(?s)<!--.*?-->|<[^>]*>|{{.*?}}|&[a-z]+;|[*_#\x60\[\]]`)
+	spaces = regexp.MustCompile(`\s+`)
+)
+
+// pageText returns the text of the page p, without markup.
+func pageText(p web.Page) string {
+	data, _ := p["FileData"].(string)
+	return strings.TrimSpace(spaces.ReplaceAllString(markup.ReplaceAllString(data, " "), " "))
+}
+
+// excerpt returns the start of text, to summarize a page without a summary.
+func excerpt(text string) string {
+	const n = 200
+	if len(text) <= n {
+		return text
+	}
+	if i := strings.LastIndex(text[:n], " "); i > 0 {
+		return text[:i] + "…"
+	}
+	return text[:n] + "…"
+}
+
+// terms returns the search terms in the query q.
+func terms(q string) []string {
+	if len(q) > maxQuery {
+		q = q[:maxQuery]
+	}
+	return strings.FieldsFunc(strings.ToLower(q), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '.' && r != '/'
+	})
+}
+
+// search returns the pages in idx matching all the given terms, best first.
+// A term found in a page's title counts more than one in its URL,
+// which counts more than one in its text.
+func search(idx []*doc, terms []string) []result {
+	if len(terms) == 0 {
+		return nil
+	}
+	var list []result
+Docs:
+	for _, d := range idx {
+		score := 0
+		for _, t := range terms {
+			n := 10*strings.Count(d.title, t) + 5*strings.Count(d.URL, t) + min(strings.Count(d.text, t), 10)
+			if n == 0 {
+				continue Docs
+			}
+			score += n
+		}
+		list = append(list, result{d, score})
+	}
+	slices.SortStableFunc(list, func(a, b result) int { return b.score - a.score })
+	return list
+}
+
+// searchHandler serves /search?q=, the results of searching for q.
+// With format=suggest, it serves the titles and URLs of the best results
+// as OpenSearch suggestions, for browsers to offer as the query is typed.
+func (s *server) searchHandler(w http.ResponseWriter, r *http.Request) {
+	q := strings.TrimSpace(r.FormValue("q"))
+	idx, err := s.index()
+	if err != nil {
+		s.site.ServeError(w, r, err)
+		return
+	}
+	list := search(idx, terms(q))
+
+	if r.FormValue("format") == "suggest" {
+		var titles, descs, urls []string
+		for _, res := range list[:min(len(list), maxSuggest)] {
+			titles = append(titles, res.Title)
+			descs = append(descs, res.Summary)
+			urls = append(urls, "https://"+r.Host+res.URL)
+		}
+		w.Header().Set("Content-Type", "application/x-suggestions+json")
+		json.NewEncoder(w).Encode([]any{q, nonNil(titles), nonNil(descs), nonNil(urls)})
+		return
+	}
+	title := "Search"
+	if q != "" && len(list) == 0 {
+		analytics.CountEmptySearch(r, q)
+	}
+	if q != "" {
+		title = "Search results for “" + q + "”"
+	}
+	s.site.ServePage(w, r, web.Page{
+		"title":   title,
+		"layout":  "search",
+		"query":   q,
+		"results": list[:min(len(list), maxResults)],
+		"total":   len(list),
+	})
+}
+
+// nonNil returns list, or an empty list if list is nil,
+// so that it is encoded in JSON as [] rather than null.
+func nonNil(list []string) []string {
+	if list == nil {
+		return []string{}
+	}
+	return list
+}
+
+// A description is an OpenSearch description document.
+// See https://github.com/dewitt/opensearch.
+type description struct {
+	XMLName       xml.Name `xml:"http://a9.com/-/spec/opensearch/1.1/ OpenSearchDescription"`
+	ShortName     string   `xml:"ShortName"`
+	Description   string   `xml:"Description"`
+	InputEncoding string   `xml:"InputEncoding"`
+	Image         image    `xml:"Image"`
+	URL           []osURL  `xml:"Url"`
+}
+
+type image struct {
+	Width  int    `xml:"width,attr"`
+	Height int    `xml:"height,attr"`
+	Type   string `xml:"type,attr"`
+	URL    string `xml:",chardata"`
+}
+
+type osURL struct {
+	Type     string `xml:"type,attr"`
+	Method   string `xml:"method,attr"`
+	Template string `xml:"template,attr"`
+}
+
+// descriptionHandler serves /opensearch.xml, the OpenSearch description
+// of the site's search, which pages link to for browsers to find.
+func (s *server) descriptionHandler(w http.ResponseWriter, r *http.Request) {
+	base := "https://" + r.Host
+	d := description{
+		ShortName:     r.Host,
+		Description:   "Search " + r.Host + ", the home of the Go programming language",
+		InputEncoding: "UTF-8",
+		Image:         image{Width: 16, Height: 16, Type: "image/png", URL: base + "/images/favicon-gopher.png"},
+		URL: []osURL{
+			{Type: "text/html", Method: "get", Template: base + "/search?q={searchTerms}"},
+			{Type: "application/x-suggestions+json", Method: "get", Template: base + "/search?format=suggest&q={searchTerms}"},
+		},
+	}
+	data, err := xml.MarshalIndent(d, "", "  ")
+	if err != nil {
+		s.site.ServeError(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/opensearchdescription+xml")
+	w.Write([]byte(xml.Header))
+	w.Write(data)
+	w.Write([]byte("\n"))
+}
@@ -0,0 +1,164 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package search
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/matttproud/yourtour/internal/router"
+	"github.com/matttproud/yourtour/internal/vhost"
+	"github.com/matttproud/yourtour/internal/web"
+)
+
+var testFS = fstest.MapFS{
+	"site.tmpl":   {Data: []byte(`{{.title}}|{{range .results}}[{{.URL}}]{{end}}`)},
+	"search.tmpl": {Data: []byte(`{{define "layout"}}{{end}}`)},
+	"doc/install.md": {Data: []byte("---\ntitle: Download and install\n---\n\n" +
+		"Download and install Go quickly with the steps described here.\n")},
+	"doc/tutorial/getting-started.html": {Data: []byte("<!--{\n\t\"title\": \"Tutorial: Get started with Go\",\n\t\"summary\": \"A brief introduction.\"\n}-->\n" +
+		"<p>In this tutorial, you'll <b>install</b> Go and write some code.</p>\n")},
+	"blog/modules.md":   {Data: []byte("---\ntitle: Using Go Modules\n---\n\nModules are how Go manages dependencies. {{code \"x.go\"}}\n")},
+	"blog/draft.md":     {Data: []byte("---\ntitle: Go install secrets\ndraft: true\n---\n\nInstall.\n")},
+	"doc/moved.md":      {Data: []byte("---\ntitle: Install\nredirect: /doc/install\n---\n")},
+	"doc/partial.md":    {Data: []byte("How to install.\n")},
+	"internal/plans.md": {Data: []byte("---\ntitle: Install plans\n---\n\nSecrets.\n")},
+}
+
+func serve(t *testing.T, url string) *httptest.ResponseRecorder {
+	t.Helper()
+	mux := http.NewServeMux()
+	var reg vhost.Registry
+	site := web.NewSite(testFS)
+	site.SetPrivate(func(p string) bool { return strings.HasPrefix(p, "/internal/") })
+	RegisterHandlers(reg.Add("", site, testFS, router.New(mux)))
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", url, nil))
+	return w
+}
+
+func TestSearch(t *testing.T) {
+	for _, tt := range []struct {
+		q, want string
+	}{
+		{"install", "Search results for “install”|[/doc/install][/doc/tutorial/getting-started]"},
+		{"INSTALL go", "Search results for “INSTALL go”|[/doc/install][/doc/tutorial/getting-started]"},
+		{"tutorial install", "Search results for “tutorial install”|[/doc/tutorial/getting-started]"},
+		{"modules", "Search results for “modules”|[/blog/modules]"},
+		{"code", "Search results for “code”|[/doc/tutorial/getting-started]"}, // not template actions
+		{"secrets", "Search results for “secrets”|"},                          // drafts and private pages are not indexed
+		{"", "Search|"},
+	} {
+		w := serve(t, "/search?q="+strings.ReplaceAll(tt.q, " ", "+"))
+		if got := w.Body.String(); w.Code != 200 || got != tt.want {
+			t.Errorf("search %q: %d %q, want %q", tt.q, w.Code, got, tt.want)
+		}
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	w := serve(t, "/search?format=suggest&q=install")
+	if ct := w.Header().Get("Content-Type"); ct != "application/x-suggestions+json" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	var got []any
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	want := `["install",` +
+		`["Download and install","Tutorial: Get started with Go"],` +
+		`["Download and install Go quickly with the steps described here.","A brief introduction."],` +
+		`["https://example.com/doc/install","https://example.com/doc/tutorial/getting-started"]]`
+	if js, _ := json.Marshal(got); string(js) != want {
+		t.Errorf("suggestions:\n%s\nwant:\n%s", js, want)
+	}
+
+	if body := serve(t, "/search?format=suggest&q=nothing").Body.String(); body != "[\"nothing\",[],[],[]]\n" {
+		t.Errorf("no suggestions: %q", body)
+	}
+}
+
+func TestDescription(t *testing.T) {
+	w := serve(t, "/opensearch.xml")
+	if ct := w.Header().Get("Content-Type"); ct != "application/opensearchdescription+xml" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	for _, want := range []string{
+		`<OpenSearchDescription xmlns="http://a9.com/-/spec/opensearch/1.1/">`,
+		`<ShortName>example.com</ShortName>`,
+		`<Url type="text/html" method="get" template="https://example.com/search?q={searchTerms}"></Url>`,
+		`<Url type="application/x-suggestions+json" method="get" template="https://example.com/search?format=suggest&amp;q={searchTerms}"></Url>`,
+	} {
+		if !strings.Contains(w.Body.String(), want) {
+			t.Errorf("description missing %s\n%s", want, w.Body)
+		}
+	}
+}
+
+func TestIndexUpdate(t *testing.T) {
+	file := filepath.Join(t.TempDir(), indexFile("go.dev", "/tour"))
+	mtime := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	fsys := fstest.MapFS{
+		"site.tmpl": {Data: []byte(`{{.Content}}`)},
+		"a.md":      {Data: []byte("---\ntitle: Alpha\n---\n\nFirst."), ModTime: mtime},
+		"b.md":      {Data: []byte("---\ntitle: Beta\n---\n\nSecond.")},
+		"c.md":      {Data: []byte("---\ntitle: Gamma\n---\n\nThird.")},
+	}
+	titles := func(s *server) string {
+		t.Helper()
+		idx, err := s.index()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var list []string
+		for _, d := range idx {
+			list = append(list, d.Title)
+		}
+		return strings.Join(list, ",")
+	}
+	s := &server{site: web.NewSite(fsys), fsys: fsys, file: file}
+	if got := titles(s); got != "Alpha,Beta,Gamma" {
+		t.Fatalf("first index: %s", got)
+	}
+	if _, err := os.Stat(file); err != nil {
+		t.Fatalf("index not saved: %v", err)
+	}
+
+	// A new server reuses the saved index: a.md, whose size and
+	// modification time are unchanged, is not read again, even though
+	// it has changed; b.md, which has no modification time, is hashed,
+	// found changed, and indexed again; c.md is gone.
+	fsys = fstest.MapFS{
+		"site.tmpl": fsys["site.tmpl"],
+		"a.md":      {Data: []byte("---\ntitle: Omega\n---\n\nFirst."), ModTime: mtime},
+		"b.md":      {Data: []byte("---\ntitle: Bet\n---\n\nSecond.")},
+		"d.md":      {Data: []byte("---\ntitle: Dalet\n---\n\nFourth.")},
+	}
+	s = &server{site: web.NewSite(fsys), fsys: fsys, file: file}
+	if got := titles(s); got != "Alpha,Bet,Dalet" {
+		t.Errorf("index after restart: %s, want Alpha,Bet,Dalet", got)
+	}
+
+	// A changed modification time marks a file to index again.
+	fsys["a.md"].ModTime = mtime.Add(time.Second)
+	s.expires = time.Time{}
+	if got := titles(s); got != "Omega,Bet,Dalet" {
+		t.Errorf("index after update: %s, want Omega,Bet,Dalet", got)
+	}
+
+	// A saved index of another version is ignored.
+	os.WriteFile(file, []byte(`{"version": 0, "files": {"a.md": {"url": "/x", "title": "X", "size": 1}}}`), 0o666)
+	s = &server{site: web.NewSite(fsys), fsys: fsys, file: file}
+	if got := titles(s); got != "Omega,Bet,Dalet" {
+		t.Errorf("index with old saved index: %s, want Omega,Bet,Dalet", got)
+	}
+}
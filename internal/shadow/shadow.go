@@ -0,0 +1,184 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package shadow mirrors live requests to an alternate implementation
+// of a handler, to de-risk rewrites of large handlers.
+//
+// A Handler serves every request with the current implementation.
+// It also sends a sample of the read-only requests to the alternate one
+// in the background, discarding its reply after comparing it with the
+// current reply: a difference in status, length, or content is logged
+// with the request's logger, to be investigated before the rewrite
+// is put in service.
+package shadow
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"math/rand/v2"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/matttproud/yourtour/internal/reqlog"
+)
+
+// maxInFlight is the most mirrored requests a Handler runs at once.
+// Requests sampled while that many are running are not mirrored,
+// so that a slow alternate cannot pile up goroutines.
+const maxInFlight = 8
+
+// maxTime is the time a mirrored request may take.
+const maxTime = 30 * time.Second
+
+// A Handler serves requests with one implementation
+// and mirrors some of them to another.
+type Handler struct {
+	name string
+	h    http.Handler
+	alt  http.Handler
+	rate float64
+	sem  chan struct{}
+	wg   sync.WaitGroup
+}
+
+// New returns a Handler that serves requests with h
+// and mirrors the fraction rate of GET and HEAD requests to alt.
+// The name identifies the handler in logs.
+func New(name string, h, alt http.Handler, rate float64) *Handler {
+	return &Handler{
+		name: name,
+		h:    h,
+		alt:  alt,
+		rate: rate,
+		sem:  make(chan struct{}, maxInFlight),
+	}
+}
+
+func (s *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" && r.Method != "HEAD" || s.rate <= 0 || rand.Float64() >= s.rate {
+		s.h.ServeHTTP(w, r)
+		return
+	}
+	select {
+	case s.sem <- struct{}{}:
+	default:
+		s.h.ServeHTTP(w, r)
+		return
+	}
+
+	// Clone r before h can modify it, and detach the clone
+	// from r's cancellation, which comes when h returns.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), maxTime)
+	r2 := r.Clone(ctx)
+
+	mirrored := false
+	defer func() {
+		if !mirrored { // h panicked
+			cancel()
+			<-s.sem
+		}
+	}()
+	rw := &writer{ResponseWriter: w, sum: sha256.New()}
+	s.h.ServeHTTP(rw, r)
+	want := rw.reply()
+
+	mirrored = true
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer func() { <-s.sem }()
+		defer cancel()
+		got := s.serveAlt(r2)
+		if got != want {
+			reqlog.Logger(ctx).Warn("shadow reply differs",
+				"shadow", s.name,
+				"path", r.URL.Path,
+				"status", want.status, "alt_status", got.status,
+				"bytes", want.bytes, "alt_bytes", got.bytes,
+				"same_body", got.sum == want.sum,
+				"alt_panic", got.panic)
+		}
+	}()
+}
+
+// serveAlt serves r with the alternate implementation,
+// returning a summary of its reply.
+func (s *Handler) serveAlt(r *http.Request) (rep reply) {
+	rw := &writer{ResponseWriter: discard{make(http.Header)}, sum: sha256.New()}
+	defer func() {
+		if v := recover(); v != nil {
+			rep = reply{status: http.StatusInternalServerError, panic: fmt.Sprint(v)}
+		}
+	}()
+	s.alt.ServeHTTP(rw, r)
+	return rw.reply()
+}
+
+// Wait waits for the mirrored requests in progress to finish.
+func (s *Handler) Wait() {
+	s.wg.Wait()
+}
+
+// A reply summarizes an HTTP reply, for comparison.
+type reply struct {
+	status int
+	bytes  int64
+	sum    string // SHA-256 of the body
+	panic  string // value of a panic while serving, if any
+}
+
+// A writer records the status, size, and checksum of a reply.
+type writer struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+	sum    hash.Hash
+}
+
+func (w *writer) WriteHeader(code int) {
+	if w.status == 0 && code >= 200 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *writer) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	w.sum.Write(b[:n])
+	return n, err
+}
+
+func (w *writer) reply() reply {
+	status := w.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	return reply{status: status, bytes: w.bytes, sum: fmt.Sprintf("%x", w.sum.Sum(nil))}
+}
+
+func (w *writer) Flush() {
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap returns the underlying ResponseWriter,
+// for use by http.ResponseController.
+func (w *writer) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// A discard is a ResponseWriter that discards the reply.
+type discard struct {
+	header http.Header
+}
+
+func (d discard) Header() http.Header       { return d.header }
+func (discard) Write(b []byte) (int, error) { return len(b), nil }
+func (discard) WriteHeader(int)             {}
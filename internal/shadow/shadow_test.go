@@ -0,0 +1,105 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package shadow
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/matttproud/yourtour/internal/reqlog"
+)
+
+// A syncBuffer is a bytes.Buffer safe for concurrent use.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestHandler(t *testing.T) {
+	old := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "hello, %s", r.URL.Path)
+	})
+	var mu sync.Mutex
+	var altCalls []string
+	alt := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		altCalls = append(altCalls, r.Method+" "+r.URL.Path)
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/missing":
+			http.NotFound(w, r)
+		case "/panic":
+			panic("broken rewrite")
+		case "/typo":
+			fmt.Fprintf(w, "hellp, %s", r.URL.Path)
+		default:
+			fmt.Fprintf(w, "hello, %s", r.URL.Path)
+		}
+	})
+	var log syncBuffer
+	logger := slog.New(slog.NewTextHandler(&log, nil))
+	s := New("test", old, alt, 1)
+
+	for _, req := range []string{"GET /same", "GET /missing", "GET /panic", "GET /typo", "POST /post"} {
+		method, path, _ := strings.Cut(req, " ")
+		r := httptest.NewRequest(method, path, nil)
+		r = r.WithContext(reqlog.NewContext(r.Context(), "id", logger))
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, r)
+		if want := "hello, " + path; w.Code != 200 || w.Body.String() != want {
+			t.Errorf("%s = %d %q, want 200 %q", req, w.Code, w.Body, want)
+		}
+	}
+	s.Wait()
+
+	slices.Sort(altCalls)
+	if want := "GET /missing GET /panic GET /same GET /typo"; strings.Join(altCalls, " ") != want {
+		t.Errorf("alt served %q, want %q", altCalls, want)
+	}
+	out := log.String()
+	for _, want := range []string{
+		"path=/missing status=200 alt_status=404",
+		"path=/panic status=200 alt_status=500",
+		`alt_panic="broken rewrite"`,
+		"path=/typo status=200 alt_status=200 bytes=12 alt_bytes=12 same_body=false",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("log does not contain %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "/same") {
+		t.Errorf("log reports matching reply:\n%s", out)
+	}
+}
+
+func TestHandlerRate(t *testing.T) {
+	alt := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("alt called at rate 0")
+	})
+	s := New("test", http.NotFoundHandler(), alt, 0)
+	r := httptest.NewRequest("GET", "/", nil).WithContext(context.Background())
+	s.ServeHTTP(httptest.NewRecorder(), r)
+	s.Wait()
+}
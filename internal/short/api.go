@@ -0,0 +1,141 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package short
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"cloud.google.com/go/datastore"
+	"github.com/matttproud/yourtour/internal/memcache"
+)
+
+// maxLinkBody is the largest accepted link creation body.
+const maxLinkBody = 4 << 10
+
+// APIHandler returns a handler serving a JSON API
+// for managing short links, at path and below it:
+//
+//	GET path        list the links, with their hits
+//	GET path/key    get one link
+//	POST path       create the link in the body, {"Key": ..., "Target": ...}
+//	PUT path/key    create or replace the link, keeping its hits
+//	DELETE path/key delete the link
+//
+// POST refuses to replace an existing link, answering 409 Conflict.
+// As with AdminHandler, it is the caller's responsibility
+// to ensure that the handler is only exposed to authorized users,
+// for example with env.AdminHandler.
+func APIHandler(path string, dc Datastore, mc memcache.Cache) http.Handler {
+	s := newServer(dc, mc)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, path), "/")
+		switch {
+		case key == "" && r.Method == "GET":
+			s.apiList(w, r)
+		case key == "" && r.Method == "POST":
+			s.apiPut(w, r, "", false)
+		case key != "" && r.Method == "GET":
+			s.apiGet(w, r, key)
+		case key != "" && r.Method == "PUT":
+			s.apiPut(w, r, key, true)
+		case key != "" && r.Method == "DELETE":
+			s.apiDelete(w, r, key)
+		default:
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+func (h *server) apiList(w http.ResponseWriter, r *http.Request) {
+	links := []*Link{}
+	q := datastore.NewQuery(kind).Order("Key")
+	if _, err := h.datastore.GetAll(r.Context(), q, &links); err != nil {
+		log.Printf("ERROR listing short links: %v", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, links)
+}
+
+func (h *server) apiGet(w http.ResponseWriter, r *http.Request, key string) {
+	var link Link
+	switch err := h.datastore.Get(r.Context(), datastore.NameKey(kind, key, nil), &link); err {
+	case nil:
+		writeJSON(w, http.StatusOK, &link)
+	case datastore.ErrNoSuchEntity:
+		http.Error(w, "not found", http.StatusNotFound)
+	default:
+		log.Printf("ERROR %q: %v", key, err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
+
+// apiPut creates the link in the request body,
+// whose key must be key unless key is empty.
+// It replaces an existing link only if replace is set.
+func (h *server) apiPut(w http.ResponseWriter, r *http.Request, key string, replace bool) {
+	var link Link
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxLinkBody)).Decode(&link); err != nil {
+		http.Error(w, "invalid link: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if key != "" {
+		if link.Key != "" && link.Key != key {
+			http.Error(w, "link key does not match URL", http.StatusBadRequest)
+			return
+		}
+		link.Key = key
+	}
+	if err := checkLink(&link); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ctx := r.Context()
+	status := http.StatusOK
+	if !replace {
+		var old Link
+		switch err := h.datastore.Get(ctx, datastore.NameKey(kind, link.Key, nil), &old); err {
+		case nil:
+			http.Error(w, "short link "+link.Key+" already exists", http.StatusConflict)
+			return
+		case datastore.ErrNoSuchEntity:
+			status = http.StatusCreated
+		default:
+			log.Printf("ERROR %q: %v", link.Key, err)
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+	}
+	if err := h.putLink(ctx, &link); err != nil {
+		log.Printf("ERROR %q: %v", link.Key, err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	h.forget(ctx, link.Key)
+	writeJSON(w, status, &link)
+}
+
+func (h *server) apiDelete(w http.ResponseWriter, r *http.Request, key string) {
+	ctx := r.Context()
+	if err := h.datastore.Delete(ctx, datastore.NameKey(kind, key, nil)); err != nil {
+		log.Printf("ERROR %q: %v", key, err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	h.forget(ctx, key)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeJSON writes v to w as the JSON body of a reply with the given status.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("ERROR writing JSON: %v", err)
+	}
+}
@@ -3,11 +3,14 @@
 // license that can be found in the LICENSE file.
 
 // Package short implements a simple URL shortener, serving shortened urls
-// from /s/key. An administrative handler is provided for other services to use.
+// from /s/key. An administrative handler and a JSON API are provided
+// for other services to use.
+//
+// Short links give stable URLs to pages that may move or that are
+// awkward to type, such as a deep step in a codewalk or a release's
+// downloads. Each link counts the redirects it has served.
 package short
 
-// TODO(adg): collect statistics on URL visits
-
 import (
 	"context"
 	_ "embed"
@@ -19,9 +22,12 @@ import (
 	"net/url"
 	"regexp"
 	"strings"
+	"sync"
 
 	"cloud.google.com/go/datastore"
+	"github.com/matttproud/yourtour/internal/accesslog"
 	"github.com/matttproud/yourtour/internal/memcache"
+	"github.com/matttproud/yourtour/internal/web"
 )
 
 // useMemcache controls whether to use Redis.
@@ -37,25 +43,110 @@ const (
 // Link represents a short link.
 type Link struct {
 	Key, Target string
+
+	// Hits is the number of redirects served for the link,
+	// as of the last time the servers recorded them.
+	Hits int64
 }
 
 var validKey = regexp.MustCompile(`^[a-zA-Z0-9-_.]+$`)
 
+// Datastore is the part of a *datastore.Client used by the shortener.
+type Datastore interface {
+	Get(ctx context.Context, key *datastore.Key, dst any) error
+	GetAll(ctx context.Context, q *datastore.Query, dst any) ([]*datastore.Key, error)
+	Put(ctx context.Context, key *datastore.Key, src any) (*datastore.Key, error)
+	Delete(ctx context.Context, key *datastore.Key) error
+}
+
+var _ Datastore = (*datastore.Client)(nil)
+
 type server struct {
-	datastore *datastore.Client
+	datastore Datastore
 	memcache  *memcache.CodecClient
+	site      *web.Site // for 404 pages; nil for plain text
+	hits      hitCounter
 }
 
-func newServer(dc *datastore.Client, mc *memcache.Client) *server {
+func newServer(dc Datastore, mc memcache.Cache) *server {
 	return &server{
 		datastore: dc,
-		memcache:  mc.WithCodec(memcache.JSON),
+		memcache:  memcache.NewCodecClient(mc, memcache.JSON),
 	}
 }
 
-func RegisterHandlers(mux *http.ServeMux, host string, dc *datastore.Client, mc *memcache.Client) {
+// RegisterHandlers registers the handler for short links on mux,
+// using host as a host prefix on the registered path.
+// Requests for unknown links are answered with the 404 page of site.
+// It returns a function that adds the redirects counted since its last call
+// to the links' hits in the datastore, for running periodically.
+func RegisterHandlers(mux *http.ServeMux, host string, site *web.Site, dc Datastore, mc memcache.Cache) (recordHits func(context.Context) error) {
 	s := newServer(dc, mc)
+	s.site = site
 	mux.HandleFunc(host+prefix+"/", s.linkHandler)
+	return s.recordHits
+}
+
+// notFound answers r with a 404 page for the unknown short link.
+func (h *server) notFound(w http.ResponseWriter, r *http.Request) {
+	if h.site == nil {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	h.site.ServeErrorStatus(w, r, fmt.Errorf("short link %s not found", r.URL.Path), http.StatusNotFound)
+}
+
+// A hitCounter counts the redirects served for each link
+// until they are recorded in the datastore.
+type hitCounter struct {
+	mu   sync.Mutex
+	hits map[string]int64
+}
+
+// add counts a redirect for the link key.
+func (c *hitCounter) add(key string, n int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.hits == nil {
+		c.hits = make(map[string]int64)
+	}
+	c.hits[key] += n
+}
+
+// take returns and resets the counts.
+func (c *hitCounter) take() map[string]int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	hits := c.hits
+	c.hits = nil
+	return hits
+}
+
+// recordHits adds the redirects counted since its last call
+// to the links' hits in the datastore. Counts it cannot record
+// are kept for the next call, except for links since deleted.
+// The read-modify-write is not transactional, so a redirect
+// counted by two servers recording the same link at once can be lost;
+// the counts are for gauging use, not billing.
+func (h *server) recordHits(ctx context.Context) error {
+	var errs []error
+	for key, n := range h.hits.take() {
+		k := datastore.NameKey(kind, key, nil)
+		var link Link
+		err := h.datastore.Get(ctx, k, &link)
+		if err == datastore.ErrNoSuchEntity {
+			continue
+		}
+		if err == nil {
+			link.Hits += n
+			_, err = h.datastore.Put(ctx, k, &link)
+		}
+		if err != nil {
+			h.hits.add(key, n)
+			errs = append(errs, fmt.Errorf("%s: %v", key, err))
+		}
+	}
+	return errors.Join(errs...)
 }
 
 // linkHandler services requests to short URLs.
@@ -66,25 +157,42 @@ func RegisterHandlers(mux *http.ServeMux, host string, dc *datastore.Client, mc
 // It then sends a redirects or an error message.
 // If the remaining path part is not empty, the redirects
 // will be the relative path from the resolved Link.
-func (h server) linkHandler(w http.ResponseWriter, r *http.Request) {
+func (h *server) linkHandler(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
 	key, remaining, err := extractKey(r)
 	if err != nil { // invalid key or url
-		http.Error(w, "not found", http.StatusNotFound)
+		h.notFound(w, r)
 		return
 	}
 
 	var link Link
 	if useMemcache {
 		err = h.memcache.Get(ctx, cacheKey(key), &link)
+		switch err {
+		case nil:
+			accesslog.Annotate(ctx, "cache", "hit")
+		case memcache.ErrNotFound:
+			accesslog.Annotate(ctx, "cache", "hit")
+			h.notFound(w, r)
+			return
+		default:
+			accesslog.Annotate(ctx, "cache", "miss")
+		}
 	}
 	if err != nil || !useMemcache {
 		k := datastore.NameKey(kind, key, nil)
 		err = h.datastore.Get(ctx, k, &link)
 		switch err {
 		case datastore.ErrNoSuchEntity:
-			http.Error(w, "not found", http.StatusNotFound)
+			if useMemcache {
+				// Remember the miss briefly, so that repeated requests
+				// for a mistyped link don't each hit datastore.
+				if err := h.memcache.SetNotFound(ctx, cacheKey(key), memcache.DefaultNotFoundTTL); err != nil {
+					log.Printf("WARNING %q: %v", key, err)
+				}
+			}
+			h.notFound(w, r)
 			return
 		default: // != nil
 			log.Printf("ERROR %q: %v", key, err)
@@ -107,6 +215,7 @@ func (h server) linkHandler(w http.ResponseWriter, r *http.Request) {
 	if remaining != "" {
 		target += remaining
 	}
+	h.hits.add(key, 1)
 	http.Redirect(w, r, target, http.StatusFound)
 }
 
@@ -134,7 +243,7 @@ func extractKey(r *http.Request) (key, remaining string, err error) {
 // AdminHandler serves an administrative interface for managing shortener entries.
 // Be careful. It is the caller’s responsibility to ensure that the handler is
 // only exposed to authorized users.
-func AdminHandler(dc *datastore.Client, mc *memcache.Client) http.HandlerFunc {
+func AdminHandler(dc Datastore, mc memcache.Cache) http.HandlerFunc {
 	s := newServer(dc, mc)
 	return s.adminHandler
 }
@@ -148,7 +257,7 @@ var (
 
 // adminHandler serves an administrative interface.
 // Be careful. Ensure that this handler is only be exposed to authorized users.
-func (h server) adminHandler(w http.ResponseWriter, r *http.Request) {
+func (h *server) adminHandler(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
 	var newLink *Link
@@ -157,7 +266,7 @@ func (h server) adminHandler(w http.ResponseWriter, r *http.Request) {
 		key := r.FormValue("key")
 		switch r.FormValue("do") {
 		case "Add":
-			newLink = &Link{key, r.FormValue("target")}
+			newLink = &Link{Key: key, Target: r.FormValue("target")}
 			doErr = h.putLink(ctx, newLink)
 		case "Delete":
 			k := datastore.NameKey(kind, key, nil)
@@ -165,12 +274,7 @@ func (h server) adminHandler(w http.ResponseWriter, r *http.Request) {
 		default:
 			http.Error(w, "unknown action", http.StatusBadRequest)
 		}
-		if useMemcache {
-			err := h.memcache.Delete(ctx, cacheKey(key))
-			if err != nil && err != memcache.ErrCacheMiss {
-				log.Printf("WARNING %q: %v", key, err)
-			}
-		}
+		h.forget(ctx, key)
 	}
 
 	var links []*Link
@@ -210,19 +314,48 @@ func (h server) adminHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// putLink validates the provided link and puts it into the datastore.
-func (h server) putLink(ctx context.Context, link *Link) error {
+// checkLink reports whether link has a valid key and target.
+func checkLink(link *Link) error {
 	if !validKey.MatchString(link.Key) {
 		return fmt.Errorf("invalid key %q; must match %s", link.Key, validKey.String())
 	}
 	if _, err := url.Parse(link.Target); err != nil {
 		return fmt.Errorf("bad target %q: %v", link.Target, err)
 	}
+	return nil
+}
+
+// putLink validates the provided link and puts it into the datastore,
+// keeping the hits of any link it replaces.
+func (h *server) putLink(ctx context.Context, link *Link) error {
+	if err := checkLink(link); err != nil {
+		return err
+	}
 	k := datastore.NameKey(kind, link.Key, nil)
+	var old Link
+	switch err := h.datastore.Get(ctx, k, &old); err {
+	case nil:
+		link.Hits = old.Hits
+	case datastore.ErrNoSuchEntity:
+		link.Hits = 0
+	default:
+		return err
+	}
 	_, err := h.datastore.Put(ctx, k, link)
 	return err
 }
 
+// forget removes any cached copy of the link key.
+func (h *server) forget(ctx context.Context, key string) {
+	if !useMemcache {
+		return
+	}
+	err := h.memcache.Delete(ctx, cacheKey(key))
+	if err != nil && err != memcache.ErrCacheMiss {
+		log.Printf("WARNING %q: %v", key, err)
+	}
+}
+
 // cacheKey returns a short URL key as a memcache key.
 func cacheKey(key string) string {
 	return "link-" + key
@@ -5,8 +5,18 @@
 package short
 
 import (
+	"context"
+	"errors"
+	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"testing/fstest"
+
+	"cloud.google.com/go/datastore"
+	"github.com/matttproud/yourtour/internal/datastoretest"
+	"github.com/matttproud/yourtour/internal/memcache"
+	"github.com/matttproud/yourtour/internal/web"
 )
 
 func TestExtractKey(t *testing.T) {
@@ -43,3 +53,104 @@ func TestExtractKey(t *testing.T) {
 		}
 	}
 }
+
+// newDatastore returns a datastore holding links.
+func newDatastore(links ...Link) *datastoretest.Datastore {
+	ds := new(datastoretest.Datastore)
+	for _, link := range links {
+		ds.Put(context.Background(), datastore.NameKey(kind, link.Key, nil), &link)
+	}
+	return ds
+}
+
+// getLink returns the link with the given key in ds.
+func getLink(ds Datastore, key string) Link {
+	var link Link
+	ds.Get(context.Background(), datastore.NameKey(kind, key, nil), &link)
+	return link
+}
+
+func TestLinkHandler(t *testing.T) {
+	ds := newDatastore(
+		Link{Key: "walk", Target: "/doc/codewalk/functions#step3", Hits: 2},
+		Link{Key: "go1", Target: "/dl/#go1"},
+	)
+	site := web.NewSite(fstest.MapFS{
+		"site.tmpl":  {Data: []byte(`{{.Content}}{{block "layout" .}}{{end}}`)},
+		"error.tmpl": {Data: []byte(`{{define "layout"}}Error: {{.error}}{{end}}`)},
+	})
+	mux := http.NewServeMux()
+	recordHits := RegisterHandlers(mux, "", site, ds, memcache.NewMemory(0))
+
+	get := func(url string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", url, nil))
+		return w
+	}
+	for i := 0; i < 3; i++ {
+		if w := get("/s/walk"); w.Code != http.StatusFound || w.Header().Get("Location") != "/doc/codewalk/functions#step3" {
+			t.Fatalf("GET /s/walk: %d to %q", w.Code, w.Header().Get("Location"))
+		}
+	}
+	get("/s/go1")
+	for _, url := range []string{"/s/nosuch", "/s/bad*key"} {
+		w := get(url)
+		if w.Code != http.StatusNotFound || !strings.Contains(w.Body.String(), "Error: short link") {
+			t.Errorf("GET %s: %d\n%s", url, w.Code, w.Body)
+		}
+	}
+
+	ctx := context.Background()
+	ds.FailPuts(errors.New("datastore down"))
+	if err := recordHits(ctx); err == nil {
+		t.Errorf("recordHits with failing datastore succeeded")
+	}
+	ds.FailPuts(nil)
+	get("/s/walk")
+	if err := recordHits(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n := getLink(ds, "walk").Hits; n != 6 {
+		t.Errorf("walk hits = %d, want 6", n)
+	}
+	if n := getLink(ds, "go1").Hits; n != 1 {
+		t.Errorf("go1 hits = %d, want 1", n)
+	}
+	if err := recordHits(ctx); err != nil || getLink(ds, "walk").Hits != 6 {
+		t.Errorf("second recordHits: %v, walk hits = %d, want 6", err, getLink(ds, "walk").Hits)
+	}
+}
+
+func TestAPIHandler(t *testing.T) {
+	ds := newDatastore(Link{Key: "walk", Target: "/doc/codewalk/functions#step3", Hits: 7})
+	h := APIHandler("/_shortlinks", ds, memcache.NewMemory(0))
+	do := func(method, url, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(method, url, strings.NewReader(body)))
+		return w
+	}
+
+	tests := []struct {
+		method, url, body string
+		code              int
+		want              string
+	}{
+		{"POST", "/_shortlinks", `{"Key": "go1.22", "Target": "/dl/#go1.22.0"}`, http.StatusCreated, `"Key":"go1.22"`},
+		{"POST", "/_shortlinks", `{"Key": "walk", "Target": "/elsewhere"}`, http.StatusConflict, "already exists"},
+		{"POST", "/_shortlinks", `{"Key": "bad key", "Target": "/x"}`, http.StatusBadRequest, "invalid key"},
+		{"POST", "/_shortlinks", `{"Key":`, http.StatusBadRequest, "invalid link"},
+		{"PUT", "/_shortlinks/walk", `{"Target": "/doc/codewalk/functions#step4"}`, http.StatusOK, `"Hits":7`},
+		{"PUT", "/_shortlinks/walk", `{"Key": "other", "Target": "/x"}`, http.StatusBadRequest, "does not match"},
+		{"GET", "/_shortlinks/walk", "", http.StatusOK, `"Target":"/doc/codewalk/functions#step4"`},
+		{"GET", "/_shortlinks", "", http.StatusOK, `[{"Key":"go1.22","Target":"/dl/#go1.22.0","Hits":0},{"Key":"walk"`},
+		{"DELETE", "/_shortlinks/go1.22", "", http.StatusNoContent, ""},
+		{"GET", "/_shortlinks/go1.22", "", http.StatusNotFound, ""},
+		{"DELETE", "/_shortlinks", "", http.StatusMethodNotAllowed, ""},
+	}
+	for _, tt := range tests {
+		w := do(tt.method, tt.url, tt.body)
+		if w.Code != tt.code || !strings.Contains(w.Body.String(), tt.want) {
+			t.Errorf("%s %s %s: %d %s, want %d containing %s", tt.method, tt.url, tt.body, w.Code, w.Body, tt.code, tt.want)
+		}
+	}
+}
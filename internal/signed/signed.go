@@ -0,0 +1,70 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package signed encodes values in strings signed with HMAC-SHA256,
+// for the cookies and tokens that the server gives clients to hold
+// and that clients must not be able to forge.
+//
+// A signed string is the base64 encoding of the value in JSON, a dot,
+// and the base64 encoding of the HMAC-SHA256 of that first part.
+// When a purpose is given, such as the name of a cookie, it is signed
+// along with the value, so that a string signed for one purpose
+// is not accepted for another signed with the same key.
+package signed
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// ErrInvalid is returned by Decode for strings
+// not signed with the key or not holding a value.
+var ErrInvalid = errors.New("invalid signed value")
+
+// Encode returns the signed string holding v in JSON,
+// signed with key for purpose.
+func Encode(key, purpose string, v any) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	return payload + "." + base64.RawURLEncoding.EncodeToString(mac(key, purpose, payload)), nil
+}
+
+// Decode decodes the value in the signed string s into v,
+// if s is signed with key for purpose, and otherwise returns ErrInvalid.
+func Decode(key, purpose, s string, v any) error {
+	payload, sig, ok := strings.Cut(s, ".")
+	if !ok {
+		return ErrInvalid
+	}
+	b, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(b, mac(key, purpose, payload)) {
+		return ErrInvalid
+	}
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return ErrInvalid
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return ErrInvalid
+	}
+	return nil
+}
+
+// mac returns the HMAC-SHA256 of payload with key.
+// A purpose is signed before the payload, separated by a bar.
+func mac(key, purpose, payload string) []byte {
+	h := hmac.New(sha256.New, []byte(key))
+	if purpose != "" {
+		h.Write([]byte(purpose + "|"))
+	}
+	h.Write([]byte(payload))
+	return h.Sum(nil)
+}
@@ -0,0 +1,47 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package signed
+
+import (
+	"strings"
+	"testing"
+)
+
+type value struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+func TestSigned(t *testing.T) {
+	s, err := Encode("key", "cookie", value{"gopher", 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v value
+	if err := Decode("key", "cookie", s, &v); err != nil || v != (value{"gopher", 2}) {
+		t.Errorf("Decode(Encode(v)) = %+v, %v, want %+v", v, err, value{"gopher", 2})
+	}
+
+	unsigned, _ := Encode("key", "", value{"gopher", 2})
+	forged, _ := Encode("other", "cookie", value{"gopher", 2})
+	payload, _, _ := strings.Cut(s, ".")
+	for _, bad := range []string{
+		unsigned, // signed for no purpose
+		forged,   // signed with another key
+		payload,
+		payload + ".",
+		payload + "!." + strings.TrimPrefix(s, payload+"."),
+		"e30." + strings.TrimPrefix(s, payload+"."),
+		"",
+	} {
+		if err := Decode("key", "cookie", bad, &v); err != ErrInvalid {
+			t.Errorf("Decode(%q) = %v, want ErrInvalid", bad, err)
+		}
+	}
+
+	if _, err := Encode("key", "", func() {}); err == nil {
+		t.Errorf("Encode of a func succeeded")
+	}
+}
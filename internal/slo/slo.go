@@ -0,0 +1,340 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package slo tracks the service level objectives of classes of routes
+// and the error budgets that the requests served leave of them.
+//
+// The Objective of a class sets the fraction of its requests that must
+// be good, twice over: for availability, answered without a server error
+// (a 5xx status), and for latency, answered without one within the
+// objective's latency. The rest, one minus the target, is the error budget.
+// A Tracker reports, for several recent windows, how fast each budget
+// is being spent. A burn rate of 1 spends the budget at exactly the rate
+// the objective allows; a burn rate of 10 would spend a day's budget in
+// under two and a half hours. Operators can hold a deploy while a budget
+// is spent, and roll back one after which the burn rate jumps.
+//
+// Requests are counted in memory in one-minute buckets for the last
+// BudgetWindow. Each server instance counts its own requests,
+// and counts are lost when it restarts.
+package slo
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BudgetWindow is the window whose error budget is reported as left.
+const BudgetWindow = 24 * time.Hour
+
+const (
+	bucket   = time.Minute
+	nbuckets = int(BudgetWindow / bucket)
+)
+
+// windows are the windows for which burn rates are reported.
+var windows = []time.Duration{5 * time.Minute, time.Hour, 6 * time.Hour, BudgetWindow}
+
+// An Objective is the service level objective of a class of routes.
+type Objective struct {
+	Target  float64       // fraction of requests that must be good, as 0.999
+	Latency time.Duration // time within which good requests are answered
+}
+
+// Objectives map route class names to their objectives.
+type Objectives map[string]Objective
+
+// ParseObjectives parses a comma-separated list of objectives of the form
+// class=target/latency, where target is a fraction, such as 0.999,
+// or a percentage, such as 99.9%, and latency is a duration. For example:
+//
+//	pages=99.9%/1s,dljson=0.9995/300ms
+func ParseObjectives(s string) (Objectives, error) {
+	objs := make(Objectives)
+	for _, f := range strings.Split(s, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		name, spec, ok := strings.Cut(f, "=")
+		target, latency, ok2 := strings.Cut(spec, "/")
+		if !ok || !ok2 || name == "" {
+			return nil, fmt.Errorf("invalid objective %q; want class=target/latency, like pages=99.9%%/1s", f)
+		}
+		num, pct := strings.CutSuffix(target, "%")
+		t, err := strconv.ParseFloat(num, 64)
+		if pct {
+			t /= 100
+		}
+		if err != nil || t <= 0 || t >= 1 {
+			return nil, fmt.Errorf("invalid objective %q: bad target %q; want a fraction between 0 and 1, like 0.999 or 99.9%%", f, target)
+		}
+		d, err := time.ParseDuration(latency)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid objective %q: bad latency %q; want a duration like 500ms", f, latency)
+		}
+		objs[name] = Objective{t, d}
+	}
+	return objs, nil
+}
+
+// String returns the objectives in the form read by ParseObjectives.
+func (objs Objectives) String() string {
+	var list []string
+	for name, o := range objs {
+		list = append(list, name+"="+strconv.FormatFloat(o.Target, 'g', -1, 64)+"/"+o.Latency.String())
+	}
+	sort.Strings(list)
+	return strings.Join(list, ",")
+}
+
+// counts are the requests of a class counted in a bucket.
+type counts struct {
+	requests int64
+	errors   int64 // answered with a server error
+	slow     int64 // answered without error, but slower than the latency
+}
+
+func (c *counts) add(d counts) {
+	c.requests += d.requests
+	c.errors += d.errors
+	c.slow += d.slow
+}
+
+// A series holds the counts of a class for the last BudgetWindow.
+type series struct {
+	objective Objective
+	minute    [nbuckets]int64 // minute of each bucket, in minutes since 1970
+	counts    [nbuckets]counts
+}
+
+// A Tracker counts the requests of classes of routes
+// against their objectives. It is safe for concurrent use.
+type Tracker struct {
+	now func() time.Time
+
+	mu      sync.Mutex
+	classes map[string]*series
+}
+
+// New returns a Tracker for the classes of routes in objs.
+func New(objs Objectives) *Tracker {
+	t := &Tracker{now: time.Now, classes: make(map[string]*series)}
+	for name, o := range objs {
+		t.classes[name] = &series{objective: o}
+	}
+	return t
+}
+
+// Observe counts a request of class, answered with status after d.
+// Requests of classes without objectives are not counted.
+func (t *Tracker) Observe(class string, status int, d time.Duration) {
+	m := t.now().Unix() / int64(bucket/time.Second)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s := t.classes[class]
+	if s == nil {
+		return
+	}
+	i := m % int64(nbuckets)
+	if s.minute[i] != m {
+		s.minute[i] = m
+		s.counts[i] = counts{}
+	}
+	c := &s.counts[i]
+	c.requests++
+	switch {
+	case status >= 500:
+		c.errors++
+	case d > s.objective.Latency:
+		c.slow++
+	}
+}
+
+// Handler returns a handler that serves requests with h, counting each
+// in the class that classify returns for it. Requests for which classify
+// returns the empty string, such as those that stream, are not counted.
+func (t *Tracker) Handler(h http.Handler, classify func(*http.Request) string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		class := classify(r)
+		if class == "" {
+			h.ServeHTTP(w, r)
+			return
+		}
+		start := t.now()
+		sw := &statusWriter{ResponseWriter: w}
+		h.ServeHTTP(sw, r)
+		status := sw.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		t.Observe(class, status, t.now().Sub(start))
+	})
+}
+
+// A Report is the state of the error budgets of all classes.
+type Report struct {
+	Time    time.Time     `json:"time"`
+	Classes []ClassReport `json:"classes"`
+}
+
+// A ClassReport is the state of the error budgets of a class.
+type ClassReport struct {
+	Class   string  `json:"class"`
+	Target  float64 `json:"target"`
+	Latency string  `json:"latency"`
+
+	// AvailabilityBudget and LatencyBudget are the fractions
+	// of the budgets of the last BudgetWindow left:
+	// 1 if none is spent, and negative if more than all of it is.
+	AvailabilityBudget float64 `json:"availability_budget"`
+	LatencyBudget      float64 `json:"latency_budget"`
+
+	Windows []WindowReport `json:"windows"`
+}
+
+// A WindowReport reports the requests of a class in a recent window.
+type WindowReport struct {
+	Window   string `json:"window"` // as 5m or 1h
+	Requests int64  `json:"requests"`
+	Errors   int64  `json:"errors"` // answered with a server error
+	Slow     int64  `json:"slow"`   // answered without error, but slower than the latency
+
+	Availability float64 `json:"availability"` // fraction of requests without error
+	Fast         float64 `json:"fast"`         // fraction of requests without error answered in time
+
+	AvailabilityBurn float64 `json:"availability_burn"`
+	LatencyBurn      float64 `json:"latency_burn"`
+}
+
+// Report returns the state of the error budgets of the classes,
+// sorted by name.
+func (t *Tracker) Report() *Report {
+	now := t.now()
+	m := now.Unix() / int64(bucket/time.Second)
+	rep := &Report{Time: now.UTC()}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for name, s := range t.classes {
+		cr := ClassReport{
+			Class:   name,
+			Target:  s.objective.Target,
+			Latency: s.objective.Latency.String(),
+		}
+		budget := 1 - s.objective.Target
+		for _, w := range windows {
+			var c counts
+			for k := range int64(w / bucket) {
+				if i := (m - k) % int64(nbuckets); s.minute[i] == m-k {
+					c.add(s.counts[i])
+				}
+			}
+			wr := WindowReport{
+				Window:       label(w),
+				Requests:     c.requests,
+				Errors:       c.errors,
+				Slow:         c.slow,
+				Availability: 1 - fraction(c.errors, c.requests),
+				Fast:         1 - fraction(c.slow, c.requests-c.errors),
+			}
+			wr.AvailabilityBurn = (1 - wr.Availability) / budget
+			wr.LatencyBurn = (1 - wr.Fast) / budget
+			if w == BudgetWindow {
+				cr.AvailabilityBudget = 1 - wr.AvailabilityBurn
+				cr.LatencyBudget = 1 - wr.LatencyBurn
+			}
+			cr.Windows = append(cr.Windows, wr)
+		}
+		rep.Classes = append(rep.Classes, cr)
+	}
+	sort.Slice(rep.Classes, func(i, j int) bool { return rep.Classes[i].Class < rep.Classes[j].Class })
+	return rep
+}
+
+// fraction returns n/total, or 0 if total is 0.
+func fraction(n, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) / float64(total)
+}
+
+// label returns d in the form used for windows: 5m or 1h.
+func label(d time.Duration) string {
+	if d%time.Hour == 0 {
+		return strconv.Itoa(int(d/time.Hour)) + "h"
+	}
+	return strconv.Itoa(int(d/time.Minute)) + "m"
+}
+
+// ReportHandler serves the Report of t in JSON,
+// or, with ?class=name, only the report of that class.
+// Be careful. It is the caller’s responsibility to ensure that the handler is
+// only exposed to authorized users.
+func (t *Tracker) ReportHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" && r.Method != "HEAD" {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		rep := t.Report()
+		if class := r.FormValue("class"); class != "" {
+			var list []ClassReport
+			for _, cr := range rep.Classes {
+				if cr.Class == class {
+					list = append(list, cr)
+				}
+			}
+			if list == nil {
+				http.Error(w, "unknown class "+strconv.Quote(class), http.StatusNotFound)
+				return
+			}
+			rep.Classes = list
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		data, err := json.MarshalIndent(rep, "", "\t")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Write(append(data, '\n'))
+	})
+}
+
+// A statusWriter records the status of a reply.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if w.status == 0 && code >= 200 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *statusWriter) Flush() {
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap returns the underlying ResponseWriter,
+// for use by http.ResponseController.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,126 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slo
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseObjectives(t *testing.T) {
+	objs, err := ParseObjectives("pages=0.99/1s, dljson=75%/300ms")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := objs.String(); s != "dljson=0.75/300ms,pages=0.99/1s" {
+		t.Errorf("String = %q", s)
+	}
+	for _, s := range []string{"pages", "pages=0.99", "=0.99/1s", "pages=1/1s", "pages=0/1s", "pages=most/1s", "pages=0.99/soon", "pages=0.99/-1s"} {
+		if _, err := ParseObjectives(s); err == nil {
+			t.Errorf("ParseObjectives(%q) succeeded, want error", s)
+		}
+	}
+}
+
+func TestTracker(t *testing.T) {
+	tr := New(Objectives{"pages": {0.9, 100 * time.Millisecond}, "dl": {0.5, time.Second}})
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	tr.now = func() time.Time { return now }
+
+	// A day ago, beyond every window: forgotten.
+	tr.Observe("pages", 500, 0)
+	// Two hours ago: 10 requests, 1 error, 1 slow.
+	now = now.Add(22 * time.Hour)
+	for i := range 10 {
+		switch i {
+		case 0:
+			tr.Observe("pages", 503, 0)
+		case 1:
+			tr.Observe("pages", 200, time.Second)
+		default:
+			tr.Observe("pages", 200, time.Millisecond)
+		}
+	}
+	// Now: 10 requests, 2 errors.
+	now = now.Add(2 * time.Hour)
+	for i := range 10 {
+		status := 200
+		if i < 2 {
+			status = 500
+		}
+		tr.Observe("pages", status, time.Millisecond)
+	}
+	tr.Observe("unknown", 500, 0)
+
+	rep := tr.Report()
+	if len(rep.Classes) != 2 || rep.Classes[0].Class != "dl" || rep.Classes[1].Class != "pages" {
+		t.Fatalf("Report classes = %+v, want dl and pages", rep.Classes)
+	}
+	if dl := rep.Classes[0]; dl.AvailabilityBudget != 1 || dl.LatencyBudget != 1 || dl.Windows[0].Requests != 0 {
+		t.Errorf("dl without requests = %+v, want full budgets", dl)
+	}
+	type want struct {
+		window                 string
+		requests, errors, slow int64
+		availBurn, latencyBurn float64
+	}
+	pages := rep.Classes[1]
+	for i, w := range []want{
+		{"5m", 10, 2, 0, 2, 0},
+		{"1h", 10, 2, 0, 2, 0},
+		{"6h", 20, 3, 1, 1.5, 1.0 / 17 / 0.1},
+		{"24h", 20, 3, 1, 1.5, 1.0 / 17 / 0.1},
+	} {
+		got := pages.Windows[i]
+		if got.Window != w.window || got.Requests != w.requests || got.Errors != w.errors || got.Slow != w.slow ||
+			!near(got.AvailabilityBurn, w.availBurn) || !near(got.LatencyBurn, w.latencyBurn) {
+			t.Errorf("pages window %d = %+v, want %+v", i, got, w)
+		}
+	}
+	if !near(pages.AvailabilityBudget, -0.5) || !near(pages.LatencyBudget, 1-1.0/17/0.1) {
+		t.Errorf("pages budgets = %v, %v, want -0.5, %v", pages.AvailabilityBudget, pages.LatencyBudget, 1-1.0/17/0.1)
+	}
+}
+
+func near(x, y float64) bool {
+	return math.Abs(x-y) < 1e-9
+}
+
+func TestHandler(t *testing.T) {
+	tr := New(Objectives{"pages": {0.99, time.Second}})
+	h := tr.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/broken" {
+			http.Error(w, "broken", 500)
+		}
+	}), func(r *http.Request) string {
+		if r.URL.Path == "/stream" {
+			return ""
+		}
+		return "pages"
+	})
+	for _, path := range []string{"/", "/broken", "/stream"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	w := httptest.NewRecorder()
+	tr.ReportHandler().ServeHTTP(w, httptest.NewRequest("GET", "/_slo?class=pages", nil))
+	var rep Report
+	if err := json.Unmarshal(w.Body.Bytes(), &rep); err != nil {
+		t.Fatalf("report: %v\n%s", err, w.Body)
+	}
+	if len(rep.Classes) != 1 || rep.Classes[0].Windows[0].Requests != 2 || rep.Classes[0].Windows[0].Errors != 1 {
+		t.Errorf("report = %+v, want 2 pages requests, 1 error", rep)
+	}
+
+	w = httptest.NewRecorder()
+	tr.ReportHandler().ServeHTTP(w, httptest.NewRequest("GET", "/_slo?class=dl", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("report of unknown class: %d, want 404", w.Code)
+	}
+}
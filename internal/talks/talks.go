@@ -7,13 +7,13 @@ package talks
 import (
 	"io/fs"
 	"log"
-	"net"
 	"net/http"
 	"path"
 	"path/filepath"
 	"sort"
 	"strings"
 
+	"github.com/matttproud/yourtour/internal/clientip"
 	"github.com/matttproud/yourtour/internal/web"
 	"golang.org/x/tools/present"
 )
@@ -41,11 +41,7 @@ func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 	handled, err := h.dirList(w, r)
 	if err != nil {
-		addr, _, e := net.SplitHostPort(r.RemoteAddr)
-		if e != nil {
-			addr = r.RemoteAddr
-		}
-		log.Printf("request from %s: %s", addr, err)
+		log.Printf("request from %s: %s", clientip.FromRequest(r), err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -0,0 +1,192 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package tenant describes the sections of the site maintained apart
+// from its main content, by other owners, such as the tour or the blog,
+// each served from a content root of its own.
+//
+// A section is mounted below a path prefix of a host, such as go.dev/tour,
+// or as a whole host, such as tour.example.com. Its content root holds
+// its pages, its layouts, starting with site.tmpl and error.tmpl,
+// and optionally its redirects in redirects.yaml, in the format read
+// by redirect.Rules.LoadFS. The server gives each section its own
+// virtual host (see package vhost), and so its own search index.
+//
+// A section mounted below /tour is served as if its content root were
+// the tour directory of the site: its page index.md is served as /tour/,
+// and its links must include the prefix.
+package tenant
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+	"time"
+)
+
+// RedirectsFile is the name of the redirects file in a content root.
+// It is not served.
+const RedirectsFile = "redirects.yaml"
+
+// A Mount says where a section is served from and where it is served.
+type Mount struct {
+	Host   string // host name, or empty for the default host
+	Prefix string // path prefix, such as "/tour", or empty for the whole host
+	Dir    string // directory holding the section's content root
+}
+
+// Pattern returns the host and prefix of m, as in a mount list.
+func (m Mount) Pattern() string {
+	return m.Host + m.Prefix
+}
+
+// ParseMounts parses a list of mounts, such as
+// "/tour=/srv/tour,docs.example.com=/srv/docs,go.dev/blog=/srv/blog",
+// each naming the host and path prefix where a section is served
+// and the directory holding its content.
+// A mount with no host is on the default host.
+func ParseMounts(s string) ([]Mount, error) {
+	var list []Mount
+	seen := make(map[string]bool)
+	for _, f := range strings.Split(s, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		pattern, dir, ok := strings.Cut(f, "=")
+		host, prefix, _ := strings.Cut(pattern, "/")
+		m := Mount{Host: strings.ToLower(host), Prefix: strings.TrimSuffix("/"+prefix, "/"), Dir: dir}
+		switch {
+		case !ok || dir == "":
+			return nil, fmt.Errorf("invalid mount %q; want host/prefix=dir", f)
+		case m.Host == "" && m.Prefix == "":
+			return nil, fmt.Errorf("invalid mount %q: no host or prefix; want host/prefix=dir with a host, a prefix, or both", f)
+		case m.Prefix != "" && m.Prefix != path.Clean(m.Prefix):
+			return nil, fmt.Errorf("invalid mount %q: unclean prefix %q", f, m.Prefix)
+		case seen[m.Pattern()]:
+			return nil, fmt.Errorf("invalid mount %q: %s already mounted", f, m.Pattern())
+		}
+		seen[m.Pattern()] = true
+		list = append(list, m)
+	}
+	return list, nil
+}
+
+// FS returns the content root root of a section mounted below prefix,
+// as its site serves it: with the files of root in the directory
+// named by prefix, and the layouts at the top of root, such as site.tmpl,
+// also at the top, where the site looks for its base layouts.
+// The redirects file is hidden.
+func FS(prefix string, root fs.FS) fs.FS {
+	return &prefixFS{dir: strings.Trim(prefix, "/"), root: root}
+}
+
+// A prefixFS is a content root mounted on dir.
+type prefixFS struct {
+	dir  string // mount point, or "" for the root
+	root fs.FS
+}
+
+func (p *prefixFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	rel := name
+	switch {
+	case p.dir == "":
+		// Mounted as a whole host.
+	case name == p.dir:
+		rel = "."
+	case strings.HasPrefix(name, p.dir+"/"):
+		rel = name[len(p.dir)+1:]
+	case name == "." || strings.HasPrefix(p.dir, name+"/"):
+		// A parent of the mount point, listing only the way to it.
+		child, _, _ := strings.Cut(strings.TrimPrefix(p.dir, strings.TrimSuffix(name+"/", "./")), "/")
+		return &parentDir{name: path.Base(name), child: child}, nil
+	case path.Ext(name) == ".tmpl" && !strings.Contains(name, "/"):
+		// A base layout.
+	default:
+		rel = ""
+	}
+	if rel == "" || rel == RedirectsFile {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	f, err := p.root.Open(rel)
+	if d, ok := f.(fs.ReadDirFile); ok && rel == "." {
+		return &rootDir{d, path.Base(name)}, nil
+	}
+	return f, err
+}
+
+// A rootDir is the top directory of a content root,
+// named for its mount point and not listing the redirects file.
+type rootDir struct {
+	fs.ReadDirFile
+	name string
+}
+
+func (d *rootDir) Stat() (fs.FileInfo, error) {
+	info, err := d.ReadDirFile.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return namedInfo{info, d.name}, nil
+}
+
+func (d *rootDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	for {
+		list, err := d.ReadDirFile.ReadDir(n)
+		list = slices.DeleteFunc(list, func(e fs.DirEntry) bool { return e.Name() == RedirectsFile })
+		if len(list) > 0 || err != nil || n <= 0 {
+			return list, err
+		}
+	}
+}
+
+// A namedInfo is an fs.FileInfo with another name.
+type namedInfo struct {
+	fs.FileInfo
+	name string
+}
+
+func (i namedInfo) Name() string { return i.name }
+
+// A parentDir is a directory above the mount point of a prefixFS,
+// holding only the next directory on the way to it.
+type parentDir struct {
+	name  string
+	child string
+	done  bool
+}
+
+func (d *parentDir) Stat() (fs.FileInfo, error) { return dirInfo(d.name), nil }
+func (d *parentDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.name, Err: errors.New("is a directory")}
+}
+func (d *parentDir) Close() error { return nil }
+
+func (d *parentDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	if d.done {
+		if n > 0 {
+			return nil, io.EOF
+		}
+		return nil, nil
+	}
+	d.done = true
+	return []fs.DirEntry{fs.FileInfoToDirEntry(dirInfo(d.child))}, nil
+}
+
+// A dirInfo is the fs.FileInfo of a parentDir.
+type dirInfo string
+
+func (i dirInfo) Name() string     { return string(i) }
+func (dirInfo) Size() int64        { return 0 }
+func (dirInfo) Mode() fs.FileMode  { return fs.ModeDir | 0o555 }
+func (dirInfo) ModTime() time.Time { return time.Time{} }
+func (dirInfo) IsDir() bool        { return true }
+func (dirInfo) Sys() any           { return nil }
@@ -0,0 +1,103 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tenant
+
+import (
+	"io/fs"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/matttproud/yourtour/internal/web"
+)
+
+func TestParseMounts(t *testing.T) {
+	for _, tt := range []struct {
+		in   string
+		want []Mount
+		err  string
+	}{
+		{"", nil, ""},
+		{"/tour=/srv/tour", []Mount{{"", "/tour", "/srv/tour"}}, ""},
+		{" Docs.Example.com=docs , go.dev/blog/=/srv/blog", []Mount{{"docs.example.com", "", "docs"}, {"go.dev", "/blog", "/srv/blog"}}, ""},
+		{"go.dev/doc/ref=ref", []Mount{{"go.dev", "/doc/ref", "ref"}}, ""},
+		{"/tour", nil, "want host/prefix=dir"},
+		{"/tour=", nil, "want host/prefix=dir"},
+		{"/=/srv", nil, "no host or prefix"},
+		{"/a//b=x", nil, "unclean prefix"},
+		{"/a/../b=x", nil, "unclean prefix"},
+		{"/tour=a,/tour/=b", nil, "/tour already mounted"},
+	} {
+		got, err := ParseMounts(tt.in)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("ParseMounts(%q) = %v, %v, want error containing %q", tt.in, got, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseMounts(%q) = %v, %v, want %v", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func testRoot() fstest.MapFS {
+	return fstest.MapFS{
+		"site.tmpl":       {Data: []byte(`{{.URL}}: {{.Content}}`)},
+		"error.tmpl":      {Data: []byte(`{{define "layout"}}{{end}}`)},
+		"index.md":        {Data: []byte("Welcome")},
+		"basics/index.md": {Data: []byte("Basics")},
+		"gopher.png":      {Data: []byte("png")},
+		RedirectsFile:     {Data: []byte("/old: /new\n")},
+	}
+}
+
+func TestFS(t *testing.T) {
+	fsys := FS("/doc/tour/", testRoot())
+	if err := fstest.TestFS(fsys, "doc/tour/index.md", "doc/tour/basics/index.md", "doc/tour/gopher.png", "doc/tour/site.tmpl", "doc/tour/error.tmpl"); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]bool{
+		"site.tmpl":                 true,
+		"index.md":                  false,
+		"gopher.png":                false,
+		"doc/index.md":              false,
+		"doc/tour/" + RedirectsFile: false,
+	} {
+		if _, err := fs.Stat(fsys, name); (err == nil) != want {
+			t.Errorf("Stat(%s) = %v, want found %v", name, err, want)
+		}
+	}
+
+	whole := FS("", testRoot())
+	if err := fstest.TestFS(whole, "index.md", "site.tmpl", "basics/index.md"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fs.Stat(whole, RedirectsFile); err == nil {
+		t.Errorf("Stat(%s) succeeded, want hidden", RedirectsFile)
+	}
+}
+
+func TestServe(t *testing.T) {
+	site := web.NewSite(FS("/tour", testRoot()))
+	for url, want := range map[string]string{
+		"/tour/":           "/tour/: <p>Welcome</p>\n",
+		"/tour/basics/":    "/tour/basics/: <p>Basics</p>\n",
+		"/tour/gopher.png": "png",
+	} {
+		w := httptest.NewRecorder()
+		site.ServeHTTP(w, httptest.NewRequest("GET", url, nil))
+		if w.Code != 200 || w.Body.String() != want {
+			t.Errorf("GET %s = %d %q, want 200 %q", url, w.Code, w.Body, want)
+		}
+	}
+	w := httptest.NewRecorder()
+	site.ServeHTTP(w, httptest.NewRequest("GET", "/tour/"+RedirectsFile, nil))
+	if w.Code != 404 {
+		t.Errorf("GET /tour/%s = %d, want 404", RedirectsFile, w.Code)
+	}
+}
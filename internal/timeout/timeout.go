@@ -0,0 +1,195 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package timeout bounds the time handlers may take to serve requests.
+//
+// A request that takes too long gets a 503 Service Unavailable reply,
+// and its context is canceled, so that datastore, cache, and other calls
+// made with it give up rather than holding the handler's goroutine
+// on a stuck dependency.
+package timeout
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Rules map URL path prefixes to the timeout for requests under them.
+// The longest matching prefix applies; the prefix "*" gives the timeout
+// for requests matching no other prefix. A timeout of zero means none.
+type Rules map[string]time.Duration
+
+// ParseRules parses a comma-separated list of rules of the form
+// prefix=timeout, where timeout is a duration or "off". For example:
+//
+//	*=30s,/dl/=10s,/debug/pprof/=off
+func ParseRules(s string) (Rules, error) {
+	rules := make(Rules)
+	for _, f := range strings.Split(s, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		prefix, spec, ok := strings.Cut(f, "=")
+		if !ok || prefix != "*" && !strings.HasPrefix(prefix, "/") {
+			return nil, fmt.Errorf("invalid rule %q; want /path/prefix=timeout or *=timeout", f)
+		}
+		var d time.Duration
+		if spec != "off" {
+			var err error
+			if d, err = time.ParseDuration(spec); err != nil || d <= 0 {
+				return nil, fmt.Errorf("invalid rule %q: bad timeout %q; want a duration like 30s, or off", f, spec)
+			}
+		}
+		rules[prefix] = d
+	}
+	return rules, nil
+}
+
+// Merge returns the rules rs overridden by the rules in override.
+func (rs Rules) Merge(override Rules) Rules {
+	m := make(Rules, len(rs)+len(override))
+	for p, d := range rs {
+		m[p] = d
+	}
+	for p, d := range override {
+		m[p] = d
+	}
+	return m
+}
+
+// For returns the timeout for a request for path, or 0 if none.
+func (rs Rules) For(path string) time.Duration {
+	best := ""
+	found := false
+	for p := range rs {
+		if p != "*" && strings.HasPrefix(path, p) && len(p) >= len(best) {
+			best, found = p, true
+		}
+	}
+	if !found {
+		return rs["*"]
+	}
+	return rs[best]
+}
+
+// String returns the rules in the form read by ParseRules.
+func (rs Rules) String() string {
+	var list []string
+	for p, d := range rs {
+		s := "off"
+		if d > 0 {
+			s = d.String()
+		}
+		list = append(list, p+"="+s)
+	}
+	sort.Strings(list)
+	return strings.Join(list, ",")
+}
+
+// Handler returns a handler that serves requests with h,
+// allowing each the time given by rules for its path.
+// When a request runs out of time, the reply h has prepared is discarded,
+// the request's context is canceled, and the request is answered by
+// calling timedOut, which should reply with a 503 error.
+//
+// Much as for http.TimeoutHandler, which Handler resembles,
+// replies are buffered until h returns, and h's ResponseWriter
+// does not support flushing. Routes that stream, such as profiling,
+// should be given no timeout.
+func Handler(h http.Handler, rules Rules, timedOut http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		d := rules.For(r.URL.Path)
+		if d <= 0 {
+			h.ServeHTTP(w, r)
+			return
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), d)
+		defer cancel()
+
+		tw := &writer{header: make(http.Header)}
+		done := make(chan struct{})
+		panicked := make(chan any, 1)
+		go func() {
+			defer func() {
+				if p := recover(); p != nil {
+					if p != http.ErrAbortHandler {
+						// Keep the stack of the goroutine that panicked.
+						p = fmt.Sprintf("%v\n\n%s", p, debug.Stack())
+					}
+					panicked <- p
+				}
+			}()
+			h.ServeHTTP(tw, r.WithContext(ctx))
+			close(done)
+		}()
+		select {
+		case p := <-panicked:
+			// Let the server's panic handling see it.
+			panic(p)
+		case <-done:
+			tw.mu.Lock()
+			defer tw.mu.Unlock()
+			for k, v := range tw.header {
+				w.Header()[k] = v
+			}
+			if tw.code == 0 {
+				tw.code = http.StatusOK
+			}
+			w.WriteHeader(tw.code)
+			w.Write(tw.buf.Bytes())
+		case <-ctx.Done():
+			tw.mu.Lock()
+			tw.timedOut = true
+			tw.mu.Unlock()
+			if ctx.Err() == context.DeadlineExceeded && r.Context().Err() == nil {
+				timedOut(w, r)
+			}
+			// Otherwise the client has gone away, and there is no one to reply to.
+		}
+	})
+}
+
+// A writer buffers a reply until the handler completes,
+// discarding it if the handler runs out of time.
+type writer struct {
+	header http.Header
+
+	mu       sync.Mutex
+	buf      bytes.Buffer
+	code     int
+	timedOut bool
+}
+
+func (w *writer) Header() http.Header {
+	return w.header
+}
+
+func (w *writer) Write(b []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	return w.buf.Write(b)
+}
+
+func (w *writer) WriteHeader(code int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timedOut || w.code != 0 || code < 200 {
+		return
+	}
+	w.code = code
+}
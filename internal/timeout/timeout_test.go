@@ -0,0 +1,136 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package timeout
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRules(t *testing.T) {
+	rules, err := ParseRules("*=30s, /dl/=10s,/debug/pprof/=off,/dl/upload=1m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := rules.String(); s != "*=30s,/debug/pprof/=off,/dl/=10s,/dl/upload=1m0s" {
+		t.Errorf("String = %q", s)
+	}
+	tests := []struct {
+		path string
+		want time.Duration
+	}{
+		{"/", 30 * time.Second},
+		{"/doc/", 30 * time.Second},
+		{"/dl/", 10 * time.Second},
+		{"/dl/?mode=json", 10 * time.Second},
+		{"/dl/upload", time.Minute},
+		{"/debug/pprof/profile", 0},
+	}
+	for _, tt := range tests {
+		if d := rules.For(tt.path); d != tt.want {
+			t.Errorf("For(%q) = %v, want %v", tt.path, d, tt.want)
+		}
+	}
+
+	merged := rules.Merge(Rules{"*": time.Second, "/dl/": 0})
+	if d := merged.For("/"); d != time.Second {
+		t.Errorf("merged For(/) = %v, want 1s", d)
+	}
+	if d := merged.For("/dl/"); d != 0 {
+		t.Errorf("merged For(/dl/) = %v, want 0", d)
+	}
+	if d := rules.For("/"); d != 30*time.Second {
+		t.Errorf("Merge modified receiver")
+	}
+	if d := (Rules{"/dl/": time.Second}).For("/"); d != 0 {
+		t.Errorf("For without default = %v, want 0", d)
+	}
+
+	for _, s := range []string{"dl=10s", "/dl/", "/dl/=10", "/dl/=-1s", "*=forever"} {
+		if _, err := ParseRules(s); err == nil {
+			t.Errorf("ParseRules(%q) succeeded, want error", s)
+		}
+	}
+}
+
+func TestHandler(t *testing.T) {
+	stuck := make(chan error, 1)
+	h := http.NewServeMux()
+	h.HandleFunc("/fast", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Test", "fast")
+		w.WriteHeader(http.StatusTeapot)
+		io.WriteString(w, "done")
+	})
+	h.HandleFunc("/stuck", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "partial")
+		<-r.Context().Done()
+		time.Sleep(10 * time.Millisecond) // let Handler see the timeout too
+		_, err := io.WriteString(w, "more")
+		stuck <- err
+	})
+	h.HandleFunc("/untimed", func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Deadline(); ok {
+			t.Errorf("untimed request has a deadline")
+		}
+	})
+	h.HandleFunc("/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+	rules := Rules{"*": 50 * time.Millisecond, "/untimed": 0}
+	timedOut := func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "timed out", http.StatusServiceUnavailable)
+	}
+	th := Handler(h, rules, timedOut)
+	serve := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		th.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w
+	}
+
+	w := serve("/fast")
+	if w.Code != http.StatusTeapot || w.Body.String() != "done" || w.Header().Get("X-Test") != "fast" {
+		t.Errorf("/fast: %d %q %v", w.Code, w.Body, w.Header())
+	}
+
+	w = serve("/stuck")
+	if w.Code != http.StatusServiceUnavailable || strings.Contains(w.Body.String(), "partial") {
+		t.Errorf("/stuck: %d %q, want 503 without partial reply", w.Code, w.Body)
+	}
+	if err := <-stuck; err != http.ErrHandlerTimeout {
+		t.Errorf("write after timeout: %v, want http.ErrHandlerTimeout", err)
+	}
+
+	serve("/untimed")
+
+	func() {
+		defer func() {
+			p := recover()
+			if s, _ := p.(string); !strings.Contains(s, "boom") || !strings.Contains(s, "goroutine") {
+				t.Errorf("recovered %v, want panic with stack", p)
+			}
+		}()
+		serve("/panic")
+	}()
+}
+
+func TestClientGone(t *testing.T) {
+	called := false
+	th := Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}), Rules{"*": time.Minute}, func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	th.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil).WithContext(ctx))
+	if called {
+		t.Errorf("timedOut called for canceled request")
+	}
+}
@@ -0,0 +1,194 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tour
+
+import (
+	"encoding/json"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/matttproud/yourtour"
+	"github.com/matttproud/yourtour/internal/memcache"
+	"github.com/matttproud/yourtour/internal/web"
+)
+
+func newAPIServer(t *testing.T) http.Handler {
+	t.Helper()
+	mux := http.NewServeMux()
+	if err := RegisterHandlers(mux, web.NewSite(website.Content()), memcache.NewMemory(0)); err != nil {
+		t.Fatal(err)
+	}
+	return mux
+}
+
+func serve(h http.Handler, method, url, token, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, url, strings.NewReader(body))
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestModules(t *testing.T) {
+	files, err := fs.ReadDir(contentTour, "tour")
+	if err != nil {
+		t.Fatal(err)
+	}
+	listed := make(map[string]int)
+	for _, name := range lessonOrder() {
+		listed[name]++
+	}
+	for _, f := range files {
+		if path.Ext(f.Name()) != ".article" {
+			continue
+		}
+		name := strings.TrimSuffix(f.Name(), ".article")
+		if n := listed[name]; n != 1 {
+			t.Errorf("lesson %s is listed in modules %d times, want once", name, n)
+		}
+		delete(listed, name)
+	}
+	for name := range listed {
+		t.Errorf("modules lists lesson %s, which has no article", name)
+	}
+}
+
+func TestManifest(t *testing.T) {
+	h := newAPIServer(t)
+	w := serve(h, "GET", "/tour/api/manifest", "", "")
+	if w.Code != 200 {
+		t.Fatalf("GET manifest: %d\n%s", w.Code, w.Body)
+	}
+	var m struct{ Modules []manifestModule }
+	if err := json.Unmarshal(w.Body.Bytes(), &m); err != nil {
+		t.Fatal(err)
+	}
+	var all []manifestLesson
+	for _, mod := range m.Modules {
+		all = append(all, mod.Lessons...)
+	}
+	if len(all) != len(lessonOrder()) {
+		t.Fatalf("manifest has %d lessons, want %d", len(all), len(lessonOrder()))
+	}
+	for i, l := range all {
+		if l.Title == "" || l.Pages == 0 {
+			t.Errorf("lesson %s: title %q, %d pages", l.ID, l.Title, l.Pages)
+		}
+		var prev, next string
+		if i > 0 {
+			prev = all[i-1].ID
+		}
+		if i+1 < len(all) {
+			next = all[i+1].ID
+		}
+		if l.Prev != prev || l.Next != next {
+			t.Errorf("lesson %s: prev, next = %q, %q, want %q, %q", l.ID, l.Prev, l.Next, prev, next)
+		}
+	}
+
+	if w := serve(h, "POST", "/tour/api/manifest", "", ""); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST manifest: %d, want 405", w.Code)
+	}
+}
+
+func TestText(t *testing.T) {
+	h := newAPIServer(t)
+	w := serve(h, "GET", "/tour/text/flowcontrol", "", "")
+	if w.Code != 200 {
+		t.Fatalf("GET flowcontrol: %d\n%s", w.Code, w.Body)
+	}
+	for _, want := range []string{
+		`<h1>A Tour of Go: Flow control statements: for, if, else, switch and defer</h1>`,
+		`<a href="/tour/flowcontrol/1">Run in the tour</a>`,
+		`rel="prev">&larr; Packages, variables, and functions.</a>`,
+		`href="/tour/text/moretypes" rel="next"`,
+	} {
+		if !strings.Contains(w.Body.String(), want) {
+			t.Errorf("flowcontrol page missing %s", want)
+		}
+	}
+
+	if w := serve(h, "GET", "/tour/text/nosuch", "", ""); w.Code != http.StatusNotFound {
+		t.Errorf("GET nosuch: %d, want 404", w.Code)
+	}
+	if w := serve(h, "GET", "/tour/text/", "", ""); w.Code != http.StatusFound || w.Header().Get("Location") != "/tour/text/welcome" {
+		t.Errorf("GET /tour/text/: %d to %q, want redirect to first lesson", w.Code, w.Header().Get("Location"))
+	}
+}
+
+func TestProgress(t *testing.T) {
+	h := newAPIServer(t)
+	w := serve(h, "POST", "/tour/api/token", "", "")
+	var tok struct{ Token string }
+	if err := json.Unmarshal(w.Body.Bytes(), &tok); err != nil || w.Code != 200 {
+		t.Fatalf("POST token: %d %v\n%s", w.Code, err, w.Body)
+	}
+	other := newToken()
+	token := tok.Token
+
+	load := func(token string) progress {
+		t.Helper()
+		w := serve(h, "GET", "/tour/api/progress", token, "")
+		var p progress
+		if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil || w.Code != 200 {
+			t.Fatalf("GET progress: %d %v\n%s", w.Code, err, w.Body)
+		}
+		return p
+	}
+	if p := load(token); len(p.Lessons) != 0 {
+		t.Errorf("new token has progress %+v", p)
+	}
+
+	for _, tt := range []struct {
+		lesson, body string
+		code         int
+	}{
+		{"basics", `{"Page": 3}`, 200},
+		{"basics", `{"Page": 5, "Completed": true}`, 200},
+		{"basics", `{"Page": 1}`, 200},
+		{"flowcontrol", `{"Page": 2}`, 200},
+		{"flowcontrol", `{"Page": 0}`, http.StatusBadRequest},
+		{"flowcontrol", `{"Page": 1000}`, http.StatusBadRequest},
+		{"flowcontrol", `{"Page":`, http.StatusBadRequest},
+		{"nosuch", `{"Page": 1}`, http.StatusNotFound},
+	} {
+		if w := serve(h, "PUT", "/tour/api/progress/"+tt.lesson, token, tt.body); w.Code != tt.code {
+			t.Errorf("PUT %s %s: %d, want %d\n%s", tt.lesson, tt.body, w.Code, tt.code, w.Body)
+		}
+	}
+
+	p := load(token)
+	if b := p.Lessons["basics"]; b.Page != 1 || !b.Completed || b.Updated.IsZero() {
+		t.Errorf("basics progress = %+v, want page 1, completed", b)
+	}
+	if f := p.Lessons["flowcontrol"]; f.Page != 2 || f.Completed {
+		t.Errorf("flowcontrol progress = %+v, want page 2", f)
+	}
+	if p := load(other); len(p.Lessons) != 0 {
+		t.Errorf("other token has progress %+v", p)
+	}
+
+	if w := serve(h, "DELETE", "/tour/api/progress", token, ""); w.Code != 200 {
+		t.Errorf("DELETE progress: %d", w.Code)
+	}
+	if p := load(token); len(p.Lessons) != 0 {
+		t.Errorf("progress after DELETE = %+v", p)
+	}
+
+	for _, token := range []string{"", "short", token + "x"} {
+		if w := serve(h, "GET", "/tour/api/progress", token, ""); w.Code != http.StatusUnauthorized {
+			t.Errorf("GET progress with token %q: %d, want 401", token, w.Code)
+		}
+	}
+	if w := serve(h, "GET", "/tour/api/token", "", ""); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET token: %d, want 405", w.Code)
+	}
+}
@@ -11,9 +11,16 @@ import (
 	"io"
 	"net/http"
 	"os"
+
+	"github.com/matttproud/yourtour/internal/memcache"
+	"github.com/matttproud/yourtour/internal/web"
 )
 
-func RegisterHandlers(mux *http.ServeMux) error {
+// RegisterHandlers registers the tour's handlers on mux:
+// the tour UI and its lessons, the lesson manifest API,
+// the lessons' text pages rendered by site, and the progress API,
+// which keeps readers' progress in mc, or in memory if mc is nil.
+func RegisterHandlers(mux *http.ServeMux, site *web.Site, mc memcache.Cache) error {
 	prepContent = gaePrepContent
 	socketAddr = gaeSocketAddr
 	analyticsHTML = template.HTML(os.Getenv("TOUR_ANALYTICS"))
@@ -22,6 +29,16 @@ func RegisterHandlers(mux *http.ServeMux) error {
 		return err
 	}
 
+	if mc == nil {
+		mc = memcache.NewMemory(0)
+	}
+	ps := &progressServer{store: newCacheStore(mc)}
+	mux.HandleFunc("/tour/api/manifest", manifestHandler)
+	mux.HandleFunc("/tour/api/token", ps.serveToken)
+	mux.HandleFunc("/tour/api/progress", ps.serveProgress)
+	mux.HandleFunc("/tour/api/progress/", ps.serveProgress)
+	mux.Handle("/tour/text/", textHandler(site))
+
 	return nil
 }
 
@@ -0,0 +1,209 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tour
+
+import (
+	"encoding/json"
+	"html/template"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/matttproud/yourtour/internal/web"
+)
+
+// A module is a group of lessons in the tour's table of contents.
+type module struct {
+	ID          string
+	Title       string
+	Description string // HTML
+	Lessons     []string
+}
+
+// modules is the tour's table of contents, in order.
+// Keep in sync with tableOfContents in _content/tour/static/js/values.js,
+// from which the tour UI builds its own.
+var modules = []module{
+	{
+		ID:          "mechanics",
+		Title:       "Using the tour",
+		Description: `<p>Welcome to a tour of the <a href="https://go.dev">Go programming language</a>. The tour covers the most important features of the language, mainly:</p>`,
+		Lessons:     []string{"welcome"},
+	},
+	{
+		ID:          "basics",
+		Title:       "Basics",
+		Description: `<p>The starting point, learn all the basics of the language.</p><p>Declaring variables, calling functions, and all the things you need to know before moving to the next lessons.</p>`,
+		Lessons:     []string{"basics", "flowcontrol", "moretypes"},
+	},
+	{
+		ID:          "methods",
+		Title:       "Methods and interfaces",
+		Description: `<p>Learn how to define methods on types, how to declare interfaces, and how to put everything together.</p>`,
+		Lessons:     []string{"methods"},
+	},
+	{
+		ID:          "generics",
+		Title:       "Generics",
+		Description: `<p>Learn how to use type parameters in Go functions and structs.</p>`,
+		Lessons:     []string{"generics"},
+	},
+	{
+		ID:          "concurrency",
+		Title:       "Concurrency",
+		Description: `<p>Go provides concurrency features as part of the core language.</p><p>This module goes over goroutines and channels, and how they are used to implement different concurrency patterns.</p>`,
+		Lessons:     []string{"concurrency"},
+	},
+}
+
+// lessonOrder returns the names of the tour's lessons, in order.
+func lessonOrder() []string {
+	var list []string
+	for _, m := range modules {
+		list = append(list, m.Lessons...)
+	}
+	return list
+}
+
+// neighbors returns the names of the lessons before and after
+// the named lesson in the tour, or the empty string
+// for the first and last lessons.
+func neighbors(name string) (prev, next string) {
+	list := lessonOrder()
+	for i, l := range list {
+		if l != name {
+			continue
+		}
+		if i > 0 {
+			prev = list[i-1]
+		}
+		if i+1 < len(list) {
+			next = list[i+1]
+		}
+		break
+	}
+	return prev, next
+}
+
+// manifestLesson defines the JSON form of a lesson in the manifest.
+type manifestLesson struct {
+	ID          string
+	Title       string
+	Description string
+	Pages       int
+	Prev        string `json:",omitempty"`
+	Next        string `json:",omitempty"`
+}
+
+// manifestModule defines the JSON form of a module in the manifest.
+type manifestModule struct {
+	ID          string
+	Title       string
+	Description string
+	Lessons     []manifestLesson
+}
+
+// manifest returns the tour's modules and their lessons, in order.
+func manifest() []manifestModule {
+	list := make([]manifestModule, 0, len(modules))
+	for _, m := range modules {
+		mm := manifestModule{ID: m.ID, Title: m.Title, Description: m.Description}
+		for _, name := range m.Lessons {
+			l, ok := parsedLessons[name]
+			if !ok {
+				continue
+			}
+			prev, next := neighbors(name)
+			mm.Lessons = append(mm.Lessons, manifestLesson{
+				ID:          name,
+				Title:       l.Title,
+				Description: l.Description,
+				Pages:       len(l.Pages),
+				Prev:        prev,
+				Next:        next,
+			})
+		}
+		list = append(list, mm)
+	}
+	return list
+}
+
+// manifestHandler serves the lesson manifest at /tour/api/manifest.
+func manifestHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" && r.Method != "HEAD" {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, struct{ Modules []manifestModule }{manifest()})
+}
+
+// writeJSON writes v to w as the JSON body of a successful reply.
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("ERROR tour: writing JSON: %v", err)
+	}
+}
+
+// textPage is a lesson page as shown on the lesson's text page.
+type textPage struct {
+	Num     int // 1-based, as in the tour UI's URLs
+	Title   string
+	Content template.HTML
+	Files   []file
+}
+
+// textHandler returns a handler serving each lesson as a single page
+// rendered by site at /tour/text/<lesson>, for reading the tour
+// without its UI. Each snippet links to its page in the tour UI,
+// where it can be run.
+func textHandler(site *web.Site) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, "/tour/text/")
+		if name == "" {
+			http.Redirect(w, r, "/tour/text/"+lessonOrder()[0], http.StatusFound)
+			return
+		}
+		l, ok := parsedLessons[name]
+		if !ok {
+			site.ServeErrorStatus(w, r, lessonNotFound, http.StatusNotFound)
+			return
+		}
+		pages := make([]textPage, len(l.Pages))
+		for i, p := range l.Pages {
+			// The lesson content is rendered from the tour's own articles.
+			pages[i] = textPage{Num: i + 1, Title: p.Title, Content: template.HTML(p.Content), Files: p.Files}
+		}
+		prev, next := neighbors(name)
+		site.ServePage(w, r, web.Page{
+			"title":    "A Tour of Go: " + l.Title,
+			"tabTitle": l.Title,
+			"layout":   "tourlesson",
+			"lesson":   name,
+			"subtitle": l.Description,
+			"pages":    pages,
+			"prev":     lessonLink(prev),
+			"next":     lessonLink(next),
+		})
+	})
+}
+
+// A link is a link to a lesson's text page.
+type link struct {
+	URL   string
+	Title string
+}
+
+// lessonLink returns the link to the named lesson's text page,
+// or nil if name is the empty string.
+func lessonLink(name string) *link {
+	l, ok := parsedLessons[name]
+	if !ok {
+		return nil
+	}
+	return &link{URL: "/tour/text/" + name, Title: l.Title}
+}
@@ -0,0 +1,221 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tour
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/matttproud/yourtour/internal/memcache"
+)
+
+// A progress is a reader's progress through the tour.
+type progress struct {
+	Lessons map[string]lessonProgress // keyed by lesson name
+}
+
+// A lessonProgress is a reader's progress through one lesson.
+type lessonProgress struct {
+	Page      int // last page visited, 1-based
+	Completed bool
+	Updated   time.Time
+}
+
+// A store stores readers' progress, keyed by their anonymous tokens.
+type store interface {
+	// Load returns the progress saved for token,
+	// which is empty if none has been saved.
+	Load(ctx context.Context, token string) (*progress, error)
+
+	// Update applies f to the progress saved for token
+	// and saves and returns the result.
+	Update(ctx context.Context, token string, f func(*progress)) (*progress, error)
+}
+
+// progressTTL is how long a reader's progress is kept
+// after it was last updated.
+const progressTTL = 180 * 24 * time.Hour
+
+// casRetries is how many times cacheStore.Update retries
+// after losing a race with a concurrent update.
+const casRetries = 5
+
+// A cacheStore is a store keeping progress in a cache.
+// Progress in a cache can be evicted early; readers then start over,
+// which the anonymous tokens make unavoidable anyway.
+type cacheStore struct {
+	cache *memcache.CodecClient
+}
+
+// newCacheStore returns a store keeping progress in mc.
+func newCacheStore(mc memcache.Cache) store {
+	return &cacheStore{memcache.NewCodecClient(mc, memcache.JSON)}
+}
+
+// progressKey returns the cache key for token's progress.
+// The token is hashed so that the cache holds no usable tokens.
+func progressKey(token string) string {
+	return fmt.Sprintf("tour:progress:%x", sha256.Sum256([]byte(token)))
+}
+
+func (s *cacheStore) Load(ctx context.Context, token string) (*progress, error) {
+	p := new(progress)
+	err := s.cache.Get(ctx, progressKey(token), p)
+	if err != nil && err != memcache.ErrCacheMiss {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (s *cacheStore) Update(ctx context.Context, token string, f func(*progress)) (*progress, error) {
+	key := progressKey(token)
+	for i := 0; i < casRetries; i++ {
+		p := new(progress)
+		item, err := s.cache.GetItem(ctx, key, p)
+		if err == memcache.ErrCacheMiss {
+			f(p)
+			err = s.cache.Add(ctx, &memcache.Item{Key: key, Object: p, Expiration: progressTTL})
+			if err == memcache.ErrNotStored {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			return p, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		f(p)
+		item.Expiration = progressTTL
+		err = s.cache.CompareAndSwap(ctx, item)
+		if err == memcache.ErrCASConflict || err == memcache.ErrNotStored {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return p, nil
+	}
+	return nil, errors.New("too many concurrent updates")
+}
+
+// tokenBytes is the number of random bytes in a token.
+const tokenBytes = 16
+
+// newToken returns a new anonymous token.
+func newToken() string {
+	b := make([]byte, tokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// requestToken returns the token in the Authorization header of r,
+// which has the form "Bearer <token>", reporting whether it is well-formed.
+func requestToken(r *http.Request) (string, bool) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return "", false
+	}
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	return token, err == nil && len(b) == tokenBytes
+}
+
+// maxProgressBody is the largest accepted progress update body.
+const maxProgressBody = 1 << 10
+
+// A progressServer serves the progress API:
+//
+//	POST /tour/api/token          issue a new token
+//	GET /tour/api/progress        progress for the token
+//	PUT /tour/api/progress/<name> record progress through a lesson
+//	DELETE /tour/api/progress     forget the token's progress
+//
+// Requests other than for a new token identify the reader
+// with an "Authorization: Bearer <token>" header.
+type progressServer struct {
+	store store
+}
+
+func (s *progressServer) serveToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, struct{ Token string }{newToken()})
+}
+
+func (s *progressServer) serveProgress(w http.ResponseWriter, r *http.Request) {
+	token, ok := requestToken(r)
+	if !ok {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, "missing or invalid token", http.StatusUnauthorized)
+		return
+	}
+	name := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/tour/api/progress"), "/")
+	var (
+		p   *progress
+		err error
+	)
+	switch {
+	case name == "" && (r.Method == "GET" || r.Method == "HEAD"):
+		p, err = s.store.Load(r.Context(), token)
+	case name == "" && r.Method == "DELETE":
+		p, err = s.store.Update(r.Context(), token, func(p *progress) { p.Lessons = nil })
+	case name != "" && r.Method == "PUT":
+		l, ok := parsedLessons[name]
+		if !ok {
+			http.Error(w, "unknown lesson", http.StatusNotFound)
+			return
+		}
+		var lp lessonProgress
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxProgressBody)).Decode(&lp); err != nil {
+			http.Error(w, "invalid progress: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if lp.Page < 1 || lp.Page > len(l.Pages) {
+			http.Error(w, fmt.Sprintf("invalid page %d; lesson %s has pages 1 to %d", lp.Page, name, len(l.Pages)), http.StatusBadRequest)
+			return
+		}
+		lp.Updated = time.Now().UTC()
+		p, err = s.store.Update(r.Context(), token, func(p *progress) {
+			if p.Lessons == nil {
+				p.Lessons = make(map[string]lessonProgress)
+			}
+			// Completing a lesson is not undone by revisiting it.
+			lp.Completed = lp.Completed || p.Lessons[name].Completed
+			p.Lessons[name] = lp
+		})
+	case name == "":
+		w.Header().Set("Allow", "GET, HEAD, DELETE")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	default:
+		w.Header().Set("Allow", "PUT")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR tour progress: %v", err)
+		http.Error(w, "progress unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	if p.Lessons == nil {
+		p.Lessons = map[string]lessonProgress{}
+	}
+	writeJSON(w, p)
+}
@@ -26,6 +26,7 @@ import (
 var (
 	uiContent      []byte
 	lessons        = make(map[string][]byte)
+	parsedLessons  = make(map[string]*lesson)
 	lessonNotFound = fmt.Errorf("lesson not found")
 )
 
@@ -71,7 +72,8 @@ func initTour(mux *http.ServeMux, transport string) error {
 }
 
 // initLessons finds all the lessons in the content directory, renders them,
-// using the given template and saves the content in the lessons map.
+// using the given template and saves the content in the lessons map
+// and the parsed lessons in the parsedLessons map.
 func initLessons(tmpl *template.Template) error {
 	files, err := fs.ReadDir(contentTour, "tour")
 	if err != nil {
@@ -81,12 +83,17 @@ func initLessons(tmpl *template.Template) error {
 		if path.Ext(f.Name()) != ".article" {
 			continue
 		}
-		content, err := parseLesson(f.Name(), tmpl)
+		l, err := parseLesson(f.Name(), tmpl)
 		if err != nil {
 			return fmt.Errorf("parsing %v: %v", f.Name(), err)
 		}
+		w := new(bytes.Buffer)
+		if err := json.NewEncoder(w).Encode(l); err != nil {
+			return fmt.Errorf("encode lesson %v: %v", f.Name(), err)
+		}
 		name := strings.TrimSuffix(f.Name(), ".article")
-		lessons[name] = content
+		lessons[name] = w.Bytes()
+		parsedLessons[name] = l
 	}
 	return nil
 }
@@ -112,9 +119,9 @@ type lesson struct {
 	Pages       []page
 }
 
-// parseLesson parses and returns a lesson content given its path
+// parseLesson parses and returns a lesson given its path
 // relative to root ('/'-separated) and the template to render it.
-func parseLesson(path string, tmpl *template.Template) ([]byte, error) {
+func parseLesson(path string, tmpl *template.Template) (*lesson, error) {
 	f, err := contentTour.Open("tour/" + path)
 	if err != nil {
 		return nil, err
@@ -130,7 +137,7 @@ func parseLesson(path string, tmpl *template.Template) ([]byte, error) {
 		return nil, err
 	}
 
-	lesson := lesson{
+	lesson := &lesson{
 		doc.Title,
 		doc.Subtitle,
 		make([]page, len(doc.Sections)),
@@ -154,12 +161,7 @@ func parseLesson(path string, tmpl *template.Template) ([]byte, error) {
 			f.Hash = base64.StdEncoding.EncodeToString(hash[:])
 		}
 	}
-
-	w := new(bytes.Buffer)
-	if err := json.NewEncoder(w).Encode(lesson); err != nil {
-		return nil, fmt.Errorf("encode lesson: %v", err)
-	}
-	return w.Bytes(), nil
+	return lesson, nil
 }
 
 // findPlayCode returns a slide with all the Code elements in the given
@@ -0,0 +1,253 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package updates serves per-section feeds of recently changed pages.
+//
+// For a section such as doc, the feeds are served at:
+//
+//	/doc/updates.rss
+//	/doc/updates.json
+//
+// A page's last modification time is taken from its “updated” metadata,
+// falling back to its “date” metadata and then to the modification
+// time recorded in the content file system.
+// Embedded and Git-backed file systems do not record modification times,
+// so pages that want to appear in the feeds should set “updated”.
+package updates
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io/fs"
+	"log"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/matttproud/yourtour/internal/web"
+)
+
+const (
+	maxEntries = 20
+	refresh    = 5 * time.Minute
+	baseURL    = "https://go.dev"
+)
+
+// A Change describes a recently changed page.
+type Change struct {
+	Title   string    `json:"title"`
+	URL     string    `json:"url"`
+	Summary string    `json:"summary,omitempty"`
+	Updated time.Time `json:"updated"`
+}
+
+// A section computes and caches the changes for one section of the site.
+type section struct {
+	name string
+	site *web.Site
+	fsys fs.FS
+
+	mu       sync.Mutex
+	loaded   time.Time // time of the latest scan
+	changes  []Change
+	scanning bool // a scan is running in the background
+}
+
+// RegisterHandlers registers the update feeds for each of the named sections
+// of site on mux, using host as a host prefix on the registered paths.
+// The file system fsys must be the one site serves from.
+// The feeds of a section missing from fsys are empty.
+func RegisterHandlers(mux *http.ServeMux, host string, site *web.Site, fsys fs.FS, sections ...string) {
+	for _, name := range sections {
+		s := &section{name: name, site: site, fsys: fsys}
+		mux.HandleFunc(host+"/"+name+"/updates.rss", s.serveRSS)
+		mux.HandleFunc(host+"/"+name+"/updates.json", s.serveJSON)
+	}
+}
+
+// recent returns the most recently changed pages in the section,
+// newest first. The first call scans the section, and concurrent
+// calls wait for that scan. Later calls return the changes found
+// by the latest scan at once, starting another in the background
+// when that one is older than refresh.
+func (s *section) recent() []Change {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.loaded.IsZero() {
+		s.update(Scan(s.site, s.fsys, s.name))
+		return s.changes
+	}
+	if time.Since(s.loaded) >= refresh && !s.scanning {
+		s.scanning = true
+		go func() {
+			changes, err := Scan(s.site, s.fsys, s.name)
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			s.scanning = false
+			s.update(changes, err)
+		}()
+	}
+	return s.changes
+}
+
+// update records the result of a scan of the section.
+// If the scan failed, the section keeps the changes it had,
+// until the scan after refresh. s.mu must be held.
+func (s *section) update(changes []Change, err error) {
+	s.loaded = time.Now()
+	if err != nil {
+		log.Printf("ERROR updates %s: %v", s.name, err)
+		return
+	}
+	s.changes = changes
+}
+
+// Scan returns up to 20 most recently changed pages below dir in fsys,
+// newest first. Pages without any known modification time
+// and private pages of site are omitted.
+// If there is no dir, there are no changes.
+func Scan(site *web.Site, fsys fs.FS, dir string) ([]Change, error) {
+	var changes []Change
+	err := fs.WalkDir(fsys, dir, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if file == dir && errors.Is(err, fs.ErrNotExist) {
+				return fs.SkipAll
+			}
+			return err
+		}
+		if d.IsDir() || (!strings.HasSuffix(file, ".md") && !strings.HasSuffix(file, ".html")) {
+			return nil
+		}
+		pages, err := site.Pages("/" + file)
+		if err != nil || len(pages) == 0 {
+			// Not a page (for example, an HTML fragment with bad metadata).
+			return nil
+		}
+		p := pages[0]
+		if _, ok := p["redirect"]; ok {
+			return nil
+		}
+		url, _ := p["URL"].(string)
+		if site.Private(url) {
+			return nil
+		}
+		t := modTime(p)
+		if t.IsZero() {
+			if info, err := d.Info(); err == nil {
+				t = info.ModTime()
+			}
+		}
+		if t.IsZero() {
+			return nil
+		}
+		title, _ := p["title"].(string)
+		summary, _ := p["summary"].(string)
+		if title == "" {
+			title = path.Base(url)
+		}
+		changes = append(changes, Change{Title: title, URL: url, Summary: summary, Updated: t})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		if !changes[i].Updated.Equal(changes[j].Updated) {
+			return changes[i].Updated.After(changes[j].Updated)
+		}
+		return changes[i].URL < changes[j].URL
+	})
+	if len(changes) > maxEntries {
+		changes = changes[:maxEntries]
+	}
+	return changes, nil
+}
+
+// modTime returns the modification time recorded in the page metadata.
+func modTime(p web.Page) time.Time {
+	for _, key := range []string{"updated", "date"} {
+		switch v := p[key].(type) {
+		case time.Time:
+			return v
+		case string:
+			for _, layout := range []string{time.RFC3339, time.DateOnly} {
+				if t, err := time.Parse(layout, v); err == nil {
+					return t
+				}
+			}
+		}
+	}
+	return time.Time{}
+}
+
+func (s *section) serveJSON(w http.ResponseWriter, r *http.Request) {
+	changes := s.recent()
+	if changes == nil {
+		changes = []Change{}
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", " ")
+	if err := enc.Encode(changes); err != nil {
+		log.Printf("ERROR rendering JSON for updates: %v", err)
+	}
+}
+
+type rss struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	GUID        string `xml:"guid"`
+	Description string `xml:"description,omitempty"`
+	PubDate     string `xml:"pubDate"`
+}
+
+func (s *section) serveRSS(w http.ResponseWriter, r *http.Request) {
+	changes := s.recent()
+	feed := rss{
+		Version: "2.0",
+		Channel: rssChannel{
+			Title:       "Recently updated: /" + s.name + "/",
+			Link:        baseURL + "/" + s.name + "/",
+			Description: "Recently updated pages in the /" + s.name + "/ section of go.dev.",
+		},
+	}
+	if len(changes) > 0 {
+		feed.Channel.LastBuildDate = changes[0].Updated.Format(time.RFC1123Z)
+	}
+	for _, c := range changes {
+		feed.Channel.Items = append(feed.Channel.Items, rssItem{
+			Title:       c.Title,
+			Link:        baseURL + c.URL,
+			GUID:        baseURL + c.URL + "#" + c.Updated.UTC().Format(time.RFC3339),
+			Description: c.Summary,
+			PubDate:     c.Updated.Format(time.RFC1123Z),
+		})
+	}
+	data, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		s.site.ServeError(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/rss+xml; charset=utf-8")
+	w.Write([]byte(xml.Header))
+	w.Write(data)
+}
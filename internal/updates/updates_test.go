@@ -0,0 +1,127 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package updates
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/matttproud/yourtour/internal/web"
+)
+
+func TestFeeds(t *testing.T) {
+	mod := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	fsys := fstest.MapFS{
+		"site.tmpl":       {Data: []byte(`{{.Content}}`)},
+		"doc/a.md":        {Data: []byte("---\ntitle: A\nupdated: 2026-05-01T00:00:00Z\n---\nA")},
+		"doc/b.md":        {Data: []byte("---\ntitle: B\ndate: 2026-04-01\nsummary: About B.\n---\nB")},
+		"doc/c/index.md":  {Data: []byte("---\ntitle: C\n---\nC"), ModTime: mod},
+		"doc/undated.md":  {Data: []byte("---\ntitle: Undated\n---\n")},
+		"doc/moved.md":    {Data: []byte("---\nredirect: /doc/a\nupdated: 2026-06-01T00:00:00Z\n---\n")},
+		"other/newest.md": {Data: []byte("---\ntitle: Elsewhere\nupdated: 2026-07-01T00:00:00Z\n---\n")},
+	}
+	site := web.NewSite(fsys)
+	mux := http.NewServeMux()
+	RegisterHandlers(mux, "", site, fsys, "doc")
+
+	rw := httptest.NewRecorder()
+	mux.ServeHTTP(rw, httptest.NewRequest("GET", "/doc/updates.json", nil))
+	var changes []Change
+	if err := json.Unmarshal(rw.Body.Bytes(), &changes); err != nil {
+		t.Fatalf("decoding JSON: %v\n%s", err, rw.Body)
+	}
+	var urls []string
+	for _, c := range changes {
+		urls = append(urls, c.URL)
+	}
+	if got, want := strings.Join(urls, " "), "/doc/a /doc/b /doc/c/"; got != want {
+		t.Errorf("updates.json URLs = %s, want %s", got, want)
+	}
+	if len(changes) == 3 && (changes[1].Summary != "About B." || !changes[2].Updated.Equal(mod)) {
+		t.Errorf("updates.json = %+v", changes)
+	}
+
+	rw = httptest.NewRecorder()
+	mux.ServeHTTP(rw, httptest.NewRequest("GET", "/doc/updates.rss", nil))
+	if ct := rw.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/rss+xml") {
+		t.Errorf("updates.rss Content-Type = %q", ct)
+	}
+	for _, want := range []string{`<rss version="2.0">`, "<link>https://go.dev/doc/a</link>", "<pubDate>Fri, 01 May 2026 00:00:00 +0000</pubDate>"} {
+		if !strings.Contains(rw.Body.String(), want) {
+			t.Errorf("updates.rss missing %q:\n%s", want, rw.Body)
+		}
+	}
+}
+
+func TestMissingSection(t *testing.T) {
+	fsys := fstest.MapFS{"site.tmpl": {Data: []byte(`{{.Content}}`)}}
+	mux := http.NewServeMux()
+	RegisterHandlers(mux, "", web.NewSite(fsys), fsys, "solutions")
+	for _, path := range []string{"/solutions/updates.json", "/solutions/updates.rss"} {
+		rw := httptest.NewRecorder()
+		mux.ServeHTTP(rw, httptest.NewRequest("GET", path, nil))
+		if rw.Code != 200 {
+			t.Errorf("GET %s of missing section: %d, want 200", path, rw.Code)
+		}
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	fsys := fstest.MapFS{
+		"site.tmpl": {Data: []byte(`{{.Content}}`)},
+		"doc/a.md":  {Data: []byte("---\ntitle: A\nupdated: 2026-05-01T00:00:00Z\n---\nA")},
+	}
+	s := &section{name: "doc", site: web.NewSite(fsys), fsys: fsys}
+	if got := s.recent(); len(got) != 1 {
+		t.Fatalf("recent() = %v, want /doc/a", got)
+	}
+
+	// After refresh, the old changes are served while the section is rescanned.
+	fsys["doc/b.md"] = &fstest.MapFile{Data: []byte("---\ntitle: B\nupdated: 2026-06-01T00:00:00Z\n---\nB")}
+	s.site = web.NewSite(fsys)
+	s.mu.Lock()
+	s.loaded = s.loaded.Add(-refresh)
+	s.mu.Unlock()
+	if got := s.recent(); len(got) != 1 {
+		t.Errorf("recent() after refresh = %v, want the changes of the last scan", got)
+	}
+	for i := 0; ; i++ {
+		if got := s.recent(); len(got) == 2 {
+			break
+		}
+		if i == 100 {
+			t.Fatalf("background scan did not find /doc/b")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestPrivate(t *testing.T) {
+	fsys := fstest.MapFS{
+		"site.tmpl":         {Data: []byte(`{{.Content}}`)},
+		"doc/a.md":          {Data: []byte("---\ntitle: A\nupdated: 2026-05-01T00:00:00Z\n---\nA")},
+		"doc/team/plans.md": {Data: []byte("---\ntitle: Plans\nupdated: 2026-06-01T00:00:00Z\n---\nSecret.")},
+		"doc/team/index.md": {Data: []byte("---\ntitle: Team\nupdated: 2026-06-01T00:00:00Z\n---\nTeam.")},
+		"doc/teamwork.md":   {Data: []byte("---\ntitle: Teamwork\nupdated: 2026-04-01T00:00:00Z\n---\nTeamwork.")},
+	}
+	site := web.NewSite(fsys)
+	site.SetPrivate(func(p string) bool { return strings.HasPrefix(p, "/doc/team/") })
+	changes, err := Scan(site, fsys, "doc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var urls []string
+	for _, c := range changes {
+		urls = append(urls, c.URL)
+	}
+	if got, want := strings.Join(urls, " "), "/doc/a /doc/teamwork"; got != want {
+		t.Errorf("Scan URLs = %s, want %s", got, want)
+	}
+}
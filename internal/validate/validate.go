@@ -0,0 +1,253 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package validate checks site content before it is served,
+// running the checks of every package that interprets the content
+// and gathering what they find into one Report.
+//
+// The same checks run in tests of the content, on content to be deployed
+// before it replaces the content being served, and on request
+// from the administrative endpoint /_validate.
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"time"
+
+	"github.com/matttproud/yourtour/internal/authors"
+	"github.com/matttproud/yourtour/internal/changelog"
+	"github.com/matttproud/yourtour/internal/codewalk"
+	"github.com/matttproud/yourtour/internal/quiz"
+	"github.com/matttproud/yourtour/internal/redirect"
+	"github.com/matttproud/yourtour/internal/tenant"
+	"github.com/matttproud/yourtour/internal/web"
+	"github.com/matttproud/yourtour/internal/workshop"
+)
+
+// A Report is the result of validating site content.
+type Report struct {
+	Checks []*Check `json:"checks"` // in the order run
+}
+
+// A Check is the result of one of the checks making up a Report.
+type Check struct {
+	Name     string   `json:"name"`               // such as "templates" or "codewalks"
+	Problems []string `json:"problems,omitempty"` // what the check found wrong, one per problem
+}
+
+// OK reports whether every check found the content valid.
+func (r *Report) OK() bool {
+	return !slices.ContainsFunc(r.Checks, func(c *Check) bool { return len(c.Problems) > 0 })
+}
+
+// Err returns an error listing every problem found, or nil if there are none.
+func (r *Report) Err() error {
+	var errs []error
+	for _, c := range r.Checks {
+		for _, p := range c.Problems {
+			errs = append(errs, errors.New(p))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// add records the result err of the check name, splitting
+// errors joined by errors.Join into one problem each.
+func (r *Report) add(name string, err error) {
+	r.Checks = append(r.Checks, &Check{Name: name, Problems: problems(err)})
+}
+
+// problems returns the messages of the errors joined in err.
+func problems(err error) []string {
+	if err == nil {
+		return nil
+	}
+	if j, ok := err.(interface{ Unwrap() []error }); ok {
+		var list []string
+		for _, e := range j.Unwrap() {
+			list = append(list, problems(e)...)
+		}
+		return list
+	}
+	return []string{err.Error()}
+}
+
+// Validate checks the site content in content, served by site,
+// which must be configured with the template functions of the site's layouts.
+// It checks that:
+//
+//   - the layout templates parse after site.tmpl;
+//   - the offline manifest lists files that exist;
+//   - the codewalks parse and their steps resolve;
+//   - authors.yaml is valid and names every author of the articles and codewalks;
+//   - the quizzes, workshops, and changelog are valid;
+//   - the redirects files parse;
+//   - the front matter of every page has values of the expected types.
+//
+// The tour and talks directories are written in formats of their own
+// and are not checked, beyond their redirects.
+func Validate(content fs.FS, site *web.Site) *Report {
+	var layouts, pages, redirects []string
+	walkErr := fs.WalkDir(content, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			if name == "tour" || name == "talks" {
+				// Rendered by their own packages, with their own functions.
+				if _, err := fs.Stat(content, path.Join(name, tenant.RedirectsFile)); err == nil {
+					redirects = append(redirects, path.Join(name, tenant.RedirectsFile))
+				}
+				return fs.SkipDir
+			}
+		case path.Base(name) == tenant.RedirectsFile:
+			redirects = append(redirects, name)
+		case path.Ext(name) == ".tmpl" && name != "site.tmpl":
+			layouts = append(layouts, name)
+		case path.Ext(name) == ".md" || path.Ext(name) == ".html":
+			pages = append(pages, name)
+		}
+		return nil
+	})
+
+	r := new(Report)
+	r.add("content", walkErr)
+	r.add("templates", site.CheckTemplates(layouts...))
+	r.add("offline", site.CheckOffline())
+	r.add("codewalks", codewalk.Validate(content))
+	r.add("authors", authors.Validate(site, content))
+	r.add("quizzes", quiz.Validate(content))
+	r.add("workshops", workshop.Validate(content))
+	r.add("changelog", changelog.Validate(content))
+	r.add("redirects", checkRedirects(content, redirects))
+	r.add("front matter", checkFrontMatter(site, pages))
+	return r
+}
+
+// checkRedirects reports whether the named redirects files parse.
+func checkRedirects(fsys fs.FS, files []string) error {
+	var errs []error
+	for _, file := range files {
+		var rules redirect.Rules
+		if err := rules.LoadFS(fsys, file, path.Join("/", path.Dir(file))); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// A kind is the kind of value a front matter key must have.
+type kind int
+
+const (
+	kindString kind = iota
+	kindBool
+	kindTime
+	kindList
+)
+
+// frontMatter lists the front matter keys read by the server and the
+// site's layouts, and the kinds of their values. Other keys are allowed.
+var frontMatter = map[string]kind{
+	"title":       kindString,
+	"linkTitle":   kindString,
+	"summary":     kindString,
+	"description": kindString,
+	"layout":      kindString,
+	"redirect":    kindString,
+	"series":      kindString,
+	"draft":       kindBool,
+	"template":    kindBool,
+	"date":        kindTime,
+	"updated":     kindTime,
+	"by":          kindList,
+	"authors":     kindList,
+	"tags":        kindList,
+}
+
+// checkFrontMatter reports whether the named page files parse
+// and their front matter matches frontMatter.
+func checkFrontMatter(site *web.Site, files []string) error {
+	var errs []error
+	for _, file := range files {
+		pages, err := site.Pages(file)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %v", file, err))
+			continue
+		}
+		for _, p := range pages {
+			keys := make([]string, 0, len(p))
+			for key := range p {
+				keys = append(keys, key)
+			}
+			slices.Sort(keys)
+			for _, key := range keys {
+				k, ok := frontMatter[key]
+				if ok && !k.matches(p[key]) {
+					errs = append(errs, fmt.Errorf("%s: %s: have %T %v, want %s", file, key, p[key], p[key], k))
+				}
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// matches reports whether v is a value of kind k.
+func (k kind) matches(v any) bool {
+	switch k {
+	case kindString:
+		_, ok := v.(string)
+		return ok
+	case kindBool:
+		_, ok := v.(bool)
+		return ok
+	case kindTime:
+		switch v := v.(type) {
+		case time.Time:
+			return true
+		case string:
+			// As package updates reads dates written as strings.
+			for _, layout := range []string{time.RFC3339, time.DateOnly} {
+				if _, err := time.Parse(layout, v); err == nil {
+					return true
+				}
+			}
+		}
+	case kindList:
+		switch v := v.(type) {
+		case []string:
+			return true
+		case []any:
+			// YAML reads some words, such as 47 in “BCP 47”, as numbers,
+			// which are formatted as written.
+			return !slices.ContainsFunc(v, func(x any) bool {
+				switch x.(type) {
+				case string, int, float64, bool:
+					return false
+				}
+				return true
+			})
+		}
+	}
+	return false
+}
+
+func (k kind) String() string {
+	switch k {
+	case kindString:
+		return "a string"
+	case kindBool:
+		return "true or false"
+	case kindTime:
+		return "a date"
+	case kindList:
+		return "a list of words"
+	}
+	return "unknown"
+}
@@ -0,0 +1,73 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package validate
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/matttproud/yourtour/internal/web"
+)
+
+func TestValidate(t *testing.T) {
+	good := fstest.MapFS{
+		"site.tmpl":          {Data: []byte(`{{block "layout" .}}{{.Content}}{{end}}`)},
+		"default.tmpl":       {Data: []byte(`{{define "layout"}}{{.title}}{{end}}`)},
+		"redirects.yaml":     {Data: []byte("/old: /new\n")},
+		"index.md":           {Data: []byte("---\ntitle: Home\ndate: 2024-02-07\ntags:\n- BCP\n- 47\n---\nHello")},
+		"doc/x.md":           {Data: []byte("---\ntitle: X\nupdated: \"2024-02-07T00:00:00Z\"\ndraft: false\nextra: [1, 2]\n---\nX")},
+		"tour/x.md":          {Data: []byte("---\ntitle: [not checked]\n---\n")},
+		"doc/codewalk/x.xml": {Data: []byte(`<codewalk title="X"></codewalk>`)},
+	}
+	r := Validate(good, web.NewSite(good))
+	if !r.OK() || r.Err() != nil {
+		t.Fatalf("Validate(good) = %v", r.Err())
+	}
+
+	bad := fstest.MapFS{
+		"site.tmpl":           {Data: []byte(`{{block "layout" .}}{{.Content}}{{end}}`)},
+		"doc/default.tmpl":    {Data: []byte(`{{if}}`)},
+		"doc/codewalk/x.xml":  {Data: []byte(`<codewalk title="X"><step src="missing.go"/></codewalk>`)},
+		"tour/redirects.yaml": {Data: []byte("old: /new\n")},
+		"index.md":            {Data: []byte("---\ntitle: [Home]\ndate: 2024-02-07:00:00Z\ndraft: yes please\ntags:\n- {a: b}\n---\n")},
+		"broken.md":           {Data: []byte("---\ntitle: [\n---\n")},
+	}
+	r = Validate(bad, web.NewSite(bad))
+	if r.OK() {
+		t.Fatal("Validate(bad).OK() = true")
+	}
+	want := map[string][]string{
+		"templates": {"doc/default.tmpl"},
+		"codewalks": {"missing.go"},
+		"redirects": {"tour/redirects.yaml"},
+		"front matter": {
+			"broken.md",
+			"index.md: date: have string 2024-02-07:00:00Z, want a date",
+			"index.md: draft: have string yes please, want true or false",
+			"index.md: tags: have []interface {} [map[a:b]], want a list of words",
+			"index.md: title: have []interface {} [Home], want a string",
+		},
+	}
+	for _, c := range r.Checks {
+		w := want[c.Name]
+		delete(want, c.Name)
+		if len(c.Problems) != len(w) {
+			t.Errorf("%s: problems %q, want %d matching %q", c.Name, c.Problems, len(w), w)
+			continue
+		}
+		for i, p := range c.Problems {
+			if !strings.Contains(p, w[i]) {
+				t.Errorf("%s: problem %q, want %q", c.Name, p, w[i])
+			}
+		}
+	}
+	for name := range want {
+		t.Errorf("no %s check", name)
+	}
+	if err := r.Err(); err == nil || !strings.Contains(err.Error(), "missing.go") {
+		t.Errorf("Validate(bad).Err() = %v, want codewalk error", err)
+	}
+}
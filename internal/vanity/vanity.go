@@ -0,0 +1,242 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package vanity serves vanity import paths:
+// import paths on the site's own hosts, such as golang.org/dl,
+// for code kept in repositories elsewhere.
+//
+// The go command resolves such a path by fetching it with ?go-get=1
+// and reading the go-import meta tag naming the repository,
+// as described at https://go.dev/ref/mod#vcs-find.
+// A go-source meta tag can also tell documentation sites
+// where to link to the source.
+// Paths holds the mapping from import path to repository, read from a file
+// like this one, which lists each module root with its repository:
+//
+//	golang.org/dl:
+//	  vcs: git
+//	  repo: https://go.googlesource.com/dl
+//	  hosts: [go.dev]
+//	golang.org/toolchain:
+//	  vcs: mod
+//	  repo: https://go.dev/dl/mod
+//	  doc: https://go.dev/dl/
+//
+// Handler answers go-get requests for those paths with the meta tags.
+// Browsers visiting a path with a doc URL see a landing page
+// pointing there; other paths are left to the site's own pages.
+package vanity
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"gopkg.in/yaml.v3"
+)
+
+// A Module describes the repository holding the packages
+// under a vanity import path.
+type Module struct {
+	// VCS and Repo set the go-import meta tag:
+	// the version control system, or "mod" for a module proxy,
+	// and the repository root URL.
+	VCS  string `yaml:"vcs"`
+	Repo string `yaml:"repo"`
+
+	// Source, if set, sets the go-source meta tag.
+	Source *Source `yaml:"source"`
+
+	// Doc, if set, is the URL of the module's documentation,
+	// to which browsers visiting the import path are sent.
+	// If it is empty, browsers see the site's own page for the path,
+	// and the go-get page links to the path's documentation on pkg.go.dev.
+	Doc string `yaml:"doc"`
+
+	// Hosts lists other hosts that answer go-get requests for the path,
+	// for import paths whose own host redirects,
+	// as golang.org redirects to go.dev.
+	Hosts []string `yaml:"hosts"`
+}
+
+// Source is the content of a go-source meta tag:
+// the repository's home page, and templates for the URLs
+// of its directories and of lines in its files,
+// as described at https://pkg.go.dev/about#source-links.
+type Source struct {
+	Home string `yaml:"home"`
+	Dir  string `yaml:"dir"`
+	File string `yaml:"file"`
+}
+
+// Paths is a set of vanity import paths. It is safe for concurrent use.
+// The zero Paths is empty.
+type Paths struct {
+	m atomic.Pointer[map[string]*Module]
+}
+
+// Load replaces the paths with those in the file at path.
+// If the file is invalid, the paths are unchanged.
+func (p *Paths) Load(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := p.Set(data); err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	return nil
+}
+
+// Set replaces the paths with those in data, in YAML form.
+// If data is invalid, the paths are unchanged.
+func (p *Paths) Set(data []byte) error {
+	m := make(map[string]*Module)
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	for root, mod := range m {
+		if err := check(root, mod); err != nil {
+			return err
+		}
+		for i, h := range mod.Hosts {
+			mod.Hosts[i] = strings.ToLower(h)
+		}
+	}
+	p.m.Store(&m)
+	return nil
+}
+
+// check reports whether mod is a valid module for the import path root.
+func check(root string, mod *Module) error {
+	host, _, _ := strings.Cut(root, "/")
+	if root == "" || !strings.Contains(host, ".") || strings.HasSuffix(root, "/") || root != strings.ToLower(root) {
+		return fmt.Errorf("invalid import path %q; want a lower-case host and path like example.com/mod", root)
+	}
+	if mod == nil || mod.VCS == "" || strings.ContainsAny(mod.VCS, " \t") {
+		return fmt.Errorf("%s: missing or invalid vcs", root)
+	}
+	if u, err := url.Parse(mod.Repo); err != nil || u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("%s: invalid repo %q; want an absolute URL", root, mod.Repo)
+	}
+	if mod.Doc != "" {
+		if u, err := url.Parse(mod.Doc); err != nil || u.Scheme == "" {
+			return fmt.Errorf("%s: invalid doc %q; want an absolute URL", root, mod.Doc)
+		}
+	}
+	if s := mod.Source; s != nil && (s.Home == "" || s.Dir == "" || s.File == "") {
+		return fmt.Errorf("%s: source needs home, dir, and file", root)
+	}
+	return nil
+}
+
+// Lookup returns the import path served at host and path (a URL path),
+// the root of the module holding it, and the module,
+// or nil if there is no such module.
+// If two module roots match, the longer wins.
+// On one of a module's other hosts, the import path
+// is still that on the module root's host.
+func (p *Paths) Lookup(host, path string) (importPath, root string, mod *Module) {
+	m := p.m.Load()
+	if m == nil {
+		return "", "", nil
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	path = strings.TrimSuffix(path, "/")
+	for r, md := range *m {
+		rhost, rpath, _ := strings.Cut(r, "/")
+		rpath = "/" + rpath
+		if path != rpath && !strings.HasPrefix(path, rpath+"/") {
+			continue
+		}
+		if host != rhost && !contains(md.Hosts, host) {
+			continue
+		}
+		if len(r) > len(root) {
+			importPath, root, mod = rhost+path, r, md
+		}
+	}
+	return importPath, root, mod
+}
+
+func contains(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Handler returns a handler that answers go-get requests for the paths,
+// and browser requests for those with a doc URL,
+// and passes all other requests to h.
+func (p *Paths) Handler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" && r.Method != "HEAD" {
+			h.ServeHTTP(w, r)
+			return
+		}
+		importPath, root, mod := p.Lookup(r.Host, r.URL.Path)
+		goGet := r.FormValue("go-get") == "1"
+		if mod == nil || (!goGet && mod.Doc == "") {
+			h.ServeHTTP(w, r)
+			return
+		}
+		doc := mod.Doc
+		if doc == "" {
+			doc = "https://pkg.go.dev/" + importPath
+		}
+		var buf bytes.Buffer
+		err := pageTemplate.Execute(&buf, &page{
+			ImportPath: importPath,
+			Root:       root,
+			Module:     mod,
+			DocURL:     doc,
+		})
+		if err != nil {
+			log.Printf("ERROR vanity page for %s: %v", importPath, err)
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(buf.Bytes())
+	})
+}
+
+// A page is the data for pageTemplate.
+type page struct {
+	ImportPath string
+	Root       string
+	*Module
+	DocURL string
+}
+
+var pageTemplate = template.Must(template.New("vanity").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<title>The Go Programming Language</title>
+<meta http-equiv="Content-Type" content="text/html; charset=utf-8"/>
+<meta name="go-import" content="{{.Root}} {{.VCS}} {{.Repo}}">
+{{with .Source -}}
+<meta name="go-source" content="{{$.Root}} {{.Home}} {{.Dir}} {{.File}}">
+{{end -}}
+<meta http-equiv="refresh" content="0; url={{.DocURL}}">
+</head>
+<body>
+<p>{{.ImportPath}} is in the module {{.Root}}, kept at <a href="{{.Repo}}">{{.Repo}}</a>.</p>
+<a href="{{.DocURL}}">Redirecting to documentation...</a>
+</body>
+</html>
+`))
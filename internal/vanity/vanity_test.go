@@ -0,0 +1,130 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vanity
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testPaths = `
+example.com/mod:
+  vcs: git
+  repo: https://git.example.com/mod
+  source:
+    home: https://git.example.com/mod
+    dir: https://git.example.com/mod/tree{/dir}
+    file: https://git.example.com/mod/blob{/dir}/{file}#L{line}
+  hosts: [Mirror.example.com]
+example.com/mod/sub:
+  vcs: hg
+  repo: https://hg.example.com/sub
+example.com/tool:
+  vcs: mod
+  repo: https://example.com/proxy
+  doc: https://example.com/tool/docs
+`
+
+func TestLookup(t *testing.T) {
+	var p Paths
+	if err := p.Set([]byte(testPaths)); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		host, path       string
+		importPath, root string
+	}{
+		{"example.com", "/mod", "example.com/mod", "example.com/mod"},
+		{"example.com", "/mod/", "example.com/mod", "example.com/mod"},
+		{"example.com:443", "/mod/pkg/x", "example.com/mod/pkg/x", "example.com/mod"},
+		{"EXAMPLE.com.", "/mod/pkg", "example.com/mod/pkg", "example.com/mod"},
+		{"mirror.example.com", "/mod/pkg", "example.com/mod/pkg", "example.com/mod"},
+		{"example.com", "/mod/sub", "example.com/mod/sub", "example.com/mod/sub"},
+		{"example.com", "/mod/sub/pkg", "example.com/mod/sub/pkg", "example.com/mod/sub"},
+		{"example.com", "/mod/subx", "example.com/mod/subx", "example.com/mod"},
+		{"mirror.example.com", "/mod/sub", "example.com/mod/sub", "example.com/mod"},
+		{"example.com", "/modx", "", ""},
+		{"example.com", "/", "", ""},
+		{"other.com", "/mod", "", ""},
+	} {
+		importPath, root, mod := p.Lookup(tt.host, tt.path)
+		if importPath != tt.importPath || root != tt.root || (mod == nil) != (tt.root == "") {
+			t.Errorf("Lookup(%q, %q) = %q, %q, %v, want %q, %q", tt.host, tt.path, importPath, root, mod, tt.importPath, tt.root)
+		}
+	}
+}
+
+func TestSet(t *testing.T) {
+	var p Paths
+	if err := p.Set([]byte(testPaths)); err != nil {
+		t.Fatal(err)
+	}
+	for _, bad := range []string{
+		"example.com/mod: [",
+		"mod:\n  vcs: git\n  repo: https://example.com/mod\n",
+		"Example.com/mod:\n  vcs: git\n  repo: https://example.com/mod\n",
+		"example.com/mod/:\n  vcs: git\n  repo: https://example.com/mod\n",
+		"example.com/mod:\n  repo: https://example.com/mod\n",
+		"example.com/mod:\n  vcs: git\n  repo: /mod\n",
+		"example.com/mod:\n  vcs: git\n  repo: https://example.com/mod\n  doc: /doc\n",
+		"example.com/mod:\n  vcs: git\n  repo: https://example.com/mod\n  source:\n    home: https://example.com\n",
+		"example.com/mod:\n",
+	} {
+		if err := p.Set([]byte(bad)); err == nil {
+			t.Errorf("Set(%q) succeeded, want error", bad)
+		}
+	}
+	if _, _, mod := p.Lookup("example.com", "/tool"); mod == nil {
+		t.Errorf("failed Set changed the paths")
+	}
+}
+
+func TestHandler(t *testing.T) {
+	var p Paths
+	if err := p.Set([]byte(testPaths)); err != nil {
+		t.Fatal(err)
+	}
+	h := p.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "site page")
+	}))
+	for _, tt := range []struct {
+		method, url string
+		want        []string
+	}{
+		{"GET", "https://example.com/mod/pkg?go-get=1", []string{
+			`<meta name="go-import" content="example.com/mod git https://git.example.com/mod">`,
+			`<meta name="go-source" content="example.com/mod https://git.example.com/mod https://git.example.com/mod/tree{/dir} https://git.example.com/mod/blob{/dir}/{file}#L{line}">`,
+			`<meta http-equiv="refresh" content="0; url=https://pkg.go.dev/example.com/mod/pkg">`,
+		}},
+		{"GET", "https://example.com/mod/sub?go-get=1", []string{
+			`<meta name="go-import" content="example.com/mod/sub hg https://hg.example.com/sub">`,
+		}},
+		{"GET", "https://example.com/tool?go-get=1", []string{
+			`<meta name="go-import" content="example.com/tool mod https://example.com/proxy">`,
+			`<meta http-equiv="refresh" content="0; url=https://example.com/tool/docs">`,
+		}},
+		{"GET", "https://example.com/tool", []string{
+			`<meta name="go-import" content="example.com/tool mod https://example.com/proxy">`,
+			`<a href="https://example.com/tool/docs">`,
+		}},
+		{"GET", "https://example.com/mod/pkg", []string{"site page"}},
+		{"GET", "https://example.com/other?go-get=1", []string{"site page"}},
+		{"POST", "https://example.com/tool?go-get=1", []string{"site page"}},
+	} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(tt.method, tt.url, nil))
+		for _, want := range tt.want {
+			if !strings.Contains(w.Body.String(), want) {
+				t.Errorf("%s %s: body missing %s\n%s", tt.method, tt.url, want, w.Body)
+			}
+		}
+		if strings.Contains(w.Body.String(), "go-source") && !strings.Contains(tt.want[0], "example.com/mod git") {
+			t.Errorf("%s %s: unexpected go-source tag\n%s", tt.method, tt.url, w.Body)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package vhost maps the host names served by one process
+// to virtual hosts, each with its own site and handlers.
+//
+// A Host pairs the web.Site that renders a host's pages and error pages,
+// with its layouts and content, and a router.Router registering handlers
+// for that host only. Subsystems take a Host in their RegisterHandlers
+// functions, so that the server chooses which hosts serve them:
+//
+//	dl.RegisterHandlers(vhosts.Lookup("golang.google.cn"), ...)
+//
+// A Host can also be mounted below a path prefix of a host name,
+// serving a section of its site, such as /tour/, from content of its own.
+package vhost
+
+import (
+	"io/fs"
+	"net"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/matttproud/yourtour/internal/router"
+	"github.com/matttproud/yourtour/internal/web"
+)
+
+// A Host is a virtual host.
+type Host struct {
+	// Name is the host name, or the empty string for the default host,
+	// which serves requests for host names not otherwise registered.
+	Name string
+
+	// Prefix is the path prefix below which the host is mounted,
+	// such as "/tour", or the empty string if it serves every path.
+	Prefix string
+
+	// Site serves the host's pages from FS.
+	Site *web.Site
+	FS   fs.FS
+
+	// Router registers handlers for requests to the host only,
+	// with paths relative to Prefix.
+	Router *router.Router
+
+	// Version reports the version of FS, which changes with its files,
+	// or the empty string if it is unknown. It is nil if FS is not versioned.
+	Version func() string
+}
+
+// A Registry is a set of virtual hosts, indexed by name and prefix.
+// The zero Registry is empty and ready for use.
+type Registry struct {
+	mu    sync.RWMutex
+	hosts map[string]*Host // by name followed by prefix
+}
+
+// Add registers the virtual host name, serving the pages in fsys with site
+// and registering its handlers with rt, restricted to name.
+// It panics if name is already registered.
+func (reg *Registry) Add(name string, site *web.Site, fsys fs.FS, rt *router.Router) *Host {
+	return reg.Mount(name, "", site, fsys, rt)
+}
+
+// Mount registers the virtual host name mounted below the path prefix,
+// such as "/tour", as for Add, but serving only the paths below prefix,
+// which its handlers are registered relative to.
+// The pages of the mounted host are those in fsys below prefix.
+// Mount panics if prefix does not begin with a slash,
+// or if name is already registered with prefix.
+func (reg *Registry) Mount(name, prefix string, site *web.Site, fsys fs.FS, rt *router.Router) *Host {
+	name = strings.ToLower(name)
+	prefix = strings.TrimSuffix(prefix, "/")
+	if prefix != "" && !strings.HasPrefix(prefix, "/") {
+		panic("vhost: prefix " + prefix + " does not begin with /")
+	}
+	h := &Host{Name: name, Prefix: prefix, Site: site, FS: fsys, Router: rt.Host(name).Prefix(prefix)}
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	if _, ok := reg.hosts[name+prefix]; ok {
+		panic("vhost: multiple registrations for host " + name + prefix)
+	}
+	if reg.hosts == nil {
+		reg.hosts = make(map[string]*Host)
+	}
+	reg.hosts[name+prefix] = h
+	return h
+}
+
+// Lookup returns the virtual host serving requests for host,
+// which may include a port: the one registered for its name,
+// or else the default host. It returns nil if there is neither.
+// Lookup does not return hosts mounted below a prefix; see LookupPath.
+func (reg *Registry) Lookup(host string) *Host {
+	host = hostName(host)
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	if h, ok := reg.hosts[host]; ok {
+		return h
+	}
+	return reg.hosts[""]
+}
+
+// LookupPath returns the virtual host serving requests for host and
+// the URL path p: the host registered for its name with the longest
+// prefix of p, or else the default host with the longest prefix of p.
+// It returns nil if there is none.
+func (reg *Registry) LookupPath(host, p string) *Host {
+	host = hostName(host)
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	for _, name := range []string{host, ""} {
+		for prefix := path.Clean("/" + p); ; prefix = path.Dir(prefix) {
+			if prefix == "/" {
+				prefix = ""
+			}
+			if h, ok := reg.hosts[name+prefix]; ok {
+				return h
+			}
+			if prefix == "" {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// hostName returns the name of host, without its port.
+func hostName(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.ToLower(strings.TrimSuffix(host, "."))
+}
+
+// Site returns the site serving r, for rendering its error pages,
+// or nil if no virtual host serves r.
+func (reg *Registry) Site(r *http.Request) *web.Site {
+	if h := reg.LookupPath(r.Host, r.URL.Path); h != nil {
+		return h.Site
+	}
+	return nil
+}
+
+// Hosts returns the registered virtual hosts, sorted by name and prefix.
+func (reg *Registry) Hosts() []*Host {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	var list []*Host
+	for _, h := range reg.hosts {
+		list = append(list, h)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Name != list[j].Name {
+			return list[i].Name < list[j].Name
+		}
+		return list[i].Prefix < list[j].Prefix
+	})
+	return list
+}
@@ -0,0 +1,134 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vhost
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/matttproud/yourtour/internal/router"
+	"github.com/matttproud/yourtour/internal/web"
+)
+
+func TestRegistry(t *testing.T) {
+	var reg Registry
+	if h := reg.Lookup("go.dev"); h != nil {
+		t.Errorf("Lookup in empty Registry = %q, want nil", h.Name)
+	}
+
+	mux := http.NewServeMux()
+	rt := router.New(mux)
+	fsys := fstest.MapFS{}
+	def := reg.Add("", web.NewSite(fsys), fsys, rt)
+	tour := reg.Add("Tour.Example.com", web.NewSite(fsys), fsys, rt)
+	for _, h := range []*Host{def, tour} {
+		h.Router.HandleFunc("GET", "/", func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, "host="+h.Name)
+		})
+	}
+
+	tests := []struct {
+		host string
+		want *Host
+	}{
+		{"example.com", def},
+		{"tour.example.com", tour},
+		{"TOUR.example.com:8080", tour},
+		{"tour.example.com.", tour},
+		{"other.example.com", def},
+	}
+	for _, tt := range tests {
+		if got := reg.Lookup(tt.host); got != tt.want {
+			t.Errorf("Lookup(%q) = %q, want %q", tt.host, got.Name, tt.want.Name)
+		}
+		r := httptest.NewRequest("GET", "http://"+tt.host+"/", nil)
+		if site := reg.Site(r); site != tt.want.Site {
+			t.Errorf("Site for %s is not the site of %q", tt.host, tt.want.Name)
+		}
+	}
+
+	for _, tt := range []struct{ host, body string }{
+		{"example.com", "host="},
+		{"tour.example.com", "host=tour.example.com"},
+	} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", "http://"+tt.host+"/", nil))
+		if w.Body.String() != tt.body {
+			t.Errorf("GET %s/ = %q, want %q", tt.host, w.Body, tt.body)
+		}
+	}
+
+	if hosts := reg.Hosts(); len(hosts) != 2 || hosts[0] != def || hosts[1] != tour {
+		t.Errorf("Hosts() = %v, want default and tour hosts", hosts)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Add of duplicate host did not panic")
+		}
+	}()
+	reg.Add("tour.example.com", web.NewSite(fsys), fsys, rt)
+}
+
+func TestMount(t *testing.T) {
+	var reg Registry
+	mux := http.NewServeMux()
+	rt := router.New(mux)
+	fsys := fstest.MapFS{}
+	def := reg.Add("", web.NewSite(fsys), fsys, rt)
+	tour := reg.Mount("", "/tour/", web.NewSite(fsys), fsys, rt)
+	doc := reg.Add("doc.example.com", web.NewSite(fsys), fsys, rt)
+	ref := reg.Mount("Doc.Example.com", "/doc/ref", web.NewSite(fsys), fsys, rt)
+	for _, h := range []*Host{def, tour, doc, ref} {
+		h.Router.HandleFunc("GET", "/", func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, h.Name+h.Prefix)
+		})
+	}
+
+	tests := []struct {
+		url  string
+		want *Host
+	}{
+		{"http://go.dev/", def},
+		{"http://go.dev/tour/", tour},
+		{"http://go.dev/tour/welcome/1", tour},
+		{"http://go.dev/tours", def},
+		{"http://doc.example.com/tour/", doc},
+		{"http://doc.example.com/doc/ref/spec", ref},
+		{"http://doc.example.com/doc/", doc},
+		{"http://go.dev/doc/ref/spec", def},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", tt.url, nil)
+		if got := reg.LookupPath(r.Host, r.URL.Path); got != tt.want {
+			t.Errorf("LookupPath(%q, %q) = %q, want %q", r.Host, r.URL.Path, got.Name+got.Prefix, tt.want.Name+tt.want.Prefix)
+		}
+		if site := reg.Site(r); site != tt.want.Site {
+			t.Errorf("Site for %s is not the site of %q", tt.url, tt.want.Name+tt.want.Prefix)
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		if want := tt.want.Name + tt.want.Prefix; w.Body.String() != want {
+			t.Errorf("GET %s = %q, want %q", tt.url, w.Body, want)
+		}
+	}
+	if h := reg.Lookup("go.dev"); h != def {
+		t.Errorf("Lookup(go.dev) = %q, want the default host", h.Name+h.Prefix)
+	}
+
+	if hosts := reg.Hosts(); len(hosts) != 4 || hosts[0] != def || hosts[1] != tour || hosts[2] != doc || hosts[3] != ref {
+		t.Errorf("Hosts() = %v, want default, tour, doc, and ref hosts", hosts)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Mount of duplicate prefix did not panic")
+		}
+	}()
+	reg.Mount("", "/tour", web.NewSite(fsys), fsys, rt)
+}
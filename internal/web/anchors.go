@@ -0,0 +1,91 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package web
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// headingRE matches the start of a heading or other element carrying an id attribute.
+var headingRE = regexp.MustCompile(`<(h[1-6]|dt|div|section|span|a)\b[^>]*\bid="([^"]+)"`)
+
+// pageAnchors returns the anchor alias map from the page's “anchors” metadata,
+// which maps old (renamed or removed) anchor names to their current names.
+func pageAnchors(p Page) (map[string]string, error) {
+	v, ok := p["anchors"]
+	if !ok {
+		return nil, nil
+	}
+	// YAML metadata decodes nested maps using the Page type itself.
+	var m map[string]interface{}
+	switch v := v.(type) {
+	case Page:
+		m = v
+	case map[string]interface{}:
+		m = v
+	default:
+		return nil, fmt.Errorf("anchors: have %T, want map of old anchor to new anchor", v)
+	}
+	aliases := make(map[string]string)
+	for old, cur := range m {
+		s, ok := cur.(string)
+		if !ok {
+			return nil, fmt.Errorf("anchors: %s: have %T, want string", old, cur)
+		}
+		aliases[strings.TrimPrefix(old, "#")] = strings.TrimPrefix(s, "#")
+	}
+	return aliases, nil
+}
+
+// addAnchorAliases returns content with an empty element inserted
+// before each element whose id is the target of an alias,
+// so that links to the old anchor names land on the renamed element.
+// Aliases whose target does not appear in content are reported as an error,
+// since they would silently land readers at the top of the page.
+func addAnchorAliases(content template.HTML, aliases map[string]string) (template.HTML, error) {
+	if len(aliases) == 0 {
+		return content, nil
+	}
+	byTarget := make(map[string][]string)
+	for old, cur := range aliases {
+		byTarget[cur] = append(byTarget[cur], old)
+	}
+	found := make(map[string]bool)
+	s := string(content)
+	var b strings.Builder
+	last := 0
+	for _, m := range headingRE.FindAllStringSubmatchIndex(s, -1) {
+		id := html.UnescapeString(s[m[4]:m[5]])
+		olds := byTarget[id]
+		if len(olds) == 0 || found[id] {
+			continue
+		}
+		found[id] = true
+		sort.Strings(olds)
+		b.WriteString(s[last:m[0]])
+		for _, old := range olds {
+			fmt.Fprintf(&b, `<span id="%s"></span>`, html.EscapeString(old))
+		}
+		last = m[0]
+	}
+	b.WriteString(s[last:])
+
+	var missing []string
+	for cur, olds := range byTarget {
+		if !found[cur] {
+			missing = append(missing, fmt.Sprintf("%s -> %s", strings.Join(olds, ", "), cur))
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return "", fmt.Errorf("anchors: no element with target id: %s", strings.Join(missing, "; "))
+	}
+	return template.HTML(b.String()), nil
+}
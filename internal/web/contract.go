@@ -0,0 +1,133 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package web
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// A ViewModel is a struct describing the data of the pages
+// rendered with a layout, as a contract between the handler serving them
+// and the layout's template. PageOf converts a ViewModel to a Page.
+//
+// Each exported field of the struct is a key of the Page, named by the
+// field's page tag, or else by the field name. A field whose tag has the
+// omitempty option, as in `page:"authors,omitempty"`, is optional: it is
+// left out of the Page when it holds its zero value. Other fields are required.
+// A field tagged `page:"-"` is not part of the Page.
+type ViewModel interface {
+	// Layout returns the name of the layout rendering the pages,
+	// which PageOf stores as the Page's "layout".
+	Layout() string
+}
+
+// A pageField is a key of the Pages of a ViewModel.
+type pageField struct {
+	index     int
+	key       string
+	typ       reflect.Type
+	omitempty bool
+}
+
+// pageFields returns the keys of the Pages of the ViewModel type t.
+func pageFields(t reflect.Type) []pageField {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("web: ViewModel %v is not a struct", t))
+	}
+	var list []pageField
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		key, opt, _ := strings.Cut(f.Tag.Get("page"), ",")
+		if key == "-" {
+			continue
+		}
+		if key == "" {
+			key = f.Name
+		}
+		list = append(list, pageField{i, key, f.Type, opt == "omitempty"})
+	}
+	return list
+}
+
+// PageOf returns the Page holding the data of v, keyed as described in
+// the ViewModel documentation, with "layout" set to v.Layout().
+func PageOf(v ViewModel) Page {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Pointer {
+		rv = rv.Elem()
+	}
+	p := Page{"layout": v.Layout()}
+	for _, f := range pageFields(rv.Type()) {
+		fv := rv.Field(f.index)
+		if f.omitempty && fv.IsZero() {
+			continue
+		}
+		p[f.key] = fv.Interface()
+	}
+	return p
+}
+
+// AddContract records v's type as the contract of the pages rendered
+// with v's layout. In development mode (see SetDevMode), serving a page
+// with that layout whose data lacks a required key of the contract,
+// or holds a key of a type other than the one of its field,
+// fails with a diagnostic page naming the keys, rather than render
+// the page with sections left blank.
+// AddContract must not be called concurrently with serving requests.
+func (s *Site) AddContract(v ViewModel) {
+	if s.contracts == nil {
+		s.contracts = make(map[string][]pageField)
+	}
+	s.contracts[v.Layout()] = pageFields(reflect.TypeOf(v))
+}
+
+// CheckContract checks the data of p against the contract
+// of its layout, if any, returning an error listing every key
+// that is missing or of the wrong type.
+func (s *Site) CheckContract(p Page) error {
+	layout, _ := p["layout"].(string)
+	fields, ok := s.contracts[layout]
+	if !ok {
+		return nil
+	}
+	var errs []error
+	for _, f := range fields {
+		v, ok := p[f.key]
+		switch {
+		case !ok || isNil(v):
+			if !f.omitempty {
+				errs = append(errs, fmt.Errorf("missing key %q, want %v", f.key, f.typ))
+			}
+		case !reflect.TypeOf(v).AssignableTo(f.typ):
+			errs = append(errs, fmt.Errorf("key %q is %T, want %v", f.key, v, f.typ))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("page data for layout %q: %w", layout, errors.Join(errs...))
+	}
+	return nil
+}
+
+// isNil reports whether v is nil or a nil pointer or map,
+// which leaves a required key as blank as a missing one.
+func isNil(v any) bool {
+	if v == nil {
+		return true
+	}
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.Pointer, reflect.Map:
+		return rv.IsNil()
+	}
+	return false
+}
@@ -0,0 +1,132 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package web
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/matttproud/yourtour/internal/reqlog"
+)
+
+// SetDevMode sets whether the site is running in development mode.
+// In development mode, a page that fails to render is answered
+// with a diagnostic page showing the failing template, line,
+// the keys of the Page being rendered, and the surrounding template source,
+// instead of the site's generic error page,
+// and draft pages are served and listed like any other.
+// In development mode, pages are also checked against the contracts
+// of their layouts (see AddContract).
+// SetDevMode must not be called concurrently with any page rendering.
+func (s *Site) SetDevMode(dev bool) {
+	s.dev = dev
+}
+
+// A templateDiag describes the location of a template failure.
+type templateDiag struct {
+	Err   error
+	Name  string // template name, usually a file name in the site's file system
+	Line  int    // 1-based line number, or 0 if unknown
+	Lines []diagLine
+	Keys  []diagKey
+}
+
+type diagLine struct {
+	N    int
+	Text string
+	Bad  bool
+}
+
+type diagKey struct {
+	Key  string
+	Type string
+}
+
+// templateErrRE matches the location prefix of text/template errors:
+// “template: name:line:col: …” for execution errors and
+// “template: name:line: …” for parse errors.
+var templateErrRE = regexp.MustCompile(`template: ([^:\s]+):(\d+)(?::\d+)?:`)
+
+// diagnose returns a templateDiag for err, which occurred rendering p.
+func (s *Site) diagnose(p Page, err error) *templateDiag {
+	d := &templateDiag{Err: err}
+	var execErr texttemplate.ExecError
+	var escErr *template.Error
+	if errors.As(err, &execErr) {
+		d.Name = execErr.Name
+	} else if errors.As(err, &escErr) {
+		d.Name, d.Line = escErr.Name, escErr.Line
+	}
+	if m := templateErrRE.FindStringSubmatch(err.Error()); m != nil {
+		d.Name = m[1]
+		d.Line, _ = strconv.Atoi(m[2])
+	}
+	if d.Name != "" && d.Line > 0 {
+		if src, err := s.readFile(".", d.Name); err == nil {
+			const context = 5
+			lines := strings.Split(string(src), "\n")
+			for i := max(d.Line-context, 1); i <= min(d.Line+context, len(lines)); i++ {
+				d.Lines = append(d.Lines, diagLine{i, lines[i-1], i == d.Line})
+			}
+		}
+	}
+	for k, v := range p {
+		d.Keys = append(d.Keys, diagKey{k, fmt.Sprintf("%T", v)})
+	}
+	sort.Slice(d.Keys, func(i, j int) bool { return d.Keys[i].Key < d.Keys[j].Key })
+	return d
+}
+
+// serveTemplateDiag responds to r with a diagnostic page for err,
+// which occurred rendering p.
+func (s *Site) serveTemplateDiag(w http.ResponseWriter, r *http.Request, p Page, err error) {
+	reqlog.Logger(r.Context()).Error("template execution", "err", err)
+	d := s.diagnose(p, err)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusInternalServerError)
+	if err := diagTemplate.Execute(w, d); err != nil {
+		log.Printf("diagTemplate: %v", err)
+	}
+}
+
+var diagTemplate = template.Must(template.New("diag").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Template error</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+pre { background: #f4f4f4; padding: 1em; overflow-x: auto; }
+.bad { background: #fdd; font-weight: bold; }
+td { padding: 0 1em 0 0; font-family: monospace; }
+</style>
+</head>
+<body>
+<h1>Template error</h1>
+<pre>{{.Err}}</pre>
+{{if .Name}}
+<h2>{{.Name}}{{if .Line}}:{{.Line}}{{end}}</h2>
+{{end}}
+{{with .Lines}}
+<pre>{{range .}}<span{{if .Bad}} class="bad"{{end}}>{{printf "%4d" .N}}  {{.Text}}</span>
+{{end}}</pre>
+{{end}}
+<h2>Page keys</h2>
+<table>
+{{range .Keys}}<tr><td>{{.Key}}</td><td>{{.Type}}</td></tr>
+{{end}}
+</table>
+<p>This page is shown only in development mode.</p>
+</body>
+</html>
+`))
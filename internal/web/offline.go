@@ -0,0 +1,213 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package web
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/matttproud/yourtour/internal/reqlog"
+	"gopkg.in/yaml.v3"
+)
+
+// ServiceWorkerPath is the path of a site's service worker script.
+// Served from the root, the worker controls every page of the site.
+const ServiceWorkerPath = "/sw.js"
+
+// offlineFile is the file listing what a site's service worker precaches.
+const offlineFile = "offline.yaml"
+
+// workerTTL is how long a site's service worker script is reused,
+// since the dynamic entries of its manifest are not known to change.
+const workerTTL = 10 * time.Minute
+
+// A PrecacheEntry is a URL that a site's service worker fetches
+// when it is installed, so that it can serve it offline.
+type PrecacheEntry struct {
+	URL      string `json:"url"`      // URL path, with any query
+	Revision string `json:"revision"` // changes when the content at URL does
+}
+
+// A Precacher returns entries for the precache manifest
+// of a site's service worker, such as the data of dynamic pages.
+type Precacher func(ctx context.Context) ([]PrecacheEntry, error)
+
+// Revision returns a revision for content data, for a PrecacheEntry.
+func Revision(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// An offlineConfig is the content of a site's offline.yaml.
+type offlineConfig struct {
+	Assets []string `yaml:"assets"` // glob patterns of files
+	Pages  []string `yaml:"pages"`  // URL paths of pages
+}
+
+// A workerScript is a site's service worker script.
+type workerScript struct {
+	data    []byte
+	expires time.Time
+}
+
+//go:embed serviceworker.js
+var serviceWorkerJS []byte
+
+// AddPrecacher adds f to the sources of entries in the precache manifest
+// of the site's service worker, which is served only if the site
+// has an offline.yaml.
+// AddPrecacher must not be called concurrently with serving requests.
+func (s *Site) AddPrecacher(f Precacher) {
+	s.precachers = append(s.precachers, f)
+}
+
+// hasOffline reports whether the site serves a service worker.
+func (s *Site) hasOffline() bool {
+	_, err := fs.Stat(s.fs, offlineFile)
+	return err == nil
+}
+
+// CheckOffline reports whether the site's offline.yaml, if any,
+// parses and lists only files and pages that exist.
+func (s *Site) CheckOffline() error {
+	if !s.hasOffline() {
+		return nil
+	}
+	_, err := s.offlineEntries()
+	return err
+}
+
+// Precache returns the precache manifest of the site's service worker:
+// the entries for the assets and pages listed in its offline.yaml,
+// followed by those from its precachers, sorted by URL,
+// leaving out private pages.
+func (s *Site) Precache(ctx context.Context) ([]PrecacheEntry, error) {
+	list, err := s.offlineEntries()
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range s.precachers {
+		more, err := f(ctx)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, more...)
+	}
+	list = slices.DeleteFunc(list, func(e PrecacheEntry) bool {
+		p, _, _ := strings.Cut(e.URL, "?")
+		return s.Private(p)
+	})
+	slices.SortFunc(list, func(a, b PrecacheEntry) int { return strings.Compare(a.URL, b.URL) })
+	return slices.CompactFunc(list, func(a, b PrecacheEntry) bool { return a.URL == b.URL }), nil
+}
+
+// offlineEntries returns the precache entries listed in the site's offline.yaml.
+func (s *Site) offlineEntries() ([]PrecacheEntry, error) {
+	data, err := fs.ReadFile(s.fs, offlineFile)
+	if err != nil {
+		return nil, err
+	}
+	var cfg offlineConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("%s: %v", offlineFile, err)
+	}
+	var list []PrecacheEntry
+	for _, pattern := range cfg.Assets {
+		names, err := fs.Glob(s.fs, strings.TrimPrefix(pattern, "/"))
+		if err != nil {
+			return nil, fmt.Errorf("%s: asset %s: %v", offlineFile, pattern, err)
+		}
+		if len(names) == 0 {
+			return nil, fmt.Errorf("%s: asset %s: no matching files", offlineFile, pattern)
+		}
+		for _, name := range names {
+			data, err := fs.ReadFile(s.fs, name)
+			if err != nil {
+				return nil, fmt.Errorf("%s: asset %s: %v", offlineFile, pattern, err)
+			}
+			list = append(list, PrecacheEntry{"/" + name, Revision(data)})
+		}
+	}
+	var base []byte
+	if len(cfg.Pages) > 0 {
+		// Pages change with the site template that frames them.
+		if base, err = fs.ReadFile(s.fs, "site.tmpl"); err != nil {
+			return nil, err
+		}
+	}
+	for _, url := range cfg.Pages {
+		p, err := s.openPage(strings.TrimPrefix(path.Clean(url), "/"))
+		if err != nil || p.url != url {
+			if err == nil {
+				err = fmt.Errorf("page is served at %s", p.url)
+			}
+			return nil, fmt.Errorf("%s: page %s: %v", offlineFile, url, err)
+		}
+		list = append(list, PrecacheEntry{url, Revision(append(slices.Clip(base), p.data...))})
+	}
+	return list, nil
+}
+
+// serveServiceWorker serves the site's service worker script,
+// which begins by defining its precache manifest.
+func (s *Site) serveServiceWorker(w http.ResponseWriter, r *http.Request) {
+	ws := s.worker.Load()
+	if ws == nil || time.Now().After(ws.expires) {
+		s.workerMu.Lock()
+		if ws = s.worker.Load(); ws == nil || time.Now().After(ws.expires) {
+			data, err := s.workerScript(r.Context())
+			if err != nil {
+				s.workerMu.Unlock()
+				reqlog.Logger(r.Context()).Error("loading service worker", "err", err)
+				s.ServeError(w, r, errors.New("cannot load service worker"))
+				return
+			}
+			ws = &workerScript{data, time.Now().Add(workerTTL)}
+			s.worker.Store(ws)
+		}
+		s.workerMu.Unlock()
+	}
+	w.Header().Set("Content-Type", "text/javascript; charset=utf-8")
+	// Browsers check for a new worker as they navigate;
+	// let them see one as soon as it is served.
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Write(ws.data)
+}
+
+// workerScript returns the service worker script, with its manifest.
+// The manifest's version names the caches the worker fills.
+func (s *Site) workerScript(ctx context.Context) ([]byte, error) {
+	list, err := s.Precache(ctx)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := json.Marshal(list)
+	if err != nil {
+		return nil, err
+	}
+	manifest, err := json.Marshal(struct {
+		Version string          `json:"version"`
+		Entries json.RawMessage `json:"entries"`
+	}{Revision(entries), entries})
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "self.precache = %s;\n", manifest)
+	buf.Write(serviceWorkerJS)
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,103 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func offlineFS(config string) fstest.MapFS {
+	return fstest.MapFS{
+		"offline.yaml":   {Data: []byte(config)},
+		"site.tmpl":      {Data: []byte(`{{.serviceWorker}}|{{block "layout" .}}{{.Content}}{{end}}`)},
+		"error.tmpl":     {Data: []byte(`{{define "layout"}}{{.error}}{{end}}`)},
+		"css/styles.css": {Data: []byte("body {}")},
+		"js/site.js":     {Data: []byte("site()")},
+		"js/other.js":    {Data: []byte("other()")},
+		"doc/index.md":   {Data: []byte("Docs")},
+		"doc/faq.md":     {Data: []byte("FAQ")},
+	}
+}
+
+func TestServiceWorker(t *testing.T) {
+	fsys := offlineFS("assets:\n  - css/*.css\n  - /js/site.js\npages:\n  - /doc/\n  - /doc/faq\n")
+	site := NewSite(fsys)
+	site.AddPrecacher(func(ctx context.Context) ([]PrecacheEntry, error) {
+		return []PrecacheEntry{{"/dl/?mode=json", "r1"}, {"/internal/plans?x=1", "r2"}}, nil
+	})
+	site.SetPrivate(func(p string) bool { return strings.HasPrefix(p, "/internal/") })
+
+	w := httptest.NewRecorder()
+	site.ServeHTTP(w, httptest.NewRequest("GET", "/doc/", nil))
+	if !strings.HasPrefix(w.Body.String(), ServiceWorkerPath+"|") {
+		t.Errorf("GET /doc/: %q, want serviceWorker %s", w.Body, ServiceWorkerPath)
+	}
+
+	w = httptest.NewRecorder()
+	site.ServeHTTP(w, httptest.NewRequest("GET", ServiceWorkerPath, nil))
+	if w.Code != 200 || w.Header().Get("Content-Type") != "text/javascript; charset=utf-8" {
+		t.Fatalf("GET %s: %d %s, want 200 text/javascript", ServiceWorkerPath, w.Code, w.Header().Get("Content-Type"))
+	}
+	line, script, _ := strings.Cut(w.Body.String(), "\n")
+	if script != string(serviceWorkerJS) {
+		t.Errorf("GET %s: script does not follow manifest", ServiceWorkerPath)
+	}
+	var manifest struct {
+		Version string
+		Entries []PrecacheEntry
+	}
+	js, ok := strings.CutPrefix(line, "self.precache = ")
+	if err := json.Unmarshal([]byte(strings.TrimSuffix(js, ";")), &manifest); !ok || err != nil {
+		t.Fatalf("GET %s: bad manifest %s: %v", ServiceWorkerPath, line, err)
+	}
+	base := []byte(fsys["site.tmpl"].Data)
+	want := []PrecacheEntry{
+		{"/css/styles.css", Revision([]byte("body {}"))},
+		{"/dl/?mode=json", "r1"},
+		{"/doc/", Revision(append(base, "Docs"...))},
+		{"/doc/faq", Revision(append(base, "FAQ"...))},
+		{"/js/site.js", Revision([]byte("site()"))},
+	}
+	if diff := cmp.Diff(want, manifest.Entries); diff != "" || manifest.Version == "" {
+		t.Errorf("manifest version %q, entries (-want +got):\n%s", manifest.Version, diff)
+	}
+
+	delete(fsys, "offline.yaml")
+	site = NewSite(fsys)
+	w = httptest.NewRecorder()
+	site.ServeHTTP(w, httptest.NewRequest("GET", ServiceWorkerPath, nil))
+	if w.Code != 404 {
+		t.Errorf("GET %s without offline.yaml: %d, want 404", ServiceWorkerPath, w.Code)
+	}
+	if err := site.CheckOffline(); err != nil {
+		t.Errorf("CheckOffline without offline.yaml: %v", err)
+	}
+}
+
+func TestCheckOffline(t *testing.T) {
+	for _, tt := range []struct {
+		config string
+		err    string
+	}{
+		{"assets: [js/*.js]\npages: [/doc/]", ""},
+		{"assets: [img/*.png]", "asset img/*.png: no matching files"},
+		{"assets: ['js/[']", "asset js/[: syntax error"},
+		{"pages: [/doc/missing]", "page /doc/missing: "},
+		{"pages: [/doc]", "page /doc: page is served at /doc/"},
+		{"pages: /doc/", "offline.yaml: yaml: "},
+	} {
+		err := NewSite(offlineFS(tt.config)).CheckOffline()
+		if tt.err == "" && err != nil || tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+			t.Errorf("CheckOffline(%q) = %v, want error containing %q", tt.config, err, tt.err)
+		}
+	}
+}
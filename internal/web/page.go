@@ -48,8 +48,11 @@ func (site *Site) openPage(file string) (*pageFile, error) {
 		file = strings.TrimSuffix(file, ".md")
 	}
 
+	// Use one cache throughout, so that pages read before ClearCache
+	// are never stored in the cache that replaced it.
+	cache := site.cache.Load()
 	now := time.Now().UnixNano()
-	if cp, ok := site.cache.Load(file); ok {
+	if cp, ok := cache.Load(file); ok {
 		// Have cache entry; only use if the underlying file hasn't changed.
 		// To avoid continuous stats, only check it has been 3s since the last one.
 		// TODO(rsc): Move caching into a more general layer and cache templates.
@@ -113,11 +116,17 @@ func (site *Site) openPage(file string) (*pageFile, error) {
 		p.url = redir
 	}
 
-	site.cache.Store(file, p)
+	cache.Store(file, p)
 
 	return p, nil
 }
 
+// isDraft reports whether p is marked “draft: true”.
+func isDraft(p Page) bool {
+	draft, _ := p["draft"].(bool)
+	return draft
+}
+
 var (
 	jsonStart = []byte("<!--{")
 	jsonEnd   = []byte("}-->")
@@ -0,0 +1,93 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package web
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/matttproud/yourtour/internal/errreport"
+	"github.com/matttproud/yourtour/internal/reqlog"
+)
+
+// errPanic is the error shown to users when serving their request panics.
+// The panic value itself is only logged and reported, since it may reveal internals.
+var errPanic = errors.New("internal server error")
+
+// Recover returns a handler that serves requests with h,
+// recovering from any panic by logging it and its stack
+// with the request's logger, sending them to the error reporter,
+// and replying with the error page of the site returned by site(r),
+// as rendered by ServeError. The reply drops the headers h had set,
+// such as its Content-Type and ETag, keeping those set before h ran.
+// If site returns nil, the reply is a plain-text error.
+//
+// If h had already started its reply when it panicked,
+// the error page cannot be sent; Recover then aborts the reply,
+// as net/http would, but without printing the stack a second time.
+// A panic with http.ErrAbortHandler is not an error and is passed through.
+func Recover(h http.Handler, site func(*http.Request) *Site) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := &recoverWriter{ResponseWriter: w}
+		header := w.Header().Clone()
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			if v == http.ErrAbortHandler {
+				panic(v)
+			}
+			stack := debug.Stack()
+			reqlog.Logger(r.Context()).Error("panic serving request",
+				"panic", fmt.Sprint(v), "stack", string(stack))
+			errreport.Panic(r, v, stack)
+			if rw.wrote {
+				panic(http.ErrAbortHandler)
+			}
+			clear(w.Header())
+			for k, v := range header {
+				w.Header()[k] = v
+			}
+			if s := site(r); s != nil {
+				s.ServeError(w, r, errPanic)
+				return
+			}
+			reqlog.Error(w, r, errPanic.Error(), http.StatusInternalServerError)
+		}()
+		h.ServeHTTP(rw, r)
+	})
+}
+
+// A recoverWriter records whether a reply has been started.
+type recoverWriter struct {
+	http.ResponseWriter
+	wrote bool
+}
+
+func (w *recoverWriter) WriteHeader(code int) {
+	if code >= 200 {
+		w.wrote = true
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *recoverWriter) Write(b []byte) (int, error) {
+	w.wrote = true
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recoverWriter) Flush() {
+	w.wrote = true
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap returns the underlying ResponseWriter,
+// for use by http.ResponseController.
+func (w *recoverWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
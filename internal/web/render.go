@@ -50,6 +50,12 @@ func (site *Site) renderHTML(p Page, tmpl string, r *http.Request) ([]byte, erro
 		// Set URL - caller did not.
 		p["URL"] = r.URL.Path
 	}
+	if _, ok := p["serviceWorker"]; !ok && site.hasOffline() {
+		p["serviceWorker"] = ServiceWorkerPath
+	}
+	for _, f := range site.pageData {
+		f(r, p)
+	}
 	file, _ := p["File"].(string)
 	data, _ := p["FileData"].(string)
 
@@ -63,29 +69,7 @@ func (site *Site) renderHTML(p Page, tmpl string, r *http.Request) ([]byte, erro
 	if dir == "" {
 		dir = "."
 	}
-	sd := &siteDir{site, dir}
-
-	t := template.New("site.tmpl").Funcs(template.FuncMap{
-		"add":          func(a, b int) int { return a + b },
-		"sub":          func(a, b int) int { return a - b },
-		"mul":          func(a, b int) int { return a * b },
-		"div":          func(a, b int) int { return a / b },
-		"code":         sd.code,
-		"data":         sd.data,
-		"page":         sd.page,
-		"pages":        sd.pages,
-		"play":         sd.play,
-		"request":      func() *http.Request { return r },
-		"path":         func() pkgPath { return pkgPath{} },
-		"strings":      func() pkgStrings { return pkgStrings{} },
-		"file":         sd.file,
-		"first":        first,
-		"markdown":     markdown,
-		"raw":          raw,
-		"yaml":         yamlFn,
-		"presentStyle": presentStyle,
-	})
-	t.Funcs(site.funcs)
+	t := site.newTemplate(&siteDir{site, dir}, r)
 
 	if err := tmplfunc.Parse(t, string(base)); err != nil {
 		return nil, err
@@ -158,12 +142,52 @@ func (site *Site) renderHTML(p Page, tmpl string, r *http.Request) ([]byte, erro
 		}
 	}
 
+	// Keep old deep links to renamed headings working.
+	aliases, err := pageAnchors(p)
+	if err != nil {
+		return nil, err
+	}
+	if content, ok := p["Content"].(template.HTML); ok && len(aliases) > 0 {
+		content, err = addAnchorAliases(content, aliases)
+		if err != nil {
+			return nil, err
+		}
+		p["Content"] = content
+	}
+
 	if err := t.Execute(&buf, p); err != nil {
 		return nil, err
 	}
 	return buf.Bytes(), nil
 }
 
+// newTemplate returns a new, empty base template for rendering pages
+// in the directory sd while serving r, with the site's functions defined.
+func (site *Site) newTemplate(sd *siteDir, r *http.Request) *template.Template {
+	t := template.New("site.tmpl").Funcs(template.FuncMap{
+		"add":          func(a, b int) int { return a + b },
+		"sub":          func(a, b int) int { return a - b },
+		"mul":          func(a, b int) int { return a * b },
+		"div":          func(a, b int) int { return a / b },
+		"code":         sd.code,
+		"data":         sd.data,
+		"page":         sd.page,
+		"pages":        sd.pages,
+		"play":         sd.play,
+		"request":      func() *http.Request { return r },
+		"path":         func() pkgPath { return pkgPath{} },
+		"strings":      func() pkgStrings { return pkgStrings{} },
+		"file":         sd.file,
+		"first":        first,
+		"markdown":     markdown,
+		"raw":          raw,
+		"yaml":         yamlFn,
+		"presentStyle": presentStyle,
+	})
+	t.Funcs(site.funcs)
+	return t
+}
+
 // findLayout searches the start directory and parent directories for a template with the given base name.
 func (site *Site) findLayout(dir, name string) (string, bool) {
 	name += ".tmpl"
@@ -0,0 +1,217 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package web
+
+import (
+	"html/template"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// A Sanitizer filters HTML fragments against an allowlist of elements and attributes.
+// It is meant for HTML that is embedded directly into pages but is not
+// under the site's control, such as the prose inside codewalk steps.
+//
+// Elements not in the allowlist are dropped, but their text content is kept,
+// except for elements like script and style, which are dropped entirely.
+// Comments and doctypes are always dropped.
+// Attributes holding URLs (href and src) are additionally required
+// to be relative URLs or to use one of the allowed schemes.
+type Sanitizer struct {
+	// Elements maps each allowed element name to the attributes allowed on it.
+	Elements map[string][]string
+
+	// Attrs lists the attributes allowed on every allowed element.
+	Attrs []string
+
+	// Schemes lists the URL schemes allowed in href and src attributes.
+	Schemes []string
+}
+
+// DefaultSanitizer allows the basic formatting, list, table, and link
+// markup used by hand-written documentation.
+var DefaultSanitizer = &Sanitizer{
+	Elements: map[string][]string{
+		"a":          {"href", "title", "name", "target", "rel"},
+		"b":          nil,
+		"blockquote": nil,
+		"br":         nil,
+		"code":       nil,
+		"dd":         nil,
+		"div":        nil,
+		"dl":         nil,
+		"dt":         nil,
+		"em":         nil,
+		"h1":         nil,
+		"h2":         nil,
+		"h3":         nil,
+		"h4":         nil,
+		"hr":         nil,
+		"i":          nil,
+		"img":        {"src", "alt", "title", "width", "height"},
+		"li":         nil,
+		"ol":         nil,
+		"p":          nil,
+		"pre":        nil,
+		"span":       nil,
+		"strong":     nil,
+		"sub":        nil,
+		"sup":        nil,
+		"table":      nil,
+		"tbody":      nil,
+		"td":         {"colspan", "rowspan", "align"},
+		"th":         {"colspan", "rowspan", "align"},
+		"thead":      nil,
+		"tr":         nil,
+		"tt":         nil,
+		"ul":         nil,
+	},
+	Attrs:   []string{"class", "id"},
+	Schemes: []string{"http", "https", "mailto"},
+}
+
+// dropContent lists the elements whose content is dropped
+// along with the element itself when they are not allowed.
+var dropContent = map[string]bool{
+	"embed":    true,
+	"iframe":   true,
+	"noscript": true,
+	"object":   true,
+	"script":   true,
+	"style":    true,
+	"template": true,
+	"textarea": true,
+	"title":    true,
+}
+
+// voidElements lists the elements that never have an end tag.
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true,
+	"hr": true, "img": true, "input": true, "link": true, "meta": true,
+	"source": true, "track": true, "wbr": true,
+}
+
+// Sanitize returns the HTML fragment src with all markup
+// not permitted by s removed.
+// The result is always well-formed: every element it opens is closed.
+func (s *Sanitizer) Sanitize(src string) template.HTML {
+	var out strings.Builder
+	var open []string // stack of open allowed elements
+	var skip string   // name of the dropped element whose content is being skipped
+	depth := 0        // nesting depth of skip elements
+	z := html.NewTokenizer(strings.NewReader(src))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			// io.EOF, since reading from a string cannot fail otherwise.
+			break
+		}
+		tok := z.Token()
+		name := tok.Data
+		switch tt {
+		case html.TextToken:
+			if skip == "" {
+				out.WriteString(html.EscapeString(tok.Data))
+			}
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			if skip != "" || dropContent[name] {
+				if tt == html.StartTagToken && !voidElements[name] && (skip == "" || skip == name) {
+					skip = name
+					depth++
+				}
+				continue
+			}
+			attrs, ok := s.Elements[name]
+			if !ok {
+				continue
+			}
+			out.WriteString("<" + name)
+			for _, a := range tok.Attr {
+				if a.Namespace != "" || !s.allowAttr(attrs, a) {
+					continue
+				}
+				out.WriteString(" " + a.Key + `="` + html.EscapeString(a.Val) + `"`)
+			}
+			if voidElements[name] {
+				out.WriteString("/>")
+				continue
+			}
+			out.WriteString(">")
+			if tt == html.SelfClosingTagToken {
+				out.WriteString("</" + name + ">")
+				continue
+			}
+			open = append(open, name)
+
+		case html.EndTagToken:
+			if skip != "" {
+				if name == skip {
+					if depth--; depth == 0 {
+						skip = ""
+					}
+				}
+				continue
+			}
+			// Close elements up to and including the most recent match.
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] == name {
+					for j := len(open) - 1; j >= i; j-- {
+						out.WriteString("</" + open[j] + ">")
+					}
+					open = open[:i]
+					break
+				}
+			}
+		}
+	}
+	for i := len(open) - 1; i >= 0; i-- {
+		out.WriteString("</" + open[i] + ">")
+	}
+	return template.HTML(out.String())
+}
+
+// allowAttr reports whether the attribute a is allowed on an element
+// whose element-specific allowed attributes are attrs.
+func (s *Sanitizer) allowAttr(attrs []string, a html.Attribute) bool {
+	if !contains(attrs, a.Key) && !contains(s.Attrs, a.Key) {
+		return false
+	}
+	if a.Key == "href" || a.Key == "src" {
+		return s.allowURL(a.Val)
+	}
+	return true
+}
+
+// allowURL reports whether u is a relative URL or uses an allowed scheme.
+func (s *Sanitizer) allowURL(u string) bool {
+	// Browsers ignore leading whitespace and embedded control characters,
+	// so " java\tscript:" is still a javascript: URL. Drop them before parsing.
+	u = strings.Map(func(r rune) rune {
+		if r <= ' ' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, u)
+	p, err := url.Parse(u)
+	if err != nil {
+		return false
+	}
+	if p.Scheme == "" {
+		return true
+	}
+	return contains(s.Schemes, strings.ToLower(p.Scheme))
+}
+
+func contains(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package web
+
+import (
+	"html/template"
+	"testing"
+)
+
+var sanitizeTests = []struct {
+	in, out string
+}{
+	{"plain text", "plain text"},
+	{"a &lt; b", "a &lt; b"},
+	{"<b>bold</b> and <i>italic</i>", "<b>bold</b> and <i>italic</i>"},
+	{"line<br>break", "line<br/>break"},
+	{`<a href="/pkg/fmt/#Fscan">Fscan</a>`, `<a href="/pkg/fmt/#Fscan">Fscan</a>`},
+	{`<a href="https://go.dev/" onclick="evil()">go.dev</a>`, `<a href="https://go.dev/">go.dev</a>`},
+	{`<a href="javascript:alert(1)">x</a>`, `<a>x</a>`},
+	{`<a href=" java	script:alert(1)">x</a>`, `<a>x</a>`},
+	{`<img src="data:image/png;base64,xx" alt="x">`, `<img alt="x"/>`},
+	{`<script>alert(1)</script>after`, `after`},
+	{`<style>b { color: red }</style>after`, `after`},
+	{`<iframe src="/">inner</iframe>after`, `after`},
+	{`<object><object></object>inner</object>after`, `after`},
+	{`<blink>text</blink>`, `text`},
+	{`<!-- comment -->text`, `text`},
+	{`<p class="x" style="color: red">p</p>`, `<p class="x">p</p>`},
+	{`<b><i>unclosed`, `<b><i>unclosed</i></b>`},
+	{`<b><i>misnested</b></i>`, `<b><i>misnested</i></b>`},
+	{`stray</b> end tag`, `stray end tag`},
+	{`<code>"quotes"</code>`, `<code>&#34;quotes&#34;</code>`},
+}
+
+func TestSanitize(t *testing.T) {
+	for _, tt := range sanitizeTests {
+		if out := DefaultSanitizer.Sanitize(tt.in); out != template.HTML(tt.out) {
+			t.Errorf("Sanitize(%q):\nhave %q\nwant %q", tt.in, out, tt.out)
+		}
+	}
+}
+
+func TestSanitizeAllowlist(t *testing.T) {
+	s := &Sanitizer{
+		Elements: map[string][]string{"a": {"href"}},
+		Schemes:  []string{"https"},
+	}
+	in := `<p><a href="http://example.com/" id="x">a</a><a href="https://example.com/">b</a></p>`
+	want := template.HTML(`<a>a</a><a href="https://example.com/">b</a>`)
+	if out := s.Sanitize(in); out != want {
+		t.Errorf("Sanitize(%q):\nhave %q\nwant %q", in, out, want)
+	}
+}
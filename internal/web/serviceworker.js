@@ -0,0 +1,89 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+/**
+ * The site's service worker, which keeps the site readable offline.
+ * The server defines self.precache before this script, listing the
+ * assets and pages fetched on install; the worker also keeps the pages
+ * the reader visits, serving them when the network cannot.
+ */
+(() => {
+  'use strict';
+
+  const prefix = 'golangorg-';
+  const precacheName = prefix + 'precache-' + self.precache.version;
+  const pagesName = prefix + 'pages';
+  const maxPages = 100;
+  const precached = new Set(self.precache.entries.map(e => e.url));
+
+  self.addEventListener('install', e => {
+    e.waitUntil(
+      caches
+        .open(precacheName)
+        .then(cache => cache.addAll([...precached]))
+        .then(() => self.skipWaiting())
+    );
+  });
+
+  self.addEventListener('activate', e => {
+    // Drop the precaches of earlier workers, keeping the visited pages.
+    e.waitUntil(
+      caches
+        .keys()
+        .then(names =>
+          Promise.all(
+            names
+              .filter(n => n.startsWith(prefix) && n !== precacheName && n !== pagesName)
+              .map(n => caches.delete(n))
+          )
+        )
+        .then(() => self.clients.claim())
+    );
+  });
+
+  self.addEventListener('fetch', e => {
+    const req = e.request;
+    const url = new URL(req.url);
+    if (req.method !== 'GET' || url.origin !== location.origin) {
+      return;
+    }
+    if (req.mode === 'navigate') {
+      e.respondWith(page(req));
+      return;
+    }
+    if (precached.has(url.pathname + url.search)) {
+      e.respondWith(caches.match(req, {cacheName: precacheName}).then(resp => resp || fetch(req)));
+    }
+  });
+
+  // page fetches the page for req from the network, keeping it,
+  // or else serves the kept or precached copy.
+  async function page(req) {
+    try {
+      const resp = await fetch(req);
+      // Pages marked no-store, such as those of private sections, are not kept.
+      const noStore = /no-store/.test(resp.headers.get('Cache-Control') || '');
+      if (resp.ok && resp.type === 'basic' && !noStore) {
+        const cache = await caches.open(pagesName);
+        await cache.put(req, resp.clone());
+        trim(cache);
+      }
+      return resp;
+    } catch (err) {
+      const resp = await caches.match(req);
+      if (resp) {
+        return resp;
+      }
+      throw err;
+    }
+  }
+
+  // trim deletes the pages kept longest beyond the most kept.
+  async function trim(cache) {
+    const keys = await cache.keys();
+    for (const key of keys.slice(0, Math.max(0, keys.length - maxPages))) {
+      await cache.delete(key);
+    }
+  }
+})();
@@ -51,6 +51,20 @@
 // The key-value pair “layout: name” selects the page layout template with the given name.
 // See the next section, “Page Rendering”, for details about layout and rendering.
 //
+// The key-value pair “anchors: map” lists renamed headings, mapping each old
+// anchor name to the current one, as in “anchors: {old-section: new-section}”.
+// Rendering inserts an empty element with the old id just before the element
+// with the new id, so that deep links to #old-section keep landing on the
+// renamed section rather than at the top of the page.
+// Heading ids are derived deterministically from the heading text,
+// or can be set explicitly in Markdown with “# Heading {#id}”.
+// It is an error for an alias to name an id that does not appear in the page.
+//
+// The key-value pair “draft: true” marks an unpublished page.
+// Outside development mode (see SetDevMode), a draft is answered with 404 Not Found
+// and omitted from the pages listed by Pages and the “pages” template function,
+// so that it can be committed and reviewed before it appears on the site.
+//
 // The key-value pair “template: bool” controls whether the page is treated as an HTML template
 // (see the next section, “Page Rendering”). The default is false for HTML
 // and true for markdown.
@@ -297,46 +311,154 @@
 // If that rendering itself fails, the Site responds with status 500
 // and the cryptic page text “error rendering error”.
 //
+// In development mode (see Site.SetDevMode), a failure to render a page
+// is instead answered with a diagnostic page describing the failing
+// template and line, the surrounding template source, and the keys
+// of the Page being rendered.
+//
 // The Site.ServeError and Site.ServeErrorStatus methods provide a way
 // for dynamic servers to generate similar responses.
+//
+// # Reading Offline
+//
+// If fsys has a file “offline.yaml”, the Site serves a service worker
+// script at /sw.js that keeps the site readable without a connection,
+// and sets “serviceWorker: /sw.js” in the data of every page,
+// for the site template to register it. The file lists the files
+// and pages that the worker fetches when it is installed:
+//
+//	assets:
+//	  - css/*.css
+//	  - js/site.js
+//	pages:
+//	  - /doc/
+//
+// Assets are glob patterns matching files in fsys, and pages are
+// URL paths of pages loaded from fsys. Each is listed in the worker's
+// precache manifest with a revision derived from its content, so that
+// the worker, which changes with the manifest, is updated and fetches
+// them again when they change. Site.AddPrecacher adds dynamic entries.
+// The worker also keeps the pages the reader visits, serving them
+// when the network cannot.
 package web
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"html"
 	"html/template"
 	"io"
 	"io/fs"
-	"log"
+	"mime"
 	"net/http"
 	"path"
 	"regexp"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/evanw/esbuild/pkg/api"
+	"github.com/matttproud/yourtour/internal/canonical"
+	"github.com/matttproud/yourtour/internal/errreport"
+	"github.com/matttproud/yourtour/internal/reqlog"
 	"github.com/matttproud/yourtour/internal/spec"
 	"github.com/matttproud/yourtour/internal/texthtml"
+	"github.com/matttproud/yourtour/internal/tmplfunc"
 )
 
 // A Site is an http.Handler that serves requests from a file system.
 // See the package doc comment for details.
 type Site struct {
-	fs         fs.FS            // from NewSite
-	fileServer http.Handler     // http.FileServer(http.FS(fs))
-	funcs      template.FuncMap // accumulated from s.Funcs
-	cache      sync.Map         // canonical file path -> *pageFile, for site.openPage
+	fs         fs.FS                       // from NewSite
+	fileServer http.Handler                // http.FileServer(http.FS(fs))
+	funcs      template.FuncMap            // accumulated from s.Funcs
+	cache      atomic.Pointer[sync.Map]    // canonical file path -> *pageFile, for site.openPage
+	dev        bool                        // from SetDevMode
+	pageData   []func(*http.Request, Page) // from s.AddPageData
+	contracts  map[string][]pageField      // layout -> keys, from s.AddContract
+	private    func(string) bool           // from s.SetPrivate
+
+	suggesters []Suggester                  // from s.AddSuggester
+	suggestMu  sync.Mutex                   // serializes loading suggest
+	suggest    atomic.Pointer[suggestIndex] // candidates for 404 suggestions
+
+	precachers []Precacher                  // from s.AddPrecacher
+	workerMu   sync.Mutex                   // serializes loading worker
+	worker     atomic.Pointer[workerScript] // service worker, for /sw.js
 }
 
 // NewSite returns a new Site for serving pages from the file system fsys.
 func NewSite(fsys fs.FS) *Site {
-	return &Site{
+	s := &Site{
 		fs:         fsys,
 		fileServer: http.FileServer(http.FS(fsys)),
 	}
+	s.cache.Store(new(sync.Map))
+	return s
+}
+
+// ClearCache discards the pages and scripts the site has cached,
+// so that later requests read them again from the site's file system.
+// Cache entries are normally checked against file modification times,
+// but those cannot reveal that an underlying file system such as an
+// embed.FS, which has none, has been replaced.
+func (s *Site) ClearCache() {
+	s.cache.Store(new(sync.Map))
+	s.suggest.Store(nil)
+	s.worker.Store(nil)
+}
+
+// CheckTemplates reports whether the named layout templates
+// parse when loaded after the site's base template "site.tmpl",
+// as they are when pages are served.
+// It returns an error describing every template that fails to parse.
+func (s *Site) CheckTemplates(names ...string) error {
+	base, err := s.readFile(".", "site.tmpl")
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, name := range names {
+		t := s.newTemplate(&siteDir{s, path.Dir(name)}, nil)
+		if err := tmplfunc.Parse(t, string(base)); err != nil {
+			return fmt.Errorf("site.tmpl: %v", err)
+		}
+		data, err := s.readFile(".", name)
+		if err == nil {
+			err = tmplfunc.Parse(t.New(name), string(data))
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %v", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// AddPageData adds f to the functions called to add data to each page
+// before it is rendered for the request r, such as site-wide announcements.
+// f is passed a copy of the page, which it can modify freely,
+// but it should not replace keys the page or its caller already set.
+// AddPageData must not be called concurrently with serving requests.
+func (s *Site) AddPageData(f func(r *http.Request, p Page)) {
+	s.pageData = append(s.pageData, f)
+}
+
+// SetPrivate sets f to report whether the page at a URL path is private,
+// served only to readers who sign in, so that the site's 404 suggestions
+// and offline manifest leave it out, as should other listings of its pages.
+// SetPrivate must not be called concurrently with serving requests.
+func (s *Site) SetPrivate(f func(path string) bool) {
+	s.private = f
+}
+
+// Private reports whether the page at the URL path p is private.
+// See SetPrivate.
+func (s *Site) Private(p string) bool {
+	return s.private != nil && s.private(p)
 }
 
 // Funcs adds the functions in m to the set of functions available to templates.
@@ -380,29 +502,81 @@ func (s *Site) ServeError(w http.ResponseWriter, r *http.Request, err error) {
 //		"status": status,
 //		"layout": error,
 //		"error": err,
+//		"requestID": reqlog.ID(r.Context()),
 //	}
+//
+// For status 404 (not found), p["suggestions"] lists the paths
+// from the site's suggesters that are closest to r.URL.Path, if any.
+// If the request asks for JSON in preference to HTML, the response is
+// instead a JSON object with the fields error, status, request_id,
+// and, for status 404, suggestions.
+//
+// Server errors (status 500 and above) are also logged
+// with the request's logger and sent to the error reporter.
 func (s *Site) ServeErrorStatus(w http.ResponseWriter, r *http.Request, err error, status int) {
 	s.serveErrorStatus(w, r, err, status, false)
 }
 
 func (s *Site) serveErrorStatus(w http.ResponseWriter, r *http.Request, err error, status int, renderingError bool) {
 
+	logger := reqlog.Logger(r.Context())
 	if renderingError {
-		log.Printf("error rendering error: %v", err)
+		logger.Error("rendering error page", "err", err)
 		w.WriteHeader(status)
 		w.Write([]byte("error rendering error"))
 		return
 	}
+	if status >= 500 && err != errPanic { // Recover has logged and reported the panic itself.
+		logger.Error("serving error page", "status", status, "err", err)
+		errreport.Request(r, err, status)
+	}
+
+	var suggestions []string
+	if status == http.StatusNotFound {
+		suggestions = s.suggestions(r.Context(), r.URL.Path)
+	}
+	if wantsJSON(r) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(errorJSON{
+			Error:       err.Error(),
+			Status:      status,
+			RequestID:   reqlog.ID(r.Context()),
+			Suggestions: suggestions,
+		})
+		return
+	}
 
 	p := Page{
-		"URL":    r.URL.Path,
-		"status": status,
-		"layout": "error",
-		"error":  err,
+		"URL":       r.URL.Path,
+		"status":    status,
+		"layout":    "error",
+		"error":     err,
+		"requestID": reqlog.ID(r.Context()),
+	}
+	if suggestions != nil {
+		p["suggestions"] = suggestions
 	}
 	s.servePage(w, r, p, true)
 }
 
+// errorJSON is the JSON form of an error page.
+type errorJSON struct {
+	Error       string   `json:"error"`
+	Status      int      `json:"status"`
+	RequestID   string   `json:"request_id,omitempty"`
+	Suggestions []string `json:"suggestions,omitempty"`
+}
+
+// wantsJSON reports whether r's Accept header lists
+// application/json first among the media types it accepts.
+func wantsJSON(r *http.Request) bool {
+	first, _, _ := strings.Cut(r.Header.Get("Accept"), ",")
+	mediaType, _, _ := strings.Cut(first, ";")
+	return strings.TrimSpace(mediaType) == "application/json"
+}
+
 // ServePage renders the page p to HTML and writes that HTML to w.
 // See the package doc comment for details about page rendering.
 //
@@ -414,8 +588,18 @@ func (s *Site) ServePage(w http.ResponseWriter, r *http.Request, p Page) {
 }
 
 func (s *Site) servePage(w http.ResponseWriter, r *http.Request, p Page, renderingError bool) {
+	if s.dev {
+		if err := s.CheckContract(p); err != nil {
+			s.serveTemplateDiag(w, r, p, err)
+			return
+		}
+	}
 	html, err := s.renderHTML(p, "site.tmpl", r)
 	if err != nil {
+		if s.dev {
+			s.serveTemplateDiag(w, r, p, err)
+			return
+		}
 		s.serveErrorStatus(w, r, fmt.Errorf("template execution: %v", err), http.StatusInternalServerError, renderingError)
 		return
 	}
@@ -431,6 +615,12 @@ func (s *Site) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	abspath := r.URL.Path
 	relpath := path.Clean(strings.TrimPrefix(abspath, "/"))
 
+	// Is it the service worker?
+	if abspath == ServiceWorkerPath && s.hasOffline() {
+		s.serveServiceWorker(w, r)
+		return
+	}
+
 	// Is it a TypeScript file?
 	if strings.HasSuffix(relpath, ".ts") {
 		s.serveTypeScript(w, r)
@@ -439,6 +629,10 @@ func (s *Site) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	// Is it a page we can generate?
 	if p, err := s.openPage(relpath); err == nil {
+		if isDraft(p.page) && !s.dev {
+			s.ServeErrorStatus(w, r, fs.ErrNotExist, http.StatusNotFound)
+			return
+		}
 		if p.url != abspath {
 			// Redirect to canonical path.
 			status := http.StatusMovedPermanently
@@ -467,7 +661,7 @@ func (s *Site) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// Serve directory.
 	if info != nil && info.IsDir() {
 		if _, ok := s.findLayout(relpath, "dir"); ok {
-			if !maybeRedirect(w, r) {
+			if !canonical.Dir(w, r) {
 				s.serveDir(w, r, relpath)
 			}
 			return
@@ -477,7 +671,7 @@ func (s *Site) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// Serve text file.
 	if isTextFile(s.fs, relpath) {
 		if _, ok := s.findLayout(path.Dir(relpath), "texthtml"); ok {
-			if !maybeRedirectFile(w, r) {
+			if !canonical.File(w, r) {
 				s.serveText(w, r, relpath)
 			}
 			return
@@ -485,33 +679,39 @@ func (s *Site) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Serve raw bytes.
+	if !info.IsDir() {
+		setDisposition(w, r, relpath)
+	}
 	s.fileServer.ServeHTTP(w, r)
 }
 
-func maybeRedirect(w http.ResponseWriter, r *http.Request) (redirected bool) {
-	canonical := path.Clean(r.URL.Path)
-	if !strings.HasSuffix(canonical, "/") {
-		canonical += "/"
+// ServesRaw reports whether ServeHTTP serves the file named file,
+// relative to the site's root, as it is: not as a page, a directory
+// listing, text formatted as HTML, or compiled TypeScript.
+func (s *Site) ServesRaw(file string) bool {
+	if !s.ServesFile(file) {
+		return false
 	}
-	if r.URL.Path != canonical {
-		url := *r.URL
-		url.Path = canonical
-		http.Redirect(w, r, url.String(), http.StatusMovedPermanently)
-		redirected = true
+	if isTextFile(s.fs, file) {
+		if _, ok := s.findLayout(path.Dir(file), "texthtml"); ok {
+			return false
+		}
 	}
-	return
+	return true
 }
 
-func maybeRedirectFile(w http.ResponseWriter, r *http.Request) (redirected bool) {
-	c := path.Clean(r.URL.Path)
-	c = strings.TrimRight(c, "/")
-	if r.URL.Path != c {
-		url := *r.URL
-		url.Path = c
-		http.Redirect(w, r, url.String(), http.StatusMovedPermanently)
-		redirected = true
+// ServesFile reports whether ServeHTTP serves the text of the file
+// named file, relative to the site's root, either as it is or formatted
+// as HTML: not as a page, a directory listing, or compiled TypeScript.
+func (s *Site) ServesFile(file string) bool {
+	if strings.HasSuffix(file, ".ts") {
+		return false
+	}
+	if _, err := s.openPage(file); err == nil {
+		return false
 	}
-	return
+	info, err := fs.Stat(s.fs, file)
+	return err == nil && !info.IsDir()
 }
 
 func (s *Site) serveHTML(w http.ResponseWriter, r *http.Request, p *pageFile) {
@@ -543,7 +743,7 @@ func (s *Site) serveHTML(w http.ResponseWriter, r *http.Request, p *pageFile) {
 }
 
 func (s *Site) serveDir(w http.ResponseWriter, r *http.Request, relpath string) {
-	if maybeRedirect(w, r) {
+	if canonical.Dir(w, r) {
 		return
 	}
 
@@ -572,13 +772,12 @@ func (s *Site) serveDir(w http.ResponseWriter, r *http.Request, relpath string)
 func (s *Site) serveText(w http.ResponseWriter, r *http.Request, relpath string) {
 	src, err := fs.ReadFile(s.fs, relpath)
 	if err != nil {
-		log.Printf("ReadFile: %s", err)
 		s.ServeError(w, r, err)
 		return
 	}
 
-	if r.FormValue("m") == "text" {
-		s.serveRawText(w, src)
+	if r.FormValue("m") == "text" || r.URL.Query().Has("download") {
+		s.serveRawText(w, r, relpath, src)
 		return
 	}
 
@@ -621,9 +820,31 @@ func rangeSelection(str string) texthtml.Selection {
 	return nil
 }
 
-func (s *Site) serveRawText(w http.ResponseWriter, text []byte) {
+// serveRawText serves src, the content of the text file relpath,
+// as plain text. Like the raw bytes of other files, it is served
+// with http.ServeContent, so that clients can fetch byte ranges
+// of it, to resume an interrupted download.
+func (s *Site) serveRawText(w http.ResponseWriter, r *http.Request, relpath string, src []byte) {
+	var modtime time.Time
+	if info, err := fs.Stat(s.fs, relpath); err == nil {
+		modtime = info.ModTime()
+	}
 	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
-	w.Write(text)
+	setDisposition(w, r, relpath)
+	http.ServeContent(w, r, relpath, modtime, bytes.NewReader(src))
+}
+
+// setDisposition sets the Content-Disposition of the reply serving
+// the raw content of the file relpath, naming the file for clients
+// that save it. A download query parameter, as in
+// /doc/codewalk/urlpoll.go?download, asks for the file to be saved
+// rather than displayed.
+func setDisposition(w http.ResponseWriter, r *http.Request, relpath string) {
+	disp := "inline"
+	if r.URL.Query().Has("download") {
+		disp = "attachment"
+	}
+	w.Header().Set("Content-Disposition", mime.FormatMediaType(disp, map[string]string{"filename": path.Base(relpath)}))
 }
 
 const cacheHeader = "X-Go-Dev-Cache-Hit"
@@ -635,7 +856,8 @@ type jsout struct {
 
 func (s *Site) serveTypeScript(w http.ResponseWriter, r *http.Request) {
 	filename := path.Clean(strings.TrimPrefix(r.URL.Path, "/"))
-	if cjs, ok := s.cache.Load(filename); ok {
+	cache := s.cache.Load()
+	if cjs, ok := cache.Load(filename); ok {
 		js := cjs.(*jsout)
 		info, err := fs.Stat(s.fs, filename)
 		if err == nil && info.ModTime().Equal(js.stat.ModTime()) {
@@ -675,7 +897,7 @@ func (s *Site) serveTypeScript(w http.ResponseWriter, r *http.Request) {
 	}
 	w.Header().Set("Content-Type", "text/javascript; charset=utf-8")
 	http.ServeContent(w, r, filename, info.ModTime(), bytes.NewReader(result.Code))
-	s.cache.Store(filename, &jsout{
+	cache.Store(filename, &jsout{
 		output: result.Code,
 		stat:   info,
 	})
@@ -5,6 +5,9 @@
 package web
 
 import (
+	"bytes"
+	"errors"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -15,6 +18,8 @@ import (
 	"testing/fstest"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/matttproud/yourtour/internal/etag"
+	"github.com/matttproud/yourtour/internal/reqlog"
 )
 
 func testServeBody(t *testing.T, p *Site, path, body string) {
@@ -64,6 +69,21 @@ func TestMarkdown(t *testing.T) {
 	testServeBody(t, site, "/doc/test3", `{{x}}`)
 }
 
+func TestAddPageData(t *testing.T) {
+	site := NewSite(fstest.MapFS{
+		"site.tmpl":    {Data: []byte(`{{.Greeting}} {{.Content}}`)},
+		"doc/test.md":  {Data: []byte("**{{.Greeting}}**")},
+		"doc/other.md": {Data: []byte("---\nGreeting: Bye\n---\nother")},
+	})
+	site.AddPageData(func(r *http.Request, p Page) {
+		if _, ok := p["Greeting"]; !ok {
+			p["Greeting"] = "Hello from " + r.URL.Path
+		}
+	})
+	testServeBody(t, site, "/doc/test", "Hello from /doc/test <p><strong>Hello from /doc/test</strong></p>")
+	testServeBody(t, site, "/doc/other", "Bye <p>other</p>")
+}
+
 func TestCode(t *testing.T) {
 	site := NewSite(fstest.MapFS{
 		"site.tmpl": {Data: []byte(`{{.Content}}`)},
@@ -213,3 +233,303 @@ func TestTypeScript(t *testing.T) {
 		})
 	}
 }
+
+func TestDevModeTemplateError(t *testing.T) {
+	fsys := fstest.MapFS{
+		"site.tmpl":    {Data: []byte("{{.Content}}\n{{block \"layout\" .}}{{end}}")},
+		"default.tmpl": {Data: []byte("{{define \"layout\"}}\nline 2\n{{.title.Missing}}\nline 4\n{{end}}")},
+		"doc/page.md":  {Data: []byte("---\ntitle: hello\n---\nbody")},
+	}
+
+	for _, dev := range []bool{false, true} {
+		site := NewSite(fsys)
+		site.SetDevMode(dev)
+		rw := httptest.NewRecorder()
+		site.ServeHTTP(rw, httptest.NewRequest("GET", "/doc/page", nil))
+		if rw.Code != 500 {
+			t.Errorf("dev=%v: status %d, want 500", dev, rw.Code)
+		}
+		body := rw.Body.String()
+		for _, want := range []string{"default.tmpl:3", `<span class="bad">   3  {{.title.Missing}}</span>`, "<td>title</td><td>string</td>"} {
+			if strings.Contains(body, want) != dev {
+				t.Errorf("dev=%v: body contains %q = %v, want %v\n%s", dev, want, !dev, dev, body)
+			}
+		}
+	}
+}
+
+// A testModel is a ViewModel for TestContract.
+type testModel struct {
+	Title   string         `page:"title"`
+	Items   []string       `page:"items"`
+	Author  *string        `page:"author,omitempty"`
+	Extra   map[string]int `page:"-"`
+	Comment string
+}
+
+func (testModel) Layout() string { return "list" }
+
+func TestContract(t *testing.T) {
+	p := PageOf(testModel{Title: "Gophers", Items: []string{"a", "b"}, Comment: "c"})
+	want := Page{"layout": "list", "title": "Gophers", "items": []string{"a", "b"}, "Comment": "c"}
+	if diff := cmp.Diff(want, p); diff != "" {
+		t.Errorf("PageOf mismatch (-want +got):\n%s", diff)
+	}
+
+	fsys := fstest.MapFS{
+		"site.tmpl": {Data: []byte(`{{block "layout" .}}{{end}}`)},
+		"list.tmpl": {Data: []byte(`{{define "layout"}}{{.title}}:{{range .items}} {{.}}{{end}}{{end}}`)},
+	}
+	site := NewSite(fsys)
+	site.AddContract(testModel{})
+	if err := site.CheckContract(p); err != nil {
+		t.Errorf("CheckContract(PageOf(...)) = %v", err)
+	}
+	bad := Page{"layout": "list", "items": "a b", "author": 1, "Comment": "c"}
+	err := site.CheckContract(bad)
+	for _, want := range []string{`missing key "title", want string`, `key "items" is string, want []string`, `key "author" is int, want *string`} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("CheckContract(bad) = %v, want %s", err, want)
+		}
+	}
+	if err := site.CheckContract(Page{"layout": "other"}); err != nil {
+		t.Errorf("CheckContract of layout without contract = %v", err)
+	}
+
+	// Outside development mode, the page renders with its title left blank.
+	untitled := Page{"layout": "list", "items": []string{"a"}}
+	for _, tt := range []struct {
+		dev  bool
+		code int
+		body string
+	}{
+		{false, 200, ": a"},
+		{true, 500, `missing key &#34;title&#34;, want string`},
+	} {
+		site.SetDevMode(tt.dev)
+		rw := httptest.NewRecorder()
+		site.ServePage(rw, httptest.NewRequest("GET", "/list", nil), untitled)
+		if rw.Code != tt.code || !strings.Contains(rw.Body.String(), tt.body) {
+			t.Errorf("dev=%v: ServePage(untitled) = %d, want %d with %q\n%s", tt.dev, rw.Code, tt.code, tt.body, rw.Body)
+		}
+	}
+}
+
+func TestDrafts(t *testing.T) {
+	fsys := fstest.MapFS{
+		"site.tmpl":      {Data: []byte(`{{.Content}}{{block "layout" .}}{{end}}`)},
+		"error.tmpl":     {Data: []byte(`{{define "layout"}}{{.error}}{{end}}`)},
+		"blog/done.md":   {Data: []byte("---\ntitle: Done\n---\nPublished.")},
+		"blog/draft.md":  {Data: []byte("---\ntitle: Draft\ndraft: true\n---\nNot yet.")},
+		"blog/index.md":  {Data: []byte(`{{range pages "/blog/*.md"}}{{.title}};{{end}}`)},
+		"blog/notyet.md": {Data: []byte("---\ntitle: Not yet\ndraft: false\n---\nPublished too.")},
+	}
+	for _, dev := range []bool{false, true} {
+		site := NewSite(fsys)
+		site.SetDevMode(dev)
+		rw := httptest.NewRecorder()
+		site.ServeHTTP(rw, httptest.NewRequest("GET", "/blog/draft", nil))
+		if want := map[bool]int{false: 404, true: 200}[dev]; rw.Code != want {
+			t.Errorf("dev=%v: GET /blog/draft: %d, want %d", dev, rw.Code, want)
+		}
+		testServeBody(t, site, "/blog/notyet", "Published too.")
+		want := "Done;Not yet;"
+		if dev {
+			want = "Done;Draft;Not yet;"
+		}
+		testServeBody(t, site, "/blog/", want)
+		pages, err := site.Pages("/blog/*.md")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n := len(pages); n != strings.Count(want, ";")+1 {
+			t.Errorf("dev=%v: Pages(/blog/*.md) = %d pages, want %d", dev, n, strings.Count(want, ";")+1)
+		}
+	}
+}
+
+func TestAnchorAliases(t *testing.T) {
+	site := NewSite(fstest.MapFS{
+		"site.tmpl":   {Data: []byte(`{{.Content}}`)},
+		"doc/test.md": {Data: []byte("---\nanchors:\n  old-intro: intro\n  '#older-intro': intro\n  gone: usage\n---\n# Intro\n\ntext\n\n## How to use {#usage}\n")},
+		"doc/bad.md":  {Data: []byte("---\nanchors:\n  old: missing\n---\n# Intro\n")},
+	})
+
+	testServeBody(t, site, "/doc/test", `<span id="old-intro"></span><span id="older-intro"></span><h1 id="intro">Intro</h1>`)
+	testServeBody(t, site, "/doc/test", `<span id="gone"></span><h2 id="usage">How to use</h2>`)
+
+	rw := httptest.NewRecorder()
+	site.ServeHTTP(rw, httptest.NewRequest("GET", "/doc/bad", nil))
+	if rw.Code != 500 {
+		t.Errorf("GET /doc/bad: status %d, want 500", rw.Code)
+	}
+}
+
+func TestServeErrorRequestID(t *testing.T) {
+	site := NewSite(fstest.MapFS{
+		"site.tmpl":  {Data: []byte(`{{block "layout" .}}{{end}}`)},
+		"error.tmpl": {Data: []byte(`{{define "layout"}}{{.error}} [{{.requestID}}]{{end}}`)},
+	})
+	h := reqlog.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		site.ServeError(w, r, errors.New("broken"))
+	}))
+	r := httptest.NewRequest("GET", "/doc/", nil)
+	r.Header.Set(reqlog.Header, "id-123")
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, r)
+	if want := "broken [id-123]"; !strings.Contains(rw.Body.String(), want) {
+		t.Errorf("error page %q does not contain %q", rw.Body, want)
+	}
+}
+
+func TestRecover(t *testing.T) {
+	site := NewSite(fstest.MapFS{
+		"site.tmpl":  {Data: []byte(`{{block "layout" .}}{{end}}`)},
+		"error.tmpl": {Data: []byte(`{{define "layout"}}{{.error}} [{{.requestID}}]{{end}}`)},
+	})
+	serve := func(h http.Handler, site *Site) (rw *httptest.ResponseRecorder, panicked any) {
+		defer func() { panicked = recover() }()
+		h = reqlog.Handler(Recover(h, func(*http.Request) *Site { return site }))
+		r := httptest.NewRequest("GET", "/doc/", nil)
+		r.Header.Set(reqlog.Header, "id-123")
+		rw = httptest.NewRecorder()
+		h.ServeHTTP(rw, r)
+		return rw, nil
+	}
+
+	var logs bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+	boom := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("ETag", `"v1"`)
+		panic("secret detail")
+	})
+	rw, p := serve(boom, site)
+	if p != nil {
+		t.Fatalf("panic escaped Recover: %v", p)
+	}
+	if rw.Code != 500 {
+		t.Errorf("status %d, want 500", rw.Code)
+	}
+	if body := rw.Body.String(); !strings.Contains(body, "internal server error [id-123]") || strings.Contains(body, "secret") {
+		t.Errorf("error page %q, want generic error with request ID", body)
+	}
+	if ct, tag, id := rw.Header().Get("Content-Type"), rw.Header().Get("ETag"), rw.Header().Get(reqlog.Header); ct == "application/zip" || tag != "" || id != "id-123" {
+		t.Errorf("error page headers: Content-Type %q, ETag %q, %s %q; want the handler's dropped", ct, tag, reqlog.Header, id)
+	}
+	if n := strings.Count(logs.String(), "level=ERROR"); n != 1 {
+		t.Errorf("panic logged %d times, want once:\n%s", n, logs.String())
+	}
+
+	rw, _ = serve(boom, nil)
+	if rw.Code != 500 || !strings.Contains(rw.Body.String(), "request ID id-123") {
+		t.Errorf("without site: %d %q, want 500 with request ID", rw.Code, rw.Body)
+	}
+
+	late := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("partial"))
+		panic("too late")
+	})
+	if _, p := serve(late, site); p != http.ErrAbortHandler {
+		t.Errorf("panic after reply started: recovered %v, want http.ErrAbortHandler", p)
+	}
+
+	abort := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { panic(http.ErrAbortHandler) })
+	if _, p := serve(abort, site); p != http.ErrAbortHandler {
+		t.Errorf("abort: recovered %v, want http.ErrAbortHandler", p)
+	}
+}
+
+func TestCheckTemplates(t *testing.T) {
+	fsys := fstest.MapFS{
+		"site.tmpl":        {Data: []byte(`{{define "nav"}}{{end}}{{.Content}}`)},
+		"default.tmpl":     {Data: []byte(`{{nav}}{{markdown "x"}}`)},
+		"doc/default.tmpl": {Data: []byte(`{{if .title}}`)},
+		"doc/dir.tmpl":     {Data: []byte(`{{undefinedFunc}}`)},
+	}
+	site := NewSite(fsys)
+	if err := site.CheckTemplates("default.tmpl"); err != nil {
+		t.Errorf("CheckTemplates(default.tmpl): %v", err)
+	}
+	err := site.CheckTemplates("default.tmpl", "doc/default.tmpl", "doc/dir.tmpl", "doc/missing.tmpl")
+	if err == nil {
+		t.Fatal("CheckTemplates succeeded, want errors")
+	}
+	for _, name := range []string{"doc/default.tmpl", "doc/dir.tmpl", "doc/missing.tmpl"} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("CheckTemplates error does not mention %s:\n%v", name, err)
+		}
+	}
+}
+
+func TestServesRaw(t *testing.T) {
+	site := NewSite(fstest.MapFS{
+		"site.tmpl":         {Data: []byte(`{{.Content}}`)},
+		"texthtml.tmpl":     {Data: []byte(`{{define "layout"}}{{.texthtml}}{{end}}`)},
+		"doc/x.md":          {Data: []byte("x")},
+		"css/styles.css":    {Data: []byte("body {}")},
+		"js/site.ts":        {Data: []byte("let x = 1")},
+		"images/logo.png":   {Data: []byte("\x89PNG\r\n\x1a\n")},
+		"doc/hello.go":      {Data: []byte("package main\n")},
+		"robots.txt":        {Data: []byte("User-agent: *\n")},
+		"doc/play/hello.go": {Data: []byte("package main\n")},
+	})
+	for file, want := range map[string]bool{
+		"doc/x.md":          false,
+		"doc":               false,
+		"missing.png":       false,
+		"css/styles.css":    true,
+		"js/site.ts":        false,
+		"images/logo.png":   true,
+		"doc/hello.go":      false,
+		"robots.txt":        true,
+		"doc/play/hello.go": true,
+	} {
+		if got := site.ServesRaw(file); got != want {
+			t.Errorf("ServesRaw(%q) = %v, want %v", file, got, want)
+		}
+	}
+}
+
+func TestRange(t *testing.T) {
+	site := NewSite(fstest.MapFS{
+		"site.tmpl":       {Data: []byte(`{{.Content}}`)},
+		"texthtml.tmpl":   {Data: []byte(`{{define "layout"}}{{.texthtml}}{{end}}`)},
+		"images/logo.png": {Data: []byte("\x89PNG\r\n\x1a\n")},
+		"doc/hello.go":    {Data: []byte("package main\n")},
+	})
+	h := etag.Handler(site, func() string { return "v1" })
+	for _, tt := range []struct {
+		url     string
+		header  map[string]string
+		code    int
+		body    string
+		disp    string
+		typ     string
+		partial bool
+	}{
+		{url: "/images/logo.png", code: 200, body: "\x89PNG\r\n\x1a\n", disp: "inline; filename=logo.png", typ: "image/png"},
+		{url: "/images/logo.png", header: map[string]string{"Range": "bytes=1-3"}, code: 206, body: "PNG", disp: "inline; filename=logo.png", typ: "image/png"},
+		{url: "/images/logo.png?download", header: map[string]string{"Range": "bytes=4-", "If-Range": `"v1"`}, code: 206, body: "\r\n\x1a\n", disp: "attachment; filename=logo.png", typ: "image/png"},
+		{url: "/images/logo.png", header: map[string]string{"Range": "bytes=4-", "If-Range": `"v0"`}, code: 200, body: "\x89PNG\r\n\x1a\n", disp: "inline; filename=logo.png", typ: "image/png"},
+		{url: "/doc/hello.go?m=text", header: map[string]string{"Range": "bytes=8-"}, code: 206, body: "main\n", disp: "inline; filename=hello.go", typ: "text/plain; charset=utf-8"},
+		{url: "/doc/hello.go?download", code: 200, body: "package main\n", disp: "attachment; filename=hello.go", typ: "text/plain; charset=utf-8"},
+	} {
+		r := httptest.NewRequest("GET", tt.url, nil)
+		for k, v := range tt.header {
+			r.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != tt.code || !strings.HasPrefix(w.Body.String(), tt.body) {
+			t.Errorf("GET %s %v: %d %q, want %d %q", tt.url, tt.header, w.Code, w.Body, tt.code, tt.body)
+		}
+		if got := w.Header().Get("Content-Disposition"); got != tt.disp {
+			t.Errorf("GET %s %v: Content-Disposition %q, want %q", tt.url, tt.header, got, tt.disp)
+		}
+		if got := w.Header().Get("Content-Type"); got != tt.typ {
+			t.Errorf("GET %s %v: Content-Type %q, want %q", tt.url, tt.header, got, tt.typ)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package web
+
+import (
+	"context"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/matttproud/yourtour/internal/reqlog"
+)
+
+// A Suggester returns URL paths served by a site, such as "/doc/install",
+// as candidates for the “did you mean” suggestions on its 404 pages.
+type Suggester func(ctx context.Context) ([]string, error)
+
+// maxSuggestions is the most suggestions shown on a 404 page.
+const maxSuggestions = 3
+
+// suggestTTL is how long the candidates from a site's suggesters are reused.
+const suggestTTL = 10 * time.Minute
+
+// A suggestIndex is the set of candidate paths for suggestions.
+type suggestIndex struct {
+	paths   []string
+	expires time.Time
+}
+
+// AddSuggester adds f to the sources of URL paths suggested on 404 pages.
+// AddSuggester must not be called concurrently with serving requests.
+func (s *Site) AddSuggester(f Suggester) {
+	s.suggesters = append(s.suggesters, f)
+}
+
+// suggestions returns the candidate paths closest to the path p
+// requested in ctx, which was not found, best first.
+func (s *Site) suggestions(ctx context.Context, p string) []string {
+	if len(s.suggesters) == 0 {
+		return nil
+	}
+	idx := s.suggest.Load()
+	if idx == nil || time.Now().After(idx.expires) {
+		s.suggestMu.Lock()
+		if idx = s.suggest.Load(); idx == nil || time.Now().After(idx.expires) {
+			idx = &suggestIndex{expires: time.Now().Add(suggestTTL)}
+			for _, f := range s.suggesters {
+				list, err := f(ctx)
+				if err != nil {
+					// Suggest what is known; try again next time.
+					reqlog.Logger(ctx).Error("listing suggestions", "err", err)
+					idx.expires = time.Time{}
+				}
+				for _, p := range list {
+					if !s.Private(p) {
+						idx.paths = append(idx.paths, p)
+					}
+				}
+			}
+			s.suggest.Store(idx)
+		}
+		s.suggestMu.Unlock()
+	}
+	return suggest(p, idx.paths, maxSuggestions)
+}
+
+// suggest returns up to n of the candidates close to p,
+// closest first, ignoring case and any trailing slash or extension.
+func suggest(p string, candidates []string, n int) []string {
+	type match struct {
+		path string
+		dist int
+	}
+	key := suggestKey(p)
+	limit := min(4, max(1, len(key)/4))
+	var matches []match
+	seen := make(map[string]bool)
+	for _, c := range candidates {
+		if c == p || seen[c] {
+			continue
+		}
+		seen[c] = true
+		ck := suggestKey(c)
+		if abs(len(ck)-len(key)) > limit {
+			continue
+		}
+		if d := editDistance(key, ck); d <= limit {
+			matches = append(matches, match{c, d})
+		}
+	}
+	slices.SortFunc(matches, func(a, b match) int {
+		if a.dist != b.dist {
+			return a.dist - b.dist
+		}
+		return strings.Compare(a.path, b.path)
+	})
+	var list []string
+	for _, m := range matches[:min(n, len(matches))] {
+		list = append(list, m.path)
+	}
+	return list
+}
+
+// suggestKey returns the form of p compared by suggest.
+func suggestKey(p string) string {
+	p = strings.ToLower(strings.TrimSuffix(p, "/"))
+	for _, ext := range []string{".html", ".md"} {
+		p = strings.TrimSuffix(p, ext)
+	}
+	return p
+}
+
+// editDistance returns the Levenshtein distance between a and b,
+// the number of single-byte insertions, deletions, and substitutions
+// that turn one into the other.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// PageSuggester returns a Suggester listing the URL paths
+// of the Markdown and HTML pages in fsys.
+func PageSuggester(fsys fs.FS) Suggester {
+	return func(ctx context.Context) ([]string, error) {
+		var list []string
+		err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			ext := path.Ext(name)
+			if ext != ".md" && ext != ".html" {
+				return nil
+			}
+			name = strings.TrimSuffix(name, ext)
+			if base := path.Base(name); base == "index" {
+				name = strings.TrimSuffix(name, base)
+			}
+			list = append(list, "/"+name)
+			return nil
+		})
+		return list, err
+	}
+}
+
+// StaticSuggester returns a Suggester listing the given paths.
+func StaticSuggester(paths ...string) Suggester {
+	return func(context.Context) ([]string, error) {
+		return paths, nil
+	}
+}
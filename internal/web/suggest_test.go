@@ -0,0 +1,118 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSuggest(t *testing.T) {
+	candidates := []string{
+		"/doc/install",
+		"/doc/effective_go",
+		"/doc/faq",
+		"/doc/",
+		"/dl/go1.22.0",
+		"/dl/go1.22.1",
+		"/doc/codewalk/sharemem",
+	}
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{"/doc/instal", []string{"/doc/install"}},
+		{"/Doc/Install/", []string{"/doc/install"}},
+		{"/doc/effective-go", []string{"/doc/effective_go"}},
+		{"/doc/faq.html", []string{"/doc/faq"}},
+		{"/dl/go1.22.2", []string{"/dl/go1.22.0", "/dl/go1.22.1"}},
+		{"/doc/codewalk/sharemen", []string{"/doc/codewalk/sharemem"}},
+		{"/doc/install", nil},
+		{"/nothing/like/it", nil},
+		{"/x", nil},
+	}
+	for _, tt := range tests {
+		got := suggest(tt.path, candidates, 3)
+		if !cmp.Equal(got, tt.want) {
+			t.Errorf("suggest(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"", "abc", 3},
+		{"kitten", "sitting", 3},
+		{"install", "instal", 1},
+		{"flaw", "lawn", 2},
+	}
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestPageSuggester(t *testing.T) {
+	fsys := fstest.MapFS{
+		"index.md":         {},
+		"doc/index.html":   {},
+		"doc/install.md":   {},
+		"doc/gopher.png":   {},
+		"blog/go1.22.md":   {},
+		"blog/go1.22.tmpl": {},
+	}
+	got, err := PageSuggester(fsys)(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"/blog/go1.22", "/doc/", "/doc/install", "/"}
+	if !cmp.Equal(got, want) {
+		t.Errorf("PageSuggester = %q, want %q", got, want)
+	}
+}
+
+func TestNotFoundSuggestions(t *testing.T) {
+	site := NewSite(fstest.MapFS{
+		"site.tmpl":      {Data: []byte(`{{block "layout" .}}{{end}}`)},
+		"error.tmpl":     {Data: []byte(`{{define "layout"}}{{.error}}{{range .suggestions}} [{{.}}]{{end}}{{end}}`)},
+		"doc/install.md": {Data: []byte("Install Go.")},
+		"doc/instant.md": {Data: []byte("Private.")},
+	})
+	site.SetPrivate(func(p string) bool { return p == "/doc/instant" })
+	site.AddSuggester(PageSuggester(site.fs))
+	site.AddSuggester(StaticSuggester("/dl/"))
+
+	rw := httptest.NewRecorder()
+	site.ServeHTTP(rw, httptest.NewRequest("GET", "/doc/instal", nil))
+	if rw.Code != 404 || !strings.HasSuffix(rw.Body.String(), " [/doc/install]") {
+		t.Errorf("GET /doc/instal = %d %q, want 404 suggesting /doc/install", rw.Code, rw.Body)
+	}
+
+	r := httptest.NewRequest("GET", "/dl", nil)
+	r.Header.Set("Accept", "application/json, text/html;q=0.9")
+	rw = httptest.NewRecorder()
+	site.ServeHTTP(rw, r)
+	if ct := rw.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+		t.Fatalf("GET /dl: Content-Type %q, want JSON", ct)
+	}
+	var got errorJSON
+	if err := json.Unmarshal(rw.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != 404 || got.Error == "" || !cmp.Equal(got.Suggestions, []string{"/dl/"}) {
+		t.Errorf("GET /dl = %+v, want 404 suggesting /dl/", got)
+	}
+}
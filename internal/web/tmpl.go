@@ -92,7 +92,8 @@ func (site *siteDir) page(u string) (Page, error) {
 	return p.page, nil
 }
 
-// Pages returns the pages found in files matching glob.
+// Pages returns the pages found in files matching glob,
+// omitting drafts outside development mode.
 func (site *Site) Pages(glob string) ([]Page, error) {
 	return (&siteDir{site, "."}).pages(glob)
 }
@@ -131,6 +132,9 @@ func (site *siteDir) pages(glob string) ([]Page, error) {
 		if err != nil {
 			return nil, fmt.Errorf("%s: %v", file, err)
 		}
+		if isDraft(p.page) && !site.dev {
+			continue
+		}
 		out = append(out, p.page)
 	}
 
@@ -0,0 +1,329 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package webhooks keeps the events received by a server's webhooks
+// in the datastore until they are handled, so that an event whose
+// handling fails, for example because a Git forge is briefly down,
+// is retried rather than lost.
+//
+// A webhook adds each event it receives to a Queue before handling it,
+// and reports the outcome with Delivered or Failed. A failed event
+// is retried by the Queue's Retry, run periodically as a background job,
+// after a wait doubling with each attempt, from a minute up to six hours.
+// After maxAttempts attempts, an event is dead: it is kept, but not retried,
+// until an administrator redelivers or discards it through the Queue's
+// administrative API.
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/datastore"
+)
+
+const (
+	kind        = "WebhookEvent"
+	maxAttempts = 10
+	minBackoff  = time.Minute
+	maxBackoff  = 6 * time.Hour
+)
+
+// An Event is an event received by a webhook and not yet handled.
+type Event struct {
+	ID        string    `datastore:"-" json:"id"` // source:payload
+	Source    string    `json:"source"`           // webhook receiving it, such as "content"
+	Payload   string    `datastore:",noindex" json:"payload"`
+	Received  time.Time `json:"received"`
+	Attempts  int       `json:"attempts"`
+	Next      time.Time `json:"next,omitzero"` // time of the next attempt; zero if dead
+	LastError string    `datastore:",noindex" json:"lastError,omitempty"`
+	Dead      bool      `json:"dead"`
+}
+
+// Datastore is the part of a *datastore.Client used by a Queue.
+type Datastore interface {
+	Get(ctx context.Context, key *datastore.Key, dst any) error
+	GetAll(ctx context.Context, q *datastore.Query, dst any) ([]*datastore.Key, error)
+	Put(ctx context.Context, key *datastore.Key, src any) (*datastore.Key, error)
+	Delete(ctx context.Context, key *datastore.Key) error
+}
+
+var _ Datastore = (*datastore.Client)(nil)
+
+// A source is a webhook registered with a Queue.
+type source struct {
+	redeliver func(payload string)
+	supersede bool
+}
+
+// A Queue holds the events of webhooks in the datastore.
+type Queue struct {
+	dc  Datastore
+	now func() time.Time
+
+	mu      sync.Mutex
+	sources map[string]source
+}
+
+// NewQueue returns a Queue holding events in dc.
+func NewQueue(dc Datastore) *Queue {
+	return &Queue{dc: dc, now: time.Now, sources: make(map[string]source)}
+}
+
+// Register registers the webhook name, whose events are redelivered
+// by calling redeliver with their payloads. Redeliver starts handling
+// the event, which, like the handling of a newly received event,
+// must end with a call to Delivered or Failed.
+// If supersede is true, each event of the webhook makes those received
+// before it moot, as a push to a branch does earlier pushes:
+// once it is delivered, the earlier ones are discarded.
+func (q *Queue) Register(name string, supersede bool, redeliver func(payload string)) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.sources[name] = source{redeliver: redeliver, supersede: supersede}
+}
+
+// key returns the datastore key of the event of src with payload.
+func key(src, payload string) *datastore.Key {
+	return datastore.NameKey(kind, src+":"+payload, nil)
+}
+
+// Add records the receipt of the event of the webhook src with payload,
+// to be retried if it is not reported delivered within minBackoff.
+// Adding an event already in the queue restarts its attempts.
+func (q *Queue) Add(ctx context.Context, src, payload string) error {
+	now := q.now()
+	e := &Event{Source: src, Payload: payload, Received: now, Next: now.Add(minBackoff)}
+	_, err := q.dc.Put(ctx, key(src, payload), e)
+	return err
+}
+
+// Delivered records that the event of src with payload was handled,
+// removing it from the queue, together with the events it supersedes.
+func (q *Queue) Delivered(ctx context.Context, src, payload string) error {
+	var e Event
+	err := q.dc.Get(ctx, key(src, payload), &e)
+	if err != nil && err != datastore.ErrNoSuchEntity {
+		return err
+	}
+	if err == nil && q.source(src).supersede {
+		list, err := q.list(ctx)
+		if err != nil {
+			return err
+		}
+		for _, old := range list {
+			if old.Source == src && old.Received.Before(e.Received) {
+				if err := q.dc.Delete(ctx, key(src, old.Payload)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return q.dc.Delete(ctx, key(src, payload))
+}
+
+// Failed records that handling the event of src with payload failed
+// with the error failure, scheduling its next attempt, if any.
+// An event not in the queue, such as one discarded meanwhile, is ignored.
+func (q *Queue) Failed(ctx context.Context, src, payload string, failure error) error {
+	var e Event
+	switch err := q.dc.Get(ctx, key(src, payload), &e); err {
+	case nil:
+	case datastore.ErrNoSuchEntity:
+		return nil
+	default:
+		return err
+	}
+	e.Attempts++
+	e.LastError = failure.Error()
+	if e.Attempts >= maxAttempts {
+		e.Dead, e.Next = true, time.Time{}
+		log.Printf("ERROR webhook event %s:%s dead after %d attempts: %v", src, payload, e.Attempts, failure)
+	} else {
+		e.Next = q.now().Add(backoff(e.Attempts))
+	}
+	_, err := q.dc.Put(ctx, key(src, payload), &e)
+	return err
+}
+
+// backoff returns the wait before the next attempt after the given number.
+func backoff(attempts int) time.Duration {
+	d := minBackoff
+	for i := 1; i < attempts && d < maxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, maxBackoff)
+}
+
+// Retry redelivers the events due to be attempted again,
+// oldest first, pushing their next attempts back meanwhile,
+// so that an event still being handled at the next Retry
+// is not redelivered twice.
+func (q *Queue) Retry(ctx context.Context) error {
+	list, err := q.list(ctx)
+	if err != nil {
+		return err
+	}
+	now := q.now()
+	var errs []error
+	for _, e := range list {
+		if e.Dead || e.Next.After(now) {
+			continue
+		}
+		s := q.source(e.Source)
+		if s.redeliver == nil {
+			continue
+		}
+		e.Next = now.Add(backoff(e.Attempts + 1))
+		if _, err := q.dc.Put(ctx, key(e.Source, e.Payload), e); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		s.redeliver(e.Payload)
+	}
+	return errors.Join(errs...)
+}
+
+func (q *Queue) source(name string) source {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.sources[name]
+}
+
+// list returns the events in the queue, oldest first.
+func (q *Queue) list(ctx context.Context) ([]*Event, error) {
+	var list []*Event
+	keys, err := q.dc.GetAll(ctx, datastore.NewQuery(kind), &list)
+	if err != nil {
+		return nil, err
+	}
+	for i, e := range list {
+		e.ID = keys[i].Name
+	}
+	slices.SortStableFunc(list, func(a, b *Event) int { return a.Received.Compare(b.Received) })
+	return list, nil
+}
+
+// APIHandler returns a handler serving a JSON API
+// for inspecting the queue, at path and below it:
+//
+//	GET path          list events, oldest first
+//	POST path/id      redeliver the event now, even if dead
+//	DELETE path/id    discard the event
+//
+// The list is of the dead events, or, with the status parameter
+// pending, of those still to be retried, or, with all, of every event.
+// It is the caller's responsibility to ensure that the handler is only
+// exposed to authorized users, for example with env.AdminHandler.
+func (q *Queue) APIHandler(path string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, path), "/")
+		switch {
+		case id == "" && r.Method == "GET":
+			q.apiList(w, r)
+		case id != "" && r.Method == "POST":
+			q.apiRedeliver(w, r, id)
+		case id != "" && r.Method == "DELETE":
+			q.apiDelete(w, r, id)
+		default:
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+func (q *Queue) apiList(w http.ResponseWriter, r *http.Request) {
+	status := r.FormValue("status")
+	if status == "" {
+		status = "dead"
+	}
+	if status != "dead" && status != "pending" && status != "all" {
+		http.Error(w, fmt.Sprintf("invalid status %q; want dead, pending, or all", status), http.StatusBadRequest)
+		return
+	}
+	all, err := q.list(r.Context())
+	if err != nil {
+		log.Printf("ERROR listing webhook events: %v", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	list := []*Event{}
+	for _, e := range all {
+		if status == "all" || e.Dead == (status == "dead") {
+			list = append(list, e)
+		}
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// load loads the event with the given ID, replying with an error if it cannot.
+func (q *Queue) load(w http.ResponseWriter, r *http.Request, id string) (*Event, bool) {
+	var e Event
+	switch err := q.dc.Get(r.Context(), datastore.NameKey(kind, id, nil), &e); err {
+	case nil:
+		e.ID = id
+		return &e, true
+	case datastore.ErrNoSuchEntity:
+		http.Error(w, "not found", http.StatusNotFound)
+	default:
+		log.Printf("ERROR webhook event %s: %v", id, err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+	return nil, false
+}
+
+func (q *Queue) apiRedeliver(w http.ResponseWriter, r *http.Request, id string) {
+	e, ok := q.load(w, r, id)
+	if !ok {
+		return
+	}
+	s := q.source(e.Source)
+	if s.redeliver == nil {
+		http.Error(w, fmt.Sprintf("no webhook %q to redeliver to", e.Source), http.StatusConflict)
+		return
+	}
+	// A redelivered dead event gets one more attempt.
+	if e.Dead {
+		e.Dead, e.Attempts = false, maxAttempts-1
+	}
+	e.Next = q.now().Add(backoff(e.Attempts + 1))
+	if _, err := q.dc.Put(r.Context(), datastore.NameKey(kind, id, nil), e); err != nil {
+		log.Printf("ERROR webhook event %s: %v", id, err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	log.Printf("redelivering webhook event %s", id)
+	s.redeliver(e.Payload)
+	writeJSON(w, http.StatusAccepted, e)
+}
+
+func (q *Queue) apiDelete(w http.ResponseWriter, r *http.Request, id string) {
+	if _, ok := q.load(w, r, id); !ok {
+		return
+	}
+	if err := q.dc.Delete(r.Context(), datastore.NameKey(kind, id, nil)); err != nil {
+		log.Printf("ERROR webhook event %s: %v", id, err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeJSON writes v to w as the JSON body of a reply with the given status.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("ERROR writing JSON: %v", err)
+	}
+}
@@ -0,0 +1,187 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/matttproud/yourtour/internal/datastoretest"
+)
+
+func TestBackoff(t *testing.T) {
+	for _, tt := range []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{0, time.Minute},
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{4, 8 * time.Minute},
+		{9, 256 * time.Minute},
+		{10, 6 * time.Hour},
+		{100, 6 * time.Hour},
+	} {
+		if got := backoff(tt.attempts); got != tt.want {
+			t.Errorf("backoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestQueue(t *testing.T) {
+	ctx := context.Background()
+	dc := new(datastoretest.Datastore)
+	q := NewQueue(dc)
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	q.now = func() time.Time { return now }
+	var redelivered []string
+	q.Register("content", true, func(payload string) { redelivered = append(redelivered, payload) })
+	retry := func() []string {
+		t.Helper()
+		redelivered = nil
+		if err := q.Retry(ctx); err != nil {
+			t.Fatal(err)
+		}
+		return redelivered
+	}
+	get := func(payload string) *Event {
+		t.Helper()
+		var e Event
+		if err := dc.Get(ctx, datastore.NameKey(kind, "content:"+payload, nil), &e); err != nil {
+			return nil
+		}
+		return &e
+	}
+
+	// A new event is retried if it is not reported delivered in time,
+	// as when the server stops while handling it.
+	if err := q.Add(ctx, "content", "a"); err != nil {
+		t.Fatal(err)
+	}
+	if got := retry(); got != nil {
+		t.Errorf("Retry at once redelivered %q", got)
+	}
+	now = now.Add(time.Minute)
+	if got := retry(); !reflect.DeepEqual(got, []string{"a"}) {
+		t.Errorf("Retry after a minute redelivered %q, want [a]", got)
+	}
+	if got := retry(); got != nil {
+		t.Errorf("Retry while redelivering redelivered %q", got)
+	}
+
+	// Failures back off until the event is dead.
+	fail := errors.New("forge unavailable")
+	for i := 1; i <= maxAttempts; i++ {
+		if err := q.Failed(ctx, "content", "a", fail); err != nil {
+			t.Fatal(err)
+		}
+		e := get("a")
+		if e.Attempts != i || e.LastError != fail.Error() {
+			t.Fatalf("after %d failures: %+v", i, e)
+		}
+		if i < maxAttempts && (e.Dead || !e.Next.Equal(now.Add(backoff(i)))) {
+			t.Fatalf("after %d failures: %+v, want next attempt in %v", i, e, backoff(i))
+		}
+	}
+	if e := get("a"); !e.Dead || !e.Next.IsZero() {
+		t.Fatalf("after %d failures: %+v, want dead", maxAttempts, e)
+	}
+	now = now.Add(24 * time.Hour)
+	if got := retry(); got != nil {
+		t.Errorf("Retry redelivered dead events %q", got)
+	}
+
+	// Delivering a later event supersedes the earlier ones.
+	now = now.Add(time.Second)
+	q.Add(ctx, "content", "b")
+	now = now.Add(time.Second)
+	q.Add(ctx, "content", "c")
+	if err := q.Delivered(ctx, "content", "b"); err != nil {
+		t.Fatal(err)
+	}
+	if get("a") != nil || get("b") != nil || get("c") == nil {
+		t.Errorf("after delivering b: a, b, c = %+v, %+v, %+v", get("a"), get("b"), get("c"))
+	}
+	if err := q.Failed(ctx, "content", "b", fail); err != nil || get("b") != nil {
+		t.Errorf("Failed(b) after delivery: %v, %+v", err, get("b"))
+	}
+}
+
+func TestAPI(t *testing.T) {
+	ctx := context.Background()
+	q := NewQueue(new(datastoretest.Datastore))
+	var redelivered []string
+	q.Register("content", false, func(payload string) { redelivered = append(redelivered, payload) })
+	q.Add(ctx, "content", "live")
+	q.Add(ctx, "content", "dead")
+	q.Add(ctx, "upload", "gone")
+	for range maxAttempts {
+		q.Failed(ctx, "content", "dead", errors.New("invalid content"))
+	}
+	h := q.APIHandler("/_webhooks")
+	do := func(method, url string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(method, url, nil))
+		return w
+	}
+	list := func(url string) []string {
+		t.Helper()
+		w := do("GET", url)
+		var events []*Event
+		if err := json.Unmarshal(w.Body.Bytes(), &events); w.Code != 200 || err != nil {
+			t.Fatalf("GET %s: %d %v\n%s", url, w.Code, err, w.Body)
+		}
+		ids := []string{}
+		for _, e := range events {
+			ids = append(ids, e.ID)
+		}
+		sort.Strings(ids)
+		return ids
+	}
+
+	if got, want := list("/_webhooks"), []string{"content:dead"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("dead events: %q, want %q", got, want)
+	}
+	if got, want := list("/_webhooks?status=pending"), []string{"content:live", "upload:gone"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("pending events: %q, want %q", got, want)
+	}
+	if w := do("GET", "/_webhooks?status=new"); w.Code != 400 {
+		t.Errorf("GET invalid status: %d, want 400", w.Code)
+	}
+
+	if w := do("POST", "/_webhooks/content:dead"); w.Code != 202 || !reflect.DeepEqual(redelivered, []string{"dead"}) {
+		t.Errorf("POST dead event: %d %s, redelivered %q", w.Code, w.Body, redelivered)
+	}
+	if got, want := list("/_webhooks"), []string{}; !reflect.DeepEqual(got, want) {
+		t.Errorf("dead events after redelivery: %q, want none", got)
+	}
+	q.Failed(ctx, "content", "dead", errors.New("invalid content"))
+	if got, want := list("/_webhooks"), []string{"content:dead"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("dead events after failed redelivery: %q, want %q", got, want)
+	}
+
+	if w := do("POST", "/_webhooks/upload:gone"); w.Code != 409 {
+		t.Errorf("POST event of unregistered webhook: %d, want 409", w.Code)
+	}
+	if w := do("POST", "/_webhooks/content:missing"); w.Code != 404 {
+		t.Errorf("POST missing event: %d, want 404", w.Code)
+	}
+	if w := do("DELETE", "/_webhooks/content:dead"); w.Code != 204 {
+		t.Errorf("DELETE dead event: %d", w.Code)
+	}
+	if got, want := list("/_webhooks?status=all"), []string{"content:live", "upload:gone"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("events after delete: %q, want %q", got, want)
+	}
+	if w := do("PUT", "/_webhooks/content:live"); w.Code != 405 {
+		t.Errorf("PUT: %d, want 405", w.Code)
+	}
+}
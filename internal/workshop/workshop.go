@@ -0,0 +1,182 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package workshop serves workshop material: slide decks and articles
+// in the present format (see golang.org/x/tools/present),
+// files named _content/doc/workshop/*.slide and *.article,
+// hosted alongside the codewalks in /doc/.
+//
+// Workshops are rendered with the layouts of the talks,
+// but the Go snippets they include with .play can be edited
+// and run in the playground, as in the blog.
+// /doc/workshop/ lists the workshops; the other files
+// in the doc/workshop tree, such as images, are served as usual.
+package workshop
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/matttproud/yourtour/internal/vhost"
+	"github.com/matttproud/yourtour/internal/web"
+	"golang.org/x/tools/present"
+)
+
+// dir is the directory of the workshops in the content.
+const dir = "doc/workshop"
+
+type server struct {
+	fsys fs.FS
+	site *web.Site
+}
+
+// RegisterHandlers registers the server for the workshops
+// of the virtual host h, in h.FS, to serve /doc/workshop/ and below.
+func RegisterHandlers(h *vhost.Host) {
+	// .play snippets are only marked runnable when parsed with play enabled.
+	present.PlayEnabled = true
+
+	s := &server{fsys: h.FS, site: h.Site}
+	h.Router.Handle("GET", "/"+dir+"/", s)
+	h.Site.AddSuggester(func(context.Context) ([]string, error) {
+		list, err := List(h.FS)
+		var paths []string
+		for _, w := range list {
+			paths = append(paths, w.Path)
+		}
+		return paths, err
+	})
+}
+
+// A Workshop is a slide deck or article, as returned by List.
+type Workshop struct {
+	Path     string // URL path, such as /doc/workshop/concurrency.slide
+	Kind     string // "slide" or "article"
+	Title    string
+	Subtitle string
+	Time     time.Time // zero if undated
+}
+
+// isDoc reports whether name is the name of a present file.
+func isDoc(name string) bool {
+	switch path.Ext(name) {
+	case ".slide", ".article":
+		return true
+	}
+	return false
+}
+
+// List returns the workshops in the doc/workshop tree of fsys, sorted by path.
+func List(fsys fs.FS) ([]*Workshop, error) {
+	var list []*Workshop
+	err := fs.WalkDir(fsys, dir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && name == dir {
+				return fs.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() || !isDoc(name) {
+			return nil
+		}
+		doc, err := parse(fsys, name, present.TitlesOnly)
+		if err != nil {
+			return err
+		}
+		list = append(list, &Workshop{
+			Path:     "/" + name,
+			Kind:     strings.TrimPrefix(path.Ext(name), "."),
+			Title:    doc.Title,
+			Subtitle: doc.Subtitle,
+			Time:     doc.Time,
+		})
+		return nil
+	})
+	return list, err
+}
+
+// Validate parses every workshop in the doc/workshop tree of fsys,
+// reporting any that cannot be parsed, such as those including
+// snippets from missing files.
+func Validate(fsys fs.FS) error {
+	list, err := List(fsys)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, w := range list {
+		if _, err := parse(fsys, strings.TrimPrefix(w.Path, "/"), 0); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// parse parses the present file name in fsys.
+func parse(fsys fs.FS, name string, mode present.ParseMode) (*present.Doc, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	ctx := &present.Context{
+		ReadFile: func(file string) ([]byte, error) {
+			return fs.ReadFile(fsys, filepath.ToSlash(file))
+		},
+	}
+	return ctx.Parse(f, name, mode)
+}
+
+// Handler for /doc/workshop/ and below.
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(path.Clean(r.URL.Path), "/")
+	switch {
+	case r.URL.Path == "/"+dir+"/":
+		s.serveList(w, r)
+	case isDoc(name):
+		s.serveDoc(w, r, name)
+	default:
+		s.site.ServeHTTP(w, r)
+	}
+}
+
+// serveList serves the list of workshops, /doc/workshop/.
+func (s *server) serveList(w http.ResponseWriter, r *http.Request) {
+	list, err := List(s.fsys)
+	if err != nil {
+		s.site.ServeError(w, r, err)
+		return
+	}
+	s.site.ServePage(w, r, web.Page{
+		"title":     "Workshops",
+		"layout":    "workshops",
+		"workshops": list,
+	})
+}
+
+// serveDoc serves the slide deck or article name.
+func (s *server) serveDoc(w http.ResponseWriter, r *http.Request, name string) {
+	if _, err := fs.Stat(s.fsys, name); err != nil {
+		s.site.ServeErrorStatus(w, r, fmt.Errorf("no workshop %s", r.URL.Path), http.StatusNotFound)
+		return
+	}
+	doc, err := parse(s.fsys, name, 0)
+	if err != nil {
+		s.site.ServeError(w, r, err)
+		return
+	}
+	s.site.ServePage(w, r, web.Page{
+		"layout":      "/talks/" + strings.TrimPrefix(path.Ext(name), "."),
+		"doc":         doc,
+		"title":       doc.Title,
+		"PlayEnabled": true,
+	})
+}
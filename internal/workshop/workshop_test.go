@@ -0,0 +1,91 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package workshop
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/matttproud/yourtour/internal/router"
+	"github.com/matttproud/yourtour/internal/vhost"
+	"github.com/matttproud/yourtour/internal/web"
+)
+
+const deck = `Go Workshop
+Learning by doing
+
+* Hello
+
+.play hello/hello.go /^func main/,/^}/
+
+* Bye
+
+.code hello/hello.go /^import/
+`
+
+func testFS() fstest.MapFS {
+	return fstest.MapFS{
+		"doc/workshop/go.slide":       {Data: []byte(deck)},
+		"doc/workshop/notes.article":  {Data: []byte("Notes\n\n* Reading\n\nSome text.\n")},
+		"doc/workshop/hello/hello.go": {Data: []byte("package main\n\nimport \"fmt\"\n\nfunc main() {\n\tfmt.Println(\"hi\")\n}\n")},
+		"site.tmpl": {Data: []byte(`{{.title}}|{{.PlayEnabled}}|` +
+			`{{range .workshops}}[{{.Path}} {{.Kind}} {{.Title}}]{{end}}` +
+			`{{with .doc}}{{range .Sections}}[{{.Title}}{{range .Elem}} {{.TemplateName}}{{if eq .TemplateName "code"}} {{.Play}}{{end}}{{end}}]{{end}}{{end}}`)},
+		"talks/slide.tmpl":   {Data: []byte(`{{define "layout"}}{{end}}`)},
+		"talks/article.tmpl": {Data: []byte(`{{define "layout"}}{{end}}`)},
+		"workshops.tmpl":     {Data: []byte(`{{define "layout"}}{{end}}`)},
+		"error.tmpl":         {Data: []byte(`{{define "layout"}}{{end}}`)},
+	}
+}
+
+func TestServe(t *testing.T) {
+	fsys := testFS()
+	mux := http.NewServeMux()
+	var reg vhost.Registry
+	site := web.NewSite(fsys)
+	RegisterHandlers(reg.Add("", site, fsys, router.New(mux)))
+	mux.Handle("/", site)
+
+	for _, tt := range []struct {
+		url  string
+		code int
+		want string
+	}{
+		{"/doc/workshop/", 200, "Workshops||[/doc/workshop/go.slide slide Go Workshop][/doc/workshop/notes.article article Notes]"},
+		{"/doc/workshop/go.slide", 200, "Go Workshop|true|[Hello code true][Bye code false]"},
+		{"/doc/workshop/notes.article", 200, "Notes|true|[Reading text]"},
+		{"/doc/workshop/missing.slide", 404, ""},
+		{"/doc/workshop/hello/hello.go", 200, ""},
+	} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", tt.url, nil))
+		if w.Code != tt.code || tt.want != "" && w.Body.String() != tt.want {
+			t.Errorf("GET %s: %d %q, want %d %q", tt.url, w.Code, w.Body, tt.code, tt.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Validate(os.DirFS("../../_content")); err != nil {
+		t.Errorf("Validate(_content): %v", err)
+	}
+	if err := Validate(testFS()); err != nil {
+		t.Errorf("Validate: %v", err)
+	}
+	if err := Validate(fstest.MapFS{}); err != nil {
+		t.Errorf("Validate without workshops: %v", err)
+	}
+
+	fsys := testFS()
+	delete(fsys, "doc/workshop/hello/hello.go")
+	err := Validate(fsys)
+	if err == nil || !strings.Contains(err.Error(), "hello.go") {
+		t.Errorf("Validate with missing snippet = %v, want error about hello.go", err)
+	}
+}